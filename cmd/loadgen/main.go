@@ -0,0 +1,41 @@
+// loadgen, нагрузочный прогон api по файлу сценария
+//
+//	loadgen run scenario.yaml
+//
+// печатает таблицу задержек и ошибок по операциям, код выхода 1, если нарушены пороги сценария
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	intloadgen "gotechtask/internal/loadgen"
+)
+
+func main() {
+	if len(os.Args) != 3 || os.Args[1] != "run" {
+		fmt.Fprintln(os.Stderr, "usage: loadgen run scenario.yaml")
+		os.Exit(2)
+	}
+	sc, err := intloadgen.Load(os.Args[2])
+	if err != nil {
+		log.Fatalf("load: %v", err)
+	}
+
+	// прерывание завершает прогон досрочно, отчет печатается по уже сделанному
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("running %d workers for %v (ramp-up %v) against %s", sc.Workers, sc.Duration, sc.RampUp, sc.BaseURL)
+	rep := intloadgen.NewRunner(sc).Run(ctx)
+	rep.Write(os.Stdout)
+
+	if err := rep.Check(sc); err != nil {
+		log.Printf("thresholds: %v", err)
+		os.Exit(1)
+	}
+}
@@ -1,32 +1,58 @@
-// main открывает соединение с базой данных, проверяет его, 
-// выполняет начальное наполнение таблицы кошельков, 
-// инициализирует репозиторий и API, настраивает руты, 
-// запускает http сервер на порту 8080
+// main читает конфигурацию, открывает соединение с базой данных, проверяет его,
+// выполняет начальное наполнение таблицы кошельков,
+// инициализирует репозиторий и API, настраивает руты, запускает фоновые обработчик очереди задач, обслуживание партиций и анализатор переводов,
+// запускает http сервер
 package main
 
 import (
 	"context"
-	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/jackc/pgx/v5/stdlib"
 
-	intapi  "gotechtask/internal/api"
-	intdb   "gotechtask/internal/db"
+	intaddress "gotechtask/internal/address"
+	intanomaly "gotechtask/internal/anomaly"
+	intapi "gotechtask/internal/api"
+	intarchive "gotechtask/internal/archive"
+	intauth "gotechtask/internal/auth"
+	intfill "gotechtask/internal/backfill"
+	intbackup "gotechtask/internal/backup"
+	intbuild "gotechtask/internal/buildinfo"
+	intcapture "gotechtask/internal/capture"
+	intchaos "gotechtask/internal/chaos"
+	intconfig "gotechtask/internal/config"
+	intdb "gotechtask/internal/db"
+	inthot "gotechtask/internal/hotwallet"
+	intinv "gotechtask/internal/invariant"
+	intjobs "gotechtask/internal/jobs"
+	intnotify "gotechtask/internal/notify"
 	intrepo "gotechtask/internal/repo"
+	intself "gotechtask/internal/selfcheck"
+	intsettle "gotechtask/internal/settlement"
+	intsnap "gotechtask/internal/snapshot"
+	intstand "gotechtask/internal/standing"
+	intstorage "gotechtask/internal/storage"
+	intsweep "gotechtask/internal/sweep"
 )
 
 func main() {
-	dsn := os.Getenv("DATABASE_URL") 
-	if dsn == "" {
-		log.Fatal("DATABASE_URL is required")
+	cfg, err := intconfig.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
 	}
+	build := intbuild.Get()
+	log.Printf("build version=%s commit=%s date=%s go=%s features=%v", build.Version, build.Commit, build.Date, build.GoVersion, build.Features)
 
-	db, err := sql.Open("pgx", dsn)
+	// время каждого запроса к базе, медленные пишутся в лог
+	queries := intdb.NewQueryStats(cfg.Live.SlowQuery)
+	db, err := intdb.Open(cfg.DatabaseURL, cfg.TenantID, queries)
 	if err != nil {
 		log.Fatalf("open db: %v", err)
 	}
@@ -37,22 +63,245 @@ func main() {
 	if err := db.PingContext(ctx); err != nil {
 		log.Fatalf("ping db: %v", err)
 	}
+	if cfg.TenantID != "" {
+		if err := intdb.CheckTenantRole(ctx, db); err != nil {
+			log.Fatalf("tenant %s: %v", cfg.TenantID, err)
+		}
+		log.Printf("tenant isolation enabled, tenant=%s", cfg.TenantID)
+	}
 
+	// один вывод адресов на сид, сброс песочницы и ручку администратора, арендатор входит в адрес
+	addresses := intaddress.NewDeriver(cfg.AddressDerivationSecret, cfg.TenantID)
+	if addresses != nil {
+		log.Printf("address derivation enabled")
+	}
+	if roles, err := intdb.SeedSystemWallets(db, addresses); err != nil {
+		log.Fatalf("seed system wallets: %v", err)
+	} else if len(roles) > 0 {
+		log.Printf("created system wallets: %v", roles)
+	}
 	if addrs, err := intdb.SeedInitialWallets(db); err != nil {
 		log.Fatalf("seed wallets: %v", err)
 	} else if len(addrs) > 0 {
 		log.Printf("seeded %d wallets (100.00 each), first=%s", len(addrs), addrs[0])
 	}
 
+	// контекст фоновых задач, отменяется по сигналу остановки
+	bg, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// проверки при запуске повторяются, пока не пройдут все, до этого сервис отвечает 503 на все, кроме /health, /ready и /api/version
+	schema, err := intself.ExpectedSchema()
+	if err != nil {
+		log.Fatalf("self-check: %v", err)
+	}
+	gate := intself.NewGate()
+	go gate.Run(bg, []intself.Check{
+		intself.DB(db),
+		intself.Schema(db, schema),
+		intself.SystemWallets(db),
+		intself.Clock(db, cfg.SelfCheck.MaxClockSkew),
+	}, cfg.SelfCheck.Retry)
+
+	// режим проверки целостности, только для стендов, в обычной сборке ненулевая вероятность останавливает запуск
+	if cfg.Faults.Rate > 0 {
+		if err := intrepo.EnableFaults(cfg.Faults); err != nil {
+			log.Fatalf("faults: %v", err)
+		}
+		log.Printf("fault injection enabled, rate=%v points=%v", cfg.Faults.Rate, cfg.Faults.Points)
+	}
+
+	// двойная запись балансов в счета, до переключения чтения на них
+	intrepo.SetLedgerMode(cfg.Live.LedgerMode)
+	if cfg.Live.LedgerMode != intrepo.LedgerOff {
+		log.Printf("ledger mode %s", cfg.Live.LedgerMode)
+	}
+	// квитанции крупных переводов ставятся в транзакции перевода
+	intrepo.SetReceiptThreshold(cfg.Live.ReceiptThresholdCents)
+
 	repo := intrepo.NewPostgres(db)
-	api := &intapi.API{Repo: repo}
+	repo.ListLimit = cfg.Listing.AdminMaxCount
+	repo.Addresses = addresses
+	if cfg.WalletLockStripes > 0 {
+		repo.Locks = intrepo.NewWalletLocks(cfg.WalletLockStripes)
+		log.Printf("wallet locks enabled, %d stripes", cfg.WalletLockStripes)
+	}
+	api := &intapi.API{
+		Repo:       repo,
+		AdminToken: cfg.AdminToken,
+		Supply:     intinv.New(repo, cfg.SupplyCheckEvery),
+
+		Keys:               intapi.NewKeyCache(cfg.APIKeyCacheTTL),
+		TxCache:            intapi.NewTxCache(cfg.TxCacheTTL),
+		KeyRotationOverlap: cfg.APIKeyRotationOverlap,
+
+		RequireSignedTransfers: cfg.RequireSignedTransfers,
+		SignatureWindow:        cfg.SignatureWindow,
+		AddressChecksumStrict:  cfg.AddressChecksumStrict,
+		Addresses:              addresses,
+
+		TwoFactorThresholdCents: cfg.Live.TwoFactorThresholdCents,
+		PendingTTL:              cfg.PendingTTL,
+		PublicURL:               cfg.PublicURL,
+
+		Lanes: intapi.NewLanes(cfg.Lanes.Capacity, cfg.Lanes.Reserved, cfg.Lanes.Wait),
+		Timeouts: intapi.Timeouts{
+			Transfer: cfg.Timeouts.Transfer,
+			Read:     cfg.Timeouts.Read,
+			Routes:   cfg.Timeouts.Routes,
+		},
+		Location: cfg.BusinessLocation,
+		Listing: intapi.ListLimits{
+			Default:  cfg.Listing.DefaultCount,
+			Max:      cfg.Listing.MaxCount,
+			AdminMax: cfg.Listing.AdminMaxCount,
+		},
+		Bodies: intapi.BodyLimits{
+			Default:     cfg.Bodies.Limit,
+			Routes:      cfg.Bodies.Routes,
+			ReadTimeout: cfg.Bodies.ReadTimeout,
+		},
+
+		Sandbox:        cfg.Sandbox,
+		FaucetMaxCents: cfg.Live.SandboxFaucetMaxCents,
+		Maintenance:    cfg.Live.Maintenance,
+
+		Queries: queries,
+	}
+	if cfg.Sandbox {
+		log.Printf("sandbox mode, faucet and data reset enabled")
+	}
+	if cfg.Live.Maintenance {
+		log.Printf("maintenance mode, public writes are refused")
+	}
+	if cfg.CaptureDir != "" {
+		rec, err := intcapture.Open(cfg.CaptureDir, time.Now())
+		if err != nil {
+			log.Fatalf("capture: %v", err)
+		}
+		api.Capture = rec
+		log.Printf("capturing send requests to %s", rec.Path())
+	}
+
+	// настройки без перезапуска по SIGHUP и ручке администратора, начатые запросы дочитывают прежние значения
+	reload := &reloader{cur: cfg.Live, apply: func(l intconfig.Live) {
+		queries.SetSlowThreshold(l.SlowQuery)
+		intrepo.SetLedgerMode(l.LedgerMode)
+		intrepo.SetReceiptThreshold(l.ReceiptThresholdCents)
+		api.SetSettings(intapi.Settings{
+			TwoFactorThresholdCents: l.TwoFactorThresholdCents,
+			FaucetMaxCents:          l.SandboxFaucetMaxCents,
+			Maintenance:             l.Maintenance,
+		})
+	}}
+	api.Reload = reload.Reload
+	go reload.watch(bg)
+
+	// кэш балансов, записи сбрасываются уведомлениями базы об изменении кошельков на любом экземпляре
+	if cfg.BalanceCacheTTL > 0 {
+		api.BalanceCache = intapi.NewBalanceCache(cfg.BalanceCacheTTL)
+		go api.BalanceCache.Run(bg, repo)
+		log.Printf("balance cache enabled, ttl=%s", cfg.BalanceCacheTTL)
+	}
+
+	// кэш ключей доступа, записи сбрасываются уведомлениями базы об отзыве и ротации ключа на любом экземпляре
+	if cfg.APIKeyCacheTTL > 0 {
+		go api.Keys.Run(bg, repo)
+	}
+
+	// живая лента транзакций для панели администратора
+	api.Feed = intapi.NewFeed(repo)
+	go api.Feed.Run(bg)
+
+	if cfg.OIDCIssuer != "" {
+		verifier, err := intauth.NewOIDC(bg, cfg.OIDCIssuer, cfg.OIDCAudience)
+		if err != nil {
+			log.Fatalf("oidc: %v", err)
+		}
+		api.OIDC = verifier
+		log.Printf("oidc login enabled, issuer=%s", cfg.OIDCIssuer)
+	}
+
+	blob, err := intstorage.New(bg, cfg.Storage)
+	if err != nil {
+		log.Fatalf("storage: %v", err)
+	}
+
+	notifier, err := intnotify.New(cfg.Notify)
+	if err != nil {
+		log.Fatalf("notify: %v", err)
+	}
+	worker := intjobs.New(repo, cfg.JobsInterval)
+	worker.Register(intnotify.KindTransferReceipt, intnotify.ReceiptHandler(repo, notifier))
+	worker.Register(intnotify.KindTransferConfirmation, intnotify.ConfirmationHandler(notifier))
+	worker.Register(intnotify.KindTOTPEnrollment, intnotify.TOTPEnrollmentHandler(notifier))
+	worker.Register(intnotify.KindLowBalance, intnotify.LowBalanceHandler(repo, notifier))
+	worker.Register(intsweep.Kind, intsweep.Handler(repo))
+	if blob != nil {
+		api.Blob = blob
+		worker.Register(intbackup.Kind, intbackup.Handler(repo, blob))
+		worker.KindTimeouts[intbackup.Kind] = cfg.BackupTimeout
+	}
+	go worker.Run(bg)
+
+	// периодические проходы на нескольких экземплярах делает тот, кто взял блокировку в базе, очередь задач и поручения разбираются всеми через skip locked
+	archiver := intarchive.New(repo, cfg.Archive.Interval, cfg.Archive.Retention)
+	archiver.Blob = blob
+	archiver.Lock = repo
+	go archiver.Run(bg)
+	// заполнение идет по всей таблице, соединения арендатора видят только свои строки, в многоарендной установке его запускают через walletctl backfill
+	if cfg.Backfill.Interval > 0 && cfg.TenantID == "" {
+		backfiller := intfill.New(repo, cfg.Backfill.Chunk, cfg.Backfill.Pause)
+		backfiller.RowsPerSecond = cfg.Backfill.RowsPerSecond
+		backfiller.LockTimeout = cfg.Backfill.LockTimeout
+		backfiller.Interval = cfg.Backfill.Interval
+		backfiller.Lock = repo
+		go backfiller.Run(bg)
+	}
+	if cfg.SnapshotInterval > 0 {
+		snapshotter := intsnap.New(repo, cfg.SnapshotInterval)
+		snapshotter.Lock = repo
+		go snapshotter.Run(bg)
+	}
+	if cfg.SettlementInterval > 0 {
+		settler := intsettle.New(repo, cfg.SettlementInterval, cfg.BusinessLocation)
+		settler.Lock = repo
+		go settler.Run(bg)
+	}
+	if cfg.StandingOrdersInterval > 0 {
+		go intstand.New(repo, cfg.StandingOrdersInterval).Run(bg)
+	}
+	if cfg.Anomaly.Enabled {
+		analyzer := intanomaly.New(repo, cfg.Anomaly)
+		analyzer.Lock = repo
+		go analyzer.Run(bg)
+	}
+	// очереди зачислений горячих кошельков разбирает каждый экземпляр, пометку могла поставить и ручка администратора
+	if err := inthot.Pin(bg, repo, cfg.HotWallet.Wallets); err != nil {
+		log.Fatalf("hot wallets: %v", err)
+	}
+	go (&inthot.Applier{Store: repo, Interval: cfg.HotWallet.ApplyInterval, Batch: 100}).Run(bg)
+	if cfg.HotWallet.Detect {
+		detector := inthot.New(repo, cfg.HotWallet)
+		detector.Lock = repo
+		go detector.Run(bg)
+	}
 
 	r := chi.NewRouter()
-	api.Routes(r) 
+	r.Use(gate.Guard("/health", "/ready", "/api/version"))
+	// внедрение задержек и ошибок для стендов, выключенный конфиг middleware не добавляет
+	if chaos := intchaos.New(cfg.Chaos); chaos != nil {
+		r.Use(chaos.Middleware)
+		log.Printf("chaos injection enabled, %d rules", len(cfg.Chaos.Rules))
+	}
+	api.Routes(r)
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		_, _ = w.Write([]byte("ok"))
 	})
+	r.Method(http.MethodGet, "/ready", gate)
 
-	log.Println("server started on :8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	log.Printf("server started on %s", cfg.HTTPAddr)
+	// заголовки ждутся не дольше тела, медленный клиент не держит соединение до первого байта тела
+	srv := &http.Server{Addr: cfg.HTTPAddr, Handler: r, ReadHeaderTimeout: cfg.Bodies.ReadTimeout}
+	log.Fatal(srv.ListenAndServe())
 }
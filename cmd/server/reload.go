@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	intconfig "gotechtask/internal/config"
+)
+
+// reloader, перечитывает настройки, которые меняются без перезапуска, вызовы по сигналу и из ручки администратора идут по очереди
+type reloader struct {
+	mu    sync.Mutex
+	cur   intconfig.Live
+	apply func(intconfig.Live)
+}
+
+// Reload, читает настройки заново и применяет их, при ошибке прежние остаются, отдает изменившиеся переменные
+func (r *reloader) Reload() ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	l, err := intconfig.LoadLive()
+	if err != nil {
+		return nil, err
+	}
+	changed := l.Changes(r.cur)
+	r.apply(l)
+	r.cur = l
+	if len(changed) > 0 {
+		log.Printf("config reloaded: %v", changed)
+	}
+	return changed, nil
+}
+
+// watch, перечитывает настройки по SIGHUP до отмены ctx, без обработчика SIGHUP завершил бы процесс
+func (r *reloader) watch(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if _, err := r.Reload(); err != nil {
+				log.Printf("reload config: %v, keeping previous settings", err)
+			}
+		}
+	}
+}
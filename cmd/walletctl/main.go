@@ -0,0 +1,309 @@
+// walletctl, административные команды обслуживания базы
+//
+//	walletctl rebuild-balances [-shadow] [-from-snapshot] [-wallet addr,addr]
+//	walletctl backup
+//	walletctl restore -id <backup id>
+//	walletctl replay -file send-*.jsonl [-speed 1]
+//	walletctl backfill [-list] [-name <backfill>] [-chunk n] [-pause d] [-rate n]
+//
+// rebuild-balances пересобирает балансы кошельков по журналу операций и печатает расхождения с текущими, без -shadow переписывает расходящиеся балансы,
+// с -shadow только заполняет теневую таблицу wallets_rebuild, код выхода 1, если расхождения есть,
+// backup снимает резервную копию кошельков и транзакций во внешнее хранилище из STORAGE_*, restore восстанавливает копию в пустую базу с проверкой контрольных сумм,
+// replay повторяет запись переводов из CAPTURE_DIR на стенде, выставляет кошелькам записи начальные балансы, выполняет переводы через обработчики api с тем же наложением во времени и печатает расхождения исходов,
+// работает только с SANDBOX=true, чтобы запись не повторили на рабочей базе,
+// backfill доводит до конца заполнения колонок, заявленные миграциями, одно по -name или все незавершенные, по частям с паузами из BACKFILL_*, флаги их переопределяют,
+// прерывание сохраняет продвижение, повторный запуск продолжает, -list печатает продвижение, запускается без TENANT_ID
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	intapi "gotechtask/internal/api"
+	intfill "gotechtask/internal/backfill"
+	intbackup "gotechtask/internal/backup"
+	intcapture "gotechtask/internal/capture"
+	intconfig "gotechtask/internal/config"
+	intdb "gotechtask/internal/db"
+	intrepo "gotechtask/internal/repo"
+	intstorage "gotechtask/internal/storage"
+)
+
+// usage, подсказка по командам
+const usage = `usage:
+  walletctl rebuild-balances [-shadow] [-from-snapshot] [-wallet addr,addr]
+  walletctl backup
+  walletctl restore -id <backup id>
+  walletctl replay -file send-*.jsonl [-speed 1]
+  walletctl backfill [-list] [-name <backfill>] [-chunk n] [-pause d] [-rate n]`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+	if cmd != "rebuild-balances" && cmd != "backup" && cmd != "restore" && cmd != "replay" && cmd != "backfill" {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	shadow := fs.Bool("shadow", false, "only fill wallets_rebuild and report, do not touch wallets")
+	fromSnapshot := fs.Bool("from-snapshot", false, "start from the latest balance snapshot instead of from scratch")
+	wallets := fs.String("wallet", "", "comma separated wallet addresses, all wallets if empty")
+	backupID := fs.String("id", "", "backup id to restore")
+	captureFile := fs.String("file", "", "capture file to replay")
+	speed := fs.Float64("speed", 1, "replay speed, 2 is twice as fast as recorded")
+	list := fs.Bool("list", false, "print backfill progress and exit")
+	fillName := fs.String("name", "", "backfill to run, all unfinished if empty")
+	chunk := fs.Int64("chunk", 0, "ids per backfill chunk, BACKFILL_CHUNK if 0")
+	pause := fs.Duration("pause", -1, "pause between backfill chunks, BACKFILL_PAUSE if negative")
+	rate := fs.Int64("rate", -1, "backfill rows per second limit, BACKFILL_ROWS_PER_SECOND if negative, 0 is unlimited")
+	_ = fs.Parse(args)
+
+	cfg, err := intconfig.Load()
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	// с TENANT_ID команда видит и меняет только строки этого арендатора
+	db, err := intdb.Open(cfg.DatabaseURL, cfg.TenantID, nil)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	// прерывание откатывает незавершенную пересборку или восстановление
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = intrepo.WithCaller(ctx, intrepo.Caller{Admin: true, Channel: intrepo.ChannelCLI})
+	repo := intrepo.NewPostgres(db)
+
+	switch cmd {
+	case "backfill":
+		if *list {
+			all, err := repo.ListBackfills(ctx)
+			if err != nil {
+				log.Fatalf("backfill: %v", err)
+			}
+			backfillReport(os.Stdout, all)
+			return
+		}
+		fill := intfill.New(repo, cfg.Backfill.Chunk, cfg.Backfill.Pause)
+		fill.RowsPerSecond = cfg.Backfill.RowsPerSecond
+		fill.LockTimeout = cfg.Backfill.LockTimeout
+		if *chunk > 0 {
+			fill.Chunk = *chunk
+		}
+		if *pause >= 0 {
+			fill.Pause = *pause
+		}
+		if *rate >= 0 {
+			fill.RowsPerSecond = *rate
+		}
+		fill.Progress = func(b intrepo.Backfill) {
+			log.Printf("backfill %s: id %d of %d, %d rows", b.Name, b.LastID, b.MaxID, b.Rows)
+		}
+		if *fillName != "" {
+			_, err = fill.Fill(ctx, *fillName)
+		} else {
+			err = fill.RunOnce(ctx)
+		}
+		if err != nil {
+			log.Fatalf("backfill: %v", err)
+		}
+		return
+	case "replay":
+		if *captureFile == "" || *speed <= 0 {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		if !cfg.Sandbox {
+			log.Fatalf("replay runs only against a staging database, set SANDBOX=true")
+		}
+		res, err := replay(ctx, repo, *captureFile, *speed)
+		if err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		replayReport(os.Stdout, res)
+		if len(res.Mismatches) > 0 {
+			os.Exit(1)
+		}
+		return
+	case "backup", "restore":
+		blob, err := intstorage.New(ctx, cfg.Storage)
+		if err != nil {
+			log.Fatalf("storage: %v", err)
+		}
+		if blob == nil {
+			log.Fatalf("storage not configured, set STORAGE_BACKEND")
+		}
+		if cmd == "backup" {
+			m, err := intbackup.Create(ctx, repo, blob, intbackup.NewID(time.Now()))
+			if err != nil {
+				log.Fatalf("backup: %v", err)
+			}
+			printManifest(os.Stdout, "backup", m)
+			return
+		}
+		if *backupID == "" {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
+		}
+		m, err := intbackup.Restore(ctx, repo, blob, *backupID)
+		if err != nil {
+			log.Fatalf("restore: %v", err)
+		}
+		printManifest(os.Stdout, "restored", m)
+		return
+	}
+
+	opts := intrepo.RebuildOptions{FromSnapshot: *fromSnapshot, Apply: !*shadow}
+	if *wallets != "" {
+		opts.Addresses = strings.Split(*wallets, ",")
+	}
+	res, err := repo.RebuildBalances(ctx, opts)
+	if err != nil {
+		log.Fatalf("rebuild: %v", err)
+	}
+	report(os.Stdout, res)
+
+	if !res.Applied && len(res.Diffs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// backfillReport, продвижение заполнений
+func backfillReport(out *os.File, all []intrepo.Backfill) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "name\ttable\tlast id\tmax id\trows\tstatus")
+	for _, b := range all {
+		status := "pending"
+		switch {
+		case b.Done():
+			status = "done " + b.DoneAt.UTC().Format(time.RFC3339)
+		case !b.StartedAt.IsZero():
+			status = "started"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\n", b.Name, b.Table, b.LastID, b.MaxID, b.Rows, status)
+	}
+	_ = tw.Flush()
+}
+
+// printManifest, копия и ее файлы
+func printManifest(out *os.File, verb string, m intbackup.Manifest) {
+	fmt.Fprintf(out, "%s %s, schema %d, snapshot %s\n", verb, m.ID, m.SchemaVersion, m.SnapshotAt.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "table\trows\tbytes\tsha256")
+	for _, f := range m.Files {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", f.Table, f.Rows, f.Bytes, f.SHA256)
+	}
+	_ = tw.Flush()
+}
+
+// report, итог пересборки и таблица расхождений
+func report(out *os.File, res intrepo.BalanceRebuild) {
+	from := "scratch"
+	if !res.SnapshotAt.IsZero() {
+		from = "snapshot " + res.SnapshotAt.UTC().Format(time.RFC3339)
+	}
+	fmt.Fprintf(out, "rebuilt %d wallets from %s, %d differ", res.Wallets, from, len(res.Diffs))
+	if res.Applied {
+		fmt.Fprint(out, ", balances rewritten")
+	}
+	fmt.Fprintln(out)
+	if res.UnattributedCents != 0 {
+		fmt.Fprintf(out, "warning: %s of supply has no wallet address, wallets funded by it rebuild short, try -from-snapshot\n", cents(res.UnattributedCents))
+	}
+	if len(res.Diffs) == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "address\tbalance\trebuilt\tdiff\t")
+	for _, d := range res.Diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", d.Address, cents(d.BalanceCents), cents(d.RebuiltCents), cents(d.RebuiltCents-d.BalanceCents))
+	}
+	_ = tw.Flush()
+}
+
+// replay, выставляет кошелькам записи начальные балансы и повторяет переводы через обработчики api с токеном администратора
+func replay(ctx context.Context, repo *intrepo.PostgresRepo, path string, speed float64) (intcapture.Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return intcapture.Result{}, err
+	}
+	entries, err := intcapture.Read(f)
+	_ = f.Close()
+	if err != nil {
+		return intcapture.Result{}, err
+	}
+	openings := intcapture.Openings(entries)
+	for addr, bal := range openings {
+		if err := repo.SeedReplayWallet(ctx, addr, bal); err != nil {
+			return intcapture.Result{}, fmt.Errorf("seed %s: %w", addr, err)
+		}
+	}
+	log.Printf("seeded %d wallets, replaying %d requests", len(openings), len(entries))
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return intcapture.Result{}, err
+	}
+	token := hex.EncodeToString(b)
+	api := &intapi.API{Repo: repo, AdminToken: token}
+	r := chi.NewRouter()
+	api.Routes(r)
+
+	send := func(ctx context.Context, from, to string, amountCents int64) (int, string) {
+		body := fmt.Sprintf(`{"from":%q,"to":%q,"amount":%s}`, from, to, cents(amountCents))
+		req := httptest.NewRequestWithContext(ctx, http.MethodPost, "/api/send", strings.NewReader(body))
+		req.Header.Set("X-Admin-Token", token)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		var resp struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &resp)
+		return rr.Code, resp.Error
+	}
+	return intcapture.Replay(ctx, entries, speed, send), nil
+}
+
+// replayReport, итог повтора и переводы с другим исходом
+func replayReport(out *os.File, res intcapture.Result) {
+	fmt.Fprintf(out, "replayed %d requests in %v, %d differ\n", res.Requests, res.Elapsed.Round(time.Millisecond), len(res.Mismatches))
+	if len(res.Mismatches) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "at_ms\tfrom\tto\tamount\trecorded\treplayed")
+	for _, m := range res.Mismatches {
+		e := m.Entry
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d %s\t%d %s\n", e.AtMS, e.From[:12], e.To[:12], cents(e.AmountCents), e.Status, e.Error, m.Status, m.Error)
+	}
+	_ = tw.Flush()
+}
+
+// cents, сумма в центах строкой с двумя знаками
+func cents(c int64) string {
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
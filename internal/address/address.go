@@ -0,0 +1,70 @@
+// Package address, адрес кошелька, 64 шестнадцатеричных символа, в базе и во всех сравнениях в нижнем регистре,
+// для показа людям есть форма с контрольной суммой в регистре букв по образцу EIP-55: буква заглавная, если соответствующий ей полубайт
+// хэша SHA3-256 от адреса в нижнем регистре не меньше 8, опечатка в такой форме почти всегда ломает регистр и ловится до перевода
+package address
+
+import (
+	"crypto/sha3"
+	"errors"
+	"strings"
+)
+
+// Len, длина адреса в символах
+const Len = 64
+
+// ошибки разбора адреса
+var (
+	ErrFormat   = errors.New("address: invalid format")
+	ErrChecksum = errors.New("address: invalid checksum")
+)
+
+// Valid, 64 шестнадцатеричных символа в любом регистре, контрольная сумма не проверяется
+func Valid(s string) bool {
+	if len(s) != Len {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", rune(s[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checksum, форма адреса с контрольной суммой в регистре букв, s, адрес в любом регистре, невалидный адрес возвращается как есть
+func Checksum(s string) string {
+	if !Valid(s) {
+		return s
+	}
+	lower := strings.ToLower(s)
+	h := sha3.Sum256([]byte(lower))
+	out := []byte(lower)
+	for i, c := range out {
+		// полубайт i хэша, старший в четных позициях
+		nib := h[i/2] >> 4
+		if i%2 == 1 {
+			nib = h[i/2] & 0x0f
+		}
+		if c >= 'a' && nib >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return string(out)
+}
+
+// Parse, адрес в каноничном нижнем регистре, смешанный регистр должен совпасть с контрольной суммой, иначе ErrChecksum,
+// целиком нижний или целиком верхний регистр значат адрес без контрольной суммы и принимаются, если strict false,
+// со strict принимается только форма с контрольной суммой
+func Parse(s string, strict bool) (string, error) {
+	if !Valid(s) {
+		return "", ErrFormat
+	}
+	lower := strings.ToLower(s)
+	if s == Checksum(s) {
+		return lower, nil
+	}
+	if !strict && (s == lower || s == strings.ToUpper(s)) {
+		return lower, nil
+	}
+	return "", ErrChecksum
+}
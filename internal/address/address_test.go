@@ -0,0 +1,107 @@
+package address
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestChecksum, форма с контрольной суммой закреплена, чтобы смена хэша или правила регистра не прошла незаметно
+func TestChecksum(t *testing.T) {
+	cases := map[string]string{
+		strings.Repeat("ab", 32): "AbABABAbabaBABAbABABAbaBabaBaBaBaBABABaBabABabAbaBAbabaBABABABab",
+		"0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef": "0123456789AbCdeF0123456789abcDEf0123456789abCDef0123456789AbCDeF",
+	}
+	for in, want := range cases {
+		if got := Checksum(in); got != want {
+			t.Errorf("Checksum(%s) = %s, want %s", in, got, want)
+		}
+		if got := Checksum(strings.ToUpper(in)); got != want {
+			t.Errorf("Checksum(upper) = %s, want %s", got, want)
+		}
+	}
+	if got := Checksum("xyz"); got != "xyz" {
+		t.Errorf("Checksum changed invalid address: %s", got)
+	}
+}
+
+// TestParse, нижний и верхний регистр принимаются без контрольной суммы, смешанный только совпавший с ней, strict требует ее всегда
+func TestParse(t *testing.T) {
+	lower := strings.Repeat("ab", 32)
+	sum := Checksum(lower)
+	// опечатка в регистре одной буквы
+	typo := strings.ToLower(sum[:1]) + sum[1:]
+
+	cases := []struct {
+		in     string
+		strict bool
+		err    error
+	}{
+		{lower, false, nil},
+		{strings.ToUpper(lower), false, nil},
+		{sum, false, nil},
+		{sum, true, nil},
+		{typo, false, ErrChecksum},
+		{lower, true, ErrChecksum},
+		{strings.ToUpper(lower), true, ErrChecksum},
+		{lower[:63], false, ErrFormat},
+		{strings.Repeat("zz", 32), false, ErrFormat},
+	}
+	for _, c := range cases {
+		got, err := Parse(c.in, c.strict)
+		if !errors.Is(err, c.err) {
+			t.Errorf("Parse(%s, %v) err = %v, want %v", c.in, c.strict, err, c.err)
+			continue
+		}
+		if err == nil && got != lower {
+			t.Errorf("Parse(%s) = %s, want %s", c.in, got, lower)
+		}
+	}
+
+	// без букв форма с контрольной суммой совпадает с нижним регистром и проходит и в strict
+	digits := strings.Repeat("0123456789", 6) + "0123"
+	if _, err := Parse(digits, true); err != nil {
+		t.Errorf("digits-only address in strict mode: %v", err)
+	}
+}
+
+// TestDerive, адрес закреплен за секретом, арендатором и меткой, смена любого из них дает другой адрес
+func TestDerive(t *testing.T) {
+	d := NewDeriver("secret", "acme")
+	want := "55135442857f37ad7b9256a1dd5e4bb2ec37d68b8c06147ffa79c46c930f040c"
+	if got := d.Derive("system:fees"); got != want {
+		t.Fatalf("Derive = %s, want %s", got, want)
+	}
+	if !Valid(want) {
+		t.Fatalf("derived address %s is not valid", want)
+	}
+	for name, other := range map[string]string{
+		"label":  d.Derive("system:fee"),
+		"tenant": NewDeriver("secret", "acme2").Derive("system:fees"),
+		"secret": NewDeriver("secret2", "acme").Derive("system:fees"),
+		// граница арендатора и метки не сдвигается
+		"split": NewDeriver("secret", "acmes").Derive("ystem:fees"),
+	} {
+		if other == want {
+			t.Errorf("%s: same address %s", name, other)
+		}
+	}
+	if NewDeriver("", "acme") != nil {
+		t.Fatal("empty secret must disable derivation")
+	}
+}
+
+// TestValidLabel, метки выводимых адресов
+func TestValidLabel(t *testing.T) {
+	for s, want := range map[string]bool{
+		"system:fees":            true,
+		"shop/eu-1.payouts":      true,
+		"":                       false,
+		"has space":              false,
+		strings.Repeat("a", 129): false,
+	} {
+		if got := ValidLabel(s); got != want {
+			t.Errorf("ValidLabel(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package address
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+// validLabel, допустимая метка выводимого адреса
+var validLabel = regexp.MustCompile(`^[A-Za-z0-9._:/-]{1,128}$`)
+
+// ValidLabel, метка выводимого адреса, 1-128 символов из латиницы, цифр и ._:/-
+func ValidLabel(s string) bool { return validLabel.MatchString(s) }
+
+// Deriver, выводит адреса из арендатора и метки по HMAC-SHA256 секрета сервера, тот же секрет при повторном развертывании окружения дает те же адреса,
+// без секрета адрес по метке не подобрать и не предсказать
+type Deriver struct {
+	secret []byte
+	tenant string
+}
+
+// NewDeriver, выводит адреса арендатора tenant, пустой секрет дает nil, то есть выключенный вывод
+func NewDeriver(secret, tenant string) *Deriver {
+	if secret == "" {
+		return nil
+	}
+	return &Deriver{secret: []byte(secret), tenant: tenant}
+}
+
+// Derive, адрес метки в нижнем регистре, арендатор и метка разделены нулевым байтом, которого нет ни в одной из них
+func (d *Deriver) Derive(label string) string {
+	m := hmac.New(sha256.New, d.secret)
+	m.Write([]byte(d.tenant))
+	m.Write([]byte{0})
+	m.Write([]byte(label))
+	return hex.EncodeToString(m.Sum(nil))
+}
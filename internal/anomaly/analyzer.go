@@ -0,0 +1,138 @@
+// Package anomaly, фоновый анализ переводов, ищет всплески оборота, массовые переводы новым получателям и транзитные схемы, пишет сигналы в таблицу alerts
+package anomaly
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gotechtask/internal/config"
+	"gotechtask/internal/leader"
+	"gotechtask/internal/repo"
+)
+
+// виды сигналов
+const (
+	KindVolumeSpike       = "volume_spike"
+	KindNewCounterparties = "new_counterparties"
+	KindPassThrough       = "pass_through"
+)
+
+// Store, данные нужные анализатору, реализуется репозиторием postgres
+type Store interface {
+	WalletVolumes(ctx context.Context, baselineStart, windowStart time.Time, minRecent int64) ([]repo.WalletVolume, error)
+	NewCounterpartyCounts(ctx context.Context, windowStart time.Time, min int) ([]repo.WalletCount, error)
+	PassThroughFlows(ctx context.Context, windowStart time.Time, minReceived int64, ratio float64) ([]repo.WalletFlow, error)
+	InsertAlert(ctx context.Context, a repo.Alert) (bool, error)
+}
+
+// Analyzer, периодически прогоняет правила поверх журнала транзакций
+type Analyzer struct {
+	Store Store
+	Cfg   config.Anomaly
+	// Lock, блокировка прохода среди экземпляров сервиса, nil для одного экземпляра
+	Lock leader.Locker
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор анализатора
+func New(s Store, cfg config.Anomaly) *Analyzer {
+	return &Analyzer{Store: s, Cfg: cfg, Now: time.Now}
+}
+
+// Run, запускает анализ с заданным интервалом до отмены контекста, ошибки одного прохода логируются и не останавливают цикл, из нескольких экземпляров проход делает один
+func (a *Analyzer) Run(ctx context.Context) {
+	t := time.NewTicker(a.Cfg.Interval)
+	defer t.Stop()
+
+	for {
+		var n int
+		_, err := leader.Do(ctx, a.Lock, "anomaly", func(ctx context.Context) (err error) {
+			n, err = a.RunOnce(ctx)
+			return err
+		})
+		if err != nil {
+			log.Printf("anomaly: %v", err)
+		} else if n > 0 {
+			log.Printf("anomaly: raised %d alerts", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, один проход всех правил по скользящему окну, сигнал привязывается к выровненному окну, чтобы повторные проходы не плодили дубли, возвращает число новых сигналов
+func (a *Analyzer) RunOnce(ctx context.Context) (int, error) {
+	now := a.Now().UTC()
+	windowStart := now.Add(-a.Cfg.Window)
+	bucket := now.Truncate(a.Cfg.Window)
+	baselineStart := windowStart.Add(-time.Duration(a.Cfg.BaselineWindows) * a.Cfg.Window)
+
+	var alerts []repo.Alert
+
+	// всплеск оборота, объем в окне сравниваем со средним по базовым окнам
+	vols, err := a.Store.WalletVolumes(ctx, baselineStart, windowStart, a.Cfg.MinVolumeCents)
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range vols {
+		avg := float64(v.BaselineCents) / float64(max(a.Cfg.BaselineWindows, 1))
+		if float64(v.RecentCents) > a.Cfg.SpikeFactor*max(avg, 1) {
+			alerts = append(alerts, repo.Alert{
+				Kind:    KindVolumeSpike,
+				Address: v.Address,
+				Details: map[string]any{
+					"recent_cents":       v.RecentCents,
+					"baseline_avg_cents": int64(avg),
+				},
+			})
+		}
+	}
+
+	// много новых получателей за окно
+	counts, err := a.Store.NewCounterpartyCounts(ctx, windowStart, a.Cfg.NewCounterparties)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range counts {
+		alerts = append(alerts, repo.Alert{
+			Kind:    KindNewCounterparties,
+			Address: c.Address,
+			Details: map[string]any{"new_counterparties": c.Count},
+		})
+	}
+
+	// транзит, почти все полученное сразу ушло дальше
+	flows, err := a.Store.PassThroughFlows(ctx, windowStart, a.Cfg.MinVolumeCents, a.Cfg.PassThroughRatio)
+	if err != nil {
+		return 0, err
+	}
+	for _, f := range flows {
+		alerts = append(alerts, repo.Alert{
+			Kind:    KindPassThrough,
+			Address: f.Address,
+			Details: map[string]any{
+				"received_cents": f.ReceivedCents,
+				"sent_cents":     f.SentCents,
+			},
+		})
+	}
+
+	created := 0
+	for _, al := range alerts {
+		al.WindowStart = bucket
+		ok, err := a.Store.InsertAlert(ctx, al)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+	}
+	return created, nil
+}
@@ -0,0 +1,88 @@
+package anomaly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotechtask/internal/config"
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, хранилище в памяти, отдает заранее заданные агрегаты и копит сигналы
+type fakeStore struct {
+	vols   []repo.WalletVolume
+	counts []repo.WalletCount
+	flows  []repo.WalletFlow
+	seen   map[string]bool
+	alerts []repo.Alert
+}
+
+func (f *fakeStore) WalletVolumes(context.Context, time.Time, time.Time, int64) ([]repo.WalletVolume, error) {
+	return f.vols, nil
+}
+
+func (f *fakeStore) NewCounterpartyCounts(context.Context, time.Time, int) ([]repo.WalletCount, error) {
+	return f.counts, nil
+}
+
+func (f *fakeStore) PassThroughFlows(context.Context, time.Time, int64, float64) ([]repo.WalletFlow, error) {
+	return f.flows, nil
+}
+
+func (f *fakeStore) InsertAlert(_ context.Context, a repo.Alert) (bool, error) {
+	key := a.Kind + a.Address + a.WindowStart.String()
+	if f.seen[key] {
+		return false, nil
+	}
+	f.seen[key] = true
+	f.alerts = append(f.alerts, a)
+	return true, nil
+}
+
+// TestRunOnce_RulesAndDedup, проверяет срабатывание правил и отсутствие дублей при повторном проходе в том же окне
+func TestRunOnce_RulesAndDedup(t *testing.T) {
+	st := &fakeStore{
+		vols: []repo.WalletVolume{
+			{Address: "spike", RecentCents: 600000, BaselineCents: 24 * 10000},
+			{Address: "steady", RecentCents: 200000, BaselineCents: 24 * 100000},
+		},
+		counts: []repo.WalletCount{{Address: "fanout", Count: 12}},
+		flows:  []repo.WalletFlow{{Address: "mule", ReceivedCents: 500000, SentCents: 490000}},
+		seen:   map[string]bool{},
+	}
+	a := New(st, config.Anomaly{
+		Window:            time.Hour,
+		BaselineWindows:   24,
+		SpikeFactor:       5,
+		MinVolumeCents:    100000,
+		NewCounterparties: 10,
+		PassThroughRatio:  0.9,
+	})
+	now := time.Date(2025, 1, 1, 10, 30, 0, 0, time.UTC)
+	a.Now = func() time.Time { return now }
+
+	n, err := a.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if n != 3 {
+		t.Fatalf("want 3 alerts, got %d: %+v", n, st.alerts)
+	}
+	want := map[string]string{"spike": KindVolumeSpike, "fanout": KindNewCounterparties, "mule": KindPassThrough}
+	for _, al := range st.alerts {
+		if want[al.Address] != al.Kind {
+			t.Fatalf("unexpected alert %s for %s", al.Kind, al.Address)
+		}
+	}
+
+	// повторный проход в том же часе не создает новых сигналов
+	now = now.Add(10 * time.Minute)
+	n, err = a.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if n != 0 {
+		t.Fatalf("want no new alerts, got %d", n)
+	}
+}
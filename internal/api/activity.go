@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// activityDTO, событие ленты кошелька, type, вид события, action, его уточнение, сумма со знаком, у событий без денег пустая
+type activityDTO struct {
+	Type         string         `json:"type"`
+	ID           int64          `json:"id"`
+	Action       string         `json:"action"`
+	Amount       string         `json:"amount,omitempty"`
+	Counterparty string         `json:"counterparty,omitempty"`
+	Actor        string         `json:"actor,omitempty"`
+	Details      map[string]any `json:"details,omitempty"`
+	CreatedAt    string         `json:"created_at"`
+}
+
+// getWalletActivity, лента активности кошелька для вкладки клиента, переводы, операции, отложенные переводы, смены состояния и лимитов одним списком,
+// новые первыми, types, виды через запятую, cursor, продолжение из next_cursor, limit по умолчанию 50, максимум 200
+func (a *API) getWalletActivity(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	aq := repo.ActivityQuery{Cursor: q.Get("cursor")}
+	if v := q.Get("types"); v != "" {
+		for _, k := range strings.Split(v, ",") {
+			k = strings.TrimSpace(k)
+			if !slices.Contains(repo.ActivityKinds, k) {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"error": "unknown activity type", "types": repo.ActivityKinds})
+				return
+			}
+			aq.Kinds = append(aq.Kinds, k)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		aq.Limit = n
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	items, err := a.Repo.WalletActivity(ctx, addr, aq)
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidCursor) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid cursor"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	out := make([]activityDTO, 0, len(items))
+	for _, it := range items {
+		d := activityDTO{
+			Type:         it.Kind,
+			ID:           it.ID,
+			Action:       it.Action,
+			Counterparty: it.Counterparty,
+			Actor:        it.Actor,
+			Details:      it.Details,
+			CreatedAt:    it.CreatedAt.UTC().Format(time.RFC3339),
+		}
+		if it.AmountCents != 0 {
+			d.Amount = formatCents(it.AmountCents)
+		}
+		out = append(out, d)
+	}
+	resp := map[string]any{"address": addr, "items": out}
+	if c := aq.NextCursor(items); c != "" {
+		resp["next_cursor"] = c
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
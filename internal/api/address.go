@@ -0,0 +1,27 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gotechtask/internal/address"
+)
+
+// parseAddress, адрес из тела запроса в каноничном нижнем регистре, смешанный регистр проверяется по контрольной сумме,
+// с AddressChecksumStrict адрес без контрольной суммы отклоняется, ошибка уже записана в ответ
+func (a *API) parseAddress(w http.ResponseWriter, s string) (string, bool) {
+	canon, err := address.Parse(s, a.AddressChecksumStrict)
+	switch {
+	case err == nil:
+		return canon, true
+	case errors.Is(err, address.ErrChecksum):
+		msg := "invalid address checksum"
+		if a.AddressChecksumStrict {
+			msg = "invalid address checksum, use the checksummed address"
+		}
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg, "address": s})
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+	}
+	return "", false
+}
@@ -0,0 +1,354 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/invariant"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// denylistReq, входная модель блокировки адреса, адрес и причина
+type denylistReq struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+// denylistDTO, представление записи стоп-листа для ответа
+type denylistDTO struct {
+	Address   string `json:"address"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at"`
+}
+
+// getDenylist, отдает все заблокированные адреса
+func (a *API) getDenylist(w http.ResponseWriter, r *http.Request) {
+	items, err := a.Repo.ListDenylist(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]denylistDTO, 0, len(items))
+	for _, e := range items {
+		out = append(out, denylistDTO{
+			Address:   e.Address,
+			Reason:    e.Reason,
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// postDenylist, валидирует адрес и добавляет его в стоп-лист
+func (a *API) postDenylist(w http.ResponseWriter, r *http.Request) {
+	var req denylistReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if len(req.Address) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+
+	if err := a.Repo.AddToDenylist(r.Context(), req.Address, req.Reason, repo.ActorFromContext(r.Context())); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// deleteDenylist, снимает блокировку с адреса из пути
+func (a *API) deleteDenylist(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	err := a.Repo.RemoveFromDenylist(r.Context(), addr, repo.ActorFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, repo.ErrDenylistEntryNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "denylist entry not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// alertDTO, представление сигнала анализатора для ответа
+type alertDTO struct {
+	ID          int64          `json:"id"`
+	Kind        string         `json:"kind"`
+	Address     string         `json:"address"`
+	Details     map[string]any `json:"details"`
+	WindowStart string         `json:"window_start"`
+	CreatedAt   string         `json:"created_at"`
+}
+
+// getAlerts, отдает сигналы анализатора с фильтрами kind, address и ограничением limit
+func (a *API) getAlerts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := repo.AlertFilter{Kind: q.Get("kind"), Address: q.Get("address")}
+	if s := q.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		f.Limit = v
+	}
+
+	items, err := a.Repo.ListAlerts(r.Context(), f)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]alertDTO, 0, len(items))
+	for _, al := range items {
+		out = append(out, alertDTO{
+			ID:          al.ID,
+			Kind:        al.Kind,
+			Address:     al.Address,
+			Details:     al.Details,
+			WindowStart: al.WindowStart.UTC().Format(time.RFC3339),
+			CreatedAt:   al.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// supplyCheckDTO, результат проверки денежной массы для ответа
+type supplyCheckDTO struct {
+	Balances   string `json:"balances"`
+	Expected   string `json:"expected"`
+	Difference string `json:"difference"`
+	OK         bool   `json:"ok"`
+}
+
+// getSupplyCheck, проверяет инвариант денежной массы по запросу, при нарушении отвечает 200 с ok=false и пишет сигнал
+func (a *API) getSupplyCheck(w http.ResponseWriter, r *http.Request) {
+	checker := a.Supply
+	if checker == nil {
+		checker = invariant.New(a.Repo, 0)
+	}
+
+	res, err := checker.Check(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, supplyCheckDTO{
+		Balances:   formatCents(res.BalancesCents),
+		Expected:   formatCents(res.ExpectedCents),
+		Difference: formatCents(res.BalancesCents - res.ExpectedCents),
+		OK:         res.OK,
+	})
+}
+
+// overdraftReq, входная модель лимита овердрафта, лимит в валюте, ноль запрещает уход в минус
+type overdraftReq struct {
+	Limit float64 `json:"limit"`
+}
+
+// putOverdraft, задает лимит овердрафта для служебных кошельков, отказывает если текущий минус больше нового лимита
+func (a *API) putOverdraft(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	var req overdraftReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Limit < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be >= 0"})
+		return
+	}
+	if toCents(req.Limit) > money.MaxCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit too large"})
+		return
+	}
+
+	err := a.Repo.SetOverdraftLimit(r.Context(), addr, toCents(req.Limit), repo.ActorFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrWalletNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+		case errors.Is(err, repo.ErrOverdraftInUse):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "balance below overdraft limit"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// walletMatchDTO, кандидат поиска кошелька для ответа
+type walletMatchDTO struct {
+	Address    string `json:"address"`
+	Balance    string `json:"balance"`
+	UserID     int64  `json:"user_id,omitempty"`
+	MatchedBy  string `json:"matched_by"`
+	Alias      string `json:"alias,omitempty"`
+	AliasOwner string `json:"alias_owner,omitempty"`
+}
+
+// getWalletSearch, поиск кошельков по началу адреса или псевдониму для поддержки, q не короче трех символов, limit до 100
+func (a *API) getWalletSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if len(strings.TrimSpace(q.Get("q"))) < repo.MinSearchLen {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q must be at least 3 characters"})
+		return
+	}
+	var limit int
+	if s := q.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = v
+	}
+
+	items, err := a.Repo.SearchWallets(r.Context(), q.Get("q"), limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]walletMatchDTO, 0, len(items))
+	for _, m := range items {
+		out = append(out, walletMatchDTO{
+			Address:    m.Address,
+			Balance:    formatCents(m.BalanceCents),
+			UserID:     m.UserID,
+			MatchedBy:  m.MatchedBy,
+			Alias:      m.Alias,
+			AliasOwner: m.AliasOwner,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// systemWalletDTO, служебный кошелек для ответа
+type systemWalletDTO struct {
+	Role        string `json:"role"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+	Balance     string `json:"balance"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// getSystemWallets, служебные кошельки с ролями и балансами
+func (a *API) getSystemWallets(w http.ResponseWriter, r *http.Request) {
+	items, err := a.Repo.ListSystemWallets(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]systemWalletDTO, 0, len(items))
+	for _, s := range items {
+		out = append(out, systemWalletDTO{
+			Role:        s.Role,
+			Address:     s.Address,
+			Description: s.Description,
+			Balance:     formatCents(s.BalanceCents),
+			CreatedAt:   s.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// treasuryReq, входная модель эмиссии и изъятия, сумма в валюте и обязательная причина для аудита
+type treasuryReq struct {
+	Amount float64 `json:"amount"`
+	Reason string  `json:"reason"`
+}
+
+// postMint, выпускает средства на кошелек казны
+func (a *API) postMint(w http.ResponseWriter, r *http.Request) {
+	a.treasuryOp(w, r, a.Repo.Mint)
+}
+
+// postBurn, изымает средства с кошелька казны
+func (a *API) postBurn(w http.ResponseWriter, r *http.Request) {
+	a.treasuryOp(w, r, a.Repo.Burn)
+}
+
+// treasuryOp, общая часть эмиссии и изъятия, проверяет тело и маппит ошибки, отдает созданную операцию
+func (a *API) treasuryOp(w http.ResponseWriter, r *http.Request, op func(ctx context.Context, amountCents int64, reason string) (repo.Transaction, error)) {
+	var req treasuryReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	amountCents := toCents(req.Amount)
+	if amountCents <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
+		return
+	}
+	if amountCents > money.MaxCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount too large"})
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "reason required"})
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	t, err := op(ctx, amountCents, req.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrSystemWalletNotFound):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "treasury wallet not configured"})
+		case errors.Is(err, repo.ErrInsufficientFunds):
+			writeJSON(w, http.StatusConflict, rejectionBody("insufficient treasury balance", err))
+		case errors.Is(err, repo.ErrBalanceOverflow):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "balance limit exceeded"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, toTxDTO(t))
+}
+
+// balanceMismatchDTO, расхождение баланса со снимком для ответа
+type balanceMismatchDTO struct {
+	Address    string `json:"address"`
+	SnapshotAt string `json:"snapshot_at"`
+	Expected   string `json:"expected"`
+	Balance    string `json:"balance"`
+}
+
+// getBalanceCheck, сверка балансов с последним снимком и операциями после него, пустой список значит что все сошлось
+func (a *API) getBalanceCheck(w http.ResponseWriter, r *http.Request) {
+	items, err := a.Repo.ReconcileBalances(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]balanceMismatchDTO, 0, len(items))
+	for _, m := range items {
+		out = append(out, balanceMismatchDTO{
+			Address:    m.Address,
+			SnapshotAt: m.SnapshotAt.UTC().Format(time.RFC3339),
+			Expected:   formatCents(m.ExpectedCents),
+			Balance:    formatCents(m.BalanceCents),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": len(out) == 0, "mismatches": out})
+}
@@ -0,0 +1,12 @@
+package api
+
+import "testing"
+
+// TestToCents, суммы из запроса переводятся в центы по десятичной записи без потери цента на ошибке float
+func TestToCents(t *testing.T) {
+	for in, want := range map[float64]int64{0.29: 29, 0.57: 57, 1.13: 113, 3.5: 350, 7: 700, 0.015: 1, 0.001: 0, 12345678.91: 1234567891} {
+		if got := toCents(in); got != want {
+			t.Errorf("toCents(%v) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+)
+
+// maxKeyRotationOverlap, верхняя граница окна, в котором старый ключ работает после ротации
+const maxKeyRotationOverlap = 7 * 24 * time.Hour
+
+// apiKeyReq, входная модель выдачи ключа, имя и области доступа, пустые области дают набор по умолчанию
+type apiKeyReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// rotateKeyReq, входная модель ротации, окно перекрытия в секундах, без него берется значение по умолчанию
+type rotateKeyReq struct {
+	OverlapSeconds *int64 `json:"overlap_seconds"`
+}
+
+// apiKeyDTO, представление ключа, сам токен отдается только при выдаче и ротации
+type apiKeyDTO struct {
+	ID        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Prefix    string   `json:"prefix"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at"`
+	ExpiresAt string   `json:"expires_at,omitempty"`
+	RevokedAt string   `json:"revoked_at,omitempty"`
+	Signed    bool     `json:"signed"`
+	APIKey    string   `json:"api_key,omitempty"`
+}
+
+// keyOwner, пользователь, ключами которого управляет запрос, вход по токену администратора пользователем не является
+func keyOwner(w http.ResponseWriter, r *http.Request) (auth.Principal, bool) {
+	p := auth.FromContext(r.Context())
+	if p.UserID == 0 {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "api keys belong to users"})
+		return p, false
+	}
+	return p, true
+}
+
+// grantable, может ли участник выдать ключ с такими областями, ключ не может породить ключ шире себя, admin:* только администратору
+func grantable(p auth.Principal, scopes []string) bool {
+	for _, s := range scopes {
+		if s == auth.ScopeAdmin && !p.Admin {
+			return false
+		}
+		if !p.Allows(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// getAPIKeys, ключи текущего пользователя
+func (a *API) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	items, err := a.Repo.ListAPIKeys(r.Context(), p.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]apiKeyDTO, 0, len(items))
+	for _, k := range items {
+		out = append(out, toAPIKeyDTO(k))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// postAPIKey, выдает новый ключ текущему пользователю
+func (a *API) postAPIKey(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	var req apiKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	scopes, err := auth.ParseScopes(req.Scopes)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !grantable(p, scopes) {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient scope"})
+		return
+	}
+
+	token, hash, prefix, err := auth.NewToken()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	k, err := a.Repo.CreateAPIKey(r.Context(), p.UserID, req.Name, scopes, hash, prefix)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	dto := toAPIKeyDTO(k)
+	dto.APIKey = token
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// rotateAPIKey, выдает замену ключу, старый работает еще overlap_seconds, затем перестает находиться
+func (a *API) rotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	var req rotateKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	overlap := a.KeyRotationOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+	if overlap < 0 || overlap > maxKeyRotationOverlap {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid overlap_seconds"})
+		return
+	}
+
+	// замена получает области старого ключа, они не должны быть шире чем у вызывающего
+	keys, err := a.Repo.ListAPIKeys(r.Context(), p.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	for _, k := range keys {
+		if k.ID == id && !grantable(p, k.Scopes) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient scope"})
+			return
+		}
+	}
+
+	token, hash, prefix, err := auth.NewToken()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	k, err := a.Repo.RotateAPIKey(r.Context(), p.UserID, id, overlap, hash, prefix)
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	// кэш должен увидеть новый срок действия старого ключа
+	a.Keys.InvalidateKey(id)
+
+	dto := toAPIKeyDTO(k)
+	dto.APIKey = token
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// deleteAPIKey, отзывает ключ сразу, запись в кэше сбрасывается
+func (a *API) deleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	if err := a.Repo.RevokeAPIKey(r.Context(), p.UserID, id); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	a.Keys.InvalidateKey(id)
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// toAPIKeyDTO, маппинг ключа в ответ
+func toAPIKeyDTO(k repo.APIKeyInfo) apiKeyDTO {
+	dto := apiKeyDTO{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.UTC().Format(time.RFC3339),
+		Signed:    k.Signed,
+	}
+	if !k.ExpiresAt.IsZero() {
+		dto.ExpiresAt = k.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if !k.RevokedAt.IsZero() {
+		dto.RevokedAt = k.RevokedAt.UTC().Format(time.RFC3339)
+	}
+	return dto
+}
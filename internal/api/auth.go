@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+)
+
+// ошибки проверки доступа, участник не имеет доступа к ресурсу, учетные данные неверны
+var (
+	errForbidden    = errors.New("forbidden")
+	errUnauthorized = errors.New("unauthorized")
+)
+
+// IdentityVerifier, проверка токенов внешнего провайдера, реализуется auth.OIDC
+type IdentityVerifier interface {
+	Verify(ctx context.Context, raw string) (auth.Identity, error)
+}
+
+// authenticate, определяет участника запроса по заголовку X-Admin-Token или Authorization: Bearer с ключом сервиса либо токеном провайдера oidc, без заголовков запрос идет анонимно, неверные данные дают 401
+func (a *API) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tok := r.Header.Get("X-Admin-Token"); tok != "" {
+			if a.AdminToken == "" || subtle.ConstantTimeCompare([]byte(tok), []byte(a.AdminToken)) != 1 {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), auth.Principal{Admin: true})))
+			return
+		}
+
+		h := r.Header.Get("Authorization")
+		if h == "" {
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), auth.Principal{})))
+			return
+		}
+		token, ok := strings.CutPrefix(h, "Bearer ")
+		if !ok || token == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		var p auth.Principal
+		var err error
+		if a.OIDC != nil && !auth.IsAPIKey(token) {
+			p, err = a.oidcPrincipal(r.Context(), token)
+		} else {
+			p, err = a.apiKeyPrincipal(r.Context(), token)
+		}
+		if err != nil {
+			if err == errUnauthorized {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+				return
+			}
+			if errors.Is(err, repo.ErrUserExists) {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "email already registered"})
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), p)))
+	})
+}
+
+// withPrincipal, кладет участника в контекст и его же как инициатора для репозитория
+func withPrincipal(ctx context.Context, p auth.Principal) context.Context {
+	ctx = auth.WithPrincipal(ctx, p)
+	return repo.WithCaller(ctx, repo.Caller{UserID: p.UserID, KeyID: p.KeyID, Admin: p.Admin})
+}
+
+// apiKeyPrincipal, участник по ключу доступа сервиса, ключ ищется сначала в кэше
+func (a *API) apiKeyPrincipal(ctx context.Context, token string) (auth.Principal, error) {
+	hash := auth.HashToken(token)
+	key, ok := a.Keys.get(hash, time.Now())
+	if !ok {
+		gen := a.Keys.generation()
+		var err error
+		key, err = a.Repo.LookupAPIKey(ctx, hash)
+		if err != nil {
+			if errors.Is(err, repo.ErrAPIKeyNotFound) {
+				return auth.Principal{}, errUnauthorized
+			}
+			return auth.Principal{}, err
+		}
+		a.Keys.put(hash, key, gen, time.Now())
+	}
+	// права администратора ключ дает только вместе с областью admin:*
+	admin := key.Admin && auth.HasScope(key.Scopes, auth.ScopeAdmin)
+	return auth.Principal{UserID: key.UserID, KeyID: key.ID, Admin: admin, Scopes: key.Scopes, SigningSecret: key.SigningSecret}, nil
+}
+
+// oidcPrincipal, участник по токену внешнего провайдера, subject сопоставляется локальному пользователю, при первом входе пользователь создается
+func (a *API) oidcPrincipal(ctx context.Context, token string) (auth.Principal, error) {
+	id, err := a.OIDC.Verify(ctx, token)
+	if err != nil {
+		return auth.Principal{}, errUnauthorized
+	}
+	u, err := a.Repo.UserByExternalIdentity(ctx, repo.ExternalIdentity{
+		Issuer:  id.Issuer,
+		Subject: id.Subject,
+		Email:   strings.ToLower(id.Email),
+		Name:    id.Name,
+	})
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	return auth.Principal{UserID: u.ID, Admin: u.Admin}, nil
+}
+
+// requireAdmin, пропускает только администраторов, аноним получает 401, обычный пользователь 403
+func (a *API) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := auth.FromContext(r.Context())
+		if p.Admin {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if p.Anonymous() {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+	})
+}
+
+// requireScope, пропускает участника, которому разрешена область доступа, ключ без нее получает 403, аноним проходит дальше к проверке владения кошельком
+func (a *API) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !auth.FromContext(r.Context()).Allows(scope) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient scope", "required_scope": scope})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireUser, пропускает только аутентифицированных участников
+func (a *API) requireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth.FromContext(r.Context()).Anonymous() {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authorizeWallet, проверяет доступ участника к кошельку, общий кошелек доступен всем, личный владельцу и администратору
+func (a *API) authorizeWallet(ctx context.Context, addr string) error {
+	p := auth.FromContext(ctx)
+	owner, err := a.Repo.WalletOwner(ctx, addr)
+	if err != nil {
+		return err
+	}
+	return authorizeOwner(p, owner)
+}
+
+// authorizeOwner, доступ участника к кошельку с известным владельцем, ноль, общий кошелек
+func authorizeOwner(p auth.Principal, owner int64) error {
+	if owner == 0 || p.Admin || owner == p.UserID {
+		return nil
+	}
+	return errForbidden
+}
+
+// txVisibility, какие транзакции показывать участнику в общих списках
+func txVisibility(ctx context.Context) repo.TxVisibility {
+	p := auth.FromContext(ctx)
+	return repo.TxVisibility{All: p.Admin, UserID: p.UserID}
+}
+
+// writeWalletAccessError, маппит ошибку проверки доступа к кошельку в http ответ
+func writeWalletAccessError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repo.ErrWalletNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+	case errors.Is(err, errForbidden):
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
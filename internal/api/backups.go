@@ -0,0 +1,51 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/backup"
+	"gotechtask/internal/repo"
+)
+
+// postBackup, ставит задачу снятия резервной копии кошельков и транзакций во внешнее хранилище, ответ 202 с именем копии, готовность видна в GET /api/admin/backups/{id},
+// без настроенного хранилища 503
+func (a *API) postBackup(w http.ResponseWriter, r *http.Request) {
+	if a.Blob == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "storage not configured"})
+		return
+	}
+	id := backup.NewID(time.Now())
+	if err := a.Repo.EnqueueJob(r.Context(), backup.Kind, backup.Payload{ID: id}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	// запись аудита не должна отменять уже поставленную копию
+	_ = a.Repo.RecordAudit(r.Context(), repo.AuditEntry{Action: repo.AuditBackupCreate, Details: map[string]any{"id": id}})
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id, "status": "queued"})
+}
+
+// getBackup, манифест копии, версия схемы, момент среза, файлы с числом строк и sha256, пока копия не дописана 404
+func (a *API) getBackup(w http.ResponseWriter, r *http.Request) {
+	if a.Blob == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "storage not configured"})
+		return
+	}
+	id := chi.URLParam(r, "id")
+	if !backup.ValidID(id) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	m, err := backup.ReadManifest(r.Context(), a.Blob, id)
+	if errors.Is(err, backup.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "backup not found or not finished"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, m)
+}
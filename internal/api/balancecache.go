@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// параметры кэша балансов, сколько кошельков он держит, при переполнении он очищается, корзин поколений, пауза переподключения слушателя
+const (
+	maxCachedWallets  = 10000
+	balanceCacheGens  = 256
+	balanceCacheRetry = 3 * time.Second
+)
+
+// BalanceCache, кэш кошельков для чтения баланса, запись сбрасывается уведомлением базы после коммита изменения на любом экземпляре,
+// кэш отдает записи, только пока слушатель уведомлений подписан, после обрыва он пуст до переподключения, TTL ограничивает возраст записи на случай потери уведомления
+type BalanceCache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	wallets map[string]cachedWallet
+	// live, подписка на уведомления действует, gens, поколения корзин адресов, растут при сбросе,
+	// чтение из базы, начатое до сброса, в кэш не кладется
+	live bool
+	gens [balanceCacheGens]uint64
+}
+
+// cachedWallet, кошелек и время чтения
+type cachedWallet struct {
+	w  repo.Wallet
+	at time.Time
+}
+
+// NewBalanceCache, конструктор, ttl равный нулю выключает кэш, до подписки Run кэш ничего не отдает
+func NewBalanceCache(ttl time.Duration) *BalanceCache {
+	return &BalanceCache{TTL: ttl, wallets: make(map[string]cachedWallet)}
+}
+
+// Run, слушает уведомления до отмены контекста, после обрыва кэш очищается и не отдает записей, пока подписка не восстановится,
+// пропущенные за это время уведомления значения не имеют, кэш при переподключении очищается еще раз
+func (c *BalanceCache) Run(ctx context.Context, src repo.Ledger) {
+	for {
+		err := src.ListenBalances(ctx, func() { c.reset(true) }, c.invalidate)
+		c.reset(false)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("balance cache: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(balanceCacheRetry):
+		}
+	}
+}
+
+// gen, поколение корзины адреса, берется до чтения из базы и передается в put
+func (c *BalanceCache) gen(address string) uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gens[genBucket(address)]
+}
+
+// get, свежий кошелек из кэша, пока подписка действует
+func (c *BalanceCache) get(address string, now time.Time) (repo.Wallet, bool) {
+	if c == nil || c.TTL <= 0 {
+		return repo.Wallet{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.wallets[address]
+	if !c.live || !ok || now.Sub(e.at) >= c.TTL {
+		return repo.Wallet{}, false
+	}
+	return e.w, true
+}
+
+// put, кладет кошелек, прочитанный в now, если с взятия gen его корзину не сбрасывали
+func (c *BalanceCache) put(w repo.Wallet, gen uint64, now time.Time) {
+	if c == nil || c.TTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.live || c.gens[genBucket(w.Address)] != gen {
+		return
+	}
+	if _, ok := c.wallets[w.Address]; !ok && len(c.wallets) >= maxCachedWallets {
+		c.wallets = make(map[string]cachedWallet)
+	}
+	c.wallets[w.Address] = cachedWallet{w: w, at: now}
+}
+
+// invalidate, сбрасывает кошелек, вызывается по уведомлению базы и после перевода этого экземпляра, чтобы он сразу видел свою запись
+func (c *BalanceCache) invalidate(address string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.wallets, address)
+	c.gens[genBucket(address)]++
+}
+
+// reset, очищает кэш, live, действует ли подписка
+func (c *BalanceCache) reset(live bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wallets = make(map[string]cachedWallet)
+	for i := range c.gens {
+		c.gens[i]++
+	}
+	c.live = live
+}
+
+// genBucket, корзина поколений адреса
+func genBucket(address string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(address))
+	return int(h.Sum32() % balanceCacheGens)
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// TestBalanceCache, кэш отдает кошелек только при действующей подписке, уведомление и свой перевод сбрасывают запись,
+// доступ к личному кошельку из кэша проверяется по владельцу, после обрыва подписки чтение снова идет в базу
+func TestBalanceCache(t *testing.T) {
+	var reads, owners int
+	owner := int64(0)
+	m := newMockRepo()
+	m.WalletOwnerFunc = func(context.Context, string) (int64, error) { owners++; return owner, nil }
+	m.GetWalletFunc = func(_ context.Context, addr string) (repo.Wallet, error) {
+		reads++
+		return repo.Wallet{Address: addr, BalanceCents: int64(reads) * 100, UserID: owner}, nil
+	}
+
+	live := make(chan struct{})
+	notify := make(chan string)
+	notified := make(chan struct{})
+	drop := make(chan struct{})
+	m.ListenBalancesFunc = func(ctx context.Context, listening func(), fn func(string)) error {
+		listening()
+		close(live)
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-drop:
+				return errors.New("connection lost")
+			case addr := <-notify:
+				fn(addr)
+				notified <- struct{}{}
+			}
+		}
+	}
+
+	cache := NewBalanceCache(time.Minute)
+	a := &API{Repo: m, AdminToken: testAdminToken, BalanceCache: cache}
+	r := chi.NewRouter()
+	a.Routes(r)
+	get := func() (int, string) {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/wallet/w1/balance", nil)
+		req.Header.Set("Authorization", "Bearer wk_mock")
+		r.ServeHTTP(rr, req)
+		var body map[string]string
+		_ = json.NewDecoder(rr.Body).Decode(&body)
+		return rr.Code, body["balance"]
+	}
+
+	// до подписки кэш не отдает записей
+	get()
+	if _, bal := get(); bal != "2.00" || reads != 2 {
+		t.Fatalf("before listening: balance %s, reads %d", bal, reads)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Run(ctx, m)
+	<-live
+
+	get()
+	if _, bal := get(); bal != "3.00" || reads != 3 || owners != 3 {
+		t.Fatalf("cached: balance %s, reads %d, owners %d", bal, reads, owners)
+	}
+
+	notify <- "w1"
+	<-notified
+	if _, bal := get(); bal != "4.00" {
+		t.Fatalf("after notification: balance %s", bal)
+	}
+
+	a.transferCommitted("w1", "w2")
+	if _, bal := get(); bal != "5.00" {
+		t.Fatalf("after own transfer: balance %s", bal)
+	}
+
+	// личный кошелек другого участника из кэша не отдается
+	owner = mockUserID + 1
+	cache.put(repo.Wallet{Address: "w1", UserID: owner}, cache.gen("w1"), time.Now())
+	if code, _ := get(); code != http.StatusForbidden {
+		t.Fatalf("foreign wallet from cache: got %d", code)
+	}
+	owner = 0
+
+	close(drop)
+	deadline := time.Now().Add(time.Second)
+	for {
+		cache.mu.Lock()
+		stopped := !cache.live
+		cache.mu.Unlock()
+		if stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cache still live after disconnect")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	before := reads
+	get()
+	get()
+	if reads != before+2 {
+		t.Fatalf("after disconnect: want reads from db, got %d", reads-before)
+	}
+}
+
+// TestBalanceCacheStaleRead, чтение, начатое до сброса, в кэш не попадает
+func TestBalanceCacheStaleRead(t *testing.T) {
+	c := NewBalanceCache(time.Minute)
+	c.reset(true)
+	now := time.Now()
+
+	gen := c.gen("w1")
+	c.invalidate("w1")
+	c.put(repo.Wallet{Address: "w1", BalanceCents: 100}, gen, now)
+	if _, ok := c.get("w1", now); ok {
+		t.Fatal("stale read cached")
+	}
+
+	c.put(repo.Wallet{Address: "w1", BalanceCents: 200}, c.gen("w1"), now)
+	if w, ok := c.get("w1", now); !ok || w.BalanceCents != 200 {
+		t.Fatalf("fresh read: %+v %v", w, ok)
+	}
+	if _, ok := c.get("w1", now.Add(time.Minute)); ok {
+		t.Fatal("expired entry returned")
+	}
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+)
+
+// maxBulkBalances, сколько адресов можно запросить за раз
+const maxBulkBalances = 500
+
+// balancesReq, входная модель пакетного запроса балансов
+type balancesReq struct {
+	Addresses []string `json:"addresses"`
+}
+
+// balanceItemDTO, баланс одного адреса пакета, для ненайденного или чужого личного кошелька только адрес и error
+type balanceItemDTO struct {
+	Address   string `json:"address"`
+	Balance   string `json:"balance,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+	LastTxAt  string `json:"last_tx_at,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Successor string `json:"successor,omitempty"`
+}
+
+// postBalances, балансы до 500 кошельков одним запросом к базе, ответ в порядке запроса, повторы адресов схлопываются, ненайденные, чужие личные и закрытые слиянием кошельки помечаются в error как в запросе одного баланса
+func (a *API) postBalances(w http.ResponseWriter, r *http.Request) {
+	var req balancesReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if len(req.Addresses) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "addresses required"})
+		return
+	}
+	if len(req.Addresses) > maxBulkBalances {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("at most %d addresses", maxBulkBalances)})
+		return
+	}
+
+	addrs := make([]string, 0, len(req.Addresses))
+	seen := make(map[string]bool, len(req.Addresses))
+	for _, addr := range req.Addresses {
+		if len(addr) != 64 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format: " + addr})
+			return
+		}
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+
+	wallets, err := a.Repo.GetWallets(r.Context(), addrs)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	byAddr := make(map[string]repo.Wallet, len(wallets))
+	for _, wl := range wallets {
+		byAddr[wl.Address] = wl
+	}
+
+	// личный кошелек виден только владельцу и администратору, как в getBalance
+	p := auth.FromContext(r.Context())
+	out := make([]balanceItemDTO, 0, len(addrs))
+	for _, addr := range addrs {
+		wl, ok := byAddr[addr]
+		switch {
+		case !ok:
+			out = append(out, balanceItemDTO{Address: addr, Error: "wallet not found"})
+		case wl.UserID != 0 && !p.Admin && wl.UserID != p.UserID:
+			out = append(out, balanceItemDTO{Address: addr, Error: "forbidden"})
+		case wl.Successor != "":
+			out = append(out, balanceItemDTO{Address: addr, Error: "wallet closed", Successor: wl.Successor})
+		default:
+			item := balanceItemDTO{
+				Address:   addr,
+				Balance:   formatCents(wl.BalanceCents),
+				UpdatedAt: wl.UpdatedAt.UTC().Format(time.RFC3339),
+			}
+			if !wl.LastTxAt.IsZero() {
+				item.LastTxAt = wl.LastTxAt.UTC().Format(time.RFC3339)
+			}
+			out = append(out, item)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"balances": out})
+}
+
+// getWalletExists, есть ли кошелек, без баланса и владельца, области balance:read не требует, чтобы отправитель мог проверить получателя до перевода, HEAD отвечает тем же кодом без тела
+func (a *API) getWalletExists(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if len(addr) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+
+	if _, err := a.Repo.WalletOwner(r.Context(), addr); err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"address": addr, "exists": true})
+}
+
+// getBalanceAt, баланс на момент at в rfc3339 или на конец бизнес-дня YYYY-MM-DD, считается от ближайшего снимка на начало суток, будущее время и незакрытый день не принимаются
+func (a *API) getBalanceAt(w http.ResponseWriter, r *http.Request, addr, v string) {
+	at, err := a.parseBound(v, true)
+	if err != nil || at.After(time.Now()) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "at must be a past RFC3339 time or closed business date"})
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	cents, err := a.Repo.BalanceAt(ctx, addr, at)
+	if err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"address": addr,
+		"balance": formatCents(cents),
+		"at":      at.UTC().Format(time.RFC3339),
+	})
+}
+
+// balanceEventDTO, изменение баланса для ответа, сумма со знаком и баланс после операции строкой
+type balanceEventDTO struct {
+	ID        int64  `json:"id"`
+	TxID      int64  `json:"tx_id"`
+	Delta     string `json:"delta"`
+	Balance   string `json:"balance"`
+	CreatedAt string `json:"created_at"`
+}
+
+// getBalanceEvents, как кошелек пришел к балансу, события изменения баланса новые первыми, until, момент в rfc3339 или бизнес-дата, первое событие дает баланс на него,
+// before_id, курсор следующей страницы из next_before_id, limit по умолчанию 100, максимум 1000
+func (a *API) getBalanceEvents(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	var bq repo.BalanceEventQuery
+	if v := q.Get("until"); v != "" {
+		until, err := a.parseBound(v, true)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "until must be an RFC3339 time or business date"})
+			return
+		}
+		bq.Until = until
+	}
+	if v := q.Get("before_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid before_id"})
+			return
+		}
+		bq.BeforeID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		bq.Limit = n
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	events, err := a.Repo.BalanceEvents(ctx, addr, bq)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	out := make([]balanceEventDTO, 0, len(events))
+	for _, e := range events {
+		out = append(out, balanceEventDTO{
+			ID:        e.ID,
+			TxID:      e.TxID,
+			Delta:     formatCents(e.DeltaCents),
+			Balance:   formatCents(e.BalanceCents),
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	resp := map[string]any{"address": addr, "events": out}
+	if len(events) > 0 {
+		resp["next_before_id"] = events[len(events)-1].ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// maxBatchItems, предел переводов в одном пакете, пакет держит блокировки кошельков до коммита
+const maxBatchItems = 100
+
+// batchReq, пакет переводов, режим atomic по умолчанию или best_effort, переводы исполняются в порядке списка
+type batchReq struct {
+	Mode  repo.BatchMode `json:"mode"`
+	Items []batchItemReq `json:"items"`
+}
+
+// batchItemReq, перевод пакета, адрес отправителя, адрес получателя, сумма
+type batchItemReq struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+// batchItemResp, исход перевода пакета, ok или failed с текстом отказа
+type batchItemResp struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Available и Shortfall, при нехватке средств доступный баланс отправителя на момент перевода и нехватка
+	Available string `json:"available,omitempty"`
+	Shortfall string `json:"shortfall,omitempty"`
+}
+
+// batchResp, итог пакета, status ok если прошли все переводы, partial если часть, failed если ни один
+type batchResp struct {
+	Status    string          `json:"status"`
+	Succeeded int             `json:"succeeded"`
+	Failed    int             `json:"failed"`
+	Items     []batchItemResp `json:"items"`
+}
+
+// writeBatchItemError, ошибка, относящаяся к одному переводу пакета, номер перевода в поле item
+func writeBatchItemError(w http.ResponseWriter, code int, index int, msg string) {
+	writeJSON(w, code, map[string]any{"error": msg, "item": index})
+}
+
+// writeBatchItemRejection, доменный отказ перевода из пакета с номером перевода и подробностями отказа
+func writeBatchItemRejection(w http.ResponseWriter, code int, index int, msg string, err error) {
+	body := rejectionBody(msg, err)
+	body["item"] = index
+	writeJSON(w, code, body)
+}
+
+// postSendBatch, пакет переводов в одной транзакции, ошибка проверки любого перевода отклоняет весь пакет с 400,
+// в режиме atomic первый отказ откатывает весь пакет и отдается с номером перевода, в режиме best_effort отказавшие переводы откатываются до своей точки сохранения, остальные фиксируются, ответ 200 с исходом каждого перевода
+func (a *API) postSendBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = repo.BatchAtomic
+	}
+	if !repo.ValidBatchMode(req.Mode) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mode"})
+		return
+	}
+	if len(req.Items) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "items required"})
+		return
+	}
+	if len(req.Items) > maxBatchItems {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "too many items", "max_items": maxBatchItems})
+		return
+	}
+
+	// проверяем все переводы до исполнения, те же правила, что у одиночного перевода
+	items := make([]repo.TransferItem, len(req.Items))
+	perSender := make(map[string]int64)
+	for i, it := range req.Items {
+		if len(it.From) != 64 || len(it.To) != 64 {
+			writeBatchItemError(w, http.StatusBadRequest, i, "invalid address format")
+			return
+		}
+		if it.Amount <= 0 {
+			writeBatchItemError(w, http.StatusBadRequest, i, "amount must be > 0")
+			return
+		}
+		cents := toCents(it.Amount)
+		if cents > money.MaxCents {
+			writeBatchItemError(w, http.StatusBadRequest, i, "amount too large")
+			return
+		}
+		items[i] = repo.TransferItem{From: it.From, To: it.To, AmountCents: cents}
+		// сумма не переполняется, переводов не больше maxBatchItems и каждый не больше MaxCents
+		perSender[it.From] += cents
+	}
+
+	for i, it := range items {
+		if _, seen := perSender[it.From]; !seen {
+			continue
+		}
+		// распоряжаться личным кошельком может только владелец или администратор
+		if err := a.authorizeWallet(r.Context(), it.From); err != nil {
+			writeWalletAccessError(w, err)
+			return
+		}
+		// порог второго фактора считается по всем переводам отправителя, иначе крупный перевод можно было бы разбить на мелкие в одном пакете
+		need, err := a.needsSecondFactor(r.Context(), it.From, perSender[it.From])
+		if err != nil {
+			writeWalletAccessError(w, err)
+			return
+		}
+		if need {
+			writeBatchItemError(w, http.StatusForbidden, i, "second factor required, send large transfers individually")
+			return
+		}
+		delete(perSender, it.From)
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	results, err := a.Repo.TransferBatch(ctx, items, req.Mode)
+	if err != nil {
+		var itemErr *repo.BatchItemError
+		if errors.As(err, &itemErr) {
+			if code, msg, ok := transferRejection(itemErr.Err); ok {
+				writeBatchItemRejection(w, code, itemErr.Index, msg, itemErr.Err)
+				return
+			}
+		}
+		a.writeTransferError(w, err)
+		return
+	}
+
+	resp := batchResp{Items: make([]batchItemResp, len(items))}
+	for i, it := range items {
+		if results[i] == nil {
+			a.transferCommitted(it.From, it.To)
+			resp.Items[i] = batchItemResp{Index: i, Status: "ok"}
+			resp.Succeeded++
+			continue
+		}
+		msg := "internal error"
+		if _, m, ok := transferRejection(results[i]); ok {
+			msg = m
+		}
+		resp.Items[i] = batchItemResp{Index: i, Status: "failed", Error: msg}
+		var short *repo.InsufficientFundsError
+		if errors.As(results[i], &short) {
+			resp.Items[i].Available = formatCents(short.AvailableCents)
+			resp.Items[i].Shortfall = formatCents(short.ShortfallCents())
+		}
+		resp.Failed++
+	}
+	switch {
+	case resp.Failed == 0:
+		resp.Status = "ok"
+	case resp.Succeeded == 0:
+		resp.Status = "failed"
+	default:
+		resp.Status = "partial"
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
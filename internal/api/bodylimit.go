@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultBodyLimit, предел тела маршрута без своего предела, если BodyLimits.Default не задан
+const defaultBodyLimit = 16 << 10
+
+// routeBodyLimits, встроенные пределы маршрутов, одиночный перевод маленький, пакеты и списки больше, ключ "METHOD /pattern"
+var routeBodyLimits = map[string]int64{
+	"POST /api/send":       4 << 10,
+	"POST /api/send/quote": 4 << 10,
+	"POST /api/send/batch": 64 << 10,
+	"POST /api/send/split": 64 << 10,
+	"POST /api/balances":   64 << 10,
+}
+
+// BodyLimits, пределы тел запросов, Routes переопределяет встроенные пределы по маршруту в шаблоне chi,
+// ReadTimeout, за сколько тело должно прийти целиком, ноль выключает
+type BodyLimits struct {
+	Default     int64
+	Routes      map[string]int64
+	ReadTimeout time.Duration
+}
+
+// bodyLimit, предел тела маршрута, настройка важнее встроенного предела
+func (b BodyLimits) bodyLimit(route string) int64 {
+	if n, ok := b.Routes[route]; ok && n > 0 {
+		return n
+	}
+	if n, ok := routeBodyLimits[route]; ok {
+		return n
+	}
+	if b.Default > 0 {
+		return b.Default
+	}
+	return defaultBodyLimit
+}
+
+// limitBody, читает тело запроса целиком до обработчиков, больше предела маршрута отвечает 413, тело, которое не пришло за ReadTimeout, 408,
+// так медленный или огромный запрос не держит соединение, полосу и транзакцию перевода, обработчики получают тело из памяти
+func (a *API) limitBody(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limit := a.Bodies.bodyLimit(r.Method + " " + routePattern(routes, r))
+			if r.ContentLength > limit {
+				writeError(w, r, http.StatusRequestEntityTooLarge, "body too large")
+				return
+			}
+
+			rc := http.NewResponseController(w)
+			if a.Bodies.ReadTimeout > 0 {
+				_ = rc.SetReadDeadline(time.Now().Add(a.Bodies.ReadTimeout))
+			}
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+			if a.Bodies.ReadTimeout > 0 {
+				_ = rc.SetReadDeadline(time.Time{})
+			}
+			var tooLarge *http.MaxBytesError
+			var netErr net.Error
+			switch {
+			case errors.As(err, &tooLarge):
+				w.Header().Set("X-Body-Limit", strconv.FormatInt(limit, 10))
+				writeError(w, r, http.StatusRequestEntityTooLarge, "body too large")
+				return
+			case errors.As(err, &netErr) && netErr.Timeout():
+				w.Header().Set("Connection", "close")
+				writeError(w, r, http.StatusRequestTimeout, "request body timeout")
+				return
+			case err != nil:
+				writeError(w, r, http.StatusBadRequest, "cannot read body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routePattern, шаблон маршрута запроса с учетом вложенных роутеров, пустая строка, если маршрута нет
+func routePattern(routes chi.Routes, r *http.Request) string {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	rctx := chi.NewRouteContext()
+	if !routes.Match(rctx, r.Method, path) {
+		return ""
+	}
+	return rctx.RoutePattern()
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// bodyRouter, роутер с пределами тел, обработчики отвечают размером прочитанного тела
+func bodyRouter(b BodyLimits) chi.Router {
+	a := &API{Bodies: b}
+	r := chi.NewRouter()
+	r.Use(a.limitBody(r))
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(strings.Repeat("x", len(body))))
+	}
+	r.Post("/api/send", echo)
+	r.Post("/api/send/batch", echo)
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Post("/wallet/{address}/email", echo)
+	})
+	return r
+}
+
+// TestLimitBody, предел берется по маршруту, настройка важнее встроенного, превышение отвечает 413 до обработчика
+func TestLimitBody(t *testing.T) {
+	r := bodyRouter(BodyLimits{Default: 100, Routes: map[string]int64{"POST /api/admin/wallet/{address}/email": 10}})
+	do := func(path string, n int, chunked bool) *httptest.ResponseRecorder {
+		var body io.Reader = strings.NewReader(strings.Repeat("a", n))
+		if chunked {
+			body = io.MultiReader(body)
+		}
+		req := httptest.NewRequest(http.MethodPost, path, body)
+		if chunked {
+			req.ContentLength = -1
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for _, tc := range []struct {
+		path    string
+		n       int
+		chunked bool
+		want    int
+	}{
+		{"/api/send", 4 << 10, false, http.StatusOK},
+		{"/api/send", 4<<10 + 1, false, http.StatusRequestEntityTooLarge},
+		{"/api/send", 4<<10 + 1, true, http.StatusRequestEntityTooLarge},
+		{"/api/send/batch", 10 << 10, false, http.StatusOK},
+		{"/api/admin/wallet/abc/email", 10, false, http.StatusOK},
+		{"/api/admin/wallet/abc/email", 11, true, http.StatusRequestEntityTooLarge},
+		{"/unknown", 101, false, http.StatusRequestEntityTooLarge},
+	} {
+		rr := do(tc.path, tc.n, tc.chunked)
+		if rr.Code != tc.want {
+			t.Errorf("%s %d bytes chunked=%t: status %d, want %d", tc.path, tc.n, tc.chunked, rr.Code, tc.want)
+		}
+		if tc.want == http.StatusOK && rr.Body.Len() != tc.n {
+			t.Errorf("%s: handler read %d bytes, want %d", tc.path, rr.Body.Len(), tc.n)
+		}
+	}
+}
+
+// TestLimitBody_Slow, тело, которое приходит медленнее таймаута, получает 408 и закрытое соединение
+func TestLimitBody_Slow(t *testing.T) {
+	srv := httptest.NewServer(bodyRouter(BodyLimits{ReadTimeout: 200 * time.Millisecond}))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_, _ = io.WriteString(conn, "POST /api/send HTTP/1.1\r\nHost: x\r\nContent-Length: 100\r\n\r\n{\"to\":")
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Fatalf("status %d, want 408", resp.StatusCode)
+	}
+	if !resp.Close {
+		t.Fatalf("connection kept open after slow body")
+	}
+}
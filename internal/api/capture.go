@@ -0,0 +1,55 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+)
+
+// captureSend, при включенной записи отладки пишет перевод и его исход в Capture, получатель по псевдониму разрешается до перевода,
+// запросы с битым телом или адресами не записываются, их исход от базы не зависит
+func (a *API) captureSend(next http.Handler) http.Handler {
+	if a.Capture == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+		if err != nil {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var req sendReq
+		if err := json.Unmarshal(body, &req); err != nil || len(req.From) != 64 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+		to := req.To
+		if req.ToAlias != "" {
+			to, _ = a.Repo.ResolvePayee(ctx, req.From, req.ToAlias)
+		}
+		if len(to) != 64 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		call := a.Capture.Start(req.From, to, toCents(req.Amount), func(addr string) (int64, bool) {
+			bal, err := a.Repo.GetBalance(ctx, addr)
+			return bal, err == nil
+		})
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		var resp struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(rec.body.Bytes(), &resp)
+		if err := call.Finish(rec.status, resp.Error); err != nil {
+			log.Printf("capture: %v", err)
+		}
+	})
+}
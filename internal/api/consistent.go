@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// consistentReads, с consistent=true ручка чтения целиком выполняется в одном снимке базы, страница, ее счетчик, ETag и сводки из нескольких запросов
+// не расходятся, даже если в это время идут переводы, без параметра и с false запрос идет как обычно, неверное значение дает 400,
+// снимок держит соединение до конца ответа, поэтому режим включается только по запросу
+func (a *API) consistentReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := r.URL.Query().Get("consistent")
+		if v == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid consistent"})
+			return
+		}
+		if !on {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		served := false
+		err = a.Repo.ReadConsistent(r.Context(), func(ctx context.Context) error {
+			served = true
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+		// ответ уже записан обработчиком, иначе снимок не начался
+		if err != nil && !served {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// snapshotMark, метка контекста, которой мок помечает чтения внутри снимка
+type snapshotMark struct{}
+
+// TestConsistentReads, consistent=true выполняет ручку внутри ReadConsistent, без параметра снимка нет, неверное значение и сбой начала снимка дают ошибку
+func TestConsistentReads(t *testing.T) {
+	m := newMockRepo()
+	var beginErr error
+	m.ReadConsistentFunc = func(ctx context.Context, fn func(ctx context.Context) error) error {
+		if beginErr != nil {
+			return beginErr
+		}
+		return fn(context.WithValue(ctx, snapshotMark{}, true))
+	}
+	var inSnapshot bool
+	m.GetWalletStatsFunc = func(ctx context.Context, address string) (repo.WalletStats, error) {
+		inSnapshot = ctx.Value(snapshotMark{}) != nil
+		return repo.WalletStats{Address: address}, nil
+	}
+	r := chi.NewRouter()
+	(&API{Repo: m}).Routes(r)
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/wallet/"+strings.Repeat("a", 64)+"/stats"+query, nil))
+		return rr
+	}
+
+	for _, c := range []struct {
+		query    string
+		snapshot bool
+	}{{"", false}, {"?consistent=false", false}, {"?consistent=true", true}} {
+		inSnapshot = false
+		if rr := get(c.query); rr.Code != http.StatusOK || inSnapshot != c.snapshot {
+			t.Fatalf("%q: status %d, in snapshot %v, want %v", c.query, rr.Code, inSnapshot, c.snapshot)
+		}
+	}
+	if calls := len(m.ReadConsistentCalls()); calls != 1 {
+		t.Fatalf("snapshot opened %d times, want 1", calls)
+	}
+
+	if rr := get("?consistent=yes"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("invalid value: want 400, got %d", rr.Code)
+	}
+	beginErr = errors.New("too many connections")
+	if rr := get("?consistent=true"); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("begin failure: want 500, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// defaultCounterpartyWindow, период сводки по контрагентам если from не задан
+const defaultCounterpartyWindow = 30 * 24 * time.Hour
+
+// counterpartyDTO, представление контрагента для ответа, суммы строкой
+type counterpartyDTO struct {
+	Address  string `json:"address"`
+	Sent     string `json:"sent"`
+	Received string `json:"received"`
+	Volume   string `json:"volume"`
+	TxCount  int64  `json:"tx_count"`
+	LastTxAt string `json:"last_tx_at"`
+}
+
+// getCounterparties, сводка переводов кошелька по контрагентам за период from..to в rfc3339 или бизнес-датах, либо за бизнес-день date, сортировка sort=volume|sent|received|count, order=asc|desc, страница limit и offset
+func (a *API) getCounterparties(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	qs := r.URL.Query()
+
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	q := repo.CounterpartyQuery{
+		Since:  now.Add(-defaultCounterpartyWindow),
+		Until:  now,
+		SortBy: qs.Get("sort"),
+		Desc:   qs.Get("order") != "asc",
+	}
+	since, until, bad := a.timeRange(qs)
+	if bad != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + bad})
+		return
+	}
+	if !since.IsZero() {
+		q.Since = since
+	}
+	if !until.IsZero() {
+		q.Until = until
+	}
+	if o := qs.Get("order"); o != "" && o != "asc" && o != "desc" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid order"})
+		return
+	}
+	for name, dst := range map[string]*int{"limit": &q.Limit, "offset": &q.Offset} {
+		if s := qs.Get(name); s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil || v < 0 {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + name})
+				return
+			}
+			*dst = v
+		}
+	}
+
+	items, err := a.Repo.ListCounterparties(r.Context(), addr, q)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrWalletNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+		case errors.Is(err, repo.ErrInvalidSort):
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid sort"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+
+	out := make([]counterpartyDTO, 0, len(items))
+	for _, c := range items {
+		out = append(out, counterpartyDTO{
+			Address:  c.Address,
+			Sent:     formatCents(c.SentCents),
+			Received: formatCents(c.ReceivedCents),
+			Volume:   formatCents(c.SentCents + c.ReceivedCents),
+			TxCount:  c.TxCount,
+			LastTxAt: c.LastTxAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// dashboardFiles, статическая панель администратора, данные она берет из админских ручек api с токеном, который вводит оператор
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// statsWindow, за какой период считать переводы и сигналы в сводке
+const statsWindow = 24 * time.Hour
+
+// statsDTO, сводка состояния сервиса, Lanes только при включенном ограничении емкости
+type statsDTO struct {
+	Since            string    `json:"since"`
+	Wallets          int64     `json:"wallets"`
+	UserWallets      int64     `json:"user_wallets"`
+	Balances         string    `json:"balances"`
+	Transactions     int64     `json:"transactions"`
+	Volume           string    `json:"volume"`
+	Alerts           int64     `json:"alerts"`
+	JobsQueued       int64     `json:"jobs_queued"`
+	JobsFailed       int64     `json:"jobs_failed"`
+	HotCreditsQueued int64     `json:"hot_credits_queued"`
+	BusinessTimezone string    `json:"business_timezone"`
+	Lanes            *lanesDTO `json:"lanes,omitempty"`
+}
+
+// lanesDTO, занятость полос
+type lanesDTO struct {
+	Capacity int64 `json:"capacity"`
+	Inflight int64 `json:"inflight"`
+}
+
+// getStats, сводка для панели, кошельки и сумма балансов, переводы, оборот и сигналы за последние сутки, очередь задач, занятость полос
+func (a *API) getStats(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	s, err := a.Repo.Stats(ctx, time.Now().Add(-statsWindow))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	dto := statsDTO{
+		Since:            s.Since.UTC().Format(time.RFC3339),
+		Wallets:          s.Wallets,
+		UserWallets:      s.UserWallets,
+		Balances:         formatCents(s.BalanceCents),
+		Transactions:     s.TxCount,
+		Volume:           formatCents(s.TxVolumeCents),
+		Alerts:           s.Alerts,
+		JobsQueued:       s.JobsQueued,
+		JobsFailed:       s.JobsFailed,
+		HotCreditsQueued: s.HotCreditsQueued,
+		BusinessTimezone: a.location().String(),
+	}
+	if a.Lanes != nil {
+		dto.Lanes = &lanesDTO{Capacity: a.Lanes.capacity, Inflight: a.Lanes.inflight.Load()}
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// dashboard, раздает файлы панели, сами файлы данных не содержат и открыты без аутентификации, встраивание в чужие страницы и внешние скрипты запрещены
+func dashboard() http.Handler {
+	sub, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	files := http.StripPrefix("/admin/", http.FileServer(http.FS(sub)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		files.ServeHTTP(w, r)
+	})
+}
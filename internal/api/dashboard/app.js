@@ -0,0 +1,294 @@
+// панель администратора, все данные из админских ручек api, токен хранится только в sessionStorage вкладки
+'use strict';
+
+const tokenKey = 'wallet-admin-token';
+const refreshMs = 10000;
+const $ = (id) => document.getElementById(id);
+
+// api, запрос к серверу с токеном администратора, 401 возвращает на форму входа
+async function api(path) {
+  const resp = await fetch(path, { headers: { 'X-Admin-Token': sessionStorage.getItem(tokenKey) || '' } });
+  if (resp.status === 401) {
+    logout();
+    throw new Error('unauthorized');
+  }
+  const body = await resp.json();
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+// table, перерисовывает таблицу, значения только как текст, onClick делает строки кликабельными
+function table(el, columns, rows, onClick) {
+  el.replaceChildren();
+  const head = el.insertRow();
+  for (const c of columns) {
+    const th = document.createElement('th');
+    th.textContent = c.title;
+    head.appendChild(th);
+  }
+  if (rows.length === 0) {
+    const td = el.insertRow().insertCell();
+    td.colSpan = columns.length;
+    td.textContent = 'нет данных';
+    return;
+  }
+  for (const row of rows) {
+    const tr = el.insertRow();
+    for (const c of columns) {
+      const td = tr.insertCell();
+      td.textContent = c.value ? c.value(row) : (row[c.key] ?? '');
+      if (c.className) td.className = c.className;
+    }
+    if (onClick) {
+      tr.className = 'clickable';
+      tr.addEventListener('click', () => onClick(row));
+    }
+  }
+}
+
+// stats, список показателей
+function stats(el, items) {
+  el.replaceChildren();
+  for (const [title, value, bad] of items) {
+    const div = document.createElement('div');
+    const dt = document.createElement('dt');
+    const dd = document.createElement('dd');
+    dt.textContent = title;
+    dd.textContent = value;
+    if (bad) dd.className = 'bad';
+    div.append(dt, dd);
+    el.appendChild(div);
+  }
+}
+
+const short = (a) => (a ? a.slice(0, 12) + '…' : '');
+const time = (s) => (s ? new Date(s).toLocaleString() : '');
+
+const txColumns = [
+  { title: 'id', key: 'id', className: 'num' },
+  { title: 'время', value: (t) => time(t.created_at) },
+  { title: 'вид', key: 'type' },
+  { title: 'откуда', value: (t) => short(t.from), className: 'addr' },
+  { title: 'куда', value: (t) => short(t.to), className: 'addr' },
+  { title: 'сумма', key: 'amount', className: 'num' },
+  { title: 'инициатор', key: 'initiated_by' },
+  { title: 'канал', key: 'channel' },
+];
+
+async function loadStats() {
+  const [s, supply] = await Promise.all([api('/api/admin/stats'), api('/api/admin/invariants/supply')]);
+  const items = [
+    ['кошельков', s.wallets],
+    ['из них личных', s.user_wallets],
+    ['сумма балансов', s.balances],
+    ['денежная масса', supply.ok ? 'сходится' : 'расхождение ' + supply.difference, !supply.ok],
+    ['переводов за сутки', s.transactions],
+    ['оборот за сутки', s.volume],
+    ['сигналов за сутки', s.alerts, s.alerts > 0],
+    ['задач в очереди', s.jobs_queued],
+    ['упавших задач', s.jobs_failed, s.jobs_failed > 0],
+    ['часовой пояс', s.business_timezone],
+  ];
+  if (s.lanes) items.push(['занято полос', s.lanes.inflight + ' из ' + s.lanes.capacity]);
+  stats($('stats'), items);
+}
+
+async function loadSystemWallets() {
+  const rows = await api('/api/admin/system-wallets');
+  table($('system-wallets'), [
+    { title: 'роль', key: 'role' },
+    { title: 'адрес', value: (w) => short(w.address), className: 'addr' },
+    { title: 'баланс', key: 'balance', className: 'num' },
+    { title: 'описание', key: 'description' },
+  ], rows, (w) => openWallet(w.address));
+}
+
+async function loadTransactions() {
+  table($('transactions'), txColumns, await api('/api/transactions?count=50'), (t) => openTransaction(t.id).catch(showError));
+}
+
+async function loadAlerts() {
+  table($('alerts'), [
+    { title: 'время', value: (a) => time(a.created_at) },
+    { title: 'вид', key: 'kind' },
+    { title: 'кошелек', value: (a) => short(a.address), className: 'addr' },
+  ], await api('/api/admin/alerts?limit=20'), (a) => a.address && openWallet(a.address));
+}
+
+async function openWallet(address) {
+  const [wl, txs] = await Promise.all([
+    api('/api/wallet/' + encodeURIComponent(address) + '/balance'),
+    api('/api/transactions?count=50&address=' + encodeURIComponent(address)),
+  ]);
+  $('wallet').hidden = false;
+  $('wallet-address').textContent = address;
+  stats($('wallet-balance'), [
+    ['баланс', wl.balance],
+    ['создан', time(wl.created_at)],
+    ['последний перевод', time(wl.last_tx_at) || 'не было'],
+  ]);
+  table($('wallet-transactions'), txColumns, txs);
+  $('wallet').scrollIntoView({ behavior: 'smooth' });
+}
+
+async function openTransaction(id) {
+  const t = await api('/api/transactions/' + encodeURIComponent(id));
+  $('tx').hidden = false;
+  $('tx-id').textContent = t.id;
+  stats($('tx-detail'), [
+    ['время', time(t.created_at)],
+    ['вид', t.type],
+    ['сумма', t.amount],
+    ['откуда', t.from],
+    ['куда', t.to],
+    ['инициатор', t.initiated_by || 'неизвестен'],
+    ['канал', t.channel || 'неизвестен'],
+  ]);
+  $('tx').scrollIntoView({ behavior: 'smooth' });
+}
+
+// живая лента, EventSource не умеет слать заголовок с токеном, поэтому поток читается через fetch и события разбираются вручную
+const feedMax = 200;
+const feedRows = [];
+let feed = null;
+let feedLastId = '';
+
+// feedRender, перерисовывает ленту, новые строки подсвечиваются
+function feedRender(fresh) {
+  table($('feed'), txColumns, feedRows, (t) => openTransaction(t.id).catch(showError));
+  const rows = $('feed').rows;
+  for (let i = 1; i <= fresh && i < rows.length; i++) rows[i].classList.add('fresh');
+}
+
+// feedEvent, одно событие потока, поле data это транзакция как в списке
+function feedEvent(block) {
+  let id = '';
+  let data = '';
+  for (const line of block.split('\n')) {
+    if (line.startsWith('id: ')) id = line.slice(4);
+    else if (line.startsWith('data: ')) data += line.slice(6);
+  }
+  if (!data) return 0;
+  if (id) feedLastId = id;
+  feedRows.unshift(JSON.parse(data));
+  feedRows.length = Math.min(feedRows.length, feedMax);
+  return 1;
+}
+
+// feedConnect, держит поток открытым, после обрыва переподключается и догоняет пропущенное по Last-Event-ID
+async function feedConnect(ctrl, query) {
+  while (!ctrl.signal.aborted) {
+    try {
+      const headers = { 'X-Admin-Token': sessionStorage.getItem(tokenKey) || '' };
+      if (feedLastId) headers['Last-Event-ID'] = feedLastId;
+      const resp = await fetch('/api/admin/transactions/stream?' + query, { headers, signal: ctrl.signal });
+      if (resp.status === 401) {
+        logout();
+        return;
+      }
+      if (!resp.ok) throw new Error((await resp.json()).error || resp.statusText);
+      $('feed-status').textContent = 'подключено';
+
+      const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+      let buf = '';
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) break;
+        buf += value;
+        let n = 0;
+        let i;
+        while ((i = buf.indexOf('\n\n')) >= 0) {
+          n += feedEvent(buf.slice(0, i));
+          buf = buf.slice(i + 2);
+        }
+        if (n) feedRender(n);
+      }
+      $('feed-status').textContent = 'переподключение…';
+    } catch (e) {
+      if (ctrl.signal.aborted) return;
+      $('feed-status').textContent = 'ошибка: ' + e.message + ', переподключение…';
+    }
+    await new Promise((r) => setTimeout(r, 3000));
+  }
+}
+
+// feedStart, запускает ленту с текущими фильтрами, предыдущий поток закрывается
+function feedStart() {
+  feedStop();
+  const q = new URLSearchParams();
+  if ($('feed-address').value.trim()) q.set('address', $('feed-address').value.trim());
+  if ($('feed-type').value) q.set('type', $('feed-type').value);
+  feedRows.length = 0;
+  feedLastId = '';
+  feedRender(0);
+  feed = new AbortController();
+  feedConnect(feed, q.toString());
+}
+
+function feedStop() {
+  if (feed) feed.abort();
+  feed = null;
+  $('feed-status').textContent = '';
+}
+
+function showError(e) {
+  $('status').textContent = 'ошибка: ' + e.message;
+}
+
+async function search(ev) {
+  ev.preventDefault();
+  const rows = await api('/api/admin/wallets/search?q=' + encodeURIComponent($('search').value.trim()));
+  table($('search-results'), [
+    { title: 'адрес', value: (m) => short(m.address), className: 'addr' },
+    { title: 'баланс', key: 'balance', className: 'num' },
+    { title: 'пользователь', key: 'user_id' },
+    { title: 'совпадение', value: (m) => (m.alias ? m.matched_by + ': ' + m.alias : m.matched_by) },
+  ], rows, (m) => openWallet(m.address));
+}
+
+// refresh, обновляет сводные панели, ошибка показывается в шапке и не останавливает обновление
+async function refresh() {
+  try {
+    await Promise.all([loadStats(), loadSystemWallets(), loadTransactions(), loadAlerts()]);
+    $('status').textContent = 'обновлено ' + new Date().toLocaleTimeString();
+  } catch (e) {
+    $('status').textContent = 'ошибка: ' + e.message;
+  }
+}
+
+let timer;
+
+function start() {
+  $('login').hidden = true;
+  $('app').hidden = false;
+  $('logout').hidden = false;
+  refresh();
+  timer = setInterval(refresh, refreshMs);
+  feedStart();
+}
+
+function logout() {
+  sessionStorage.removeItem(tokenKey);
+  clearInterval(timer);
+  feedStop();
+  $('login').hidden = false;
+  $('app').hidden = true;
+  $('logout').hidden = true;
+}
+
+$('login-form').addEventListener('submit', (ev) => {
+  ev.preventDefault();
+  sessionStorage.setItem(tokenKey, $('token').value);
+  $('token').value = '';
+  start();
+});
+$('logout').addEventListener('click', logout);
+$('search-form').addEventListener('submit', (ev) => search(ev).catch(showError));
+$('feed-form').addEventListener('submit', (ev) => {
+  ev.preventDefault();
+  feedStart();
+});
+
+if (sessionStorage.getItem(tokenKey)) start();
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestDashboard_Served, панель отдается из встроенных файлов без аутентификации, с запретом внешних скриптов, /admin ведет на /admin/
+func TestDashboard_Served(t *testing.T) {
+	r := chi.NewRouter()
+	(&API{}).Routes(r)
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	if rr := get("/admin"); rr.Code != http.StatusMovedPermanently || rr.Header().Get("Location") != "/admin/" {
+		t.Fatalf("/admin: want redirect to /admin/, got %d %q", rr.Code, rr.Header().Get("Location"))
+	}
+	rr := get("/admin/")
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `<script src="app.js">`) {
+		t.Fatalf("index: %d %s", rr.Code, rr.Body.String())
+	}
+	if csp := rr.Header().Get("Content-Security-Policy"); !strings.Contains(csp, "default-src 'self'") {
+		t.Fatalf("want restrictive csp, got %q", csp)
+	}
+	for _, f := range []string{"/admin/app.js", "/admin/style.css"} {
+		if rr := get(f); rr.Code != http.StatusOK || rr.Body.Len() == 0 {
+			t.Fatalf("%s: %d", f, rr.Code)
+		}
+	}
+	if rr := get("/admin/missing.js"); rr.Code != http.StatusNotFound {
+		t.Fatalf("missing file: want 404, got %d", rr.Code)
+	}
+}
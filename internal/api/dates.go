@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/url"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// location, часовой пояс бизнес-дня, по умолчанию utc
+func (a *API) location() *time.Location {
+	if a.Location == nil {
+		return time.UTC
+	}
+	return a.Location
+}
+
+// parseBound, граница периода, момент в rfc3339 или бизнес-дата YYYY-MM-DD в часовом поясе бизнеса, дата как начало периода это полночь дня, как конец полночь следующего дня, то есть день включается целиком
+func (a *API) parseBound(s string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	start, next, err := repo.DayBounds(s, a.location())
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		return next, nil
+	}
+	return start, nil
+}
+
+// timeRange, полуинтервал [since, until) из параметров from, to и date, date это один бизнес-день и не сочетается с from и to, незаданная граница остается нулевой, bad, имя неверного параметра
+func (a *API) timeRange(qs url.Values) (since, until time.Time, bad string) {
+	if d := qs.Get("date"); d != "" {
+		if qs.Get("from") != "" || qs.Get("to") != "" {
+			return since, until, "date"
+		}
+		start, next, err := repo.DayBounds(d, a.location())
+		if err != nil {
+			return since, until, "date"
+		}
+		return start, next, ""
+	}
+	if s := qs.Get("from"); s != "" {
+		v, err := a.parseBound(s, false)
+		if err != nil {
+			return since, until, "from"
+		}
+		since = v
+	}
+	if s := qs.Get("to"); s != "" {
+		v, err := a.parseBound(s, true)
+		if err != nil {
+			return since, until, "to"
+		}
+		until = v
+	}
+	return since, until, ""
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestTimeRange_BusinessDate, даты в фильтрах берутся в часовом поясе бизнеса, конец периода включает день целиком
+func TestTimeRange_BusinessDate(t *testing.T) {
+	a := &API{Location: time.FixedZone("UTC+3", 3*3600)}
+	utc := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	cases := []struct {
+		query        string
+		since, until time.Time
+		bad          string
+	}{
+		{"date=2025-06-14", utc("2025-06-13T21:00:00Z"), utc("2025-06-14T21:00:00Z"), ""},
+		{"from=2025-06-01&to=2025-06-14", utc("2025-05-31T21:00:00Z"), utc("2025-06-14T21:00:00Z"), ""},
+		{"from=2025-06-01T10:00:00Z", utc("2025-06-01T10:00:00Z"), time.Time{}, ""},
+		{"to=2025-06-14T00:00:00%2B03:00", time.Time{}, utc("2025-06-13T21:00:00Z"), ""},
+		{"", time.Time{}, time.Time{}, ""},
+		{"date=2025-06-14&from=2025-06-01", time.Time{}, time.Time{}, "date"},
+		{"date=14.06.2025", time.Time{}, time.Time{}, "date"},
+		{"from=yesterday", time.Time{}, time.Time{}, "from"},
+		{"to=2025-13-01", time.Time{}, time.Time{}, "to"},
+	}
+	for _, c := range cases {
+		qs, err := url.ParseQuery(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		since, until, bad := a.timeRange(qs)
+		if bad != c.bad || !since.Equal(c.since) || !until.Equal(c.until) {
+			t.Errorf("%q: got [%v, %v) bad=%q, want [%v, %v) bad=%q", c.query, since, until, bad, c.since, c.until, c.bad)
+		}
+	}
+}
+
+// TestTimeRange_DefaultUTC, без настроенного часового пояса дни считаются в utc
+func TestTimeRange_DefaultUTC(t *testing.T) {
+	a := &API{}
+	since, until, bad := a.timeRange(url.Values{"date": {"2025-06-14"}})
+	if bad != "" || !since.Equal(time.Date(2025, 6, 14, 0, 0, 0, 0, time.UTC)) || until.Sub(since) != 24*time.Hour {
+		t.Fatalf("got [%v, %v) bad=%q", since, until, bad)
+	}
+}
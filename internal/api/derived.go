@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"gotechtask/internal/address"
+	"gotechtask/internal/repo"
+)
+
+// derivedReq, входная модель кошелька с выведенным адресом, label, метка, из которой выводится адрес
+type derivedReq struct {
+	Label string `json:"label"`
+}
+
+// postDerivedWallet, заводит общий кошелек с адресом, выведенным из метки секретом сервера, повтор с той же меткой отдает тот же кошелек с 200,
+// так повторное развертывание окружения получает прежние адреса, занятый адрес или метка, привязанная к другому адресу, дают 409
+func (a *API) postDerivedWallet(w http.ResponseWriter, r *http.Request) {
+	var req derivedReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if !address.ValidLabel(req.Label) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid label"})
+		return
+	}
+	// метки служебных кошельков выводит только сид
+	if strings.HasPrefix(req.Label, repo.SystemLabel("")) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "label reserved"})
+		return
+	}
+
+	wl, created, err := a.Repo.CreateDerivedWallet(r.Context(), a.Addresses.Derive(req.Label), req.Label, repo.ActorFromContext(r.Context()))
+	if err != nil {
+		var col *repo.AddressCollisionError
+		if errors.As(err, &col) {
+			body := map[string]string{"error": "address collision", "address": col.Address, "label": col.Label, "reason": col.Reason}
+			if col.Bound != "" {
+				body["bound"] = col.Bound
+			}
+			writeJSON(w, http.StatusConflict, body)
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	dto := toWalletDTO(wl)
+	dto.Address = address.Checksum(wl.Address)
+	if !created {
+		writeJSON(w, http.StatusOK, dto)
+		return
+	}
+	writeCreated(w, "/api/wallet/"+wl.Address, dto)
+}
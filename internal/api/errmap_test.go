@@ -0,0 +1,767 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/address"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+	"gotechtask/internal/repo/repomock"
+)
+
+// errBoom, непредвиденная ошибка репозитория, ручки отвечают на нее 500
+var errBoom = errors.New("boom")
+
+// mockUserID, пользователь, которому принадлежит любой ключ мока
+const mockUserID = 7
+
+// newMockRepo, мок репозитория, все кошельки общие, любой ключ доступа принадлежит mockUserID с областями по умолчанию,
+// остальные методы задает тест, вызов незаданного паникует
+func newMockRepo() *repomock.RepoMock {
+	return &repomock.RepoMock{
+		WalletOwnerFunc: func(context.Context, string) (int64, error) { return 0, nil },
+		LookupAPIKeyFunc: func(context.Context, string) (repo.APIKey, error) {
+			return repo.APIKey{ID: 1, UserID: mockUserID, Scopes: auth.DefaultScopes}, nil
+		},
+	}
+}
+
+// errCase, запрос к ручке и ошибка, которую вернет мок
+type errCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	// user, запрос с ключом пользователя, admin, с токеном администратора
+	user  bool
+	admin bool
+	// ifMatch, заголовок If-Match запроса, если не пустой
+	ifMatch string
+	setup   func(m *repomock.RepoMock)
+	// status и error, ожидаемый ответ
+	status int
+	error  string
+}
+
+// transferErrCases, отказы перевода для ручки, которая маппит их через writeTransferError
+func transferErrCases(prefix, method, path, body string, setup func(m *repomock.RepoMock, err error)) []errCase {
+	var out []errCase
+	for _, e := range []struct {
+		err    error
+		status int
+		msg    string
+	}{
+		{repo.ErrWalletNotFound, http.StatusNotFound, "wallet not found"},
+		{repo.ErrInsufficientFunds, http.StatusConflict, "insufficient funds"},
+		{repo.ErrBalanceOverflow, http.StatusConflict, "balance limit exceeded"},
+		{repo.ErrSameAddress, http.StatusBadRequest, "from must differ from to"},
+		{repo.ErrAddressDenied, http.StatusForbidden, "address denylisted"},
+		{repo.ErrContention, http.StatusConflict, "transfer contention, retry later"},
+		{&repo.WalletClosedError{Address: "y", Successor: "z"}, http.StatusGone, "wallet closed"},
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, "transfer timed out"},
+		{errBoom, http.StatusInternalServerError, "internal error"},
+		{fmt.Errorf("transfer x->y: %w", &repo.InsufficientFundsError{Address: "x", NeededCents: 250}), http.StatusConflict, "insufficient funds"},
+		{fmt.Errorf("transfer x->y: %w", &repo.WalletNotFoundError{Address: "y"}), http.StatusNotFound, "wallet not found"},
+	} {
+		err := e.err
+		out = append(out, errCase{
+			name: prefix + "/" + err.Error(), method: method, path: path, body: body,
+			setup:  func(m *repomock.RepoMock) { setup(m, err) },
+			status: e.status, error: e.msg,
+		})
+	}
+	return out
+}
+
+// errCases, ветки маппинга ошибок репозитория в ответы ручек
+func errCases() []errCase {
+	from, to := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	wallet := "/api/wallet/" + from
+
+	cases := []errCase{
+		{name: "balance/not found", method: "GET", path: wallet + "/balance",
+			setup: func(m *repomock.RepoMock) {
+				m.WalletOwnerFunc = func(context.Context, string) (int64, error) { return 0, repo.ErrWalletNotFound }
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "balance/forbidden", method: "GET", path: wallet + "/balance",
+			setup: func(m *repomock.RepoMock) {
+				m.WalletOwnerFunc = func(context.Context, string) (int64, error) { return mockUserID + 1, nil }
+			},
+			status: http.StatusForbidden, error: "forbidden"},
+		{name: "balance/owner error", method: "GET", path: wallet + "/balance",
+			setup: func(m *repomock.RepoMock) {
+				m.WalletOwnerFunc = func(context.Context, string) (int64, error) { return 0, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "balance/wallet error", method: "GET", path: wallet + "/balance",
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletFunc = func(context.Context, string) (repo.Wallet, error) { return repo.Wallet{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal server error"},
+		{name: "stats/not found", method: "GET", path: wallet + "/stats",
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletStatsFunc = func(context.Context, string) (repo.WalletStats, error) {
+					return repo.WalletStats{}, repo.ErrWalletNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "stats/error", method: "GET", path: wallet + "/stats",
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletStatsFunc = func(context.Context, string) (repo.WalletStats, error) { return repo.WalletStats{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "counterparties/invalid sort", method: "GET", path: wallet + "/counterparties?sort=x",
+			setup: func(m *repomock.RepoMock) {
+				m.ListCounterpartiesFunc = func(context.Context, string, repo.CounterpartyQuery) ([]repo.Counterparty, error) {
+					return nil, repo.ErrInvalidSort
+				}
+			},
+			status: http.StatusBadRequest, error: "invalid sort"},
+		{name: "counterparties/error", method: "GET", path: wallet + "/counterparties",
+			setup: func(m *repomock.RepoMock) {
+				m.ListCounterpartiesFunc = func(context.Context, string, repo.CounterpartyQuery) ([]repo.Counterparty, error) {
+					return nil, errBoom
+				}
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+
+		{name: "payee add/exists", method: "POST", path: wallet + "/payees", body: `{"alias":"bob","address":"` + to + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.AddPayeeFunc = func(context.Context, string, string, string) (repo.Payee, error) {
+					return repo.Payee{}, repo.ErrPayeeExists
+				}
+			},
+			status: http.StatusConflict, error: "payee alias already exists"},
+		{name: "payee add/wallet not found", method: "POST", path: wallet + "/payees", body: `{"alias":"bob","address":"` + to + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.AddPayeeFunc = func(context.Context, string, string, string) (repo.Payee, error) {
+					return repo.Payee{}, repo.ErrWalletNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "payee add/error", method: "POST", path: wallet + "/payees", body: `{"alias":"bob","address":"` + to + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.AddPayeeFunc = func(context.Context, string, string, string) (repo.Payee, error) { return repo.Payee{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "payee delete/not found", method: "DELETE", path: wallet + "/payees/bob",
+			setup: func(m *repomock.RepoMock) {
+				m.DeletePayeeFunc = func(context.Context, string, string) error { return repo.ErrPayeeNotFound }
+			},
+			status: http.StatusNotFound, error: "payee not found"},
+		{name: "payee delete/error", method: "DELETE", path: wallet + "/payees/bob",
+			setup: func(m *repomock.RepoMock) {
+				m.DeletePayeeFunc = func(context.Context, string, string) error { return errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "send/payee not found", method: "POST", path: "/api/send", body: `{"from":"` + from + `","to_alias":"bob","amount":1}`,
+			setup: func(m *repomock.RepoMock) {
+				m.ResolvePayeeFunc = func(context.Context, string, string) (string, error) { return "", repo.ErrPayeeNotFound }
+			},
+			status: http.StatusNotFound, error: "payee not found"},
+
+		{name: "transaction/not found", method: "GET", path: "/api/transactions/1",
+			setup: func(m *repomock.RepoMock) {
+				m.GetTransactionFunc = func(context.Context, int64, repo.TxVisibility) (repo.Transaction, error) {
+					return repo.Transaction{}, repo.ErrTransactionNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "transaction not found"},
+		{name: "transaction/error", method: "GET", path: "/api/transactions/1",
+			setup: func(m *repomock.RepoMock) {
+				m.GetTransactionFunc = func(context.Context, int64, repo.TxVisibility) (repo.Transaction, error) {
+					return repo.Transaction{}, errBoom
+				}
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "balance/wallet closed", method: "GET", path: wallet + "/balance",
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletFunc = func(context.Context, string) (repo.Wallet, error) {
+					return repo.Wallet{Address: from, Successor: to, ClosedAt: time.Now()}, nil
+				}
+			},
+			status: http.StatusGone, error: "wallet closed"},
+		{name: "wallet/closed", method: "GET", path: wallet,
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletFunc = func(context.Context, string) (repo.Wallet, error) {
+					return repo.Wallet{Address: from, Successor: to, ClosedAt: time.Now()}, nil
+				}
+			},
+			status: http.StatusGone, error: "wallet closed"},
+		{name: "merge/refused", method: "POST", path: "/api/admin/wallet/" + from + "/merge", admin: true, body: `{"into":"` + to + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.MergeWalletFunc = func(context.Context, string, string, string) (repo.WalletMerge, error) {
+					return repo.WalletMerge{}, &repo.MergeRefusedError{Address: from, Reason: repo.MergeReasonHot}
+				}
+			},
+			status: http.StatusConflict, error: "wallet cannot be merged"},
+		{name: "merge/into closed", method: "POST", path: "/api/admin/wallet/" + from + "/merge", admin: true, body: `{"into":"` + to + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.MergeWalletFunc = func(context.Context, string, string, string) (repo.WalletMerge, error) {
+					return repo.WalletMerge{}, &repo.WalletClosedError{Address: to, Successor: from}
+				}
+			},
+			status: http.StatusConflict, error: "wallet closed"},
+		{name: "merge/same address", method: "POST", path: "/api/admin/wallet/" + from + "/merge", admin: true, body: `{"into":"` + from + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.MergeWalletFunc = func(context.Context, string, string, string) (repo.WalletMerge, error) {
+					return repo.WalletMerge{}, repo.ErrSameAddress
+				}
+			},
+			status: http.StatusBadRequest, error: "into must differ from the merged wallet"},
+		{name: "derived/collision", method: "POST", path: "/api/admin/wallets/derived", admin: true, body: `{"label":"shop"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.CreateDerivedWalletFunc = func(_ context.Context, addr, label, _ string) (repo.Wallet, bool, error) {
+					return repo.Wallet{}, false, fmt.Errorf("derive wallet: %w", &repo.AddressCollisionError{Label: label, Address: addr, Reason: repo.CollisionAddressTaken})
+				}
+			},
+			status: http.StatusConflict, error: "address collision"},
+		{name: "derived/error", method: "POST", path: "/api/admin/wallets/derived", admin: true, body: `{"label":"shop"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.CreateDerivedWalletFunc = func(context.Context, string, string, string) (repo.Wallet, bool, error) {
+					return repo.Wallet{}, false, errBoom
+				}
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "derived/invalid label", method: "POST", path: "/api/admin/wallets/derived", admin: true, body: `{"label":"a b"}`,
+			setup:  func(*repomock.RepoMock) {},
+			status: http.StatusBadRequest, error: "invalid label"},
+		{name: "merge/not found", method: "POST", path: "/api/admin/wallet/" + from + "/merge", admin: true, body: `{"into":"` + to + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.MergeWalletFunc = func(context.Context, string, string, string) (repo.WalletMerge, error) {
+					return repo.WalletMerge{}, &repo.WalletNotFoundError{Address: to}
+				}
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "ledger check/error", method: "GET", path: "/api/admin/invariants/ledger", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.CompareLedgerFunc = func(context.Context) (repo.LedgerComparison, error) { return repo.LedgerComparison{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "ledger reset/error", method: "DELETE", path: "/api/admin/ledger", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.ResetLedgerFunc = func(context.Context) (int64, error) { return 0, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "transaction trace/not found", method: "GET", path: "/api/admin/transactions/1/trace", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.TraceTransactionFunc = func(context.Context, int64) (repo.TransferTrace, error) {
+					return repo.TransferTrace{}, repo.ErrTransactionNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "transaction not found"},
+		{name: "transaction trace/invalid id", method: "GET", path: "/api/admin/transactions/x/trace", admin: true,
+			setup:  func(*repomock.RepoMock) {},
+			status: http.StatusBadRequest, error: "invalid id"},
+		{name: "transaction trace/admin only", method: "GET", path: "/api/admin/transactions/1/trace",
+			setup:  func(*repomock.RepoMock) {},
+			status: http.StatusUnauthorized, error: "unauthorized"},
+		{name: "transactions/invalid cursor", method: "GET", path: "/api/transactions?cursor=x",
+			setup: func(m *repomock.RepoMock) {
+				m.LastTransactionFunc = func(context.Context) (int64, time.Time, error) { return 0, time.Time{}, nil }
+				m.ListTransactionsFunc = func(context.Context, repo.ListOptions) ([]repo.Transaction, error) { return nil, repo.ErrInvalidCursor }
+			},
+			status: http.StatusBadRequest, error: "invalid cursor"},
+
+		{name: "register/exists", method: "POST", path: "/api/users", body: `{"email":"a@example.com","name":"A"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.RegisterUserFunc = func(context.Context, string, string, string, string) (repo.User, error) {
+					return repo.User{}, repo.ErrUserExists
+				}
+			},
+			status: http.StatusConflict, error: "user already exists"},
+		{name: "register/error", method: "POST", path: "/api/users", body: `{"email":"a@example.com","name":"A"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.RegisterUserFunc = func(context.Context, string, string, string, string) (repo.User, error) { return repo.User{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "key lookup/error", method: "GET", path: "/api/me", user: true,
+			setup: func(m *repomock.RepoMock) {
+				m.LookupAPIKeyFunc = func(context.Context, string) (repo.APIKey, error) { return repo.APIKey{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "key lookup/not found", method: "GET", path: "/api/me", user: true,
+			setup: func(m *repomock.RepoMock) {
+				m.LookupAPIKeyFunc = func(context.Context, string) (repo.APIKey, error) { return repo.APIKey{}, repo.ErrAPIKeyNotFound }
+			},
+			status: http.StatusUnauthorized, error: "unauthorized"},
+		{name: "me/not found", method: "GET", path: "/api/me", user: true,
+			setup: func(m *repomock.RepoMock) {
+				m.GetUserFunc = func(context.Context, int64) (repo.User, error) { return repo.User{}, repo.ErrUserNotFound }
+			},
+			status: http.StatusNotFound, error: "user not found"},
+		{name: "me/error", method: "GET", path: "/api/me", user: true,
+			setup: func(m *repomock.RepoMock) {
+				m.GetUserFunc = func(context.Context, int64) (repo.User, error) { return repo.User{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "wallet create/exists", method: "POST", path: "/api/wallets", user: true, body: `{"address":"` + from + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.CreateWalletFunc = func(context.Context, int64, string) (repo.Wallet, error) { return repo.Wallet{}, repo.ErrWalletExists }
+			},
+			status: http.StatusConflict, error: "wallet already exists"},
+		{name: "wallet create/error", method: "POST", path: "/api/wallets", user: true,
+			setup: func(m *repomock.RepoMock) {
+				m.CreateWalletFunc = func(context.Context, int64, string) (repo.Wallet, error) { return repo.Wallet{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "wallet get or create/error", method: "POST", path: "/api/wallets", user: true, body: `{"address":"` + from + `","mode":"get_or_create"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.GetOrCreateWalletFunc = func(context.Context, int64, string) (repo.Wallet, bool, error) { return repo.Wallet{}, false, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+
+		{name: "payment request/not found", method: "POST", path: "/api/requests/1/decline",
+			setup: func(m *repomock.RepoMock) {
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{}, repo.ErrPaymentRequestNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "payment request not found"},
+		{name: "payment request/not payer", method: "POST", path: "/api/requests/1/decline",
+			setup: func(m *repomock.RepoMock) {
+				m.WalletOwnerFunc = func(context.Context, string) (int64, error) { return mockUserID + 1, nil }
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{ID: 1, Payer: from}, nil
+				}
+			},
+			status: http.StatusNotFound, error: "payment request not found"},
+		{name: "payment request/resolved", method: "POST", path: "/api/requests/1/decline",
+			setup: func(m *repomock.RepoMock) {
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{ID: 1, Payer: from}, nil
+				}
+				m.DeclinePaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{}, repo.ErrPaymentRequestResolved
+				}
+			},
+			status: http.StatusConflict, error: "payment request already resolved"},
+		{name: "payment request/expired", method: "POST", path: "/api/requests/1/decline",
+			setup: func(m *repomock.RepoMock) {
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{ID: 1, Payer: from}, nil
+				}
+				m.DeclinePaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{}, repo.ErrPaymentRequestExpired
+				}
+			},
+			status: http.StatusGone, error: "payment request expired"},
+		{name: "payment request get/invalid id", method: "GET", path: "/api/requests/x",
+			setup:  func(m *repomock.RepoMock) {},
+			status: http.StatusBadRequest, error: "invalid id"},
+		{name: "payment request get/not found", method: "GET", path: "/api/requests/1",
+			setup: func(m *repomock.RepoMock) {
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{}, repo.ErrPaymentRequestNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "payment request not found"},
+		{name: "payment request get/foreign", method: "GET", path: "/api/requests/1",
+			setup: func(m *repomock.RepoMock) {
+				m.WalletOwnerFunc = func(context.Context, string) (int64, error) { return mockUserID + 1, nil }
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+					return repo.PaymentRequest{ID: 1, Payee: to, Payer: from}, nil
+				}
+			},
+			status: http.StatusNotFound, error: "payment request not found"},
+		{name: "payment request get/error", method: "GET", path: "/api/requests/1",
+			setup: func(m *repomock.RepoMock) {
+				m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) { return repo.PaymentRequest{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "wallet get/not found", method: "GET", path: wallet,
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletFunc = func(context.Context, string) (repo.Wallet, error) { return repo.Wallet{}, repo.ErrWalletNotFound }
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "wallet get/error", method: "GET", path: wallet,
+			setup: func(m *repomock.RepoMock) {
+				m.GetWalletFunc = func(context.Context, string) (repo.Wallet, error) { return repo.Wallet{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+
+		{name: "standing order/not found", method: "POST", path: "/api/standing-orders/1/pause",
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{}, repo.ErrStandingOrderNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "standing order not found"},
+		{name: "standing order/state", method: "POST", path: "/api/standing-orders/1/pause",
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from}, nil
+				}
+				m.PauseStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{}, repo.ErrStandingOrderState
+				}
+			},
+			status: http.StatusConflict, error: "standing order state does not allow this"},
+		{name: "standing order/error", method: "POST", path: "/api/standing-orders/1/cancel",
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from}, nil
+				}
+				m.CancelStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) { return repo.StandingOrder{}, errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "standing order patch/no if-match", method: "PATCH", path: "/api/standing-orders/1", body: `{"amount":1}`,
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from, Version: 1}, nil
+				}
+			},
+			status: http.StatusPreconditionRequired, error: "If-Match required, use the ETag of the resource"},
+		{name: "standing order patch/malformed if-match", method: "PATCH", path: "/api/standing-orders/1", body: `{"amount":1}`, ifMatch: `W/"v1"`,
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from, Version: 1}, nil
+				}
+			},
+			status: http.StatusPreconditionFailed, error: "resource was modified"},
+		{name: "standing order patch/stale", method: "PATCH", path: "/api/standing-orders/1", body: `{"at":"10:00"}`, ifMatch: `"v1"`,
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from, Schedule: "daily", At: "09:00", Timezone: "UTC", FailurePolicy: repo.StandingOrderSkip, Version: 2}, nil
+				}
+				m.UpdateStandingOrderFunc = func(context.Context, int64, int64, repo.StandingOrderPatch) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, Version: 2}, repo.ErrVersionMismatch
+				}
+			},
+			status: http.StatusPreconditionFailed, error: "resource was modified"},
+		{name: "standing order patch/cancelled", method: "PATCH", path: "/api/standing-orders/1", body: `{"failure_policy":"skip"}`, ifMatch: "*",
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from, FailurePolicy: repo.StandingOrderSkip, Version: 3}, nil
+				}
+				m.UpdateStandingOrderFunc = func(context.Context, int64, int64, repo.StandingOrderPatch) (repo.StandingOrder, error) {
+					return repo.StandingOrder{}, repo.ErrStandingOrderState
+				}
+			},
+			status: http.StatusConflict, error: "standing order state does not allow this"},
+		{name: "standing order patch/skip with retries", method: "PATCH", path: "/api/standing-orders/1", body: `{"max_retries":2}`, ifMatch: `"v1"`,
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from, FailurePolicy: repo.StandingOrderSkip, Version: 1}, nil
+				}
+			},
+			status: http.StatusBadRequest, error: "max_retries requires failure_policy retry"},
+		{name: "wallet patch/not found", method: "PATCH", path: wallet, body: `{"low_balance_threshold":1}`, ifMatch: "*",
+			setup: func(m *repomock.RepoMock) {
+				m.UpdateWalletMetaFunc = func(context.Context, string, int64, repo.WalletMetaPatch, string) (repo.Wallet, error) {
+					return repo.Wallet{}, repo.ErrWalletNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "wallet patch/stale", method: "PATCH", path: wallet, body: `{"low_balance_threshold":1}`, ifMatch: `"v1"`,
+			setup: func(m *repomock.RepoMock) {
+				m.UpdateWalletMetaFunc = func(context.Context, string, int64, repo.WalletMetaPatch, string) (repo.Wallet, error) {
+					return repo.Wallet{MetaVersion: 2}, repo.ErrVersionMismatch
+				}
+			},
+			status: http.StatusPreconditionFailed, error: "resource was modified"},
+
+		{name: "standing order delete/deleted", method: "DELETE", path: "/api/standing-orders/1",
+			setup: func(m *repomock.RepoMock) {
+				m.GetStandingOrderFunc = func(context.Context, int64) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1, From: from, DeletedAt: time.Now()}, nil
+				}
+			},
+			status: http.StatusNotFound, error: "standing order not found"},
+		{name: "standing order restore/not deleted", method: "POST", path: "/api/admin/standing-orders/1/restore", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.RestoreStandingOrderFunc = func(context.Context, int64, string) (repo.StandingOrder, error) {
+					return repo.StandingOrder{ID: 1}, repo.ErrStandingOrderState
+				}
+			},
+			status: http.StatusConflict, error: "standing order state does not allow this"},
+		{name: "standing orders/deleted not admin", method: "GET", path: wallet + "/standing-orders?deleted=true",
+			setup:  func(m *repomock.RepoMock) {},
+			status: http.StatusForbidden, error: "deleted items are visible to admin only"},
+		{name: "payees/deleted not admin", method: "GET", path: wallet + "/payees?deleted=true",
+			setup:  func(m *repomock.RepoMock) {},
+			status: http.StatusForbidden, error: "deleted items are visible to admin only"},
+		{name: "payee restore/not found", method: "POST", path: "/api/admin/wallet/" + from + "/payees/rent/restore", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.RestorePayeeFunc = func(context.Context, string, string, string) (repo.Payee, error) {
+					return repo.Payee{}, repo.ErrPayeeNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "deleted payee not found"},
+		{name: "payee restore/alias taken", method: "POST", path: "/api/admin/wallet/" + from + "/payees/rent/restore", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.RestorePayeeFunc = func(context.Context, string, string, string) (repo.Payee, error) {
+					return repo.Payee{}, repo.ErrPayeeExists
+				}
+			},
+			status: http.StatusConflict, error: "payee alias already exists"},
+
+		{name: "activity/unknown type", method: "GET", path: wallet + "/activity?types=transfer,webhook",
+			setup:  func(m *repomock.RepoMock) {},
+			status: http.StatusBadRequest, error: "unknown activity type"},
+		{name: "activity/invalid cursor", method: "GET", path: wallet + "/activity?cursor=x",
+			setup: func(m *repomock.RepoMock) {
+				m.WalletActivityFunc = func(context.Context, string, repo.ActivityQuery) ([]repo.ActivityItem, error) {
+					return nil, repo.ErrInvalidCursor
+				}
+			},
+			status: http.StatusBadRequest, error: "invalid cursor"},
+
+		{name: "denylist remove/not found", method: "DELETE", path: "/api/admin/denylist/" + from, admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.RemoveFromDenylistFunc = func(context.Context, string, string) error { return repo.ErrDenylistEntryNotFound }
+			},
+			status: http.StatusNotFound, error: "denylist entry not found"},
+		{name: "denylist add/error", method: "POST", path: "/api/admin/denylist", admin: true, body: `{"address":"` + from + `"}`,
+			setup: func(m *repomock.RepoMock) {
+				m.AddToDenylistFunc = func(context.Context, string, string, string) error { return errBoom }
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+		{name: "overdraft/in use", method: "PUT", path: "/api/admin/wallet/" + from + "/overdraft", admin: true, body: `{"limit":1}`,
+			setup: func(m *repomock.RepoMock) {
+				m.SetOverdraftLimitFunc = func(context.Context, string, int64, string) error { return repo.ErrOverdraftInUse }
+			},
+			status: http.StatusConflict, error: "balance below overdraft limit"},
+		{name: "overdraft/not found", method: "PUT", path: "/api/admin/wallet/" + from + "/overdraft", admin: true, body: `{"limit":1}`,
+			setup: func(m *repomock.RepoMock) {
+				m.SetOverdraftLimitFunc = func(context.Context, string, int64, string) error { return repo.ErrWalletNotFound }
+			},
+			status: http.StatusNotFound, error: "wallet not found"},
+		{name: "settlement/not found", method: "GET", path: "/api/admin/reports/settlement/2024-01-02", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.GetSettlementFunc = func(context.Context, string) (repo.SettlementRun, []repo.SettlementLine, error) {
+					return repo.SettlementRun{}, nil, repo.ErrSettlementNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "settlement not found"},
+		{name: "settlement/already settled", method: "POST", path: "/api/admin/reports/settlement/2024-01-02", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.SettleFunc = func(context.Context, string, *time.Location) (repo.SettlementRun, error) {
+					return repo.SettlementRun{}, repo.ErrAlreadySettled
+				}
+			},
+			status: http.StatusConflict, error: "business date already settled"},
+		{name: "sweep/not found", method: "GET", path: "/api/admin/sweeps/1", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.GetSweepFunc = func(context.Context, int64) (repo.Sweep, []repo.SweepWallet, error) {
+					return repo.Sweep{}, nil, repo.ErrSweepNotFound
+				}
+			},
+			status: http.StatusNotFound, error: "sweep not found"},
+		{name: "sweep resume/done", method: "POST", path: "/api/admin/sweeps/1/resume", admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.ResumeSweepFunc = func(context.Context, int64) (repo.Sweep, error) { return repo.Sweep{}, repo.ErrSweepDone }
+			},
+			status: http.StatusConflict, error: "sweep already done"},
+		{name: "explain/unknown query", method: "GET", path: "/api/admin/explain?query=x&address=" + from, admin: true,
+			setup: func(m *repomock.RepoMock) {
+				m.ExplainFunc = func(context.Context, string, repo.ExplainParams) (repo.QueryPlan, error) {
+					return repo.QueryPlan{}, repo.ErrUnknownQuery
+				}
+			},
+			status: http.StatusBadRequest, error: "unknown query"},
+		{name: "send quote/internal", method: "POST", path: "/api/send/quote", body: `{"from":"` + from + `","to":"` + to + `","amount":1}`,
+			setup: func(m *repomock.RepoMock) {
+				m.QuoteTransferFunc = func(context.Context, string, string, int64) (repo.TransferQuote, error) {
+					return repo.TransferQuote{}, errBoom
+				}
+			},
+			status: http.StatusInternalServerError, error: "internal error"},
+	}
+
+	cases = append(cases, transferErrCases("send", "POST", "/api/send", `{"from":"`+from+`","to":"`+to+`","amount":1}`,
+		func(m *repomock.RepoMock, err error) {
+			m.TransferFunc = func(context.Context, string, string, int64) error { return err }
+		})...)
+	cases = append(cases, transferErrCases("accept request", "POST", "/api/requests/1/accept", "",
+		func(m *repomock.RepoMock, err error) {
+			m.GetPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) {
+				return repo.PaymentRequest{ID: 1, Payer: from, Payee: to, AmountCents: 100, Status: repo.PaymentRequestPending}, nil
+			}
+			m.PayPaymentRequestFunc = func(context.Context, int64) (repo.PaymentRequest, error) { return repo.PaymentRequest{}, err }
+		})...)
+	return cases
+}
+
+// TestErrorMapping, ошибки репозитория превращаются в коды и тексты ответов без базы, каждая ветка маппинга проверяется отдельно
+func TestErrorMapping(t *testing.T) {
+	for _, tc := range errCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockRepo()
+			tc.setup(m)
+			r := chi.NewRouter()
+			(&API{Repo: m, AdminToken: testAdminToken, Addresses: address.NewDeriver("errmap", "")}).Routes(r)
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.user {
+				req.Header.Set("Authorization", "Bearer wk_mock")
+			}
+			if tc.admin {
+				req.Header.Set("X-Admin-Token", testAdminToken)
+			}
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Fatalf("status %d, want %d, body %s", rr.Code, tc.status, rr.Body.String())
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Error != tc.error {
+				t.Fatalf("error %q, want %q", body.Error, tc.error)
+			}
+		})
+	}
+}
+
+// TestSendAddressChecksum, адреса перевода приходят в репозиторий в нижнем регистре, смешанный регистр с неверной контрольной суммой дает 400,
+// в строгом режиме адрес без контрольной суммы тоже отклоняется
+func TestSendAddressChecksum(t *testing.T) {
+	from, to := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	sum := address.Checksum(to)
+	typo := strings.ToLower(sum[:1]) + sum[1:]
+	if typo == sum {
+		typo = strings.ToUpper(sum[:1]) + sum[1:]
+	}
+
+	for _, tc := range []struct {
+		name   string
+		strict bool
+		to     string
+		status int
+		error  string
+	}{
+		{"lowercase", false, to, http.StatusOK, ""},
+		{"uppercase", false, strings.ToUpper(to), http.StatusOK, ""},
+		{"checksum", false, sum, http.StatusOK, ""},
+		{"typo", false, typo, http.StatusBadRequest, "invalid address checksum"},
+		{"format", false, to[:63], http.StatusBadRequest, "invalid address format"},
+		{"strict/checksum", true, sum, http.StatusOK, ""},
+		{"strict/lowercase", true, to, http.StatusBadRequest, "invalid address checksum, use the checksummed address"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotTo string
+			m := newMockRepo()
+			m.TransferFunc = func(_ context.Context, _, to string, _ int64) error {
+				gotTo = to
+				return nil
+			}
+			r := chi.NewRouter()
+			(&API{Repo: m, AdminToken: testAdminToken, AddressChecksumStrict: tc.strict}).Routes(r)
+
+			// отправитель в нижнем регистре, в строгом режиме с контрольной суммой
+			src := from
+			if tc.strict {
+				src = address.Checksum(from)
+			}
+			req := httptest.NewRequest("POST", "/api/send", strings.NewReader(`{"from":"`+src+`","to":"`+tc.to+`","amount":1}`))
+			req.Header.Set("Authorization", "Bearer wk_mock")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Fatalf("status %d, want %d, body %s", rr.Code, tc.status, rr.Body.String())
+			}
+			if tc.status == http.StatusOK {
+				if gotTo != to {
+					t.Fatalf("transfer to %q, want %q", gotTo, to)
+				}
+				return
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if body.Error != tc.error {
+				t.Fatalf("error %q, want %q", body.Error, tc.error)
+			}
+		})
+	}
+}
+
+// TestLedgerResetWritesOn, пока двойная запись включена, сброс счетов отклоняется и до репозитория не доходит
+func TestLedgerResetWritesOn(t *testing.T) {
+	repo.SetLedgerMode(repo.LedgerDual)
+	defer repo.SetLedgerMode(repo.LedgerOff)
+
+	m := newMockRepo()
+	m.ResetLedgerFunc = func(context.Context) (int64, error) {
+		t.Fatal("reset reached the repo")
+		return 0, nil
+	}
+	r := chi.NewRouter()
+	(&API{Repo: m, AdminToken: testAdminToken}).Routes(r)
+
+	req := httptest.NewRequest("DELETE", "/api/admin/ledger", nil)
+	req.Header.Set("X-Admin-Token", testAdminToken)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("status %d, want 409, body %s", rr.Code, rr.Body.String())
+	}
+}
+
+// TestInsufficientFundsDetails, отказ по средствам отдает в 409 адрес, нужную и доступную сумму и нехватку, в пакете еще номер перевода
+func TestInsufficientFundsDetails(t *testing.T) {
+	from, to := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	short := &repo.InsufficientFundsError{Address: from, NeededCents: 1000, AvailableCents: 750}
+
+	m := newMockRepo()
+	m.TransferFunc = func(context.Context, string, string, int64) error {
+		return fmt.Errorf("transfer %s->%s: %w", from, to, short)
+	}
+	m.TransferBatchFunc = func(context.Context, []repo.TransferItem, repo.BatchMode) ([]error, error) {
+		return nil, fmt.Errorf("transfer batch of 2: %w", &repo.BatchItemError{Index: 1, Err: short})
+	}
+	r := chi.NewRouter()
+	(&API{Repo: m, AdminToken: testAdminToken}).Routes(r)
+
+	item := `{"from":"` + from + `","to":"` + to + `","amount":10}`
+	for _, tc := range []struct {
+		path, body string
+		item       bool
+	}{
+		{"/api/send", item, false},
+		{"/api/send/batch", `{"mode":"atomic","items":[` + item + `,` + item + `]}`, true},
+	} {
+		req := httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body))
+		req.Header.Set("Authorization", "Bearer wk_mock")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("%s: status %d, body %s", tc.path, rr.Code, rr.Body.String())
+		}
+		var body map[string]any
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: decode body: %v", tc.path, err)
+		}
+		want := map[string]any{"error": "insufficient funds", "address": from, "needed": "10.00", "available": "7.50", "shortfall": "2.50"}
+		if tc.item {
+			want["item"] = float64(1)
+		}
+		if !reflect.DeepEqual(body, want) {
+			t.Fatalf("%s: body %v, want %v", tc.path, body, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"gotechtask/internal/repo"
+)
+
+// queryPlanDTO, план одного запроса приложения
+type queryPlanDTO struct {
+	Name string `json:"name"`
+	SQL  string `json:"sql"`
+	Plan string `json:"plan"`
+}
+
+// getExplain, планы запросов приложения с фактическим временем на живой базе, address, кошелек для параметров, to, получатель выборки перевода,
+// query, только один запрос из balance, listing, transfer, без него все по порядку
+func (a *API) getExplain(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	p := repo.ExplainParams{Address: q.Get("address"), To: q.Get("to")}
+	if len(p.Address) != 64 || (p.To != "" && len(p.To) != 64) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	names := repo.ExplainQueries
+	if name := q.Get("query"); name != "" {
+		names = []string{name}
+	}
+
+	out := make([]queryPlanDTO, 0, len(names))
+	for _, name := range names {
+		plan, err := a.Repo.Explain(r.Context(), name, p)
+		if errors.Is(err, repo.ErrUnknownQuery) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unknown query"})
+			return
+		}
+		if err != nil {
+			log.Printf("explain %s: %v", name, err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		out = append(out, queryPlanDTO{Name: plan.Name, SQL: plan.SQL, Plan: plan.Plan})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"plans": out})
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// exportWriter, ставит заголовки файла перед первой строкой выгрузки, пока ничего не записано, ошибку еще можно отдать ответом json
+type exportWriter struct {
+	w       http.ResponseWriter
+	name    string
+	started bool
+}
+
+func (e *exportWriter) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.w.Header().Set("Content-Type", "text/csv")
+		e.w.Header().Set("Content-Disposition", `attachment; filename="`+e.name+`"`)
+	}
+	return e.w.Write(p)
+}
+
+// getTransactionExport, выгрузка всех транзакций под фильтрами списка транзакций файлом csv по возрастанию id, без страниц и предела числа строк,
+// строки идут из базы потоком, ошибка посреди выгрузки обрывает соединение, чтобы неполный файл не приняли за целый
+func (a *API) getTransactionExport(w http.ResponseWriter, r *http.Request) {
+	opts := repo.ListOptions{Visibility: repo.TxVisibility{All: true}}
+	if bad := a.txFilters(r.URL.Query(), &opts); bad != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + bad})
+		return
+	}
+
+	ew := &exportWriter{w: w, name: "transactions-" + time.Now().UTC().Format("20060102T150405Z") + ".csv"}
+	n, err := a.Repo.ExportTransactions(r.Context(), opts, ew)
+	if err == nil {
+		return
+	}
+	if !ew.started {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if r.Context().Err() == nil {
+		log.Printf("export transactions: after %d rows: %v", n, err)
+	}
+	panic(http.ErrAbortHandler)
+}
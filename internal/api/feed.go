@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// параметры живой ленты, пауза переподключения слушателя, окно пачки уведомлений, очередь подписчика, период пустых сообщений против обрыва прокси, предел догона после переподключения
+const (
+	feedReconnect  = 3 * time.Second
+	feedBatch      = 100 * time.Millisecond
+	feedBuffer     = 256
+	feedHeartbeat  = 15 * time.Second
+	feedReplayMax  = 500
+	feedRetryDelay = 3000
+)
+
+// Feed, рассылка новых транзакций подписчикам потока, один слушатель уведомлений базы на процесс, транзакции читаются пачками и только при наличии подписчиков
+type Feed struct {
+	src repo.TransactionReader
+
+	mu   sync.Mutex
+	subs map[chan repo.Transaction]struct{}
+}
+
+// NewFeed, конструктор ленты, слушать начинает Run
+func NewFeed(src repo.TransactionReader) *Feed {
+	return &Feed{src: src, subs: make(map[chan repo.Transaction]struct{})}
+}
+
+// Run, слушает уведомления до отмены контекста, после обрыва переподключается с паузой, пропущенное за это время подписчики догоняют по Last-Event-ID
+func (f *Feed) Run(ctx context.Context) {
+	ids := make(chan int64, feedBuffer)
+	go f.deliver(ctx, ids)
+
+	for {
+		err := f.src.ListenTransactions(ctx, func(id int64) {
+			select {
+			case ids <- id:
+			case <-ctx.Done():
+			}
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("feed: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(feedReconnect):
+		}
+	}
+}
+
+// deliver, собирает id за окно feedBatch, читает транзакции одним запросом и рассылает
+func (f *Feed) deliver(ctx context.Context, ids <-chan int64) {
+	t := time.NewTicker(feedBatch)
+	defer t.Stop()
+
+	var batch []int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-ids:
+			batch = append(batch, id)
+		case <-t.C:
+			if len(batch) == 0 {
+				continue
+			}
+			if f.subscribers() > 0 {
+				txs, err := f.src.TransactionsByIDs(ctx, batch)
+				if err != nil {
+					log.Printf("feed: %v", err)
+				}
+				for _, tx := range txs {
+					f.broadcast(tx)
+				}
+			}
+			batch = batch[:0]
+		}
+	}
+}
+
+// subscribe, новый подписчик, канал закрывается при отписке или если подписчик не успевает читать
+func (f *Feed) subscribe() (<-chan repo.Transaction, func()) {
+	ch := make(chan repo.Transaction, feedBuffer)
+	f.mu.Lock()
+	f.subs[ch] = struct{}{}
+	f.mu.Unlock()
+	return ch, func() { f.drop(ch) }
+}
+
+// drop, убирает подписчика и закрывает его канал, повторный вызов ничего не делает
+func (f *Feed) drop(ch chan repo.Transaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[ch]; ok {
+		delete(f.subs, ch)
+		close(ch)
+	}
+}
+
+// subscribers, число подписчиков
+func (f *Feed) subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+// broadcast, отдает транзакцию всем подписчикам, отставший с полной очередью отключается и догонит после переподключения
+func (f *Feed) broadcast(tx repo.Transaction) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for ch := range f.subs {
+		select {
+		case ch <- tx:
+		default:
+			delete(f.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// feedFilter, фильтры потока, пустые не ограничивают
+type feedFilter struct {
+	address string
+	types   map[string]bool
+}
+
+func (ff feedFilter) match(t repo.Transaction) bool {
+	if ff.address != "" && t.FromAddress != ff.address && t.ToAddress != ff.address {
+		return false
+	}
+	return len(ff.types) == 0 || ff.types[t.Type]
+}
+
+// getTransactionStream, поток новых транзакций в формате server-sent events, событие transaction с id транзакции и телом как в списке, фильтры address и type через запятую, после переподключения с Last-Event-ID или after сначала догоняются пропущенные, не больше 500
+func (a *API) getTransactionStream(w http.ResponseWriter, r *http.Request) {
+	if a.Feed == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "live feed disabled"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "streaming unsupported"})
+		return
+	}
+
+	qs := r.URL.Query()
+	ff := feedFilter{address: qs.Get("address")}
+	if v := qs.Get("type"); v != "" {
+		ff.types = map[string]bool{}
+		for _, t := range strings.Split(v, ",") {
+			if !repo.ValidTxType(t) {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid type"})
+				return
+			}
+			ff.types[t] = true
+		}
+	}
+	var last int64
+	if v := r.Header.Get("Last-Event-ID"); v != "" || qs.Get("after") != "" {
+		if v == "" {
+			v = qs.Get("after")
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid after"})
+			return
+		}
+		last = n
+	}
+
+	// подписка до догона, иначе транзакции между ними потеряются, повторы отсекаются по last
+	ch, cancel := a.Feed.subscribe()
+	defer cancel()
+
+	ctx := r.Context()
+	var replay []repo.Transaction
+	if last > 0 {
+		var err error
+		if replay, err = a.Repo.TransactionsAfter(ctx, last, feedReplayMax); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", feedRetryDelay)
+
+	send := func(t repo.Transaction) error {
+		if t.ID <= last {
+			return nil
+		}
+		last = t.ID
+		if !ff.match(t) {
+			return nil
+		}
+		body, _ := json.Marshal(toTxDTO(t))
+		_, err := fmt.Fprintf(w, "id: %d\nevent: transaction\ndata: %s\n\n", t.ID, body)
+		return err
+	}
+	for _, t := range replay {
+		if err := send(t); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(feedHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t, ok := <-ch:
+			if !ok {
+				// не успевали читать, клиент переподключится и догонит
+				return
+			}
+			if err := send(t); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
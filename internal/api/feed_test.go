@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// fakeFeedSource, источник уведомлений без базы, id из канала, транзакции из карты
+type fakeFeedSource struct {
+	repo.TransactionReader
+	ids chan int64
+	txs map[int64]repo.Transaction
+}
+
+func (f *fakeFeedSource) ListenTransactions(ctx context.Context, fn func(id int64)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case id := <-f.ids:
+			fn(id)
+		}
+	}
+}
+
+func (f *fakeFeedSource) TransactionsByIDs(ctx context.Context, ids []int64) ([]repo.Transaction, error) {
+	var out []repo.Transaction
+	for _, id := range ids {
+		if t, ok := f.txs[id]; ok {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// TestTransactionStream, поток отдает новые транзакции событиями с id, фильтр по виду отсекает лишние
+func TestTransactionStream(t *testing.T) {
+	src := &fakeFeedSource{ids: make(chan int64), txs: map[int64]repo.Transaction{
+		1: {ID: 1, FromAddress: "a", ToAddress: "b", AmountCents: 150, Type: repo.TxTypeFee},
+		2: {ID: 2, FromAddress: "a", ToAddress: "b", AmountCents: 250, Type: repo.TxTypeTransfer},
+	}}
+	feed := NewFeed(src)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go feed.Run(ctx)
+
+	srv := httptest.NewServer(http.HandlerFunc((&API{Feed: feed}).getTransactionStream))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?type=transfer")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("want event stream, got %d %q", resp.StatusCode, ct)
+	}
+
+	for feed.subscribers() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	src.ids <- 1
+	src.ids <- 2
+
+	lines := make(chan string)
+	go func() {
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	var got []string
+	timeout := time.After(5 * time.Second)
+	for len(got) < 3 {
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream closed, got %q", got)
+			}
+			if strings.HasPrefix(l, "id:") || strings.HasPrefix(l, "event:") || strings.HasPrefix(l, "data:") {
+				got = append(got, l)
+			}
+		case <-timeout:
+			t.Fatalf("no event, got %q", got)
+		}
+	}
+	if got[0] != "id: 2" || got[1] != "event: transaction" || !strings.Contains(got[2], `"amount":"2.50"`) {
+		t.Fatalf("unexpected event %q", got)
+	}
+}
+
+// TestTransactionStream_Validation, без ленты 503, неизвестный вид и плохой after дают 400
+func TestTransactionStream_Validation(t *testing.T) {
+	r := chi.NewRouter()
+	(&API{AdminToken: "secret"}).Routes(r)
+	get := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Admin-Token", "secret")
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := get("/api/admin/transactions/stream"); code != http.StatusServiceUnavailable {
+		t.Fatalf("feed disabled: want 503, got %d", code)
+	}
+
+	a := &API{Feed: NewFeed(&fakeFeedSource{})}
+	for _, q := range []string{"type=bogus", "after=x"} {
+		rr := httptest.NewRecorder()
+		a.getTransactionStream(rr, httptest.NewRequest(http.MethodGet, "/?"+q, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: want 400, got %d", q, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,461 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+)
+
+// update, перезаписать эталонные ответы вместо сравнения, go test ./internal/api -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// адреса кошельков эталонного репозитория, общий с деньгами, общий пустой, личный пользователя goldenUserID, несуществующий
+var (
+	goldenFrom    = strings.Repeat("a", 64)
+	goldenTo      = strings.Repeat("b", 64)
+	goldenPrivate = strings.Repeat("d", 64)
+	goldenMissing = strings.Repeat("c", 64)
+)
+
+const (
+	goldenUserID = 7
+	// goldenToken, ключ пользователя goldenUserID, goldenReadToken, его же ключ только на чтение
+	goldenToken     = "wk_golden"
+	goldenReadToken = "wk_golden_read"
+)
+
+// goldenTime, время всех записей эталонного репозитория
+var goldenTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// goldenRepo, репозиторий с постоянными данными без базы, методы, которые эталонные запросы не вызывают, паникуют через nil интерфейс
+type goldenRepo struct {
+	repo.Repo
+}
+
+func (goldenRepo) wallet(addr string) (repo.Wallet, bool) {
+	w := repo.Wallet{Address: addr, CreatedAt: goldenTime, UpdatedAt: goldenTime, MetaVersion: 1}
+	switch addr {
+	case goldenFrom:
+		w.BalanceCents, w.LastTxAt = 123456, goldenTime
+	case goldenTo:
+		w.BalanceCents = 5
+	case goldenPrivate:
+		w.BalanceCents, w.UserID = 100, goldenUserID
+		w.Email, w.LowBalanceCents, w.MetaVersion = "owner@example.com", 5000, 2
+	default:
+		return repo.Wallet{}, false
+	}
+	return w, true
+}
+
+func (g goldenRepo) GetWallet(_ context.Context, addr string) (repo.Wallet, error) {
+	w, ok := g.wallet(addr)
+	if !ok {
+		return repo.Wallet{}, repo.ErrWalletNotFound
+	}
+	return w, nil
+}
+
+func (g goldenRepo) GetBalance(ctx context.Context, addr string) (int64, error) {
+	w, err := g.GetWallet(ctx, addr)
+	return w.BalanceCents, err
+}
+
+func (g goldenRepo) GetWallets(_ context.Context, addrs []string) ([]repo.Wallet, error) {
+	var out []repo.Wallet
+	for _, a := range addrs {
+		if w, ok := g.wallet(a); ok {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+func (g goldenRepo) WalletOwner(ctx context.Context, addr string) (int64, error) {
+	w, err := g.GetWallet(ctx, addr)
+	return w.UserID, err
+}
+
+func (g goldenRepo) Transfer(ctx context.Context, from, to string, cents int64) error {
+	src, err := g.GetWallet(ctx, from)
+	if err != nil {
+		return err
+	}
+	if _, err := g.GetWallet(ctx, to); err != nil {
+		return err
+	}
+	if src.BalanceCents < cents {
+		return fmt.Errorf("transfer %s->%s: %w", from, to, &repo.InsufficientFundsError{Address: from, NeededCents: cents, AvailableCents: src.BalanceCents})
+	}
+	return nil
+}
+
+func (g goldenRepo) QuoteTransfer(ctx context.Context, from, to string, cents int64) (repo.TransferQuote, error) {
+	var q repo.TransferQuote
+	src, err := g.GetWallet(ctx, from)
+	if err != nil {
+		q.Err = err
+		return q, nil
+	}
+	q.FromBalanceCents = src.BalanceCents
+	dst, err := g.GetWallet(ctx, to)
+	if err != nil {
+		q.Err = err
+		return q, nil
+	}
+	q.ToBalanceCents, q.ToFound = dst.BalanceCents, true
+	if src.BalanceCents < cents {
+		q.Err = &repo.InsufficientFundsError{Address: from, NeededCents: cents, AvailableCents: src.BalanceCents}
+	}
+	return q, nil
+}
+
+func (g goldenRepo) TransferBatch(ctx context.Context, items []repo.TransferItem, mode repo.BatchMode) ([]error, error) {
+	errs := make([]error, len(items))
+	for i, it := range items {
+		errs[i] = g.Transfer(ctx, it.From, it.To, it.AmountCents)
+	}
+	return errs, nil
+}
+
+func (g goldenRepo) TransferGroup(ctx context.Context, items []repo.TransferItem) (string, error) {
+	for _, it := range items {
+		if err := g.Transfer(ctx, it.From, it.To, it.AmountCents); err != nil {
+			return "", err
+		}
+	}
+	return "00000000-0000-0000-0000-000000000001", nil
+}
+
+func (goldenRepo) transactions() []repo.Transaction {
+	return []repo.Transaction{
+		{ID: 2, FromAddress: goldenFrom, ToAddress: goldenTo, AmountCents: 1050, CreatedAt: goldenTime, Type: repo.TxTypeTransfer, InitiatedBy: "user:7", Channel: "api"},
+		{ID: 1, FromAddress: goldenTo, ToAddress: goldenFrom, AmountCents: 5, CreatedAt: goldenTime.Add(-time.Hour), Type: repo.TxTypeTransfer},
+	}
+}
+
+func (g goldenRepo) ListTransactions(context.Context, repo.ListOptions) ([]repo.Transaction, error) {
+	return g.transactions(), nil
+}
+
+func (g goldenRepo) CountTransactions(context.Context, repo.ListOptions, int64) (int64, bool, error) {
+	return int64(len(g.transactions())), true, nil
+}
+
+func (g goldenRepo) LastTransaction(context.Context) (int64, time.Time, error) {
+	return 2, goldenTime, nil
+}
+
+func (g goldenRepo) GetTransaction(_ context.Context, id int64, _ repo.TxVisibility) (repo.Transaction, error) {
+	for _, t := range g.transactions() {
+		if t.ID == id {
+			return t, nil
+		}
+	}
+	return repo.Transaction{}, repo.ErrTransactionNotFound
+}
+
+func (g goldenRepo) GetWalletStats(ctx context.Context, addr string) (repo.WalletStats, error) {
+	if _, err := g.GetWallet(ctx, addr); err != nil {
+		return repo.WalletStats{}, err
+	}
+	return repo.WalletStats{Address: addr, SentCents: 1050, ReceivedCents: 5, SentCount: 1, ReceivedCount: 1,
+		FirstTxAt: goldenTime.Add(-time.Hour), LastTxAt: goldenTime}, nil
+}
+
+func (goldenRepo) ListCounterparties(_ context.Context, _ string, q repo.CounterpartyQuery) ([]repo.Counterparty, error) {
+	switch q.SortBy {
+	case "", "volume", "sent", "received", "count":
+	default:
+		return nil, repo.ErrInvalidSort
+	}
+	return []repo.Counterparty{{Address: goldenTo, SentCents: 1050, ReceivedCents: 5, TxCount: 2, LastTxAt: goldenTime}}, nil
+}
+
+func (goldenRepo) ListPayees(context.Context, string) ([]repo.Payee, error) {
+	return []repo.Payee{{Alias: "bob", Address: goldenTo, CreatedAt: goldenTime}}, nil
+}
+
+func (goldenRepo) AddPayee(_ context.Context, _, alias, addr string) (repo.Payee, error) {
+	if alias == "bob" {
+		return repo.Payee{}, repo.ErrPayeeExists
+	}
+	return repo.Payee{Alias: alias, Address: addr, CreatedAt: goldenTime}, nil
+}
+
+func (goldenRepo) DeletePayee(_ context.Context, _, alias string) error {
+	if alias != "bob" {
+		return repo.ErrPayeeNotFound
+	}
+	return nil
+}
+
+func (goldenRepo) LookupAPIKey(_ context.Context, hash string) (repo.APIKey, error) {
+	switch hash {
+	case auth.HashToken(goldenToken):
+		return repo.APIKey{ID: 3, UserID: goldenUserID, Scopes: auth.DefaultScopes}, nil
+	case auth.HashToken(goldenReadToken):
+		return repo.APIKey{ID: 4, UserID: goldenUserID, Scopes: []string{auth.ScopeBalanceRead}}, nil
+	}
+	return repo.APIKey{}, repo.ErrAPIKeyNotFound
+}
+
+func (goldenRepo) GetUser(_ context.Context, id int64) (repo.User, error) {
+	return repo.User{ID: id, Email: "golden@example.com", Name: "Golden", CreatedAt: goldenTime}, nil
+}
+
+func (goldenRepo) RegisterUser(_ context.Context, email, name, _, _ string) (repo.User, error) {
+	if email == "taken@example.com" {
+		return repo.User{}, repo.ErrUserExists
+	}
+	return repo.User{ID: goldenUserID, Email: email, Name: name, CreatedAt: goldenTime}, nil
+}
+
+func (g goldenRepo) ListUserWallets(ctx context.Context, _ int64) ([]repo.Wallet, error) {
+	w, _ := g.wallet(goldenPrivate)
+	return []repo.Wallet{w}, nil
+}
+
+func (g goldenRepo) GetOrCreateWallet(_ context.Context, userID int64, addr string) (repo.Wallet, bool, error) {
+	if w, ok := g.wallet(addr); ok {
+		if w.UserID != userID {
+			return repo.Wallet{}, false, repo.ErrWalletExists
+		}
+		return w, false, nil
+	}
+	return repo.Wallet{Address: addr, UserID: userID, CreatedAt: goldenTime, UpdatedAt: goldenTime, MetaVersion: 1}, true, nil
+}
+
+func (g goldenRepo) UpdateWalletMeta(_ context.Context, addr string, version int64, p repo.WalletMetaPatch, _ string) (repo.Wallet, error) {
+	w, ok := g.wallet(addr)
+	if !ok {
+		return repo.Wallet{}, repo.ErrWalletNotFound
+	}
+	if version != 0 && version != w.MetaVersion {
+		return w, repo.ErrVersionMismatch
+	}
+	if p.Email != nil {
+		w.Email = *p.Email
+		w.MetaVersion++
+	}
+	if p.LowBalanceCents != nil {
+		w.LowBalanceCents = *p.LowBalanceCents
+		w.MetaVersion++
+	}
+	return w, nil
+}
+
+func (g goldenRepo) CreateWallet(ctx context.Context, userID int64, addr string) (repo.Wallet, error) {
+	w, created, err := g.GetOrCreateWallet(ctx, userID, addr)
+	if err == nil && !created {
+		err = repo.ErrWalletExists
+	}
+	return w, err
+}
+
+func (goldenRepo) ListDenylist(context.Context) ([]repo.DenylistEntry, error) {
+	return []repo.DenylistEntry{{Address: goldenMissing, Reason: "fraud", CreatedAt: goldenTime}}, nil
+}
+
+func (goldenRepo) CheckMoneySupply(context.Context) (repo.SupplyCheck, error) {
+	return repo.SupplyCheck{BalancesCents: 123561, ExpectedCents: 123561, OK: true}, nil
+}
+
+// goldenCase, запрос и имя эталонного файла ответа
+type goldenCase struct {
+	name   string
+	method string
+	path   string
+	body   string
+	// token, ключ пользователя, admin, токен администратора, ifMatch, заголовок If-Match
+	token   string
+	admin   bool
+	ifMatch string
+}
+
+// goldenCases, успешные ответы и ответы с ошибками ручек, имена файлов в testdata/golden
+var goldenCases = []goldenCase{
+	{name: "wallet", method: "GET", path: "/api/wallet/" + goldenFrom},
+	{name: "wallet_not_found", method: "GET", path: "/api/wallet/" + goldenMissing},
+	{name: "wallet_forbidden", method: "GET", path: "/api/wallet/" + goldenPrivate},
+	{name: "wallet_patch", method: "PATCH", path: "/api/wallet/" + goldenPrivate, token: goldenToken, ifMatch: `"v2"`, body: `{"low_balance_threshold":10}`},
+	{name: "wallet_patch_stale", method: "PATCH", path: "/api/wallet/" + goldenPrivate, token: goldenToken, ifMatch: `"v1"`, body: `{"low_balance_threshold":10}`},
+	{name: "wallet_patch_no_if_match", method: "PATCH", path: "/api/wallet/" + goldenPrivate, token: goldenToken, body: `{"low_balance_threshold":10}`},
+	{name: "wallet_patch_email_not_admin", method: "PATCH", path: "/api/wallet/" + goldenPrivate, token: goldenToken, ifMatch: "*", body: `{"email":"new@example.com"}`},
+	{name: "wallet_patch_email", method: "PATCH", path: "/api/wallet/" + goldenPrivate, admin: true, ifMatch: "*", body: `{"email":"new@example.com"}`},
+	{name: "balance", method: "GET", path: "/api/wallet/" + goldenFrom + "/balance"},
+	{name: "balance_not_found", method: "GET", path: "/api/wallet/" + goldenMissing + "/balance"},
+	{name: "balance_forbidden", method: "GET", path: "/api/wallet/" + goldenPrivate + "/balance"},
+	{name: "exists", method: "GET", path: "/api/wallet/" + goldenFrom + "/exists"},
+	{name: "exists_missing", method: "GET", path: "/api/wallet/" + goldenMissing + "/exists"},
+	{name: "balances", method: "POST", path: "/api/balances", body: `{"addresses":["` + goldenFrom + `","` + goldenTo + `","` + goldenMissing + `"]}`},
+	{name: "balances_invalid_json", method: "POST", path: "/api/balances", body: `{`},
+	{name: "stats", method: "GET", path: "/api/wallet/" + goldenFrom + "/stats"},
+	{name: "stats_not_found", method: "GET", path: "/api/wallet/" + goldenMissing + "/stats"},
+	{name: "counterparties", method: "GET", path: "/api/wallet/" + goldenFrom + "/counterparties"},
+	{name: "counterparties_invalid_sort", method: "GET", path: "/api/wallet/" + goldenFrom + "/counterparties?sort=nope"},
+	{name: "payees", method: "GET", path: "/api/wallet/" + goldenFrom + "/payees"},
+	{name: "payee_add", method: "POST", path: "/api/wallet/" + goldenFrom + "/payees", body: `{"alias":"carol","address":"` + goldenTo + `"}`},
+	{name: "payee_exists", method: "POST", path: "/api/wallet/" + goldenFrom + "/payees", body: `{"alias":"bob","address":"` + goldenTo + `"}`},
+	{name: "payee_delete_not_found", method: "DELETE", path: "/api/wallet/" + goldenFrom + "/payees/carol"},
+
+	{name: "send", method: "POST", path: "/api/send", body: `{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":10.5}`},
+	{name: "send_insufficient_funds", method: "POST", path: "/api/send", body: `{"from":"` + goldenTo + `","to":"` + goldenFrom + `","amount":10.5}`},
+	{name: "send_wallet_not_found", method: "POST", path: "/api/send", body: `{"from":"` + goldenFrom + `","to":"` + goldenMissing + `","amount":1}`},
+	{name: "send_invalid_json", method: "POST", path: "/api/send", body: `{"from":`},
+	{name: "send_invalid_address", method: "POST", path: "/api/send", body: `{"from":"abc","to":"` + goldenTo + `","amount":1}`},
+	{name: "send_invalid_amount", method: "POST", path: "/api/send", body: `{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":0}`},
+	{name: "send_amount_too_large", method: "POST", path: "/api/send", body: `{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":1e20}`},
+	{name: "send_insufficient_scope", method: "POST", path: "/api/send", token: goldenReadToken, body: `{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":1}`},
+	{name: "send_quote", method: "POST", path: "/api/send/quote", body: `{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":10.5}`},
+	{name: "send_quote_insufficient_funds", method: "POST", path: "/api/send/quote", body: `{"from":"` + goldenTo + `","to":"` + goldenFrom + `","amount":10.5}`},
+	{name: "send_quote_wallet_not_found", method: "POST", path: "/api/send/quote", body: `{"from":"` + goldenFrom + `","to":"` + goldenMissing + `","amount":1}`},
+	{name: "send_quote_invalid_amount", method: "POST", path: "/api/send/quote", body: `{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":0}`},
+	{name: "send_batch", method: "POST", path: "/api/send/batch", body: `{"mode":"best_effort","items":[` +
+		`{"from":"` + goldenFrom + `","to":"` + goldenTo + `","amount":1.25},{"from":"` + goldenTo + `","to":"` + goldenFrom + `","amount":9}]}`},
+	{name: "send_batch_invalid_mode", method: "POST", path: "/api/send/batch", body: `{"mode":"nope","items":[]}`},
+	{name: "send_split", method: "POST", path: "/api/send/split", body: `{"from":"` + goldenFrom + `","amount":10,` +
+		`"shares":[{"to":"` + goldenTo + `","percent":33.3},{"to":"` + goldenPrivate + `","percent":66.7}]}`},
+	{name: "send_split_invalid_remainder", method: "POST", path: "/api/send/split", body: `{"from":"` + goldenFrom + `","amount":10,"remainder":"nope"}`},
+	{name: "payment_uri_parse", method: "POST", path: "/api/payment-uri/parse", body: `{"uri":"wallet:` + goldenTo + `?amount=12.5&memo=rent"}`},
+	{name: "payment_uri_parse_invalid", method: "POST", path: "/api/payment-uri/parse", body: `{"uri":"http://example.com"}`},
+	{name: "standing_order_preview_invalid", method: "GET", path: "/api/standing-orders/preview?schedule=hourly"},
+
+	{name: "transactions", method: "GET", path: "/api/transactions"},
+	{name: "transactions_invalid_count", method: "GET", path: "/api/transactions?count=abc"},
+	{name: "transactions_invalid_order", method: "GET", path: "/api/transactions?order=sideways"},
+	{name: "transaction", method: "GET", path: "/api/transactions/2"},
+	{name: "transaction_not_found", method: "GET", path: "/api/transactions/99"},
+	{name: "transaction_invalid_id", method: "GET", path: "/api/transactions/abc"},
+
+	{name: "user_register", method: "POST", path: "/api/users", body: `{"email":"new@example.com","name":"New"}`},
+	{name: "user_register_exists", method: "POST", path: "/api/users", body: `{"email":"taken@example.com","name":"Taken"}`},
+	{name: "user_register_invalid_email", method: "POST", path: "/api/users", body: `{"email":"nope","name":"Nope"}`},
+	{name: "me", method: "GET", path: "/api/me", token: goldenToken},
+	{name: "me_unauthorized", method: "GET", path: "/api/me"},
+	{name: "me_bad_token", method: "GET", path: "/api/me", token: "wk_unknown"},
+	{name: "my_wallets", method: "GET", path: "/api/me/wallets", token: goldenToken},
+	{name: "wallet_create", method: "POST", path: "/api/wallets", token: goldenToken, body: `{"address":"` + strings.Repeat("e", 64) + `"}`},
+	{name: "wallet_get_or_create", method: "POST", path: "/api/wallets", token: goldenToken, body: `{"address":"` + goldenPrivate + `","mode":"get_or_create"}`},
+	{name: "wallet_exists", method: "POST", path: "/api/wallets", token: goldenToken, body: `{"address":"` + goldenFrom + `"}`},
+	{name: "wallet_invalid_mode", method: "POST", path: "/api/wallets", token: goldenToken, body: `{"mode":"nope"}`},
+
+	{name: "admin_denylist", method: "GET", path: "/api/admin/denylist", admin: true},
+	{name: "admin_supply", method: "GET", path: "/api/admin/invariants/supply", admin: true},
+	{name: "admin_unauthorized", method: "GET", path: "/api/admin/denylist"},
+	{name: "admin_forbidden", method: "GET", path: "/api/admin/denylist", token: goldenToken},
+
+	{name: "route_not_found", method: "GET", path: "/api/nope"},
+	{name: "method_not_allowed", method: "DELETE", path: "/api/send"},
+	{name: "body_too_large", method: "POST", path: "/api/send", body: `{"from":"` + strings.Repeat("a", 5<<10) + `"}`},
+}
+
+// TestGolden, ответы ручек совпадают с эталонами в testdata/golden вплоть до имен полей и форматирования сумм,
+// после намеренного изменения ответа эталоны перезаписываются флагом -update и попадают в дифф вместе с кодом
+func TestGolden(t *testing.T) {
+	h := chi.NewRouter()
+	api := &API{Repo: goldenRepo{}, AdminToken: testAdminToken}
+	api.Routes(h)
+
+	seen := map[string]bool{}
+	for _, tc := range goldenCases {
+		if seen[tc.name] {
+			t.Fatalf("duplicate golden case %q", tc.name)
+		}
+		seen[tc.name] = true
+		t.Run(tc.name, func(t *testing.T) {
+			var body *strings.Reader
+			if tc.body != "" {
+				body = strings.NewReader(tc.body)
+			}
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if body != nil {
+				req = httptest.NewRequest(tc.method, tc.path, body)
+				req.Header.Set("Content-Type", "application/json")
+			}
+			req.Header.Set(requestIDHeader, "golden-"+tc.name)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			if tc.admin {
+				req.Header.Set("X-Admin-Token", testAdminToken)
+			}
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			got := goldenResponse(t, rr)
+			path := filepath.Join("testdata", "golden", tc.name+".golden")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden file: %v, run go test ./internal/api -run TestGolden -update", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("response differs from %s, rerun with -update if the change is intended\n--- got\n%s\n--- want\n%s", path, got, want)
+			}
+		})
+	}
+}
+
+// randomValue, значения, которые меняются от запуска к запуску, в эталоне заменяются заглушкой
+var randomValue = regexp.MustCompile(`"wk_[0-9a-f]{64}"`)
+
+// volatileHeaders, заголовки со временем запроса, в эталоне только их наличие
+var volatileHeaders = map[string]bool{"Date": true, "X-Request-Deadline": true}
+
+// goldenResponse, статус, заголовки ответа и тело json с отступами
+func goldenResponse(t *testing.T, rr *httptest.ResponseRecorder) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "HTTP %d %s\n", rr.Code, http.StatusText(rr.Code))
+	var keys []string
+	for k := range rr.Header() {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := strings.Join(rr.Header()[k], ", ")
+		if volatileHeaders[k] {
+			v = "<volatile>"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", k, v)
+	}
+	b.WriteString("\n")
+
+	// json.Indent сохраняет порядок полей и запись чисел как есть, так в эталоне видны и перестановки, и смена формата сумм
+	raw := bytes.TrimSpace(rr.Body.Bytes())
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		b.Write(raw)
+	} else {
+		b.Write(randomValue.ReplaceAll(out.Bytes(), []byte(`"wk_<random>"`)))
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
@@ -3,59 +3,307 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
-	"time"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gotechtask/internal/address"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/capture"
+	"gotechtask/internal/db"
+	"gotechtask/internal/invariant"
+	"gotechtask/internal/money"
 	"gotechtask/internal/repo"
+	"gotechtask/internal/storage"
 )
 
-// API, хранит зависимость репозитория, предоставляет обработчики http
+// API, хранит зависимость репозитория, токен администратора и проверку денежной массы, предоставляет обработчики http
 type API struct {
-	Repo repo.Repo
+	Repo       repo.Repo
+	AdminToken string
+	Supply     *invariant.Checker
+	// OIDC, проверка токенов внешнего провайдера, nil выключает вход через провайдера
+	OIDC IdentityVerifier
+	// Keys, кэш ключей доступа, nil выключает кэширование, KeyRotationOverlap, сколько старый ключ работает после ротации по умолчанию
+	Keys               *KeyCache
+	KeyRotationOverlap time.Duration
+	// TwoFactorThresholdCents, переводы с личного кошелька больше этой суммы требуют подтверждения вторым фактором, ноль выключает
+	TwoFactorThresholdCents int64
+	// PendingTTL, сколько ждать подтверждения, PublicURL, внешний адрес сервиса для ссылок в письмах
+	PendingTTL time.Duration
+	PublicURL  string
+	// RequireSignedTransfers, переводы любым ключом доступа должны быть подписаны, SignatureWindow, допустимый возраст подписи
+	RequireSignedTransfers bool
+	SignatureWindow        time.Duration
+	// AddressChecksumStrict, перевод принимает адреса только с контрольной суммой регистра, без него и в нижнем регистре
+	AddressChecksumStrict bool
+	// Addresses, вывод адресов из меток для POST /api/admin/wallets/derived, nil выключает ручку
+	Addresses *address.Deriver
+	// Lanes, резерв емкости для администраторов, nil без ограничения
+	Lanes *Lanes
+	// Timeouts, таймауты ручек, нулевое значение дает 15s для переводов и 5s для чтения
+	Timeouts Timeouts
+	// Location, часовой пояс бизнес-дня, nil дает utc
+	Location *time.Location
+	// Feed, живая лента транзакций для потока администратора, nil выключает поток
+	Feed *Feed
+	// Blob, внешнее хранилище резервных копий, nil выключает ручки копий
+	Blob storage.Store
+	// Sandbox, режим песочницы с краном и сбросом данных, FaucetMaxCents, предел одного пополнения из крана
+	Sandbox        bool
+	FaucetMaxCents int64
+	// Capture, запись переводов для отладки, nil выключает
+	Capture *capture.Recorder
+	// Listing, размеры страниц истории транзакций
+	Listing ListLimits
+	// TxCache, кэш страниц истории транзакций, nil выключает кэш, ETag и Last-Modified отдаются и без него
+	TxCache *TxCache
+	// BalanceCache, кэш кошельков для чтения баланса со сбросом по уведомлениям базы, nil выключает кэш
+	BalanceCache *BalanceCache
+	// Queries, статистика запросов к базе, nil выключает ручку статистики
+	Queries *db.QueryStats
+	// Bodies, пределы размера и времени чтения тел запросов, нулевое значение дает встроенные пределы без таймаута
+	Bodies BodyLimits
+	// Maintenance, режим обслуживания, публичные изменяющие запросы получают 503
+	Maintenance bool
+	// Reload, перечитывает настройки без перезапуска и отдает изменившиеся, nil выключает ручку
+	Reload func() ([]string, error)
+
+	// live, настройки после SetSettings, важнее полей выше
+	live atomic.Pointer[Settings]
 }
 
-// Routes, регистрирует маршруты, баланс кошелька, перевод, последние транзакции
+// Routes, регистрирует маршруты, баланс кошелька, перевод, запросы платежа, постоянные поручения, последние транзакции, пользователи и их кошельки, административные ручки, все под аутентификацией, ручки кошельков требуют области доступа ключа, статическая панель администратора /admin открыта, данные она запрашивает с токеном, в песочнице еще кран и сброс данных, а ответы помечены заголовком X-Sandbox,
+// HEAD и OPTIONS работают на всех маршрутах роутера, включая добавленные после, у каждого ответа есть X-Request-ID,
+// неизвестный путь и метод отвечают ошибкой json с этим идентификатором
 func (a *API) Routes(r chi.Router) {
-	r.Get("/api/wallet/{address}/balance", a.getBalance)
-	r.Post("/api/send", a.postSend)
-	r.Get("/api/transactions", a.getLastTransactions)
+	r.Use(requestID, methods(r), a.limitBody(r))
+	r.NotFound(notFound)
+	r.MethodNotAllowed(methodNotAllowed(r))
+	if a.Sandbox {
+		r.Use(markSandbox)
+	}
+	r.Group(func(r chi.Router) {
+		r.Use(a.authenticate, a.limitLanes, a.maintenance)
+		a.routes(r)
+	})
+
+	// поток и выгрузка держат соединение долго и в полосах места не занимают
+	r.Group(func(r chi.Router) {
+		r.Use(a.authenticate, a.requireAdmin)
+		r.Get("/api/admin/transactions/stream", a.getTransactionStream)
+		r.With(a.consistentReads).Get("/api/admin/exports/transactions", a.getTransactionExport)
+	})
+
+	// сведения о сборке нужны и до аутентификации, и при перегрузке
+	r.Get("/api/version", a.getVersion)
+
+	// панель администратора, данные она берет из ручек выше
+	r.Handle("/admin", http.RedirectHandler("/admin/", http.StatusMovedPermanently))
+	r.Handle("/admin/*", dashboard())
 }
 
-// getBalance, берет адрес из пути, запрашивает баланс у репозитория, маппит ошибки в коды http, отдает адрес и баланс строкой
+// routes, маршруты api без общих middleware
+func (a *API) routes(r chi.Router) {
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/wallet/{address}", a.getWallet)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Patch("/api/wallet/{address}", a.patchWallet)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/wallet/{address}/balance", a.getBalance)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Post("/api/balances", a.postBalances)
+	r.With(a.requireScope(auth.ScopeBalanceRead), a.consistentReads).Get("/api/wallet/{address}/balance-events", a.getBalanceEvents)
+	r.With(a.requireScope(auth.ScopeBalanceRead), a.consistentReads).Get("/api/wallet/{address}/activity", a.getWalletActivity)
+	r.Get("/api/wallet/{address}/exists", a.getWalletExists)
+	r.Head("/api/wallet/{address}/exists", a.getWalletExists)
+	r.With(a.requireScope(auth.ScopeBalanceRead), a.consistentReads).Get("/api/wallet/{address}/counterparties", a.getCounterparties)
+	r.With(a.requireScope(auth.ScopeBalanceRead), a.consistentReads).Get("/api/wallet/{address}/stats", a.getWalletStats)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/wallet/{address}/qr", a.getWalletQR)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/wallet/{address}/payees", a.getPayees)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/wallet/{address}/payees", a.postPayee)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Delete("/api/wallet/{address}/payees/{alias}", a.deletePayee)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Put("/api/wallet/{address}/low-balance", a.putLowBalance)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature, a.captureSend, a.idempotent).Post("/api/send", a.postSend)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Post("/api/send/quote", a.postSendQuote)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature, a.idempotent).Post("/api/send/batch", a.postSendBatch)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature, a.idempotent).Post("/api/send/split", a.postSendSplit)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/wallet/{address}/requests", a.getPaymentRequests)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.idempotent).Post("/api/requests", a.postPaymentRequest)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/requests/{id}", a.getPaymentRequest)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature).Post("/api/requests/{id}/accept", a.acceptPaymentRequest)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/requests/{id}/decline", a.declinePaymentRequest)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/wallet/{address}/standing-orders", a.getStandingOrders)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature, a.idempotent).Post("/api/standing-orders", a.postStandingOrder)
+	r.Get("/api/standing-orders/preview", a.getStandingOrderPreview)
+	r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/standing-orders/{id}", a.getStandingOrder)
+	r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature).Patch("/api/standing-orders/{id}", a.patchStandingOrder)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/standing-orders/{id}/pause", a.pauseStandingOrder)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/standing-orders/{id}/resume", a.resumeStandingOrder)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/standing-orders/{id}/cancel", a.cancelStandingOrder)
+	r.With(a.requireScope(auth.ScopeTransferWrite)).Delete("/api/standing-orders/{id}", a.deleteStandingOrder)
+	r.With(a.requireScope(auth.ScopeTransactionsRead), a.consistentReads).Get("/api/transactions", a.getLastTransactions)
+	r.With(a.requireScope(auth.ScopeTransactionsRead)).Get("/api/transactions/{id}", a.getTransaction)
+	r.Post("/api/payment-uri/parse", a.postParsePaymentURI)
+	if a.Sandbox {
+		r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/sandbox/faucet", a.postFaucet)
+	}
+
+	r.Post("/api/users", a.postUser)
+	// ссылка из письма, сам токен и есть учетные данные
+	r.Get("/api/transfers/pending/confirm", a.confirmPendingByLink)
+	r.Group(func(r chi.Router) {
+		r.Use(a.requireUser)
+		r.Get("/api/me", a.getMe)
+		r.Get("/api/transfers/pending/{id}", a.getPendingTransfer)
+		r.With(a.requireScope(auth.ScopeTransferWrite), a.requireSignature).Post("/api/transfers/pending/{id}/confirm", a.confirmPendingTransfer)
+		r.With(a.requireSignature).Post("/api/me/totp", a.postTOTP)
+		r.Post("/api/me/totp/enable", a.enableTOTP)
+		r.Get("/api/me/keys", a.getAPIKeys)
+		// подписанный ключ не должен суметь выдать себе неподписанную замену или снять подпись без секрета
+		r.Group(func(r chi.Router) {
+			r.Use(a.requireSignature)
+			r.Post("/api/me/keys", a.postAPIKey)
+			r.Post("/api/me/keys/{id}/rotate", a.rotateAPIKey)
+			r.Delete("/api/me/keys/{id}", a.deleteAPIKey)
+			r.Post("/api/me/keys/{id}/signing-secret", a.postSigningSecret)
+			r.Delete("/api/me/keys/{id}/signing-secret", a.deleteSigningSecret)
+		})
+		r.With(a.requireScope(auth.ScopeBalanceRead)).Get("/api/me/wallets", a.getMyWallets)
+		r.With(a.requireScope(auth.ScopeTransferWrite)).Post("/api/wallets", a.postWallet)
+	})
+
+	r.Route("/api/admin", func(r chi.Router) {
+		r.Use(a.requireAdmin)
+		r.Get("/denylist", a.getDenylist)
+		r.Post("/denylist", a.postDenylist)
+		r.Delete("/denylist/{address}", a.deleteDenylist)
+		r.Get("/alerts", a.getAlerts)
+		r.With(a.consistentReads).Get("/invariants/supply", a.getSupplyCheck)
+		r.Get("/invariants/balances", a.getBalanceCheck)
+		r.Get("/invariants/ledger", a.getLedgerCheck)
+		r.Delete("/ledger", a.deleteLedger)
+		r.With(a.consistentReads).Get("/stats", a.getStats)
+		r.Put("/wallet/{address}/overdraft", a.putOverdraft)
+		r.Put("/wallet/{address}/email", a.putWalletEmail)
+		r.Put("/wallet/{address}/hot", a.putWalletHot)
+		r.Post("/wallet/{address}/merge", a.postWalletMerge)
+		if a.Addresses != nil {
+			r.Post("/wallets/derived", a.postDerivedWallet)
+		}
+		r.Post("/wallet/{address}/payees/{alias}/restore", a.restorePayee)
+		r.Post("/standing-orders/{id}/restore", a.restoreStandingOrder)
+		r.Get("/hot-wallets", a.getHotWallets)
+		r.With(a.consistentReads).Get("/transactions/{id}/trace", a.getTransactionTrace)
+		r.Get("/explain", a.getExplain)
+		if a.Reload != nil {
+			r.Post("/config/reload", a.postConfigReload)
+		}
+		if a.Queries != nil {
+			r.Get("/query-stats", a.getQueryStats)
+			r.Delete("/query-stats", a.deleteQueryStats)
+		}
+		r.With(a.consistentReads).Get("/reports/dormant", a.getDormantReport)
+		r.With(a.consistentReads).Get("/reports/settlement/{date}", a.getSettlementReport)
+		r.Post("/reports/settlement/{date}", a.postSettlement)
+		r.Get("/wallets/search", a.getWalletSearch)
+		r.Get("/system-wallets", a.getSystemWallets)
+		r.Post("/treasury/mint", a.postMint)
+		r.Post("/treasury/burn", a.postBurn)
+		r.Post("/sweeps", a.postSweep)
+		r.Get("/sweeps/{id}", a.getSweep)
+		r.Post("/sweeps/{id}/resume", a.resumeSweep)
+		r.Post("/backups", a.postBackup)
+		r.Get("/backups/{id}", a.getBackup)
+		if a.Sandbox {
+			r.Post("/sandbox/reset", a.postSandboxReset)
+		}
+	})
+}
+
+// getBalance, берет адрес из пути, запрашивает кошелек у репозитория, маппит ошибки в коды http, отдает адрес, баланс строкой и отметки времени активности
 func (a *API) getBalance(w http.ResponseWriter, r *http.Request) {
 	addr := chi.URLParam(r, "address")
 
-	cents, err := a.Repo.GetBalance(r.Context(), addr)
-	if err != nil {
-		if err == repo.ErrWalletNotFound {
-			// кошелек не найден, 404
-			writeJSON(w, http.StatusNotFound, map[string]string{
-				"error": "wallet not found",
+	// личный кошелек виден только владельцу и администратору, у кошелька из кэша владелец уже известен
+	now := time.Now()
+	wl, cached := a.BalanceCache.get(addr, now)
+	if cached {
+		if err := authorizeOwner(auth.FromContext(r.Context()), wl.UserID); err != nil {
+			writeWalletAccessError(w, err)
+			return
+		}
+	} else if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	// исторический баланс на момент at
+	if v := r.URL.Query().Get("at"); v != "" {
+		a.getBalanceAt(w, r, addr, v)
+		return
+	}
+
+	if !cached {
+		gen := a.BalanceCache.gen(addr)
+		var err error
+		if wl, err = a.Repo.GetWallet(r.Context(), addr); err == nil {
+			a.BalanceCache.put(wl, gen, now)
+		}
+		if err != nil {
+			if errors.Is(err, repo.ErrWalletNotFound) {
+				// кошелек не найден, 404
+				writeJSON(w, http.StatusNotFound, map[string]string{
+					"error": "wallet not found",
+				})
+				return
+			}
+			// прочая ошибка, 500
+			writeJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": "internal server error",
 			})
 			return
 		}
-		// прочая ошибка, 500
-		writeJSON(w, http.StatusInternalServerError, map[string]string{
-			"error": "internal server error",
-		})
+	}
+
+	// кошелек слит с другим, его баланс теперь у преемника
+	if wl.Successor != "" {
+		writeWalletClosed(w, wl)
 		return
 	}
 
-	// успех, возвращаем адрес и баланс в человекочитаемом виде
-	writeJSON(w, http.StatusOK, map[string]string{
-		"address": addr,
-		"balance": formatCents(cents),
-	})
+	// успех, возвращаем адрес и баланс в человекочитаемом виде, время создания, изменения и последнего перевода
+	resp := map[string]string{
+		"address":    addr,
+		"balance":    formatCents(wl.BalanceCents),
+		"created_at": wl.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at": wl.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if !wl.LastTxAt.IsZero() {
+		resp["last_tx_at"] = wl.LastTxAt.UTC().Format(time.RFC3339)
+	}
+	// состояние низкого баланса, только если порог задан, active с момента, когда баланс опустился ниже порога
+	if wl.LowBalanceCents > 0 {
+		resp["low_balance_threshold"] = formatCents(wl.LowBalanceCents)
+		resp["low_balance_alert"] = "clear"
+		if !wl.LowBalanceSince.IsZero() {
+			resp["low_balance_alert"] = "active"
+			resp["low_balance_since"] = wl.LowBalanceSince.UTC().Format(time.RFC3339)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
-// sendReq, входная модель перевода, адрес отправителя, адрес получателя, сумма
+// sendReq, входная модель перевода, адрес отправителя, адрес получателя либо псевдоним из адресной книги отправителя, сумма
 type sendReq struct {
-	From   string  `json:"from"`
-	To     string  `json:"to"`
-	Amount float64 `json:"amount"`
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+	ToAlias string  `json:"to_alias,omitempty"`
+	Amount  float64 `json:"amount"`
 }
 
 // sendResp, выходная модель перевода, статус выполнения
@@ -65,49 +313,159 @@ type sendResp struct {
 
 // postSend, валидирует тело запроса, проверяет формат адресов и сумму, конвертирует в центы, вызывает перевод у репозитория с таймаутом, возвращает коды в зависимости от ошибки
 func (a *API) postSend(w http.ResponseWriter, r *http.Request) {
+	req, amountCents, ok := a.decodeSend(w, r)
+	if !ok {
+		return
+	}
+
+	// ограничиваем время операции перевода, чтобы не зависать
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	// крупный перевод с личного кошелька его владельцем ждет подтверждения вторым фактором
+	need, err := a.needsSecondFactor(ctx, req.From, amountCents)
+	if err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	if need {
+		a.createPendingTransfer(w, r, req.From, req.To, amountCents, 0)
+		return
+	}
+
+	// выполняем перевод через доменную логику репозитория
+	if err := a.Repo.Transfer(ctx, req.From, req.To, amountCents); err != nil {
+		a.writeTransferError(w, err)
+		return
+	}
+	a.transferCommitted(req.From, req.To)
+
+	// успех, отдаем ок
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// decodeSend, читает и проверяет тело перевода, адреса, сумму, доступ к кошельку отправителя и псевдоним получателя, общая часть перевода и его оценки,
+// при ошибке ответ уже записан
+func (a *API) decodeSend(w http.ResponseWriter, r *http.Request) (sendReq, int64, bool) {
 	var req sendReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		// битый json, 400
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
-		return
+		return req, 0, false
 	}
-	if len(req.From) != 64 || len(req.To) != 64 {
-		// неверная длина адресов, 400
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
-		return
+	if req.ToAlias != "" && req.To != "" {
+		// получатель задается либо адресом, либо псевдонимом, 400
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "to and to_alias are mutually exclusive"})
+		return req, 0, false
+	}
+	// адреса приводятся к нижнему регистру, опечатка в адресе с контрольной суммой дает 400 до перевода
+	var ok bool
+	if req.From, ok = a.parseAddress(w, req.From); !ok {
+		return req, 0, false
+	}
+	if req.ToAlias == "" {
+		if req.To, ok = a.parseAddress(w, req.To); !ok {
+			return req, 0, false
+		}
 	}
 	if req.Amount <= 0 {
 		// сумма должна быть больше нуля, 400
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
-		return
+		return req, 0, false
+	}
+	if toCents(req.Amount) > money.MaxCents {
+		// сумма больше предела, 400, а не заворот через int64
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount too large"})
+		return req, 0, false
 	}
 
-	// переводим сумму в центы, без округления вверх, дробная часть отбрасывается правилами float к int64
-	amountCents := int64(req.Amount * 100)
-
-	// ограничиваем время операции перевода, чтобы не зависать
-	ctx, cancel := context.WithTimeout(r.Context(), 15*time.Second)
-	defer cancel()
+	// распоряжаться личным кошельком может только владелец или администратор
+	if err := a.authorizeWallet(r.Context(), req.From); err != nil {
+		writeWalletAccessError(w, err)
+		return req, 0, false
+	}
 
-	// выполняем перевод через доменную логику репозитория
-	err := a.Repo.Transfer(ctx, req.From, req.To, amountCents)
-	if err != nil {
-		// маппим доменные ошибки в http коды
-		switch err {
-		case repo.ErrWalletNotFound:
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
-		case repo.ErrInsufficientFunds:
-			writeJSON(w, http.StatusConflict, map[string]string{"error": "insufficient funds"})
-		case repo.ErrSameAddress:
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must differ from to"})
-		default:
+	// псевдоним ищется только в адресной книге отправителя, доступ к которой уже проверен
+	if req.ToAlias != "" {
+		to, err := a.Repo.ResolvePayee(r.Context(), req.From, req.ToAlias)
+		if err != nil {
+			if errors.Is(err, repo.ErrPayeeNotFound) {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "payee not found"})
+				return req, 0, false
+			}
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return req, 0, false
 		}
+		req.To = to
+	}
+
+	// переводим сумму в центы, без округления вверх, знаки после второго отбрасываются
+	return req, toCents(req.Amount), true
+}
+
+// writeTransferError, маппит доменные ошибки перевода в http коды, конфликт блокировок и таймаут отдаются с подсказкой повтора по текущей загрузке
+func (a *API) writeTransferError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		writeRetryable(w, http.StatusServiceUnavailable, "transfer timed out", a.retryAfter(busyRetryAfter))
 		return
 	}
+	if code, msg, ok := transferRejection(err); ok {
+		writeJSON(w, code, rejectionBody(msg, err))
+		return
+	}
+	switch {
+	case errors.Is(err, repo.ErrContention):
+		writeRetryable(w, http.StatusConflict, "transfer contention, retry later", a.retryAfter(contentionRetryAfter))
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
 
-	// успех, отдаем ок
-	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+// transferRejection, код и текст ответа для доменного отказа перевода, ok false для остальных ошибок
+func transferRejection(err error) (code int, msg string, ok bool) {
+	switch {
+	case errors.Is(err, repo.ErrWalletNotFound):
+		return http.StatusNotFound, "wallet not found", true
+	case errors.Is(err, repo.ErrInsufficientFunds):
+		return http.StatusConflict, "insufficient funds", true
+	case errors.Is(err, repo.ErrBalanceOverflow):
+		return http.StatusConflict, "balance limit exceeded", true
+	case errors.Is(err, repo.ErrSameAddress):
+		return http.StatusBadRequest, "from must differ from to", true
+	case errors.Is(err, repo.ErrAddressDenied):
+		return http.StatusForbidden, "address denylisted", true
+	case errors.Is(err, repo.ErrWalletClosed):
+		return http.StatusGone, "wallet closed", true
+	}
+	return 0, "", false
+}
+
+// rejectionBody, тело ответа на доменный отказ перевода, при нехватке средств с адресом, нужной и доступной суммой и нехваткой
+func rejectionBody(msg string, err error) map[string]any {
+	body := map[string]any{"error": msg}
+	var e *repo.InsufficientFundsError
+	if errors.As(err, &e) {
+		body["address"] = e.Address
+		body["needed"] = formatCents(e.NeededCents)
+		body["available"] = formatCents(e.AvailableCents)
+		body["shortfall"] = formatCents(e.ShortfallCents())
+	}
+	var c *repo.WalletClosedError
+	if errors.As(err, &c) {
+		body["address"] = c.Address
+		body["successor"] = c.Successor
+	}
+	return body
+}
+
+// transferCommitted, действия после успешного перевода, сброс кэша балансов и учет для проверки денежной массы, квитанции ставит сам перевод в своей транзакции
+func (a *API) transferCommitted(from, to string) {
+	// свой перевод виден этому экземпляру сразу, не дожидаясь уведомления базы
+	a.BalanceCache.invalidate(from)
+	a.BalanceCache.invalidate(to)
+
+	// учитываем перевод для периодической проверки денежной массы
+	a.Supply.TransferCommitted()
 }
 
 // writeJSON, устанавливает заголовок контента, пишет код ответа, кодирует структуру в json
@@ -117,6 +475,12 @@ func writeJSON(w http.ResponseWriter, code int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// writeCreated, ответ 201 с заголовком Location, путем ручки GET созданного ресурса
+func writeCreated(w http.ResponseWriter, location string, v any) {
+	w.Header().Set("Location", location)
+	writeJSON(w, http.StatusCreated, v)
+}
+
 // formatCents, форматирует сумму в центах в строку с двумя десятичными знаками, учитывает знак
 func formatCents(c int64) string {
 	sign := ""
@@ -127,19 +491,108 @@ func formatCents(c int64) string {
 	return sign + fmt.Sprintf("%d.%02d", c/100, c%100)
 }
 
-// txDTO, представление транзакции для ответа, id, адреса, сумма строкой, время создания
+// toCents, сумма из запроса в центы по ее десятичной записи, знаки после второго отбрасываются, клиент не получает перевод больше, чем написал
+func toCents(amount float64) int64 {
+	return money.Cents(amount, money.Truncate)
+}
+
+// txDTO, представление транзакции для ответа, id, адреса, сумма строкой, время создания, инициатор и канал если известны, вид операции, id группы у связанных переводов
 type txDTO struct {
-	ID        int64  `json:"id"`
-	From      string `json:"from"`
-	To        string `json:"to"`
-	Amount    string `json:"amount"`
-	CreatedAt string `json:"created_at"`
+	ID          int64  `json:"id"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"`
+	CreatedAt   string `json:"created_at"`
+	InitiatedBy string `json:"initiated_by,omitempty"`
+	Channel     string `json:"channel,omitempty"`
+	Type        string `json:"type"`
+	GroupID     string `json:"group_id,omitempty"`
+}
+
+// toTxDTO, маппинг транзакции в ответ, сумма строкой, время в rfc3339
+func toTxDTO(t repo.Transaction) txDTO {
+	return txDTO{
+		ID:          t.ID,
+		From:        t.FromAddress,
+		To:          t.ToAddress,
+		Amount:      formatCents(t.AmountCents),
+		CreatedAt:   t.CreatedAt.UTC().Format(time.RFC3339),
+		InitiatedBy: t.InitiatedBy,
+		Channel:     t.Channel,
+		Type:        t.Type,
+		GroupID:     t.GroupID,
+	}
+}
+
+// txFilters, фильтры транзакций из запроса в opts, address, initiated_by, channel, group_id, type через запятую, период from..to или бизнес-день date, отдает имя битого параметра
+func (a *API) txFilters(qs url.Values, opts *repo.ListOptions) (bad string) {
+	opts.Address = qs.Get("address")
+	opts.InitiatedBy = qs.Get("initiated_by")
+	opts.Channel = qs.Get("channel")
+	opts.GroupID = qs.Get("group_id")
+	if opts.Since, opts.Until, bad = a.timeRange(qs); bad != "" {
+		return bad
+	}
+	switch opts.Channel {
+	case "", repo.ChannelHTTP, repo.ChannelGRPC, repo.ChannelCLI, repo.ChannelScheduled, repo.ChannelAdminAdjustment:
+	default:
+		return "channel"
+	}
+	if opts.GroupID != "" {
+		if _, err := uuid.Parse(opts.GroupID); err != nil {
+			return "group_id"
+		}
+	}
+	if v := qs.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			if !repo.ValidTxType(t) {
+				return "type"
+			}
+			opts.Types = append(opts.Types, t)
+		}
+	}
+	return ""
+}
+
+// maxPageCount, наибольшая страница истории, которая собирается в памяти и кэшируется, большие идут потоком
+const maxPageCount = 100
+
+// ListLimits, размеры страниц истории, нулевые поля дают 10, 100 и repo.MaxListLimit
+type ListLimits struct {
+	// Default, страница без count, Max, предел count для всех, кроме администраторов, AdminMax, предел для администраторов
+	Default  int
+	Max      int
+	AdminMax int
+}
+
+// count, страница по умолчанию и предел для участника
+func (l ListLimits) count(admin bool) (int, int) {
+	def, max := l.Default, l.Max
+	if def <= 0 {
+		def = 10
+	}
+	if max <= 0 {
+		max = maxPageCount
+	}
+	if admin {
+		max = repo.MaxListLimit
+		if l.AdminMax > 0 {
+			max = min(l.AdminMax, repo.MaxListLimit)
+		}
+	}
+	return def, max
 }
 
-// getLastTransactions, читает параметр count, применяет дефолт и верхний предел, запрашивает последние транзакции у репозитория, форматирует ответ
+// maxTotalCount, до какого числа считать транзакции для X-Total-Count, дальше счет не идет, чтобы не сканировать всю таблицу
+const maxTotalCount = 10000
+
+// getLastTransactions, читает параметр count, применяет дефолт и верхний предел, сортировку sort=created_at|amount и order=asc|desc, фильтры address, initiated_by, channel, group_id и type через запятую, период from..to или бизнес-день date, смещение offset или курсор cursor, запрашивает страницу транзакций у репозитория, форматирует ответ, with_total=true добавляет заголовок X-Total-Count, X-Next-Cursor ведет на следующую страницу,
+// count по умолчанию и его предел задаются в Listing, у администратора предел выше, страница больше maxPageCount идет потоком
 func (a *API) getLastTransactions(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query().Get("count")
-	n := 10
+	qs := r.URL.Query()
+	q := qs.Get("count")
+	def, maxCount := a.Listing.count(auth.FromContext(r.Context()).Admin)
+	n := def
 	if q != "" {
 		v, err := strconv.Atoi(q)
 		if err != nil {
@@ -149,36 +602,197 @@ func (a *API) getLastTransactions(w http.ResponseWriter, r *http.Request) {
 		}
 		n = v
 	}
-	// нормализация границ, минимум десять по умолчанию, максимум сто
+	// нормализация границ, страница по умолчанию и предел из настроек, у администратора предел выше
 	if n <= 0 {
-		n = 10
+		n = def
+	}
+	if n > maxCount {
+		n = maxCount
+	}
+	// аноним видит только переводы между общими кошельками, пользователь переводы своих кошельков, администратор все
+	opts := repo.ListOptions{
+		Visibility: txVisibility(r.Context()),
+		SortBy:     qs.Get("sort"),
+		Desc:       qs.Get("order") != "asc",
+		Limit:      n,
+		MaxLimit:   maxCount,
+		Cursor:     qs.Get("cursor"),
+	}
+	if o := qs.Get("order"); o != "" && o != "asc" && o != "desc" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid order"})
+		return
+	}
+	if bad := a.txFilters(qs, &opts); bad != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid " + bad})
+		return
 	}
-	if n > 100 {
-		n = 100
+	if s := qs.Get("offset"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid offset"})
+			return
+		}
+		opts.Offset = v
+	}
+	withTotal := false
+	if s := qs.Get("with_total"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid with_total"})
+			return
+		}
+		withTotal = v
 	}
 
 	// короткий таймаут для простого запроса чтения
-	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
 	defer cancel()
 
-	items, err := a.Repo.GetLastTransactions(ctx, n)
+	// большие страницы администратора идут потоком мимо кэша
+	if n > maxPageCount {
+		a.streamTransactions(ctx, w, r, opts, withTotal)
+		return
+	}
+
+	// свежая страница из кэша отдается сразу, иначе страница перечитывается, только если появилась новая транзакция
+	now := time.Now()
+	key := txPageKey(opts.Visibility, qs)
+	page, fresh := a.TxCache.get(key, now)
+	if !fresh {
+		maxID, at, err := a.Repo.LastTransaction(ctx)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		if page == nil || page.maxID != maxID {
+			meta := newTxPage(key, maxID, at, now)
+			var status int
+			if page, status, err = a.listTransactionsPage(ctx, opts, withTotal); err != nil {
+				writeJSON(w, status, map[string]string{"error": err.Error()})
+				return
+			}
+			page.maxID, page.etag, page.modified = meta.maxID, meta.etag, meta.modified
+		}
+		a.TxCache.put(key, page, now)
+	}
+	page.write(w, r)
+}
+
+// streamTransactions, большая страница истории, элементы пишутся в ответ по мере чтения строк и в памяти не копятся,
+// X-Next-Cursor известен только в конце и приходит трейлером, ошибка посреди ответа обрывает соединение, чтобы неполный список не приняли за целый
+func (a *API) streamTransactions(ctx context.Context, w http.ResponseWriter, r *http.Request, opts repo.ListOptions, withTotal bool) {
+	maxID, at, err := a.Repo.LastTransaction(ctx)
 	if err != nil {
-		// внутренняя ошибка, 500
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
 		return
 	}
+	if newTxPage(txPageKey(opts.Visibility, r.URL.Query()), maxID, at, time.Now()).head(w, r) {
+		return
+	}
+	if withTotal {
+		if err := a.setTotalCount(ctx, w.Header(), opts); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+	w.Header().Set("Trailer", "X-Next-Cursor")
+
+	arr := newJSONArray(w)
+	var last repo.Transaction
+	err = a.Repo.StreamTransactions(ctx, opts, func(t repo.Transaction) error {
+		last = t
+		return arr.add(toTxDTO(t))
+	})
+	if err != nil && !arr.started() {
+		status, msg := txListError(err)
+		writeJSON(w, status, map[string]string{"error": msg})
+		return
+	}
+	if err == nil {
+		err = arr.close()
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("stream transactions: after %d rows: %v", arr.n, err)
+		}
+		panic(http.ErrAbortHandler)
+	}
+	if arr.n == opts.Limit {
+		w.Header().Set("X-Next-Cursor", opts.CursorAfter(last))
+	}
+}
+
+// txListError, код и текст ответа для ошибки чтения истории
+func txListError(err error) (int, string) {
+	switch {
+	case errors.Is(err, repo.ErrInvalidSort):
+		return http.StatusBadRequest, "invalid sort"
+	case errors.Is(err, repo.ErrInvalidCursor):
+		return http.StatusBadRequest, "invalid cursor"
+	}
+	// внутренняя ошибка, 500
+	return http.StatusInternalServerError, "internal error"
+}
+
+// setTotalCount, ставит X-Total-Count с числом видимых транзакций под фильтрами opts
+func (a *API) setTotalCount(ctx context.Context, h http.Header, opts repo.ListOptions) error {
+	total, capped, err := a.Repo.CountTransactions(ctx, opts, maxTotalCount)
+	if err != nil {
+		return err
+	}
+	h.Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if capped {
+		// реальное число больше, таблице достаточно знать что страниц много
+		h.Set("X-Total-Count-Capped", "true")
+	}
+	return nil
+}
+
+// listTransactionsPage, читает страницу истории и готовит ответ, ошибка несет код ответа и текст для клиента
+func (a *API) listTransactionsPage(ctx context.Context, opts repo.ListOptions, withTotal bool) (*txPage, int, error) {
+	items, err := a.Repo.ListTransactions(ctx, opts)
+	if err != nil {
+		status, msg := txListError(err)
+		return nil, status, errors.New(msg)
+	}
+	page := &txPage{header: http.Header{}}
+	if withTotal {
+		if err := a.setTotalCount(ctx, page.header, opts); err != nil {
+			return nil, http.StatusInternalServerError, errors.New("internal error")
+		}
+	}
+	if next := opts.NextCursor(items); next != "" {
+		page.header.Set("X-Next-Cursor", next)
+	}
 
 	// маппим доменную модель в dto, форматируем сумму и время в rfc3339
 	out := make([]txDTO, 0, len(items))
 	for _, t := range items {
-		out = append(out, txDTO{
-			ID:        t.ID,
-			From:      t.FromAddress,
-			To:        t.ToAddress,
-			Amount:    formatCents(t.AmountCents),
-			CreatedAt: t.CreatedAt.UTC().Format(time.RFC3339),
-		})
+		out = append(out, toTxDTO(t))
+	}
+	if page.body, err = json.Marshal(out); err != nil {
+		return nil, http.StatusInternalServerError, errors.New("internal error")
+	}
+	// как у json.Encoder в writeJSON, тело кончается переводом строки
+	page.body = append(page.body, '\n')
+	return page, 0, nil
+}
+
+// getTransaction, одна транзакция с инициатором и каналом, невидимая участнику дает 404
+func (a *API) getTransaction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	t, err := a.Repo.GetTransaction(r.Context(), id, txVisibility(r.Context()))
+	if err != nil {
+		if errors.Is(err, repo.ErrTransactionNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transaction not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
 	}
-	// успешный ответ со списком
-	writeJSON(w, http.StatusOK, out)
+	writeJSON(w, http.StatusOK, toTxDTO(t))
 }
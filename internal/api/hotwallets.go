@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// hotWalletDTO, горячий кошелек и глубина его очереди зачислений, hot_since пуст, если пометка уже снята, а очередь еще не разобрана
+type hotWalletDTO struct {
+	Address          string  `json:"address"`
+	HotSince         string  `json:"hot_since,omitempty"`
+	QueuedCredits    int64   `json:"queued_credits"`
+	Queued           string  `json:"queued"`
+	OldestAgeSeconds float64 `json:"oldest_age_seconds"`
+}
+
+// getHotWallets, горячие кошельки с глубиной очередей, сколько зачислений ждет, на какую сумму и как давно ждет самое старое
+func (a *API) getHotWallets(w http.ResponseWriter, r *http.Request) {
+	items, err := a.Repo.ListHotWallets(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	now := time.Now()
+	out := make([]hotWalletDTO, 0, len(items))
+	for _, h := range items {
+		d := hotWalletDTO{
+			Address:       h.Address,
+			QueuedCredits: h.PendingCount,
+			Queued:        formatCents(h.PendingCents),
+		}
+		if !h.Since.IsZero() {
+			d.HotSince = h.Since.UTC().Format(time.RFC3339)
+		}
+		if !h.OldestPending.IsZero() {
+			d.OldestAgeSeconds = now.Sub(h.OldestPending).Seconds()
+		}
+		out = append(out, d)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// hotReq, входная модель пометки горячего кошелька
+type hotReq struct {
+	Hot bool `json:"hot"`
+}
+
+// putWalletHot, вручную помечает кошелек горячим или снимает пометку, при включенном поиске остывший кошелек пометку со временем теряет
+func (a *API) putWalletHot(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	var req hotReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if err := a.Repo.SetWalletHot(r.Context(), addr, req.Hot, repo.ActorFromContext(r.Context())); err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// HeaderIdempotencyKey, ключ идемпотентности запроса, HeaderIdempotentReplayed, отметка ответа, взятого из сохраненного
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// idempotencyTTL, сколько помнить ответ по ключу, maxIdempotencyKeyLen, предел длины ключа
+const (
+	idempotencyTTL       = 24 * time.Hour
+	maxIdempotencyKeyLen = 128
+)
+
+// idempotent, запрос с заголовком Idempotency-Key выполняется один раз на участника и ключ, повтор получает сохраненный ответ вместе с заголовком Location, тот же ключ с другим телом дает 422, пока первый запрос выполняется повтор получает 409 с Retry-After, ошибки, которые стоит повторить, ключ не занимают
+func (a *API) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid idempotency key"})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+		if err != nil {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(append([]byte(r.Method+" "+r.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		ctx := r.Context()
+		actor := repo.ActorFromContext(ctx)
+		saved, err := a.Repo.BeginIdempotent(ctx, actor, key, fingerprint, idempotencyTTL)
+		switch {
+		case err == nil:
+		case errors.Is(err, repo.ErrIdempotencyKeyReused):
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "idempotency key reused with a different request"})
+			return
+		case errors.Is(err, repo.ErrIdempotencyInProgress):
+			writeRetryable(w, http.StatusConflict, "request with this idempotency key is in progress", a.retryAfter(contentionRetryAfter))
+			return
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		if saved != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set(HeaderIdempotentReplayed, "true")
+			if saved.Location != "" {
+				w.Header().Set("Location", saved.Location)
+			}
+			w.WriteHeader(saved.StatusCode)
+			_, _ = w.Write(saved.Body)
+			return
+		}
+
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// результат сохраняется и после обрыва соединения клиентом, иначе ключ останется занятым до истечения
+		ctx = context.WithoutCancel(ctx)
+		if rec.status >= http.StatusInternalServerError || rec.Header().Get("Retry-After") != "" {
+			err = a.Repo.ReleaseIdempotent(ctx, actor, key)
+		} else {
+			err = a.Repo.CompleteIdempotent(ctx, actor, key, repo.IdempotentResponse{StatusCode: rec.status, Body: rec.body.Bytes(), Location: rec.Header().Get("Location")})
+		}
+		if err != nil {
+			log.Printf("idempotency key %s/%s: %v", actor, key, err)
+		}
+	})
+}
+
+// responseRecorder, пропускает ответ клиенту и запоминает код и тело
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// jsonArray, пишет массив json по одному элементу, ответ начинается с первым элементом или закрытием,
+// пока ничего не записано, ошибку еще можно отдать обычным ответом json
+type jsonArray struct {
+	w   http.ResponseWriter
+	enc *json.Encoder
+	n   int
+}
+
+// newJSONArray, массив в тело ответа w
+func newJSONArray(w http.ResponseWriter) *jsonArray {
+	return &jsonArray{w: w, enc: json.NewEncoder(w)}
+}
+
+// started, ответ уже начат
+func (a *jsonArray) started() bool { return a.n > 0 }
+
+// add, дописывает элемент, первый элемент отправляет заголовки со статусом 200
+func (a *jsonArray) add(v any) error {
+	sep := ","
+	if a.n == 0 {
+		a.w.Header().Set("Content-Type", "application/json")
+		a.w.WriteHeader(http.StatusOK)
+		sep = "["
+	}
+	a.n++
+	if _, err := a.w.Write([]byte(sep)); err != nil {
+		return err
+	}
+	return a.enc.Encode(v)
+}
+
+// close, закрывает массив, без элементов отдает пустой
+func (a *jsonArray) close() error {
+	if a.n == 0 {
+		a.w.Header().Set("Content-Type", "application/json")
+		a.w.WriteHeader(http.StatusOK)
+		_, err := a.w.Write([]byte("[]\n"))
+		return err
+	}
+	_, err := a.w.Write([]byte("]\n"))
+	return err
+}
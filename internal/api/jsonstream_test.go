@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestJSONArray, элементы складываются в разбираемый массив, пустой массив тоже валиден
+func TestJSONArray(t *testing.T) {
+	for _, n := range []int{0, 1, 3} {
+		rr := httptest.NewRecorder()
+		arr := newJSONArray(rr)
+		for i := 0; i < n; i++ {
+			if err := arr.add(map[string]int{"i": i}); err != nil {
+				t.Fatalf("add: %v", err)
+			}
+		}
+		if err := arr.close(); err != nil {
+			t.Fatalf("close: %v", err)
+		}
+		var got []map[string]int
+		if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+			t.Fatalf("n=%d: %v, body=%q", n, err, rr.Body.String())
+		}
+		if len(got) != n || rr.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("n=%d: got %v", n, got)
+		}
+		for i, m := range got {
+			if m["i"] != i {
+				t.Fatalf("n=%d: element %d = %v", n, i, m)
+			}
+		}
+	}
+}
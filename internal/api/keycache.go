@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// keyCacheRetry, пауза переподключения слушателя уведомлений о ключах
+const keyCacheRetry = 3 * time.Second
+
+// KeyCache, кэш найденных ключей доступа по хэшу токена, снимает запрос к базе с каждого обращения, отзыв и ротация через api сбрасывают запись сразу,
+// на других экземплярах сервиса запись сбрасывается уведомлением базы после коммита, как в BalanceCache, кэш отдает записи, только пока слушатель подписан
+type KeyCache struct {
+	TTL time.Duration
+
+	mu    sync.Mutex
+	items map[string]cachedKey
+	// live, подписка на уведомления действует, gen, растет при каждом сбросе, ключ, прочитанный из базы до сброса, в кэш не кладется
+	live bool
+	gen  uint64
+}
+
+// cachedKey, запись кэша и момент, после которого ее нужно перечитать
+type cachedKey struct {
+	key   repo.APIKey
+	until time.Time
+}
+
+// NewKeyCache, конструктор, ttl равный нулю выключает кэш, до подписки Run кэш ничего не отдает
+func NewKeyCache(ttl time.Duration) *KeyCache {
+	return &KeyCache{TTL: ttl, items: make(map[string]cachedKey)}
+}
+
+// Run, слушает уведомления об изменении ключей до отмены контекста, после обрыва кэш очищается и не отдает записей, пока подписка не восстановится
+func (c *KeyCache) Run(ctx context.Context, src repo.Users) {
+	for {
+		err := src.ListenAPIKeys(ctx, func() { c.reset(true) }, c.InvalidateKey)
+		c.reset(false)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("key cache: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(keyCacheRetry):
+		}
+	}
+}
+
+// generation, поколение кэша, берется до чтения ключа из базы и передается в put
+func (c *KeyCache) generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// get, ключ из кэша, пока подписка действует, истекшие записи и ключи с вышедшим сроком действия не отдаются
+func (c *KeyCache) get(hash string, now time.Time) (repo.APIKey, bool) {
+	if c == nil || c.TTL <= 0 {
+		return repo.APIKey{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	it, ok := c.items[hash]
+	if !c.live || !ok {
+		return repo.APIKey{}, false
+	}
+	if !now.Before(it.until) {
+		delete(c.items, hash)
+		return repo.APIKey{}, false
+	}
+	return it.key, true
+}
+
+// put, кладет найденный ключ, если с взятия gen кэш не сбрасывали, запись не переживает срок действия ключа
+func (c *KeyCache) put(hash string, k repo.APIKey, gen uint64, now time.Time) {
+	if c == nil || c.TTL <= 0 {
+		return
+	}
+	until := now.Add(c.TTL)
+	if !k.ExpiresAt.IsZero() && k.ExpiresAt.Before(until) {
+		until = k.ExpiresAt
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.live || c.gen != gen {
+		return
+	}
+	c.items[hash] = cachedKey{key: k, until: until}
+}
+
+// InvalidateKey, сбрасывает записи ключа по его идентификатору, вызывается после отзыва и ротации на этом экземпляре и по уведомлению базы
+func (c *KeyCache) InvalidateKey(id int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for h, it := range c.items {
+		if it.key.ID == id {
+			delete(c.items, h)
+		}
+	}
+	c.gen++
+}
+
+// reset, очищает кэш, live, действует ли подписка
+func (c *KeyCache) reset(live bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = make(map[string]cachedKey)
+	c.gen++
+	c.live = live
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// TestKeyCache_ExpiryAndInvalidate, проверяет ttl, срок действия ключа и сброс по идентификатору
+func TestKeyCache_ExpiryAndInvalidate(t *testing.T) {
+	c := NewKeyCache(time.Minute)
+	c.reset(true)
+	now := time.Now()
+
+	c.put("h1", repo.APIKey{ID: 1}, c.generation(), now)
+	if _, ok := c.get("h1", now.Add(30*time.Second)); !ok {
+		t.Fatalf("want hit within ttl")
+	}
+	if _, ok := c.get("h1", now.Add(2*time.Minute)); ok {
+		t.Fatalf("want miss after ttl")
+	}
+
+	// ключ после ротации истекает раньше ttl
+	c.put("h2", repo.APIKey{ID: 2, ExpiresAt: now.Add(10 * time.Second)}, c.generation(), now)
+	if _, ok := c.get("h2", now.Add(20*time.Second)); ok {
+		t.Fatalf("want miss after key expiry")
+	}
+
+	c.put("h3", repo.APIKey{ID: 3}, c.generation(), now)
+	c.InvalidateKey(3)
+	if _, ok := c.get("h3", now); ok {
+		t.Fatalf("want miss after invalidate")
+	}
+
+	// ключ, прочитанный до сброса, в кэш не кладется
+	gen := c.generation()
+	c.InvalidateKey(5)
+	c.put("h5", repo.APIKey{ID: 5}, gen, now)
+	if _, ok := c.get("h5", now); ok {
+		t.Fatalf("want stale read dropped")
+	}
+
+	var disabled *KeyCache
+	disabled.put("h4", repo.APIKey{ID: 4}, 0, now)
+	if _, ok := disabled.get("h4", now); ok {
+		t.Fatalf("want nil cache to never hit")
+	}
+}
+
+// TestKeyCache_Notifications, кэш отдает ключ только при действующей подписке, отзыв на другом экземпляре приходит уведомлением
+// и ключ сразу перестает работать, после обрыва подписки ключ снова ищется в базе
+func TestKeyCache_Notifications(t *testing.T) {
+	var lookups int
+	revoked := false
+	m := newMockRepo()
+	m.LookupAPIKeyFunc = func(context.Context, string) (repo.APIKey, error) {
+		lookups++
+		if revoked {
+			return repo.APIKey{}, repo.ErrAPIKeyNotFound
+		}
+		return repo.APIKey{ID: 1, UserID: mockUserID}, nil
+	}
+
+	live := make(chan struct{})
+	notify := make(chan int64)
+	notified := make(chan struct{})
+	drop := make(chan struct{})
+	m.ListenAPIKeysFunc = func(ctx context.Context, listening func(), fn func(int64)) error {
+		listening()
+		close(live)
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-drop:
+				return errors.New("connection lost")
+			case id := <-notify:
+				fn(id)
+				notified <- struct{}{}
+			}
+		}
+	}
+
+	cache := NewKeyCache(time.Minute)
+	a := &API{Repo: m, Keys: cache}
+	lookup := func() error {
+		_, err := a.apiKeyPrincipal(context.Background(), "wk_other")
+		return err
+	}
+
+	// до подписки кэш не отдает записей
+	_ = lookup()
+	_ = lookup()
+	if lookups != 2 {
+		t.Fatalf("before listening: lookups %d", lookups)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cache.Run(ctx, m)
+	<-live
+
+	_ = lookup()
+	if err := lookup(); err != nil || lookups != 3 {
+		t.Fatalf("cached: err %v, lookups %d", err, lookups)
+	}
+
+	// ключ отозван на другом экземпляре
+	revoked = true
+	notify <- 1
+	<-notified
+	if err := lookup(); !errors.Is(err, errUnauthorized) {
+		t.Fatalf("after revoke notification: want unauthorized, got %v", err)
+	}
+
+	revoked = false
+	close(drop)
+	deadline := time.Now().Add(time.Second)
+	for {
+		cache.mu.Lock()
+		stopped := !cache.live
+		cache.mu.Unlock()
+		if stopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cache still live after disconnect")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	before := lookups
+	_ = lookup()
+	_ = lookup()
+	if lookups != before+2 {
+		t.Fatalf("after disconnect: want lookups from db, got %d", lookups-before)
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+	"gotechtask/internal/auth"
+)
+
+// Lanes, ограничение одновременных запросов с резервом для внутреннего трафика, администраторы и служебные задачи занимают общий семафор, публичные запросы дополнительно свой, меньший на резерв, поэтому поток публичных переводов не выбирает всю емкость пула базы
+type Lanes struct {
+	total    *semaphore.Weighted
+	public   *semaphore.Weighted
+	wait     time.Duration
+	capacity int64
+	inflight atomic.Int64
+}
+
+// NewLanes, capacity, сколько запросов обрабатывается одновременно, reserved, сколько из них недоступно публичным, wait, сколько запрос ждет места до 503, нулевая емкость выключает ограничение и дает nil
+func NewLanes(capacity, reserved int, wait time.Duration) *Lanes {
+	if capacity <= 0 {
+		return nil
+	}
+	if reserved < 0 {
+		reserved = 0
+	}
+	if reserved >= capacity {
+		reserved = capacity - 1
+	}
+	return &Lanes{
+		total:    semaphore.NewWeighted(int64(capacity)),
+		public:   semaphore.NewWeighted(int64(capacity - reserved)),
+		wait:     wait,
+		capacity: int64(capacity),
+	}
+}
+
+// load, доля занятой емкости от 0 до 1, без ограничения ноль
+func (l *Lanes) load() float64 {
+	if l == nil {
+		return 0
+	}
+	return float64(l.inflight.Load()) / float64(l.capacity)
+}
+
+// acquire, занимает место в своей полосе, internal только в общем семафоре, release освобождает
+func (l *Lanes) acquire(ctx context.Context, internal bool) (release func(), err error) {
+	if l.wait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.wait)
+		defer cancel()
+	}
+	if internal {
+		if err := l.total.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+		l.inflight.Add(1)
+		return func() { l.inflight.Add(-1); l.total.Release(1) }, nil
+	}
+	if err := l.public.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	if err := l.total.Acquire(ctx, 1); err != nil {
+		l.public.Release(1)
+		return nil, err
+	}
+	l.inflight.Add(1)
+	return func() { l.inflight.Add(-1); l.total.Release(1); l.public.Release(1) }, nil
+}
+
+// limitLanes, ставит запрос в полосу по участнику, администратор во внутреннюю, остальные в публичную, не дождавшийся места получает 503 с подсказкой повтора
+func (a *API) limitLanes(next http.Handler) http.Handler {
+	if a.Lanes == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := a.Lanes.acquire(r.Context(), auth.FromContext(r.Context()).Admin)
+		if err != nil {
+			writeRetryable(w, http.StatusServiceUnavailable, "server busy", a.retryAfter(busyRetryAfter))
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotechtask/internal/auth"
+)
+
+// TestLanes_ReserveForAdmin, публичные запросы занимают не больше емкости без резерва, лишний ждет и получает 503, администратор проходит в резерв
+func TestLanes_ReserveForAdmin(t *testing.T) {
+	a := &API{Lanes: NewLanes(2, 1, 20*time.Millisecond)}
+	hold := make(chan struct{})
+	entered := make(chan struct{}, 4)
+	h := a.limitLanes(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		if r.URL.Path == "/hold" {
+			<-hold
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// публичный запрос занимает единственное публичное место
+	done := make(chan int)
+	go func() {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/hold", nil))
+		done <- rr.Code
+	}()
+	<-entered
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/public", nil))
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("second public: want 503 with Retry-After, got %d", rr.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Admin: true}))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("admin: want 200 from reserve, got %d", rr.Code)
+	}
+
+	close(hold)
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("held public: got %d", code)
+	}
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/public", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("public after release: got %d", rr.Code)
+	}
+}
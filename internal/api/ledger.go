@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// ledgerMismatchDTO, расхождение колонки баланса со счетом, journal только у полной сверки, at только у теневого чтения
+type ledgerMismatchDTO struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"`
+	Ledger  string `json:"ledger"`
+	Journal string `json:"journal,omitempty"`
+	At      string `json:"at,omitempty"`
+}
+
+// ledgerShadowDTO, итоги теневого чтения этого процесса с запуска
+type ledgerShadowDTO struct {
+	Reads      int64               `json:"reads"`
+	Compared   int64               `json:"compared"`
+	Unopened   int64               `json:"unopened"`
+	Mismatches int64               `json:"mismatches"`
+	Recent     []ledgerMismatchDTO `json:"recent"`
+}
+
+// ledgerCheckDTO, сверка колонки баланса со счетами, ok, расхождений нет, total, все расхождения, в mismatches не больше сотни
+type ledgerCheckDTO struct {
+	Mode       repo.LedgerMode     `json:"mode"`
+	OK         bool                `json:"ok"`
+	Wallets    int64               `json:"wallets"`
+	Opened     int64               `json:"opened"`
+	Total      int64               `json:"total"`
+	Mismatches []ledgerMismatchDTO `json:"mismatches"`
+	Shadow     ledgerShadowDTO     `json:"shadow"`
+}
+
+// toLedgerMismatchDTO, расхождение в ответ
+func toLedgerMismatchDTO(m repo.LedgerMismatch) ledgerMismatchDTO {
+	out := ledgerMismatchDTO{Address: m.Address, Balance: formatCents(m.BalanceCents), Ledger: formatCents(m.LedgerCents)}
+	if m.At.IsZero() {
+		out.Journal = formatCents(m.JournalCents)
+	} else {
+		out.At = m.At.UTC().Format(time.RFC3339)
+	}
+	return out
+}
+
+// getLedgerCheck, готовность перехода на счета, полная сверка каждого открытого счета с колонкой баланса и с проводками,
+// рядом режим и итоги теневого чтения этого процесса, переключать чтение на счета можно, когда ok держится под нагрузкой
+func (a *API) getLedgerCheck(w http.ResponseWriter, r *http.Request) {
+	c, err := a.Repo.CompareLedger(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	st := repo.LedgerShadowStats()
+	out := ledgerCheckDTO{
+		Mode:       repo.CurrentLedgerMode(),
+		OK:         c.Total == 0,
+		Wallets:    c.Wallets,
+		Opened:     c.Opened,
+		Total:      c.Total,
+		Mismatches: make([]ledgerMismatchDTO, 0, len(c.Mismatches)),
+		Shadow: ledgerShadowDTO{
+			Reads:      st.Reads,
+			Compared:   st.Compared,
+			Unopened:   st.Unopened,
+			Mismatches: st.Mismatches,
+			Recent:     make([]ledgerMismatchDTO, 0, len(st.Recent)),
+		},
+	}
+	for _, m := range c.Mismatches {
+		out.Mismatches = append(out.Mismatches, toLedgerMismatchDTO(m))
+	}
+	for _, m := range st.Recent {
+		out.Shadow.Recent = append(out.Shadow.Recent, toLedgerMismatchDTO(m))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// deleteLedger, удаляет счета и проводки перед повторным включением двойной записи, пока запись включена, отказывает 409,
+// режим проверяется только у этого процесса, остальные экземпляры к этому времени должны быть выключены
+func (a *API) deleteLedger(w http.ResponseWriter, r *http.Request) {
+	if repo.CurrentLedgerMode() != repo.LedgerOff {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "ledger writes are on"})
+		return
+	}
+	n, err := a.Repo.ResetLedger(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"deleted": n})
+}
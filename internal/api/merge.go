@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// mergeReq, входная модель слияния, into, кошелек-преемник
+type mergeReq struct {
+	Into string `json:"into"`
+}
+
+// writeWalletClosed, ответ 410 на чтение кошелька, закрытого слиянием, с адресом преемника
+func writeWalletClosed(w http.ResponseWriter, wl repo.Wallet) {
+	writeJSON(w, http.StatusGone, map[string]string{
+		"error":     "wallet closed",
+		"address":   wl.Address,
+		"successor": wl.Successor,
+		"closed_at": wl.ClosedAt.UTC().Format(time.RFC3339),
+	})
+}
+
+// postWalletMerge, сливает кошелек из пути в into, баланс переходит преемнику одной операцией вида merge, кошелек закрывается,
+// его история остается в журнале, чтение его баланса дальше дает 410 с адресом преемника, переводы с ним отклоняются
+func (a *API) postWalletMerge(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	var req mergeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if len(addr) != 64 || len(req.Into) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	m, err := a.Repo.MergeWallet(ctx, addr, req.Into, repo.ActorFromContext(r.Context()))
+	if err != nil {
+		var refused *repo.MergeRefusedError
+		switch {
+		case errors.As(err, &refused):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "wallet cannot be merged", "address": refused.Address, "reason": refused.Reason})
+		case errors.Is(err, repo.ErrSameAddress):
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "into must differ from the merged wallet"})
+		case errors.Is(err, repo.ErrWalletClosed):
+			writeJSON(w, http.StatusConflict, rejectionBody("wallet closed", err))
+		default:
+			a.writeTransferError(w, err)
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"address":   m.From,
+		"successor": m.Into,
+		"moved":     formatCents(m.MovedCents),
+		"closed_at": m.ClosedAt.UTC().Format(time.RFC3339),
+	})
+}
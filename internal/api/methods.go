@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// routeMethods, методы, которые проверяются при ответе на OPTIONS, в порядке заголовка Allow
+var routeMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// methods, общие HEAD и OPTIONS для всех маршрутов роутера, HEAD без своего маршрута идет в обработчик GET, тело ответа отбрасывает сервер,
+// OPTIONS отвечает 204 с методами маршрута в Allow и Access-Control-Allow-Methods до аутентификации, предварительный запрос браузера токена не несет,
+// путь без маршрутов уходит дальше и получает 404
+func methods(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		head := middleware.GetHead(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodOptions {
+				head.ServeHTTP(w, r)
+				return
+			}
+			allowed := allowedMethods(routes, r)
+			if len(allowed) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			allow := strings.Join(append(allowed, http.MethodOptions), ", ")
+			w.Header().Set("Allow", allow)
+			if r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", allow)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// allowedMethods, методы, для которых у пути запроса есть маршрут, HEAD есть везде, где есть GET
+func allowedMethods(routes chi.Routes, r *http.Request) []string {
+	path := r.URL.RawPath
+	if path == "" {
+		path = r.URL.Path
+	}
+	var out []string
+	get := routes.Match(chi.NewRouteContext(), http.MethodGet, path)
+	for _, m := range routeMethods {
+		if (m == http.MethodHead && get) || routes.Match(chi.NewRouteContext(), m, path) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
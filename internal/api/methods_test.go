@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestMethods, OPTIONS перечисляет методы маршрута без обращения к обработчикам, HEAD идет в GET, свой HEAD не перекрывается
+func TestMethods(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(methods(r))
+	var gets int
+	r.Group(func(r chi.Router) {
+		r.Use(func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			})
+		})
+		r.Post("/private", func(http.ResponseWriter, *http.Request) {})
+	})
+	r.Get("/item/{id}", func(w http.ResponseWriter, _ *http.Request) {
+		gets++
+		_, _ = w.Write([]byte("body"))
+	})
+	r.Delete("/item/{id}", func(http.ResponseWriter, *http.Request) {})
+	r.Head("/own", func(w http.ResponseWriter, _ *http.Request) { w.Header().Set("X-Own", "1") })
+	r.Get("/own", func(http.ResponseWriter, *http.Request) { t.Fatalf("GET handler for HEAD with own route") })
+
+	do := func(method, path string, h http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range h {
+			req.Header[k] = v
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do(http.MethodOptions, "/item/7", http.Header{"Origin": {"https://app"}, "Access-Control-Request-Method": {"DELETE"}})
+	if rr.Code != http.StatusNoContent || rr.Header().Get("Allow") != "GET, HEAD, DELETE, OPTIONS" || rr.Header().Get("Access-Control-Allow-Methods") != rr.Header().Get("Allow") {
+		t.Fatalf("options item: %d %q %q", rr.Code, rr.Header().Get("Allow"), rr.Header().Get("Access-Control-Allow-Methods"))
+	}
+	// предварительный запрос проходит мимо аутентификации группы
+	if rr := do(http.MethodOptions, "/private", nil); rr.Code != http.StatusNoContent || rr.Header().Get("Allow") != "POST, OPTIONS" {
+		t.Fatalf("options private: %d %q", rr.Code, rr.Header().Get("Allow"))
+	}
+	if rr := do(http.MethodOptions, "/missing", nil); rr.Code != http.StatusNotFound {
+		t.Fatalf("options missing: %d", rr.Code)
+	}
+
+	if rr := do(http.MethodHead, "/item/7", nil); rr.Code != http.StatusOK || gets != 1 {
+		t.Fatalf("head: %d, gets %d", rr.Code, gets)
+	}
+	if rr := do(http.MethodHead, "/own", nil); rr.Header().Get("X-Own") != "1" {
+		t.Fatalf("own head route not used")
+	}
+	if rr := do(http.MethodPut, "/item/7", nil); rr.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("put: %d", rr.Code)
+	}
+}
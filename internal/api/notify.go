@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/mail"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// walletEmailReq, входная модель адреса почты кошелька, пустая строка очищает адрес
+type walletEmailReq struct {
+	Email string `json:"email"`
+}
+
+// putWalletEmail, задает адрес почты кошелька для квитанций
+func (a *API) putWalletEmail(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	var req walletEmailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	// хранится только сам адрес, имя из формы "Name <addr>" в поле To письма не нужно
+	if req.Email != "" {
+		parsed, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid email"})
+			return
+		}
+		req.Email = parsed.Address
+	}
+
+	err := a.Repo.SetWalletEmail(r.Context(), addr, req.Email, repo.ActorFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// lowBalanceReq, входная модель порога низкого баланса, порог в валюте, ноль выключает
+type lowBalanceReq struct {
+	Threshold float64 `json:"threshold"`
+}
+
+// putLowBalance, задает порог низкого баланса кошелька, перевод, опустивший баланс ниже порога, ставит письмо на почту кошелька, задать порог может тот, кто распоряжается кошельком
+func (a *API) putLowBalance(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	var req lowBalanceReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Threshold < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "threshold must be >= 0"})
+		return
+	}
+	if toCents(req.Threshold) > money.MaxCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "threshold too large"})
+		return
+	}
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	err := a.Repo.SetLowBalanceThreshold(r.Context(), addr, toCents(req.Threshold), repo.ActorFromContext(r.Context()))
+	if err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestPutWalletEmail, в кошельке хранится сам адрес без имени, пустая строка очищает, неразбираемый адрес дает 400
+func TestPutWalletEmail(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		email string
+		code  int
+		want  string
+	}{
+		{name: "plain", email: "u@example.com", code: http.StatusOK, want: "u@example.com"},
+		{name: "with name", email: "User <u@example.com>", code: http.StatusOK, want: "u@example.com"},
+		{name: "clear", email: "", code: http.StatusOK, want: ""},
+		{name: "invalid", email: "not an email", code: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockRepo()
+			m.SetWalletEmailFunc = func(context.Context, string, string, string) error { return nil }
+			r := chi.NewRouter()
+			(&API{Repo: m, AdminToken: testAdminToken}).Routes(r)
+
+			req := httptest.NewRequest(http.MethodPut, "/api/admin/wallet/w1/email", strings.NewReader(`{"email":"`+tc.email+`"}`))
+			req.Header.Set("X-Admin-Token", testAdminToken)
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != tc.code {
+				t.Fatalf("status %d, body %s", rr.Code, rr.Body.String())
+			}
+			calls := m.SetWalletEmailCalls()
+			if tc.code != http.StatusOK {
+				if len(calls) != 0 {
+					t.Fatal("invalid email stored")
+				}
+				return
+			}
+			if len(calls) != 1 || calls[0].Email != tc.want {
+				t.Fatalf("stored %+v, want %q", calls, tc.want)
+			}
+		})
+	}
+}
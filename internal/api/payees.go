@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+)
+
+// payeeAlias, допустимый псевдоним получателя
+var payeeAlias = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// payeeReq, входная модель сохранения получателя, псевдоним и адрес
+type payeeReq struct {
+	Alias   string `json:"alias"`
+	Address string `json:"address"`
+}
+
+// payeeDTO, представление получателя для ответа
+type payeeDTO struct {
+	Alias     string `json:"alias"`
+	Address   string `json:"address"`
+	CreatedAt string `json:"created_at"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// getPayees, адресная книга кошелька
+func (a *API) getPayees(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	deleted, ok := listDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	var items []repo.Payee
+	var err error
+	if deleted {
+		items, err = a.Repo.ListDeletedPayees(r.Context(), addr)
+	} else {
+		items, err = a.Repo.ListPayees(r.Context(), addr)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	out := make([]payeeDTO, 0, len(items))
+	for _, p := range items {
+		out = append(out, toPayeeDTO(p))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// postPayee, сохраняет получателя под псевдонимом, адрес получателя должен быть существующим кошельком
+func (a *API) postPayee(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	var req payeeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if !payeeAlias.MatchString(req.Alias) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid alias"})
+		return
+	}
+	if len(req.Address) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	if _, err := a.Repo.WalletOwner(r.Context(), req.Address); err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "payee wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	p, err := a.Repo.AddPayee(r.Context(), addr, req.Alias, req.Address)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrPayeeExists):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "payee alias already exists"})
+		case errors.Is(err, repo.ErrWalletNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+	writeJSON(w, http.StatusCreated, toPayeeDTO(p))
+}
+
+// deletePayee, удаляет получателя по псевдониму, псевдоним сразу свободен, вернуть получателя может администратор
+func (a *API) deletePayee(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	if err := a.Repo.DeletePayee(r.Context(), addr, chi.URLParam(r, "alias")); err != nil {
+		if errors.Is(err, repo.ErrPayeeNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "payee not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// restorePayee, администратор возвращает последнего удаленного получателя с псевдонимом, псевдоним, занятый новым получателем, дает 409
+func (a *API) restorePayee(w http.ResponseWriter, r *http.Request) {
+	p, err := a.Repo.RestorePayee(r.Context(), chi.URLParam(r, "address"), chi.URLParam(r, "alias"), repo.ActorFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrPayeeNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "deleted payee not found"})
+		case errors.Is(err, repo.ErrPayeeExists):
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "payee alias already exists"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, toPayeeDTO(p))
+}
+
+// listDeleted, запрошен ли вместо обычного списка список удаленных, deleted=true, удаленные видит только администратор, остальным 403,
+// ошибка уже записана в ответ
+func listDeleted(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	deleted, _ := strconv.ParseBool(r.URL.Query().Get("deleted"))
+	if deleted && !auth.FromContext(r.Context()).Admin {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "deleted items are visible to admin only"})
+		return false, false
+	}
+	return deleted, true
+}
+
+// toPayeeDTO, маппинг получателя в ответ
+func toPayeeDTO(p repo.Payee) payeeDTO {
+	d := payeeDTO{
+		Alias:     p.Alias,
+		Address:   p.Address,
+		CreatedAt: p.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !p.DeletedAt.IsZero() {
+		d.DeletedAt = p.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return d
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// сроки запроса платежа, по умолчанию и максимальный
+const (
+	defaultPaymentRequestTTL = 7 * 24 * time.Hour
+	maxPaymentRequestTTL     = 30 * 24 * time.Hour
+)
+
+// paymentRequestReq, входная модель запроса платежа, From, кошелек получателя денег, To, кошелек плательщика, срок в секундах, ноль берет срок по умолчанию
+type paymentRequestReq struct {
+	From             string  `json:"from"`
+	To               string  `json:"to"`
+	Amount           float64 `json:"amount"`
+	Memo             string  `json:"memo"`
+	ExpiresInSeconds int64   `json:"expires_in_seconds"`
+}
+
+// paymentRequestDTO, представление запроса платежа
+type paymentRequestDTO struct {
+	ID         int64  `json:"request_id"`
+	Payee      string `json:"payee"`
+	Payer      string `json:"payer"`
+	Amount     string `json:"amount"`
+	Memo       string `json:"memo,omitempty"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+}
+
+// postPaymentRequest, кошелек выставляет запрос суммы другому кошельку, распоряжаться должен владелец кошелька получателя
+func (a *API) postPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	var req paymentRequestReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if len(req.From) != 64 || len(req.To) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	if req.Amount <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
+		return
+	}
+	if toCents(req.Amount) > money.MaxCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount too large"})
+		return
+	}
+	if len(req.Memo) > 256 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "memo too long"})
+		return
+	}
+	ttl := defaultPaymentRequestTTL
+	if req.ExpiresInSeconds != 0 {
+		ttl = time.Duration(req.ExpiresInSeconds) * time.Second
+		if req.ExpiresInSeconds < 0 || ttl > maxPaymentRequestTTL {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "expires_in_seconds out of range"})
+			return
+		}
+	}
+
+	if err := a.authorizeWallet(r.Context(), req.From); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	p, err := a.Repo.CreatePaymentRequest(r.Context(), repo.PaymentRequest{
+		Payee:       req.From,
+		Payer:       req.To,
+		AmountCents: toCents(req.Amount),
+		Memo:        req.Memo,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		a.writeTransferError(w, err)
+		return
+	}
+	writeCreated(w, "/api/requests/"+strconv.FormatInt(p.ID, 10), toPaymentRequestDTO(p))
+}
+
+// getPaymentRequest, запрос платежа по id, виден владельцам кошельков получателя и плательщика, чужой запрос неотличим от отсутствующего
+func (a *API) getPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	p, err := a.Repo.GetPaymentRequest(r.Context(), id)
+	if err == nil && a.authorizeWallet(r.Context(), p.Payee) != nil && a.authorizeWallet(r.Context(), p.Payer) != nil {
+		err = repo.ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		a.writePaymentRequestError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toPaymentRequestDTO(p))
+}
+
+// getPaymentRequests, запросы кошелька, role=payer входящие к оплате, role=payee выставленные им, status фильтрует по состоянию
+func (a *API) getPaymentRequests(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	var f repo.PaymentRequestFilter
+	switch q.Get("role") {
+	case "", "payer":
+		f.AsPayer = true
+	case "payee":
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "role must be payer or payee"})
+		return
+	}
+	switch s := q.Get("status"); s {
+	case "", repo.PaymentRequestPending, repo.PaymentRequestPaid, repo.PaymentRequestDeclined, repo.PaymentRequestExpired:
+		f.Status = s
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		f.Limit = n
+	}
+
+	items, err := a.Repo.ListPaymentRequests(r.Context(), addr, f)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	out := make([]paymentRequestDTO, 0, len(items))
+	for _, p := range items {
+		out = append(out, toPaymentRequestDTO(p))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// acceptPaymentRequest, плательщик оплачивает запрос, перевод и отметка об оплате атомарны, крупная сумма ждет второго фактора как обычный перевод
+func (a *API) acceptPaymentRequest(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.payerRequest(w, r)
+	if !ok {
+		return
+	}
+	switch p.Status {
+	case repo.PaymentRequestPending:
+	case repo.PaymentRequestExpired:
+		a.writePaymentRequestError(w, repo.ErrPaymentRequestExpired)
+		return
+	default:
+		a.writePaymentRequestError(w, repo.ErrPaymentRequestResolved)
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	need, err := a.needsSecondFactor(ctx, p.Payer, p.AmountCents)
+	if err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	if need {
+		a.createPendingTransfer(w, r, p.Payer, p.Payee, p.AmountCents, p.ID)
+		return
+	}
+
+	p, err = a.Repo.PayPaymentRequest(ctx, p.ID)
+	if err != nil {
+		a.writePaymentRequestError(w, err)
+		return
+	}
+	a.transferCommitted(p.Payer, p.Payee)
+	writeJSON(w, http.StatusOK, toPaymentRequestDTO(p))
+}
+
+// declinePaymentRequest, плательщик отклоняет запрос
+func (a *API) declinePaymentRequest(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.payerRequest(w, r)
+	if !ok {
+		return
+	}
+	p, err := a.Repo.DeclinePaymentRequest(r.Context(), p.ID)
+	if err != nil {
+		a.writePaymentRequestError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toPaymentRequestDTO(p))
+}
+
+// payerRequest, запрос платежа из пути, распоряжаться им может только плательщик, чужой запрос неотличим от отсутствующего
+func (a *API) payerRequest(w http.ResponseWriter, r *http.Request) (repo.PaymentRequest, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return repo.PaymentRequest{}, false
+	}
+	p, err := a.Repo.GetPaymentRequest(r.Context(), id)
+	if err == nil && a.authorizeWallet(r.Context(), p.Payer) != nil {
+		err = repo.ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		a.writePaymentRequestError(w, err)
+		return repo.PaymentRequest{}, false
+	}
+	return p, true
+}
+
+// writePaymentRequestError, маппит ошибки запроса платежа в http ответ, ошибки самого перевода как у обычного перевода
+func (a *API) writePaymentRequestError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repo.ErrPaymentRequestNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "payment request not found"})
+	case errors.Is(err, repo.ErrPaymentRequestResolved):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "payment request already resolved"})
+	case errors.Is(err, repo.ErrPaymentRequestExpired):
+		writeJSON(w, http.StatusGone, map[string]string{"error": "payment request expired"})
+	default:
+		a.writeTransferError(w, err)
+	}
+}
+
+// toPaymentRequestDTO, маппинг запроса платежа в ответ
+func toPaymentRequestDTO(p repo.PaymentRequest) paymentRequestDTO {
+	dto := paymentRequestDTO{
+		ID:        p.ID,
+		Payee:     p.Payee,
+		Payer:     p.Payer,
+		Amount:    formatCents(p.AmountCents),
+		Memo:      p.Memo,
+		Status:    p.Status,
+		CreatedAt: p.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt: p.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+	if !p.ResolvedAt.IsZero() {
+		dto.ResolvedAt = p.ResolvedAt.UTC().Format(time.RFC3339)
+	}
+	return dto
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// versionETag, сильный тег изменяемого ресурса из его версии
+func versionETag(version int64) string {
+	return `"v` + strconv.FormatInt(version, 10) + `"`
+}
+
+// ifMatchVersion, версия ресурса из If-Match изменяющего запроса, * дает ноль, тогда изменение применяется к любой версии,
+// без заголовка ответ 428, чтобы правки двух клиентов не затирали друг друга молча, слабый, чужой или не один тег дает 412,
+// ошибка уже записана в ответ
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" {
+		writeJSON(w, http.StatusPreconditionRequired, map[string]string{"error": "If-Match required, use the ETag of the resource"})
+		return 0, false
+	}
+	if h == "*" {
+		return 0, true
+	}
+	if v, ok := strings.CutPrefix(h, `"v`); ok && strings.HasSuffix(v, `"`) {
+		if n, err := strconv.ParseInt(strings.TrimSuffix(v, `"`), 10, 64); err == nil && n > 0 {
+			return n, true
+		}
+	}
+	writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "resource was modified"})
+	return 0, false
+}
+
+// writeVersionMismatch, ответ 412 на изменение устаревшей версии, текущий тег в ETag, клиенту остается перечитать ресурс и повторить правку
+func writeVersionMismatch(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", versionETag(version))
+	writeJSON(w, http.StatusPreconditionFailed, map[string]string{"error": "resource was modified"})
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	qrcode "github.com/skip2/go-qrcode"
+	"gotechtask/internal/address"
+	"gotechtask/internal/payuri"
+)
+
+// размеры картинки qr кода в пикселях
+const (
+	defaultQRSize = 256
+	minQRSize     = 128
+	maxQRSize     = 1024
+)
+
+// getWalletQR, qr код для приема на кошелек, format=png|svg, size для png, amount и memo попадают в кодируемую ссылку wallet:
+func (a *API) getWalletQR(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	q := r.URL.Query()
+	var amountCents int64
+	if v := q.Get("amount"); v != "" {
+		var err error
+		if amountCents, err = payuri.ParseAmount(v); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0 with at most two decimals"})
+			return
+		}
+	}
+	memo := q.Get("memo")
+	if len(memo) > payuri.MaxMemo {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "memo too long"})
+		return
+	}
+	size := defaultQRSize
+	if v := q.Get("size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minQRSize || n > maxQRSize {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("size must be between %d and %d", minQRSize, maxQRSize)})
+			return
+		}
+		size = n
+	}
+
+	uri := payuri.URI{Address: address.Checksum(addr), AmountCents: amountCents, Memo: memo}.String()
+	code, err := qrcode.New(uri, qrcode.Medium)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	// сама ссылка в заголовке, клиенту не нужно декодировать картинку, чтобы показать ее текстом
+	w.Header().Set("X-Payment-URI", uri)
+	switch q.Get("format") {
+	case "", "png":
+		png, err := code.PNG(size)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write(png)
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		_, _ = w.Write([]byte(qrSVG(code.Bitmap())))
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be png or svg"})
+	}
+}
+
+// qrSVG, рисует матрицу qr кода в svg, модуль единичный квадрат, масштабирует клиент
+func qrSVG(bits [][]bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, len(bits), len(bits))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bits), len(bits))
+	for y, row := range bits {
+		for x, on := range row {
+			if on {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return b.String()
+}
+
+// paymentURIReq, входная модель разбора ссылки
+type paymentURIReq struct {
+	URI string `json:"uri"`
+}
+
+// paymentURIResp, разобранная ссылка, каноничная форма и заготовка тела /api/send, отправителя клиент подставляет сам
+type paymentURIResp struct {
+	URI     string  `json:"uri"`
+	Address string  `json:"address"`
+	Amount  string  `json:"amount,omitempty"`
+	Memo    string  `json:"memo,omitempty"`
+	Send    sendReq `json:"send"`
+}
+
+// postParsePaymentURI, проверяет ссылку wallet: и переводит ее в тело перевода, существование кошелька не проверяется, это сделает сам перевод
+func (a *API) postParsePaymentURI(w http.ResponseWriter, r *http.Request) {
+	var req paymentURIReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	u, err := payuri.Parse(req.URI)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": strings.TrimPrefix(err.Error(), "payuri: ")})
+		return
+	}
+	resp := paymentURIResp{
+		URI:     u.String(),
+		Address: u.Address,
+		Memo:    u.Memo,
+		Send:    sendReq{To: u.Address},
+	}
+	if u.AmountCents > 0 {
+		resp.Amount = formatCents(u.AmountCents)
+		resp.Send.Amount = float64(u.AmountCents) / 100
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// queryStatDTO, статистика одного запроса к базе, время в миллисекундах
+type queryStatDTO struct {
+	SQL     string  `json:"sql"`
+	Calls   int64   `json:"calls"`
+	Errors  int64   `json:"errors"`
+	Slow    int64   `json:"slow"`
+	TotalMs float64 `json:"total_ms"`
+	MeanMs  float64 `json:"mean_ms"`
+	MaxMs   float64 `json:"max_ms"`
+}
+
+// getQueryStats, запросы к базе с момента запуска или сброса, самые долгие в сумме первыми, limit по умолчанию 50
+func (a *API) getQueryStats(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if s := r.URL.Query().Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = v
+	}
+
+	stats := a.Queries.Snapshot(limit)
+	out := make([]queryStatDTO, 0, len(stats))
+	for _, s := range stats {
+		d := queryStatDTO{
+			SQL:     s.SQL,
+			Calls:   s.Calls,
+			Errors:  s.Errors,
+			Slow:    s.Slow,
+			TotalMs: float64(s.Total.Microseconds()) / 1000,
+			MaxMs:   float64(s.Max.Microseconds()) / 1000,
+		}
+		if s.Calls > 0 {
+			d.MeanMs = d.TotalMs / float64(s.Calls)
+		}
+		out = append(out, d)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"slow_threshold_ms": a.Queries.SlowThreshold().Milliseconds(),
+		"queries":           out,
+	})
+}
+
+// deleteQueryStats, обнуляет статистику запросов, например перед замером
+func (a *API) deleteQueryStats(w http.ResponseWriter, r *http.Request) {
+	a.Queries.Reset()
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gotechtask/internal/auth"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// комиссия перевода в базисных пунктах и ее округление до цента, переводы /api/send идут без комиссии, оценка считает ее тем же правилом, что считался бы ненулевой тариф
+const (
+	transferFeeBps      = 0
+	transferFeeRounding = money.HalfUp
+)
+
+// quoteLimitsDTO, пределы перевода, available, сколько отправитель может перевести сейчас с учетом овердрафта, available_after, что останется после перевода,
+// recipient_over_limit, зачисление превысило бы предел баланса получателя, recipient_headroom, сколько еще примет получатель, только если вызывающему доступен его кошелек,
+// иначе по нему читался бы чужой баланс, two_factor_threshold, с какой суммы перевод с личного кошелька ждет подтверждения, нет, если порог выключен
+type quoteLimitsDTO struct {
+	Available          string `json:"available"`
+	AvailableAfter     string `json:"available_after"`
+	OverdraftLimit     string `json:"overdraft_limit"`
+	RecipientOverLimit bool   `json:"recipient_over_limit"`
+	RecipientHeadroom  string `json:"recipient_headroom,omitempty"`
+	TwoFactorThreshold string `json:"two_factor_threshold,omitempty"`
+}
+
+// sendQuoteDTO, оценка перевода, ok, перевод сейчас прошел бы, confirmation, перевод будет отложен до подтверждения этим способом, totp или email,
+// rejection, отказ с тем же телом, что вернул бы /api/send
+type sendQuoteDTO struct {
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Amount       string         `json:"amount"`
+	Fee          string         `json:"fee"`
+	Total        string         `json:"total"`
+	OK           bool           `json:"ok"`
+	Confirmation string         `json:"confirmation,omitempty"`
+	Rejection    map[string]any `json:"rejection,omitempty"`
+	Limits       quoteLimitsDTO `json:"limits"`
+}
+
+// postSendQuote, оценка перевода для экрана подтверждения, тело и проверки как у /api/send, средства не двигаются и ничего не пишется,
+// доменный отказ приходит в rejection с кодом 200, ошибки тела и доступа к кошельку теми же кодами, что у перевода,
+// строки кошельков не блокируются, поэтому одновременный перевод может изменить исход
+func (a *API) postSendQuote(w http.ResponseWriter, r *http.Request) {
+	req, amountCents, ok := a.decodeSend(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	q, err := a.Repo.QuoteTransfer(ctx, req.From, req.To, amountCents)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	fee := money.Fee(amountCents, transferFeeBps, transferFeeRounding)
+	total := amountCents + fee
+	out := sendQuoteDTO{
+		From:   req.From,
+		To:     req.To,
+		Amount: formatCents(amountCents),
+		Fee:    formatCents(fee),
+		Total:  formatCents(total),
+		Limits: quoteLimitsDTO{
+			Available:      formatCents(q.AvailableCents()),
+			AvailableAfter: formatCents(q.AvailableCents() - total),
+			OverdraftLimit: formatCents(q.FromOverdraftCents),
+		},
+	}
+	if q.ToFound {
+		headroom := max(money.MaxCents-q.ToBalanceCents, 0)
+		out.Limits.RecipientOverLimit = headroom < amountCents
+		// запас показывается только по кошельку, баланс которого вызывающий и так может прочитать
+		switch err := a.authorizeWallet(ctx, req.To); {
+		case err == nil:
+			out.Limits.RecipientHeadroom = formatCents(headroom)
+		case !errors.Is(err, errForbidden) && !errors.Is(err, repo.ErrWalletNotFound):
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+	th := a.settings().TwoFactorThresholdCents
+	if th > 0 {
+		out.Limits.TwoFactorThreshold = formatCents(th)
+	}
+
+	switch {
+	case q.Err != nil:
+		_, msg, known := transferRejection(q.Err)
+		if !known {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		out.Rejection = rejectionBody(msg, q.Err)
+	default:
+		// крупный перевод с личного кошелька его владельцем ждет подтверждения, без способа подтверждения отклоняется
+		need, err := a.needsSecondFactor(ctx, req.From, amountCents)
+		if err != nil {
+			writeWalletAccessError(w, err)
+			return
+		}
+		if need {
+			method, _, err := a.secondFactor(ctx, auth.FromContext(ctx).UserID)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+				return
+			}
+			if method == "" {
+				out.Rejection = map[string]any{"error": noSecondFactorMsg}
+			}
+			out.Confirmation = method
+		}
+	}
+	out.OK = out.Rejection == nil
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// TestSendQuoteSecondFactor, крупный перевод владельца с личного кошелька оценивается со способом подтверждения,
+// без totp и почты оценка отказывает, как отказал бы перевод, сам перевод не вызывается
+func TestSendQuoteSecondFactor(t *testing.T) {
+	from, to := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	for _, tc := range []struct {
+		name         string
+		totp         bool
+		email        string
+		amount       string
+		ok           bool
+		confirmation string
+	}{
+		{name: "below threshold", amount: "1", ok: true},
+		{name: "totp", totp: true, email: "u@example.com", amount: "5", ok: true, confirmation: "totp"},
+		{name: "email", email: "u@example.com", amount: "5", ok: true, confirmation: "email"},
+		{name: "none", amount: "5", ok: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockRepo()
+			m.WalletOwnerFunc = func(context.Context, string) (int64, error) { return mockUserID, nil }
+			m.QuoteTransferFunc = func(context.Context, string, string, int64) (repo.TransferQuote, error) {
+				return repo.TransferQuote{FromBalanceCents: 1000, ToFound: true}, nil
+			}
+			m.GetTOTPFunc = func(context.Context, int64) (string, bool, error) { return "", tc.totp, nil }
+			m.GetUserFunc = func(context.Context, int64) (repo.User, error) {
+				return repo.User{ID: mockUserID, Email: tc.email}, nil
+			}
+			r := chi.NewRouter()
+			(&API{Repo: m, TwoFactorThresholdCents: 200}).Routes(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/send/quote", strings.NewReader(`{"from":"`+from+`","to":"`+to+`","amount":`+tc.amount+`}`))
+			req.Header.Set("Authorization", "Bearer wk_mock")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status %d, body %s", rr.Code, rr.Body.String())
+			}
+			var got sendQuoteDTO
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.OK != tc.ok || got.Confirmation != tc.confirmation || got.Limits.TwoFactorThreshold != "2.00" {
+				t.Fatalf("quote: %+v", got)
+			}
+			if !tc.ok && got.Rejection["error"] != noSecondFactorMsg {
+				t.Fatalf("rejection: %v", got.Rejection)
+			}
+			if len(m.TransferCalls()) != 0 {
+				t.Fatal("quote moved funds")
+			}
+		})
+	}
+}
+
+// TestSendQuoteForeignRecipient, по чужому личному кошельку получателя оценка говорит только, превысит ли зачисление предел, но не запас до него
+func TestSendQuoteForeignRecipient(t *testing.T) {
+	from, to := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	for _, tc := range []struct {
+		name     string
+		owner    int64
+		balance  int64
+		over     bool
+		headroom string
+	}{
+		{name: "own", owner: mockUserID, balance: 500, headroom: formatCents(money.MaxCents - 500)},
+		{name: "foreign", owner: mockUserID + 1, balance: 500},
+		{name: "foreign over limit", owner: mockUserID + 1, balance: money.MaxCents - 50, over: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockRepo()
+			m.WalletOwnerFunc = func(_ context.Context, addr string) (int64, error) {
+				if addr == to {
+					return tc.owner, nil
+				}
+				return mockUserID, nil
+			}
+			m.QuoteTransferFunc = func(context.Context, string, string, int64) (repo.TransferQuote, error) {
+				return repo.TransferQuote{FromBalanceCents: 1000, ToBalanceCents: tc.balance, ToFound: true}, nil
+			}
+			r := chi.NewRouter()
+			(&API{Repo: m}).Routes(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/send/quote", strings.NewReader(`{"from":"`+from+`","to":"`+to+`","amount":1}`))
+			req.Header.Set("Authorization", "Bearer wk_mock")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status %d, body %s", rr.Code, rr.Body.String())
+			}
+			var got sendQuoteDTO
+			if err := json.NewDecoder(rr.Body).Decode(&got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.Limits.RecipientOverLimit != tc.over || got.Limits.RecipientHeadroom != tc.headroom {
+				t.Fatalf("limits: %+v", got.Limits)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// defaultDormantDays, сколько дней без активности считается неактивностью по умолчанию
+const defaultDormantDays = 180
+
+// dormantDTO, строка отчета по неактивным кошелькам
+type dormantDTO struct {
+	Address     string `json:"address"`
+	Balance     string `json:"balance"`
+	UserID      int64  `json:"user_id,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	LastTxAt    string `json:"last_tx_at,omitempty"`
+	DormantDays int64  `json:"dormant_days"`
+}
+
+// getDormantReport, кошельки без переводов days дней (по умолчанию 180) с балансом не меньше min_balance, самые давние первыми, format=csv отдает файл
+func (a *API) getDormantReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	days := defaultDormantDays
+	if s := q.Get("days"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid days"})
+			return
+		}
+		days = v
+	}
+	now := time.Now()
+	dq := repo.DormantQuery{Before: now.AddDate(0, 0, -days)}
+	if s := q.Get("min_balance"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid min_balance"})
+			return
+		}
+		dq.MinBalanceCents = toCents(v)
+	}
+	if s := q.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		dq.Limit = v
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json or csv"})
+		return
+	}
+
+	items, err := a.Repo.DormantWallets(r.Context(), dq)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]dormantDTO, 0, len(items))
+	for _, wl := range items {
+		last := wl.LastTxAt
+		if last.IsZero() {
+			last = wl.CreatedAt
+		}
+		d := dormantDTO{
+			Address:     wl.Address,
+			Balance:     formatCents(wl.BalanceCents),
+			UserID:      wl.UserID,
+			CreatedAt:   wl.CreatedAt.UTC().Format(time.RFC3339),
+			DormantDays: int64(now.Sub(last) / (24 * time.Hour)),
+		}
+		if !wl.LastTxAt.IsZero() {
+			d.LastTxAt = wl.LastTxAt.UTC().Format(time.RFC3339)
+		}
+		out = append(out, d)
+	}
+
+	if format != "csv" {
+		writeJSON(w, http.StatusOK, out)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="dormant-wallets-`+now.UTC().Format("2006-01-02")+`.csv"`)
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"address", "balance", "user_id", "created_at", "last_tx_at", "dormant_days"})
+	for _, d := range out {
+		user := ""
+		if d.UserID != 0 {
+			user = strconv.FormatInt(d.UserID, 10)
+		}
+		_ = cw.Write([]string{d.Address, d.Balance, user, d.CreatedAt, d.LastTxAt, strconv.FormatInt(d.DormantDays, 10)})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"gotechtask/internal/repo"
+)
+
+// requestIDHeader, заголовок идентификатора запроса в запросе и ответе
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen, идентификатор клиента длиннее не принимается и заменяется своим
+const maxRequestIDLen = 64
+
+// requestID, берет идентификатор запроса из X-Request-ID клиента или прокси, пустой, слишком длинный или с непечатными символами заменяет новым uuid,
+// кладет его в контекст и отдает в заголовке ответа, по нему ответ находится в логах прокси и клиента, а перевод в сведениях о выполнении
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(repo.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID, идентификатор непустой, не длиннее maxRequestIDLen и из печатных ascii без пробелов
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	return strings.IndexFunc(id, func(c rune) bool { return c <= ' ' || c > '~' }) < 0
+}
+
+// RequestIDFrom, идентификатор запроса из контекста, пустой вне обработки запроса
+func RequestIDFrom(ctx context.Context) string {
+	return repo.RequestIDFromContext(ctx)
+}
+
+// writeError, ошибка в общем конверте json с идентификатором запроса
+func writeError(w http.ResponseWriter, r *http.Request, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg, "request_id": RequestIDFrom(r.Context())})
+}
+
+// notFound, ответ на путь без маршрута
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "not found")
+}
+
+// methodNotAllowed, ответ на метод, которого у маршрута нет, методы маршрута в Allow, как у chi по умолчанию
+func methodNotAllowed(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if allowed := allowedMethods(routes, r); len(allowed) > 0 {
+			w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+		}
+		writeError(w, r, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestNotFoundAndMethodNotAllowed, неизвестный путь и метод отвечают json с идентификатором запроса, идентификатор клиента сохраняется, плохой заменяется
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	r := chi.NewRouter()
+	(&API{AdminToken: testAdminToken}).Routes(r)
+
+	do := func(method, path, id string) (*httptest.ResponseRecorder, map[string]string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, nil)
+		if id != "" {
+			req.Header.Set(requestIDHeader, id)
+		}
+		req.Header.Set("X-Admin-Token", testAdminToken)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		var body map[string]string
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s: not json: %q", method, path, rr.Body.String())
+		}
+		return rr, body
+	}
+
+	rr, body := do(http.MethodGet, "/api/nope", "client-42")
+	if rr.Code != http.StatusNotFound || body["error"] != "not found" || body["request_id"] != "client-42" || rr.Header().Get(requestIDHeader) != "client-42" {
+		t.Fatalf("404: %d %v", rr.Code, body)
+	}
+	// вложенный роутер администратора получает те же обработчики, после проверки токена
+	if rr, body := do(http.MethodGet, "/api/admin/nope", ""); rr.Code != http.StatusNotFound || body["request_id"] == "" {
+		t.Fatalf("admin 404: %d %v", rr.Code, body)
+	}
+
+	rr, body = do(http.MethodPut, "/api/transactions", "bad id\n")
+	if rr.Code != http.StatusMethodNotAllowed || body["error"] != "method not allowed" || !strings.Contains(rr.Header().Get("Allow"), http.MethodGet) {
+		t.Fatalf("405: %d %v allow=%q", rr.Code, body, rr.Header().Get("Allow"))
+	}
+	if id := body["request_id"]; id == "" || id == "bad id\n" {
+		t.Fatalf("invalid client id kept: %q", id)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// базовые подсказки повтора, после конфликта блокировок и при нехватке емкости, maxRetryAfter, предел подсказки
+const (
+	contentionRetryAfter = 200 * time.Millisecond
+	busyRetryAfter       = time.Second
+	maxRetryAfter        = 30 * time.Second
+)
+
+// retryAfter, через сколько стоит повторить, база растет с загрузкой полос до пятикратной, сверху до четверти случайно, чтобы отказанные клиенты не вернулись одновременно
+func (a *API) retryAfter(base time.Duration) time.Duration {
+	d := base + time.Duration(float64(base)*4*a.Lanes.load())
+	d += time.Duration(rand.Int63n(int64(d)/4 + 1))
+	if d > maxRetryAfter {
+		d = maxRetryAfter
+	}
+	return d
+}
+
+// writeRetryable, ответ об ошибке, которую стоит повторить, Retry-After в целых секундах не меньше одной, точная подсказка в retry_after_ms тела
+func writeRetryable(w http.ResponseWriter, code int, msg string, after time.Duration) {
+	secs := int64((after + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	writeJSON(w, code, map[string]any{"error": msg, "retry_after_ms": after.Milliseconds()})
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotechtask/internal/repo"
+)
+
+// TestWriteTransferError_RetryHints, конфликт блокировок дает 409 и таймаут 503, оба с Retry-After и retry_after_ms, подсказка растет с загрузкой полос
+func TestWriteTransferError_RetryHints(t *testing.T) {
+	hint := func(a *API, err error) (int, string, int64) {
+		rr := httptest.NewRecorder()
+		a.writeTransferError(rr, err)
+		var body struct {
+			RetryAfterMS int64 `json:"retry_after_ms"`
+		}
+		_ = json.Unmarshal(rr.Body.Bytes(), &body)
+		return rr.Code, rr.Header().Get("Retry-After"), body.RetryAfterMS
+	}
+
+	code, header, ms := hint(&API{}, repo.ErrContention)
+	if code != http.StatusConflict || header != "1" || ms < 200 || ms > 250 {
+		t.Fatalf("contention: got %d, Retry-After=%q, retry_after_ms=%d", code, header, ms)
+	}
+
+	code, header, ms = hint(&API{}, fmt.Errorf("transfer: %w", context.DeadlineExceeded))
+	if code != http.StatusServiceUnavailable || header != "2" && header != "1" || ms < 1000 {
+		t.Fatalf("timeout: got %d, Retry-After=%q, retry_after_ms=%d", code, header, ms)
+	}
+
+	// полосы заняты целиком, подсказка пятикратная
+	lanes := NewLanes(2, 0, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := lanes.acquire(context.Background(), false); err != nil {
+			t.Fatalf("acquire: %v", err)
+		}
+	}
+	if _, _, ms = hint(&API{Lanes: lanes}, repo.ErrContention); ms < 1000 || ms > 1250 {
+		t.Fatalf("loaded contention: want 1000..1250ms, got %d", ms)
+	}
+
+	if code, header, _ = hint(&API{}, repo.ErrInsufficientFunds); code != http.StatusConflict || header != "" {
+		t.Fatalf("insufficient funds must not carry retry hint, got %d %q", code, header)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gotechtask/internal/repo"
+)
+
+// faucetReq, пополнение кошелька из крана песочницы
+type faucetReq struct {
+	Address string  `json:"address"`
+	Amount  float64 `json:"amount"`
+}
+
+// markSandbox, помечает ответы песочницы, чтобы интегратор не спутал ее с боевым сервисом
+func markSandbox(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Sandbox", "true")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// postFaucet, зачисляет на кошелек ненастоящие деньги, сумма до FaucetMaxCents за раз, личный кошелек пополняет только владелец или администратор, ответ, созданная операция mint
+func (a *API) postFaucet(w http.ResponseWriter, r *http.Request) {
+	var req faucetReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if len(req.Address) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	amountCents := toCents(req.Amount)
+	if amountCents <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
+		return
+	}
+	if limit := a.settings().FaucetMaxCents; amountCents > limit {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount too large", "max_amount": formatCents(limit)})
+		return
+	}
+	if err := a.authorizeWallet(r.Context(), req.Address); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	t, err := a.Repo.Faucet(ctx, req.Address, amountCents)
+	switch {
+	case err == nil:
+	case errors.Is(err, repo.ErrWalletNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+		return
+	case errors.Is(err, repo.ErrBalanceOverflow):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "balance limit exceeded"})
+		return
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, toTxDTO(t))
+}
+
+// postSandboxReset, удаляет все кошельки, операции и связанные данные песочницы и заново создает служебные кошельки, отдает число удаленных строк по таблицам
+func (a *API) postSandboxReset(w http.ResponseWriter, r *http.Request) {
+	deleted, err := a.Repo.ResetSandbox(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "deleted": deleted})
+}
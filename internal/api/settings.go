@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"gotechtask/internal/auth"
+)
+
+// Settings, настройки ручек, которые меняются без перезапуска, запрос читает их один раз, начатые переводы дочитывают прежние
+type Settings struct {
+	TwoFactorThresholdCents int64
+	FaucetMaxCents          int64
+	Maintenance             bool
+}
+
+// maintenanceRetryAfter, подсказка повтора в режиме обслуживания
+const maintenanceRetryAfter = 30 * time.Second
+
+// settings, текущие настройки, до первого SetSettings из полей API
+func (a *API) settings() Settings {
+	if s := a.live.Load(); s != nil {
+		return *s
+	}
+	return Settings{
+		TwoFactorThresholdCents: a.TwoFactorThresholdCents,
+		FaucetMaxCents:          a.FaucetMaxCents,
+		Maintenance:             a.Maintenance,
+	}
+}
+
+// SetSettings, заменяет настройки для следующих запросов, безопасен рядом с обработкой запросов
+func (a *API) SetSettings(s Settings) {
+	a.live.Store(&s)
+}
+
+// maintenance, в режиме обслуживания публичные изменяющие запросы получают 503 с подсказкой повтора, чтение и администраторы проходят
+func (a *API) maintenance(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if a.settings().Maintenance && !auth.FromContext(r.Context()).Admin {
+				writeRetryable(w, http.StatusServiceUnavailable, "maintenance", maintenanceRetryAfter)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// postConfigReload, перечитывает настройки, которые меняются без перезапуска, как по SIGHUP, ответ, изменившиеся переменные,
+// ошибка чтения оставляет прежние настройки и отдает 400 с причиной
+func (a *API) postConfigReload(w http.ResponseWriter, r *http.Request) {
+	changed, err := a.Reload()
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid config", "reason": err.Error()})
+		return
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"changed": changed})
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+)
+
+// TestMaintenance, в режиме обслуживания публичная запись получает 503, чтение и администратор проходят, SetSettings выключает режим на ходу
+func TestMaintenance(t *testing.T) {
+	a := &API{Maintenance: true}
+	h := a.maintenance(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	serve := func(method string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/send", nil)
+		if admin {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Admin: true}))
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := serve(http.MethodPost, false); rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Fatalf("public write: want 503 with Retry-After, got %d", rr.Code)
+	}
+	if rr := serve(http.MethodGet, false); rr.Code != http.StatusOK {
+		t.Fatalf("public read: got %d", rr.Code)
+	}
+	if rr := serve(http.MethodPost, true); rr.Code != http.StatusOK {
+		t.Fatalf("admin write: got %d", rr.Code)
+	}
+
+	a.SetSettings(Settings{})
+	if rr := serve(http.MethodPost, false); rr.Code != http.StatusOK {
+		t.Fatalf("after reload: got %d", rr.Code)
+	}
+}
+
+// TestSettingsOverrideFields, настройки после SetSettings важнее полей API, например предел крана
+func TestSettingsOverrideFields(t *testing.T) {
+	a := &API{FaucetMaxCents: 100, TwoFactorThresholdCents: 5}
+	if s := a.settings(); s.FaucetMaxCents != 100 || s.TwoFactorThresholdCents != 5 {
+		t.Fatalf("before SetSettings: %+v", s)
+	}
+	a.SetSettings(Settings{FaucetMaxCents: 300})
+	if s := a.settings(); s.FaucetMaxCents != 300 || s.TwoFactorThresholdCents != 0 {
+		t.Fatalf("after SetSettings: %+v", s)
+	}
+}
+
+// TestConfigReload, ручка отдает изменившиеся переменные, ошибка чтения дает 400 с причиной, без Reload ручки нет
+func TestConfigReload(t *testing.T) {
+	var fail error
+	a := &API{Repo: newMockRepo(), AdminToken: testAdminToken, Reload: func() ([]string, error) {
+		if fail != nil {
+			return nil, fail
+		}
+		return []string{"MAINTENANCE=true"}, nil
+	}}
+	r := chi.NewRouter()
+	a.Routes(r)
+	post := func(r http.Handler) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/config/reload", nil)
+		req.Header.Set("X-Admin-Token", testAdminToken)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := post(r); rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"changed":["MAINTENANCE=true"]`) {
+		t.Fatalf("reload: %d %s", rr.Code, rr.Body.String())
+	}
+	fail = errors.New("MAINTENANCE: invalid syntax")
+	if rr := post(r); rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "invalid syntax") {
+		t.Fatalf("reload error: %d %s", rr.Code, rr.Body.String())
+	}
+
+	off := chi.NewRouter()
+	(&API{Repo: newMockRepo(), AdminToken: testAdminToken}).Routes(off)
+	if rr := post(off); rr.Code != http.StatusNotFound {
+		t.Fatalf("without Reload: want 404, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// settlementDTO, сводка расчета за бизнес-день и строки кошельков
+type settlementDTO struct {
+	BusinessDate string              `json:"business_date"`
+	Timezone     string              `json:"timezone"`
+	PeriodStart  string              `json:"period_start"`
+	PeriodEnd    string              `json:"period_end"`
+	Wallets      int                 `json:"wallets"`
+	TxCount      int64               `json:"tx_count"`
+	Volume       string              `json:"volume"`
+	Fees         string              `json:"fees"`
+	CreatedAt    string              `json:"created_at"`
+	Lines        []settlementLineDTO `json:"lines,omitempty"`
+}
+
+// settlementLineDTO, итоги кошелька за день
+type settlementLineDTO struct {
+	Address string `json:"address"`
+	In      string `json:"in"`
+	Out     string `json:"out"`
+	Net     string `json:"net"`
+	Fees    string `json:"fees"`
+	TxCount int64  `json:"tx_count"`
+}
+
+// settlementDate, бизнес-дата из пути в формате YYYY-MM-DD
+func settlementDate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	date := chi.URLParam(r, "date")
+	if _, err := time.Parse(repo.DateLayout, date); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+		return "", false
+	}
+	return date, true
+}
+
+// getSettlementReport, расчет за бизнес-день со строками кошельков, format=csv отдает строки файлом
+func (a *API) getSettlementReport(w http.ResponseWriter, r *http.Request) {
+	date, ok := settlementDate(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "format must be json or csv"})
+		return
+	}
+
+	run, lines, err := a.Repo.GetSettlement(r.Context(), date)
+	if errors.Is(err, repo.ErrSettlementNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "settlement not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	if format != "csv" {
+		dto := toSettlementDTO(run)
+		dto.Lines = make([]settlementLineDTO, 0, len(lines))
+		for _, l := range lines {
+			dto.Lines = append(dto.Lines, toSettlementLineDTO(l))
+		}
+		writeJSON(w, http.StatusOK, dto)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="settlement-`+date+`.csv"`)
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"business_date", "address", "in", "out", "net", "fees", "tx_count"})
+	for _, l := range lines {
+		d := toSettlementLineDTO(l)
+		_ = cw.Write([]string{date, d.Address, d.In, d.Out, d.Net, d.Fees, strconv.FormatInt(d.TxCount, 10)})
+	}
+	cw.Flush()
+}
+
+// postSettlement, расчет закрытого бизнес-дня по требованию, например если задача была выключена, повторный расчет дает 409
+func (a *API) postSettlement(w http.ResponseWriter, r *http.Request) {
+	date, ok := settlementDate(w, r)
+	if !ok {
+		return
+	}
+	loc := a.location()
+	_, end, err := repo.DayBounds(date, loc)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "date must be YYYY-MM-DD"})
+		return
+	}
+	if time.Now().Before(end) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "business date is not closed yet"})
+		return
+	}
+
+	run, err := a.Repo.Settle(r.Context(), date, loc)
+	if errors.Is(err, repo.ErrAlreadySettled) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "business date already settled"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, toSettlementDTO(run))
+}
+
+// toSettlementDTO, маппинг сводки расчета в ответ
+func toSettlementDTO(s repo.SettlementRun) settlementDTO {
+	return settlementDTO{
+		BusinessDate: s.BusinessDate,
+		Timezone:     s.Timezone,
+		PeriodStart:  s.PeriodStart.UTC().Format(time.RFC3339),
+		PeriodEnd:    s.PeriodEnd.UTC().Format(time.RFC3339),
+		Wallets:      s.Wallets,
+		TxCount:      s.TxCount,
+		Volume:       formatCents(s.VolumeCents),
+		Fees:         formatCents(s.FeeCents),
+		CreatedAt:    s.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// toSettlementLineDTO, маппинг строки кошелька в ответ
+func toSettlementLineDTO(l repo.SettlementLine) settlementLineDTO {
+	return settlementLineDTO{
+		Address: l.Address,
+		In:      formatCents(l.InCents),
+		Out:     formatCents(l.OutCents),
+		Net:     formatCents(l.NetCents),
+		Fees:    formatCents(l.FeeCents),
+		TxCount: l.TxCount,
+	}
+}
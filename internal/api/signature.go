@@ -0,0 +1,118 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/repo"
+)
+
+// defaultSignatureWindow, допустимое расхождение времени подписи с часами сервера, если в API не задано другое
+const defaultSignatureWindow = 5 * time.Minute
+
+// maxSignedBodyBytes, предел тела подписываемого запроса, тело читается в память целиком
+const maxSignedBodyBytes = 1 << 20
+
+// requireSignature, проверяет hmac подпись тела запроса для ключей с секретом подписи и что nonce запроса еще не использовался, в режиме RequireSignedTransfers ключ без секрета получает 401, прочие участники проходят без проверки
+func (a *API) requireSignature(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := auth.FromContext(r.Context())
+		if p.SigningSecret == "" {
+			if a.RequireSignedTransfers && p.KeyID != 0 {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "signing secret not configured for api key"})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+		if err != nil {
+			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "body too large"})
+			return
+		}
+		window := a.SignatureWindow
+		if window <= 0 {
+			window = defaultSignatureWindow
+		}
+		now := time.Now()
+		nonce := r.Header.Get(auth.HeaderSignatureNonce)
+		if err := auth.VerifySignature(p.SigningSecret, r.Header.Get(auth.HeaderSignatureTimestamp), nonce, r.Header.Get(auth.HeaderSignature), body, now, window); err != nil {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+			return
+		}
+
+		// подпись с временем из будущего проходит проверку до now+2*window, столько и храним nonce
+		fresh, err := a.Repo.ConsumeNonce(r.Context(), p.KeyID, nonce, now.Add(2*window))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		if !fresh {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "signature nonce already used"})
+			return
+		}
+
+		// обработчик читает тело заново
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// postSigningSecret, выдает ключу новый секрет подписи, секрет показывается один раз, с этого момента переводы этим ключом должны быть подписаны
+func (a *API) postSigningSecret(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	secret, err := auth.NewSigningSecret()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if err := a.Repo.SetAPIKeySigningSecret(r.Context(), p.UserID, id, secret); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	a.Keys.InvalidateKey(id)
+	writeJSON(w, http.StatusCreated, map[string]string{"signing_secret": secret})
+}
+
+// deleteSigningSecret, выключает подпись переводов для ключа
+func (a *API) deleteSigningSecret(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	if err := a.Repo.SetAPIKeySigningSecret(r.Context(), p.UserID, id, ""); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	a.Keys.InvalidateKey(id)
+	writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+}
+
+// writeAPIKeyError, маппит ошибку операции с ключом в http ответ
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, repo.ErrAPIKeyNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "api key not found"})
+		return
+	}
+	writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+}
@@ -0,0 +1,109 @@
+//go:build soak
+
+package api
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gotechtask/internal/loadgen"
+	"gotechtask/internal/soak"
+	"gotechtask/internal/testfixtures"
+)
+
+// параметры долгого прогона, длительность задается SOAK_DURATION, прогон делится на фазы, после каждой снимается снимок ресурсов
+const (
+	defaultSoakDuration = 5 * time.Minute
+	soakPhases          = 10
+	soakWorkers         = 16
+	// soakCancelTimeout, предел запросов второго потока, короткий, чтобы запросы обрывались посреди перевода
+	soakCancelTimeout = 15 * time.Millisecond
+)
+
+// TestSoak, долго нагружает api поверх тестовой базы обычными и обрываемыми запросами, после каждой фазы ждет затишья и снимает горутины, пул базы и кучу, монотонный рост по фазам считается утечкой
+func TestSoak(t *testing.T) {
+	d := defaultSoakDuration
+	if s := os.Getenv("SOAK_DURATION"); s != "" {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			t.Fatalf("SOAK_DURATION: %v", err)
+		}
+		d = v
+	}
+	phase := d / soakPhases
+
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+
+	wallets := make([]string, 8)
+	for i := range wallets {
+		wallets[i] = fx.Wallet(1_000_000)
+	}
+
+	srv := httptest.NewServer(buildRouter(db))
+	defer srv.Close()
+
+	scenario := func(timeout time.Duration) loadgen.Scenario {
+		return loadgen.Scenario{
+			BaseURL:     srv.URL,
+			Wallets:     wallets,
+			Workers:     soakWorkers,
+			Duration:    phase,
+			Timeout:     timeout,
+			Mix:         map[string]int{loadgen.OpSend: 4, loadgen.OpBalance: 4, loadgen.OpList: 2},
+			AmountCents: 1,
+			ListCount:   20,
+		}
+	}
+	normal := loadgen.NewRunner(scenario(5 * time.Second))
+	cancelled := loadgen.NewRunner(scenario(soakCancelTimeout))
+
+	var samples []soak.Sample
+	for i := 0; i <= soakPhases; i++ {
+		// нулевая фаза прогревает пулы и кэши, ее снимок первый в ряду, сборщики новые на каждую фазу, иначе задержки копились бы в куче самого теста
+		normal.Recorder, cancelled.Recorder = loadgen.NewRecorder(), loadgen.NewRecorder()
+		var wg sync.WaitGroup
+		for _, r := range []*loadgen.Runner{normal, cancelled} {
+			wg.Add(1)
+			go func(r *loadgen.Runner) {
+				defer wg.Done()
+				r.Run(context.Background())
+			}(r)
+		}
+		wg.Wait()
+
+		normal.Client.HTTP.CloseIdleConnections()
+		cancelled.Client.HTTP.CloseIdleConnections()
+		srv.CloseClientConnections()
+		quiesce(db)
+
+		s := soak.Take(db)
+		rep := normal.Recorder.Report(phase)
+		t.Logf("phase %d: %v, requests=%d errors=%d p99=%v", i, s, rep.Total.Requests, rep.Total.Errors, rep.Total.P99)
+		if rep.Total.Requests == 0 {
+			t.Fatalf("phase %d: no requests completed", i)
+		}
+		samples = append(samples, s)
+	}
+
+	if leaks := soak.Leaks(samples, soak.DefaultThresholds); len(leaks) > 0 {
+		for _, l := range leaks {
+			t.Error(l)
+		}
+	}
+}
+
+// quiesce, ждет до пяти секунд, пока занятых соединений базы не останется, обработчики оборванных запросов досчитывают в фоне
+func quiesce(db *sql.DB) {
+	deadline := time.Now().Add(5 * time.Second)
+	for db.Stats().InUse > 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	// горутинам соединений нужно время, чтобы завершиться после закрытия
+	time.Sleep(200 * time.Millisecond)
+}
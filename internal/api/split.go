@@ -0,0 +1,201 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// maxSplitShares, предел получателей одной разбивки
+const maxSplitShares = 100
+
+// splitReq, разбивка платежа, отправитель, общая сумма, доли получателей и правило остатка,
+// доли задаются либо все суммами, тогда общая сумма необязательна и должна совпасть с их суммой, либо все процентами до сотых, тогда общая сумма обязательна, а проценты в сумме дают 100
+type splitReq struct {
+	From      string          `json:"from"`
+	Amount    float64         `json:"amount"`
+	Remainder money.Remainder `json:"remainder"`
+	Shares    []splitShareReq `json:"shares"`
+}
+
+// splitShareReq, доля получателя, сумма или процент
+type splitShareReq struct {
+	To      string  `json:"to"`
+	Amount  float64 `json:"amount"`
+	Percent float64 `json:"percent"`
+}
+
+// splitShareResp, сколько досталось получателю
+type splitShareResp struct {
+	To     string `json:"to"`
+	Amount string `json:"amount"`
+}
+
+// splitResp, итог разбивки, id группы, которым помечены все ее транзакции
+type splitResp struct {
+	Status  string           `json:"status"`
+	GroupID string           `json:"group_id"`
+	Amount  string           `json:"amount"`
+	Shares  []splitShareResp `json:"shares"`
+}
+
+// postSendSplit, один отправитель платит нескольким получателям одной атомарной транзакцией, процентные доли округляются вниз до цента, остаток получает доля по правилу remainder, largest по умолчанию,
+// отказ любого перевода откатывает всю разбивку и отдается с номером доли, как у пакета переводов
+func (a *API) postSendSplit(w http.ResponseWriter, r *http.Request) {
+	var req splitReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Remainder == "" {
+		req.Remainder = money.RemainderLargest
+	}
+	if !money.ValidRemainder(req.Remainder) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid remainder"})
+		return
+	}
+	if len(req.From) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	if len(req.Shares) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "shares required"})
+		return
+	}
+	if len(req.Shares) > maxSplitShares {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "too many shares", "max_shares": maxSplitShares})
+		return
+	}
+	if req.Amount < 0 || toCents(req.Amount) > money.MaxCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid amount"})
+		return
+	}
+
+	// доли одного вида, получатели разные и не совпадают с отправителем
+	byPercent := req.Shares[0].Percent != 0
+	seen := make(map[string]bool, len(req.Shares))
+	weights := make([]int64, len(req.Shares))
+	for i, s := range req.Shares {
+		if len(s.To) != 64 {
+			writeBatchItemError(w, http.StatusBadRequest, i, "invalid address format")
+			return
+		}
+		if s.To == req.From {
+			writeBatchItemError(w, http.StatusBadRequest, i, "from must differ from to")
+			return
+		}
+		if seen[s.To] {
+			writeBatchItemError(w, http.StatusBadRequest, i, "duplicate recipient")
+			return
+		}
+		seen[s.To] = true
+		if s.Amount == 0 && s.Percent == 0 {
+			writeBatchItemError(w, http.StatusBadRequest, i, "share must be > 0")
+			return
+		}
+		if (s.Percent != 0) != byPercent || (s.Amount != 0) == byPercent {
+			writeBatchItemError(w, http.StatusBadRequest, i, "shares must be all amounts or all percents")
+			return
+		}
+		v := s.Amount
+		if byPercent {
+			v = s.Percent
+		}
+		// процент хранится в сотых долях, то есть в базисных пунктах, сумма в центах
+		weights[i] = toCents(v)
+		if v <= 0 || weights[i] <= 0 {
+			writeBatchItemError(w, http.StatusBadRequest, i, "share must be > 0")
+			return
+		}
+		if weights[i] > money.MaxCents {
+			writeBatchItemError(w, http.StatusBadRequest, i, "amount too large")
+			return
+		}
+	}
+
+	var total int64
+	amounts := weights
+	if byPercent {
+		var bps int64
+		for _, w := range weights {
+			bps += w
+		}
+		if bps != 10000 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "percents must add up to 100"})
+			return
+		}
+		total = toCents(req.Amount)
+		if total <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
+			return
+		}
+		split, err := money.Split(total, weights, req.Remainder)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid shares"})
+			return
+		}
+		for i, c := range split {
+			if c == 0 {
+				writeBatchItemError(w, http.StatusBadRequest, i, "share rounds to zero")
+				return
+			}
+		}
+		amounts = split
+	} else {
+		// сумма не переполняется, долей не больше maxSplitShares и каждая не больше MaxCents
+		for _, c := range amounts {
+			total += c
+		}
+		if req.Amount != 0 && toCents(req.Amount) != total {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount does not match shares"})
+			return
+		}
+	}
+
+	// распоряжаться личным кошельком может только владелец или администратор
+	if err := a.authorizeWallet(r.Context(), req.From); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	// порог второго фактора считается по всей разбивке, отложенный перевод бывает только к одному получателю
+	need, err := a.needsSecondFactor(r.Context(), req.From, total)
+	if err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	if need {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "second factor required, send large transfers individually"})
+		return
+	}
+
+	items := make([]repo.TransferItem, len(amounts))
+	for i, c := range amounts {
+		items[i] = repo.TransferItem{From: req.From, To: req.Shares[i].To, AmountCents: c}
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	groupID, err := a.Repo.TransferGroup(ctx, items)
+	if err != nil {
+		var itemErr *repo.BatchItemError
+		if errors.As(err, &itemErr) {
+			if code, msg, ok := transferRejection(itemErr.Err); ok {
+				writeBatchItemRejection(w, code, itemErr.Index, msg, itemErr.Err)
+				return
+			}
+		}
+		a.writeTransferError(w, err)
+		return
+	}
+
+	resp := splitResp{Status: "ok", GroupID: groupID, Amount: formatCents(total), Shares: make([]splitShareResp, len(items))}
+	for i, it := range items {
+		a.transferCommitted(it.From, it.To)
+		resp.Shares[i] = splitShareResp{To: it.To, Amount: formatCents(it.AmountCents)}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,509 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+	"gotechtask/internal/schedule"
+)
+
+// пределы поручений, ближайших сроков в ответе по умолчанию и максимум, повторов отказавшего срока по умолчанию и максимум, исходов сроков в карточке поручения
+const (
+	defaultStandingPreview  = 5
+	maxStandingPreview      = 20
+	defaultStandingRetries  = 3
+	maxStandingRetries      = 10
+	standingOrderRecentRuns = 20
+)
+
+// standingOrderReq, постоянное поручение, расписание daily, weekly:mon или monthly:1, monthly:last, время HH:MM, пустое значит полночь, и часовой пояс IANA, по умолчанию часовой пояс бизнеса,
+// failure_policy skip по умолчанию или retry, max_retries, сколько раз повторять отказавший срок при retry
+type standingOrderReq struct {
+	From          string  `json:"from"`
+	To            string  `json:"to"`
+	Amount        float64 `json:"amount"`
+	Schedule      string  `json:"schedule"`
+	At            string  `json:"at"`
+	Timezone      string  `json:"timezone"`
+	FailurePolicy string  `json:"failure_policy"`
+	MaxRetries    *int    `json:"max_retries"`
+}
+
+// standingOrderPatchReq, изменение поручения, отсутствующее поле не меняется, правила те же, что при создании
+type standingOrderPatchReq struct {
+	Amount        *float64 `json:"amount"`
+	Schedule      *string  `json:"schedule"`
+	At            *string  `json:"at"`
+	Timezone      *string  `json:"timezone"`
+	FailurePolicy *string  `json:"failure_policy"`
+	MaxRetries    *int     `json:"max_retries"`
+}
+
+// standingOrderDTO, представление поручения, next_run_at только у активного, next_runs в ответе на создание и в карточке, runs только в карточке
+type standingOrderDTO struct {
+	ID            int64                 `json:"id"`
+	From          string                `json:"from"`
+	To            string                `json:"to"`
+	Amount        string                `json:"amount"`
+	Schedule      string                `json:"schedule"`
+	At            string                `json:"at"`
+	Timezone      string                `json:"timezone"`
+	FailurePolicy string                `json:"failure_policy"`
+	MaxRetries    int                   `json:"max_retries"`
+	Status        string                `json:"status"`
+	NextRunAt     string                `json:"next_run_at,omitempty"`
+	Attempt       int                   `json:"attempt,omitempty"`
+	LastRunAt     string                `json:"last_run_at,omitempty"`
+	LastError     string                `json:"last_error,omitempty"`
+	DeletedAt     string                `json:"deleted_at,omitempty"`
+	CreatedBy     string                `json:"created_by"`
+	CreatedAt     string                `json:"created_at"`
+	NextRuns      []string              `json:"next_runs,omitempty"`
+	Runs          []standingOrderRunDTO `json:"runs,omitempty"`
+}
+
+// standingOrderRunDTO, исход срока поручения
+type standingOrderRunDTO struct {
+	ScheduledFor string `json:"scheduled_for"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	Error        string `json:"error,omitempty"`
+	UpdatedAt    string `json:"updated_at"`
+}
+
+// toStandingOrderDTO, маппинг поручения в ответ, суммы строкой, время в rfc3339
+func toStandingOrderDTO(o repo.StandingOrder) standingOrderDTO {
+	d := standingOrderDTO{
+		ID:            o.ID,
+		From:          o.From,
+		To:            o.To,
+		Amount:        formatCents(o.AmountCents),
+		Schedule:      o.Schedule,
+		At:            o.At,
+		Timezone:      o.Timezone,
+		FailurePolicy: o.FailurePolicy,
+		MaxRetries:    o.MaxRetries,
+		Status:        o.Status,
+		Attempt:       o.Attempt,
+		LastError:     o.LastError,
+		CreatedBy:     o.CreatedBy,
+		CreatedAt:     o.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if o.Status == repo.StandingOrderActive && o.DeletedAt.IsZero() {
+		d.NextRunAt = o.NextRunAt.UTC().Format(time.RFC3339)
+	}
+	if !o.LastRunAt.IsZero() {
+		d.LastRunAt = o.LastRunAt.UTC().Format(time.RFC3339)
+	}
+	if !o.DeletedAt.IsZero() {
+		d.DeletedAt = o.DeletedAt.UTC().Format(time.RFC3339)
+	}
+	return d
+}
+
+// formatRuns, сроки в rfc3339 со смещением часового пояса поручения, чтобы было видно местное время запуска
+func formatRuns(runs []time.Time) []string {
+	out := make([]string, len(runs))
+	for i, t := range runs {
+		out[i] = t.Format(time.RFC3339)
+	}
+	return out
+}
+
+// previewCount, число ближайших сроков из параметра count
+func previewCount(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("count")
+	if v == "" {
+		return defaultStandingPreview, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxStandingPreview {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid count", "max_count": maxStandingPreview})
+		return 0, false
+	}
+	return n, true
+}
+
+// parseStandingSchedule, расписание, время и часовой пояс поручения, пустой пояс берет часовой пояс бизнеса, ошибка уже записана в ответ
+func (a *API) parseStandingSchedule(w http.ResponseWriter, spec, at, tz string) (schedule.Schedule, *time.Location, bool) {
+	s, err := schedule.Parse(spec, at)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid schedule, want daily, weekly:mon..sun or monthly:1..31|last with at HH:MM"})
+		return s, nil, false
+	}
+	if tz == "" {
+		return s, a.location(), true
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid timezone"})
+		return s, nil, false
+	}
+	return s, loc, true
+}
+
+// postStandingOrder, заводит постоянное поручение с кошелька, которым распоряжается вызывающий, сроки исполняются фоновой задачей без участия владельца,
+// поэтому сумма выше порога второго фактора отклоняется
+func (a *API) postStandingOrder(w http.ResponseWriter, r *http.Request) {
+	var req standingOrderReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if len(req.From) != 64 || len(req.To) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	if req.From == req.To {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from must differ from to"})
+		return
+	}
+	if req.Amount <= 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
+		return
+	}
+	cents := toCents(req.Amount)
+	if cents > money.MaxCents {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount too large"})
+		return
+	}
+	s, loc, ok := a.parseStandingSchedule(w, req.Schedule, req.At, req.Timezone)
+	if !ok {
+		return
+	}
+	if req.FailurePolicy == "" {
+		req.FailurePolicy = repo.StandingOrderSkip
+	}
+	if !repo.ValidFailurePolicy(req.FailurePolicy) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failure_policy must be skip or retry"})
+		return
+	}
+	retries := 0
+	if req.FailurePolicy == repo.StandingOrderRetry {
+		retries = defaultStandingRetries
+		if req.MaxRetries != nil {
+			retries = *req.MaxRetries
+		}
+		if retries < 1 || retries > maxStandingRetries {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid max_retries", "max_retries": maxStandingRetries})
+			return
+		}
+	} else if req.MaxRetries != nil && *req.MaxRetries != 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "max_retries requires failure_policy retry"})
+		return
+	}
+
+	// распоряжаться личным кошельком может только владелец или администратор
+	if err := a.authorizeWallet(r.Context(), req.From); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	need, err := a.needsSecondFactor(r.Context(), req.From, cents)
+	if err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	if need {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "amount requires second factor, not allowed for standing orders"})
+		return
+	}
+
+	o, err := a.Repo.CreateStandingOrder(r.Context(), repo.StandingOrder{
+		From:          req.From,
+		To:            req.To,
+		AmountCents:   cents,
+		Schedule:      s.String(),
+		At:            s.At(),
+		Timezone:      loc.String(),
+		FailurePolicy: req.FailurePolicy,
+		MaxRetries:    retries,
+	})
+	if err != nil {
+		a.writeStandingOrderError(w, err)
+		return
+	}
+	dto := toStandingOrderDTO(o)
+	dto.NextRuns = formatRuns(append([]time.Time{o.SlotAt.In(loc)}, s.Upcoming(o.SlotAt, loc, defaultStandingPreview-1)...))
+	w.Header().Set("ETag", versionETag(o.Version))
+	writeCreated(w, "/api/standing-orders/"+strconv.FormatInt(o.ID, 10), dto)
+}
+
+// getStandingOrders, поручения кошелька-отправителя, отмененные только с cancelled=true
+func (a *API) getStandingOrders(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	deleted, ok := listDeleted(w, r)
+	if !ok {
+		return
+	}
+	withCancelled, _ := strconv.ParseBool(r.URL.Query().Get("cancelled"))
+
+	var items []repo.StandingOrder
+	var err error
+	if deleted {
+		items, err = a.Repo.ListDeletedStandingOrders(r.Context(), addr)
+	} else {
+		items, err = a.Repo.ListStandingOrders(r.Context(), addr, withCancelled)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	out := make([]standingOrderDTO, 0, len(items))
+	for _, o := range items {
+		out = append(out, toStandingOrderDTO(o))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// getStandingOrder, карточка поручения с ближайшими сроками, count штук, и последними исходами,
+// у активного первым идет исполняемый срок, у приостановленного сроки, которые будут после возобновления сейчас
+func (a *API) getStandingOrder(w http.ResponseWriter, r *http.Request) {
+	o, ok := a.ownStandingOrder(w, r)
+	if !ok {
+		return
+	}
+	n, ok := previewCount(w, r)
+	if !ok {
+		return
+	}
+	runs, err := a.Repo.StandingOrderRuns(r.Context(), o.ID, standingOrderRecentRuns)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	dto := toStandingOrderDTO(o)
+	s, serr := schedule.Parse(o.Schedule, o.At)
+	loc, lerr := time.LoadLocation(o.Timezone)
+	if serr == nil && lerr == nil {
+		switch o.Status {
+		case repo.StandingOrderActive:
+			dto.NextRuns = formatRuns(append([]time.Time{o.SlotAt.In(loc)}, s.Upcoming(o.SlotAt, loc, n-1)...))
+		case repo.StandingOrderPaused:
+			dto.NextRuns = formatRuns(s.Upcoming(time.Now(), loc, n))
+		}
+	}
+	for _, run := range runs {
+		dto.Runs = append(dto.Runs, standingOrderRunDTO{
+			ScheduledFor: run.SlotAt.UTC().Format(time.RFC3339),
+			Status:       run.Status,
+			Attempts:     run.Attempts,
+			Error:        run.Error,
+			UpdatedAt:    run.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	w.Header().Set("ETag", versionETag(o.Version))
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// getStandingOrderPreview, ближайшие сроки расписания без заведения поручения, параметры schedule, at, timezone и count как у поручения
+func (a *API) getStandingOrderPreview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s, loc, ok := a.parseStandingSchedule(w, q.Get("schedule"), q.Get("at"), q.Get("timezone"))
+	if !ok {
+		return
+	}
+	n, ok := previewCount(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"schedule":  s.String(),
+		"at":        s.At(),
+		"timezone":  loc.String(),
+		"next_runs": formatRuns(s.Upcoming(time.Now(), loc, n)),
+	})
+}
+
+// pauseStandingOrder, приостанавливает активное поручение
+func (a *API) pauseStandingOrder(w http.ResponseWriter, r *http.Request) {
+	a.changeStandingOrder(w, r, a.Repo.PauseStandingOrder)
+}
+
+// resumeStandingOrder, возобновляет приостановленное поручение с ближайшего срока после текущего момента
+func (a *API) resumeStandingOrder(w http.ResponseWriter, r *http.Request) {
+	a.changeStandingOrder(w, r, a.Repo.ResumeStandingOrder)
+}
+
+// cancelStandingOrder, отменяет поручение насовсем
+func (a *API) cancelStandingOrder(w http.ResponseWriter, r *http.Request) {
+	a.changeStandingOrder(w, r, a.Repo.CancelStandingOrder)
+}
+
+// deleteStandingOrder, удаляет свое поручение в любом состоянии, оно перестает исполняться и пропадает из списков, вернуть его может администратор
+func (a *API) deleteStandingOrder(w http.ResponseWriter, r *http.Request) {
+	a.changeStandingOrder(w, r, a.Repo.DeleteStandingOrder)
+}
+
+// restoreStandingOrder, администратор возвращает удаленное поручение, не удаленное дает 409
+func (a *API) restoreStandingOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	o, err := a.Repo.RestoreStandingOrder(r.Context(), id, repo.ActorFromContext(r.Context()))
+	if err != nil {
+		a.writeStandingOrderError(w, err)
+		return
+	}
+	w.Header().Set("ETag", versionETag(o.Version))
+	writeJSON(w, http.StatusOK, toStandingOrderDTO(o))
+}
+
+// changeStandingOrder, меняет состояние своего поручения, недопустимый переход дает 409
+func (a *API) changeStandingOrder(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id int64) (repo.StandingOrder, error)) {
+	o, ok := a.ownStandingOrder(w, r)
+	if !ok {
+		return
+	}
+	o, err := change(r.Context(), o.ID)
+	if err != nil {
+		a.writeStandingOrderError(w, err)
+		return
+	}
+	w.Header().Set("ETag", versionETag(o.Version))
+	writeJSON(w, http.StatusOK, toStandingOrderDTO(o))
+}
+
+// patchStandingOrder, меняет сумму, расписание и политику отказа своего поручения, заголовок If-Match с ETag карточки обязателен,
+// поручение, измененное после чтения, дает 412 с текущим ETag, отмененное 409, новая сумма выше порога второго фактора 403, как при создании
+func (a *API) patchStandingOrder(w http.ResponseWriter, r *http.Request) {
+	var req standingOrderPatchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	o, ok := a.ownStandingOrder(w, r)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	var p repo.StandingOrderPatch
+	if req.Amount != nil {
+		if *req.Amount <= 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount must be > 0"})
+			return
+		}
+		cents := toCents(*req.Amount)
+		if cents > money.MaxCents {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "amount too large"})
+			return
+		}
+		need, err := a.needsSecondFactor(r.Context(), o.From, cents)
+		if err != nil {
+			writeWalletAccessError(w, err)
+			return
+		}
+		if need {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "amount requires second factor, not allowed for standing orders"})
+			return
+		}
+		p.AmountCents = &cents
+	}
+	if req.Schedule != nil || req.At != nil || req.Timezone != nil {
+		spec, at, tz := o.Schedule, o.At, o.Timezone
+		if req.Schedule != nil {
+			spec = *req.Schedule
+		}
+		if req.At != nil {
+			at = *req.At
+		}
+		if req.Timezone != nil {
+			tz = *req.Timezone
+		}
+		s, loc, ok := a.parseStandingSchedule(w, spec, at, tz)
+		if !ok {
+			return
+		}
+		spec, at, tz = s.String(), s.At(), loc.String()
+		p.Schedule, p.At, p.Timezone = &spec, &at, &tz
+	}
+
+	// политика и число повторов проверяются вместе, как при создании
+	policy, retries := o.FailurePolicy, o.MaxRetries
+	if req.FailurePolicy != nil {
+		policy = *req.FailurePolicy
+		if !repo.ValidFailurePolicy(policy) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "failure_policy must be skip or retry"})
+			return
+		}
+		if policy != o.FailurePolicy {
+			retries = 0
+			if policy == repo.StandingOrderRetry {
+				retries = defaultStandingRetries
+			}
+		}
+	}
+	if req.MaxRetries != nil {
+		retries = *req.MaxRetries
+	}
+	if policy == repo.StandingOrderRetry && (retries < 1 || retries > maxStandingRetries) {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid max_retries", "max_retries": maxStandingRetries})
+		return
+	}
+	if policy == repo.StandingOrderSkip && retries != 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "max_retries requires failure_policy retry"})
+		return
+	}
+	if policy != o.FailurePolicy || retries != o.MaxRetries {
+		p.FailurePolicy, p.MaxRetries = &policy, &retries
+	}
+
+	o, err := a.Repo.UpdateStandingOrder(r.Context(), o.ID, version, p)
+	if errors.Is(err, repo.ErrVersionMismatch) {
+		writeVersionMismatch(w, o.Version)
+		return
+	}
+	if err != nil {
+		a.writeStandingOrderError(w, err)
+		return
+	}
+	w.Header().Set("ETag", versionETag(o.Version))
+	writeJSON(w, http.StatusOK, toStandingOrderDTO(o))
+}
+
+// ownStandingOrder, поручение из пути, распоряжаться им может только тот, кто распоряжается кошельком-отправителем, чужое поручение неотличимо от отсутствующего
+func (a *API) ownStandingOrder(w http.ResponseWriter, r *http.Request) (repo.StandingOrder, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return repo.StandingOrder{}, false
+	}
+	o, err := a.Repo.GetStandingOrder(r.Context(), id)
+	if err == nil && (!o.DeletedAt.IsZero() || a.authorizeWallet(r.Context(), o.From) != nil) {
+		err = repo.ErrStandingOrderNotFound
+	}
+	if err != nil {
+		a.writeStandingOrderError(w, err)
+		return repo.StandingOrder{}, false
+	}
+	return o, true
+}
+
+// writeStandingOrderError, маппит ошибки поручений в http ответ, ошибки кошельков как у обычного перевода
+func (a *API) writeStandingOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repo.ErrStandingOrderNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "standing order not found"})
+	case errors.Is(err, repo.ErrStandingOrderState):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "standing order state does not allow this"})
+	default:
+		a.writeTransferError(w, err)
+	}
+}
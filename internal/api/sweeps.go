@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+	"gotechtask/internal/sweep"
+)
+
+// пределы сбора, кошельков в одном сборе и в атомарном, атомарный держит блокировки всех кошельков до коммита
+const (
+	maxSweepWallets       = 10000
+	maxAtomicSweepWallets = 500
+)
+
+// sweepReq, сбор остатков кошельков from на кошелек to, режим atomic по умолчанию или chunked, chunk_size, кошельков в части для chunked
+type sweepReq struct {
+	To        string         `json:"to"`
+	From      []string       `json:"from"`
+	Mode      repo.SweepMode `json:"mode"`
+	ChunkSize int            `json:"chunk_size"`
+}
+
+// sweepDTO, сбор для ответа, итоги по кошелькам и собранная сумма
+type sweepDTO struct {
+	ID          int64            `json:"id"`
+	Destination string           `json:"destination"`
+	Mode        string           `json:"mode"`
+	Status      string           `json:"status"`
+	GroupID     string           `json:"group_id"`
+	CreatedBy   string           `json:"created_by"`
+	CreatedAt   string           `json:"created_at"`
+	FinishedAt  string           `json:"finished_at,omitempty"`
+	Wallets     int              `json:"wallets"`
+	Pending     int              `json:"pending"`
+	Swept       int              `json:"swept"`
+	Skipped     int              `json:"skipped"`
+	Failed      int              `json:"failed"`
+	Amount      string           `json:"amount"`
+	Items       []sweepWalletDTO `json:"items,omitempty"`
+}
+
+// sweepWalletDTO, исход кошелька сбора
+type sweepWalletDTO struct {
+	Address string `json:"address"`
+	Status  string `json:"status"`
+	Amount  string `json:"amount"`
+	Error   string `json:"error,omitempty"`
+}
+
+// toSweepDTO, маппинг сбора в ответ, суммы строкой, время в rfc3339
+func toSweepDTO(s repo.Sweep) sweepDTO {
+	d := sweepDTO{
+		ID:          s.ID,
+		Destination: s.Destination,
+		Mode:        string(s.Mode),
+		Status:      s.Status,
+		GroupID:     s.GroupID,
+		CreatedBy:   s.CreatedBy,
+		CreatedAt:   s.CreatedAt.UTC().Format(time.RFC3339),
+		Wallets:     s.Wallets,
+		Pending:     s.Pending,
+		Swept:       s.Swept,
+		Skipped:     s.Skipped,
+		Failed:      s.Failed,
+		Amount:      formatCents(s.SweptCents),
+	}
+	if !s.FinishedAt.IsZero() {
+		d.FinishedAt = s.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	return d
+}
+
+// postSweep, собирает остатки списка кошельков на один кошелек, atomic выполняется в запросе, отказ любого кошелька откатывает сбор и отдается с его номером, ответ 200,
+// chunked записывается и уходит в фоновую задачу, ответ 202, ход виден в GET /api/admin/sweeps/{id}
+func (a *API) postSweep(w http.ResponseWriter, r *http.Request) {
+	var req sweepReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = repo.SweepAtomic
+	}
+	if !repo.ValidSweepMode(req.Mode) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mode"})
+		return
+	}
+	if len(req.To) != 64 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid address format"})
+		return
+	}
+	if len(req.From) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from required"})
+		return
+	}
+	limit := maxSweepWallets
+	if req.Mode == repo.SweepAtomic {
+		limit = maxAtomicSweepWallets
+	}
+	if len(req.From) > limit {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "too many wallets", "max_wallets": limit})
+		return
+	}
+	if req.ChunkSize < 0 || req.ChunkSize > sweep.MaxChunkSize {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid chunk_size", "max_chunk_size": sweep.MaxChunkSize})
+		return
+	}
+	seen := make(map[string]bool, len(req.From))
+	for i, addr := range req.From {
+		if len(addr) != 64 {
+			writeBatchItemError(w, http.StatusBadRequest, i, "invalid address format")
+			return
+		}
+		if addr == req.To {
+			writeBatchItemError(w, http.StatusBadRequest, i, "from must differ from to")
+			return
+		}
+		if seen[addr] {
+			writeBatchItemError(w, http.StatusBadRequest, i, "duplicate wallet")
+			return
+		}
+		seen[addr] = true
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	s, err := a.Repo.CreateSweep(ctx, req.To, req.From, req.Mode)
+	if err != nil {
+		var itemErr *repo.BatchItemError
+		if errors.As(err, &itemErr) {
+			if code, msg, ok := transferRejection(itemErr.Err); ok {
+				writeBatchItemRejection(w, code, itemErr.Index, msg, itemErr.Err)
+				return
+			}
+		}
+		a.writeTransferError(w, err)
+		return
+	}
+	if req.Mode == repo.SweepAtomic {
+		writeJSON(w, http.StatusOK, toSweepDTO(s))
+		return
+	}
+	a.enqueueSweep(w, r, s, req.ChunkSize)
+}
+
+// enqueueSweep, ставит задачу сбора в очередь и отдает 202, сбой очереди не теряет сбор, его можно продолжить через resume
+func (a *API) enqueueSweep(w http.ResponseWriter, r *http.Request, s repo.Sweep, chunkSize int) {
+	if err := a.Repo.EnqueueJob(r.Context(), sweep.Kind, sweep.Payload{SweepID: s.ID, ChunkSize: chunkSize}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"error": "sweep recorded but not queued, resume it", "id": s.ID})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, toSweepDTO(s))
+}
+
+// sweepID, номер сбора из пути, неверный дает 400
+func sweepID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// getSweep, сбор с итогами и исходом каждого кошелька
+func (a *API) getSweep(w http.ResponseWriter, r *http.Request) {
+	id, ok := sweepID(w, r)
+	if !ok {
+		return
+	}
+	s, wallets, err := a.Repo.GetSweep(r.Context(), id)
+	if errors.Is(err, repo.ErrSweepNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "sweep not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	dto := toSweepDTO(s)
+	dto.Items = make([]sweepWalletDTO, 0, len(wallets))
+	for _, sw := range wallets {
+		dto.Items = append(dto.Items, sweepWalletDTO{Address: sw.Address, Status: sw.Status, Amount: formatCents(sw.AmountCents), Error: sw.Error})
+	}
+	writeJSON(w, http.StatusOK, dto)
+}
+
+// resumeSweep, возвращает отказавшие кошельки в ожидание и снова ставит задачу сбора, размер части можно передать параметром chunk_size, завершенный сбор без отказов дает 409
+func (a *API) resumeSweep(w http.ResponseWriter, r *http.Request) {
+	id, ok := sweepID(w, r)
+	if !ok {
+		return
+	}
+	chunkSize := 0
+	if v := r.URL.Query().Get("chunk_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > sweep.MaxChunkSize {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"error": "invalid chunk_size", "max_chunk_size": sweep.MaxChunkSize})
+			return
+		}
+		chunkSize = n
+	}
+	s, err := a.Repo.ResumeSweep(r.Context(), id)
+	switch {
+	case err == nil:
+	case errors.Is(err, repo.ErrSweepNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "sweep not found"})
+		return
+	case errors.Is(err, repo.ErrSweepDone):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "sweep already done"})
+		return
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	a.enqueueSweep(w, r, s, chunkSize)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotechtask/internal/auth"
+	intdb "gotechtask/internal/db"
+	"gotechtask/internal/testfixtures"
+)
+
+// openTenant, пул экземпляра арендатора, закрывается в t.Cleanup, роль в обход политик пропускает тест
+func openTenant(t *testing.T, tenant string) *sql.DB {
+	t.Helper()
+	db, err := intdb.Open(testfixtures.DSN(), tenant, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatalf("ping db: %v", err)
+	}
+	if err := intdb.CheckTenantRole(context.Background(), db); errors.Is(err, intdb.ErrRLSBypassed) {
+		t.Skip("test role bypasses row level security")
+	} else if err != nil {
+		t.Fatalf("check role: %v", err)
+	}
+	return db
+}
+
+// TestAPIKey_OtherTenant, ключ администратора арендатора A работает на его экземпляре, а на экземпляре арендатора B не находится,
+// ни как ключ пользователя, ни как администратора
+func TestAPIKey_OtherTenant(t *testing.T) {
+	a := openTenant(t, "test-a")
+	b := openTenant(t, "test-b")
+
+	fx := testfixtures.New(t, a)
+	u := fx.NewUser().Admin().Create()
+	token := fx.NewAPIKey(u.ID).Scopes(append(auth.DefaultScopes, auth.ScopeAdmin)...).Create()
+
+	for _, tc := range []struct {
+		name string
+		db   *sql.DB
+		code int
+	}{
+		{name: "own tenant", db: a, code: http.StatusOK},
+		{name: "other tenant", db: b, code: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := buildRouter(tc.db)
+			for _, path := range []string{"/api/me", "/api/admin/alerts"} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.Header.Set("Authorization", "Bearer "+token)
+				rr := httptest.NewRecorder()
+				r.ServeHTTP(rr, req)
+				if rr.Code != tc.code {
+					t.Fatalf("%s: status %d, want %d, body %s", path, rr.Code, tc.code, rr.Body.String())
+				}
+			}
+		})
+	}
+}
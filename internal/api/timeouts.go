@@ -0,0 +1,53 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// таймауты по умолчанию, для переводов и для простых запросов чтения
+const (
+	defaultTransferTimeout = 15 * time.Second
+	defaultReadTimeout     = 5 * time.Second
+)
+
+// Timeouts, сколько ручка ждет базу, Transfer для переводов, Read для чтения, нулевые берут значения по умолчанию, Routes, переопределения по маршруту вида "POST /api/send" в шаблоне chi
+type Timeouts struct {
+	Transfer time.Duration
+	Read     time.Duration
+	Routes   map[string]time.Duration
+}
+
+// withDeadline, контекст с таймаутом ручки, переопределение по маршруту важнее класса, итоговый срок уходит в заголовки X-Request-Timeout и X-Request-Deadline для отладки
+func (a *API) withDeadline(w http.ResponseWriter, r *http.Request, class time.Duration) (context.Context, context.CancelFunc) {
+	d := class
+	if rc := chi.RouteContext(r.Context()); rc != nil {
+		if v, ok := a.Timeouts.Routes[r.Method+" "+rc.RoutePattern()]; ok && v > 0 {
+			d = v
+		}
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), d)
+	deadline, _ := ctx.Deadline()
+	w.Header().Set("X-Request-Timeout", d.String())
+	w.Header().Set("X-Request-Deadline", deadline.UTC().Format(time.RFC3339Nano))
+	return ctx, cancel
+}
+
+// transferTimeout, таймаут переводов
+func (a *API) transferTimeout() time.Duration {
+	if a.Timeouts.Transfer > 0 {
+		return a.Timeouts.Transfer
+	}
+	return defaultTransferTimeout
+}
+
+// readTimeout, таймаут запросов чтения
+func (a *API) readTimeout() time.Duration {
+	if a.Timeouts.Read > 0 {
+		return a.Timeouts.Read
+	}
+	return defaultReadTimeout
+}
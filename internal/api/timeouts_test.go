@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TestWithDeadline, таймаут класса по умолчанию, переопределение по шаблону маршрута, срок в заголовках
+func TestWithDeadline(t *testing.T) {
+	serve := func(a *API, method, path string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		h := func(w http.ResponseWriter, r *http.Request) {
+			_, cancel := a.withDeadline(w, r, a.readTimeout())
+			defer cancel()
+			w.WriteHeader(http.StatusOK)
+		}
+		r.Get("/api/transactions", h)
+		r.Get("/api/transactions/{id}", h)
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+		return rr
+	}
+
+	rr := serve(&API{}, http.MethodGet, "/api/transactions")
+	if got := rr.Header().Get("X-Request-Timeout"); got != "5s" {
+		t.Fatalf("default: want 5s, got %q", got)
+	}
+	deadline, err := time.Parse(time.RFC3339Nano, rr.Header().Get("X-Request-Deadline"))
+	if err != nil || time.Until(deadline) > 5*time.Second || time.Until(deadline) < 4*time.Second {
+		t.Fatalf("deadline %q, err=%v", rr.Header().Get("X-Request-Deadline"), err)
+	}
+
+	a := &API{Timeouts: Timeouts{Read: 2 * time.Second, Routes: map[string]time.Duration{"GET /api/transactions/{id}": 700 * time.Millisecond}}}
+	if got := serve(a, http.MethodGet, "/api/transactions").Header().Get("X-Request-Timeout"); got != "2s" {
+		t.Fatalf("class override: want 2s, got %q", got)
+	}
+	if got := serve(a, http.MethodGet, "/api/transactions/42").Header().Get("X-Request-Timeout"); got != "700ms" {
+		t.Fatalf("route override: want 700ms, got %q", got)
+	}
+}
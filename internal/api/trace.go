@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// traceEventDTO, изменение баланса стороны операцией
+type traceEventDTO struct {
+	Address   string `json:"address"`
+	Delta     string `json:"delta"`
+	Balance   string `json:"balance"`
+	CreatedAt string `json:"created_at"`
+}
+
+// traceJobDTO, задача доставки квитанции
+type traceJobDTO struct {
+	ID        int64  `json:"id"`
+	Kind      string `json:"kind"`
+	Side      string `json:"side,omitempty"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error,omitempty"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// traceAuditDTO, запись аудита рядом с операцией
+type traceAuditDTO struct {
+	ID        int64          `json:"id"`
+	Action    string         `json:"action"`
+	Actor     string         `json:"actor,omitempty"`
+	Address   string         `json:"address,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+	CreatedAt string         `json:"created_at"`
+}
+
+// traceExecutionDTO, сведения о выполнении, lock_wait_ms, ожидание полос и строк кошельков за все попытки
+type traceExecutionDTO struct {
+	RequestID  string `json:"request_id,omitempty"`
+	Attempts   int    `json:"attempts"`
+	LockWaitMs int64  `json:"lock_wait_ms"`
+}
+
+// traceDTO, жизненный путь операции, execution пустой, если сведения о выполнении не записаны
+type traceDTO struct {
+	Transaction   txDTO              `json:"transaction"`
+	Execution     *traceExecutionDTO `json:"execution,omitempty"`
+	BalanceEvents []traceEventDTO    `json:"balance_events"`
+	PendingCredit string             `json:"pending_credit,omitempty"`
+	Group         []txDTO            `json:"group"`
+	Receipts      []traceJobDTO      `json:"receipts"`
+	Audit         []traceAuditDTO    `json:"audit"`
+}
+
+// getTransactionTrace, что случилось с операцией, запрос, попытки и ожидание блокировок, изменения балансов, зачисление горячему кошельку,
+// операции той же группы, доставка квитанций и аудит сторон рядом по времени, для разбора обращений, видит любые операции
+func (a *API) getTransactionTrace(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	tr, err := a.Repo.TraceTransaction(ctx, id)
+	if err != nil {
+		if errors.Is(err, repo.ErrTransactionNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "transaction not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := traceDTO{
+		Transaction:   toTxDTO(tr.Transaction),
+		BalanceEvents: make([]traceEventDTO, 0, len(tr.BalanceEvents)),
+		Group:         make([]txDTO, 0, len(tr.Group)),
+		Receipts:      make([]traceJobDTO, 0, len(tr.Receipts)),
+		Audit:         make([]traceAuditDTO, 0, len(tr.Audit)),
+	}
+	if tr.Recorded {
+		out.Execution = &traceExecutionDTO{RequestID: tr.RequestID, Attempts: tr.Attempts, LockWaitMs: tr.LockWait.Milliseconds()}
+	}
+	if tr.PendingCreditCents != 0 {
+		out.PendingCredit = formatCents(tr.PendingCreditCents)
+	}
+	for _, e := range tr.BalanceEvents {
+		out.BalanceEvents = append(out.BalanceEvents, traceEventDTO{
+			Address:   e.Address,
+			Delta:     formatCents(e.DeltaCents),
+			Balance:   formatCents(e.BalanceCents),
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	for _, t := range tr.Group {
+		out.Group = append(out.Group, toTxDTO(t))
+	}
+	for _, j := range tr.Receipts {
+		out.Receipts = append(out.Receipts, traceJobDTO{
+			ID:        j.ID,
+			Kind:      j.Kind,
+			Side:      j.Side,
+			Status:    j.Status,
+			Attempts:  j.Attempts,
+			LastError: j.LastError,
+			CreatedAt: j.CreatedAt.UTC().Format(time.RFC3339),
+			UpdatedAt: j.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	for _, e := range tr.Audit {
+		out.Audit = append(out.Audit, traceAuditDTO{
+			ID:        e.ID,
+			Action:    e.Action,
+			Actor:     e.Actor,
+			Address:   e.Address,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
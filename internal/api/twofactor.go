@@ -0,0 +1,430 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/notify"
+	"gotechtask/internal/repo"
+)
+
+// параметры подтверждения по умолчанию, срок ожидания и число попыток ввода кода
+const (
+	defaultPendingTTL  = 10 * time.Minute
+	maxConfirmAttempts = 5
+	totpIssuer         = "Wallet Service"
+)
+
+// pendingDTO, представление отложенного перевода
+type pendingDTO struct {
+	ID         int64  `json:"pending_id"`
+	Status     string `json:"status"`
+	Method     string `json:"method"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Amount     string `json:"amount"`
+	Failure    string `json:"failure,omitempty"`
+	CreatedAt  string `json:"created_at"`
+	ExpiresAt  string `json:"expires_at"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+	// PaymentRequestID, оплачиваемый запрос платежа
+	PaymentRequestID int64 `json:"payment_request_id,omitempty"`
+}
+
+// codeReq, входная модель кода totp
+type codeReq struct {
+	Code string `json:"code"`
+}
+
+// needsSecondFactor, нужен ли второй фактор, только для суммы выше порога с личного кошелька, которым распоряжается его владелец
+func (a *API) needsSecondFactor(ctx context.Context, from string, amountCents int64) (bool, error) {
+	if th := a.settings().TwoFactorThresholdCents; th <= 0 || amountCents <= th {
+		return false, nil
+	}
+	p := auth.FromContext(ctx)
+	if p.UserID == 0 {
+		return false, nil
+	}
+	owner, err := a.Repo.WalletOwner(ctx, from)
+	if err != nil {
+		return false, err
+	}
+	return owner == p.UserID, nil
+}
+
+// noSecondFactorMsg, отказ, когда у пользователя нет способа подтвердить крупный перевод
+const noSecondFactorMsg = "second factor required, enable totp or set an email"
+
+// secondFactor, способ подтверждения перевода пользователя, totp, если подключен, иначе письмо, если есть почта, пустой, если нет ни того, ни другого
+func (a *API) secondFactor(ctx context.Context, userID int64) (string, repo.User, error) {
+	_, totpOn, err := a.Repo.GetTOTP(ctx, userID)
+	if err != nil {
+		return "", repo.User{}, err
+	}
+	u, err := a.Repo.GetUser(ctx, userID)
+	if err != nil {
+		return "", repo.User{}, err
+	}
+	switch {
+	case totpOn:
+		return repo.PendingMethodTOTP, u, nil
+	case u.Email != "":
+		return repo.PendingMethodEmail, u, nil
+	}
+	return "", u, nil
+}
+
+// createPendingTransfer, откладывает перевод до подтверждения, кодом totp если он подключен, иначе ссылкой на почту пользователя, без обоих способов перевод отклоняется, requestID, оплачиваемый запрос платежа или ноль
+func (a *API) createPendingTransfer(w http.ResponseWriter, r *http.Request, from, to string, amountCents, requestID int64) {
+	ctx := r.Context()
+	userID := auth.FromContext(ctx).UserID
+
+	method, u, err := a.secondFactor(ctx, userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	ttl := a.PendingTTL
+	if ttl <= 0 {
+		ttl = defaultPendingTTL
+	}
+	p := repo.PendingTransfer{
+		UserID:      userID,
+		From:        from,
+		To:          to,
+		AmountCents: amountCents,
+		ExpiresAt:   time.Now().Add(ttl),
+		Method:      method,
+
+		PaymentRequestID: requestID,
+	}
+
+	var token, tokenHash string
+	switch method {
+	case repo.PendingMethodEmail:
+		if token, tokenHash, err = auth.NewConfirmationToken(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	case "":
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": noSecondFactorMsg})
+		return
+	}
+
+	p, err = a.Repo.CreatePendingTransfer(ctx, p, tokenHash)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if p.Method == repo.PendingMethodEmail {
+		err := a.Repo.EnqueueJob(ctx, notify.KindTransferConfirmation, notify.Confirmation{
+			Email:       u.Email,
+			Link:        a.confirmationLink(token),
+			From:        from,
+			To:          to,
+			AmountCents: amountCents,
+			ExpiresAt:   p.ExpiresAt,
+		})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+	writeJSON(w, http.StatusAccepted, toPendingDTO(p))
+}
+
+// confirmationLink, ссылка подтверждения для письма
+func (a *API) confirmationLink(token string) string {
+	return strings.TrimRight(a.PublicURL, "/") + "/api/transfers/pending/confirm?token=" + url.QueryEscape(token)
+}
+
+// getPendingTransfer, состояние отложенного перевода текущего пользователя
+func (a *API) getPendingTransfer(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.ownPending(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, toPendingDTO(p))
+}
+
+// confirmPendingTransfer, подтверждает перевод кодом totp и исполняет его, после maxConfirmAttempts неверных кодов перевод отклоняется
+func (a *API) confirmPendingTransfer(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.ownPending(w, r)
+	if !ok {
+		return
+	}
+	if p.Method != repo.PendingMethodTOTP {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "transfer is confirmed by email link"})
+		return
+	}
+	var req codeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+
+	secret, _, err := a.Repo.GetTOTP(r.Context(), p.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	valid, err := a.checkTOTP(r.Context(), p.UserID, secret, req.Code)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if !valid {
+		switch err := a.Repo.RecordPendingAttempt(r.Context(), p.ID, maxConfirmAttempts); {
+		case err == nil:
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+		case errors.Is(err, repo.ErrTooManyAttempts):
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "too many attempts, transfer rejected"})
+		default:
+			writePendingError(w, err)
+		}
+		return
+	}
+	a.executePending(w, r, p.ID)
+}
+
+// confirmPendingByLink, подтверждает перевод по токену из письма и исполняет его
+func (a *API) confirmPendingByLink(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "token required"})
+		return
+	}
+	p, err := a.Repo.PendingTransferByToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		writePendingError(w, err)
+		return
+	}
+	// ссылку открывают без аутентификации, инициатором перевода остается создавший его пользователь
+	r = r.WithContext(repo.WithCaller(r.Context(), repo.Caller{UserID: p.UserID}))
+	a.executePending(w, r, p.ID)
+}
+
+// executePending, исполняет подтвержденный перевод и отдает его итоговое состояние
+func (a *API) executePending(w http.ResponseWriter, r *http.Request, id int64) {
+	ctx, cancel := a.withDeadline(w, r, a.transferTimeout())
+	defer cancel()
+
+	p, err := a.Repo.ExecutePendingTransfer(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrPendingNotFound), errors.Is(err, repo.ErrPendingResolved), errors.Is(err, repo.ErrPendingExpired):
+			writePendingError(w, err)
+		default:
+			a.writePaymentRequestError(w, err)
+		}
+		return
+	}
+	a.transferCommitted(p.From, p.To)
+	writeJSON(w, http.StatusOK, toPendingDTO(p))
+}
+
+// ownPending, отложенный перевод из пути, чужой перевод неотличим от отсутствующего
+func (a *API) ownPending(w http.ResponseWriter, r *http.Request) (repo.PendingTransfer, bool) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return repo.PendingTransfer{}, false
+	}
+	p, err := a.Repo.GetPendingTransfer(r.Context(), id)
+	if err == nil && p.UserID != auth.FromContext(r.Context()).UserID {
+		err = repo.ErrPendingNotFound
+	}
+	if err != nil {
+		writePendingError(w, err)
+		return repo.PendingTransfer{}, false
+	}
+	return p, true
+}
+
+// writePendingError, маппит ошибки отложенного перевода в http ответ
+func writePendingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, repo.ErrPendingNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "pending transfer not found"})
+	case errors.Is(err, repo.ErrPendingResolved):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "pending transfer already resolved"})
+	case errors.Is(err, repo.ErrPendingExpired):
+		writeJSON(w, http.StatusGone, map[string]string{"error": "pending transfer expired"})
+	default:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
+
+// checkTOTP, проверяет код и отмечает его интервал принятым, повтор уже принятого кода неверен, как и чужой
+func (a *API) checkTOTP(ctx context.Context, userID int64, secret, code string) (bool, error) {
+	counter, ok := auth.VerifyTOTP(secret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	switch err := a.Repo.UseTOTPCounter(ctx, userID, counter); {
+	case errors.Is(err, repo.ErrTOTPCodeUsed):
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// postTOTP, начинает подключение totp, отдает секрет и otpauth ссылку, замена уже включенного totp требует текущего кода,
+// пользователь с почтой подтверждает переводы письмом, поэтому подключение вместо почты завершается токеном, отправленным на нее,
+// иначе ключ доступа без почтового ящика сам заменил бы второй фактор
+func (a *API) postTOTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	var req codeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	current, enabled, err := a.Repo.GetTOTP(r.Context(), p.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if enabled {
+		valid, err := a.checkTOTP(r.Context(), p.UserID, current, req.Code)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		if !valid {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "current totp code required"})
+			return
+		}
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	u, err := a.Repo.GetUser(r.Context(), p.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	var token, tokenHash string
+	var expires time.Time
+	if !enabled && u.Email != "" {
+		if token, tokenHash, err = auth.NewConfirmationToken(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+		ttl := a.PendingTTL
+		if ttl <= 0 {
+			ttl = defaultPendingTTL
+		}
+		expires = time.Now().Add(ttl)
+	}
+	if err := a.Repo.SetTOTPSecret(r.Context(), p.UserID, secret, tokenHash, expires); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if token != "" {
+		err := a.Repo.EnqueueJob(r.Context(), notify.KindTOTPEnrollment, notify.TOTPEnrollment{Email: u.Email, Token: token, ExpiresAt: expires})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+			return
+		}
+	}
+
+	account := u.Email
+	if account == "" {
+		account = strconv.FormatInt(u.ID, 10)
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{
+		"secret":             secret,
+		"otpauth_uri":        auth.TOTPURI(totpIssuer, account, secret),
+		"email_confirmation": token != "",
+	})
+}
+
+// enableTOTPReq, входная модель включения totp, код из приложения и токен из письма, если подключение его ждет
+type enableTOTPReq struct {
+	Code       string `json:"code"`
+	EmailToken string `json:"email_token"`
+}
+
+// enableTOTP, включает totp после проверки первого кода из приложения и токена из письма, если он был отправлен
+func (a *API) enableTOTP(w http.ResponseWriter, r *http.Request) {
+	p, ok := keyOwner(w, r)
+	if !ok {
+		return
+	}
+	var req enableTOTPReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	secret, _, err := a.Repo.GetTOTP(r.Context(), p.UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if secret == "" {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "totp not enrolled"})
+		return
+	}
+	valid, err := a.checkTOTP(r.Context(), p.UserID, secret, req.Code)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if !valid {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid code"})
+		return
+	}
+	var tokenHash string
+	if req.EmailToken != "" {
+		tokenHash = auth.HashToken(req.EmailToken)
+	}
+	switch err := a.Repo.EnableTOTP(r.Context(), p.UserID, tokenHash); {
+	case errors.Is(err, repo.ErrTOTPNotEnrolled):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "totp not enrolled"})
+	case errors.Is(err, repo.ErrTOTPEnrollToken):
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "email token required"})
+	case err != nil:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	default:
+		writeJSON(w, http.StatusOK, sendResp{Status: "ok"})
+	}
+}
+
+// toPendingDTO, маппинг отложенного перевода в ответ
+func toPendingDTO(p repo.PendingTransfer) pendingDTO {
+	dto := pendingDTO{
+		ID:        p.ID,
+		Status:    p.Status,
+		Method:    p.Method,
+		From:      p.From,
+		To:        p.To,
+		Amount:    formatCents(p.AmountCents),
+		Failure:   p.Failure,
+		CreatedAt: p.CreatedAt.UTC().Format(time.RFC3339),
+		ExpiresAt: p.ExpiresAt.UTC().Format(time.RFC3339),
+
+		PaymentRequestID: p.PaymentRequestID,
+	}
+	if !p.ResolvedAt.IsZero() {
+		dto.ResolvedAt = p.ResolvedAt.UTC().Format(time.RFC3339)
+	}
+	return dto
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/notify"
+	"gotechtask/internal/repo"
+)
+
+// TestTOTPEnrollment, пользователю с почтой токен подключения уходит письмом, без почты и при замене включенного totp текущим кодом письма нет,
+// текущий код, уже принятый раньше, замену не разрешает
+func TestTOTPEnrollment(t *testing.T) {
+	current, _ := auth.NewTOTPSecret()
+	code, _ := auth.TOTPCode(current, time.Now())
+	for _, tc := range []struct {
+		name    string
+		email   string
+		enabled bool
+		used    bool
+		status  int
+		emailed bool
+	}{
+		{name: "email user", email: "u@example.com", status: http.StatusCreated, emailed: true},
+		{name: "no email", status: http.StatusCreated},
+		{name: "replace with current code", email: "u@example.com", enabled: true, status: http.StatusCreated},
+		{name: "replace with used code", email: "u@example.com", enabled: true, used: true, status: http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newMockRepo()
+			m.GetTOTPFunc = func(context.Context, int64) (string, bool, error) { return current, tc.enabled, nil }
+			m.GetUserFunc = func(context.Context, int64) (repo.User, error) {
+				return repo.User{ID: mockUserID, Email: tc.email}, nil
+			}
+			m.UseTOTPCounterFunc = func(context.Context, int64, int64) error {
+				if tc.used {
+					return repo.ErrTOTPCodeUsed
+				}
+				return nil
+			}
+			m.SetTOTPSecretFunc = func(context.Context, int64, string, string, time.Time) error { return nil }
+			m.EnqueueJobFunc = func(context.Context, string, any) error { return nil }
+			r := chi.NewRouter()
+			(&API{Repo: m}).Routes(r)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/me/totp", strings.NewReader(`{"code":"`+code+`"}`))
+			req.Header.Set("Authorization", "Bearer wk_mock")
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != tc.status {
+				t.Fatalf("status %d, body %s", rr.Code, rr.Body.String())
+			}
+			if tc.status != http.StatusCreated {
+				if len(m.SetTOTPSecretCalls()) != 0 {
+					t.Fatal("secret replaced without a valid current code")
+				}
+				return
+			}
+			set := m.SetTOTPSecretCalls()
+			if len(set) != 1 || (set[0].EnrollTokenHash != "") != tc.emailed {
+				t.Fatalf("set secret: %+v", set)
+			}
+			jobs := m.EnqueueJobCalls()
+			if tc.emailed != (len(jobs) == 1 && jobs[0].Kind == notify.KindTOTPEnrollment) {
+				t.Fatalf("enrollment email: %+v", jobs)
+			}
+			if tc.emailed && auth.HashToken(jobs[0].Payload.(notify.TOTPEnrollment).Token) != set[0].EnrollTokenHash {
+				t.Fatal("emailed token does not match stored hash")
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// maxTxPages, сколько разных страниц истории держит кэш, при переполнении он очищается
+const maxTxPages = 1000
+
+// TxCache, кэш готовых страниц истории транзакций по участнику и параметрам запроса, панели опрашивают один и тот же запрос,
+// свежая запись отдается без обращения к базе, устаревшая перечитывается, только если сдвинулся id последней транзакции
+type TxCache struct {
+	TTL time.Duration
+
+	mu    sync.Mutex
+	pages map[string]*txPage
+}
+
+// txPage, готовый ответ страницы истории, maxID, последняя транзакция на момент чтения, body, тело json
+type txPage struct {
+	maxID    int64
+	etag     string
+	modified time.Time
+	header   http.Header
+	body     []byte
+	at       time.Time
+}
+
+// NewTxCache, конструктор, ttl равный нулю выключает кэш, условные запросы работают и без него
+func NewTxCache(ttl time.Duration) *TxCache {
+	return &TxCache{TTL: ttl, pages: make(map[string]*txPage)}
+}
+
+// get, страница по ключу и свежа ли она, несвежую можно отдать, если последняя транзакция не изменилась
+func (c *TxCache) get(key string, now time.Time) (*txPage, bool) {
+	if c == nil || c.TTL <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pages[key]
+	if !ok {
+		return nil, false
+	}
+	return p, now.Sub(p.at) < c.TTL
+}
+
+// put, кладет страницу, прочитанную или подтвержденную в now
+func (c *TxCache) put(key string, p *txPage, now time.Time) {
+	if c == nil || c.TTL <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.pages[key]; !ok && len(c.pages) >= maxTxPages {
+		c.pages = make(map[string]*txPage)
+	}
+	c.pages[key] = &txPage{maxID: p.maxID, etag: p.etag, modified: p.modified, header: p.header, body: p.body, at: now}
+}
+
+// txPageKey, ключ страницы, видимость участника и параметры запроса в каноническом порядке
+func txPageKey(vis repo.TxVisibility, qs url.Values) string {
+	return fmt.Sprintf("%t:%d?%s", vis.All, vis.UserID, qs.Encode())
+}
+
+// txPageETag, тег страницы из ключа и id последней транзакции, новая транзакция меняет теги всех страниц
+func txPageETag(key string, maxID int64) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return fmt.Sprintf(`"%d-%x"`, maxID, h.Sum64())
+}
+
+// newTxPage, страница без тела с тегом и временем последней транзакции, Last-Modified с точностью до секунды,
+// в текущей секунде еще могут прийти транзакции, поэтому время ставится только за прошедшие
+func newTxPage(key string, maxID int64, at, now time.Time) *txPage {
+	p := &txPage{maxID: maxID, etag: txPageETag(key, maxID)}
+	if at.Truncate(time.Second).Before(now.Truncate(time.Second)) {
+		p.modified = at
+	}
+	return p
+}
+
+// notModified, клиент уже держит эту страницу, If-None-Match важнее If-Modified-Since
+func (p *txPage) notModified(r *http.Request) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatch(inm, p.etag)
+	}
+	if p.modified.IsZero() {
+		return false
+	}
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !p.modified.Truncate(time.Second).After(ims)
+}
+
+// etagMatch, тег есть в списке If-None-Match, слабые теги сравниваются без префикса
+func etagMatch(header, etag string) bool {
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || t == etag || t == "W/"+etag {
+			return true
+		}
+	}
+	return false
+}
+
+// head, ставит тег и время страницы, если клиент ее уже держит, отдает 304 и true
+func (p *txPage) head(w http.ResponseWriter, r *http.Request) bool {
+	h := w.Header()
+	h.Set("ETag", p.etag)
+	if !p.modified.IsZero() {
+		h.Set("Last-Modified", p.modified.UTC().Format(http.TimeFormat))
+	}
+	h.Set("Cache-Control", "private, no-cache")
+	if p.notModified(r) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// write, отдает страницу или 304 с ее тегом
+func (p *txPage) write(w http.ResponseWriter, r *http.Request) {
+	if p.head(w, r) {
+		return
+	}
+	h := w.Header()
+	for k, v := range p.header {
+		h[k] = v
+	}
+	h.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(p.body)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// TestTxCache_FreshAndConditional, свежесть записи по ttl, ключ различает участников, условные заголовки дают 304
+func TestTxCache_FreshAndConditional(t *testing.T) {
+	c := NewTxCache(time.Second)
+	now := time.Now()
+	qs := url.Values{"count": {"10"}}
+	admin, user := txPageKey(repo.TxVisibility{All: true}, qs), txPageKey(repo.TxVisibility{UserID: 7}, qs)
+	if admin == user {
+		t.Fatalf("visibility not in key: %s", admin)
+	}
+
+	at := now.Add(-time.Minute)
+	c.put(admin, &txPage{maxID: 5, etag: txPageETag(admin, 5), modified: at, body: []byte("[]\n")}, now)
+	if p, fresh := c.get(admin, now.Add(500*time.Millisecond)); p == nil || !fresh {
+		t.Fatalf("want fresh hit within ttl")
+	}
+	p, fresh := c.get(admin, now.Add(2*time.Second))
+	if p == nil || fresh || p.maxID != 5 {
+		t.Fatalf("want stale page with max id after ttl, got %+v %v", p, fresh)
+	}
+	if p, _ := c.get(user, now); p != nil {
+		t.Fatalf("page leaked to another participant")
+	}
+
+	serve := func(h, v string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/transactions", nil)
+		if h != "" {
+			req.Header.Set(h, v)
+		}
+		rr := httptest.NewRecorder()
+		p.write(rr, req)
+		return rr
+	}
+	if rr := serve("", ""); rr.Code != http.StatusOK || rr.Body.String() != "[]\n" || rr.Header().Get("ETag") != p.etag {
+		t.Fatalf("plain: %d %q %q", rr.Code, rr.Body.String(), rr.Header().Get("ETag"))
+	}
+	if rr := serve("If-None-Match", `"other", `+p.etag); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("matching etag: %d", rr.Code)
+	}
+	if rr := serve("If-None-Match", txPageETag(admin, 6)); rr.Code != http.StatusOK {
+		t.Fatalf("new transaction etag: %d", rr.Code)
+	}
+	if rr := serve("If-Modified-Since", at.UTC().Format(http.TimeFormat)); rr.Code != http.StatusNotModified {
+		t.Fatalf("if-modified-since: %d", rr.Code)
+	}
+	if rr := serve("If-Modified-Since", at.Add(-time.Hour).UTC().Format(http.TimeFormat)); rr.Code != http.StatusOK {
+		t.Fatalf("older if-modified-since: %d", rr.Code)
+	}
+
+	var disabled *TxCache
+	disabled.put(admin, p, now)
+	if p, _ := disabled.get(admin, now); p != nil {
+		t.Fatalf("want nil cache to never hit")
+	}
+}
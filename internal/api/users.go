@@ -0,0 +1,313 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/address"
+	"gotechtask/internal/auth"
+	"gotechtask/internal/money"
+	"gotechtask/internal/repo"
+)
+
+// userReq, входная модель регистрации, почта и имя
+type userReq struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+}
+
+// userDTO, представление пользователя, ключ доступа отдается только при регистрации
+type userDTO struct {
+	ID        int64  `json:"id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	APIKey    string `json:"api_key,omitempty"`
+}
+
+// walletDTO, представление кошелька в списках
+type walletDTO struct {
+	Address   string `json:"address"`
+	Balance   string `json:"balance"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	LastTxAt  string `json:"last_tx_at,omitempty"`
+	// Email и LowBalanceThreshold, настройки кошелька, почта для квитанций и порог низкого баланса, только заданные
+	Email               string `json:"email,omitempty"`
+	LowBalanceThreshold string `json:"low_balance_threshold,omitempty"`
+	// ClosedAt и Successor, только у кошелька, закрытого слиянием, преемник получил его баланс
+	ClosedAt  string `json:"closed_at,omitempty"`
+	Successor string `json:"successor,omitempty"`
+	// DerivedLabel, метка, из которой выведен адрес
+	DerivedLabel string `json:"derived_label,omitempty"`
+}
+
+// walletPatchReq, изменение настроек кошелька, отсутствующее поле не меняется, пустая почта очищает адрес, нулевой порог выключает уведомление
+type walletPatchReq struct {
+	Email               *string  `json:"email"`
+	LowBalanceThreshold *float64 `json:"low_balance_threshold"`
+}
+
+// postUser, регистрирует пользователя и выдает ему ключ доступа, ключ показывается один раз, в базе хранится только хэш
+func (a *API) postUser(w http.ResponseWriter, r *http.Request) {
+	var req userReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid email"})
+		return
+	}
+
+	token, hash, prefix, err := auth.NewToken()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	u, err := a.Repo.RegisterUser(r.Context(), req.Email, req.Name, hash, prefix)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserExists) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "user already exists"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	dto := toUserDTO(u)
+	dto.APIKey = token
+	writeJSON(w, http.StatusCreated, dto)
+}
+
+// getMe, отдает текущего пользователя
+func (a *API) getMe(w http.ResponseWriter, r *http.Request) {
+	p := auth.FromContext(r.Context())
+	if p.UserID == 0 {
+		// администратор по общему токену не является пользователем
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+		return
+	}
+	u, err := a.Repo.GetUser(r.Context(), p.UserID)
+	if err != nil {
+		if errors.Is(err, repo.ErrUserNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, toUserDTO(u))
+}
+
+// getMyWallets, кошельки текущего пользователя
+func (a *API) getMyWallets(w http.ResponseWriter, r *http.Request) {
+	items, err := a.Repo.ListUserWallets(r.Context(), auth.FromContext(r.Context()).UserID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := make([]walletDTO, 0, len(items))
+	for _, wl := range items {
+		out = append(out, toWalletDTO(wl))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// walletModeGetOrCreate, режим создания, при котором свой существующий кошелек отдается вместо ошибки
+const walletModeGetOrCreate = "get_or_create"
+
+// walletReq, входная модель создания кошелька, тело необязательно, address, свой адрес вместо случайного,
+// mode, пустой или get_or_create для идемпотентного заведения кошельков
+type walletReq struct {
+	Address string `json:"address"`
+	Mode    string `json:"mode"`
+}
+
+// postWallet, создает пустой кошелек текущего пользователя, занятый адрес отвечает 409 с кодом wallet_exists,
+// в режиме get_or_create свой кошелек с этим адресом отдается с 200
+func (a *API) postWallet(w http.ResponseWriter, r *http.Request) {
+	p := auth.FromContext(r.Context())
+	if p.UserID == 0 {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "wallets belong to users"})
+		return
+	}
+	var req walletReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if req.Address != "" {
+		// свой адрес принимается в нижнем регистре или с верной контрольной суммой, строгий режим касается только отправки
+		canon, err := address.Parse(req.Address, false)
+		if err != nil {
+			msg := "invalid address format"
+			if errors.Is(err, address.ErrChecksum) {
+				msg = "invalid address checksum"
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+			return
+		}
+		req.Address = canon
+	}
+
+	var wl repo.Wallet
+	var err error
+	created := true
+	switch req.Mode {
+	case "":
+		wl, err = a.Repo.CreateWallet(r.Context(), p.UserID, req.Address)
+	case walletModeGetOrCreate:
+		if req.Address == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "address is required for get_or_create"})
+			return
+		}
+		wl, created, err = a.Repo.GetOrCreateWallet(r.Context(), p.UserID, req.Address)
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mode"})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, repo.ErrWalletExists) {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "wallet already exists", "code": "wallet_exists", "address": req.Address})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	// новый адрес отдается с контрольной суммой в регистре букв, путь в Location каноничный
+	dto := toWalletDTO(wl)
+	dto.Address = address.Checksum(wl.Address)
+	w.Header().Set("ETag", versionETag(wl.MetaVersion))
+	if !created {
+		writeJSON(w, http.StatusOK, dto)
+		return
+	}
+	writeCreated(w, "/api/wallet/"+wl.Address, dto)
+}
+
+// getWallet, кошелек с балансом и временем создания, изменения и последнего перевода, личный кошелек виден только владельцу и администратору
+func (a *API) getWallet(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	wl, err := a.Repo.GetWallet(r.Context(), addr)
+	if err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+	if wl.Successor != "" {
+		writeWalletClosed(w, wl)
+		return
+	}
+	w.Header().Set("ETag", versionETag(wl.MetaVersion))
+	writeJSON(w, http.StatusOK, toWalletDTO(wl))
+}
+
+// patchWallet, меняет настройки кошелька, порог низкого баланса может менять тот, кто распоряжается кошельком, почту только администратор,
+// заголовок If-Match с ETag кошелька обязателен, настройки, измененные после чтения, дают 412 с текущим ETag
+func (a *API) patchWallet(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+
+	var req walletPatchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	var p repo.WalletMetaPatch
+	if req.Email != nil {
+		if !auth.FromContext(r.Context()).Admin {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "email can be changed by admin only"})
+			return
+		}
+		if *req.Email != "" {
+			if _, err := mail.ParseAddress(*req.Email); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid email"})
+				return
+			}
+		}
+		p.Email = req.Email
+	}
+	if req.LowBalanceThreshold != nil {
+		if *req.LowBalanceThreshold < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "threshold must be >= 0"})
+			return
+		}
+		cents := toCents(*req.LowBalanceThreshold)
+		if cents > money.MaxCents {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "threshold too large"})
+			return
+		}
+		p.LowBalanceCents = &cents
+	}
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+	version, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+
+	wl, err := a.Repo.UpdateWalletMeta(r.Context(), addr, version, p, repo.ActorFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, repo.ErrVersionMismatch):
+			writeVersionMismatch(w, wl.MetaVersion)
+		case errors.Is(err, repo.ErrWalletNotFound):
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		}
+		return
+	}
+	w.Header().Set("ETag", versionETag(wl.MetaVersion))
+	writeJSON(w, http.StatusOK, toWalletDTO(wl))
+}
+
+// toUserDTO, маппинг пользователя в ответ
+func toUserDTO(u repo.User) userDTO {
+	return userDTO{
+		ID:        u.ID,
+		Email:     u.Email,
+		Name:      u.Name,
+		CreatedAt: u.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// toWalletDTO, маппинг кошелька в ответ
+func toWalletDTO(wl repo.Wallet) walletDTO {
+	dto := walletDTO{
+		Address:   wl.Address,
+		Balance:   formatCents(wl.BalanceCents),
+		CreatedAt: wl.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: wl.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if !wl.LastTxAt.IsZero() {
+		dto.LastTxAt = wl.LastTxAt.UTC().Format(time.RFC3339)
+	}
+	dto.Email = wl.Email
+	if wl.LowBalanceCents > 0 {
+		dto.LowBalanceThreshold = formatCents(wl.LowBalanceCents)
+	}
+	if wl.Successor != "" {
+		dto.ClosedAt = wl.ClosedAt.UTC().Format(time.RFC3339)
+		dto.Successor = wl.Successor
+	}
+	dto.DerivedLabel = wl.DerivedLabel
+	return dto
+}
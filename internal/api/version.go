@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"gotechtask/internal/buildinfo"
+)
+
+// versionDTO, сведения о сборке, commit и date пустые, если бинарник собран без ldflags и без сведений о vcs
+type versionDTO struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	Date      string   `json:"date"`
+	Modified  bool     `json:"modified,omitempty"`
+	GoVersion string   `json:"go_version"`
+	Features  []string `json:"features"`
+}
+
+// getVersion, что именно развернуто, версия, коммит и дата сборки, версия go и флаги возможностей, открыта без аутентификации
+func (a *API) getVersion(w http.ResponseWriter, r *http.Request) {
+	b := buildinfo.Get()
+	writeJSON(w, http.StatusOK, versionDTO{
+		Version:   b.Version,
+		Commit:    b.Commit,
+		Date:      b.Date,
+		Modified:  b.Modified,
+		GoVersion: b.GoVersion,
+		Features:  b.Features,
+	})
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/buildinfo"
+)
+
+// TestVersion, сведения о сборке из ldflags отдаются без аутентификации
+func TestVersion(t *testing.T) {
+	defer func(v, c, d, f string) {
+		buildinfo.Version, buildinfo.Commit, buildinfo.Date, buildinfo.Features = v, c, d, f
+	}(buildinfo.Version, buildinfo.Commit, buildinfo.Date, buildinfo.Features)
+	buildinfo.Version, buildinfo.Commit, buildinfo.Date, buildinfo.Features = "1.4.0", "abc123", "2026-10-01T12:00:00Z", "ledger, backfill"
+
+	r := chi.NewRouter()
+	(&API{Repo: newMockRepo(), AdminToken: testAdminToken}).Routes(r)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/version", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("want 200, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	var got versionDTO
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Version != "1.4.0" || got.Commit != "abc123" || got.Date != "2026-10-01T12:00:00Z" || got.Modified || got.GoVersion != runtime.Version() {
+		t.Fatalf("version: %+v", got)
+	}
+	if !slices.Equal(got.Features, []string{"backfill", "ledger"}) {
+		t.Fatalf("features: %v", got.Features)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"gotechtask/internal/repo"
+)
+
+// walletStatsDTO, итоги переводов кошелька, суммы строкой, время пустое, если переводов не было
+type walletStatsDTO struct {
+	Address       string `json:"address"`
+	Sent          string `json:"sent"`
+	Received      string `json:"received"`
+	SentCount     int64  `json:"sent_count"`
+	ReceivedCount int64  `json:"received_count"`
+	TxCount       int64  `json:"tx_count"`
+	FirstTxAt     string `json:"first_tx_at,omitempty"`
+	LastTxAt      string `json:"last_tx_at,omitempty"`
+}
+
+// getWalletStats, сколько кошелек отправил и получил за все время, число переводов и время первого и последнего
+func (a *API) getWalletStats(w http.ResponseWriter, r *http.Request) {
+	addr := chi.URLParam(r, "address")
+	if err := a.authorizeWallet(r.Context(), addr); err != nil {
+		writeWalletAccessError(w, err)
+		return
+	}
+
+	ctx, cancel := a.withDeadline(w, r, a.readTimeout())
+	defer cancel()
+
+	s, err := a.Repo.GetWalletStats(ctx, addr)
+	if err != nil {
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "wallet not found"})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+		return
+	}
+
+	out := walletStatsDTO{
+		Address:       s.Address,
+		Sent:          formatCents(s.SentCents),
+		Received:      formatCents(s.ReceivedCents),
+		SentCount:     s.SentCount,
+		ReceivedCount: s.ReceivedCount,
+		TxCount:       s.TxCount(),
+	}
+	if !s.FirstTxAt.IsZero() {
+		out.FirstTxAt = s.FirstTxAt.UTC().Format(time.RFC3339)
+		out.LastTxAt = s.LastTxAt.UTC().Format(time.RFC3339)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
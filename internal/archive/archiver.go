@@ -0,0 +1,122 @@
+// Package archive, обслуживание помесячных партиций транзакций, заранее создает будущие месяцы и уносит в архив месяцы старше срока хранения
+package archive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"gotechtask/internal/leader"
+	"gotechtask/internal/repo"
+	"gotechtask/internal/storage"
+)
+
+// Store, операции над партициями, реализуется репозиторием postgres
+type Store interface {
+	EnsureTransactionPartition(ctx context.Context, at time.Time) (string, error)
+	ListTransactionPartitions(ctx context.Context) ([]repo.TxPartition, error)
+	ArchiveTransactionPartition(ctx context.Context, p repo.TxPartition) (int64, error)
+	WriteTransactionPartitionCSV(ctx context.Context, p repo.TxPartition, w io.Writer) error
+	DropTransactionPartition(ctx context.Context, p repo.TxPartition) error
+}
+
+// Archiver, периодическая задача обслуживания партиций
+type Archiver struct {
+	Store Store
+	// Interval, период запуска
+	Interval time.Duration
+	// Retention, сколько хранить транзакции в горячей таблице, ноль отключает перенос в архив
+	Retention time.Duration
+	// Blob, внешнее хранилище, если задано партиции выгружаются туда в csv вместо таблицы transactions_archive
+	Blob storage.Store
+	// Lock, блокировка прохода среди экземпляров сервиса, nil для одного экземпляра
+	Lock leader.Locker
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор архиватора
+func New(s Store, interval, retention time.Duration) *Archiver {
+	return &Archiver{Store: s, Interval: interval, Retention: retention, Now: time.Now}
+}
+
+// Run, выполняет обслуживание сразу и затем с заданным интервалом до отмены контекста, из нескольких экземпляров проход делает один
+func (a *Archiver) Run(ctx context.Context) {
+	t := time.NewTicker(a.Interval)
+	defer t.Stop()
+
+	for {
+		if _, err := leader.Do(ctx, a.Lock, "archive", a.RunOnce); err != nil {
+			log.Printf("archive: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, создает партиции текущего и следующего месяца, затем архивирует партиции, целиком лежащие старше срока хранения
+func (a *Archiver) RunOnce(ctx context.Context) error {
+	now := a.Now().UTC()
+	for _, at := range []time.Time{now, now.AddDate(0, 1, 0)} {
+		if _, err := a.Store.EnsureTransactionPartition(ctx, at); err != nil {
+			return err
+		}
+	}
+
+	if a.Retention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-a.Retention)
+
+	parts, err := a.Store.ListTransactionPartitions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range parts {
+		// партиция уходит в архив только когда конец ее месяца старше границы хранения
+		if p.Month.AddDate(0, 1, 0).After(cutoff) {
+			continue
+		}
+		if a.Blob != nil {
+			key, err := a.export(ctx, p)
+			if err != nil {
+				return err
+			}
+			// партиция удаляется только после успешной выгрузки
+			if err := a.Store.DropTransactionPartition(ctx, p); err != nil {
+				return err
+			}
+			log.Printf("archive: exported partition %s to %s", p.Name, key)
+			continue
+		}
+		n, err := a.Store.ArchiveTransactionPartition(ctx, p)
+		if err != nil {
+			return err
+		}
+		log.Printf("archive: moved partition %s, %d rows", p.Name, n)
+	}
+	return nil
+}
+
+// export, потоково выгружает партицию в хранилище через pipe, строки читаются из базы по мере загрузки, возвращает ключ объекта
+func (a *Archiver) export(ctx context.Context, p repo.TxPartition) (string, error) {
+	key := fmt.Sprintf("archive/transactions/%s.csv", p.Month.Format("2006-01"))
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(a.Store.WriteTransactionPartitionCSV(ctx, p, pw))
+	}()
+	err := a.Blob.Put(ctx, key, pr, "text/csv")
+	// если загрузка оборвалась раньше, останавливаем писателя
+	pr.CloseWithError(err)
+	if err != nil {
+		return "", fmt.Errorf("export %s: %w", p.Name, err)
+	}
+	return key, nil
+}
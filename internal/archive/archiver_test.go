@@ -0,0 +1,61 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, партиции в памяти
+type fakeStore struct {
+	ensured  []time.Time
+	parts    []repo.TxPartition
+	archived []string
+}
+
+func (f *fakeStore) EnsureTransactionPartition(_ context.Context, at time.Time) (string, error) {
+	f.ensured = append(f.ensured, at)
+	return "", nil
+}
+
+func (f *fakeStore) ListTransactionPartitions(context.Context) ([]repo.TxPartition, error) {
+	return f.parts, nil
+}
+
+func (f *fakeStore) ArchiveTransactionPartition(_ context.Context, p repo.TxPartition) (int64, error) {
+	f.archived = append(f.archived, p.Name)
+	return 1, nil
+}
+
+func (f *fakeStore) WriteTransactionPartitionCSV(context.Context, repo.TxPartition, io.Writer) error {
+	return nil
+}
+
+func (f *fakeStore) DropTransactionPartition(context.Context, repo.TxPartition) error { return nil }
+
+func month(y int, m time.Month) time.Time { return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC) }
+
+// TestRunOnce_ArchivesOnlyExpiredMonths, проверяет что в архив уходят только месяцы, целиком старше срока хранения
+func TestRunOnce_ArchivesOnlyExpiredMonths(t *testing.T) {
+	st := &fakeStore{parts: []repo.TxPartition{
+		{Name: "transactions_y2025m01", Month: month(2025, time.January)},
+		{Name: "transactions_y2025m02", Month: month(2025, time.February)},
+		{Name: "transactions_y2025m03", Month: month(2025, time.March)},
+		{Name: "transactions_y2025m06", Month: month(2025, time.June)},
+	}}
+	a := New(st, time.Hour, 90*24*time.Hour)
+	a.Now = func() time.Time { return time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC) }
+
+	if err := a.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(st.ensured) != 2 {
+		t.Fatalf("want current and next month ensured, got %v", st.ensured)
+	}
+	if len(st.archived) != 2 || st.archived[0] != "transactions_y2025m01" || st.archived[1] != "transactions_y2025m02" {
+		t.Fatalf("unexpected archived partitions: %v", st.archived)
+	}
+}
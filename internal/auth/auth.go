@@ -0,0 +1,73 @@
+// Package auth, участник запроса и работа с токенами доступа, участник кладется в контекст middleware и читается обработчиками
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Principal, аутентифицированный участник запроса, пользователь, ключ которым он вошел, признак администратора, области доступа ключа
+type Principal struct {
+	UserID int64
+	KeyID  int64
+	Admin  bool
+	// Scopes, области доступа ключа, nil для входа без ключа (токен администратора, провайдер oidc, аноним), такой участник ограничен только владением кошельками
+	Scopes []string
+	// SigningSecret, секрет подписи переводов ключа, пустой если подпись для ключа не включена
+	SigningSecret string
+}
+
+// Anonymous, участник без аутентификации
+func (p Principal) Anonymous() bool { return p.UserID == 0 && !p.Admin }
+
+// Allows, разрешена ли участнику область доступа
+func (p Principal) Allows(scope string) bool {
+	return p.Scopes == nil || HasScope(p.Scopes, scope)
+}
+
+type ctxKey struct{}
+
+// WithPrincipal, кладет участника в контекст
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext, достает участника из контекста, без него возвращает анонимного
+func FromContext(ctx context.Context) Principal {
+	p, _ := ctx.Value(ctxKey{}).(Principal)
+	return p
+}
+
+// tokenPrefix, префикс токенов сервиса, помогает сканерам утечек узнавать их
+const tokenPrefix = "wk_"
+
+// NewToken, генерирует токен доступа, возвращает сам токен для выдачи один раз, его хэш для хранения и короткий префикс для отображения
+func NewToken() (token, hash, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	token = tokenPrefix + hex.EncodeToString(b)
+	return token, HashToken(token), token[:len(tokenPrefix)+8], nil
+}
+
+// HashToken, sha256 от токена в hex, токены случайные и длинные, поэтому соль не нужна
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// confirmationPrefix, префикс токенов ссылок подтверждения
+const confirmationPrefix = "wkc_"
+
+// NewConfirmationToken, одноразовый токен ссылки подтверждения и его хэш для хранения
+func NewConfirmationToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = confirmationPrefix + hex.EncodeToString(b)
+	return token, HashToken(token), nil
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+)
+
+// Identity, подтвержденные провайдером данные о пользователе из токена
+type Identity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDC, проверка токенов внешнего провайдера (Keycloak, Auth0), ключи подписи берутся из jwks провайдера и кэшируются, при незнакомом kid набор ключей перечитывается
+type OIDC struct {
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewOIDC, читает discovery документ провайдера по issuer, audience это client id, на который выписаны токены
+func NewOIDC(ctx context.Context, issuer, audience string) (*OIDC, error) {
+	p, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	return &OIDC{verifier: p.Verifier(&oidc.Config{ClientID: audience})}, nil
+}
+
+// Verify, проверяет подпись, издателя, аудиторию и срок действия токена, возвращает личность пользователя
+func (o *OIDC) Verify(ctx context.Context, raw string) (Identity, error) {
+	tok, err := o.verifier.Verify(ctx, raw)
+	if err != nil {
+		return Identity{}, err
+	}
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := tok.Claims(&claims); err != nil {
+		return Identity{}, err
+	}
+	return Identity{
+		Issuer:        tok.Issuer,
+		Subject:       tok.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// IsAPIKey, токен выпущен самим сервисом, а не внешним провайдером
+func IsAPIKey(token string) bool {
+	return len(token) > len(tokenPrefix) && token[:len(tokenPrefix)] == tokenPrefix
+}
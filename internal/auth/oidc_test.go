@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/coreos/go-oidc/v3/oidc/oidctest"
+)
+
+// TestOIDC_Verify, проверяет разбор токена тестового провайдера и отказ для чужой аудитории
+func TestOIDC_Verify(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	s := &oidctest.Server{PublicKeys: []oidctest.PublicKey{{PublicKey: priv.Public(), KeyID: "k1", Algorithm: oidc.RS256}}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	s.SetIssuer(srv.URL)
+
+	sign := func(aud string) string {
+		return oidctest.SignIDToken(priv, "k1", oidc.RS256, `{
+			"iss": "`+srv.URL+`",
+			"aud": "`+aud+`",
+			"sub": "user-1",
+			"exp": `+strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)+`,
+			"email": "alice@example.com",
+			"email_verified": true,
+			"name": "Alice"
+		}`)
+	}
+
+	ctx := context.Background()
+	o, err := NewOIDC(ctx, srv.URL, "wallet")
+	if err != nil {
+		t.Fatalf("new oidc: %v", err)
+	}
+
+	id, err := o.Verify(ctx, sign("wallet"))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	want := Identity{Issuer: srv.URL, Subject: "user-1", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+	if id != want {
+		t.Fatalf("want %+v, got %+v", want, id)
+	}
+
+	if _, err := o.Verify(ctx, sign("other")); err == nil {
+		t.Fatalf("want error for foreign audience")
+	}
+}
+
+// TestIsAPIKey, токены сервиса отличаются от токенов провайдера по префиксу
+func TestIsAPIKey(t *testing.T) {
+	tok, _, _, err := NewToken()
+	if err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	if !IsAPIKey(tok) {
+		t.Fatalf("want api key for %q", tok)
+	}
+	if IsAPIKey("eyJhbGciOiJSUzI1NiJ9.e30.sig") || IsAPIKey("wk_") {
+		t.Fatalf("want jwt and bare prefix not to be api keys")
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+)
+
+// области доступа ключей, admin:* покрывает все административные ручки
+const (
+	ScopeBalanceRead      = "balance:read"
+	ScopeTransferWrite    = "transfer:write"
+	ScopeTransactionsRead = "transactions:read"
+	ScopeAdmin            = "admin:*"
+)
+
+// DefaultScopes, области ключа пользователя по умолчанию, все кроме административных
+var DefaultScopes = []string{ScopeBalanceRead, ScopeTransferWrite, ScopeTransactionsRead}
+
+// knownScopes, допустимые значения при выдаче ключа
+var knownScopes = map[string]bool{
+	ScopeBalanceRead:      true,
+	ScopeTransferWrite:    true,
+	ScopeTransactionsRead: true,
+	ScopeAdmin:            true,
+}
+
+// HasScope, покрывает ли набор областей требуемую, область вида "ресурс:*" покрывает любое действие над ресурсом
+func HasScope(scopes []string, want string) bool {
+	for _, s := range scopes {
+		if s == want {
+			return true
+		}
+		if res, ok := strings.CutSuffix(s, ":*"); ok && strings.HasPrefix(want, res+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScopes, проверяет и нормализует запрошенные области, пустой список дает DefaultScopes
+func ParseScopes(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return append([]string(nil), DefaultScopes...), nil
+	}
+	seen := make(map[string]bool, len(in))
+	out := make([]string, 0, len(in))
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		if !knownScopes[s] {
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
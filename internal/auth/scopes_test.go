@@ -0,0 +1,52 @@
+package auth
+
+import "testing"
+
+// TestHasScope, проверяет точное совпадение и шаблон ресурс:*
+func TestHasScope(t *testing.T) {
+	cases := []struct {
+		scopes []string
+		want   string
+		ok     bool
+	}{
+		{[]string{ScopeBalanceRead}, ScopeBalanceRead, true},
+		{[]string{ScopeBalanceRead}, ScopeTransferWrite, false},
+		{[]string{ScopeAdmin}, "admin:denylist", true},
+		{[]string{ScopeAdmin}, ScopeTransferWrite, false},
+		{nil, ScopeBalanceRead, false},
+	}
+	for _, c := range cases {
+		if got := HasScope(c.scopes, c.want); got != c.ok {
+			t.Errorf("HasScope(%v, %q) = %v, want %v", c.scopes, c.want, got, c.ok)
+		}
+	}
+}
+
+// TestPrincipal_Allows, участник без ключа не ограничен областями, ключ только своими
+func TestPrincipal_Allows(t *testing.T) {
+	if !(Principal{Admin: true}).Allows(ScopeTransferWrite) {
+		t.Fatalf("want principal without key to be unrestricted")
+	}
+	ro := Principal{UserID: 1, KeyID: 1, Scopes: []string{ScopeBalanceRead, ScopeTransactionsRead}}
+	if ro.Allows(ScopeTransferWrite) {
+		t.Fatalf("want read-only key to be denied transfers")
+	}
+	if !ro.Allows(ScopeBalanceRead) {
+		t.Fatalf("want read-only key to read balances")
+	}
+}
+
+// TestParseScopes, проверяет дефолт, дедупликацию и отказ на неизвестной области
+func TestParseScopes(t *testing.T) {
+	got, err := ParseScopes(nil)
+	if err != nil || len(got) != len(DefaultScopes) {
+		t.Fatalf("want default scopes, got %v, %v", got, err)
+	}
+	got, err = ParseScopes([]string{ScopeBalanceRead, " balance:read "})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("want one scope, got %v, %v", got, err)
+	}
+	if _, err := ParseScopes([]string{"wallet:delete"}); err == nil {
+		t.Fatalf("want error for unknown scope")
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// заголовки подписи запроса, время подписи в unix секундах, одноразовое значение запроса и hmac-sha256 в hex
+const (
+	HeaderSignature          = "X-Signature"
+	HeaderSignatureTimestamp = "X-Signature-Timestamp"
+	HeaderSignatureNonce     = "X-Signature-Nonce"
+)
+
+// ошибки проверки подписи
+var (
+	ErrSignatureMissing = errors.New("signature missing")
+	ErrSignatureExpired = errors.New("signature timestamp outside replay window")
+	ErrSignatureInvalid = errors.New("signature invalid")
+	ErrNonceInvalid     = errors.New("signature nonce must be 16-64 characters of [A-Za-z0-9_-]")
+)
+
+// signingSecretPrefix, префикс секретов подписи, отличает их от ключей доступа
+const signingSecretPrefix = "wks_"
+
+// NewSigningSecret, генерирует секрет подписи запросов
+func NewSigningSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return signingSecretPrefix + hex.EncodeToString(b), nil
+}
+
+// Sign, подпись тела запроса, hmac-sha256 от строки "<timestamp>.<nonce>.<body>" на секрете вызывающего
+func Sign(secret string, ts int64, nonce string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(strconv.FormatInt(ts, 10)))
+	m.Write([]byte{'.'})
+	m.Write([]byte(nonce))
+	m.Write([]byte{'.'})
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// VerifySignature, проверяет подпись и что время подписи отличается от now не больше чем на window в любую сторону, повторное использование nonce проверяет вызывающий
+func VerifySignature(secret, tsHeader, nonce, sig string, body []byte, now time.Time, window time.Duration) error {
+	if tsHeader == "" || nonce == "" || sig == "" {
+		return ErrSignatureMissing
+	}
+	if !validNonce(nonce) {
+		return ErrNonceInvalid
+	}
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > window || d < -window {
+		return ErrSignatureExpired
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	want, _ := hex.DecodeString(Sign(secret, ts, nonce, body))
+	if !hmac.Equal(got, want) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// validNonce, nonce достаточной длины из безопасных символов, хранится в базе как есть
+func validNonce(n string) bool {
+	if len(n) < 16 || len(n) > 64 {
+		return false
+	}
+	for _, c := range n {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestVerifySignature, проверяет корректную подпись, подмену тела и nonce, чужой секрет и окно времени
+func TestVerifySignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"from":"a","to":"b","amount":1}`)
+	ts := now.Unix()
+	nonce := "0123456789abcdef"
+	sig := Sign("secret", ts, nonce, body)
+	tsh := strconv.FormatInt(ts, 10)
+
+	if err := VerifySignature("secret", tsh, nonce, sig, body, now, 5*time.Minute); err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if err := VerifySignature("secret", tsh, nonce, sig, []byte(`{"from":"a","to":"b","amount":100}`), now, 5*time.Minute); err != ErrSignatureInvalid {
+		t.Fatalf("tampered body: want ErrSignatureInvalid, got %v", err)
+	}
+	if err := VerifySignature("other", tsh, nonce, sig, body, now, 5*time.Minute); err != ErrSignatureInvalid {
+		t.Fatalf("foreign secret: want ErrSignatureInvalid, got %v", err)
+	}
+	if err := VerifySignature("secret", tsh, nonce, sig, body, now.Add(6*time.Minute), 5*time.Minute); err != ErrSignatureExpired {
+		t.Fatalf("old timestamp: want ErrSignatureExpired, got %v", err)
+	}
+	if err := VerifySignature("secret", tsh, nonce, sig, body, now.Add(-6*time.Minute), 5*time.Minute); err != ErrSignatureExpired {
+		t.Fatalf("future timestamp: want ErrSignatureExpired, got %v", err)
+	}
+	if err := VerifySignature("secret", "", nonce, sig, body, now, 5*time.Minute); err != ErrSignatureMissing {
+		t.Fatalf("no timestamp: want ErrSignatureMissing, got %v", err)
+	}
+	if err := VerifySignature("secret", tsh, nonce, "zz", body, now, 5*time.Minute); err != ErrSignatureInvalid {
+		t.Fatalf("bad hex: want ErrSignatureInvalid, got %v", err)
+	}
+	// nonce входит в подпись, подмена nonce ломает подпись
+	if err := VerifySignature("secret", tsh, "fedcba9876543210", sig, body, now, 5*time.Minute); err != ErrSignatureInvalid {
+		t.Fatalf("swapped nonce: want ErrSignatureInvalid, got %v", err)
+	}
+	if err := VerifySignature("secret", tsh, "short", sig, body, now, 5*time.Minute); err != ErrNonceInvalid {
+		t.Fatalf("short nonce: want ErrNonceInvalid, got %v", err)
+	}
+}
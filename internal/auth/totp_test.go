@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// TestTOTPCode_RFC6238, проверяет коды по тестовым векторам rfc 6238 для sha1
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	cases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, c := range cases {
+		got, err := TOTPCode(secret, time.Unix(c.unix, 0))
+		if err != nil {
+			t.Fatalf("code: %v", err)
+		}
+		if got != c.code {
+			t.Errorf("at %d want %s, got %s", c.unix, c.code, got)
+		}
+	}
+}
+
+// TestVerifyTOTP, проверяет допуск в один интервал, интервал совпавшего кода и отказ для старого кода
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("secret: %v", err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	code, _ := TOTPCode(secret, now)
+	want := now.Unix() / int64(totpPeriod/time.Second)
+
+	if counter, ok := VerifyTOTP(secret, code, now.Add(totpPeriod)); !ok || counter != want {
+		t.Fatalf("want code accepted one period later with counter %d, got %d %v", want, counter, ok)
+	}
+	if _, ok := VerifyTOTP(secret, code, now.Add(3*totpPeriod)); ok {
+		t.Fatalf("want code rejected three periods later")
+	}
+	if _, ok := VerifyTOTP(secret, "12345", now); ok {
+		t.Fatalf("want short code rejected")
+	}
+}
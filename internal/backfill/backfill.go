@@ -0,0 +1,143 @@
+// Package backfill, заполнение колонок, заявленных миграциями в таблице backfills, диапазонами id с паузами между частями,
+// каждая часть отдельная короткая транзакция, большая таблица не блокируется надолго, прерванное заполнение продолжается с сохраненного id
+package backfill
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gotechtask/internal/leader"
+	"gotechtask/internal/repo"
+)
+
+// Store, продвижение заполнений, реализуется репозиторием postgres
+type Store interface {
+	ListBackfills(ctx context.Context) ([]repo.Backfill, error)
+	StartBackfill(ctx context.Context, name string) (repo.Backfill, error)
+	BackfillChunk(ctx context.Context, name string, size int64, lockTimeout time.Duration) (repo.Backfill, error)
+}
+
+// maxBackoff, наибольшая пауза после части, не дождавшейся блокировок
+const maxBackoff = time.Minute
+
+// Runner, заполнение по частям
+type Runner struct {
+	Store Store
+	// Chunk, часть в значениях id, Pause, пауза после каждой части
+	Chunk int64
+	Pause time.Duration
+	// RowsPerSecond, предел скорости изменения строк, пауза растягивается, чтобы его не превысить, ноль не ограничивает
+	RowsPerSecond int64
+	// LockTimeout, сколько часть ждет блокировок строк, занятые строки откладывают часть с растущей паузой, а не держат очередь за собой
+	LockTimeout time.Duration
+	// Interval, период поиска новых заполнений в сервисе
+	Interval time.Duration
+	// Lock, блокировка прохода среди экземпляров сервиса, nil для одного экземпляра
+	Lock leader.Locker
+	// Progress, вызывается после каждой части, nil ничего не делает
+	Progress func(repo.Backfill)
+	// Sleep, ожидание между частями, подменяется в тестах
+	Sleep func(ctx context.Context, d time.Duration) error
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор с частью chunk и паузой pause, блокировки ждутся до 2 секунд
+func New(s Store, chunk int64, pause time.Duration) *Runner {
+	return &Runner{Store: s, Chunk: chunk, Pause: pause, LockTimeout: 2 * time.Second, Sleep: sleep, Now: time.Now}
+}
+
+// sleep, ожидание d с выходом по отмене контекста
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Run, заполняет незавершенные заявления сразу и затем с заданным интервалом до отмены контекста, из нескольких экземпляров заполняет один
+func (r *Runner) Run(ctx context.Context) {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+
+	for {
+		if _, err := leader.Do(ctx, r.Lock, "backfill", r.RunOnce); err != nil && ctx.Err() == nil {
+			log.Printf("backfill: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, доводит до конца все незавершенные заполнения в порядке заявления
+func (r *Runner) RunOnce(ctx context.Context) error {
+	all, err := r.Store.ListBackfills(ctx)
+	if err != nil {
+		return err
+	}
+	for _, b := range all {
+		if b.Done() {
+			continue
+		}
+		if _, err := r.Fill(ctx, b.Name); err != nil {
+			return err
+		}
+		log.Printf("backfill %s done", b.Name)
+	}
+	return nil
+}
+
+// Fill, доводит заполнение name до конца, часть за частью с паузами, отмена контекста останавливает между частями без потери продвижения
+func (r *Runner) Fill(ctx context.Context, name string) (repo.Backfill, error) {
+	b, err := r.Store.StartBackfill(ctx, name)
+	if err != nil {
+		return b, err
+	}
+	backoff := r.Pause
+	for !b.Done() {
+		start := r.Now()
+		next, err := r.Store.BackfillChunk(ctx, name, r.Chunk, r.LockTimeout)
+		if errors.Is(err, repo.ErrBackfillLocked) {
+			// строки заняты переводами, уступаем им и пробуем ту же часть позже
+			backoff = min(max(2*backoff, r.LockTimeout), maxBackoff)
+			if err := r.Sleep(ctx, backoff); err != nil {
+				return b, err
+			}
+			continue
+		}
+		if err != nil {
+			return b, err
+		}
+		backoff = r.Pause
+		if r.Progress != nil {
+			r.Progress(next)
+		}
+		if next.Done() {
+			return next, nil
+		}
+		if err := r.Sleep(ctx, r.pause(next.Rows-b.Rows, r.Now().Sub(start))); err != nil {
+			return next, err
+		}
+		b = next
+	}
+	return b, nil
+}
+
+// pause, пауза после части из n строк, выполненной за took, не меньше Pause и достаточная, чтобы уложиться в RowsPerSecond
+func (r *Runner) pause(n int64, took time.Duration) time.Duration {
+	d := r.Pause
+	if r.RowsPerSecond > 0 {
+		d = max(d, time.Duration(n)*time.Second/time.Duration(r.RowsPerSecond)-took)
+	}
+	return d
+}
@@ -0,0 +1,132 @@
+package backfill
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, заполнение в памяти, каждое значение id до maxID одна строка
+type fakeStore struct {
+	b      repo.Backfill
+	maxID  int64
+	locked int
+	chunks [][2]int64
+}
+
+func (f *fakeStore) ListBackfills(context.Context) ([]repo.Backfill, error) {
+	return []repo.Backfill{f.b}, nil
+}
+
+func (f *fakeStore) StartBackfill(_ context.Context, name string) (repo.Backfill, error) {
+	if f.b.Name != name {
+		return repo.Backfill{}, repo.ErrBackfillNotFound
+	}
+	if f.b.StartedAt.IsZero() {
+		f.b.StartedAt = time.Unix(1, 0)
+		f.b.MaxID = f.maxID
+	}
+	return f.b, nil
+}
+
+func (f *fakeStore) BackfillChunk(_ context.Context, _ string, size int64, _ time.Duration) (repo.Backfill, error) {
+	if f.locked > 0 {
+		f.locked--
+		return f.b, repo.ErrBackfillLocked
+	}
+	hi := min(f.b.LastID+size, f.b.MaxID)
+	f.chunks = append(f.chunks, [2]int64{f.b.LastID, hi})
+	f.b.Rows += hi - f.b.LastID
+	f.b.LastID = hi
+	if hi >= f.b.MaxID {
+		f.b.DoneAt = time.Unix(2, 0)
+	}
+	return f.b, nil
+}
+
+// newRunner, заполнение без настоящих пауз, паузы копятся в slept
+func newRunner(st Store, chunk int64, slept *[]time.Duration) *Runner {
+	r := New(st, chunk, 10*time.Millisecond)
+	r.Sleep = func(_ context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		return nil
+	}
+	now := time.Unix(0, 0)
+	r.Now = func() time.Time { return now }
+	return r
+}
+
+// TestFill_ChunksAndResumes, заполнение идет частями по id до max_id, повторный запуск продолжает с сохраненного id
+func TestFill_ChunksAndResumes(t *testing.T) {
+	st := &fakeStore{b: repo.Backfill{Name: "tx_kind", LastID: 40}, maxID: 100}
+	var slept []time.Duration
+	b, err := newRunner(st, 25, &slept).Fill(context.Background(), "tx_kind")
+	if err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	want := [][2]int64{{40, 65}, {65, 90}, {90, 100}}
+	if len(st.chunks) != len(want) {
+		t.Fatalf("chunks %v, want %v", st.chunks, want)
+	}
+	for i := range want {
+		if st.chunks[i] != want[i] {
+			t.Fatalf("chunks %v, want %v", st.chunks, want)
+		}
+	}
+	if !b.Done() || b.Rows != 60 {
+		t.Fatalf("result %+v", b)
+	}
+	// пауза между частями, после последней не нужна
+	if len(slept) != 2 {
+		t.Fatalf("pauses %v, want 2", slept)
+	}
+}
+
+// TestFill_BacksOffOnLocks, часть, не дождавшаяся блокировок, повторяется с растущей паузой
+func TestFill_BacksOffOnLocks(t *testing.T) {
+	st := &fakeStore{b: repo.Backfill{Name: "tx_kind"}, maxID: 10, locked: 3}
+	var slept []time.Duration
+	r := newRunner(st, 100, &slept)
+	r.LockTimeout = time.Second
+	if _, err := r.Fill(context.Background(), "tx_kind"); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if len(slept) != len(want) {
+		t.Fatalf("pauses %v, want %v", slept, want)
+	}
+	for i := range want {
+		if slept[i] != want[i] {
+			t.Fatalf("pauses %v, want %v", slept, want)
+		}
+	}
+	if len(st.chunks) != 1 {
+		t.Fatalf("chunks %v, want one", st.chunks)
+	}
+}
+
+// TestPause_RowsPerSecond, предел скорости растягивает паузу на время, которого не хватило части
+func TestPause_RowsPerSecond(t *testing.T) {
+	r := New(nil, 1000, 10*time.Millisecond)
+	if got := r.pause(1000, 100*time.Millisecond); got != 10*time.Millisecond {
+		t.Fatalf("no limit: pause %v", got)
+	}
+	r.RowsPerSecond = 500
+	if got := r.pause(1000, 500*time.Millisecond); got != 1500*time.Millisecond {
+		t.Fatalf("limited: pause %v, want 1.5s", got)
+	}
+	if got := r.pause(10, 500*time.Millisecond); got != 10*time.Millisecond {
+		t.Fatalf("under limit: pause %v, want Pause", got)
+	}
+}
+
+// TestFill_Unknown, незаявленное заполнение дает ErrBackfillNotFound
+func TestFill_Unknown(t *testing.T) {
+	st := &fakeStore{b: repo.Backfill{Name: "tx_kind"}}
+	var slept []time.Duration
+	if _, err := newRunner(st, 10, &slept).Fill(context.Background(), "nope"); err != repo.ErrBackfillNotFound {
+		t.Fatalf("err %v, want ErrBackfillNotFound", err)
+	}
+}
@@ -0,0 +1,221 @@
+// Package backup, логическая резервная копия кошельков и транзакций во внешнем хранилище и восстановление из нее в пустую базу,
+// файлы таблиц в csv, рядом манифест с версией схемы, числом строк и sha256 каждого файла, манифест пишется последним, его наличие значит, что копия целая
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"regexp"
+	"time"
+
+	"gotechtask/internal/repo"
+	"gotechtask/internal/storage"
+)
+
+// Kind, вид фоновой задачи снятия копии
+const Kind = "backup"
+
+// ошибки копий, копии нет или она не дописана, файл не совпал с контрольной суммой манифеста
+var (
+	ErrNotFound = errors.New("backup not found")
+	ErrChecksum = errors.New("backup checksum mismatch")
+)
+
+// Store, выгрузка и загрузка таблиц, реализуется репозиторием postgres
+type Store interface {
+	ExportBackup(ctx context.Context, put func(table string, copyTo func(w io.Writer) error) error) (repo.BackupInfo, error)
+	RestoreBackup(ctx context.Context, info repo.BackupInfo, open func(table string) (io.ReadCloser, error)) error
+}
+
+// Payload, полезная нагрузка задачи, имя копии
+type Payload struct {
+	ID string `json:"id"`
+}
+
+// File, файл таблицы в копии
+type File struct {
+	Table  string `json:"table"`
+	Key    string `json:"key"`
+	Rows   int64  `json:"rows"`
+	Bytes  int64  `json:"bytes"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest, описание копии, SnapshotAt, момент согласованного среза базы
+type Manifest struct {
+	ID            string    `json:"id"`
+	SchemaVersion int64     `json:"schema_version"`
+	SnapshotAt    time.Time `json:"snapshot_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	Files         []File    `json:"files"`
+}
+
+// idFormat, имя копии, момент запуска в utc с миллисекундами
+const idFormat = "20060102T150405.000Z"
+
+var validID = regexp.MustCompile(`^\d{8}T\d{6}\.\d{3}Z$`)
+
+// NewID, имя новой копии
+func NewID(now time.Time) string { return now.UTC().Format(idFormat) }
+
+// ValidID, имя копии нашего формата, оно становится частью ключа объекта
+func ValidID(id string) bool { return validID.MatchString(id) }
+
+// key, ключ объекта копии
+func key(id, name string) string { return path.Join("backups", id, name) }
+
+// Create, снимает копию id в хранилище, таблицы идут потоком из базы в хранилище без буферизации целиком
+func Create(ctx context.Context, s Store, blob storage.Store, id string) (Manifest, error) {
+	if !ValidID(id) {
+		return Manifest{}, fmt.Errorf("invalid backup id %q", id)
+	}
+	m := Manifest{ID: id}
+	info, err := s.ExportBackup(ctx, func(table string, copyTo func(w io.Writer) error) error {
+		f, err := put(ctx, blob, key(id, table+".csv"), copyTo)
+		if err != nil {
+			return err
+		}
+		f.Table = table
+		m.Files = append(m.Files, f)
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+	m.SchemaVersion = info.SchemaVersion
+	m.SnapshotAt = info.At.UTC()
+	m.FinishedAt = time.Now().UTC()
+	for i := range m.Files {
+		m.Files[i].Rows = info.Rows[m.Files[i].Table]
+	}
+
+	body, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := blob.Put(ctx, key(id, "manifest.json"), bytes.NewReader(body), "application/json"); err != nil {
+		return Manifest{}, fmt.Errorf("backup manifest: %w", err)
+	}
+	return m, nil
+}
+
+// put, загружает объект, который пишет copyTo, через pipe, по пути считает размер и sha256
+func put(ctx context.Context, blob storage.Store, k string, copyTo func(w io.Writer) error) (File, error) {
+	f := File{Key: k}
+	h := sha256.New()
+	n := new(counter)
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := copyTo(io.MultiWriter(pw, h, n))
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	err := blob.Put(ctx, k, pr, "text/csv")
+	// если загрузка оборвалась раньше, останавливаем писателя
+	pr.CloseWithError(err)
+	if cerr := <-done; err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return f, fmt.Errorf("upload %s: %w", k, err)
+	}
+	f.Bytes = int64(*n)
+	f.SHA256 = hex.EncodeToString(h.Sum(nil))
+	return f, nil
+}
+
+// counter, считает записанные байты
+type counter int64
+
+func (c *counter) Write(p []byte) (int, error) {
+	*c += counter(len(p))
+	return len(p), nil
+}
+
+// ReadManifest, манифест копии, недописанная копия манифеста не имеет и дает ErrNotFound
+func ReadManifest(ctx context.Context, blob storage.Store, id string) (Manifest, error) {
+	var m Manifest
+	if !ValidID(id) {
+		return m, ErrNotFound
+	}
+	rc, err := blob.Get(ctx, key(id, "manifest.json"))
+	if errors.Is(err, storage.ErrNotFound) {
+		return m, ErrNotFound
+	}
+	if err != nil {
+		return m, err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(&m); err != nil {
+		return m, fmt.Errorf("backup manifest: %w", err)
+	}
+	return m, nil
+}
+
+// Restore, восстанавливает копию id в пустую базу, каждый файл сверяется с контрольной суммой по мере чтения, расхождение откатывает восстановление
+func Restore(ctx context.Context, s Store, blob storage.Store, id string) (Manifest, error) {
+	m, err := ReadManifest(ctx, blob, id)
+	if err != nil {
+		return m, err
+	}
+	files := make(map[string]File, len(m.Files))
+	info := repo.BackupInfo{SchemaVersion: m.SchemaVersion, At: m.SnapshotAt, Rows: make(map[string]int64, len(m.Files))}
+	for _, f := range m.Files {
+		files[f.Table] = f
+		info.Rows[f.Table] = f.Rows
+	}
+	err = s.RestoreBackup(ctx, info, func(table string) (io.ReadCloser, error) {
+		f, ok := files[table]
+		if !ok {
+			return nil, fmt.Errorf("backup %s has no table %s", id, table)
+		}
+		rc, err := blob.Get(ctx, f.Key)
+		if err != nil {
+			return nil, err
+		}
+		return &verifyReader{rc: rc, h: sha256.New(), f: f}, nil
+	})
+	return m, err
+}
+
+// verifyReader, считает sha256 прочитанного и на конце файла отдает ErrChecksum вместо io.EOF, если сумма или размер не совпали
+type verifyReader struct {
+	rc io.ReadCloser
+	h  hash.Hash
+	n  int64
+	f  File
+}
+
+func (v *verifyReader) Read(p []byte) (int, error) {
+	n, err := v.rc.Read(p)
+	v.h.Write(p[:n])
+	v.n += int64(n)
+	if err == io.EOF && (v.n != v.f.Bytes || hex.EncodeToString(v.h.Sum(nil)) != v.f.SHA256) {
+		return n, fmt.Errorf("%s: %w", v.f.Key, ErrChecksum)
+	}
+	return n, err
+}
+
+func (v *verifyReader) Close() error { return v.rc.Close() }
+
+// Handler, обработчик задачи снятия копии, повтор после сбоя переписывает файлы той же копии
+func Handler(s Store, blob storage.Store) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p Payload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		_, err := Create(ctx, s, blob, p.ID)
+		return err
+	}
+}
@@ -0,0 +1,116 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+	"gotechtask/internal/storage"
+)
+
+// memBlob, хранилище объектов в памяти
+type memBlob map[string][]byte
+
+func (b memBlob) Put(_ context.Context, key string, r io.Reader, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b[key] = data
+	return nil
+}
+
+func (b memBlob) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	data, ok := b[key]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// fakeStore, выгружает по две строки на таблицу и запоминает, что пришло при восстановлении
+type fakeStore struct {
+	restored map[string]string
+}
+
+func (f *fakeStore) ExportBackup(_ context.Context, put func(table string, copyTo func(w io.Writer) error) error) (repo.BackupInfo, error) {
+	info := repo.BackupInfo{SchemaVersion: 34, At: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC), Rows: map[string]int64{}}
+	for _, t := range repo.BackupTables {
+		if err := put(t, func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "id\n1-%s\n2-%s\n", t, t)
+			return err
+		}); err != nil {
+			return info, err
+		}
+		info.Rows[t] = 2
+	}
+	return info, nil
+}
+
+func (f *fakeStore) RestoreBackup(_ context.Context, info repo.BackupInfo, open func(table string) (io.ReadCloser, error)) error {
+	f.restored = map[string]string{}
+	for _, t := range repo.BackupTables {
+		rc, err := open(t)
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		if info.Rows[t] != 2 {
+			return fmt.Errorf("%s: rows %d", t, info.Rows[t])
+		}
+		f.restored[t] = string(data)
+	}
+	return nil
+}
+
+// TestCreateRestore, копия пишет файлы и манифест с контрольными суммами, восстановление отдает файлы как есть, испорченный файл дает ErrChecksum
+func TestCreateRestore(t *testing.T) {
+	ctx := context.Background()
+	blob := memBlob{}
+	st := &fakeStore{}
+	id := NewID(time.Date(2026, 10, 15, 12, 0, 1, 0, time.UTC))
+
+	if _, err := ReadManifest(ctx, blob, id); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("missing manifest: want ErrNotFound, got %v", err)
+	}
+	m, err := Create(ctx, st, blob, id)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if len(m.Files) != len(repo.BackupTables) || m.SchemaVersion != 34 {
+		t.Fatalf("manifest: %+v", m)
+	}
+	for _, f := range m.Files {
+		if f.Rows != 2 || f.Bytes != int64(len(blob[f.Key])) || len(f.SHA256) != 64 {
+			t.Fatalf("file: %+v", f)
+		}
+	}
+	if got, err := ReadManifest(ctx, blob, id); err != nil || got.ID != id || len(got.Files) != len(m.Files) {
+		t.Fatalf("read manifest: %+v %v", got, err)
+	}
+
+	if _, err := Restore(ctx, st, blob, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if st.restored["wallets"] != "id\n1-wallets\n2-wallets\n" {
+		t.Fatalf("restored wallets: %q", st.restored["wallets"])
+	}
+
+	blob[m.Files[0].Key][5] ^= 1
+	if _, err := Restore(ctx, st, blob, id); !errors.Is(err, ErrChecksum) {
+		t.Fatalf("corrupted file: want ErrChecksum, got %v", err)
+	}
+
+	if _, err := Create(ctx, st, blob, "../x"); err == nil {
+		t.Fatal("invalid id accepted")
+	}
+}
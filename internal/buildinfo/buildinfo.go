@@ -0,0 +1,63 @@
+// Package buildinfo, сведения о сборке, версия, коммит, дата и флаги возможностей задаются при сборке через -ldflags,
+// например -ldflags "-X gotechtask/internal/buildinfo.Version=1.4.0 -X gotechtask/internal/buildinfo.Features=ledger,backfill"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// значения подставляются линковщиком, без ldflags версия dev, коммит и дата берутся из сведений о vcs, которые go build кладет в бинарник сам
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+	// Features, включенные при сборке флаги возможностей через запятую
+	Features = ""
+)
+
+// Info, сведения о сборке, Modified, бинарник собран из дерева с незакоммиченными правками, известно только из сведений о vcs
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string
+	Modified  bool
+	GoVersion string
+	Features  []string
+}
+
+// Get, сведения о сборке текущего бинарника, значения из ldflags важнее сведений о vcs
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, Date: Date, GoVersion: runtime.Version(), Features: ParseFeatures(Features)}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = s.Value
+				}
+			case "vcs.modified":
+				info.Modified = Commit == "" && s.Value == "true"
+			}
+		}
+	}
+	return info
+}
+
+// ParseFeatures, флаги из строки через запятую без пробелов и повторов по алфавиту, пустая строка дает пустой список
+func ParseFeatures(s string) []string {
+	out := []string{}
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" && !slices.Contains(out, f) {
+			out = append(out, f)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,28 @@
+package buildinfo
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFeatures(t *testing.T) {
+	for in, want := range map[string][]string{
+		"":                     {},
+		" , ":                  {},
+		"ledger":               {"ledger"},
+		"shadow,ledger,ledger": {"ledger", "shadow"},
+		" b , a ":              {"a", "b"},
+	} {
+		if got := ParseFeatures(in); !slices.Equal(got, want) {
+			t.Fatalf("ParseFeatures(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+// TestGetDefaults, без ldflags версия dev, флагов нет, список пустой, а не nil, чтобы в json был []
+func TestGetDefaults(t *testing.T) {
+	info := Get()
+	if info.Version != "dev" || info.GoVersion == "" || info.Features == nil || len(info.Features) != 0 {
+		t.Fatalf("defaults: %+v", info)
+	}
+}
@@ -0,0 +1,226 @@
+// Package capture, запись переводов для отладки и их повтор на стенде, адреса в записи заменены псевдонимами, суммы, время и исходы сохраняются как есть
+package capture
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry, один записанный перевод, AtMS, начало запроса от начала записи, From и To, псевдонимы адресов,
+// Status и Error, код и текст ошибки ответа, Opening, балансы кошельков, которые в записи встретились впервые, на момент перед запросом
+type Entry struct {
+	AtMS        int64            `json:"at_ms"`
+	From        string           `json:"from"`
+	To          string           `json:"to"`
+	AmountCents int64            `json:"amount_cents"`
+	Status      int              `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	DurationMS  int64            `json:"duration_ms"`
+	Opening     map[string]int64 `json:"opening,omitempty"`
+}
+
+// Recorder, пишет переводы в файл jsonl, строка на перевод в порядке завершения, безопасен для параллельного использования
+type Recorder struct {
+	mu    sync.Mutex
+	f     *os.File
+	salt  []byte
+	start time.Time
+	seen  map[string]bool
+}
+
+// Open, начинает запись в новый файл send-<время>.jsonl в dir, соль псевдонимов случайна на каждую запись, так что адреса из разных записей не сопоставить
+func Open(dir string, now time.Time) (*Recorder, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(dir, "send-"+now.UTC().Format("20060102T150405Z")+".jsonl")
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{f: f, salt: salt, start: now, seen: make(map[string]bool)}, nil
+}
+
+// Path, файл записи
+func (rec *Recorder) Path() string {
+	return rec.f.Name()
+}
+
+// Close, закрывает файл записи
+func (rec *Recorder) Close() error {
+	return rec.f.Close()
+}
+
+// Pseudonym, псевдоним адреса, hmac-sha256 с солью записи, по виду тоже адрес из 64 hex символов
+func (rec *Recorder) Pseudonym(addr string) string {
+	m := hmac.New(sha256.New, rec.salt)
+	m.Write([]byte(addr))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// Call, перевод, начатый и еще не записанный
+type Call struct {
+	rec   *Recorder
+	began time.Time
+	e     Entry
+}
+
+// Start, начинает запись перевода, для кошельков, которых в записи еще не было, берет баланс через balance до выполнения запроса,
+// ok false, кошелька нет или баланс не прочитан, тогда баланс возьмется при следующей встрече
+func (rec *Recorder) Start(from, to string, amountCents int64, balance func(addr string) (int64, bool)) *Call {
+	now := time.Now()
+	c := &Call{rec: rec, began: now, e: Entry{
+		AtMS:        now.Sub(rec.start).Milliseconds(),
+		From:        rec.Pseudonym(from),
+		To:          rec.Pseudonym(to),
+		AmountCents: amountCents,
+	}}
+	for _, addr := range []string{from, to} {
+		p := rec.Pseudonym(addr)
+		rec.mu.Lock()
+		seen := rec.seen[p]
+		rec.mu.Unlock()
+		if seen {
+			continue
+		}
+		bal, ok := balance(addr)
+		if !ok {
+			continue
+		}
+		rec.mu.Lock()
+		// параллельный запрос мог прочитать баланс раньше, первым остается его
+		if !rec.seen[p] {
+			rec.seen[p] = true
+			if c.e.Opening == nil {
+				c.e.Opening = make(map[string]int64, 2)
+			}
+			c.e.Opening[p] = bal
+		}
+		rec.mu.Unlock()
+	}
+	return c
+}
+
+// Finish, записывает исход перевода, код ответа и текст ошибки
+func (c *Call) Finish(status int, errText string) error {
+	c.e.Status = status
+	c.e.Error = errText
+	c.e.DurationMS = time.Since(c.began).Milliseconds()
+	line, err := json.Marshal(c.e)
+	if err != nil {
+		return err
+	}
+	c.rec.mu.Lock()
+	defer c.rec.mu.Unlock()
+	_, err = c.rec.f.Write(append(line, '\n'))
+	return err
+}
+
+// Read, читает запись и сортирует переводы по времени начала
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		if len(e.From) != 64 || len(e.To) != 64 {
+			return nil, fmt.Errorf("line %d: invalid address", n)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].AtMS < entries[j].AtMS })
+	return entries, nil
+}
+
+// Openings, начальные балансы всех кошельков записи по псевдонимам, кошельков без баланса в записи не было, их повтор не создает
+func Openings(entries []Entry) map[string]int64 {
+	out := make(map[string]int64)
+	for _, e := range entries {
+		for addr, bal := range e.Opening {
+			if _, ok := out[addr]; !ok {
+				out[addr] = bal
+			}
+		}
+	}
+	return out
+}
+
+// Sender, выполняет один перевод повтора, отдает код ответа и текст ошибки
+type Sender func(ctx context.Context, from, to string, amountCents int64) (status int, errText string)
+
+// Mismatch, перевод, исход которого при повторе отличается от записанного
+type Mismatch struct {
+	Entry  Entry
+	Status int
+	Error  string
+}
+
+// Result, итог повтора
+type Result struct {
+	Requests   int
+	Elapsed    time.Duration
+	Mismatches []Mismatch
+}
+
+// Replay, выполняет переводы записи через send, каждый в своей горутине в момент его начала в записи, speed ускоряет время, 2 вдвое быстрее записи,
+// так сохраняется наложение параллельных запросов, результат в порядке записи
+func Replay(ctx context.Context, entries []Entry, speed float64, send Sender) Result {
+	if speed <= 0 {
+		speed = 1
+	}
+	start := time.Now()
+	got := make([]Mismatch, len(entries))
+	var wg sync.WaitGroup
+	for i, e := range entries {
+		at := time.Duration(float64(e.AtMS) * float64(time.Millisecond) / speed)
+		if d := time.Until(start.Add(at)); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+			case <-t.C:
+			}
+		}
+		if ctx.Err() != nil {
+			entries = entries[:i]
+			break
+		}
+		wg.Add(1)
+		go func(i int, e Entry) {
+			defer wg.Done()
+			status, errText := send(ctx, e.From, e.To, e.AmountCents)
+			got[i] = Mismatch{Entry: e, Status: status, Error: errText}
+		}(i, e)
+	}
+	wg.Wait()
+
+	res := Result{Requests: len(entries), Elapsed: time.Since(start)}
+	for _, m := range got[:len(entries)] {
+		if m.Status != m.Entry.Status || m.Error != m.Entry.Error {
+			res.Mismatches = append(res.Mismatches, m)
+		}
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package capture
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecordReplay, запись прячет адреса за псевдонимами, начальный баланс кошелька пишется один раз, повтор находит переводы с другим исходом
+func TestRecordReplay(t *testing.T) {
+	rec, err := Open(t.TempDir(), time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	alice, bob := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	balances := map[string]int64{alice: 500, bob: 0}
+	balance := func(addr string) (int64, bool) {
+		bal, ok := balances[addr]
+		return bal, ok
+	}
+
+	if err := rec.Start(alice, bob, 300, balance).Finish(http.StatusOK, ""); err != nil {
+		t.Fatal(err)
+	}
+	balances[alice], balances[bob] = 200, 300
+	if err := rec.Start(alice, bob, 300, balance).Finish(http.StatusConflict, "insufficient funds"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(rec.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), alice) || strings.Contains(string(data), bob) {
+		t.Fatalf("capture leaks addresses: %s", data)
+	}
+	entries, err := Read(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	pa, pb := rec.Pseudonym(alice), rec.Pseudonym(bob)
+	if entries[0].From != pa || entries[0].To != pb || entries[1].From != pa {
+		t.Fatalf("pseudonyms not stable: %+v", entries)
+	}
+	if entries[1].Opening != nil {
+		t.Fatalf("opening balances repeated: %+v", entries[1].Opening)
+	}
+	if got := Openings(entries); got[pa] != 500 || got[pb] != 0 || len(got) != 2 {
+		t.Fatalf("openings = %v", got)
+	}
+
+	// второй перевод при повторе проходит, это расхождение
+	send := func(_ context.Context, from, to string, amountCents int64) (int, string) {
+		return http.StatusOK, ""
+	}
+	res := Replay(context.Background(), entries, 100, send)
+	if res.Requests != 2 || len(res.Mismatches) != 1 {
+		t.Fatalf("replay = %+v", res)
+	}
+	if m := res.Mismatches[0]; m.Entry.Status != http.StatusConflict || m.Status != http.StatusOK {
+		t.Fatalf("mismatch = %+v", m)
+	}
+}
@@ -0,0 +1,190 @@
+// Package chaos, внедрение задержек и ошибок в http ответы для стендов, чтобы клиенты проверяли свои повторы и таймауты, включается только конфигурацией
+//
+// Правила задаются строкой, правила через точку с запятой, первое подошедшее к запросу применяется:
+//
+//	[METHOD] /path key=value ...
+//
+// path в стиле chi, {param} совпадает с одним сегментом, * в конце с остатком пути, ключи latency и jitter (длительности), error (вероятность ошибки от 0 до 1), status (код ошибки, по умолчанию 503)
+package chaos
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Header, заголовок ответа с тем, что было внедрено, чтобы клиент отличал искусственный сбой от настоящего
+const Header = "X-Chaos-Injected"
+
+// Config, настройки внедрения, Enabled, общий выключатель, Rules, правила по маршрутам, Seed, зерно генератора, ноль берет случайное
+type Config struct {
+	Enabled bool
+	Rules   []Rule
+	Seed    int64
+}
+
+// Rule, что внедрять в запросы маршрута, пустой Method подходит к любому методу
+type Rule struct {
+	Method string
+	Path   string
+	// Latency, задержка перед обработкой, Jitter, случайная добавка к ней от нуля до Jitter
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate, доля запросов, на которые сразу отвечаем ошибкой ErrorStatus вместо обработки
+	ErrorRate   float64
+	ErrorStatus int
+}
+
+// Parse, разбирает правила из строки, пустая строка дает пустой список
+func Parse(s string) ([]Rule, error) {
+	var out []Rule
+	for _, part := range strings.Split(s, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		var rl Rule
+		if !strings.HasPrefix(fields[0], "/") {
+			rl.Method, fields = strings.ToUpper(fields[0]), fields[1:]
+		}
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+			return nil, fmt.Errorf("chaos rule %q: path must start with /", strings.TrimSpace(part))
+		}
+		rl.Path, fields = fields[0], fields[1:]
+		for _, f := range fields {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				return nil, fmt.Errorf("chaos rule %q: want key=value, got %q", strings.TrimSpace(part), f)
+			}
+			var err error
+			switch k {
+			case "latency":
+				rl.Latency, err = time.ParseDuration(v)
+			case "jitter":
+				rl.Jitter, err = time.ParseDuration(v)
+			case "error":
+				rl.ErrorRate, err = strconv.ParseFloat(v, 64)
+				if err == nil && (rl.ErrorRate < 0 || rl.ErrorRate > 1) {
+					err = fmt.Errorf("must be between 0 and 1")
+				}
+			case "status":
+				rl.ErrorStatus, err = strconv.Atoi(v)
+				if err == nil && (rl.ErrorStatus < 400 || rl.ErrorStatus > 599) {
+					err = fmt.Errorf("must be 4xx or 5xx")
+				}
+			default:
+				err = fmt.Errorf("unknown key")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("chaos rule %q: %s: %w", strings.TrimSpace(part), k, err)
+			}
+		}
+		if rl.ErrorStatus == 0 {
+			rl.ErrorStatus = http.StatusServiceUnavailable
+		}
+		out = append(out, rl)
+	}
+	return out, nil
+}
+
+// match, подходит ли правило к методу и пути запроса
+func (rl Rule) match(method, path string) bool {
+	if rl.Method != "" && rl.Method != method {
+		return false
+	}
+	want := strings.Split(strings.Trim(rl.Path, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	for i, w := range want {
+		if w == "*" && i == len(want)-1 {
+			return true
+		}
+		if i >= len(got) {
+			return false
+		}
+		if !(strings.HasPrefix(w, "{") && strings.HasSuffix(w, "}")) && w != got[i] {
+			return false
+		}
+	}
+	return len(want) == len(got)
+}
+
+// Injector, middleware внедрения по правилам, генератор общий для всех запросов, поэтому под мьютексом
+type Injector struct {
+	rules []Rule
+	mu    sync.Mutex
+	rnd   *rand.Rand
+}
+
+// New, конструктор, выключенный конфиг или пустые правила дают nil, у nil Middleware пропускает запросы как есть
+func New(c Config) *Injector {
+	if !c.Enabled || len(c.Rules) == 0 {
+		return nil
+	}
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{rules: c.Rules, rnd: rand.New(rand.NewSource(seed))}
+}
+
+// roll, задержка и решение об ошибке для правила
+func (in *Injector) roll(rl Rule) (time.Duration, bool) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	d := rl.Latency
+	if rl.Jitter > 0 {
+		d += time.Duration(in.rnd.Int63n(int64(rl.Jitter)))
+	}
+	return d, rl.ErrorRate > 0 && in.rnd.Float64() < rl.ErrorRate
+}
+
+// Middleware, ждет задержку правила и с его вероятностью отвечает ошибкой, не вызывая обработчик, отмена запроса клиентом прерывает ожидание
+func (in *Injector) Middleware(next http.Handler) http.Handler {
+	if in == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rule *Rule
+		for i := range in.rules {
+			if in.rules[i].match(r.Method, r.URL.Path) {
+				rule = &in.rules[i]
+				break
+			}
+		}
+		if rule == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		delay, fail := in.roll(*rule)
+		var injected []string
+		if delay > 0 {
+			t := time.NewTimer(delay)
+			select {
+			case <-t.C:
+			case <-r.Context().Done():
+				t.Stop()
+				return
+			}
+			injected = append(injected, "latency="+delay.String())
+		}
+		if fail {
+			injected = append(injected, "error="+strconv.Itoa(rule.ErrorStatus))
+		}
+		if len(injected) > 0 {
+			w.Header().Set(Header, strings.Join(injected, ", "))
+		}
+		if !fail {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rule.ErrorStatus)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "injected failure"})
+	})
+}
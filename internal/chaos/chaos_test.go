@@ -0,0 +1,93 @@
+package chaos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestParse, разбор правил, метод необязателен, статус по умолчанию 503, битые правила отклоняются
+func TestParse(t *testing.T) {
+	rules, err := Parse("POST /api/send latency=200ms jitter=50ms error=0.1 status=500; /api/wallet/{address}/* latency=1s ;")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []Rule{
+		{Method: "POST", Path: "/api/send", Latency: 200 * time.Millisecond, Jitter: 50 * time.Millisecond, ErrorRate: 0.1, ErrorStatus: 500},
+		{Path: "/api/wallet/{address}/*", Latency: time.Second, ErrorStatus: 503},
+	}
+	if len(rules) != len(want) {
+		t.Fatalf("got %d rules, want %d", len(rules), len(want))
+	}
+	for i := range want {
+		if rules[i] != want[i] {
+			t.Errorf("rule %d = %+v, want %+v", i, rules[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"POST", "GET api/send", "/api/send latency", "/api/send error=2", "/api/send status=200", "/api/send retries=3"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q): want error", bad)
+		}
+	}
+}
+
+// TestRule_Match, параметры пути совпадают с одним сегментом, звездочка с остатком
+func TestRule_Match(t *testing.T) {
+	cases := []struct {
+		rule   Rule
+		method string
+		path   string
+		want   bool
+	}{
+		{Rule{Method: "POST", Path: "/api/send"}, "POST", "/api/send", true},
+		{Rule{Method: "POST", Path: "/api/send"}, "GET", "/api/send", false},
+		{Rule{Path: "/api/wallet/{address}/balance"}, "GET", "/api/wallet/abc/balance", true},
+		{Rule{Path: "/api/wallet/{address}/balance"}, "GET", "/api/wallet/abc/qr", false},
+		{Rule{Path: "/api/wallet/*"}, "GET", "/api/wallet/abc/qr", true},
+		{Rule{Path: "/api/transactions"}, "GET", "/api/transactions/5", false},
+		{Rule{Path: "/*"}, "DELETE", "/api/me/keys/1", true},
+	}
+	for _, c := range cases {
+		if got := c.rule.match(c.method, c.path); got != c.want {
+			t.Errorf("%+v match %s %s = %v, want %v", c.rule, c.method, c.path, got, c.want)
+		}
+	}
+}
+
+// TestMiddleware, ошибка с вероятностью 1 отвечает без вызова обработчика, задержка видна в заголовке, чужие маршруты не трогаются
+func TestMiddleware(t *testing.T) {
+	in := New(Config{Enabled: true, Seed: 1, Rules: []Rule{
+		{Method: "POST", Path: "/api/send", ErrorRate: 1, ErrorStatus: 503},
+		{Path: "/api/transactions", Latency: 10 * time.Millisecond},
+	}})
+	called := 0
+	h := in.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called++
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/send", nil))
+	if rr.Code != http.StatusServiceUnavailable || called != 0 || rr.Header().Get(Header) != "error=503" {
+		t.Fatalf("send: got %d, called=%d, header=%q", rr.Code, called, rr.Header().Get(Header))
+	}
+
+	start := time.Now()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/transactions", nil))
+	if rr.Code != http.StatusOK || called != 1 || time.Since(start) < 10*time.Millisecond || rr.Header().Get(Header) != "latency=10ms" {
+		t.Fatalf("transactions: got %d, called=%d, header=%q", rr.Code, called, rr.Header().Get(Header))
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rr.Code != http.StatusOK || called != 2 || rr.Header().Get(Header) != "" {
+		t.Fatalf("health: got %d, called=%d, header=%q", rr.Code, called, rr.Header().Get(Header))
+	}
+
+	if New(Config{Rules: []Rule{{Path: "/*", ErrorRate: 1}}}) != nil {
+		t.Fatalf("disabled config must give nil injector")
+	}
+}
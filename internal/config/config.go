@@ -0,0 +1,525 @@
+// Package config, читает настройки сервиса из переменных окружения, подставляет значения по умолчанию
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotechtask/internal/chaos"
+	"gotechtask/internal/notify"
+	"gotechtask/internal/repo"
+	"gotechtask/internal/storage"
+)
+
+// Config, настройки сервиса, строка подключения к базе, адрес http сервера, токен администратора, параметры анализатора аномалий
+type Config struct {
+	DatabaseURL string
+	// TenantID, арендатор экземпляра, задается каждому соединению с базой, политики row level security оставляют ему только его строки, пустой выключает изоляцию
+	TenantID   string
+	HTTPAddr   string
+	AdminToken string
+
+	// SupplyCheckEvery, через сколько переводов автоматически проверять денежную массу, ноль выключает
+	SupplyCheckEvery int64
+
+	// JobsInterval, период опроса очереди фоновых задач
+	JobsInterval time.Duration
+	// SnapshotInterval, как часто проверять, нужен ли снимок балансов на начало суток, ноль выключает снимки
+	SnapshotInterval time.Duration
+	// SettlementInterval, как часто проверять, закрыт ли бизнес-день для расчета, ноль выключает расчет, BusinessLocation, часовой пояс бизнес-дня
+	SettlementInterval time.Duration
+	BusinessLocation   *time.Location
+	// StandingOrdersInterval, как часто исполнять наступившие сроки постоянных поручений, ноль выключает исполнение
+	StandingOrdersInterval time.Duration
+	// Sandbox, режим песочницы для интеграторов, кран пополнения и сброс данных
+	Sandbox bool
+	// CaptureDir, каталог записи переводов для отладки, пустой выключает запись
+	CaptureDir string
+	// WalletLockStripes, полос очереди переводов по кошелькам внутри процесса, только для одного экземпляра, ноль выключает
+	WalletLockStripes int
+	// TxCacheTTL, сколько страница истории транзакций отдается из памяти без обращения к базе, ноль выключает кэш
+	TxCacheTTL time.Duration
+	// BalanceCacheTTL, сколько кошелек для чтения баланса отдается из памяти, изменения сбрасывают его уведомлениями базы, ноль выключает кэш
+	BalanceCacheTTL time.Duration
+	// BackupTimeout, предельное время задачи резервной копии, меньше закрепления задачи в очереди в 5 минут, большие базы копируются через walletctl
+	BackupTimeout time.Duration
+
+	// OIDCIssuer, адрес провайдера oidc, пустой выключает вход через провайдера, OIDCAudience, client id в токенах
+	OIDCIssuer   string
+	OIDCAudience string
+
+	// APIKeyCacheTTL, сколько держать найденный ключ в памяти, ноль выключает кэш, APIKeyRotationOverlap, окно работы старого ключа после ротации
+	APIKeyCacheTTL        time.Duration
+	APIKeyRotationOverlap time.Duration
+	// PendingTTL, срок подтверждения перевода вторым фактором, PublicURL, внешний адрес сервиса для ссылок в письмах
+	PendingTTL time.Duration
+	PublicURL  string
+
+	// RequireSignedTransfers, переводы любым ключом доступа должны быть подписаны hmac, SignatureWindow, допустимое расхождение времени подписи
+	RequireSignedTransfers bool
+	SignatureWindow        time.Duration
+	// AddressChecksumStrict, перевод принимает адреса только в форме с контрольной суммой регистра, без него и в нижнем регистре
+	AddressChecksumStrict bool
+	// AddressDerivationSecret, секрет вывода адресов служебных и заведенных по метке кошельков, пустой выключает вывод, адреса случайные
+	AddressDerivationSecret string
+
+	// Live, настройки, которые перечитываются без перезапуска
+	Live Live
+
+	SelfCheck SelfCheck
+	Anomaly   Anomaly
+	Archive   Archive
+	Backfill  Backfill
+	HotWallet HotWallet
+	Lanes     Lanes
+	Listing   Listing
+	Storage   storage.Config
+	Notify    notify.Config
+
+	// Timeouts, таймауты ручек api
+	Timeouts Timeouts
+	// Bodies, пределы тел запросов
+	Bodies Bodies
+
+	// Chaos, задержки и ошибки в ответах для стендов, по умолчанию выключено
+	Chaos chaos.Config
+
+	// Faults, режим проверки целостности переводов, работает только в сборке с тегом faultinject
+	Faults repo.FaultConfig
+}
+
+// SelfCheck, проверки при запуске, до их прохождения сервис не готов
+type SelfCheck struct {
+	// MaxClockSkew, допустимое расхождение часов процесса с часами базы
+	MaxClockSkew time.Duration
+	// Retry, через сколько повторять непрошедшие проверки
+	Retry time.Duration
+}
+
+// Archive, настройки обслуживания партиций транзакций
+type Archive struct {
+	// Interval, период запуска обслуживания
+	Interval time.Duration
+	// Retention, срок хранения транзакций в горячей таблице, ноль хранит все
+	Retention time.Duration
+}
+
+// Backfill, заполнение колонок, заявленных миграциями, по частям
+type Backfill struct {
+	// Interval, период поиска незавершенных заполнений, ноль выключает заполнение в сервисе, тогда его запускают через walletctl backfill
+	Interval time.Duration
+	// Chunk, часть в значениях id, Pause, пауза между частями, RowsPerSecond, предел скорости, ноль не ограничивает
+	Chunk         int64
+	Pause         time.Duration
+	RowsPerSecond int64
+	// LockTimeout, сколько часть ждет блокировок строк, прежде чем уступить переводам
+	LockTimeout time.Duration
+}
+
+// Timeouts, сколько ручки ждут базу, Transfer для переводов, Read для чтения, Routes, переопределения по маршруту вида "POST /api/send"
+type Timeouts struct {
+	Transfer time.Duration
+	Read     time.Duration
+	Routes   map[string]time.Duration
+}
+
+// Lanes, резерв емкости обработки запросов для администраторов
+type Lanes struct {
+	// Capacity, сколько запросов обрабатывается одновременно, ноль выключает ограничение
+	Capacity int
+	// Reserved, сколько мест из Capacity недоступно публичным запросам
+	Reserved int
+	// Wait, сколько запрос ждет свободного места до ответа 503
+	Wait time.Duration
+}
+
+// Bodies, пределы размера и времени чтения тел запросов
+type Bodies struct {
+	// Limit, предел тела маршрута без своего, Routes, пределы маршрутов поверх встроенных, ключ "METHOD /pattern"
+	Limit  int64
+	Routes map[string]int64
+	// ReadTimeout, за сколько тело должно прийти целиком, ноль выключает
+	ReadTimeout time.Duration
+}
+
+// Listing, размеры страниц истории транзакций
+type Listing struct {
+	// DefaultCount, страница без count, MaxCount, предел count для всех, кроме администраторов
+	DefaultCount int
+	MaxCount     int
+	// AdminMaxCount, предел count для администраторов, не больше repo.MaxListLimit
+	AdminMaxCount int
+}
+
+// Anomaly, настройки фонового анализатора переводов
+type Anomaly struct {
+	Enabled bool
+	// Interval, как часто запускается анализ
+	Interval time.Duration
+	// Window, окно наблюдения, свежая активность сравнивается с базовой за BaselineWindows предыдущих окон
+	Window          time.Duration
+	BaselineWindows int
+	// SpikeFactor, во сколько раз объем в окне должен превысить средний базовый, MinVolumeCents, минимальный объем для срабатывания
+	SpikeFactor    float64
+	MinVolumeCents int64
+	// NewCounterparties, сколько новых получателей в окне считается подозрительным
+	NewCounterparties int
+	// PassThroughRatio, доля полученного, отправленная дальше в том же окне
+	PassThroughRatio float64
+}
+
+// HotWallet, настройки горячих кошельков, получателей непропорционально большой доли переводов, зачисления на них идут очередью
+type HotWallet struct {
+	// Detect, включает поиск горячих кошельков, без него пометку ставит только администратор
+	Detect bool
+	// Interval, как часто идет поиск, Window, за какое окно считаются переводы
+	Interval time.Duration
+	Window   time.Duration
+	// Share, доля переводов окна, с которой получатель становится горячим, MinCredits, сколько зачислений за окно для этого нужно не меньше
+	Share      float64
+	MinCredits int
+	// ApplyInterval, как часто очереди зачислений применяются к балансам
+	ApplyInterval time.Duration
+	// Wallets, заранее известные кошельки с большим потоком зачислений, помечаются при старте, поиск с них пометку не снимает
+	Wallets []string
+}
+
+// validTenant, допустимое имя арендатора
+var validTenant = regexp.MustCompile(`^[a-z0-9_-]{1,63}$`)
+
+// Load, собирает конфигурацию из окружения, возвращает ошибку при отсутствии обязательных или битых значений
+func Load() (Config, error) {
+	c := Config{
+		DatabaseURL: os.Getenv("DATABASE_URL"),
+		TenantID:    os.Getenv("TENANT_ID"),
+		HTTPAddr:    envString("HTTP_ADDR", ":8080"),
+		AdminToken:  os.Getenv("ADMIN_TOKEN"),
+
+		OIDCIssuer:   os.Getenv("OIDC_ISSUER"),
+		OIDCAudience: os.Getenv("OIDC_AUDIENCE"),
+	}
+	if c.DatabaseURL == "" {
+		return c, fmt.Errorf("DATABASE_URL is required")
+	}
+	if c.TenantID != "" && !validTenant.MatchString(c.TenantID) {
+		return c, fmt.Errorf("TENANT_ID: want 1-63 of a-z, 0-9, _ and -, got %q", c.TenantID)
+	}
+
+	live, err := LoadLive()
+	if err != nil {
+		return c, err
+	}
+	c.Live = live
+
+	p := parser{err: &err}
+	c.SupplyCheckEvery = p.int64("SUPPLY_CHECK_EVERY", 1000)
+	c.JobsInterval = p.duration("JOBS_INTERVAL", time.Second)
+	c.SnapshotInterval = p.duration("SNAPSHOT_INTERVAL", time.Hour)
+	c.SettlementInterval = p.duration("SETTLEMENT_INTERVAL", 10*time.Minute)
+	c.StandingOrdersInterval = p.duration("STANDING_ORDERS_INTERVAL", time.Minute)
+	c.BusinessLocation = p.location("BUSINESS_TIMEZONE", time.UTC)
+	c.BackupTimeout = p.duration("BACKUP_TIMEOUT", 4*time.Minute)
+	c.Sandbox = p.bool("SANDBOX", false)
+	c.CaptureDir = os.Getenv("CAPTURE_DIR")
+	c.WalletLockStripes = p.int("WALLET_LOCK_STRIPES", 0)
+	c.TxCacheTTL = p.duration("TX_CACHE_TTL", time.Second)
+	c.BalanceCacheTTL = p.duration("BALANCE_CACHE_TTL", 0)
+	c.APIKeyCacheTTL = p.duration("API_KEY_CACHE_TTL", 30*time.Second)
+	c.APIKeyRotationOverlap = p.duration("API_KEY_ROTATION_OVERLAP", 24*time.Hour)
+	c.PendingTTL = p.duration("PENDING_TRANSFER_TTL", 10*time.Minute)
+	c.PublicURL = envString("PUBLIC_URL", "http://localhost:8080")
+	c.RequireSignedTransfers = p.bool("REQUIRE_SIGNED_TRANSFERS", false)
+	c.SignatureWindow = p.duration("SIGNATURE_WINDOW", 5*time.Minute)
+	c.AddressChecksumStrict = p.bool("ADDRESS_CHECKSUM_STRICT", false)
+	c.AddressDerivationSecret = os.Getenv("ADDRESS_DERIVATION_SECRET")
+	c.Anomaly = Anomaly{
+		Enabled:           p.bool("ANOMALY_ENABLED", true),
+		Interval:          p.duration("ANOMALY_INTERVAL", time.Minute),
+		Window:            p.duration("ANOMALY_WINDOW", time.Hour),
+		BaselineWindows:   p.int("ANOMALY_BASELINE_WINDOWS", 24),
+		SpikeFactor:       p.float("ANOMALY_SPIKE_FACTOR", 5),
+		MinVolumeCents:    p.int64("ANOMALY_MIN_VOLUME_CENTS", 100000),
+		NewCounterparties: p.int("ANOMALY_NEW_COUNTERPARTIES", 10),
+		PassThroughRatio:  p.float("ANOMALY_PASSTHROUGH_RATIO", 0.9),
+	}
+	c.HotWallet = HotWallet{
+		Detect:        p.bool("HOT_WALLET_DETECT", false),
+		Interval:      p.duration("HOT_WALLET_INTERVAL", time.Minute),
+		Window:        p.duration("HOT_WALLET_WINDOW", 5*time.Minute),
+		Share:         p.float("HOT_WALLET_SHARE", 0.2),
+		MinCredits:    p.int("HOT_WALLET_MIN_CREDITS", 100),
+		ApplyInterval: p.duration("HOT_WALLET_APPLY_INTERVAL", 200*time.Millisecond),
+		Wallets:       envList("HOT_WALLETS"),
+	}
+	c.SelfCheck = SelfCheck{
+		MaxClockSkew: p.duration("SELFCHECK_MAX_CLOCK_SKEW", 2*time.Second),
+		Retry:        p.duration("SELFCHECK_RETRY", 5*time.Second),
+	}
+	c.Archive = Archive{
+		Interval:  p.duration("ARCHIVE_INTERVAL", time.Hour),
+		Retention: p.duration("ARCHIVE_RETENTION", 0),
+	}
+	c.Backfill = Backfill{
+		Interval:      p.duration("BACKFILL_INTERVAL", time.Minute),
+		Chunk:         p.int64("BACKFILL_CHUNK", 10000),
+		Pause:         p.duration("BACKFILL_PAUSE", 100*time.Millisecond),
+		RowsPerSecond: p.int64("BACKFILL_ROWS_PER_SECOND", 0),
+		LockTimeout:   p.duration("BACKFILL_LOCK_TIMEOUT", 2*time.Second),
+	}
+	c.Lanes = Lanes{
+		Capacity: p.int("LANES_CAPACITY", 0),
+		Reserved: p.int("LANES_RESERVED", 2),
+		Wait:     p.duration("LANES_WAIT", 2*time.Second),
+	}
+	c.Listing = Listing{
+		DefaultCount:  p.int("LIST_DEFAULT_COUNT", 10),
+		MaxCount:      p.int("LIST_MAX_COUNT", 100),
+		AdminMaxCount: p.int("LIST_ADMIN_MAX_COUNT", repo.MaxListLimit),
+	}
+	c.Timeouts = Timeouts{
+		Transfer: p.duration("TIMEOUT_TRANSFER", 15*time.Second),
+		Read:     p.duration("TIMEOUT_READ", 5*time.Second),
+		Routes:   p.routeDurations("TIMEOUT_ROUTES"),
+	}
+	c.Bodies = Bodies{
+		Limit:       p.int64("BODY_LIMIT", 16<<10),
+		Routes:      p.routeSizes("BODY_LIMIT_ROUTES"),
+		ReadTimeout: p.duration("BODY_READ_TIMEOUT", 10*time.Second),
+	}
+	c.Storage = storage.Config{
+		Backend:     os.Getenv("STORAGE_BACKEND"),
+		Bucket:      os.Getenv("STORAGE_BUCKET"),
+		Prefix:      os.Getenv("STORAGE_PREFIX"),
+		PartSize:    p.int64("STORAGE_PART_SIZE_MB", storage.DefaultPartSize>>20) << 20,
+		S3Region:    os.Getenv("S3_REGION"),
+		S3Endpoint:  os.Getenv("S3_ENDPOINT"),
+		S3PathStyle: p.bool("S3_PATH_STYLE", false),
+	}
+	c.Notify = notify.Config{
+		Backend:        os.Getenv("NOTIFY_BACKEND"),
+		From:           os.Getenv("NOTIFY_FROM"),
+		SMTPAddr:       os.Getenv("SMTP_ADDR"),
+		SMTPUser:       os.Getenv("SMTP_USER"),
+		SMTPPassword:   os.Getenv("SMTP_PASSWORD"),
+		SendGridAPIKey: os.Getenv("SENDGRID_API_KEY"),
+	}
+	c.Chaos = chaos.Config{
+		Enabled: p.bool("CHAOS_ENABLED", false),
+		Seed:    p.int64("CHAOS_SEED", 0),
+	}
+	if rules, perr := chaos.Parse(os.Getenv("CHAOS_RULES")); perr != nil {
+		p.fail("CHAOS_RULES", perr)
+	} else {
+		c.Chaos.Rules = rules
+	}
+	c.Faults = repo.FaultConfig{
+		Rate:   p.float("FAULT_INJECT_RATE", 0),
+		Points: envList("FAULT_INJECT_POINTS"),
+		Seed:   p.int64("FAULT_INJECT_SEED", 0),
+	}
+	if c.Lanes.Capacity > 0 && (c.Lanes.Reserved < 0 || c.Lanes.Reserved >= c.Lanes.Capacity) {
+		return c, fmt.Errorf("LANES_RESERVED must be between 0 and LANES_CAPACITY-1")
+	}
+	if c.HotWallet.Share <= 0 || c.HotWallet.Share > 1 {
+		return c, fmt.Errorf("HOT_WALLET_SHARE must be in (0, 1]")
+	}
+	if c.HotWallet.ApplyInterval <= 0 {
+		return c, fmt.Errorf("HOT_WALLET_APPLY_INTERVAL must be > 0")
+	}
+	// на этих интервалах работают тикеры фоновых проходов, выключаются они своими флагами, а не нулевым интервалом
+	if c.JobsInterval <= 0 || c.Anomaly.Interval <= 0 || c.HotWallet.Interval <= 0 || c.Archive.Interval <= 0 {
+		return c, fmt.Errorf("JOBS_INTERVAL, ANOMALY_INTERVAL, HOT_WALLET_INTERVAL and ARCHIVE_INTERVAL must be > 0")
+	}
+	if c.Listing.DefaultCount <= 0 || c.Listing.DefaultCount > c.Listing.MaxCount {
+		return c, fmt.Errorf("LIST_DEFAULT_COUNT must be in [1, LIST_MAX_COUNT]")
+	}
+	if c.Listing.AdminMaxCount < c.Listing.MaxCount || c.Listing.AdminMaxCount > repo.MaxListLimit {
+		return c, fmt.Errorf("LIST_ADMIN_MAX_COUNT must be in [LIST_MAX_COUNT, %d]", repo.MaxListLimit)
+	}
+	if c.Bodies.Limit <= 0 {
+		return c, fmt.Errorf("BODY_LIMIT must be > 0")
+	}
+	if c.Bodies.ReadTimeout < 0 {
+		return c, fmt.Errorf("BODY_READ_TIMEOUT must be >= 0")
+	}
+	if c.SelfCheck.MaxClockSkew <= 0 || c.SelfCheck.Retry <= 0 {
+		return c, fmt.Errorf("SELFCHECK_MAX_CLOCK_SKEW and SELFCHECK_RETRY must be > 0")
+	}
+	if c.WalletLockStripes < 0 {
+		return c, fmt.Errorf("WALLET_LOCK_STRIPES must be >= 0")
+	}
+	if c.Backfill.Chunk <= 0 || c.Backfill.Pause < 0 || c.Backfill.RowsPerSecond < 0 || c.Backfill.LockTimeout <= 0 {
+		return c, fmt.Errorf("BACKFILL_CHUNK and BACKFILL_LOCK_TIMEOUT must be > 0, BACKFILL_PAUSE and BACKFILL_ROWS_PER_SECOND >= 0")
+	}
+	if c.OIDCIssuer != "" && c.OIDCAudience == "" {
+		return c, fmt.Errorf("OIDC_AUDIENCE is required with OIDC_ISSUER")
+	}
+	if c.Storage.Backend != "" && c.Storage.Bucket == "" {
+		return c, fmt.Errorf("STORAGE_BUCKET is required for STORAGE_BACKEND=%s", c.Storage.Backend)
+	}
+	return c, err
+}
+
+// envString, значение переменной или дефолт если она пуста
+func envString(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envList, значения через запятую без пустых, nil если переменная пуста
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// parser, разбирает типизированные переменные окружения, запоминает первую ошибку, значения из file важнее окружения
+type parser struct {
+	err  *error
+	file map[string]string
+}
+
+// getenv, значение из файла перечитываемых настроек, если оно там есть, иначе из окружения
+func (p parser) getenv(key string) string {
+	if v, ok := p.file[key]; ok {
+		return v
+	}
+	return os.Getenv(key)
+}
+
+// fail, сохраняет первую ошибку разбора
+func (p parser) fail(key string, err error) {
+	if *p.err == nil {
+		*p.err = fmt.Errorf("%s: %w", key, err)
+	}
+}
+
+// location, часовой пояс по имени из базы iana, например Europe/Moscow
+func (p parser) location(key string, def *time.Location) *time.Location {
+	v := p.getenv(key)
+	if v == "" {
+		return def
+	}
+	loc, err := time.LoadLocation(v)
+	if err != nil {
+		p.fail(key, err)
+		return def
+	}
+	return loc
+}
+
+func (p parser) bool(key string, def bool) bool {
+	v := p.getenv(key)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		p.fail(key, err)
+		return def
+	}
+	return b
+}
+
+func (p parser) int(key string, def int) int {
+	v := p.getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		p.fail(key, err)
+		return def
+	}
+	return n
+}
+
+func (p parser) int64(key string, def int64) int64 {
+	v := p.getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		p.fail(key, err)
+		return def
+	}
+	return n
+}
+
+func (p parser) float(key string, def float64) float64 {
+	v := p.getenv(key)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		p.fail(key, err)
+		return def
+	}
+	return f
+}
+
+func (p parser) duration(key string, def time.Duration) time.Duration {
+	v := p.getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		p.fail(key, err)
+		return def
+	}
+	return d
+}
+
+// routeDurations, длительности по маршрутам, пары "METHOD /path=15s" через точку с запятой
+func (p parser) routeDurations(key string) map[string]time.Duration {
+	return routeValues(p, key, "duration", func(s string) (time.Duration, bool) {
+		d, err := time.ParseDuration(s)
+		return d, err == nil && d > 0
+	})
+}
+
+// routeSizes, размеры в байтах по маршрутам, пары "METHOD /path=4096" через точку с запятой
+func (p parser) routeSizes(key string) map[string]int64 {
+	return routeValues(p, key, "size", func(s string) (int64, bool) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, err == nil && n > 0
+	})
+}
+
+// routeValues, значения по маршрутам, пары "METHOD /path=значение" через точку с запятой, what, название значения в ошибке
+func routeValues[T any](p parser, key, what string, parse func(string) (T, bool)) map[string]T {
+	out := map[string]T{}
+	for _, part := range strings.Split(p.getenv(key), ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		route, v, ok := strings.Cut(part, "=")
+		method, path, okRoute := strings.Cut(strings.TrimSpace(route), " ")
+		path = strings.TrimSpace(path)
+		if !ok || !okRoute || !strings.HasPrefix(path, "/") {
+			p.fail(key, fmt.Errorf("want \"METHOD /path=%s\", got %q", what, part))
+			return nil
+		}
+		val, ok := parse(strings.TrimSpace(v))
+		if !ok {
+			p.fail(key, fmt.Errorf("invalid %s in %q", what, part))
+			return nil
+		}
+		out[strings.ToUpper(method)+" "+path] = val
+	}
+	return out
+}
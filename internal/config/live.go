@@ -0,0 +1,122 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// Live, настройки, которые перечитываются без перезапуска по SIGHUP или POST /api/admin/config/reload,
+// окружение процесса после запуска не меняется, поэтому новые значения берутся из файла RELOAD_FILE, он важнее окружения
+type Live struct {
+	// SlowQuery, с какого времени запрос к базе пишется в лог как медленный, параметры запроса в лог не попадают, ноль выключает лог
+	SlowQuery time.Duration
+	// ReceiptThresholdCents, с какой суммы перевода отправлять квитанции на почту, ноль выключает
+	ReceiptThresholdCents int64
+	// TwoFactorThresholdCents, с какой суммы перевод с личного кошелька требует второго фактора, ноль выключает
+	TwoFactorThresholdCents int64
+	// SandboxFaucetMaxCents, предел одного пополнения из крана песочницы
+	SandboxFaucetMaxCents int64
+	// Maintenance, режим обслуживания, публичные изменяющие запросы получают 503, чтение и ручки администратора работают
+	Maintenance bool
+	// LedgerMode, переход на счета и проводки, off, dual, двойная запись, shadow, двойная запись и сверка при чтении баланса
+	LedgerMode repo.LedgerMode
+}
+
+// LiveKeys, переменные, которые можно менять без перезапуска, только они допустимы в RELOAD_FILE
+var LiveKeys = []string{
+	"DB_SLOW_QUERY", "RECEIPT_THRESHOLD_CENTS", "TWO_FACTOR_THRESHOLD_CENTS", "SANDBOX_FAUCET_MAX_CENTS", "MAINTENANCE", "LEDGER_MODE",
+}
+
+// ErrNotLive, переменная из RELOAD_FILE меняется только перезапуском
+var ErrNotLive = errors.New("not reloadable without restart")
+
+// LoadLive, читает перечитываемые настройки из RELOAD_FILE и окружения, при ошибке прежние настройки остаются у вызывающего
+func LoadLive() (Live, error) {
+	file, err := readReloadFile(os.Getenv("RELOAD_FILE"))
+	if err != nil {
+		return Live{}, err
+	}
+	p := parser{err: &err, file: file}
+	l := Live{
+		SlowQuery:               p.duration("DB_SLOW_QUERY", 500*time.Millisecond),
+		ReceiptThresholdCents:   p.int64("RECEIPT_THRESHOLD_CENTS", 100000),
+		TwoFactorThresholdCents: p.int64("TWO_FACTOR_THRESHOLD_CENTS", 100000),
+		SandboxFaucetMaxCents:   p.int64("SANDBOX_FAUCET_MAX_CENTS", 100000),
+		Maintenance:             p.bool("MAINTENANCE", false),
+	}
+	if m, perr := repo.ParseLedgerMode(p.getenv("LEDGER_MODE")); perr != nil {
+		p.fail("LEDGER_MODE", perr)
+	} else {
+		l.LedgerMode = m
+	}
+	if err != nil {
+		return Live{}, err
+	}
+	if l.SlowQuery < 0 {
+		return Live{}, fmt.Errorf("DB_SLOW_QUERY must be >= 0")
+	}
+	if l.ReceiptThresholdCents < 0 || l.TwoFactorThresholdCents < 0 || l.SandboxFaucetMaxCents < 0 {
+		return Live{}, fmt.Errorf("RECEIPT_THRESHOLD_CENTS, TWO_FACTOR_THRESHOLD_CENTS and SANDBOX_FAUCET_MAX_CENTS must be >= 0")
+	}
+	return l, nil
+}
+
+// Changes, изменившиеся относительно prev переменные с новыми значениями вида KEY=value в порядке LiveKeys
+func (l Live) Changes(prev Live) []string {
+	var out []string
+	add := func(key string, changed bool, v string) {
+		if changed {
+			out = append(out, key+"="+v)
+		}
+	}
+	add("DB_SLOW_QUERY", l.SlowQuery != prev.SlowQuery, l.SlowQuery.String())
+	add("RECEIPT_THRESHOLD_CENTS", l.ReceiptThresholdCents != prev.ReceiptThresholdCents, strconv.FormatInt(l.ReceiptThresholdCents, 10))
+	add("TWO_FACTOR_THRESHOLD_CENTS", l.TwoFactorThresholdCents != prev.TwoFactorThresholdCents, strconv.FormatInt(l.TwoFactorThresholdCents, 10))
+	add("SANDBOX_FAUCET_MAX_CENTS", l.SandboxFaucetMaxCents != prev.SandboxFaucetMaxCents, strconv.FormatInt(l.SandboxFaucetMaxCents, 10))
+	add("MAINTENANCE", l.Maintenance != prev.Maintenance, strconv.FormatBool(l.Maintenance))
+	add("LEDGER_MODE", l.LedgerMode != prev.LedgerMode, string(l.LedgerMode))
+	return out
+}
+
+// readReloadFile, пары KEY=value по строке, пустые строки и строки с # пропускаются, пустой путь дает пустой набор,
+// переменная не из LiveKeys это ошибка, чтобы правка настройки, которой нужен перезапуск, не прошла молча
+func readReloadFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("RELOAD_FILE: %w", err)
+	}
+	defer f.Close()
+
+	out := map[string]string{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, v, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("RELOAD_FILE line %d: want KEY=value", n)
+		}
+		if !slices.Contains(LiveKeys, key) {
+			return nil, fmt.Errorf("RELOAD_FILE line %d: %w: %s", n, ErrNotLive, key)
+		}
+		out[key] = strings.TrimSpace(v)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("RELOAD_FILE: %w", err)
+	}
+	return out, nil
+}
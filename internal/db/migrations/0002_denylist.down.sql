@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_audit_log_address;
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS denylist;
@@ -0,0 +1,18 @@
+-- 0002_denylist.up.sql
+CREATE TABLE IF NOT EXISTS denylist (
+  address TEXT PRIMARY KEY,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  action TEXT NOT NULL,
+  actor TEXT NOT NULL DEFAULT '',
+  address TEXT NOT NULL DEFAULT '',
+  details JSONB NOT NULL DEFAULT '{}'::jsonb,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_address
+  ON audit_log (address, created_at DESC);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_transactions_to_created_at;
+DROP INDEX IF EXISTS idx_transactions_from_created_at;
+DROP INDEX IF EXISTS idx_alerts_created_at;
+DROP TABLE IF EXISTS alerts;
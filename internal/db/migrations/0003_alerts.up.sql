@@ -0,0 +1,19 @@
+-- 0003_alerts.up.sql
+CREATE TABLE IF NOT EXISTS alerts (
+  id BIGSERIAL PRIMARY KEY,
+  kind TEXT NOT NULL,
+  address TEXT NOT NULL,
+  details JSONB NOT NULL DEFAULT '{}'::jsonb,
+  window_start TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (kind, address, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_created_at
+  ON alerts (created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_from_created_at
+  ON transactions (from_address, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_to_created_at
+  ON transactions (to_address, created_at);
@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS check_money_supply();
+DROP INDEX IF EXISTS idx_supply_adjustments_address;
+DROP TABLE IF EXISTS supply_adjustments;
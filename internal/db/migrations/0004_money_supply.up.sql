@@ -0,0 +1,25 @@
+-- 0004_money_supply.up.sql
+CREATE TABLE IF NOT EXISTS supply_adjustments (
+  id BIGSERIAL PRIMARY KEY,
+  delta_cents BIGINT NOT NULL,
+  reason TEXT NOT NULL,
+  address TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_supply_adjustments_address
+  ON supply_adjustments (address);
+
+-- текущие балансы существующей базы принимаются за исходную эмиссию
+INSERT INTO supply_adjustments(delta_cents, reason)
+SELECT COALESCE(SUM(balance_cents), 0), 'baseline'
+FROM wallets;
+
+-- инвариант денежной массы, сумма балансов должна совпадать с суммой эмиссий и корректировок
+CREATE OR REPLACE FUNCTION check_money_supply()
+RETURNS TABLE (balances_cents NUMERIC, expected_cents NUMERIC, ok BOOLEAN)
+LANGUAGE sql STABLE AS $$
+  SELECT b.total, s.total, b.total = s.total
+  FROM (SELECT COALESCE(SUM(balance_cents), 0)::numeric AS total FROM wallets) b,
+       (SELECT COALESCE(SUM(delta_cents), 0)::numeric AS total FROM supply_adjustments) s
+$$;
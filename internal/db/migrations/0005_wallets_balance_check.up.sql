@@ -0,0 +1,3 @@
+-- 0005_wallets_balance_check.up.sql
+ALTER TABLE wallets
+  ADD CONSTRAINT wallets_balance_nonnegative CHECK (balance_cents >= 0);
@@ -0,0 +1,4 @@
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_within_overdraft;
+ALTER TABLE wallets
+  ADD CONSTRAINT wallets_balance_nonnegative CHECK (balance_cents >= 0);
+ALTER TABLE wallets DROP COLUMN IF EXISTS overdraft_limit_cents;
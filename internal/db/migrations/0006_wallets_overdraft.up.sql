@@ -0,0 +1,9 @@
+-- 0006_wallets_overdraft.up.sql
+ALTER TABLE wallets
+  ADD COLUMN IF NOT EXISTS overdraft_limit_cents BIGINT NOT NULL DEFAULT 0
+  CHECK (overdraft_limit_cents >= 0);
+
+-- баланс может уходить в минус только в пределах разрешенного овердрафта
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_nonnegative;
+ALTER TABLE wallets
+  ADD CONSTRAINT wallets_balance_within_overdraft CHECK (balance_cents >= -overdraft_limit_cents);
@@ -0,0 +1,25 @@
+DROP TABLE IF EXISTS transactions_archive;
+DROP FUNCTION IF EXISTS ensure_transactions_partition(TIMESTAMPTZ);
+
+ALTER TABLE transactions RENAME TO transactions_partitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_partitioned_pkey;
+ALTER SEQUENCE transactions_id_seq OWNED BY NONE;
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_from_created_at;
+DROP INDEX IF EXISTS idx_transactions_to_created_at;
+
+CREATE TABLE transactions (
+  id BIGINT PRIMARY KEY DEFAULT nextval('transactions_id_seq'),
+  from_address TEXT NOT NULL,
+  to_address TEXT NOT NULL,
+  amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+ALTER SEQUENCE transactions_id_seq OWNED BY transactions.id;
+
+INSERT INTO transactions SELECT * FROM transactions_partitioned;
+DROP TABLE transactions_partitioned;
+
+CREATE INDEX idx_transactions_created_at ON transactions (created_at DESC);
+CREATE INDEX idx_transactions_from_created_at ON transactions (from_address, created_at);
+CREATE INDEX idx_transactions_to_created_at ON transactions (to_address, created_at);
@@ -0,0 +1,78 @@
+-- 0007_transactions_partitioning.up.sql
+-- таблица транзакций переводится на помесячные партиции по created_at, старые месяцы уносятся в архив фоновой задачей
+
+ALTER TABLE transactions RENAME TO transactions_unpartitioned;
+ALTER INDEX transactions_pkey RENAME TO transactions_unpartitioned_pkey;
+ALTER SEQUENCE transactions_id_seq OWNED BY NONE;
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_from_created_at;
+DROP INDEX IF EXISTS idx_transactions_to_created_at;
+
+CREATE TABLE transactions (
+  id BIGINT NOT NULL DEFAULT nextval('transactions_id_seq'),
+  from_address TEXT NOT NULL,
+  to_address TEXT NOT NULL,
+  amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+ALTER SEQUENCE transactions_id_seq OWNED BY transactions.id;
+
+-- страховочная партиция для строк, месяц которых еще не создан
+CREATE TABLE transactions_default PARTITION OF transactions DEFAULT;
+
+CREATE INDEX idx_transactions_created_at ON transactions (created_at DESC);
+CREATE INDEX idx_transactions_from_created_at ON transactions (from_address, created_at);
+CREATE INDEX idx_transactions_to_created_at ON transactions (to_address, created_at);
+
+-- ensure_transactions_partition, создает партицию месяца (utc) содержащего p_at, переносит в нее строки из default партиции, возвращает имя
+CREATE OR REPLACE FUNCTION ensure_transactions_partition(p_at TIMESTAMPTZ)
+RETURNS TEXT
+LANGUAGE plpgsql AS $$
+DECLARE
+  m TIMESTAMP := date_trunc('month', p_at AT TIME ZONE 'UTC');
+  m_start TIMESTAMPTZ := m AT TIME ZONE 'UTC';
+  m_end TIMESTAMPTZ := (m + INTERVAL '1 month') AT TIME ZONE 'UTC';
+  part TEXT := format('transactions_y%sm%s', to_char(m, 'YYYY'), to_char(m, 'MM'));
+BEGIN
+  IF to_regclass(part) IS NOT NULL THEN
+    RETURN part;
+  END IF;
+  EXECUTE format('CREATE TABLE %I (LIKE transactions INCLUDING DEFAULTS INCLUDING CONSTRAINTS)', part);
+  EXECUTE format(
+    'WITH moved AS (DELETE FROM transactions_default WHERE created_at >= %L AND created_at < %L RETURNING *) INSERT INTO %I SELECT * FROM moved',
+    m_start, m_end, part);
+  EXECUTE format('ALTER TABLE transactions ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)', part, m_start, m_end);
+  RETURN part;
+END
+$$;
+
+-- партиции на все месяцы существующих данных плюс текущий и следующий
+DO $$
+DECLARE
+  first_month TIMESTAMPTZ;
+  m TIMESTAMPTZ;
+BEGIN
+  SELECT COALESCE(MIN(created_at), now()) INTO first_month FROM transactions_unpartitioned;
+  m := first_month;
+  WHILE m < now() + INTERVAL '2 months' LOOP
+    PERFORM ensure_transactions_partition(m);
+    m := m + INTERVAL '1 month';
+  END LOOP;
+END
+$$;
+
+INSERT INTO transactions SELECT * FROM transactions_unpartitioned;
+DROP TABLE transactions_unpartitioned;
+
+-- архив, сюда переезжают строки партиций старше срока хранения
+CREATE TABLE IF NOT EXISTS transactions_archive (
+  id BIGINT NOT NULL,
+  from_address TEXT NOT NULL,
+  to_address TEXT NOT NULL,
+  amount_cents BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (id, created_at)
+);
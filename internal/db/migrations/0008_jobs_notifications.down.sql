@@ -0,0 +1,3 @@
+ALTER TABLE wallets DROP COLUMN IF EXISTS email;
+DROP INDEX IF EXISTS idx_jobs_pending;
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_wallets_user_id;
+ALTER TABLE wallets DROP COLUMN IF EXISTS user_id;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,24 @@
+-- 0009_users.up.sql
+CREATE TABLE IF NOT EXISTS users (
+  id BIGSERIAL PRIMARY KEY,
+  email TEXT UNIQUE NOT NULL,
+  name TEXT NOT NULL DEFAULT '',
+  is_admin BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- ключи доступа, хранится только sha256 от токена, prefix нужен чтобы узнать ключ в списке
+CREATE TABLE IF NOT EXISTS api_keys (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  key_hash TEXT UNIQUE NOT NULL,
+  prefix TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+-- кошелек без владельца остается общедоступным, как раньше
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS user_id BIGINT REFERENCES users(id);
+CREATE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets (user_id) WHERE user_id IS NOT NULL;
@@ -0,0 +1,8 @@
+-- привязка пользователя к внешнему провайдеру, subject уникален в пределах issuer
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc ON users (oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL;
+
+-- провайдер может не отдавать почту, уникальность сохраняется для заданных значений
+ALTER TABLE users ALTER COLUMN email DROP NOT NULL;
@@ -0,0 +1,8 @@
+-- области доступа ключа, выданные ранее ключи сохраняют полный пользовательский доступ
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS scopes TEXT[] NOT NULL
+  DEFAULT ARRAY['balance:read', 'transfer:write', 'transactions:read'];
+
+-- ключи администраторов продолжают открывать административные ручки
+UPDATE api_keys k SET scopes = k.scopes || ARRAY['admin:*']
+FROM users u
+WHERE u.id = k.user_id AND u.is_admin AND NOT 'admin:*' = ANY(k.scopes);
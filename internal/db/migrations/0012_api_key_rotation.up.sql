@@ -0,0 +1,4 @@
+-- имя ключа для списка, срок действия старого ключа после ротации, ссылка на ключ-замену
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS replaced_by BIGINT REFERENCES api_keys(id);
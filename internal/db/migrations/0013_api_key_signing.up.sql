@@ -0,0 +1,2 @@
+-- секрет подписи запросов ключа, нужен для проверки hmac поэтому хранится как есть, заданный секрет делает подпись переводов обязательной
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS signing_secret TEXT;
@@ -0,0 +1,9 @@
+-- использованные nonce подписанных запросов, хранятся пока подпись с ними может пройти проверку времени
+CREATE TABLE IF NOT EXISTS signature_nonces (
+  key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+  nonce TEXT NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (key_id, nonce)
+);
+
+CREATE INDEX IF NOT EXISTS idx_signature_nonces_expires_at ON signature_nonces (expires_at);
@@ -0,0 +1,22 @@
+-- секрет totp пользователя, нужен для проверки кодов поэтому хранится как есть, включается после первого верного кода
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false;
+
+-- крупные переводы с личных кошельков ждут подтверждения вторым фактором
+CREATE TABLE IF NOT EXISTS pending_transfers (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  from_address TEXT NOT NULL,
+  to_address TEXT NOT NULL,
+  amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+  method TEXT NOT NULL CHECK (method IN ('totp', 'email')),
+  token_hash TEXT UNIQUE,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'executed', 'failed', 'expired')),
+  failure TEXT NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  resolved_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_pending_transfers_user_id ON pending_transfers (user_id, created_at DESC);
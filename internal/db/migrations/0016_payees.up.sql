@@ -0,0 +1,9 @@
+-- адресная книга кошелька, псевдоним уникален в пределах кошелька
+CREATE TABLE IF NOT EXISTS payees (
+  id BIGSERIAL PRIMARY KEY,
+  wallet_address TEXT NOT NULL REFERENCES wallets(address) ON DELETE CASCADE,
+  alias TEXT NOT NULL,
+  payee_address TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (wallet_address, alias)
+);
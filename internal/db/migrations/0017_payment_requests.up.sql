@@ -0,0 +1,19 @@
+-- запросы платежа, payee_address просит сумму у payer_address, статус pending пока плательщик не оплатит или не отклонит запрос
+CREATE TABLE IF NOT EXISTS payment_requests (
+  id BIGSERIAL PRIMARY KEY,
+  payee_address TEXT NOT NULL REFERENCES wallets(address) ON DELETE CASCADE,
+  payer_address TEXT NOT NULL REFERENCES wallets(address) ON DELETE CASCADE,
+  amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+  memo TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'paid', 'declined', 'expired')),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  resolved_at TIMESTAMPTZ,
+  CHECK (payee_address <> payer_address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer ON payment_requests (payer_address, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payee ON payment_requests (payee_address, status, created_at DESC);
+
+-- крупная оплата запроса проходит через подтверждение вторым фактором, исполнение отмечает запрос оплаченным
+ALTER TABLE pending_transfers ADD COLUMN IF NOT EXISTS payment_request_id BIGINT REFERENCES payment_requests(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+ALTER TABLE transactions_archive DROP COLUMN IF EXISTS channel;
+ALTER TABLE transactions_archive DROP COLUMN IF EXISTS initiated_by;
+
+DROP INDEX IF EXISTS idx_transactions_initiated_by_created_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS channel;
+ALTER TABLE transactions DROP COLUMN IF EXISTS initiated_by;
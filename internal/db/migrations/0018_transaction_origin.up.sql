@@ -0,0 +1,9 @@
+-- кто и через какой канал создал перевод, у старых строк неизвестно и остается null
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS initiated_by TEXT;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS channel TEXT
+  CHECK (channel IN ('http', 'grpc', 'cli', 'scheduled', 'admin-adjustment'));
+
+CREATE INDEX IF NOT EXISTS idx_transactions_initiated_by_created_at ON transactions (initiated_by, created_at);
+
+ALTER TABLE transactions_archive ADD COLUMN IF NOT EXISTS initiated_by TEXT;
+ALTER TABLE transactions_archive ADD COLUMN IF NOT EXISTS channel TEXT;
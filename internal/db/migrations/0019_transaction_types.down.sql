@@ -0,0 +1,4 @@
+ALTER TABLE transactions_archive DROP COLUMN IF EXISTS type;
+
+DROP INDEX IF EXISTS idx_transactions_type_created_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS type;
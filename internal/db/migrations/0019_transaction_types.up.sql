@@ -0,0 +1,7 @@
+-- вид операции, все существующие строки это обычные переводы, константный default заполняет их без перезаписи таблицы
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'transfer'
+  CHECK (type IN ('transfer', 'adjustment', 'fee', 'reversal', 'exchange'));
+
+CREATE INDEX IF NOT EXISTS idx_transactions_type_created_at ON transactions (type, created_at);
+
+ALTER TABLE transactions_archive ADD COLUMN IF NOT EXISTS type TEXT NOT NULL DEFAULT 'transfer';
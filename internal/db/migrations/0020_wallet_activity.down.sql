@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_wallets_last_tx_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS last_tx_at;
+ALTER TABLE wallets DROP COLUMN IF EXISTS updated_at;
@@ -0,0 +1,20 @@
+-- время последнего изменения кошелька и последнего перевода с его участием, для отчетов по неактивным кошелькам
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS last_tx_at TIMESTAMPTZ;
+
+UPDATE wallets w SET last_tx_at = t.last_at
+FROM (
+  SELECT address, MAX(created_at) AS last_at FROM (
+    SELECT from_address AS address, created_at FROM transactions
+    UNION ALL
+    SELECT to_address, created_at FROM transactions
+  ) m
+  GROUP BY address
+) t
+WHERE w.address = t.address;
+
+UPDATE wallets SET updated_at = COALESCE(last_tx_at, created_at);
+ALTER TABLE wallets ALTER COLUMN updated_at SET DEFAULT now();
+ALTER TABLE wallets ALTER COLUMN updated_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_wallets_last_tx_at ON wallets (last_tx_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payees_alias_trgm;
+DROP INDEX IF EXISTS idx_wallets_address_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,5 @@
+-- поиск кошельков по части адреса и псевдониму для поддержки, триграммы покрывают и префикс, и подстроку
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_wallets_address_trgm ON wallets USING gin (address gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_payees_alias_trgm ON payees USING gin (lower(alias) gin_trgm_ops);
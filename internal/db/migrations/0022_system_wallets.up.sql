@@ -0,0 +1,7 @@
+-- служебные кошельки с известными ролями, казна, комиссии, невыясненные суммы, функции ищут их по роли, а не по адресу из конфигурации
+CREATE TABLE IF NOT EXISTS system_wallets (
+  role TEXT PRIMARY KEY,
+  address TEXT NOT NULL UNIQUE REFERENCES wallets(address),
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
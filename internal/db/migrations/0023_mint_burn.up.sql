@@ -0,0 +1,4 @@
+-- эмиссия и изъятие средств казной, отдельные виды операций, вторая сторона пустая, денег вне системы кошельком нет
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+  CHECK (type IN ('transfer', 'adjustment', 'fee', 'reversal', 'exchange', 'mint', 'burn'));
@@ -0,0 +1,9 @@
+-- балансы кошельков на начало суток, исторический баланс и сверка считаются от ближайшего снимка, а не от всей истории
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+  as_of TIMESTAMPTZ NOT NULL,
+  address TEXT NOT NULL,
+  balance_cents BIGINT NOT NULL,
+  PRIMARY KEY (as_of, address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_snapshots_address_as_of ON balance_snapshots (address, as_of DESC);
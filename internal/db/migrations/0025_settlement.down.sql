@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS settlement_lines;
+DROP TABLE IF EXISTS settlement_runs;
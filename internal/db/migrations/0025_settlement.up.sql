@@ -0,0 +1,23 @@
+-- расчет за бизнес-день, итоги по каждому кошельку и сводка дня, день задается датой в часовом поясе бизнеса
+CREATE TABLE IF NOT EXISTS settlement_runs (
+  business_date DATE PRIMARY KEY,
+  timezone TEXT NOT NULL,
+  period_start TIMESTAMPTZ NOT NULL,
+  period_end TIMESTAMPTZ NOT NULL,
+  wallets INT NOT NULL,
+  tx_count BIGINT NOT NULL,
+  volume_cents BIGINT NOT NULL,
+  fee_cents BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS settlement_lines (
+  business_date DATE NOT NULL REFERENCES settlement_runs(business_date) ON DELETE CASCADE,
+  address TEXT NOT NULL,
+  in_cents BIGINT NOT NULL,
+  out_cents BIGINT NOT NULL,
+  net_cents BIGINT NOT NULL,
+  fee_cents BIGINT NOT NULL,
+  tx_count BIGINT NOT NULL,
+  PRIMARY KEY (business_date, address)
+);
@@ -0,0 +1,10 @@
+-- ключи идемпотентности запросов, повтор с тем же ключом получает сохраненный ответ вместо повторного перевода, status_code NULL пока запрос выполняется
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  actor TEXT NOT NULL,
+  key TEXT NOT NULL,
+  fingerprint TEXT NOT NULL,
+  status_code INT,
+  response BYTEA,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (actor, key)
+);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS transactions_notify ON transactions;
+DROP FUNCTION IF EXISTS notify_transaction();
@@ -0,0 +1,12 @@
+-- уведомление о каждой новой транзакции для живой ленты, приходит слушателям в момент коммита и в порядке коммитов, в теле id транзакции
+CREATE OR REPLACE FUNCTION notify_transaction() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  PERFORM pg_notify('transactions', NEW.id::text);
+  RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS transactions_notify ON transactions;
+CREATE TRIGGER transactions_notify AFTER INSERT ON transactions
+  FOR EACH ROW EXECUTE FUNCTION notify_transaction();
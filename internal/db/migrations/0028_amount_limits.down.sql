@@ -0,0 +1,2 @@
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_overdraft_max;
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_balance_max;
@@ -0,0 +1,5 @@
+-- предел сумм и балансов, совпадает с money.MaxCents, база отказывает раньше, чем bigint переполнится
+ALTER TABLE wallets
+  ADD CONSTRAINT wallets_balance_max CHECK (balance_cents <= 1000000000000000);
+ALTER TABLE wallets
+  ADD CONSTRAINT wallets_overdraft_max CHECK (overdraft_limit_cents <= 1000000000000000);
@@ -0,0 +1,4 @@
+ALTER TABLE transactions_archive DROP COLUMN IF EXISTS group_id;
+
+DROP INDEX IF EXISTS idx_transactions_group_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS group_id;
@@ -0,0 +1,6 @@
+-- общий id у транзакций одной операции, например у переводов одной разбивки платежа, у одиночных переводов null
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS group_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_group_id ON transactions (group_id) WHERE group_id IS NOT NULL;
+
+ALTER TABLE transactions_archive ADD COLUMN IF NOT EXISTS group_id UUID;
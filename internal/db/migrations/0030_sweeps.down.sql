@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sweep_wallets;
+DROP TABLE IF EXISTS sweeps;
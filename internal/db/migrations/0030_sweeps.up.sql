@@ -0,0 +1,26 @@
+-- сбор остатков списка кошельков на один кошелек, например при закрытии промо кошельков, строки кошельков помнят исход, поэтому прерванный сбор продолжается с места остановки
+CREATE TABLE IF NOT EXISTS sweeps (
+  id BIGSERIAL PRIMARY KEY,
+  destination TEXT NOT NULL,
+  mode TEXT NOT NULL CHECK (mode IN ('atomic', 'chunked')),
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'done')),
+  group_id UUID NOT NULL,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  finished_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS sweep_wallets (
+  sweep_id BIGINT NOT NULL REFERENCES sweeps(id) ON DELETE CASCADE,
+  position INT NOT NULL,
+  address TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'swept', 'skipped', 'failed')),
+  amount_cents BIGINT NOT NULL DEFAULT 0,
+  error TEXT,
+  processed_at TIMESTAMPTZ,
+  PRIMARY KEY (sweep_id, position),
+  UNIQUE (sweep_id, address)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sweep_wallets_pending ON sweep_wallets (sweep_id, position) WHERE status = 'pending';
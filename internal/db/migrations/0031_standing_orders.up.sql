@@ -0,0 +1,35 @@
+-- постоянные поручения, регулярный перевод по расписанию, slot_at, срок, который сейчас исполняется, next_run_at, когда пробовать, при повторе после отказа позже slot_at
+CREATE TABLE IF NOT EXISTS standing_orders (
+  id BIGSERIAL PRIMARY KEY,
+  from_address TEXT NOT NULL,
+  to_address TEXT NOT NULL,
+  amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+  schedule TEXT NOT NULL,
+  run_at TEXT NOT NULL,
+  timezone TEXT NOT NULL,
+  failure_policy TEXT NOT NULL CHECK (failure_policy IN ('skip', 'retry')),
+  max_retries INT NOT NULL DEFAULT 0,
+  status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'paused', 'cancelled')),
+  slot_at TIMESTAMPTZ NOT NULL,
+  next_run_at TIMESTAMPTZ NOT NULL,
+  attempt INT NOT NULL DEFAULT 0,
+  last_run_at TIMESTAMPTZ,
+  last_error TEXT,
+  created_by TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_standing_orders_due ON standing_orders (next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_standing_orders_from ON standing_orders (from_address);
+
+-- исходы сроков поручения, одна строка на срок, попытки повтора считаются в ней
+CREATE TABLE IF NOT EXISTS standing_order_runs (
+  order_id BIGINT NOT NULL REFERENCES standing_orders(id) ON DELETE CASCADE,
+  slot_at TIMESTAMPTZ NOT NULL,
+  status TEXT NOT NULL CHECK (status IN ('done', 'retrying', 'failed')),
+  attempts INT NOT NULL DEFAULT 1,
+  error TEXT,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (order_id, slot_at)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE wallets
+  DROP COLUMN IF EXISTS low_balance_since,
+  DROP COLUMN IF EXISTS low_balance_cents;
@@ -0,0 +1,4 @@
+-- порог низкого баланса кошелька, NULL выключает, low_balance_since, с какого момента баланс ниже порога, NULL если не ниже
+ALTER TABLE wallets
+  ADD COLUMN IF NOT EXISTS low_balance_cents BIGINT CHECK (low_balance_cents > 0),
+  ADD COLUMN IF NOT EXISTS low_balance_since TIMESTAMPTZ;
@@ -0,0 +1,12 @@
+-- изменения балансов кошельков, пишутся в транзакции перевода, по строке на каждую затронутую сторону, баланс после изменения,
+-- без внешнего ключа на transactions, строки переводов уходят в архив вместе с партицией
+CREATE TABLE IF NOT EXISTS balance_events (
+  id BIGSERIAL PRIMARY KEY,
+  address TEXT NOT NULL,
+  tx_id BIGINT NOT NULL,
+  delta_cents BIGINT NOT NULL,
+  balance_cents BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_events_address ON balance_events (address, id);
@@ -0,0 +1,7 @@
+-- теневая таблица пересборки балансов, текущий и пересобранный по журналу баланс каждого кошелька последнего прогона walletctl rebuild-balances
+CREATE TABLE IF NOT EXISTS wallets_rebuild (
+  address TEXT PRIMARY KEY,
+  balance_cents BIGINT NOT NULL,
+  rebuilt_cents BIGINT NOT NULL,
+  rebuilt_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,28 @@
+ALTER TABLE settlement_lines DROP CONSTRAINT IF EXISTS settlement_lines_tenant_id_business_date_fkey;
+ALTER TABLE settlement_runs DROP CONSTRAINT IF EXISTS settlement_runs_pkey;
+ALTER TABLE settlement_runs ADD PRIMARY KEY (business_date);
+ALTER TABLE settlement_lines ADD FOREIGN KEY (business_date) REFERENCES settlement_runs(business_date) ON DELETE CASCADE;
+
+ALTER TABLE system_wallets DROP CONSTRAINT IF EXISTS system_wallets_pkey;
+ALTER TABLE system_wallets ADD PRIMARY KEY (role);
+
+DROP POLICY IF EXISTS tenant_archive_insert ON transactions_archive;
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'wallets', 'system_wallets', 'supply_adjustments', 'transactions', 'transactions_archive', 'balance_events', 'balance_snapshots',
+    'wallets_rebuild', 'jobs', 'alerts', 'audit_log', 'pending_transfers', 'payees', 'payment_requests',
+    'sweeps', 'sweep_wallets', 'standing_orders', 'standing_order_runs', 'settlement_runs', 'settlement_lines'
+  ] LOOP
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+    EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I DROP COLUMN IF EXISTS tenant_id', t);
+  END LOOP;
+END
+$$;
+
+DROP FUNCTION IF EXISTS app_tenant();
@@ -0,0 +1,38 @@
+-- изоляция арендаторов политиками row level security, арендатор задается на соединении параметром app.tenant_id (TENANT_ID сервиса),
+-- строка получает арендатора соединения при вставке, соединение с арендатором видит и меняет только его строки,
+-- без арендатора (пустой app.tenant_id, одиночная установка, миграции, walletctl) видно все, политики действуют и на владельца таблиц, но не на суперпользователя и BYPASSRLS
+CREATE OR REPLACE FUNCTION app_tenant() RETURNS TEXT
+LANGUAGE sql STABLE AS $$
+  SELECT COALESCE(current_setting('app.tenant_id', true), '')
+$$;
+
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY[
+    'wallets', 'system_wallets', 'supply_adjustments', 'transactions', 'transactions_archive', 'balance_events', 'balance_snapshots',
+    'wallets_rebuild', 'jobs', 'alerts', 'audit_log', 'pending_transfers', 'payees', 'payment_requests',
+    'sweeps', 'sweep_wallets', 'standing_orders', 'standing_order_runs', 'settlement_runs', 'settlement_lines'
+  ] LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT app_tenant()', t);
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (app_tenant() = %L OR tenant_id = app_tenant()) WITH CHECK (app_tenant() = %L OR tenant_id = app_tenant())', t, '', '');
+  END LOOP;
+END
+$$;
+
+-- архив пополняется целыми месяцами из партиций, в которых строки всех арендаторов, вставка в него открыта, чтение и изменение по арендатору
+DROP POLICY IF EXISTS tenant_archive_insert ON transactions_archive;
+CREATE POLICY tenant_archive_insert ON transactions_archive FOR INSERT WITH CHECK (true);
+
+-- роли служебных кошельков и бизнес-дни расчета уникальны внутри арендатора
+ALTER TABLE system_wallets DROP CONSTRAINT IF EXISTS system_wallets_pkey;
+ALTER TABLE system_wallets ADD PRIMARY KEY (tenant_id, role);
+
+ALTER TABLE settlement_lines DROP CONSTRAINT IF EXISTS settlement_lines_business_date_fkey;
+ALTER TABLE settlement_runs DROP CONSTRAINT IF EXISTS settlement_runs_pkey;
+ALTER TABLE settlement_runs ADD PRIMARY KEY (tenant_id, business_date);
+ALTER TABLE settlement_lines ADD FOREIGN KEY (tenant_id, business_date) REFERENCES settlement_runs(tenant_id, business_date) ON DELETE CASCADE;
@@ -0,0 +1,15 @@
+-- очередь зачислений применяется к балансам, иначе деньги из нее пропадут
+UPDATE wallets w SET balance_cents = w.balance_cents + h.total, updated_at = now()
+FROM (SELECT address, SUM(amount_cents) AS total FROM hot_credits GROUP BY address) h
+WHERE w.address = h.address;
+
+CREATE OR REPLACE FUNCTION check_money_supply()
+RETURNS TABLE (balances_cents NUMERIC, expected_cents NUMERIC, ok BOOLEAN)
+LANGUAGE sql STABLE AS $$
+  SELECT b.total, s.total, b.total = s.total
+  FROM (SELECT COALESCE(SUM(balance_cents), 0)::numeric AS total FROM wallets) b,
+       (SELECT COALESCE(SUM(delta_cents), 0)::numeric AS total FROM supply_adjustments) s
+$$;
+
+DROP TABLE IF EXISTS hot_credits;
+ALTER TABLE wallets DROP COLUMN IF EXISTS hot_since;
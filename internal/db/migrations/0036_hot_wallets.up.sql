@@ -0,0 +1,31 @@
+-- горячие кошельки, получатели непропорционально большой доли переводов, зачисления на них не трогают строку кошелька,
+-- а копятся очередью hot_credits и применяются к балансу пачками, перевод блокирует только отправителя
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS hot_since TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS hot_credits (
+  id BIGSERIAL PRIMARY KEY,
+  address TEXT NOT NULL REFERENCES wallets(address) ON DELETE CASCADE,
+  tx_id BIGINT NOT NULL,
+  amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  tenant_id TEXT NOT NULL DEFAULT app_tenant()
+);
+
+CREATE INDEX IF NOT EXISTS idx_hot_credits_address ON hot_credits (address, id);
+
+ALTER TABLE hot_credits ENABLE ROW LEVEL SECURITY;
+ALTER TABLE hot_credits FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON hot_credits;
+CREATE POLICY tenant_isolation ON hot_credits
+  USING (app_tenant() = '' OR tenant_id = app_tenant())
+  WITH CHECK (app_tenant() = '' OR tenant_id = app_tenant());
+
+-- неприменные зачисления уже списаны с отправителей и входят в денежную массу
+CREATE OR REPLACE FUNCTION check_money_supply()
+RETURNS TABLE (balances_cents NUMERIC, expected_cents NUMERIC, ok BOOLEAN)
+LANGUAGE sql STABLE AS $$
+  SELECT b.total + h.total, s.total, b.total + h.total = s.total
+  FROM (SELECT COALESCE(SUM(balance_cents), 0)::numeric AS total FROM wallets) b,
+       (SELECT COALESCE(SUM(amount_cents), 0)::numeric AS total FROM hot_credits) h,
+       (SELECT COALESCE(SUM(delta_cents), 0)::numeric AS total FROM supply_adjustments) s
+$$;
@@ -0,0 +1,7 @@
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_from_created_at ON transactions (from_address, created_at);
+CREATE INDEX IF NOT EXISTS idx_transactions_to_created_at ON transactions (to_address, created_at);
+
+DROP INDEX IF EXISTS idx_transactions_created_at_id;
+DROP INDEX IF EXISTS idx_transactions_from_created_at_id;
+DROP INDEX IF EXISTS idx_transactions_to_created_at_id;
@@ -0,0 +1,9 @@
+-- листинг транзакций сортируется по времени с id для равных значений, индексы повторяют этот порядок целиком,
+-- так страница и продолжение по курсору читаются из индекса без сортировки, прежние индексы по времени перекрываются новыми
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at_id ON transactions (created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_from_created_at_id ON transactions (from_address, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_to_created_at_id ON transactions (to_address, created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_transactions_created_at;
+DROP INDEX IF EXISTS idx_transactions_from_created_at;
+DROP INDEX IF EXISTS idx_transactions_to_created_at;
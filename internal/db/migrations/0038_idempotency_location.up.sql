@@ -0,0 +1,2 @@
+-- заголовок Location сохраненного ответа, повтор создания ресурса получает ту же ссылку на него, NULL у ответов без заголовка
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS location TEXT;
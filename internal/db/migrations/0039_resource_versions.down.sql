@@ -0,0 +1,2 @@
+ALTER TABLE wallets DROP COLUMN IF EXISTS meta_version;
+ALTER TABLE standing_orders DROP COLUMN IF EXISTS version;
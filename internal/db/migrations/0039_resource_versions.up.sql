@@ -0,0 +1,4 @@
+-- версии изменяемых ресурсов для оптимистичной блокировки, ETag ответа строится из версии, PATCH с If-Match старой версии отклоняется,
+-- версия поручения растет при изменении полей и состояния, версия кошелька при изменении почты и порога низкого баланса, переводы ее не трогают
+ALTER TABLE standing_orders ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS meta_version BIGINT NOT NULL DEFAULT 1;
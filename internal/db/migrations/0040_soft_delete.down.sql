@@ -0,0 +1,5 @@
+ALTER TABLE standing_orders DROP COLUMN IF EXISTS deleted_at;
+DELETE FROM payees WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS idx_payees_alias_live;
+ALTER TABLE payees ADD CONSTRAINT payees_wallet_address_alias_key UNIQUE (wallet_address, alias);
+ALTER TABLE payees DROP COLUMN IF EXISTS deleted_at;
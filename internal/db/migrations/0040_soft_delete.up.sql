@@ -0,0 +1,6 @@
+-- мягкое удаление получателей адресной книги и постоянных поручений, удаленные скрыты из списков, администратор может их вернуть,
+-- псевдоним уникален только среди не удаленных, так удаленный псевдоним можно занять снова
+ALTER TABLE payees ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE payees DROP CONSTRAINT IF EXISTS payees_wallet_address_alias_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payees_alias_live ON payees (wallet_address, alias) WHERE deleted_at IS NULL;
+ALTER TABLE standing_orders ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
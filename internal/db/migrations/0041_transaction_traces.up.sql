@@ -0,0 +1,17 @@
+-- сведения о выполнении перевода для разбора обращений, идентификатор запроса, число попыток из-за дедлоков, суммарное ожидание блокировок кошельков,
+-- пишутся в транзакции перевода, без внешнего ключа на transactions, как balance_events
+CREATE TABLE IF NOT EXISTS transaction_traces (
+  tx_id BIGINT PRIMARY KEY,
+  request_id TEXT NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 1,
+  lock_wait_ms BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  tenant_id TEXT NOT NULL DEFAULT app_tenant()
+);
+
+ALTER TABLE transaction_traces ENABLE ROW LEVEL SECURITY;
+ALTER TABLE transaction_traces FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON transaction_traces;
+CREATE POLICY tenant_isolation ON transaction_traces
+  USING (app_tenant() = '' OR tenant_id = app_tenant())
+  WITH CHECK (app_tenant() = '' OR tenant_id = app_tenant());
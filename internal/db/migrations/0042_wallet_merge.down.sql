@@ -0,0 +1,6 @@
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+  CHECK (type IN ('transfer', 'adjustment', 'fee', 'reversal', 'exchange', 'mint', 'burn'));
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_closed_empty;
+ALTER TABLE wallets DROP COLUMN IF EXISTS successor;
+ALTER TABLE wallets DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,12 @@
+-- слияние кошельков, закрытый кошелек передал баланс преемнику successor, переводы с его участием отклоняются,
+-- ограничение страхует от зачисления на закрытый кошелек в обход проверок, внешнего ключа на преемника нет, тестовые и песочные данные удаляются в любом порядке
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS successor TEXT;
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS wallets_closed_empty;
+ALTER TABLE wallets ADD CONSTRAINT wallets_closed_empty
+  CHECK (closed_at IS NULL OR (balance_cents = 0 AND successor IS NOT NULL));
+
+-- перенос баланса при слиянии, отдельный вид операции
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check
+  CHECK (type IN ('transfer', 'adjustment', 'fee', 'reversal', 'exchange', 'mint', 'burn', 'merge'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS wallets_derived_label_idx;
+ALTER TABLE wallets DROP COLUMN IF EXISTS derived_label;
@@ -0,0 +1,4 @@
+-- метка выведенного адреса, адрес кошелька получен HMAC секрета сервера от арендатора и метки, повторное развертывание с тем же секретом дает тот же адрес,
+-- метка уникальна внутри арендатора, другой секрет дал бы метке второй адрес, а уникальность ловит это как коллизию
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS derived_label TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS wallets_derived_label_idx ON wallets(tenant_id, derived_label) WHERE derived_label IS NOT NULL;
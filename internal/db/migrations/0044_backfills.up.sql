@@ -0,0 +1,16 @@
+-- заполнение новых колонок больших таблиц по частям, миграция добавляет колонку без тяжелого default и заявляет здесь, чем ее заполнить,
+-- выражение set_clause для строк where_clause применяется диапазонами id короткими транзакциями, last_id хранит продвижение, прерванное заполнение продолжается с него,
+-- max_id фиксируется при первом запуске, строки новее пишет уже код, знающий о колонке, таблица общая для арендаторов, заполнение идет по всей таблице без app.tenant_id
+CREATE TABLE IF NOT EXISTS backfills (
+  name         TEXT PRIMARY KEY,
+  table_name   TEXT NOT NULL,
+  set_clause   TEXT NOT NULL,
+  where_clause TEXT NOT NULL DEFAULT 'true',
+  last_id      BIGINT NOT NULL DEFAULT 0,
+  max_id       BIGINT,
+  rows         BIGINT NOT NULL DEFAULT 0,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+  started_at   TIMESTAMPTZ,
+  updated_at   TIMESTAMPTZ,
+  done_at      TIMESTAMPTZ
+);
@@ -0,0 +1,36 @@
+-- новая модель балансов, счет кошелька и проводки, пишется рядом со старой колонкой wallets.balance_cents в режиме двойной записи,
+-- счет открывается первой проводкой, opening_cents, баланс кошелька до нее, так что balance_cents = opening_cents + сумма проводок
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+  address TEXT PRIMARY KEY REFERENCES wallets(address) ON DELETE CASCADE,
+  opening_cents BIGINT NOT NULL,
+  balance_cents BIGINT NOT NULL,
+  opened_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  tenant_id TEXT NOT NULL DEFAULT app_tenant()
+);
+
+-- проводки, изменение счета операцией, без внешнего ключа на transactions, как balance_events
+CREATE TABLE IF NOT EXISTS ledger_entries (
+  id BIGSERIAL PRIMARY KEY,
+  address TEXT NOT NULL REFERENCES ledger_accounts(address) ON DELETE CASCADE,
+  tx_id BIGINT NOT NULL,
+  delta_cents BIGINT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  tenant_id TEXT NOT NULL DEFAULT app_tenant()
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_address_idx ON ledger_entries(address, id);
+
+ALTER TABLE ledger_accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ledger_accounts FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON ledger_accounts;
+CREATE POLICY tenant_isolation ON ledger_accounts
+  USING (app_tenant() = '' OR tenant_id = app_tenant())
+  WITH CHECK (app_tenant() = '' OR tenant_id = app_tenant());
+
+ALTER TABLE ledger_entries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE ledger_entries FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON ledger_entries;
+CREATE POLICY tenant_isolation ON ledger_entries
+  USING (app_tenant() = '' OR tenant_id = app_tenant())
+  WITH CHECK (app_tenant() = '' OR tenant_id = app_tenant());
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS hot_credits_notify ON hot_credits;
+DROP TRIGGER IF EXISTS wallets_notify ON wallets;
+DROP FUNCTION IF EXISTS notify_balance();
@@ -0,0 +1,25 @@
+-- уведомление об изменении кошелька для сброса кэша балансов на всех экземплярах, приходит слушателям в момент коммита, в теле адрес,
+-- канал по корзине хэша адреса, balances_0 .. balances_15, число корзин совпадает с balanceChannels в repo,
+-- строка кошелька меняется при переводе, правке и слиянии, видимый баланс горячего кошелька еще и при зачислении в очередь
+CREATE OR REPLACE FUNCTION notify_balance() RETURNS trigger
+LANGUAGE plpgsql AS $$
+DECLARE
+  addr TEXT;
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    addr := OLD.address;
+  ELSE
+    addr := NEW.address;
+  END IF;
+  PERFORM pg_notify('balances_' || (hashtext(addr) & 15), addr);
+  RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS wallets_notify ON wallets;
+CREATE TRIGGER wallets_notify AFTER UPDATE OR DELETE ON wallets
+  FOR EACH ROW EXECUTE FUNCTION notify_balance();
+
+DROP TRIGGER IF EXISTS hot_credits_notify ON hot_credits;
+CREATE TRIGGER hot_credits_notify AFTER INSERT ON hot_credits
+  FOR EACH ROW EXECUTE FUNCTION notify_balance();
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enroll_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enroll_token_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_counter;
@@ -0,0 +1,5 @@
+-- totp_last_counter, интервал последнего принятого кода, код того же или более раннего интервала второй раз не принимается,
+-- totp_enroll_token_hash, хэш токена из письма, без которого не включается totp у пользователя, подтверждающего переводы почтой, срок токена в totp_enroll_expires_at
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_counter BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enroll_token_hash TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enroll_expires_at TIMESTAMPTZ;
@@ -0,0 +1,22 @@
+-- пользователи и ключи доступа принадлежат арендатору, как кошельки в 0035, ключ, выданный на экземпляре одного арендатора,
+-- на экземпляре другого не находится, а администратор одного арендатора не становится администратором остальных
+DO $$
+DECLARE
+  t TEXT;
+BEGIN
+  FOREACH t IN ARRAY ARRAY['users', 'api_keys'] LOOP
+    EXECUTE format('ALTER TABLE %I ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT app_tenant()', t);
+    EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+    EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+    EXECUTE format('DROP POLICY IF EXISTS tenant_isolation ON %I', t);
+    EXECUTE format('CREATE POLICY tenant_isolation ON %I USING (app_tenant() = %L OR tenant_id = app_tenant()) WITH CHECK (app_tenant() = %L OR tenant_id = app_tenant())', t, '', '');
+  END LOOP;
+END
+$$;
+
+-- почта и вход oidc уникальны внутри арендатора
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (tenant_id, email);
+
+DROP INDEX IF EXISTS idx_users_oidc;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oidc ON users (tenant_id, oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL;
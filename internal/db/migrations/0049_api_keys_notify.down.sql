@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS api_keys_notify ON api_keys;
+DROP FUNCTION IF EXISTS notify_api_key();
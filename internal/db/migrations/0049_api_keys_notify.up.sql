@@ -0,0 +1,13 @@
+-- уведомление об изменении или удалении ключа доступа для сброса кэша ключей на всех экземплярах, приходит слушателям в момент коммита,
+-- в теле идентификатор ключа, ключ меняется при отзыве, ротации и включении подписи, удаляется вместе с пользователем
+CREATE OR REPLACE FUNCTION notify_api_key() RETURNS trigger
+LANGUAGE plpgsql AS $$
+BEGIN
+  PERFORM pg_notify('api_keys', OLD.id::text);
+  RETURN NULL;
+END
+$$;
+
+DROP TRIGGER IF EXISTS api_keys_notify ON api_keys;
+CREATE TRIGGER api_keys_notify AFTER UPDATE OR DELETE ON api_keys
+  FOR EACH ROW EXECUTE FUNCTION notify_api_key();
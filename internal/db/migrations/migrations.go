@@ -0,0 +1,46 @@
+// Package migrations, sql миграции схемы, в проде их применяет migrate из docker-compose, пакет отдает их встроенными для тестов
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// files, миграции вверх
+//
+//go:embed *.up.sql
+var files embed.FS
+
+// Migration, миграция вверх, версия из префикса имени файла
+type Migration struct {
+	Version uint
+	Name    string
+	SQL     string
+}
+
+// Up, миграции вверх по возрастанию версии
+func Up() ([]Migration, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Migration, 0, len(names))
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		v, err := strconv.ParseUint(prefix, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version", name)
+		}
+		b, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, Migration{Version: uint(v), Name: name, SQL: string(b)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import "testing"
+
+// TestUp, все миграции встроены по порядку версий без пропусков
+func TestUp(t *testing.T) {
+	ms, err := Up()
+	if err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if len(ms) == 0 {
+		t.Fatalf("no migrations embedded")
+	}
+	for i, m := range ms {
+		if m.Version != uint(i+1) {
+			t.Fatalf("migration %d is %s, want version %d", i, m.Name, i+1)
+		}
+		if m.SQL == "" {
+			t.Fatalf("%s is empty", m.Name)
+		}
+	}
+}
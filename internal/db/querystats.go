@@ -0,0 +1,159 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// maxStatements, сколько разных запросов считается отдельно, остальные идут в одну строку otherStatement, динамические запросы не раздувают память
+const maxStatements = 500
+
+// otherStatement, строка статистики для запросов сверх maxStatements
+const otherStatement = "(other)"
+
+// maxLoggedSQL, до какой длины текст запроса попадает в лог медленных
+const maxLoggedSQL = 1000
+
+// QueryStat, статистика одного запроса по нормализованному тексту, Slow, сколько выполнений были не короче порога
+type QueryStat struct {
+	SQL    string
+	Calls  int64
+	Errors int64
+	Slow   int64
+	Total  time.Duration
+	Max    time.Duration
+}
+
+// QueryStats, трассировщик pgx, считает время каждого запроса пула по его тексту и пишет в лог запросы не короче порога,
+// параметры в лог не попадают, только их типы, безопасен для параллельного использования
+type QueryStats struct {
+	// slow, порог медленного запроса в наносекундах, ноль выключает лог, меняется на ходу
+	slow atomic.Int64
+	// Logf, куда писать медленные запросы, подменяется в тестах
+	Logf func(format string, args ...any)
+
+	mu    sync.Mutex
+	stats map[string]*QueryStat
+}
+
+// NewQueryStats, трассировщик с порогом медленного запроса
+func NewQueryStats(slow time.Duration) *QueryStats {
+	q := &QueryStats{Logf: log.Printf, stats: make(map[string]*QueryStat)}
+	q.SetSlowThreshold(slow)
+	return q
+}
+
+// SlowThreshold, текущий порог медленного запроса
+func (q *QueryStats) SlowThreshold() time.Duration { return time.Duration(q.slow.Load()) }
+
+// SetSlowThreshold, меняет порог для следующих запросов, ноль выключает лог
+func (q *QueryStats) SetSlowThreshold(d time.Duration) { q.slow.Store(int64(d)) }
+
+// queryStart, ключ контекста с началом запроса
+type queryStart struct{}
+
+// queryTrace, что запоминается о запросе до его выполнения
+type queryTrace struct {
+	at   time.Time
+	sql  string
+	args []any
+}
+
+// TraceQueryStart, запоминает время начала и текст запроса
+func (q *QueryStats) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStart{}, queryTrace{at: time.Now(), sql: data.SQL, args: data.Args})
+}
+
+// TraceQueryEnd, добавляет выполнение в статистику, медленное пишет в лог
+func (q *QueryStats) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	tr, ok := ctx.Value(queryStart{}).(queryTrace)
+	if !ok {
+		return
+	}
+	d := time.Since(tr.at)
+	sql := normalizeSQL(tr.sql)
+	threshold := q.SlowThreshold()
+	slow := threshold > 0 && d >= threshold
+
+	q.mu.Lock()
+	s, ok := q.stats[sql]
+	if !ok {
+		key := sql
+		if len(q.stats) >= maxStatements {
+			key = otherStatement
+		}
+		if s, ok = q.stats[key]; !ok {
+			s = &QueryStat{SQL: key}
+			q.stats[key] = s
+		}
+	}
+	s.Calls++
+	s.Total += d
+	if d > s.Max {
+		s.Max = d
+	}
+	if data.Err != nil {
+		s.Errors++
+	}
+	if slow {
+		s.Slow++
+	}
+	q.mu.Unlock()
+
+	if slow {
+		if len(sql) > maxLoggedSQL {
+			sql = sql[:maxLoggedSQL] + "..."
+		}
+		q.Logf("slow query %s: %s args=%s", d.Round(time.Millisecond), sql, redactArgs(tr.args))
+	}
+}
+
+// Snapshot, статистика по запросам, самые долгие в сумме первыми, limit ноль без ограничения
+func (q *QueryStats) Snapshot(limit int) []QueryStat {
+	q.mu.Lock()
+	out := make([]QueryStat, 0, len(q.stats))
+	for _, s := range q.stats {
+		out = append(out, *s)
+	}
+	q.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Total != out[j].Total {
+			return out[i].Total > out[j].Total
+		}
+		return out[i].SQL < out[j].SQL
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// Reset, обнуляет статистику
+func (q *QueryStats) Reset() {
+	q.mu.Lock()
+	q.stats = make(map[string]*QueryStat)
+	q.mu.Unlock()
+}
+
+// normalizeSQL, текст запроса одной строкой, пробелы и переносы схлопываются, значения в запросах идут параметрами, поэтому текст от них не зависит
+func normalizeSQL(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// redactArgs, параметры запроса без значений, только типы, адреса, суммы и почта в лог не попадают
+func redactArgs(args []any) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprintf("$%d=<%T>", i+1, a)
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
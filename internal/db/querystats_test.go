@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TestQueryStats, выполнения одного запроса с разными пробелами считаются вместе, медленный пишется в лог без значений параметров
+func TestQueryStats(t *testing.T) {
+	var logged []string
+	q := NewQueryStats(time.Nanosecond)
+	q.Logf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+
+	run := func(sql string, err error, args ...any) {
+		ctx := q.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: sql, Args: args})
+		time.Sleep(time.Millisecond)
+		q.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: err})
+	}
+	run("SELECT balance_cents\n\tFROM wallets WHERE address = $1", nil, "secret-address")
+	run("SELECT balance_cents FROM wallets   WHERE address = $1", errors.New("boom"), "secret-address")
+	run("SELECT 1", nil)
+
+	stats := q.Snapshot(0)
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	s := stats[0]
+	if s.SQL != "SELECT balance_cents FROM wallets WHERE address = $1" || s.Calls != 2 || s.Errors != 1 || s.Slow != 2 || s.Max <= 0 || s.Total < s.Max {
+		t.Fatalf("top stat = %+v", s)
+	}
+	if len(logged) != 3 {
+		t.Fatalf("logged %d slow queries, want 3", len(logged))
+	}
+	for _, l := range logged {
+		if strings.Contains(l, "secret-address") {
+			t.Fatalf("parameter value in log: %s", l)
+		}
+	}
+	if !strings.Contains(logged[0], "$1=<string>") {
+		t.Fatalf("parameter type missing: %s", logged[0])
+	}
+
+	if got := q.Snapshot(1); len(got) != 1 {
+		t.Fatalf("limit ignored: %d", len(got))
+	}
+	q.Reset()
+	if got := q.Snapshot(0); len(got) != 0 {
+		t.Fatalf("after reset: %+v", got)
+	}
+}
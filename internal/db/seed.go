@@ -7,6 +7,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"time"
+
+	"gotechtask/internal/address"
+	"gotechtask/internal/repo"
 )
 
 // defaultWallets, количество кошельков создаваемых при инициализации
@@ -15,15 +18,28 @@ const defaultWallets = 10
 // defaultBalanceCents, стартовый баланс в центах для каждого кошелька
 const defaultBalanceCents int64 = 10000 // 100.00
 
-// SeedInitialWallets, инициализирует таблицу кошельков начальными данными если она пуста, возвращает список созданных адресов или nil если записи уже есть
+// SeedInitialWallets, инициализирует таблицу кошельков начальными данными если она пуста, возвращает список созданных адресов или nil если записи уже есть,
+// одновременный запуск нескольких экземпляров сериализуется блокировкой, засевает только первый
 func SeedInitialWallets(db *sql.DB) ([]string, error) {
 	// ограничиваем время операции
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// проверяем есть ли уже кошельки в таблице
+	// начинаем транзакцию на запись
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, fmt.Errorf("seed begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// второй экземпляр ждет здесь и после коммита первого видит его кошельки
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('seed_initial_wallets'))`); err != nil {
+		return nil, fmt.Errorf("seed lock: %w", err)
+	}
+
+	// проверяем есть ли уже кошельки в таблице, служебные не считаются
 	var n int
-	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM wallets`).Scan(&n); err != nil {
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM wallets WHERE address NOT IN (SELECT address FROM system_wallets)`).Scan(&n); err != nil {
 		return nil, fmt.Errorf("seed count wallets: %w", err)
 	}
 	if n > 0 {
@@ -31,13 +47,6 @@ func SeedInitialWallets(db *sql.DB) ([]string, error) {
 		return nil, nil
 	}
 
-	// начинаем транзакцию на запись
-	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
-	if err != nil {
-		return nil, fmt.Errorf("seed begin tx: %w", err)
-	}
-	defer func() { _ = tx.Rollback() }()
-
 	// подготавливаем выражение вставки
 	stmt, err := tx.PrepareContext(ctx, `INSERT INTO wallets(address, balance_cents) VALUES ($1,$2)`)
 	if err != nil {
@@ -45,6 +54,13 @@ func SeedInitialWallets(db *sql.DB) ([]string, error) {
 	}
 	defer stmt.Close()
 
+	// стартовые балансы учитываются как эмиссия, чтобы сходился инвариант денежной массы
+	supply, err := tx.PrepareContext(ctx, `INSERT INTO supply_adjustments(delta_cents, reason, address) VALUES ($1,'seed',$2)`)
+	if err != nil {
+		return nil, fmt.Errorf("seed prepare supply: %w", err)
+	}
+	defer supply.Close()
+
 	// генерируем адреса и вставляем записи с одинаковым балансом
 	addrs := make([]string, 0, defaultWallets)
 	for i := 0; i < defaultWallets; i++ {
@@ -55,6 +71,9 @@ func SeedInitialWallets(db *sql.DB) ([]string, error) {
 		if _, err := stmt.ExecContext(ctx, addr, defaultBalanceCents); err != nil {
 			return nil, fmt.Errorf("seed insert: %w", err)
 		}
+		if _, err := supply.ExecContext(ctx, defaultBalanceCents, addr); err != nil {
+			return nil, fmt.Errorf("seed insert supply: %w", err)
+		}
 		addrs = append(addrs, addr)
 	}
 
@@ -65,6 +84,44 @@ func SeedInitialWallets(db *sql.DB) ([]string, error) {
 	return addrs, nil
 }
 
+// SeedSystemWallets, создает служебные кошельки недостающих ролей с нулевым балансом, повторный запуск ничего не меняет, одновременный запуск нескольких экземпляров сериализуется блокировкой, возвращает созданные роли,
+// с d адреса выводятся из ролей и одинаковы в каждом развертывании с тем же секретом, занятый выведенный адрес дает *repo.AddressCollisionError, без d адреса случайные
+func SeedSystemWallets(db *sql.DB, d *address.Deriver) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, fmt.Errorf("seed system begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// второй экземпляр ждет здесь и после коммита первого видит уже созданные роли
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('seed_system_wallets'))`); err != nil {
+		return nil, fmt.Errorf("seed system lock: %w", err)
+	}
+
+	var created []string
+	for _, sw := range repo.SystemWalletRoles {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM system_wallets WHERE role = $1)`, sw.Role).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("seed system check %s: %w", sw.Role, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := repo.NewSystemWallet(ctx, tx, d, sw.Role, sw.Description); err != nil {
+			return nil, fmt.Errorf("seed system wallet %s: %w", sw.Role, err)
+		}
+		created = append(created, sw.Role)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("seed system commit: %w", err)
+	}
+	return created, nil
+}
+
 // randomHex, возвращает случайную строку hex длиной nBytes байт
 func randomHex(nBytes int) (string, error) {
 	b := make([]byte, nBytes)
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// ErrRLSBypassed, роль подключения обходит row level security, изоляция арендаторов на ней не работает
+var ErrRLSBypassed = errors.New("database role bypasses row level security")
+
+// Open, открывает пул соединений, с арендатором каждое новое соединение сразу получает app.tenant_id, запросы без него на этом пуле не выполняются,
+// так что ошибка в запросе не покажет строки другого арендатора, tracer, если не nil, видит каждый запрос пула, например QueryStats
+func Open(dsn, tenant string, tracer pgx.QueryTracer) (*sql.DB, error) {
+	if tenant == "" && tracer == nil {
+		return sql.Open("pgx", dsn)
+	}
+	cfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Tracer = tracer
+	if tenant == "" {
+		return stdlib.OpenDB(*cfg), nil
+	}
+	return stdlib.OpenDB(*cfg, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+		_, err := conn.Exec(ctx, `SELECT set_config('app.tenant_id', $1, false)`, tenant)
+		return err
+	})), nil
+}
+
+// CheckTenantRole, проверяет, что политики арендаторов действуют на роль подключения, суперпользователь и BYPASSRLS их не видят
+func CheckTenantRole(ctx context.Context, db *sql.DB) error {
+	var bypass bool
+	if err := db.QueryRowContext(ctx, `SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user`).Scan(&bypass); err != nil {
+		return err
+	}
+	if bypass {
+		return ErrRLSBypassed
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// openTenant, пул арендатора с проверкой ping, роль в обход политик пропускает тест
+func openTenant(t *testing.T, tenant string) *sql.DB {
+	t.Helper()
+	db, err := Open(testfixtures.DSN(), tenant, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.PingContext(context.Background()); err != nil {
+		t.Fatalf("ping db: %v", err)
+	}
+	if err := CheckTenantRole(context.Background(), db); errors.Is(err, ErrRLSBypassed) {
+		db.Close()
+		t.Skip("test role bypasses row level security")
+	} else if err != nil {
+		t.Fatalf("check role: %v", err)
+	}
+	return db
+}
+
+// TestTenantIsolation, кошелек одного арендатора не виден и не меняется через пул другого, даже запросом без условия на арендатора
+func TestTenantIsolation(t *testing.T) {
+	a := openTenant(t, "test-a")
+	defer a.Close()
+	b := openTenant(t, "test-b")
+	defer b.Close()
+
+	addr, err := randomHex(32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Exec(`INSERT INTO wallets(address, balance_cents) VALUES ($1, 0)`, addr); err != nil {
+		t.Fatalf("insert wallet: %v", err)
+	}
+	defer func() { _, _ = a.Exec(`DELETE FROM wallets WHERE address=$1`, addr) }()
+
+	var tenant string
+	if err := a.QueryRow(`SELECT tenant_id FROM wallets WHERE address=$1`, addr).Scan(&tenant); err != nil || tenant != "test-a" {
+		t.Fatalf("owner tenant: %q %v", tenant, err)
+	}
+	var n int
+	if err := b.QueryRow(`SELECT COUNT(*) FROM wallets WHERE address=$1`, addr).Scan(&n); err != nil || n != 0 {
+		t.Fatalf("other tenant sees wallet: %d %v", n, err)
+	}
+	res, err := b.Exec(`UPDATE wallets SET balance_cents = 100`)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	var bal int64
+	_ = a.QueryRow(`SELECT balance_cents FROM wallets WHERE address=$1`, addr).Scan(&bal)
+	if bal != 0 {
+		rows, _ := res.RowsAffected()
+		t.Fatalf("other tenant changed wallet, balance %d, rows %d", bal, rows)
+	}
+	other, _ := randomHex(32)
+	if _, err := b.Exec(`INSERT INTO wallets(address, balance_cents, tenant_id) VALUES ($1, 0, 'test-a')`, other); err == nil {
+		_, _ = a.Exec(`DELETE FROM wallets WHERE address=$1`, other)
+		t.Fatal("insert into another tenant accepted")
+	}
+}
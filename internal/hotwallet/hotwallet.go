@@ -0,0 +1,158 @@
+// Package hotwallet, горячие кошельки, поиск получателей непропорционально большой доли переводов и применение их очередей зачислений к балансам
+package hotwallet
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gotechtask/internal/config"
+	"gotechtask/internal/leader"
+	"gotechtask/internal/repo"
+)
+
+// actor, кто ставит и снимает пометку в журнале аудита
+const actor = "hotwallet"
+
+// Store, данные поиска горячих кошельков, реализуется репозиторием postgres
+type Store interface {
+	RecipientCounts(ctx context.Context, since time.Time, min int) (int, []repo.WalletCount, error)
+	ListHotWallets(ctx context.Context) ([]repo.HotWallet, error)
+	SetWalletHot(ctx context.Context, address string, hot bool, actor string) error
+}
+
+// Detector, периодически пересматривает горячие кошельки по доле переводов за окно,
+// пометка снимается, только когда доля и число зачислений упали ниже половины порогов, так кошелек на границе не переключается каждый проход,
+// кошельки из Cfg.Wallets остаются горячими всегда
+type Detector struct {
+	Store Store
+	Cfg   config.HotWallet
+	// Lock, блокировка прохода среди экземпляров сервиса, nil для одного экземпляра
+	Lock leader.Locker
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор поиска
+func New(s Store, cfg config.HotWallet) *Detector {
+	return &Detector{Store: s, Cfg: cfg, Now: time.Now}
+}
+
+// Run, ищет сразу и затем с заданным интервалом до отмены контекста, из нескольких экземпляров проход делает один
+func (d *Detector) Run(ctx context.Context) {
+	t := time.NewTicker(d.Cfg.Interval)
+	defer t.Stop()
+
+	for {
+		if _, err := leader.Do(ctx, d.Lock, "hotwallet", d.RunOnce); err != nil {
+			log.Printf("hotwallet: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, один проход, помечает новые горячие кошельки и снимает пометку с остывших, пометки администратора тоже снимаются, если кошелек остыл
+func (d *Detector) RunOnce(ctx context.Context) error {
+	since := d.Now().Add(-d.Cfg.Window)
+	total, counts, err := d.Store.RecipientCounts(ctx, since, d.Cfg.MinCredits/2)
+	if err != nil {
+		return err
+	}
+	hot, err := d.Store.ListHotWallets(ctx)
+	if err != nil {
+		return err
+	}
+
+	count := make(map[string]int, len(counts))
+	for _, c := range counts {
+		count[c.Address] = c.Count
+	}
+	// above, число зачислений не меньше min и доля в окне не меньше s
+	above := func(n, min int, s float64) bool {
+		return n >= min && total > 0 && float64(n) >= s*float64(total)
+	}
+
+	pinned := make(map[string]bool, len(d.Cfg.Wallets))
+	for _, addr := range d.Cfg.Wallets {
+		pinned[addr] = true
+	}
+	was := make(map[string]bool, len(hot))
+	for _, w := range hot {
+		if w.Since.IsZero() {
+			continue
+		}
+		was[w.Address] = true
+		if !pinned[w.Address] && !above(count[w.Address], d.Cfg.MinCredits/2, d.Cfg.Share/2) {
+			if err := d.Store.SetWalletHot(ctx, w.Address, false, actor); err != nil {
+				return err
+			}
+			log.Printf("hotwallet: %s cooled down, %d of %d transfers", w.Address, count[w.Address], total)
+		}
+	}
+	for _, c := range counts {
+		if was[c.Address] || !above(c.Count, d.Cfg.MinCredits, d.Cfg.Share) {
+			continue
+		}
+		if err := d.Store.SetWalletHot(ctx, c.Address, true, actor); err != nil {
+			return err
+		}
+		log.Printf("hotwallet: %s is hot, %d of %d transfers", c.Address, c.Count, total)
+	}
+	return nil
+}
+
+// Pin, помечает горячими кошельки из настроек, кошелек, которого нет в базе, пропускается с записью в лог
+func Pin(ctx context.Context, s Store, wallets []string) error {
+	for _, addr := range wallets {
+		err := s.SetWalletHot(ctx, addr, true, actor)
+		if errors.Is(err, repo.ErrWalletNotFound) {
+			log.Printf("hotwallet: configured wallet %s not found", addr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Queue, очереди зачислений, реализуется репозиторием postgres
+type Queue interface {
+	ApplyHotCredits(ctx context.Context, limit int) (int, error)
+}
+
+// Applier, применяет очереди зачислений к балансам, работает на каждом экземпляре, кошелек применяет тот, кто первым взял его строку
+type Applier struct {
+	Store    Queue
+	Interval time.Duration
+	// Batch, сколько кошельков за один проход
+	Batch int
+}
+
+// Run, применяет очереди с интервалом до отмены контекста, полный проход сразу повторяется, пока очереди не разобраны
+func (a *Applier) Run(ctx context.Context) {
+	t := time.NewTicker(a.Interval)
+	defer t.Stop()
+
+	for {
+		n, err := a.Store.ApplyHotCredits(ctx, a.Batch)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("hotwallet: apply: %v", err)
+		}
+		if err == nil && a.Batch > 0 && n >= a.Batch {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
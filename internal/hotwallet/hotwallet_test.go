@@ -0,0 +1,92 @@
+package hotwallet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotechtask/internal/config"
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, переводы окна и пометки в памяти
+type fakeStore struct {
+	total  int
+	counts map[string]int
+	hot    map[string]bool
+}
+
+func (f *fakeStore) RecipientCounts(_ context.Context, _ time.Time, min int) (int, []repo.WalletCount, error) {
+	var out []repo.WalletCount
+	for addr, n := range f.counts {
+		if n >= min {
+			out = append(out, repo.WalletCount{Address: addr, Count: n})
+		}
+	}
+	return f.total, out, nil
+}
+
+func (f *fakeStore) ListHotWallets(context.Context) ([]repo.HotWallet, error) {
+	var out []repo.HotWallet
+	for addr := range f.hot {
+		out = append(out, repo.HotWallet{Address: addr, Since: time.Now()})
+	}
+	return out, nil
+}
+
+func (f *fakeStore) SetWalletHot(_ context.Context, addr string, hot bool, _ string) error {
+	if hot {
+		f.hot[addr] = true
+	} else {
+		delete(f.hot, addr)
+	}
+	return nil
+}
+
+// TestRunOnce_Hysteresis, кошелек становится горячим по доле и числу зачислений, остается им до падения ниже половины порогов
+func TestRunOnce_Hysteresis(t *testing.T) {
+	st := &fakeStore{hot: map[string]bool{}}
+	d := New(st, config.HotWallet{Window: time.Minute, Share: 0.2, MinCredits: 100})
+	run := func(total int, counts map[string]int) {
+		t.Helper()
+		st.total, st.counts = total, counts
+		if err := d.RunOnce(context.Background()); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+
+	// доля есть, зачислений мало, и наоборот
+	run(200, map[string]int{"m": 60, "n": 5})
+	if len(st.hot) != 0 {
+		t.Fatalf("below min credits: hot = %v", st.hot)
+	}
+	run(10000, map[string]int{"m": 1000})
+	if len(st.hot) != 0 {
+		t.Fatalf("below share: hot = %v", st.hot)
+	}
+
+	run(1000, map[string]int{"m": 300, "n": 150})
+	if !st.hot["m"] || st.hot["n"] {
+		t.Fatalf("want only m hot, got %v", st.hot)
+	}
+
+	// между половиной порога и порогом пометка держится
+	run(1000, map[string]int{"m": 150})
+	if !st.hot["m"] {
+		t.Fatalf("m cooled above half threshold")
+	}
+	run(1000, map[string]int{"m": 90})
+	if st.hot["m"] {
+		t.Fatalf("m still hot below half threshold")
+	}
+
+	// кошелек из настроек не остывает
+	d.Cfg.Wallets = []string{"p"}
+	if err := Pin(context.Background(), st, d.Cfg.Wallets); err != nil {
+		t.Fatalf("pin: %v", err)
+	}
+	run(1000, map[string]int{"m": 10})
+	if !st.hot["p"] {
+		t.Fatalf("pinned wallet cooled down")
+	}
+}
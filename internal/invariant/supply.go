@@ -0,0 +1,77 @@
+// Package invariant, проверки инвариантов хранилища, сейчас только сохранение денежной массы
+package invariant
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// KindSupplyMismatch, вид сигнала о нарушении инварианта денежной массы
+const KindSupplyMismatch = "supply_mismatch"
+
+// Store, данные нужные проверке, реализуется репозиторием postgres
+type Store interface {
+	CheckMoneySupply(ctx context.Context) (repo.SupplyCheck, error)
+	InsertAlert(ctx context.Context, a repo.Alert) (bool, error)
+}
+
+// Checker, проверяет инвариант по запросу и автоматически после каждых Every переводов, при нарушении пишет сигнал в alerts
+type Checker struct {
+	Store Store
+	Every int64
+
+	transfers atomic.Int64
+	running   atomic.Bool
+}
+
+// New, конструктор, every равный нулю выключает автоматическую проверку
+func New(s Store, every int64) *Checker {
+	return &Checker{Store: s, Every: every}
+}
+
+// Check, выполняет проверку, при расхождении пишет лог и сигнал, ошибка записи сигнала не скрывает результат проверки
+func (c *Checker) Check(ctx context.Context) (repo.SupplyCheck, error) {
+	res, err := c.Store.CheckMoneySupply(ctx)
+	if err != nil {
+		return res, err
+	}
+	if !res.OK {
+		log.Printf("invariant: money supply mismatch, balances=%d expected=%d", res.BalancesCents, res.ExpectedCents)
+		if _, err := c.Store.InsertAlert(ctx, repo.Alert{
+			Kind: KindSupplyMismatch,
+			Details: map[string]any{
+				"balances_cents": res.BalancesCents,
+				"expected_cents": res.ExpectedCents,
+			},
+			WindowStart: time.Now().UTC().Truncate(time.Minute),
+		}); err != nil {
+			log.Printf("invariant: insert alert: %v", err)
+		}
+	}
+	return res, nil
+}
+
+// TransferCommitted, отмечает успешный перевод, на каждом Every-м запускает проверку в фоне, одновременно идет не больше одной проверки
+func (c *Checker) TransferCommitted() {
+	if c == nil || c.Every <= 0 {
+		return
+	}
+	if c.transfers.Add(1)%c.Every != 0 {
+		return
+	}
+	if !c.running.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer c.running.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := c.Check(ctx); err != nil {
+			log.Printf("invariant: check money supply: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,123 @@
+// Package jobs, обработчик фоновых задач из таблицы jobs, задачи разбираются пачками, неудачные повторяются с растущей задержкой
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// Store, операции очереди, реализуется репозиторием postgres
+type Store interface {
+	ClaimJobs(ctx context.Context, limit int) ([]repo.Job, error)
+	StartJob(ctx context.Context, id int64, attempt int) error
+	CompleteJob(ctx context.Context, id int64, attempt int) error
+	FailJob(ctx context.Context, id int64, attempt int, cause error, retryAt time.Time) error
+}
+
+// Handler, обработчик задачи одного вида, получает сырую полезную нагрузку
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Worker, разбирает очередь и раздает задачи обработчикам по виду
+type Worker struct {
+	Store    Store
+	Interval time.Duration
+	Batch    int
+	// Timeout, предельное время одной задачи, KindTimeouts, свое время для отдельных видов, должно оставаться меньше закрепления задачи в репозитории,
+	// закрепление продлевается перед каждой задачей пачки, так что предел действует на задачу, а не на пачку
+	Timeout      time.Duration
+	KindTimeouts map[string]time.Duration
+
+	handlers map[string]Handler
+}
+
+// New, конструктор обработчика очереди
+func New(s Store, interval time.Duration) *Worker {
+	return &Worker{Store: s, Interval: interval, Batch: 20, Timeout: time.Minute, KindTimeouts: map[string]time.Duration{}, handlers: map[string]Handler{}}
+}
+
+// Register, привязывает обработчик к виду задачи
+func (w *Worker) Register(kind string, h Handler) {
+	w.handlers[kind] = h
+}
+
+// Run, опрашивает очередь до отмены контекста, пока есть задачи разбирает их без паузы
+func (w *Worker) Run(ctx context.Context) {
+	for {
+		n, err := w.RunOnce(ctx)
+		if err != nil {
+			log.Printf("jobs: %v", err)
+		}
+		if n > 0 && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.Interval):
+		}
+	}
+}
+
+// RunOnce, забирает и выполняет одну пачку задач, возвращает их число
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	claimed, err := w.Store.ClaimJobs(ctx, w.Batch)
+	if err != nil {
+		return 0, err
+	}
+	for _, j := range claimed {
+		w.process(ctx, j)
+	}
+	return len(claimed), nil
+}
+
+// process, выполняет задачу и фиксирует результат, неизвестный вид сразу помечается failed, исход записывается только за своей попыткой,
+// если задача пережила закрепление и ее забрал другой экземпляр, этот исход отбрасывается, а пока ждала в пачке, не запускается вовсе
+func (w *Worker) process(ctx context.Context, j repo.Job) {
+	h, ok := w.handlers[j.Kind]
+	if !ok {
+		if err := w.Store.FailJob(ctx, j.ID, j.Attempts, fmt.Errorf("no handler for %q", j.Kind), time.Time{}); err != nil {
+			log.Printf("jobs: fail job %d: %v", j.ID, err)
+		}
+		return
+	}
+
+	if err := w.Store.StartJob(ctx, j.ID, j.Attempts); err != nil {
+		log.Printf("jobs: start job %d: %v", j.ID, err)
+		return
+	}
+
+	timeout := w.Timeout
+	if d, ok := w.KindTimeouts[j.Kind]; ok {
+		timeout = d
+	}
+	jctx, cancel := context.WithTimeout(ctx, timeout)
+	err := h(jctx, j.Payload)
+	cancel()
+
+	if err == nil {
+		if err := w.Store.CompleteJob(ctx, j.ID, j.Attempts); err != nil {
+			log.Printf("jobs: complete job %d: %v", j.ID, err)
+		}
+		return
+	}
+
+	log.Printf("jobs: %s job %d attempt %d: %v", j.Kind, j.ID, j.Attempts, err)
+	if err := w.Store.FailJob(ctx, j.ID, j.Attempts, err, time.Now().Add(Backoff(j.Attempts))); err != nil {
+		log.Printf("jobs: fail job %d: %v", j.ID, err)
+	}
+}
+
+// Backoff, задержка перед следующей попыткой, квадратичный рост от 10 секунд, не больше часа
+func Backoff(attempt int) time.Duration {
+	d := time.Duration(attempt*attempt) * 10 * time.Second
+	if d > time.Hour {
+		d = time.Hour
+	}
+	return d
+}
@@ -0,0 +1,112 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, очередь в памяти, фиксирует исходы задач
+type fakeStore struct {
+	queue     []repo.Job
+	completed []int64
+	failed    map[int64]time.Time
+	// reclaimed, задачи, которые после истечения закрепления забрал другой экземпляр
+	reclaimed map[int64]bool
+}
+
+func (f *fakeStore) ClaimJobs(_ context.Context, limit int) ([]repo.Job, error) {
+	n := min(limit, len(f.queue))
+	out := f.queue[:n]
+	f.queue = f.queue[n:]
+	return out, nil
+}
+
+func (f *fakeStore) StartJob(_ context.Context, id int64, _ int) error {
+	if f.reclaimed[id] {
+		return repo.ErrJobLeaseLost
+	}
+	return nil
+}
+
+func (f *fakeStore) CompleteJob(_ context.Context, id int64, _ int) error {
+	f.completed = append(f.completed, id)
+	return nil
+}
+
+func (f *fakeStore) FailJob(_ context.Context, id int64, _ int, _ error, retryAt time.Time) error {
+	f.failed[id] = retryAt
+	return nil
+}
+
+// TestRunOnce_Outcomes, проверяет успех, повтор с задержкой после ошибки и окончательный отказ для неизвестного вида
+func TestRunOnce_Outcomes(t *testing.T) {
+	st := &fakeStore{
+		queue: []repo.Job{
+			{ID: 1, Kind: "ok", Attempts: 1},
+			{ID: 2, Kind: "flaky", Attempts: 2},
+			{ID: 3, Kind: "unknown", Attempts: 1},
+		},
+		failed: map[int64]time.Time{},
+	}
+	w := New(st, time.Second)
+	w.Register("ok", func(context.Context, json.RawMessage) error { return nil })
+	w.Register("flaky", func(context.Context, json.RawMessage) error { return errors.New("smtp down") })
+
+	n, err := w.RunOnce(context.Background())
+	if err != nil || n != 3 {
+		t.Fatalf("want 3 jobs, got %d, err=%v", n, err)
+	}
+	if len(st.completed) != 1 || st.completed[0] != 1 {
+		t.Fatalf("unexpected completed: %v", st.completed)
+	}
+	if at, ok := st.failed[2]; !ok || time.Until(at) < 30*time.Second {
+		t.Fatalf("flaky job must be retried later, got %v", at)
+	}
+	if at, ok := st.failed[3]; !ok || !at.IsZero() {
+		t.Fatalf("unknown job must fail permanently, got %v", at)
+	}
+}
+
+// TestRunOnce_LeaseExpiredInBatch, задача, чье закрепление истекло, пока она ждала в пачке, и которую забрал другой экземпляр,
+// не запускается и исхода не получает, следующие задачи пачки выполняются
+func TestRunOnce_LeaseExpiredInBatch(t *testing.T) {
+	st := &fakeStore{
+		queue: []repo.Job{
+			{ID: 1, Kind: "slow", Attempts: 1},
+			{ID: 2, Kind: "ok", Attempts: 1},
+			{ID: 3, Kind: "ok", Attempts: 1},
+		},
+		failed:    map[int64]time.Time{},
+		reclaimed: map[int64]bool{},
+	}
+	ran := 0
+	w := New(st, time.Second)
+	w.Register("slow", func(context.Context, json.RawMessage) error {
+		// пока первая задача выполнялась, закрепление второй истекло и ее забрал другой экземпляр
+		st.reclaimed[2] = true
+		ran++
+		return nil
+	})
+	w.Register("ok", func(context.Context, json.RawMessage) error {
+		ran++
+		return nil
+	})
+
+	if _, err := w.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if ran != 2 {
+		t.Fatalf("want 2 handlers run, got %d", ran)
+	}
+	if len(st.completed) != 2 || st.completed[0] != 1 || st.completed[1] != 3 {
+		t.Fatalf("unexpected completed: %v", st.completed)
+	}
+	if _, ok := st.failed[2]; ok {
+		t.Fatal("reclaimed job must not get an outcome")
+	}
+}
@@ -0,0 +1,22 @@
+// Package leader, один исполнитель периодической задачи среди нескольких экземпляров сервиса на одной базе, проход выполняет тот, кто взял блокировку, остальные его пропускают
+package leader
+
+import "context"
+
+// Locker, неблокирующая блокировка по имени, ok false, блокировку держит другой экземпляр, unlock освобождает взятую, реализуется репозиторием postgres
+type Locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Do, выполняет fn под блокировкой name, если ее держит другой экземпляр, проход пропускается и ran false, nil l значит один экземпляр, fn выполняется всегда
+func Do(ctx context.Context, l Locker, name string, fn func(ctx context.Context) error) (ran bool, err error) {
+	if l == nil {
+		return true, fn(ctx)
+	}
+	unlock, ok, err := l.TryLock(ctx, name)
+	if err != nil || !ok {
+		return false, err
+	}
+	defer unlock()
+	return true, fn(ctx)
+}
@@ -0,0 +1,39 @@
+package leader
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeLocker, блокировки в памяти одного процесса
+type fakeLocker map[string]bool
+
+func (l fakeLocker) TryLock(_ context.Context, name string) (func(), bool, error) {
+	if l[name] {
+		return nil, false, nil
+	}
+	l[name] = true
+	return func() { delete(l, name) }, true, nil
+}
+
+// TestDo, проход под взятой другим блокировкой пропускается, своя блокировка отпускается после прохода, без Locker проход выполняется всегда
+func TestDo(t *testing.T) {
+	ctx := context.Background()
+	runs := 0
+	fn := func(context.Context) error { runs++; return nil }
+
+	l := fakeLocker{}
+	if ran, err := Do(ctx, l, "snapshot", fn); !ran || err != nil || l["snapshot"] {
+		t.Fatalf("free lock: ran=%v err=%v held=%v", ran, err, l["snapshot"])
+	}
+	l["snapshot"] = true
+	if ran, err := Do(ctx, l, "snapshot", fn); ran || err != nil {
+		t.Fatalf("held lock: ran=%v err=%v", ran, err)
+	}
+	if ran, err := Do(ctx, nil, "snapshot", fn); !ran || err != nil {
+		t.Fatalf("nil locker: ran=%v err=%v", ran, err)
+	}
+	if runs != 2 {
+		t.Fatalf("want 2 runs, got %d", runs)
+	}
+}
@@ -0,0 +1,157 @@
+package loadgen
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestParse, сценарий с длительностями строками, умолчания, опечатки и противоречия отклоняются
+func TestParse(t *testing.T) {
+	sc, err := Parse(strings.NewReader(`
+base_url: http://localhost:8080
+wallets: [a, b]
+ramp_up: 10s
+duration: 2m
+mix: {send: 1, balance: 3}
+max_p99: 250ms
+`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if sc.RampUp != 10*time.Second || sc.Duration != 2*time.Minute || sc.MaxP99 != 250*time.Millisecond {
+		t.Fatalf("durations: %+v", sc)
+	}
+	if sc.Workers != defaultWorkers || sc.Timeout != defaultTimeout || sc.AmountCents != 1 || sc.ListCount != defaultListCount {
+		t.Fatalf("defaults: %+v", sc)
+	}
+
+	for name, in := range map[string]string{
+		"unknown field":  "base_url: x\nwallets: [a]\nmix: {balance: 1}\nworker: 3\n",
+		"unknown op":     "base_url: x\nwallets: [a]\nmix: {withdraw: 1}\n",
+		"empty mix":      "base_url: x\nwallets: [a]\nmix: {send: 0}\n",
+		"one wallet":     "base_url: x\nwallets: [a]\nmix: {send: 1}\n",
+		"no base url":    "wallets: [a]\nmix: {balance: 1}\n",
+		"ramp too long":  "base_url: x\nwallets: [a]\nmix: {balance: 1}\nramp_up: 2m\nduration: 1m\n",
+		"bad error rate": "base_url: x\nwallets: [a]\nmix: {balance: 1}\nmax_error_rate: 2\n",
+	} {
+		if _, err := Parse(strings.NewReader(in)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+// TestPicker, операции выбираются пропорционально весам, операции с нулевым весом не выбираются
+func TestPicker(t *testing.T) {
+	pick := newPicker(map[string]int{OpSend: 1, OpBalance: 3, OpList: 0})
+	rnd := rand.New(rand.NewSource(1))
+	got := map[string]int{}
+	for range 40000 {
+		got[pick(rnd)]++
+	}
+	if got[OpList] != 0 {
+		t.Fatalf("list picked with zero weight: %v", got)
+	}
+	if r := float64(got[OpBalance]) / float64(got[OpSend]); r < 2.8 || r > 3.2 {
+		t.Fatalf("balance/send ratio %.2f, want about 3: %v", r, got)
+	}
+}
+
+// TestPercentile, ближайший ранг
+func TestPercentile(t *testing.T) {
+	var lat []time.Duration
+	for i := 1; i <= 100; i++ {
+		lat = append(lat, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[int]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond} {
+		if got := percentile(lat, p); got != want {
+			t.Errorf("p%d = %v, want %v", p, got, want)
+		}
+	}
+	if got := percentile(lat[:1], 99); got != time.Millisecond {
+		t.Errorf("single sample p99 = %v", got)
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("empty p50 = %v", got)
+	}
+}
+
+// TestRun, прогон против подставного сервера, все операции смеси доходят, ошибки сервера разбираются по коду и тексту, пороги срабатывают
+func TestRun(t *testing.T) {
+	var sends, balances, lists atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/send":
+			// каждый четвертый перевод без средств
+			if sends.Add(1)%4 == 0 {
+				w.WriteHeader(http.StatusConflict)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "insufficient funds"})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+		case strings.HasSuffix(r.URL.Path, "/balance"):
+			balances.Add(1)
+			_ = json.NewEncoder(w).Encode(map[string]string{"address": "a", "balance": "1.00"})
+		case r.URL.Path == "/api/transactions":
+			lists.Add(1)
+			_, _ = w.Write([]byte("[]"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	sc := Scenario{
+		BaseURL:      srv.URL,
+		Wallets:      []string{"a", "b", "c"},
+		Workers:      4,
+		RampUp:       50 * time.Millisecond,
+		Duration:     300 * time.Millisecond,
+		Mix:          map[string]int{OpSend: 1, OpBalance: 1, OpList: 1},
+		Seed:         7,
+		MaxErrorRate: 0.01,
+	}
+	sc.defaults()
+	if err := sc.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	rep := NewRunner(sc).Run(context.Background())
+
+	if len(rep.Ops) != 3 || sends.Load() == 0 || balances.Load() == 0 || lists.Load() == 0 {
+		t.Fatalf("not all operations ran: %+v", rep.Ops)
+	}
+	for _, or := range rep.Ops {
+		switch or.Op {
+		case OpSend:
+			if or.Errors == 0 || or.ErrorKinds["409 insufficient funds"] != or.Errors {
+				t.Fatalf("send errors: %+v", or)
+			}
+		default:
+			if or.Errors != 0 {
+				t.Fatalf("%s errors: %+v", or.Op, or)
+			}
+		}
+		if or.P50 <= 0 || or.P99 < or.P50 || or.Max < or.P99 {
+			t.Fatalf("%s percentiles: %+v", or.Op, or)
+		}
+	}
+	if rep.Total.Requests != rep.Ops[0].Requests+rep.Ops[1].Requests+rep.Ops[2].Requests {
+		t.Fatalf("total: %+v", rep.Total)
+	}
+	if err := rep.Check(sc); err == nil {
+		t.Fatalf("want threshold error at error rate %.3f", rep.ErrorRate())
+	}
+
+	var out strings.Builder
+	rep.Write(&out)
+	if !strings.Contains(out.String(), "send: ") || !strings.Contains(out.String(), "x 409 insufficient funds") {
+		t.Fatalf("report:\n%s", out.String())
+	}
+}
@@ -0,0 +1,185 @@
+package loadgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gotechtask/pkg/client"
+)
+
+// opStats, результаты одной операции, задержки всех запросов и число ошибок по виду
+type opStats struct {
+	latencies []time.Duration
+	errors    map[string]int
+}
+
+// Recorder, собирает результаты запросов исполнителей, безопасен для конкурентного использования
+type Recorder struct {
+	mu    sync.Mutex
+	stats map[string]*opStats
+}
+
+// NewRecorder, пустой сборщик
+func NewRecorder() *Recorder {
+	return &Recorder{stats: make(map[string]*opStats)}
+}
+
+// Record, учитывает запрос операции op, его задержку и ошибку, nil значит успех
+func (r *Recorder) Record(op string, d time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.stats[op]
+	if s == nil {
+		s = &opStats{errors: make(map[string]int)}
+		r.stats[op] = s
+	}
+	s.latencies = append(s.latencies, d)
+	if err != nil {
+		s.errors[errorKind(err)]++
+	}
+}
+
+// errorKind, вид ошибки для разбора, код и текст ответа сервера, таймаут или сетевая ошибка
+func errorKind(err error) string {
+	var e *client.Error
+	var ne net.Error
+	switch {
+	case errors.As(err, &e):
+		return strconv.Itoa(e.StatusCode) + " " + e.Message
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &ne) && ne.Timeout():
+		return "timeout"
+	default:
+		return "network error"
+	}
+}
+
+// OpReport, итог одной операции
+type OpReport struct {
+	Op       string
+	Requests int
+	Errors   int
+	// ErrorKinds, число ошибок по виду
+	ErrorKinds map[string]int
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// Report, итог прогона по операциям и в целом
+type Report struct {
+	Elapsed time.Duration
+	Ops     []OpReport
+	Total   OpReport
+}
+
+// Report, сводит собранное в отчет, elapsed, фактическая длительность прогона для расчета запросов в секунду
+func (r *Recorder) Report(elapsed time.Duration) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rep := Report{Elapsed: elapsed}
+	var all []time.Duration
+	total := OpReport{Op: "total", ErrorKinds: make(map[string]int)}
+	for _, op := range ops {
+		s := r.stats[op]
+		if s == nil {
+			continue
+		}
+		or := summarize(op, s.latencies, s.errors)
+		rep.Ops = append(rep.Ops, or)
+		all = append(all, s.latencies...)
+		for k, n := range s.errors {
+			total.ErrorKinds[k] += n
+		}
+	}
+	rep.Total = summarize("total", all, total.ErrorKinds)
+	return rep
+}
+
+// summarize, перцентили и счетчики по задержкам одной группы
+func summarize(op string, lat []time.Duration, kinds map[string]int) OpReport {
+	lat = slices.Clone(lat)
+	slices.Sort(lat)
+	or := OpReport{Op: op, Requests: len(lat), ErrorKinds: kinds}
+	for _, n := range kinds {
+		or.Errors += n
+	}
+	or.P50 = percentile(lat, 50)
+	or.P90 = percentile(lat, 90)
+	or.P99 = percentile(lat, 99)
+	if len(lat) > 0 {
+		or.Max = lat[len(lat)-1]
+	}
+	return or
+}
+
+// percentile, перцентиль p по отсортированным задержкам методом ближайшего ранга
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return sorted[i-1]
+}
+
+// ErrorRate, доля ошибочных запросов прогона
+func (rep Report) ErrorRate() float64 {
+	if rep.Total.Requests == 0 {
+		return 0
+	}
+	return float64(rep.Total.Errors) / float64(rep.Total.Requests)
+}
+
+// Check, сверяет прогон с порогами сценария, ошибка перечисляет нарушенные
+func (rep Report) Check(sc Scenario) error {
+	var errs []error
+	if sc.MaxErrorRate > 0 && rep.ErrorRate() > sc.MaxErrorRate {
+		errs = append(errs, fmt.Errorf("error rate %.4f exceeds %.4f", rep.ErrorRate(), sc.MaxErrorRate))
+	}
+	if sc.MaxP99 > 0 && rep.Total.P99 > sc.MaxP99 {
+		errs = append(errs, fmt.Errorf("p99 %v exceeds %v", rep.Total.P99, sc.MaxP99))
+	}
+	return errors.Join(errs...)
+}
+
+// Write, отчет таблицей, строка на операцию и итог, затем ошибки по видам от частых к редким
+func (rep Report) Write(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "op\trequests\terrors\trps\tp50\tp90\tp99\tmax\t")
+	secs := rep.Elapsed.Seconds()
+	for _, or := range append(slices.Clone(rep.Ops), rep.Total) {
+		rps := 0.0
+		if secs > 0 {
+			rps = float64(or.Requests) / secs
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%v\t%v\t%v\t%v\t\n", or.Op, or.Requests, or.Errors, rps,
+			or.P50.Round(time.Microsecond), or.P90.Round(time.Microsecond), or.P99.Round(time.Microsecond), or.Max.Round(time.Microsecond))
+	}
+	_ = tw.Flush()
+
+	for _, or := range rep.Ops {
+		kinds := make([]string, 0, len(or.ErrorKinds))
+		for k := range or.ErrorKinds {
+			kinds = append(kinds, k)
+		}
+		sort.Slice(kinds, func(i, j int) bool {
+			a, b := or.ErrorKinds[kinds[i]], or.ErrorKinds[kinds[j]]
+			return a > b || a == b && kinds[i] < kinds[j]
+		})
+		for _, k := range kinds {
+			fmt.Fprintf(w, "%s: %d x %s\n", or.Op, or.ErrorKinds[k], k)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package loadgen
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"gotechtask/pkg/client"
+)
+
+// Runner, исполняет сценарий, Client, через что идут запросы, Recorder, куда пишутся результаты
+type Runner struct {
+	Scenario Scenario
+	Client   *client.Client
+	Recorder *Recorder
+}
+
+// NewRunner, исполнитель поверх клиента api, повторы и таймаут клиента берутся из сценария
+func NewRunner(sc Scenario) *Runner {
+	c := client.New(sc.BaseURL, sc.APIKey)
+	c.AdminToken = sc.AdminToken
+	c.MaxRetries = sc.Retries
+	c.HTTP = &http.Client{
+		Timeout: sc.Timeout,
+		// транспорт по умолчанию держит два простаивающих соединения на хост, на нагрузке это переоткрытие соединений вместо запросов
+		Transport: &http.Transport{MaxIdleConnsPerHost: sc.Workers, MaxIdleConns: sc.Workers},
+	}
+	return &Runner{Scenario: sc, Client: c, Recorder: NewRecorder()}
+}
+
+// Run, запускает исполнителей равномерно за RampUp и гоняет их до конца Duration или отмены ctx, отдает отчет
+func (r *Runner) Run(ctx context.Context) Report {
+	sc := r.Scenario
+	ctx, cancel := context.WithTimeout(ctx, sc.Duration)
+	defer cancel()
+
+	seed := sc.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	pick := newPicker(sc.Mix)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < sc.Workers; i++ {
+		delay := time.Duration(0)
+		if sc.Workers > 1 {
+			delay = sc.RampUp * time.Duration(i) / time.Duration(sc.Workers-1)
+		}
+		wg.Add(1)
+		go func(rnd *rand.Rand) {
+			defer wg.Done()
+			t := time.NewTimer(delay)
+			defer t.Stop()
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			for ctx.Err() == nil {
+				op := pick(rnd)
+				began := time.Now()
+				err := r.do(ctx, rnd, op)
+				if ctx.Err() != nil {
+					// запрос, прерванный концом прогона, не ошибка сервиса
+					return
+				}
+				r.Recorder.Record(op, time.Since(began), err)
+			}
+		}(rand.New(rand.NewSource(seed + int64(i))))
+	}
+	wg.Wait()
+	return r.Recorder.Report(time.Since(start))
+}
+
+// do, один запрос операции op по случайным кошелькам сценария
+func (r *Runner) do(ctx context.Context, rnd *rand.Rand, op string) error {
+	sc := r.Scenario
+	w := sc.Wallets
+	switch op {
+	case OpSend:
+		i := rnd.Intn(len(w))
+		j := rnd.Intn(len(w) - 1)
+		if j >= i {
+			j++
+		}
+		_, err := r.Client.Send(ctx, client.SendRequest{From: w[i], To: w[j], AmountCents: sc.AmountCents})
+		return err
+	case OpBalance:
+		_, err := r.Client.Balance(ctx, w[rnd.Intn(len(w))])
+		return err
+	default:
+		_, err := r.Client.Transactions(ctx, client.TransactionQuery{Address: w[rnd.Intn(len(w))], Count: sc.ListCount})
+		return err
+	}
+}
+
+// newPicker, выбор операции с вероятностью по весу смеси, порядок операций фиксирован, чтобы прогон с тем же зерном повторялся
+func newPicker(mix map[string]int) func(*rand.Rand) string {
+	var names []string
+	var bounds []int
+	total := 0
+	for _, op := range ops {
+		if w := mix[op]; w > 0 {
+			total += w
+			names = append(names, op)
+			bounds = append(bounds, total)
+		}
+	}
+	return func(rnd *rand.Rand) string {
+		n := rnd.Intn(total)
+		for i, b := range bounds {
+			if n < b {
+				return names[i]
+			}
+		}
+		return names[len(names)-1]
+	}
+}
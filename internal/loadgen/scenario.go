@@ -0,0 +1,147 @@
+// Package loadgen, нагрузочные прогоны api по сценарию, взвешенная смесь операций, плавный выход на нагрузку, отчет с перцентилями задержек и разбором ошибок
+package loadgen
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// операции сценария, перевод, чтение баланса, страница ленты транзакций
+const (
+	OpSend    = "send"
+	OpBalance = "balance"
+	OpList    = "list"
+)
+
+// ops, известные операции в порядке отчета
+var ops = []string{OpSend, OpBalance, OpList}
+
+// Scenario, файл сценария, длительности строками вида 30s или 2m
+type Scenario struct {
+	// BaseURL, адрес сервиса, APIKey или AdminToken, чем авторизуются запросы
+	BaseURL    string `yaml:"base_url"`
+	APIKey     string `yaml:"api_key"`
+	AdminToken string `yaml:"admin_token"`
+	// Wallets, кошельки нагрузки, переводы идут между случайной парой, чтения по случайному
+	Wallets []string `yaml:"wallets"`
+	// Workers, одновременных исполнителей на полной нагрузке, RampUp, за сколько они запускаются равномерно, Duration, длительность прогона вместе с RampUp
+	Workers  int           `yaml:"workers"`
+	RampUp   time.Duration `yaml:"ramp_up"`
+	Duration time.Duration `yaml:"duration"`
+	// Timeout, предел одного запроса, Retries, повторы клиента, по умолчанию ноль, чтобы задержки были честными
+	Timeout time.Duration `yaml:"timeout"`
+	Retries int           `yaml:"retries"`
+	// Mix, веса операций, например send: 20, balance: 70, list: 10
+	Mix map[string]int `yaml:"mix"`
+	// AmountCents, сумма перевода, ListCount, размер страницы ленты
+	AmountCents int64 `yaml:"amount_cents"`
+	ListCount   int   `yaml:"list_count"`
+	// Seed, зерно выбора операций и кошельков, ноль берет текущее время
+	Seed int64 `yaml:"seed"`
+	// MaxErrorRate и MaxP99, пороги прогона, превышение дает ненулевой код выхода, нулевые не проверяются
+	MaxErrorRate float64       `yaml:"max_error_rate"`
+	MaxP99       time.Duration `yaml:"max_p99"`
+}
+
+// значения сценария по умолчанию
+const (
+	defaultWorkers     = 10
+	defaultDuration    = time.Minute
+	defaultTimeout     = 10 * time.Second
+	defaultAmountCents = 1
+	defaultListCount   = 10
+)
+
+// Load, читает и проверяет сценарий из файла
+func Load(path string) (Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse, разбирает сценарий, неизвестные поля отклоняются, чтобы опечатка в имени не давала молча другой прогон
+func Parse(r io.Reader) (Scenario, error) {
+	var sc Scenario
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&sc); err != nil {
+		return Scenario{}, fmt.Errorf("scenario: %w", err)
+	}
+	sc.defaults()
+	if err := sc.Validate(); err != nil {
+		return Scenario{}, err
+	}
+	return sc, nil
+}
+
+// defaults, заполняет незаданные поля
+func (sc *Scenario) defaults() {
+	if sc.Workers == 0 {
+		sc.Workers = defaultWorkers
+	}
+	if sc.Duration == 0 {
+		sc.Duration = defaultDuration
+	}
+	if sc.Timeout == 0 {
+		sc.Timeout = defaultTimeout
+	}
+	if sc.AmountCents == 0 {
+		sc.AmountCents = defaultAmountCents
+	}
+	if sc.ListCount == 0 {
+		sc.ListCount = defaultListCount
+	}
+}
+
+// Validate, проверяет согласованность сценария
+func (sc Scenario) Validate() error {
+	if sc.BaseURL == "" {
+		return errors.New("scenario: base_url required")
+	}
+	if sc.Workers < 0 || sc.Retries < 0 || sc.AmountCents < 0 || sc.ListCount < 0 || sc.ListCount > 100 {
+		return errors.New("scenario: workers, retries, amount_cents and list_count must be >= 0, list_count at most 100")
+	}
+	if sc.RampUp < 0 || sc.Duration <= sc.RampUp {
+		return errors.New("scenario: duration must exceed ramp_up")
+	}
+	if sc.MaxErrorRate < 0 || sc.MaxErrorRate > 1 {
+		return errors.New("scenario: max_error_rate must be within [0, 1]")
+	}
+	total := 0
+	for op, w := range sc.Mix {
+		if !knownOp(op) {
+			return fmt.Errorf("scenario: unknown operation %q in mix", op)
+		}
+		if w < 0 {
+			return fmt.Errorf("scenario: negative weight for %s", op)
+		}
+		total += w
+	}
+	if total == 0 {
+		return errors.New("scenario: mix must have a positive weight")
+	}
+	if len(sc.Wallets) == 0 {
+		return errors.New("scenario: wallets required")
+	}
+	if sc.Mix[OpSend] > 0 && len(sc.Wallets) < 2 {
+		return errors.New("scenario: send needs at least two wallets")
+	}
+	return nil
+}
+
+func knownOp(op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
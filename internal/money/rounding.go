@@ -0,0 +1,119 @@
+// Package money, явные правила округления сумм в центах, суммы из запросов и комиссии округляются только здесь
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Rounding, политика округления до целого цента
+type Rounding int
+
+const (
+	// HalfEven, банковское округление, половина к четному, не дает систематического сдвига на больших объемах
+	HalfEven Rounding = iota
+	// HalfUp, половина от нуля, как принято считать на бумаге
+	HalfUp
+	// Truncate, отбрасывание дробной части к нулю
+	Truncate
+)
+
+// String, имя политики для логов и конфигурации
+func (r Rounding) String() string {
+	switch r {
+	case HalfEven:
+		return "half_even"
+	case HalfUp:
+		return "half_up"
+	case Truncate:
+		return "truncate"
+	}
+	return "rounding(" + strconv.Itoa(int(r)) + ")"
+}
+
+// MaxCents, наибольшая сумма и наибольший баланс в центах, десять триллионов в валюте, с запасом до предела int64, сумма двух допустимых значений не переполняется
+const MaxCents int64 = 1_000_000_000_000_000
+
+// ErrOverflow, результат за пределами MaxCents
+var ErrOverflow = errors.New("money: amount out of range")
+
+// bpsDenominator, базисных пунктов в единице
+const bpsDenominator = 10000
+
+// Cents, сумма в валюте в центы по ее десятичной записи, а не через f*100, знаки после второго округляются по политике
+func Cents(amount float64, r Rounding) int64 {
+	s := strconv.FormatFloat(amount, 'f', -1, 64)
+	neg := strings.HasPrefix(s, "-")
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	frac += "00"
+	n, err := strconv.ParseInt(whole+frac[:2], 10, 64)
+	if err != nil {
+		// за пределами int64, ParseInt уже отдал предел, округлять некуда
+		if neg {
+			return -n
+		}
+		return n
+	}
+	if rest := strings.TrimRight(frac[2:], "0"); rest != "" {
+		var up bool
+		switch r {
+		case HalfUp:
+			up = rest[0] >= '5'
+		case HalfEven:
+			up = rest[0] > '5' || rest[0] == '5' && (len(rest) > 1 || n%2 == 1)
+		}
+		if up {
+			n++
+		}
+	}
+	if neg {
+		n = -n
+	}
+	return n
+}
+
+// InRange, сумма по модулю не больше MaxCents
+func InRange(cents int64) bool {
+	return cents >= -MaxCents && cents <= MaxCents
+}
+
+// Add, сумма двух значений в пределах MaxCents, иначе ErrOverflow вместо заворота через предел int64
+func Add(a, b int64) (int64, error) {
+	if !InRange(a) || !InRange(b) || !InRange(a+b) {
+		return 0, ErrOverflow
+	}
+	return a + b, nil
+}
+
+// MulDiv, v*num/den с округлением результата по политике, промежуточное произведение не переполняется
+func MulDiv(v, num, den int64, r Rounding) int64 {
+	if den == 0 {
+		panic("money: zero denominator")
+	}
+	p := new(big.Int).Mul(big.NewInt(v), big.NewInt(num))
+	d := big.NewInt(den)
+	q, rem := new(big.Int).QuoRem(p, d, new(big.Int))
+	if rem.Sign() != 0 {
+		// сравниваем остаток с половиной делителя, 2|rem| против |den|
+		twice := new(big.Int).Abs(rem)
+		cmp := twice.Lsh(twice, 1).Cmp(new(big.Int).Abs(d))
+		var away bool
+		switch r {
+		case HalfUp:
+			away = cmp >= 0
+		case HalfEven:
+			away = cmp > 0 || cmp == 0 && q.Bit(0) == 1
+		}
+		if away {
+			q.Add(q, big.NewInt(int64(p.Sign()*d.Sign())))
+		}
+	}
+	return q.Int64()
+}
+
+// Fee, комиссия в базисных пунктах от суммы, 25 bps это 0.25%
+func Fee(amountCents, bps int64, r Rounding) int64 {
+	return MulDiv(amountCents, bps, bpsDenominator, r)
+}
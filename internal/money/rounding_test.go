@@ -0,0 +1,118 @@
+package money
+
+import "testing"
+
+// TestCents, сумма из запроса в центы, третий знак и дальше округляются по политике, ошибка float не съедает цент
+func TestCents(t *testing.T) {
+	cases := []struct {
+		in   float64
+		r    Rounding
+		want int64
+	}{
+		{0.29, Truncate, 29},
+		{0.57, HalfEven, 57},
+		{1.13, HalfUp, 113},
+		{12345678.91, HalfEven, 1234567891},
+		{0.015, Truncate, 1},
+		{0.015, HalfUp, 2},
+		{0.015, HalfEven, 2},
+		{0.025, HalfEven, 2},
+		{0.025, HalfUp, 3},
+		{0.0251, HalfEven, 3},
+		{0.004, HalfUp, 0},
+		{-0.015, HalfUp, -2},
+		{-0.025, HalfEven, -2},
+		{-0.019, Truncate, -1},
+	}
+	for _, c := range cases {
+		if got := Cents(c.in, c.r); got != c.want {
+			t.Errorf("Cents(%v, %v) = %d, want %d", c.in, c.r, got, c.want)
+		}
+	}
+}
+
+// TestMulDiv, деление с остатком ровно в половину и около нее, оба знака
+func TestMulDiv(t *testing.T) {
+	cases := []struct {
+		v, num, den int64
+		r           Rounding
+		want        int64
+	}{
+		{5, 1, 2, HalfEven, 2},
+		{7, 1, 2, HalfEven, 4},
+		{5, 1, 2, HalfUp, 3},
+		{5, 1, 2, Truncate, 2},
+		{-5, 1, 2, HalfEven, -2},
+		{-5, 1, 2, HalfUp, -3},
+		{-7, 1, 2, Truncate, -3},
+		{10, 1, 3, HalfUp, 3},
+		{20, 1, 3, HalfEven, 7},
+		{20, 1, 3, Truncate, 6},
+		{6, 4, 1, HalfEven, 24},
+		// произведение за пределами int64, результат в них
+		{1 << 62, 1 << 20, 1 << 21, HalfEven, 1 << 61},
+	}
+	for _, c := range cases {
+		if got := MulDiv(c.v, c.num, c.den, c.r); got != c.want {
+			t.Errorf("MulDiv(%d, %d, %d, %v) = %d, want %d", c.v, c.num, c.den, c.r, got, c.want)
+		}
+	}
+}
+
+// TestFee, комиссия в базисных пунктах
+func TestFee(t *testing.T) {
+	cases := []struct {
+		amount, bps int64
+		r           Rounding
+		want        int64
+	}{
+		{10000, 25, HalfEven, 25},
+		{200, 25, HalfEven, 0},  // 0.5 цента к четному нулю
+		{600, 25, HalfEven, 2},  // 1.5 к двум
+		{200, 25, HalfUp, 1},    // 0.5 от нуля
+		{399, 25, Truncate, 0},  // 0.9975 отбрасывается
+		{1999, 150, HalfUp, 30}, // 29.985
+	}
+	for _, c := range cases {
+		if got := Fee(c.amount, c.bps, c.r); got != c.want {
+			t.Errorf("Fee(%d, %d, %v) = %d, want %d", c.amount, c.bps, c.r, got, c.want)
+		}
+	}
+}
+
+// TestAdd, сумма за пределами MaxCents дает ErrOverflow, а не заворачивается
+func TestAdd(t *testing.T) {
+	cases := []struct {
+		a, b int64
+		want int64
+		err  error
+	}{
+		{1, 2, 3, nil},
+		{MaxCents - 1, 1, MaxCents, nil},
+		{MaxCents, 1, 0, ErrOverflow},
+		{-MaxCents, -1, 0, ErrOverflow},
+		{1<<63 - 1, 1, 0, ErrOverflow},
+		{MaxCents, -MaxCents, 0, nil},
+	}
+	for _, c := range cases {
+		got, err := Add(c.a, c.b)
+		if got != c.want || err != c.err {
+			t.Errorf("Add(%d, %d) = %d, %v, want %d, %v", c.a, c.b, got, err, c.want, c.err)
+		}
+	}
+}
+
+// TestCents_OutOfRange, абсурдная сумма не заворачивается в отрицательную и не округляется через предел int64
+func TestCents_OutOfRange(t *testing.T) {
+	for _, r := range []Rounding{HalfEven, HalfUp, Truncate} {
+		if got := Cents(1e30, r); got <= MaxCents {
+			t.Errorf("Cents(1e30, %v) = %d, want above MaxCents", r, got)
+		}
+		if got := Cents(-1e30, r); got >= -MaxCents {
+			t.Errorf("Cents(-1e30, %v) = %d, want below -MaxCents", r, got)
+		}
+		if got := Cents(92233720368547758.079, r); got <= MaxCents {
+			t.Errorf("Cents(near max int64, %v) = %d, want above MaxCents", r, got)
+		}
+	}
+}
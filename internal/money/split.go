@@ -0,0 +1,60 @@
+package money
+
+import "errors"
+
+// Remainder, кому достается остаток, который не делится по долям без дробных центов
+type Remainder string
+
+const (
+	// RemainderLargest, остаток получает наибольшая доля, при равных первая из них
+	RemainderLargest Remainder = "largest"
+	// RemainderFirst, остаток получает первая доля
+	RemainderFirst Remainder = "first"
+	// RemainderLast, остаток получает последняя доля
+	RemainderLast Remainder = "last"
+)
+
+// ErrWeights, веса долей пустые или не положительные
+var ErrWeights = errors.New("money: invalid split weights")
+
+// ValidRemainder, правило из списка известных
+func ValidRemainder(r Remainder) bool {
+	return r == RemainderLargest || r == RemainderFirst || r == RemainderLast
+}
+
+// Split, делит totalCents по весам, каждая доля округляется вниз до цента, остаток целиком уходит одной доле по правилу rule, сумма долей всегда равна totalCents
+func Split(totalCents int64, weights []int64, rule Remainder) ([]int64, error) {
+	if len(weights) == 0 {
+		return nil, ErrWeights
+	}
+	var sum int64
+	for _, w := range weights {
+		if w <= 0 {
+			return nil, ErrWeights
+		}
+		if sum, _ = Add(sum, w); sum > MaxCents {
+			return nil, ErrWeights
+		}
+	}
+
+	out := make([]int64, len(weights))
+	rest := totalCents
+	for i, w := range weights {
+		out[i] = MulDiv(totalCents, w, sum, Truncate)
+		rest -= out[i]
+	}
+
+	idx := 0
+	switch rule {
+	case RemainderLast:
+		idx = len(out) - 1
+	case RemainderLargest:
+		for i, w := range weights {
+			if w > weights[idx] {
+				idx = i
+			}
+		}
+	}
+	out[idx] += rest
+	return out, nil
+}
@@ -0,0 +1,39 @@
+package money
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSplit, доли в сумме дают исходную сумму, остаток уходит доле по правилу
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		total   int64
+		weights []int64
+		rule    Remainder
+		want    []int64
+	}{
+		{100, []int64{1, 1, 1}, RemainderFirst, []int64{34, 33, 33}},
+		{100, []int64{1, 1, 1}, RemainderLast, []int64{33, 33, 34}},
+		{100, []int64{1, 1, 1}, RemainderLargest, []int64{34, 33, 33}},
+		{1000, []int64{2500, 2500, 5000}, RemainderLargest, []int64{250, 250, 500}},
+		{1001, []int64{3333, 3333, 3334}, RemainderLargest, []int64{333, 333, 335}},
+		{5, []int64{1, 1, 1, 1, 1, 1, 1}, RemainderLast, []int64{0, 0, 0, 0, 0, 0, 5}},
+		{MaxCents, []int64{1, 2}, RemainderFirst, []int64{333_333_333_333_334, 666_666_666_666_666}},
+	}
+	for _, c := range cases {
+		got, err := Split(c.total, c.weights, c.rule)
+		if err != nil {
+			t.Fatalf("Split(%d, %v, %s): %v", c.total, c.weights, c.rule, err)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Split(%d, %v, %s) = %v, want %v", c.total, c.weights, c.rule, got, c.want)
+		}
+	}
+
+	for _, w := range [][]int64{nil, {1, 0}, {5, -1}} {
+		if _, err := Split(100, w, RemainderFirst); err != ErrWeights {
+			t.Errorf("Split(100, %v): want ErrWeights, got %v", w, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// KindTransferConfirmation, вид фоновой задачи письма со ссылкой подтверждения крупного перевода
+const KindTransferConfirmation = "transfer_confirmation"
+
+// Confirmation, полезная нагрузка задачи подтверждения, адрес почты, ссылка, параметры перевода и срок действия ссылки
+type Confirmation struct {
+	Email       string    `json:"email"`
+	Link        string    `json:"link"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	AmountCents int64     `json:"amount_cents"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// ConfirmationHandler, обработчик задачи подтверждения, отправляет письмо со ссылкой, просроченную ссылку не отправляет
+func ConfirmationHandler(n Notifier) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var c Confirmation
+		if err := json.Unmarshal(payload, &c); err != nil {
+			return err
+		}
+		if !time.Now().Before(c.ExpiresAt) {
+			return nil
+		}
+		amount := fmt.Sprintf("%d.%02d", c.AmountCents/100, c.AmountCents%100)
+		return n.Send(ctx, Message{
+			To:      c.Email,
+			Subject: "Подтвердите перевод",
+			Body: fmt.Sprintf("Запрошен перевод %s с кошелька %s на %s. Чтобы выполнить его, откройте ссылку до %s UTC:\n%s\nЕсли вы не делали этот перевод, не открывайте ссылку и отзовите ключи доступа.",
+				amount, c.From, c.To, c.ExpiresAt.UTC().Format(time.RFC3339), c.Link),
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// KindLowBalance, вид фоновой задачи письма о низком балансе кошелька
+const KindLowBalance = "low_balance"
+
+// LowBalance, полезная нагрузка задачи низкого баланса, адрес, баланс после перевода, порог, время перевода
+type LowBalance struct {
+	Address        string    `json:"address"`
+	BalanceCents   int64     `json:"balance_cents"`
+	ThresholdCents int64     `json:"threshold_cents"`
+	At             time.Time `json:"at"`
+}
+
+// LowBalanceHandler, обработчик задачи низкого баланса, пишет на почту кошелька если она задана
+func LowBalanceHandler(emails EmailLookup, n Notifier) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var lb LowBalance
+		if err := json.Unmarshal(payload, &lb); err != nil {
+			return err
+		}
+		email, err := emails.WalletEmail(ctx, lb.Address)
+		if err != nil {
+			return err
+		}
+		if email == "" {
+			return nil
+		}
+		return n.Send(ctx, Message{
+			To:      email,
+			Subject: "Низкий баланс кошелька",
+			Body: fmt.Sprintf("Баланс кошелька %s опустился до %s, ниже порога %s, %s UTC.",
+				lb.Address, formatCents(lb.BalanceCents), formatCents(lb.ThresholdCents), lb.At.UTC().Format(time.RFC3339)),
+		})
+	}
+}
+
+// formatCents, сумма в центах строкой с двумя знаками, баланс кошелька с овердрафтом бывает отрицательным
+func formatCents(c int64) string {
+	sign := ""
+	if c < 0 {
+		sign, c = "-", -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
@@ -0,0 +1,61 @@
+// Package notify, отправка уведомлений пользователям, транспорты smtp, sendgrid и заглушка noop, письма уходят из фоновых задач, а не из пути перевода
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// Message, письмо, получатель, тема и текст
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier, транспорт отправки писем
+type Notifier interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// Config, настройки транспорта, Backend один из smtp, sendgrid, noop
+type Config struct {
+	Backend string
+	From    string
+
+	SMTPAddr     string
+	SMTPUser     string
+	SMTPPassword string
+
+	SendGridAPIKey string
+}
+
+// New, создает транспорт по конфигурации, пустой Backend означает noop
+func New(c Config) (Notifier, error) {
+	switch c.Backend {
+	case "", "noop":
+		return Noop{}, nil
+	case "smtp":
+		if c.SMTPAddr == "" || c.From == "" {
+			return nil, fmt.Errorf("smtp notifier requires SMTP_ADDR and NOTIFY_FROM")
+		}
+		return &SMTP{Addr: c.SMTPAddr, User: c.SMTPUser, Password: c.SMTPPassword, From: c.From}, nil
+	case "sendgrid":
+		if c.SendGridAPIKey == "" || c.From == "" {
+			return nil, fmt.Errorf("sendgrid notifier requires SENDGRID_API_KEY and NOTIFY_FROM")
+		}
+		return NewSendGrid(c.SendGridAPIKey, c.From), nil
+	default:
+		return nil, fmt.Errorf("unknown notify backend %q", c.Backend)
+	}
+}
+
+// Noop, транспорт заглушка, только пишет в лог
+type Noop struct{}
+
+// Send, логирует письмо вместо отправки
+func (Noop) Send(_ context.Context, m Message) error {
+	log.Printf("notify noop: to=%s subject=%q", m.To, m.Subject)
+	return nil
+}
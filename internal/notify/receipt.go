@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// KindTransferReceipt, вид фоновой задачи отправки квитанции о переводе
+const KindTransferReceipt = "transfer_receipt"
+
+// стороны перевода, на каждую ставится своя задача, чтобы повтор после сбоя не дублировал уже отправленное письмо
+const (
+	SideSender    = "sender"
+	SideRecipient = "recipient"
+)
+
+// Receipt, полезная нагрузка задачи квитанции, сторона перевода, адреса, сумма, время
+type Receipt struct {
+	Side        string    `json:"side"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	AmountCents int64     `json:"amount_cents"`
+	At          time.Time `json:"at"`
+}
+
+// Receipts, пара задач квитанций для отправителя и получателя
+func Receipts(from, to string, amountCents int64, at time.Time) []Receipt {
+	return []Receipt{
+		{Side: SideSender, From: from, To: to, AmountCents: amountCents, At: at},
+		{Side: SideRecipient, From: from, To: to, AmountCents: amountCents, At: at},
+	}
+}
+
+// EmailLookup, поиск адреса почты кошелька
+type EmailLookup interface {
+	WalletEmail(ctx context.Context, address string) (string, error)
+}
+
+// ReceiptHandler, обработчик задачи квитанции, пишет своей стороне перевода если у ее кошелька задана почта
+func ReceiptHandler(emails EmailLookup, n Notifier) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var rc Receipt
+		if err := json.Unmarshal(payload, &rc); err != nil {
+			return err
+		}
+		amount := fmt.Sprintf("%d.%02d", rc.AmountCents/100, rc.AmountCents%100)
+		at := rc.At.UTC().Format(time.RFC3339)
+
+		var m Message
+		var addr string
+		switch rc.Side {
+		case SideSender:
+			addr = rc.From
+			m.Subject = "Перевод отправлен"
+			m.Body = fmt.Sprintf("С кошелька %s отправлено %s на %s, %s UTC.", rc.From, amount, rc.To, at)
+		case SideRecipient:
+			addr = rc.To
+			m.Subject = "Перевод получен"
+			m.Body = fmt.Sprintf("На кошелек %s поступило %s от %s, %s UTC.", rc.To, amount, rc.From, at)
+		default:
+			return fmt.Errorf("unknown receipt side %q", rc.Side)
+		}
+
+		email, err := emails.WalletEmail(ctx, addr)
+		if err != nil {
+			return err
+		}
+		if email == "" {
+			return nil
+		}
+		m.To = email
+		return n.Send(ctx, m)
+	}
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// sendGridURL, адрес api отправки писем sendgrid v3
+const sendGridURL = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGrid, транспорт через http api sendgrid
+type SendGrid struct {
+	APIKey string
+	From   string
+	URL    string
+	Client *http.Client
+}
+
+// NewSendGrid, конструктор транспорта sendgrid
+func NewSendGrid(apiKey, from string) *SendGrid {
+	return &SendGrid{APIKey: apiKey, From: from, URL: sendGridURL, Client: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// Send, отправляет письмо, любой ответ кроме 2xx считается ошибкой, задача будет повторена
+func (s *SendGrid) Send(ctx context.Context, m Message) error {
+	type addr struct {
+		Email string `json:"email"`
+	}
+	body, err := json.Marshal(map[string]any{
+		"personalizations": []map[string]any{{"to": []addr{{Email: m.To}}}},
+		"from":             addr{Email: s.From},
+		"subject":          m.Subject,
+		"content":          []map[string]string{{"type": "text/plain", "value": m.Body}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sendgrid: status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// KindTOTPEnrollment, вид фоновой задачи письма с токеном подключения totp
+const KindTOTPEnrollment = "totp_enrollment"
+
+// TOTPEnrollment, полезная нагрузка задачи подключения totp, адрес почты, токен и срок его действия
+type TOTPEnrollment struct {
+	Email     string    `json:"email"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TOTPEnrollmentHandler, обработчик задачи подключения totp, отправляет токен на почту, просроченный не отправляет
+func TOTPEnrollmentHandler(n Notifier) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var e TOTPEnrollment
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return err
+		}
+		if !time.Now().Before(e.ExpiresAt) {
+			return nil
+		}
+		return n.Send(ctx, Message{
+			To:      e.Email,
+			Subject: "Подключение приложения аутентификатора",
+			Body: fmt.Sprintf("Запрошено подключение приложения аутентификатора вместо подтверждения переводов по почте. Чтобы завершить его, передайте вместе с кодом из приложения токен до %s UTC:\n%s\nЕсли вы не подключали приложение, не передавайте токен и отзовите ключи доступа.",
+				e.ExpiresAt.UTC().Format(time.RFC3339), e.Token),
+		})
+	}
+}
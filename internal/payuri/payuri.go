@@ -0,0 +1,139 @@
+// Package payuri, ссылка на оплату в схеме wallet:, единый формат для qr кодов и мобильных клиентов
+//
+//	wallet:<address>[?amount=<сумма>&memo=<комментарий>]
+//
+// address, 64 шестнадцатеричных символа, amount, положительная сумма не больше чем с двумя знаками после точки и не больше money.MaxCents, memo, до 256 байт
+package payuri
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gotechtask/internal/address"
+	"gotechtask/internal/money"
+)
+
+// Scheme, схема ссылки
+const Scheme = "wallet"
+
+// MaxMemo, предельная длина комментария в байтах
+const MaxMemo = 256
+
+// ошибки разбора ссылки
+var (
+	ErrScheme  = errors.New("payuri: scheme must be wallet")
+	ErrAddress = errors.New("payuri: invalid address")
+	ErrAmount  = errors.New("payuri: invalid amount")
+	ErrMemo    = errors.New("payuri: memo too long")
+	ErrParam   = errors.New("payuri: unknown or repeated parameter")
+)
+
+// URI, разобранная ссылка, нулевая сумма и пустой комментарий значат что плательщик задает их сам
+type URI struct {
+	Address     string
+	AmountCents int64
+	Memo        string
+}
+
+// Parse, разбирает и проверяет ссылку, адрес в смешанном регистре должен совпасть с контрольной суммой, приводится к нижнему регистру,
+// неизвестные параметры отклоняются, чтобы клиенты не расходились в толковании
+func Parse(s string) (URI, error) {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || !strings.EqualFold(u.Scheme, Scheme) {
+		return URI{}, ErrScheme
+	}
+	// wallet://<address> не каноничная форма, но встречается у клиентов, принимаем и ее
+	addr := u.Opaque
+	if addr == "" && u.Host != "" && (u.Path == "" || u.Path == "/") {
+		addr = u.Host
+	}
+	canon, err := address.Parse(addr, false)
+	if err != nil {
+		return URI{}, ErrAddress
+	}
+	out := URI{Address: canon}
+
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return URI{}, ErrParam
+	}
+	for k, vs := range q {
+		if len(vs) != 1 {
+			return URI{}, ErrParam
+		}
+		switch k {
+		case "amount":
+			if out.AmountCents, err = ParseAmount(vs[0]); err != nil {
+				return URI{}, err
+			}
+		case "memo":
+			if len(vs[0]) > MaxMemo {
+				return URI{}, ErrMemo
+			}
+			out.Memo = vs[0]
+		default:
+			return URI{}, ErrParam
+		}
+	}
+	return out, nil
+}
+
+// String, каноничная форма ссылки, параметры в алфавитном порядке, сумма с двумя знаками
+func (u URI) String() string {
+	v := url.Values{}
+	if u.AmountCents > 0 {
+		v.Set("amount", FormatAmount(u.AmountCents))
+	}
+	if u.Memo != "" {
+		v.Set("memo", u.Memo)
+	}
+	s := Scheme + ":" + u.Address
+	if len(v) > 0 {
+		s += "?" + v.Encode()
+	}
+	return s
+}
+
+// ValidAddress, адрес кошелька, 64 шестнадцатеричных символа, контрольная сумма регистра не проверяется
+func ValidAddress(s string) bool {
+	return address.Valid(s)
+}
+
+// ParseAmount, сумма из десятичной строки в центы без float, больше двух знаков после точки считается ошибкой
+func ParseAmount(s string) (int64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if !digits(whole) || len(frac) > 2 || (frac != "" && !digits(frac)) {
+		return 0, ErrAmount
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || w > money.MaxCents/100 {
+		return 0, ErrAmount
+	}
+	f, _ := strconv.ParseInt((frac + "00")[:2], 10, 64)
+	cents := w*100 + f
+	if cents <= 0 || cents > money.MaxCents {
+		return 0, ErrAmount
+	}
+	return cents, nil
+}
+
+// digits, непустая строка из цифр
+func digits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatAmount, центы в строку с двумя знаками после точки
+func FormatAmount(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
@@ -0,0 +1,57 @@
+package payuri
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParse_RoundTrip, разбор и каноничная форма, адрес в нижнем регистре, сумма с двумя знаками
+func TestParse_RoundTrip(t *testing.T) {
+	addr := strings.Repeat("ab", 32)
+	cases := []struct {
+		in   string
+		want URI
+		out  string
+	}{
+		{"wallet:" + addr, URI{Address: addr}, "wallet:" + addr},
+		{"WALLET:" + strings.ToUpper(addr) + "?amount=12.5", URI{Address: addr, AmountCents: 1250}, "wallet:" + addr + "?amount=12.50"},
+		{"wallet://" + addr + "?memo=coffee+%26+cake&amount=3", URI{Address: addr, AmountCents: 300, Memo: "coffee & cake"}, "wallet:" + addr + "?amount=3.00&memo=coffee+%26+cake"},
+	}
+	for _, c := range cases {
+		got, err := Parse(c.in)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", c.in, got, c.want)
+		}
+		if s := got.String(); s != c.out {
+			t.Errorf("String() = %q, want %q", s, c.out)
+		}
+	}
+}
+
+// TestParse_Invalid, ошибки разбора
+func TestParse_Invalid(t *testing.T) {
+	addr := strings.Repeat("0f", 32)
+	cases := map[string]error{
+		"bitcoin:" + addr:                                            ErrScheme,
+		"wallet:" + addr[:63]:                                        ErrAddress,
+		"wallet:" + strings.Repeat("zz", 32):                         ErrAddress,
+		"wallet:0F" + addr[2:]:                                       ErrAddress,
+		"wallet:" + addr + "?amount=1.234":                           ErrAmount,
+		"wallet:" + addr + "?amount=-1":                              ErrAmount,
+		"wallet:" + addr + "?amount=0":                               ErrAmount,
+		"wallet:" + addr + "?amount=1e3":                             ErrAmount,
+		"wallet:" + addr + "?amount=10000000000000.01":               ErrAmount,
+		"wallet:" + addr + "?amount=99999999999999999999":            ErrAmount,
+		"wallet:" + addr + "?label=x":                                ErrParam,
+		"wallet:" + addr + "?amount=1&amount=2":                      ErrParam,
+		"wallet:" + addr + "?memo=" + strings.Repeat("m", MaxMemo+1): ErrMemo,
+	}
+	for in, want := range cases {
+		if _, err := Parse(in); err != want {
+			t.Errorf("Parse(%q) error = %v, want %v", in, err, want)
+		}
+	}
+}
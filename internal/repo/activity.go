@@ -0,0 +1,168 @@
+package repo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// виды событий ленты активности кошелька, перевод, операция казны или служебная проводка, перевод, ждущий второго фактора,
+// смена состояния кошелька администратором, смена лимитов кошелька
+const (
+	ActivityTransfer        = "transfer"
+	ActivityAdjustment      = "adjustment"
+	ActivityPendingTransfer = "pending_transfer"
+	ActivityStatus          = "status"
+	ActivityLimit           = "limit"
+)
+
+// ActivityKinds, все виды событий ленты
+var ActivityKinds = []string{ActivityTransfer, ActivityAdjustment, ActivityPendingTransfer, ActivityStatus, ActivityLimit}
+
+// activityAudit, действия журнала аудита, которые попадают в ленту, и их вид
+var activityAudit = map[string]string{
+	AuditDenylistAdd:      ActivityStatus,
+	AuditDenylistRemove:   ActivityStatus,
+	AuditWalletHot:        ActivityStatus,
+	AuditWalletOverdraft:  ActivityLimit,
+	AuditWalletLowBalance: ActivityLimit,
+}
+
+// ActivityItem, событие ленты кошелька, Kind, вид события, ID, идентификатор в источнике, транзакции, отложенного перевода или записи аудита,
+// Action, уточнение вида, тип операции, статус отложенного перевода или действие аудита, AmountCents со знаком, списание отрицательное
+type ActivityItem struct {
+	Kind         string
+	ID           int64
+	Action       string
+	Counterparty string
+	AmountCents  int64
+	Actor        string
+	Details      map[string]any
+	CreatedAt    time.Time
+}
+
+// ActivityQuery, выборка ленты, Kinds, только эти виды, пустой без фильтра, Cursor, продолжение из NextCursor, Limit по умолчанию 50, максимум 200
+type ActivityQuery struct {
+	Kinds  []string
+	Cursor string
+	Limit  int
+}
+
+// limit, размер страницы с умолчанием и пределом
+func (q ActivityQuery) limit() int {
+	if q.Limit <= 0 {
+		return 50
+	}
+	return min(q.Limit, 200)
+}
+
+// NextCursor, курсор страницы после items, пустой если страница неполная и продолжения нет
+func (q ActivityQuery) NextCursor(items []ActivityItem) string {
+	if len(items) == 0 || len(items) < q.limit() {
+		return ""
+	}
+	last := items[len(items)-1]
+	return base64.RawURLEncoding.EncodeToString([]byte(last.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + last.Kind + "|" + strconv.FormatInt(last.ID, 10)))
+}
+
+// decodeActivityCursor, время, вид и id последнего события страницы
+func decodeActivityCursor(c string) (time.Time, string, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(c)
+	if err != nil {
+		return time.Time{}, "", 0, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return time.Time{}, "", 0, ErrInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return time.Time{}, "", 0, ErrInvalidCursor
+	}
+	return at, parts[1], id, nil
+}
+
+// WalletActivity, лента кошелька, переводы и операции из журнала, отложенные переводы с него, смены состояния и лимитов из аудита,
+// новые первыми, при равном времени порядок по виду и id, так курсор не теряет и не повторяет события,
+// каждый источник читается по своему индексу уже с условием курсора и пределом, общий порядок собирается из их начал
+func (r *PostgresRepo) WalletActivity(ctx context.Context, address string, q ActivityQuery) ([]ActivityItem, error) {
+	var kinds any
+	if len(q.Kinds) > 0 {
+		kinds = q.Kinds
+	}
+	var at any
+	var kind string
+	var id int64
+	if q.Cursor != "" {
+		t, k, i, err := decodeActivityCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		at, kind, id = t, k, i
+	}
+	var actions, limits []string
+	for a, k := range activityAudit {
+		actions = append(actions, a)
+		if k == ActivityLimit {
+			limits = append(limits, a)
+		}
+	}
+
+	// $1 адрес, $2 виды, $3..$5 курсор, $6 предел, $7 действия аудита, $8 действия с видом limit
+	page := `($3::timestamptz IS NULL OR (at, kind, id) < ($3, $4, $5)) AND ($2::text[] IS NULL OR kind = ANY($2))
+		ORDER BY at DESC, kind DESC, id DESC LIMIT $6`
+	rows, err := r.reader(ctx).QueryContext(ctx, `
+		SELECT kind, id, action, counterparty, amount_cents, actor, details, at FROM (
+			(SELECT * FROM (
+				SELECT CASE WHEN t.type = 'transfer' THEN 'transfer' ELSE 'adjustment' END AS kind, t.id, t.type AS action,
+					CASE WHEN t.from_address = $1 THEN t.to_address ELSE t.from_address END AS counterparty,
+					CASE WHEN t.from_address = $1 THEN -t.amount_cents ELSE t.amount_cents END AS amount_cents,
+					COALESCE(t.initiated_by, '') AS actor, '{}'::jsonb AS details, t.created_at AS at
+				FROM transactions t
+				WHERE t.from_address = $1 OR t.to_address = $1
+			) tx WHERE `+page+`)
+			UNION ALL
+			(SELECT * FROM (
+				SELECT 'pending_transfer' AS kind, p.id, p.status AS action, p.to_address AS counterparty, -p.amount_cents AS amount_cents,
+					'' AS actor, jsonb_strip_nulls(jsonb_build_object('method', p.method, 'expires_at', p.expires_at, 'failure', NULLIF(p.failure, ''))) AS details,
+					p.created_at AS at
+				FROM pending_transfers p
+				WHERE p.from_address = $1
+			) pt WHERE `+page+`)
+			UNION ALL
+			(SELECT * FROM (
+				SELECT CASE WHEN a.action = ANY($8) THEN 'limit' ELSE 'status' END AS kind, a.id, a.action, '' AS counterparty, 0::bigint AS amount_cents,
+					a.actor, a.details, a.created_at AS at
+				FROM audit_log a
+				WHERE a.address = $1 AND a.action = ANY($7)
+			) au WHERE `+page+`)
+		) e
+		ORDER BY at DESC, kind DESC, id DESC
+		LIMIT $6
+	`, address, kinds, at, kind, id, q.limit(), actions, limits)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ActivityItem
+	for rows.Next() {
+		var it ActivityItem
+		var details []byte
+		if err := rows.Scan(&it.Kind, &it.ID, &it.Action, &it.Counterparty, &it.AmountCents, &it.Actor, &details, &it.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &it.Details); err != nil {
+			return nil, err
+		}
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,194 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Alert, сигнал о подозрительной активности кошелька, вид правила, адрес, детали срабатывания, начало окна наблюдения
+type Alert struct {
+	ID          int64
+	Kind        string
+	Address     string
+	Details     map[string]any
+	WindowStart time.Time
+	CreatedAt   time.Time
+}
+
+// AlertFilter, фильтр выборки сигналов, пустые поля не ограничивают выборку
+type AlertFilter struct {
+	Kind    string
+	Address string
+	Limit   int
+}
+
+// WalletVolume, оборот кошелька в текущем окне и в базовом периоде до него
+type WalletVolume struct {
+	Address       string
+	RecentCents   int64
+	BaselineCents int64
+}
+
+// WalletCount, количество событий по кошельку
+type WalletCount struct {
+	Address string
+	Count   int
+}
+
+// WalletFlow, сколько кошелек получил и отправил в окне
+type WalletFlow struct {
+	Address       string
+	ReceivedCents int64
+	SentCents     int64
+}
+
+// InsertAlert, сохраняет сигнал, повтор для того же правила, адреса и окна игнорируется, возвращает true если запись создана
+func (r *PostgresRepo) InsertAlert(ctx context.Context, a Alert) (bool, error) {
+	details, err := json.Marshal(a.Details)
+	if err != nil {
+		return false, err
+	}
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO alerts(kind, address, details, window_start)
+		VALUES ($1, $2, COALESCE($3::jsonb, '{}'::jsonb), $4)
+		ON CONFLICT (kind, address, window_start) DO NOTHING
+	`, a.Kind, a.Address, nullJSON(details), a.WindowStart)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListAlerts, возвращает сигналы по фильтру, новые первыми
+func (r *PostgresRepo) ListAlerts(ctx context.Context, f AlertFilter) ([]Alert, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, kind, address, details, window_start, created_at
+		FROM alerts
+		WHERE ($1 = '' OR kind = $1)
+		  AND ($2 = '' OR address = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, f.Kind, f.Address, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Alert
+	for rows.Next() {
+		var a Alert
+		var details []byte
+		if err := rows.Scan(&a.ID, &a.Kind, &a.Address, &details, &a.WindowStart, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(details, &a.Details); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// behaviouralTypes, анализ аномалий смотрит только на операции по воле клиента, переводы и обмен, служебные корректировки, комиссии и сторно поведения не описывают
+const behaviouralTypes = `type IN ('transfer', 'exchange')`
+
+// WalletVolumes, считает оборот каждого кошелька, входящий и исходящий, за окно с windowStart и за базовый период с baselineStart до windowStart, отдает только кошельки с оборотом в окне не меньше minRecent
+func (r *PostgresRepo) WalletVolumes(ctx context.Context, baselineStart, windowStart time.Time, minRecent int64) ([]WalletVolume, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		WITH moves AS (
+			SELECT from_address AS address, amount_cents, created_at
+			FROM transactions WHERE created_at >= $1 AND `+behaviouralTypes+`
+			UNION ALL
+			SELECT to_address, amount_cents, created_at
+			FROM transactions WHERE created_at >= $1 AND `+behaviouralTypes+`
+		)
+		SELECT address,
+		       COALESCE(SUM(amount_cents) FILTER (WHERE created_at >= $2), 0),
+		       COALESCE(SUM(amount_cents) FILTER (WHERE created_at < $2), 0)
+		FROM moves
+		GROUP BY address
+		HAVING COALESCE(SUM(amount_cents) FILTER (WHERE created_at >= $2), 0) >= $3
+	`, baselineStart, windowStart, minRecent)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WalletVolume
+	for rows.Next() {
+		var v WalletVolume
+		if err := rows.Scan(&v.Address, &v.RecentCents, &v.BaselineCents); err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// NewCounterpartyCounts, считает для отправителей число получателей в окне, с которыми раньше не было ни одного перевода, отдает кошельки с числом не меньше min
+func (r *PostgresRepo) NewCounterpartyCounts(ctx context.Context, windowStart time.Time, min int) ([]WalletCount, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT t.from_address, COUNT(DISTINCT t.to_address)
+		FROM transactions t
+		WHERE t.created_at >= $1 AND t.`+behaviouralTypes+`
+		  AND NOT EXISTS (
+			SELECT 1 FROM transactions p
+			WHERE p.created_at < $1
+			  AND ((p.from_address = t.from_address AND p.to_address = t.to_address)
+			    OR (p.from_address = t.to_address AND p.to_address = t.from_address))
+		  )
+		GROUP BY t.from_address
+		HAVING COUNT(DISTINCT t.to_address) >= $2
+	`, windowStart, min)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WalletCount
+	for rows.Next() {
+		var c WalletCount
+		if err := rows.Scan(&c.Address, &c.Count); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// PassThroughFlows, ищет кошельки, которые в окне получили не меньше minReceived и отправили дальше не меньше ratio от полученного
+func (r *PostgresRepo) PassThroughFlows(ctx context.Context, windowStart time.Time, minReceived int64, ratio float64) ([]WalletFlow, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		WITH flows AS (
+			SELECT to_address AS address, amount_cents AS received, 0::bigint AS sent
+			FROM transactions WHERE created_at >= $1 AND `+behaviouralTypes+`
+			UNION ALL
+			SELECT from_address, 0, amount_cents
+			FROM transactions WHERE created_at >= $1 AND `+behaviouralTypes+`
+		)
+		SELECT address, SUM(received), SUM(sent)
+		FROM flows
+		GROUP BY address
+		HAVING SUM(received) >= $2 AND SUM(sent) >= SUM(received) * $3::float8
+	`, windowStart, minReceived, ratio)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WalletFlow
+	for rows.Next() {
+		var f WalletFlow
+		if err := rows.Scan(&f.Address, &f.ReceivedCents, &f.SentCents); err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"context"
+	"strconv"
+)
+
+// ListenAPIKeys, слушает уведомления об изменении и удалении ключей доступа на отдельном соединении и передает идентификаторы ключей в fn,
+// listening вызывается, когда подписка действует, как в ListenBalances, возвращается при отмене контекста или обрыве соединения
+func (r *PostgresRepo) ListenAPIKeys(ctx context.Context, listening func(), fn func(id int64)) error {
+	return r.listen(ctx, []string{"api_keys"}, listening, func(_, payload string) {
+		if id, err := strconv.ParseInt(payload, 10, 64); err == nil {
+			fn(id)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// действия журнала аудита по ключам доступа
+const (
+	AuditAPIKeyCreate  = "api_key.create"
+	AuditAPIKeyRotate  = "api_key.rotate"
+	AuditAPIKeyRevoke  = "api_key.revoke"
+	AuditAPIKeySigning = "api_key.signing"
+)
+
+// APIKeyInfo, ключ доступа в списке пользователя, сам токен не хранится, только префикс для узнавания, нулевые ExpiresAt и RevokedAt означают что срок и отзыв не заданы, Signed, для ключа включена подпись переводов
+type APIKeyInfo struct {
+	ID        int64
+	Name      string
+	Prefix    string
+	Scopes    []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+	Signed    bool
+}
+
+// userActor, инициатор действия в журнале аудита для пользователя
+func userActor(userID int64) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// CreateAPIKey, выдает пользователю новый ключ с заданными областями доступа
+func (r *PostgresRepo) CreateAPIKey(ctx context.Context, userID int64, name string, scopes []string, keyHash, keyPrefix string) (APIKeyInfo, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	k, err := insertAPIKey(ctx, tx, userID, name, scopes, keyHash, keyPrefix)
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditAPIKeyCreate,
+		Actor:   userActor(userID),
+		Details: map[string]any{"key_id": k.ID, "prefix": k.Prefix, "scopes": k.Scopes},
+	}); err != nil {
+		return APIKeyInfo{}, err
+	}
+	return k, tx.Commit()
+}
+
+// insertAPIKey, вставляет ключ внутри транзакции
+func insertAPIKey(ctx context.Context, tx *sql.Tx, userID int64, name string, scopes []string, keyHash, keyPrefix string) (APIKeyInfo, error) {
+	k := APIKeyInfo{Name: name, Prefix: keyPrefix, Scopes: scopes}
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO api_keys(user_id, key_hash, prefix, name, scopes)
+		VALUES ($1, $2, $3, $4, string_to_array($5, ' '))
+		RETURNING id, created_at
+	`, userID, keyHash, keyPrefix, name, strings.Join(scopes, " ")).Scan(&k.ID, &k.CreatedAt)
+	return k, err
+}
+
+// ListAPIKeys, все ключи пользователя, включая отозванные и истекшие, новые первыми
+func (r *PostgresRepo) ListAPIKeys(ctx context.Context, userID int64) ([]APIKeyInfo, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT id, name, prefix, array_to_string(scopes, ' '), created_at, expires_at, revoked_at, signing_secret IS NOT NULL
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []APIKeyInfo
+	for rows.Next() {
+		var k APIKeyInfo
+		var scopes string
+		var expires, revoked sql.NullTime
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &k.CreatedAt, &expires, &revoked, &k.Signed); err != nil {
+			return nil, err
+		}
+		k.Scopes = strings.Fields(scopes)
+		k.ExpiresAt = expires.Time
+		k.RevokedAt = revoked.Time
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// RotateAPIKey, выдает замену действующему ключу с теми же именем, областями и секретом подписи, старый ключ продолжает работать еще overlap, чтобы клиенты успели переключиться, ноль отключает его сразу
+func (r *PostgresRepo) RotateAPIKey(ctx context.Context, userID, keyID int64, overlap time.Duration, keyHash, keyPrefix string) (APIKeyInfo, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var name, scopes string
+	var secret sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT name, array_to_string(scopes, ' '), signing_secret
+		FROM api_keys
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND replaced_by IS NULL
+		  AND (expires_at IS NULL OR expires_at > now())
+		FOR UPDATE
+	`, keyID, userID).Scan(&name, &scopes, &secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKeyInfo{}, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+
+	k, err := insertAPIKey(ctx, tx, userID, name, strings.Fields(scopes), keyHash, keyPrefix)
+	if err != nil {
+		return APIKeyInfo{}, err
+	}
+	// замена наследует секрет подписи, интеграции не нужно менять его вместе с ключом
+	if secret.Valid {
+		if _, err := tx.ExecContext(ctx, `UPDATE api_keys SET signing_secret = $2 WHERE id = $1`, k.ID, secret.String); err != nil {
+			return APIKeyInfo{}, err
+		}
+		k.Signed = true
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET replaced_by = $2, expires_at = now() + make_interval(secs => $3)
+		WHERE id = $1
+	`, keyID, k.ID, overlap.Seconds()); err != nil {
+		return APIKeyInfo{}, err
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditAPIKeyRotate,
+		Actor:   userActor(userID),
+		Details: map[string]any{"key_id": keyID, "new_key_id": k.ID, "overlap_seconds": int64(overlap.Seconds())},
+	}); err != nil {
+		return APIKeyInfo{}, err
+	}
+	return k, tx.Commit()
+}
+
+// RevokeAPIKey, отзывает ключ пользователя сразу
+func (r *PostgresRepo) RevokeAPIKey(ctx context.Context, userID, keyID int64) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, keyID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditAPIKeyRevoke,
+		Actor:   userActor(userID),
+		Details: map[string]any{"key_id": keyID},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetAPIKeySigningSecret, задает секрет подписи переводов для действующего ключа пользователя, пустой секрет выключает подпись
+func (r *PostgresRepo) SetAPIKeySigningSecret(ctx context.Context, userID, keyID int64, secret string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET signing_secret = NULLIF($3, '')
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, keyID, userID, secret)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditAPIKeySigning,
+		Actor:   userActor(userID),
+		Details: map[string]any{"key_id": keyID, "enabled": secret != ""},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
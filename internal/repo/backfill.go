@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ошибки заполнения колонок
+var (
+	// ErrBackfillNotFound, заполнения с таким именем миграции не заявляли
+	ErrBackfillNotFound = errors.New("backfill not found")
+	// ErrBackfillTenant, соединение с арендатором видит только его строки и заполнило бы таблицу частично
+	ErrBackfillTenant = errors.New("backfill needs a connection without tenant")
+	// ErrBackfillLocked, часть не дождалась блокировки строк за lock timeout, ее стоит повторить позже
+	ErrBackfillLocked = errors.New("backfill chunk lock timeout")
+)
+
+// Backfill, заявленное миграцией заполнение колонки, Set и Where, выражения UPDATE, LastID, до какого id таблица заполнена,
+// MaxID, последний id на момент запуска, ноль до запуска, Rows, сколько строк изменено
+type Backfill struct {
+	Name      string
+	Table     string
+	Set       string
+	Where     string
+	LastID    int64
+	MaxID     int64
+	Rows      int64
+	CreatedAt time.Time
+	StartedAt time.Time
+	UpdatedAt time.Time
+	DoneAt    time.Time
+}
+
+// Done, заполнение завершено
+func (b Backfill) Done() bool { return !b.DoneAt.IsZero() }
+
+const backfillColumns = `name, table_name, set_clause, where_clause, last_id, COALESCE(max_id, 0), rows, created_at, started_at, updated_at, done_at`
+
+// scanBackfill, читает заполнение из строки
+func scanBackfill(row interface{ Scan(...any) error }) (Backfill, error) {
+	var b Backfill
+	var started, updated, done sql.NullTime
+	err := row.Scan(&b.Name, &b.Table, &b.Set, &b.Where, &b.LastID, &b.MaxID, &b.Rows, &b.CreatedAt, &started, &updated, &done)
+	b.StartedAt, b.UpdatedAt, b.DoneAt = started.Time, updated.Time, done.Time
+	return b, err
+}
+
+// ListBackfills, заявленные заполнения в порядке заявления, незавершенные и завершенные
+func (r *PostgresRepo) ListBackfills(ctx context.Context) ([]Backfill, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+backfillColumns+` FROM backfills ORDER BY created_at, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Backfill
+	for rows.Next() {
+		b, err := scanBackfill(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// StartBackfill, фиксирует верхнюю границу id заполнения при первом запуске, повторный запуск отдает сохраненное продвижение
+func (r *PostgresRepo) StartBackfill(ctx context.Context, name string) (Backfill, error) {
+	var tenant string
+	if err := r.DB.QueryRowContext(ctx, `SELECT app_tenant()`).Scan(&tenant); err != nil {
+		return Backfill{}, err
+	}
+	if tenant != "" {
+		return Backfill{}, ErrBackfillTenant
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Backfill{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	b, err := scanBackfill(tx.QueryRowContext(ctx, `SELECT `+backfillColumns+` FROM backfills WHERE name = $1 FOR UPDATE`, name))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Backfill{}, ErrBackfillNotFound
+	}
+	if err != nil || !b.StartedAt.IsZero() {
+		return b, wrapf(err, "backfill %s", name)
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(max(id), 0) FROM `+pgx.Identifier{b.Table}.Sanitize()).Scan(&b.MaxID); err != nil {
+		return Backfill{}, wrapf(err, "backfill %s", name)
+	}
+	// пустая таблица заполнена сразу
+	b, err = scanBackfill(tx.QueryRowContext(ctx, `
+		UPDATE backfills SET max_id = $2, started_at = now(), updated_at = now(), done_at = CASE WHEN last_id >= $2 THEN now() END
+		WHERE name = $1
+		RETURNING `+backfillColumns, name, b.MaxID))
+	if err != nil {
+		return Backfill{}, wrapf(err, "backfill %s", name)
+	}
+	return b, tx.Commit()
+}
+
+// BackfillChunk, заполняет следующие size значений id после LastID одной транзакцией и сдвигает LastID в ней же, так что прерывание не теряет и не повторяет часть,
+// строки ждут чужие блокировки не дольше lockTimeout, иначе ErrBackfillLocked и продвижения нет, одновременные вызовы по одному имени идут по очереди
+func (r *PostgresRepo) BackfillChunk(ctx context.Context, name string, size int64, lockTimeout time.Duration) (Backfill, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Backfill{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`SET LOCAL lock_timeout = %d`, lockTimeout.Milliseconds())); err != nil {
+		return Backfill{}, err
+	}
+	b, err := scanBackfill(tx.QueryRowContext(ctx, `SELECT `+backfillColumns+` FROM backfills WHERE name = $1 FOR UPDATE`, name))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return Backfill{}, ErrBackfillNotFound
+	case isLockTimeout(err):
+		return Backfill{}, ErrBackfillLocked
+	}
+	if err != nil || b.Done() || b.StartedAt.IsZero() {
+		return b, wrapf(err, "backfill %s", name)
+	}
+
+	hi := min(b.LastID+size, b.MaxID)
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET %s WHERE id > $1 AND id <= $2 AND (%s)`,
+		pgx.Identifier{b.Table}.Sanitize(), b.Set, b.Where), b.LastID, hi)
+	if isLockTimeout(err) {
+		return b, ErrBackfillLocked
+	}
+	if err != nil {
+		return Backfill{}, wrapf(err, "backfill %s ids (%d, %d]", name, b.LastID, hi)
+	}
+	n, _ := res.RowsAffected()
+	b, err = scanBackfill(tx.QueryRowContext(ctx, `
+		UPDATE backfills SET last_id = $2, rows = rows + $3, updated_at = now(), done_at = CASE WHEN $2 >= max_id THEN now() END
+		WHERE name = $1
+		RETURNING `+backfillColumns, name, hi, n))
+	if err != nil {
+		return Backfill{}, wrapf(err, "backfill %s", name)
+	}
+	return b, tx.Commit()
+}
+
+// isLockTimeout, запрос не дождался блокировки за lock_timeout, код postgres 55P03
+func isLockTimeout(err error) bool {
+	var pgerr *pgconn.PgError
+	return errors.As(err, &pgerr) && pgerr.Code == "55P03"
+}
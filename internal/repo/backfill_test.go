@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestBackfillChunks, заполнение идет диапазонами id до max_id на момент запуска, строки новее не трогает, повторный старт продолжает с last_id
+func TestBackfillChunks(t *testing.T) {
+	t.Parallel()
+
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := context.Background()
+
+	table := "backfill_" + fx.Address()[:12]
+	name := table + "_kind"
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id BIGSERIAL PRIMARY KEY, amount BIGINT NOT NULL, kind TEXT)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	defer func() {
+		_, _ = db.Exec(`DROP TABLE IF EXISTS ` + table)
+		_, _ = db.Exec(`DELETE FROM backfills WHERE name = $1`, name)
+	}()
+	if _, err := db.Exec(`INSERT INTO ` + table + `(amount) SELECT g FROM generate_series(1, 25) g`); err != nil {
+		t.Fatalf("insert rows: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO backfills(name, table_name, set_clause, where_clause) VALUES ($1, $2, $3, $4)`,
+		name, table, `kind = CASE WHEN amount % 2 = 0 THEN 'even' ELSE 'odd' END`, `kind IS NULL`); err != nil {
+		t.Fatalf("declare backfill: %v", err)
+	}
+
+	b, err := r.StartBackfill(ctx, name)
+	if err != nil || b.MaxID != 25 || b.Done() {
+		t.Fatalf("start: %+v %v", b, err)
+	}
+	// строка после запуска пишется уже новым кодом и в границу не входит
+	if _, err := db.Exec(`INSERT INTO ` + table + `(amount, kind) VALUES (26, 'new')`); err != nil {
+		t.Fatalf("insert new row: %v", err)
+	}
+	if b, err = r.BackfillChunk(ctx, name, 10, time.Second); err != nil || b.LastID != 10 || b.Rows != 10 {
+		t.Fatalf("first chunk: %+v %v", b, err)
+	}
+	if b, err = r.StartBackfill(ctx, name); err != nil || b.LastID != 10 || b.MaxID != 25 {
+		t.Fatalf("restart: %+v %v", b, err)
+	}
+	for !b.Done() {
+		if b, err = r.BackfillChunk(ctx, name, 10, time.Second); err != nil {
+			t.Fatalf("chunk: %v", err)
+		}
+	}
+	if b.LastID != 25 || b.Rows != 25 {
+		t.Fatalf("done: %+v", b)
+	}
+
+	var even, odd, other int
+	if err := db.QueryRow(`SELECT count(*) FILTER (WHERE kind = 'even'), count(*) FILTER (WHERE kind = 'odd'), count(*) FILTER (WHERE kind = 'new') FROM `+table).Scan(&even, &odd, &other); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if even != 12 || odd != 13 || other != 1 {
+		t.Fatalf("kinds even=%d odd=%d new=%d", even, odd, other)
+	}
+	if _, err := r.StartBackfill(ctx, "missing_"+name); err != ErrBackfillNotFound {
+		t.Fatalf("unknown backfill: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package repo
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// BackupTables, таблицы логической копии в порядке восстановления, кошельки раньше служебных и очереди зачислений, которые на них ссылаются
+var BackupTables = []string{"wallets", "system_wallets", "supply_adjustments", "transactions", "transactions_archive", "hot_credits"}
+
+// действия журнала аудита, запрос резервной копии и восстановление базы из нее
+const (
+	AuditBackupCreate  = "backup.create"
+	AuditBackupRestore = "backup.restore"
+)
+
+// ошибки восстановления, в базе уже есть данные, версия схемы копии другая, строк в файле не столько, сколько в копии, балансы не сходятся с эмиссией
+var (
+	ErrRestoreNotEmpty = errors.New("restore target is not empty")
+	ErrSchemaVersion   = errors.New("backup schema version differs from database")
+	ErrRestoreRows     = errors.New("restored rows differ from backup")
+	ErrRestoreSupply   = errors.New("restored balances do not match money supply")
+)
+
+// BackupInfo, срез копии, версия схемы по schema_migrations, момент среза и число строк по таблицам
+type BackupInfo struct {
+	SchemaVersion int64
+	At            time.Time
+	Rows          map[string]int64
+}
+
+// ExportBackup, снимает согласованную логическую копию, все таблицы читаются в одной транзакции repeatable read, переводы при этом не ждут,
+// для каждой таблицы вызывает put с функцией, которая пишет ее csv с заголовком в переданный писатель, ошибка put прерывает копию
+func (r *PostgresRepo) ExportBackup(ctx context.Context, put func(table string, copyTo func(w io.Writer) error) error) (BackupInfo, error) {
+	info := BackupInfo{Rows: make(map[string]int64, len(BackupTables))}
+	err := r.withPgxConn(ctx, func(pc *pgx.Conn) error {
+		tx, err := pc.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		if err := tx.QueryRow(ctx, `SELECT version, now() FROM schema_migrations`).Scan(&info.SchemaVersion, &info.At); err != nil {
+			return err
+		}
+		for _, t := range BackupTables {
+			// партиционированную таблицу COPY читает только через запрос
+			err := put(t, func(w io.Writer) error {
+				tag, err := tx.Conn().PgConn().CopyTo(ctx, w, fmt.Sprintf(`COPY (SELECT * FROM %s) TO STDOUT WITH (FORMAT csv, HEADER)`, t))
+				info.Rows[t] = tag.RowsAffected()
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("backup %s: %w", t, err)
+			}
+		}
+		return tx.Commit(ctx)
+	})
+	return info, err
+}
+
+// RestoreBackup, загружает копию в пустую базу той же версии схемы одной транзакцией, open отдает csv таблицы, ошибка чтения, например несовпавшая контрольная сумма, откатывает все,
+// владельцы кошельков в копию не входят и сбрасываются, счетчики id продолжаются после восстановленных, итог проверяется инвариантом денежной массы
+func (r *PostgresRepo) RestoreBackup(ctx context.Context, info BackupInfo, open func(table string) (io.ReadCloser, error)) error {
+	err := r.withPgxConn(ctx, func(pc *pgx.Conn) error {
+		tx, err := pc.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+
+		var version int64
+		if err := tx.QueryRow(ctx, `SELECT version FROM schema_migrations`).Scan(&version); err != nil {
+			return err
+		}
+		if version != info.SchemaVersion {
+			return fmt.Errorf("%w: backup %d, database %d", ErrSchemaVersion, info.SchemaVersion, version)
+		}
+
+		// блокировка не дает старту сервера засеять кошельки посреди восстановления
+		if _, err := tx.Exec(ctx, `LOCK TABLE wallets IN EXCLUSIVE MODE`); err != nil {
+			return err
+		}
+		// эмиссия пустой базы, например нулевая исходная из миграции, заменяется эмиссией копии
+		var used bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM wallets) OR EXISTS (SELECT 1 FROM system_wallets)
+				OR EXISTS (SELECT 1 FROM transactions) OR EXISTS (SELECT 1 FROM transactions_archive)
+				OR EXISTS (SELECT 1 FROM supply_adjustments WHERE delta_cents <> 0)
+		`).Scan(&used); err != nil {
+			return err
+		}
+		if used {
+			return ErrRestoreNotEmpty
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM supply_adjustments`); err != nil {
+			return err
+		}
+
+		for _, t := range BackupTables {
+			if err := restoreTable(ctx, tx, t, info.Rows[t], open); err != nil {
+				return fmt.Errorf("restore %s: %w", t, err)
+			}
+		}
+
+		var ok bool
+		if err := tx.QueryRow(ctx, `SELECT ok FROM check_money_supply()`).Scan(&ok); err != nil {
+			return err
+		}
+		if !ok {
+			return ErrRestoreSupply
+		}
+		for _, q := range []string{
+			`SELECT setval(pg_get_serial_sequence('wallets', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM wallets`,
+			`SELECT setval(pg_get_serial_sequence('supply_adjustments', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM supply_adjustments`,
+			`SELECT setval(pg_get_serial_sequence('hot_credits', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM hot_credits`,
+			// архив делит счетчик id с горячей таблицей
+			`SELECT setval(pg_get_serial_sequence('transactions', 'id'), COALESCE(MAX(id), 0) + 1, false)
+			 FROM (SELECT id FROM transactions UNION ALL SELECT id FROM transactions_archive) t`,
+		} {
+			if _, err := tx.Exec(ctx, q); err != nil {
+				return err
+			}
+		}
+		return tx.Commit(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	return r.RecordAudit(ctx, AuditEntry{Action: AuditBackupRestore, Details: map[string]any{"snapshot_at": info.At, "rows": info.Rows}})
+}
+
+// restoreTable, грузит csv таблицы во временную таблицу той же формы, сверяет число строк и переносит в настоящую,
+// транзакциям заранее создаются партиции их месяцев, иначе строки легли бы в default партицию
+func restoreTable(ctx context.Context, tx pgx.Tx, table string, want int64, open func(table string) (io.ReadCloser, error)) error {
+	stage := "restore_" + table
+	if _, err := tx.Exec(ctx, fmt.Sprintf(`CREATE TEMP TABLE %s (LIKE %s) ON COMMIT DROP`, stage, table)); err != nil {
+		return err
+	}
+	rc, err := open(table)
+	if err != nil {
+		return err
+	}
+	tag, err := tx.Conn().PgConn().CopyFrom(ctx, rc, fmt.Sprintf(`COPY %s FROM STDIN WITH (FORMAT csv, HEADER)`, stage))
+	_ = rc.Close()
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() != want {
+		return fmt.Errorf("%w: want %d, got %d", ErrRestoreRows, want, tag.RowsAffected())
+	}
+
+	switch table {
+	case "wallets":
+		if _, err := tx.Exec(ctx, `UPDATE restore_wallets SET user_id = NULL`); err != nil {
+			return err
+		}
+	case "transactions":
+		if _, err := tx.Exec(ctx, `
+			SELECT ensure_transactions_partition(m)
+			FROM (SELECT DISTINCT date_trunc('month', created_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS m FROM restore_transactions) x
+		`); err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(ctx, fmt.Sprintf(`INSERT INTO %s SELECT * FROM %s`, table, stage))
+	return err
+}
+
+// withPgxConn, выполняет fn на отдельном соединении как на соединении pgx, нужно для COPY, после ошибки соединение в пул не возвращается, его состояние неизвестно
+func (r *PostgresRepo) withPgxConn(ctx context.Context, fn func(pc *pgx.Conn) error) error {
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var fnErr error
+	_ = conn.Raw(func(dc any) error {
+		if fnErr = fn(dc.(*stdlib.Conn).Conn()); fnErr != nil {
+			return driver.ErrBadConn
+		}
+		return nil
+	})
+	return fnErr
+}
@@ -0,0 +1,61 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// BalanceEvent, изменение баланса кошелька операцией TxID, DeltaCents со знаком, BalanceCents, баланс сразу после операции
+type BalanceEvent struct {
+	ID           int64
+	Address      string
+	TxID         int64
+	DeltaCents   int64
+	BalanceCents int64
+	CreatedAt    time.Time
+}
+
+// BalanceEventQuery, выборка событий кошелька, Until, события не позже момента, нулевой без ограничения, BeforeID, курсор, события с меньшим id, ноль с последнего
+type BalanceEventQuery struct {
+	Until    time.Time
+	BeforeID int64
+	Limit    int
+}
+
+// BalanceEvents, события изменения баланса кошелька, новые первыми, первое событие выборки с Until дает баланс на этот момент, limit по умолчанию 100, максимум 1000
+func (r *PostgresRepo) BalanceEvents(ctx context.Context, address string, q BalanceEventQuery) ([]BalanceEvent, error) {
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+	if q.Limit > 1000 {
+		q.Limit = 1000
+	}
+	var until any
+	if !q.Until.IsZero() {
+		until = q.Until
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx, `
+		SELECT id, address, tx_id, delta_cents, balance_cents, created_at
+		FROM balance_events
+		WHERE address = $1
+		  AND ($2::timestamptz IS NULL OR created_at <= $2)
+		  AND ($3 = 0 OR id < $3)
+		ORDER BY id DESC
+		LIMIT $4
+	`, address, until, q.BeforeID, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BalanceEvent
+	for rows.Next() {
+		var e BalanceEvent
+		if err := rows.Scan(&e.ID, &e.Address, &e.TxID, &e.DeltaCents, &e.BalanceCents, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,20 @@
+package repo
+
+import (
+	"context"
+	"strconv"
+)
+
+// balanceChannels, число каналов уведомлений об изменении кошельков, корзин хэша адреса, совпадает с маской в миграции 0046
+const balanceChannels = 16
+
+// ListenBalances, слушает уведомления об изменении кошельков всех корзин на отдельном соединении и передает адреса в fn в порядке коммитов,
+// listening вызывается, когда подписка действует, с этого момента ни одно изменение не пропадет, все, что было до него, слушатель мог пропустить,
+// возвращается при отмене контекста или обрыве соединения
+func (r *PostgresRepo) ListenBalances(ctx context.Context, listening func(), fn func(address string)) error {
+	channels := make([]string, balanceChannels)
+	for i := range channels {
+		channels[i] = "balances_" + strconv.Itoa(i)
+	}
+	return r.listen(ctx, channels, listening, func(_, payload string) { fn(payload) })
+}
@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// BatchMode, как пакет переводов реагирует на отказ отдельного перевода
+type BatchMode string
+
+// режимы пакета, atomic, первый отказ откатывает весь пакет, best_effort, отказавший перевод откатывается до своей точки сохранения, остальные фиксируются
+const (
+	BatchAtomic     BatchMode = "atomic"
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+// ValidBatchMode, режим из списка известных
+func ValidBatchMode(m BatchMode) bool {
+	return m == BatchAtomic || m == BatchBestEffort
+}
+
+// TransferItem, один перевод пакета
+type TransferItem struct {
+	From        string
+	To          string
+	AmountCents int64
+}
+
+// BatchItemError, отказ перевода пакета с его номером, атомарный пакет возвращает его вместо доменной ошибки
+type BatchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchItemError) Error() string { return fmt.Sprintf("batch item %d: %v", e.Index, e.Err) }
+
+func (e *BatchItemError) Unwrap() error { return e.Err }
+
+// batchSavepoint, имя точки сохранения перевода пакета, точка освобождается после каждого перевода, поэтому одного имени достаточно
+const batchSavepoint = "batch_item"
+
+// isItemError, доменный отказ отдельного перевода, после него пакет в режиме best_effort продолжается, остальные ошибки прерывают пакет целиком
+func isItemError(err error) bool {
+	for _, target := range []error{ErrSameAddress, ErrWalletNotFound, ErrInsufficientFunds, ErrBalanceOverflow, ErrAddressDenied, ErrWalletClosed} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// TransferBatch, выполняет переводы пакета по порядку в одной транзакции базы, в режиме atomic первый отказ откатывает весь пакет и возвращается как *BatchItemError,
+// в режиме best_effort каждый перевод идет под точкой сохранения, отказ откатывает только его, ответ содержит ошибку каждого перевода, nil у прошедших,
+// дедлок повторяет весь пакет, отказы по стоп-листу пишутся в аудит, с Locks пакет сначала ждет полосы всех своих кошельков
+func (r *PostgresRepo) TransferBatch(ctx context.Context, items []TransferItem, mode BatchMode) ([]error, error) {
+	addrs := make([]string, 0, 2*len(items))
+	for _, it := range items {
+		addrs = append(addrs, it.From, it.To)
+	}
+	unlock, err := r.lockWallets(ctx, addrs...)
+	if err != nil {
+		return nil, err
+	}
+	var results []error
+	err = retryDeadlocks(ctx, func() error {
+		var err error
+		results, err = r.transferBatchOnce(ctx, items, mode)
+		return err
+	})
+	unlock()
+
+	var itemErr *BatchItemError
+	switch {
+	case err == nil:
+		for i, e := range results {
+			if errors.Is(e, ErrAddressDenied) {
+				r.auditDeniedTransfer(ctx, items[i].From, items[i].To, items[i].AmountCents)
+			}
+		}
+		return results, nil
+	case errors.As(err, &itemErr) && errors.Is(itemErr.Err, ErrAddressDenied):
+		it := items[itemErr.Index]
+		r.auditDeniedTransfer(ctx, it.From, it.To, it.AmountCents)
+	}
+	return nil, wrapf(err, "transfer batch of %d", len(items))
+}
+
+type groupKey struct{}
+
+// WithTransferGroup, кладет в контекст id группы, им помечаются все транзакции, записанные с этим контекстом
+func WithTransferGroup(ctx context.Context, groupID string) context.Context {
+	return context.WithValue(ctx, groupKey{}, groupID)
+}
+
+// TransferGroupFromContext, id группы из контекста, пустой если переводы не объединены
+func TransferGroupFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(groupKey{}).(string)
+	return id
+}
+
+// TransferGroup, атомарный пакет переводов, записи журнала которого связаны общим новым id группы, ответ, id группы
+func (r *PostgresRepo) TransferGroup(ctx context.Context, items []TransferItem) (string, error) {
+	groupID := uuid.NewString()
+	if _, err := r.TransferBatch(WithTransferGroup(ctx, groupID), items, BatchAtomic); err != nil {
+		return "", wrapf(err, "transfer group %s", groupID)
+	}
+	return groupID, nil
+}
+
+// transferBatchOnce, одна попытка пакета в отдельной транзакции
+func (r *PostgresRepo) transferBatchOnce(ctx context.Context, items []TransferItem, mode BatchMode) ([]error, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	results := make([]error, len(items))
+	for i, it := range items {
+		if mode == BatchBestEffort {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT "+batchSavepoint); err != nil {
+				return nil, err
+			}
+		}
+		err := transferTx(ctx, tx, it.From, it.To, it.AmountCents)
+		if err != nil && (mode != BatchBestEffort || !isItemError(err)) {
+			// атомарный пакет откатывается целиком, дедлок и сбой базы прерывают любой пакет, дедлок уходит наверх как есть, чтобы пакет повторился
+			if isItemError(err) {
+				return nil, &BatchItemError{Index: i, Err: err}
+			}
+			return nil, err
+		}
+		if mode == BatchBestEffort {
+			if err != nil {
+				// откатываем только этот перевод, сделанное пакетом до него остается
+				if _, rerr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+batchSavepoint); rerr != nil {
+					return nil, rerr
+				}
+				results[i] = err
+			}
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+batchSavepoint); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := injectFault(FaultBeforeCommit); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+)
+
+// Caller, кто выполняет операцию, кладется в контекст слоем api, репозиторий берет его для журнала аудита и отметок об инициаторе без отдельного параметра в каждом методе
+type Caller struct {
+	UserID int64
+	KeyID  int64
+	Admin  bool
+	// Channel, через какой канал пришла операция, пустой считается http
+	Channel string
+}
+
+// каналы, через которые создаются переводы
+const (
+	ChannelHTTP            = "http"
+	ChannelGRPC            = "grpc"
+	ChannelCLI             = "cli"
+	ChannelScheduled       = "scheduled"
+	ChannelAdminAdjustment = "admin-adjustment"
+)
+
+type callerKey struct{}
+
+// WithCaller, кладет инициатора в контекст
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// CallerFromContext, инициатор из контекста, ok false если контекст пришел не из запроса, например из фоновой задачи
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	return c, ok
+}
+
+// Actor, имя инициатора для журнала аудита, user:<id> или user:<id>/key:<id> для ключа, admin для токена администратора, anonymous без аутентификации
+func (c Caller) Actor() string {
+	switch {
+	case c.UserID != 0 && c.KeyID != 0:
+		return fmt.Sprintf("user:%d/key:%d", c.UserID, c.KeyID)
+	case c.UserID != 0:
+		return userActor(c.UserID)
+	case c.Admin:
+		return "admin"
+	default:
+		return "anonymous"
+	}
+}
+
+// ActorFromContext, имя инициатора из контекста, system для операций вне запроса
+func ActorFromContext(ctx context.Context) string {
+	c, ok := CallerFromContext(ctx)
+	if !ok {
+		return "system"
+	}
+	return c.Actor()
+}
+
+// ChannelFromContext, канал операции из контекста, scheduled для операций вне запроса
+func ChannelFromContext(ctx context.Context) string {
+	c, ok := CallerFromContext(ctx)
+	switch {
+	case !ok:
+		return ChannelScheduled
+	case c.Channel == "":
+		return ChannelHTTP
+	default:
+		return c.Channel
+	}
+}
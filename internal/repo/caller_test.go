@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+	"testing"
+)
+
+// TestActorFromContext, имя инициатора для аудита по участнику из контекста
+func TestActorFromContext(t *testing.T) {
+	cases := []struct {
+		ctx  context.Context
+		want string
+	}{
+		{context.Background(), "system"},
+		{WithCaller(context.Background(), Caller{}), "anonymous"},
+		{WithCaller(context.Background(), Caller{Admin: true}), "admin"},
+		{WithCaller(context.Background(), Caller{UserID: 3}), "user:3"},
+		{WithCaller(context.Background(), Caller{UserID: 3, KeyID: 9, Admin: true}), "user:3/key:9"},
+	}
+	for _, c := range cases {
+		if got := ActorFromContext(c.ctx); got != c.want {
+			t.Errorf("ActorFromContext = %q, want %q", got, c.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// snapshotKey, ключ контекста со снимком согласованного чтения
+type snapshotKey struct{}
+
+// readSnapshot, транзакция снимка и его идентификатор для других соединений
+type readSnapshot struct {
+	tx *sql.Tx
+	id string
+}
+
+// querier, общий интерфейс sql.DB и sql.Tx для чтения
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// ReadConsistent, выполняет fn в одной транзакции REPEATABLE READ только для чтения, все чтения репозитория с контекстом fn видят один снимок базы,
+// так страница и ее счетчик или сводка из нескольких запросов не расходятся при записи в это время, выгрузка через COPY импортирует тот же снимок,
+// транзакция держит одно соединение, чтения внутри fn идут по очереди, ошибка начала транзакции возвращается до вызова fn
+func (r *PostgresRepo) ReadConsistent(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	// транзакция только читает, откат ничего не теряет
+	defer func() { _ = tx.Rollback() }()
+
+	s := &readSnapshot{tx: tx}
+	if err := tx.QueryRowContext(ctx, `SELECT pg_export_snapshot()`).Scan(&s.id); err != nil {
+		return err
+	}
+	return fn(context.WithValue(ctx, snapshotKey{}, s))
+}
+
+// reader, транзакция снимка из контекста, вне ReadConsistent пул соединений
+func (r *PostgresRepo) reader(ctx context.Context) querier {
+	if s, ok := ctx.Value(snapshotKey{}).(*readSnapshot); ok {
+		return s.tx
+	}
+	return r.DB
+}
+
+// importSnapshot, на соединении pc начинает транзакцию со снимком из контекста, вне ReadConsistent ничего не делает и отдает false,
+// начатую транзакцию вызывающий закрывает сам, прежде чем соединение вернется в пул
+func importSnapshot(ctx context.Context, pc *pgx.Conn) (bool, error) {
+	s, ok := ctx.Value(snapshotKey{}).(*readSnapshot)
+	if !ok {
+		return false, nil
+	}
+	if _, err := pc.Exec(ctx, `BEGIN ISOLATION LEVEL REPEATABLE READ READ ONLY`); err != nil {
+		return true, err
+	}
+	_, err := pc.Exec(ctx, `SET TRANSACTION SNAPSHOT '`+s.id+`'`)
+	return true, err
+}
@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Counterparty, сводка по контрагенту кошелька за период, сколько отправлено ему, сколько получено от него, число переводов, время последнего
+type Counterparty struct {
+	Address       string
+	SentCents     int64
+	ReceivedCents int64
+	TxCount       int64
+	LastTxAt      time.Time
+}
+
+// CounterpartyQuery, параметры выборки контрагентов, полуинтервал времени [Since, Until), сортировка, страница
+type CounterpartyQuery struct {
+	Since  time.Time
+	Until  time.Time
+	SortBy string
+	Desc   bool
+	Limit  int
+	Offset int
+}
+
+// поля сортировки контрагентов
+const (
+	CounterpartySortVolume   = "volume"
+	CounterpartySortSent     = "sent"
+	CounterpartySortReceived = "received"
+	CounterpartySortCount    = "count"
+)
+
+// counterpartySortExpr, допустимые выражения сортировки, значение из запроса в sql не подставляется
+var counterpartySortExpr = map[string]string{
+	CounterpartySortVolume:   "SUM(sent) + SUM(received)",
+	CounterpartySortSent:     "SUM(sent)",
+	CounterpartySortReceived: "SUM(received)",
+	CounterpartySortCount:    "COUNT(*)",
+}
+
+// ErrInvalidSort, неизвестное поле сортировки
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ListCounterparties, агрегирует переводы кошелька по контрагентам за период, проверяет существование кошелька
+func (r *PostgresRepo) ListCounterparties(ctx context.Context, address string, q CounterpartyQuery) ([]Counterparty, error) {
+	if q.SortBy == "" {
+		q.SortBy = CounterpartySortVolume
+	}
+	expr, ok := counterpartySortExpr[q.SortBy]
+	if !ok {
+		return nil, ErrInvalidSort
+	}
+	dir := "ASC"
+	if q.Desc {
+		dir = "DESC"
+	}
+	if q.Limit <= 0 || q.Limit > 500 {
+		q.Limit = 50
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	var one int
+	if err := r.reader(ctx).QueryRowContext(ctx, `SELECT 1 FROM wallets WHERE address=$1`, address).Scan(&one); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, &WalletNotFoundError{Address: address}
+		}
+		return nil, err
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx, fmt.Sprintf(`
+		WITH flows AS (
+			SELECT to_address AS counterparty, amount_cents AS sent, 0::bigint AS received, created_at
+			FROM transactions
+			WHERE from_address = $1 AND created_at >= $2 AND created_at < $3
+			UNION ALL
+			SELECT from_address, 0, amount_cents, created_at
+			FROM transactions
+			WHERE to_address = $1 AND created_at >= $2 AND created_at < $3
+		)
+		SELECT counterparty, SUM(sent), SUM(received), COUNT(*), MAX(created_at)
+		FROM flows
+		GROUP BY counterparty
+		ORDER BY %s %s, counterparty
+		LIMIT $4 OFFSET $5
+	`, expr, dir), address, q.Since, q.Until, q.Limit, q.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Counterparty
+	for rows.Next() {
+		var c Counterparty
+		if err := rows.Scan(&c.Address, &c.SentCents, &c.ReceivedCents, &c.TxCount, &c.LastTxAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
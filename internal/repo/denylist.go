@@ -0,0 +1,161 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrDenylistEntryNotFound, адреса нет в стоп-листе
+var ErrDenylistEntryNotFound = errors.New("denylist entry not found")
+
+// DenylistEntry, запись стоп-листа, адрес, причина блокировки, время добавления
+type DenylistEntry struct {
+	Address   string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// AuditEntry, запись журнала аудита, действие, инициатор, адрес кошелька, произвольные детали
+type AuditEntry struct {
+	Action  string
+	Actor   string
+	Address string
+	Details map[string]any
+}
+
+// действия журнала аудита
+const (
+	AuditDenylistAdd    = "denylist.add"
+	AuditDenylistRemove = "denylist.remove"
+	AuditTransferDenied = "transfer.denied"
+)
+
+// AddToDenylist, добавляет адрес в стоп-лист или обновляет причину, пишет запись аудита в той же транзакции
+func (r *PostgresRepo) AddToDenylist(ctx context.Context, address, reason, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO denylist(address, reason) VALUES ($1, $2)
+		ON CONFLICT (address) DO UPDATE SET reason = EXCLUDED.reason
+	`, address, reason); err != nil {
+		return err
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditDenylistAdd,
+		Actor:   actor,
+		Address: address,
+		Details: map[string]any{"reason": reason},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveFromDenylist, удаляет адрес из стоп-листа, маппит отсутствие записи на доменную ошибку
+func (r *PostgresRepo) RemoveFromDenylist(ctx context.Context, address, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM denylist WHERE address = $1`, address)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrDenylistEntryNotFound
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditDenylistRemove,
+		Actor:   actor,
+		Address: address,
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListDenylist, возвращает все записи стоп-листа, новые первыми
+func (r *PostgresRepo) ListDenylist(ctx context.Context) ([]DenylistEntry, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT address, reason, created_at
+		FROM denylist
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []DenylistEntry
+	for rows.Next() {
+		var e DenylistEntry
+		if err := rows.Scan(&e.Address, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// execer, общий интерфейс sql.DB и sql.Tx для выполнения команд
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// RecordAudit, пишет запись в журнал аудита вне транзакции
+func (r *PostgresRepo) RecordAudit(ctx context.Context, e AuditEntry) error {
+	return insertAudit(ctx, r.DB, e)
+}
+
+// insertAudit, пишет запись в журнал аудита через переданное соединение или транзакцию, пустой инициатор берется из контекста
+func insertAudit(ctx context.Context, ex execer, e AuditEntry) error {
+	if e.Actor == "" {
+		e.Actor = ActorFromContext(ctx)
+	}
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return err
+	}
+	_, err = ex.ExecContext(ctx, `
+		INSERT INTO audit_log(action, actor, address, details)
+		VALUES ($1, $2, $3, COALESCE($4::jsonb, '{}'::jsonb))
+	`, e.Action, e.Actor, e.Address, nullJSON(details))
+	return err
+}
+
+// nullJSON, превращает json null от пустой карты в sql null, чтобы сработал дефолт
+func nullJSON(b []byte) any {
+	if string(b) == "null" {
+		return nil
+	}
+	return string(b)
+}
+
+// auditDeniedTransfer, фиксирует отклоненную попытку перевода, ошибка записи только логируется, чтобы не подменить доменную ошибку
+func (r *PostgresRepo) auditDeniedTransfer(ctx context.Context, from, to string, amountCents int64) {
+	// используем отдельный контекст, исходный может быть уже отменен клиентом
+	actx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+
+	if err := r.RecordAudit(actx, AuditEntry{
+		Action:  AuditTransferDenied,
+		Address: from,
+		Details: map[string]any{
+			"from":         from,
+			"to":           to,
+			"amount_cents": amountCents,
+		},
+	}); err != nil {
+		log.Printf("audit denied transfer: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"gotechtask/internal/address"
+)
+
+// AuditWalletDerive, действие журнала аудита, заведение кошелька с выведенным адресом
+const AuditWalletDerive = "wallet.derive"
+
+// ErrAddressCollision, выведенный адрес или метка уже заняты, причина в *AddressCollisionError
+var ErrAddressCollision = errors.New("derived address collision")
+
+// AddressCollisionError, коллизия выведенного адреса, errors.Is с ErrAddressCollision дает true,
+// Bound, адрес, к которому метка уже привязана, при причине CollisionLabelBound
+type AddressCollisionError struct {
+	Label   string
+	Address string
+	Reason  string
+	Bound   string
+}
+
+func (e *AddressCollisionError) Error() string {
+	return fmt.Sprintf("derived address %s for label %q: %s", e.Address, e.Label, e.Reason)
+}
+
+func (e *AddressCollisionError) Is(target error) bool { return target == ErrAddressCollision }
+
+// причины коллизии выведенного адреса
+const (
+	// CollisionAddressTaken, адрес занят кошельком без этой метки, обычным, с другой меткой или другого арендатора
+	CollisionAddressTaken = "address taken"
+	// CollisionLabelBound, метка уже привязана к другому адресу, обычно после смены секрета вывода
+	CollisionLabelBound = "label bound to another address"
+)
+
+// SystemLabel, метка выведенного адреса служебного кошелька роли, префикс system: закрыт для меток администратора
+func SystemLabel(role string) string { return "system:" + role }
+
+// CreateDerivedWallet, заводит общий кошелек с выведенным адресом addr и меткой label, created, создан ли он этим вызовом,
+// повтор с той же меткой отдает тот же кошелек, занятый адрес или метка дают *AddressCollisionError
+func (r *PostgresRepo) CreateDerivedWallet(ctx context.Context, addr, label, actor string) (Wallet, bool, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Wallet{}, false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w, created, err := insertDerivedWallet(ctx, tx, addr, label)
+	if err != nil || !created {
+		return w, false, wrapf(err, "derive wallet %q", label)
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditWalletDerive,
+		Actor:   actor,
+		Address: addr,
+		Details: map[string]any{"label": label},
+	}); err != nil {
+		return Wallet{}, false, err
+	}
+	return w, true, tx.Commit()
+}
+
+// insertDerivedWallet, вставка пустого кошелька с меткой, без вставки разбирает, кто занял адрес или метку,
+// кошелек другого арендатора политики не показывают, его адрес считается просто занятым
+func insertDerivedWallet(ctx context.Context, tx *sql.Tx, addr, label string) (Wallet, bool, error) {
+	w, err := scanWallet(tx.QueryRowContext(ctx, `
+		INSERT INTO wallets(address, balance_cents, derived_label) VALUES ($1, 0, $2)
+		ON CONFLICT DO NOTHING
+		RETURNING `+walletColumns,
+		addr, label))
+	if err == nil {
+		return w, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Wallet{}, false, err
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE address = $1 OR derived_label = $2`, addr, label)
+	if err != nil {
+		return Wallet{}, false, err
+	}
+	defer rows.Close()
+	bound := ""
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return Wallet{}, false, err
+		}
+		switch {
+		case w.Address == addr && w.DerivedLabel == label:
+			return w, false, nil
+		case w.Address == addr:
+			return Wallet{}, false, &AddressCollisionError{Label: label, Address: addr, Reason: CollisionAddressTaken}
+		default:
+			bound = w.Address
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Wallet{}, false, err
+	}
+	if bound != "" {
+		return Wallet{}, false, &AddressCollisionError{Label: label, Address: addr, Reason: CollisionLabelBound, Bound: bound}
+	}
+	return Wallet{}, false, &AddressCollisionError{Label: label, Address: addr, Reason: CollisionAddressTaken}
+}
+
+// NewSystemWallet, заводит кошелек служебной роли в транзакции сида или сброса песочницы, с d адрес выводится из метки роли,
+// и повторное развертывание получает те же адреса, без d адрес случайный
+func NewSystemWallet(ctx context.Context, tx *sql.Tx, d *address.Deriver, role, description string) (string, error) {
+	var addr string
+	if d != nil {
+		addr = d.Derive(SystemLabel(role))
+		if _, _, err := insertDerivedWallet(ctx, tx, addr, SystemLabel(role)); err != nil {
+			return "", err
+		}
+	} else {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		addr = hex.EncodeToString(b)
+		if _, err := tx.ExecContext(ctx, `INSERT INTO wallets(address, balance_cents) VALUES ($1, 0)`, addr); err != nil {
+			return "", err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO system_wallets(role, address, description) VALUES ($1, $2, $3)`, role, addr, description); err != nil {
+		return "", err
+	}
+	return addr, nil
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// DormantQuery, выборка неактивных кошельков, без переводов с Before, с балансом не меньше MinBalanceCents
+type DormantQuery struct {
+	Before          time.Time
+	MinBalanceCents int64
+	Limit           int
+}
+
+// DormantWallets, кошельки, последний перевод которых (или создание, если переводов не было) раньше Before, самые давние первыми, limit по умолчанию 1000, максимум 100000
+func (r *PostgresRepo) DormantWallets(ctx context.Context, q DormantQuery) ([]Wallet, error) {
+	if q.Limit <= 0 {
+		q.Limit = 1000
+	}
+	if q.Limit > 100000 {
+		q.Limit = 100000
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx, `
+		SELECT `+walletColumns+`
+		FROM wallets
+		WHERE COALESCE(last_tx_at, created_at) < $1 AND balance_cents >= $2 AND closed_at IS NULL
+		ORDER BY COALESCE(last_tx_at, created_at), id
+		LIMIT $3
+	`, q.Before, q.MinBalanceCents, q.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Wallet
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
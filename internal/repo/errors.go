@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrVersionMismatch, ресурс изменился после того, как вызывающий прочитал его версию, изменение не применено
+var ErrVersionMismatch = errors.New("resource version mismatch")
+
+// InsufficientFundsError, отказ перевода по средствам, кошелек отправителя, сколько нужно и сколько было доступно с учетом овердрафта,
+// errors.Is с ErrInsufficientFunds дает true, так вызывающим, которым детали не нужны, достаточно сравнения с ошибкой-значением
+type InsufficientFundsError struct {
+	Address        string
+	NeededCents    int64
+	AvailableCents int64
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("insufficient funds on %s: need %d cents, available %d", e.Address, e.NeededCents, e.AvailableCents)
+}
+
+func (e *InsufficientFundsError) Is(target error) bool { return target == ErrInsufficientFunds }
+
+// ShortfallCents, сколько не хватило до суммы перевода
+func (e *InsufficientFundsError) ShortfallCents() int64 { return e.NeededCents - e.AvailableCents }
+
+// WalletNotFoundError, кошелька с адресом нет, errors.Is с ErrWalletNotFound дает true
+type WalletNotFoundError struct {
+	Address string
+}
+
+func (e *WalletNotFoundError) Error() string { return fmt.Sprintf("wallet %s not found", e.Address) }
+
+func (e *WalletNotFoundError) Is(target error) bool { return target == ErrWalletNotFound }
+
+// WalletClosedError, кошелек закрыт слиянием и передал баланс преемнику, errors.Is с ErrWalletClosed дает true
+type WalletClosedError struct {
+	Address   string
+	Successor string
+}
+
+func (e *WalletClosedError) Error() string {
+	return fmt.Sprintf("wallet %s closed, merged into %s", e.Address, e.Successor)
+}
+
+func (e *WalletClosedError) Is(target error) bool { return target == ErrWalletClosed }
+
+// closedWallet, ошибка для первого закрытого кошелька из got, nil если все открыты
+func closedWallet(got []lockedWallet) error {
+	for _, w := range got {
+		if w.successor != "" {
+			return &WalletClosedError{Address: w.addr, Successor: w.successor}
+		}
+	}
+	return nil
+}
+
+// missingWallet, ошибка для кошелька из addrs, которого нет среди найденных строк
+func missingWallet(got []lockedWallet, addrs ...string) error {
+	for _, a := range addrs {
+		found := false
+		for _, w := range got {
+			found = found || w.addr == a
+		}
+		if !found {
+			return &WalletNotFoundError{Address: a}
+		}
+	}
+	return ErrWalletNotFound
+}
+
+// wrapf, добавляет к ошибке контекст операции, цепочка сохраняется для errors.Is и errors.As, nil остается nil
+func wrapf(err error, format string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
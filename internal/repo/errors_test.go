@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestTypedErrors, типизированные ошибки после обертки сравниваются с ошибками-значениями через errors.Is и достаются через errors.As
+func TestTypedErrors(t *testing.T) {
+	err := wrapf(&InsufficientFundsError{Address: "a", NeededCents: 1000, AvailableCents: 750}, "transfer %s->%s", "a", "b")
+	if !errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrWalletNotFound) {
+		t.Fatalf("errors.Is: %v", err)
+	}
+	var ife *InsufficientFundsError
+	if !errors.As(err, &ife) || ife.ShortfallCents() != 250 {
+		t.Fatalf("errors.As: %v", err)
+	}
+	if got, want := err.Error(), "transfer a->b: insufficient funds on a: need 1000 cents, available 750"; got != want {
+		t.Fatalf("message %q, want %q", got, want)
+	}
+
+	// в пакете ошибка перевода лежит внутри BatchItemError
+	err = wrapf(&BatchItemError{Index: 1, Err: missingWallet([]lockedWallet{{addr: "a"}}, "a", "b")}, "transfer batch of %d", 2)
+	var wnf *WalletNotFoundError
+	if !errors.Is(err, ErrWalletNotFound) || !errors.As(err, &wnf) || wnf.Address != "b" {
+		t.Fatalf("missing wallet: %v", err)
+	}
+
+	if wrapf(nil, "transfer") != nil {
+		t.Fatalf("wrapf(nil) must stay nil")
+	}
+}
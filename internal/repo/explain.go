@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// balanceQuery, баланс кошелька с очередью зачислений горячего кошелька
+const balanceQuery = `SELECT balance_cents + ` + hotPendingCents + ` FROM wallets WHERE address=$1`
+
+// lockWalletsQuery, выборка и блокировка кошельков перевода по порядку адресов
+const lockWalletsQuery = `
+	SELECT address, balance_cents, overdraft_limit_cents, COALESCE(low_balance_cents, 0), low_balance_since IS NOT NULL, COALESCE(successor, '')
+	FROM wallets
+	WHERE address = $1 OR address = $2
+	ORDER BY address
+	FOR UPDATE
+`
+
+// запросы, план которых можно снять, баланс кошелька, страница истории кошелька и выборка кошельков перевода
+const (
+	ExplainBalance  = "balance"
+	ExplainListing  = "listing"
+	ExplainTransfer = "transfer"
+)
+
+// ExplainQueries, имена запросов для Explain в порядке вывода
+var ExplainQueries = []string{ExplainBalance, ExplainListing, ExplainTransfer}
+
+// ErrUnknownQuery, запроса с таким именем нет среди ExplainQueries
+var ErrUnknownQuery = errors.New("unknown query")
+
+// ExplainParams, параметры запросов плана, Address, кошелек баланса, истории и отправитель перевода, To, получатель перевода, пустой дает Address
+type ExplainParams struct {
+	Address string
+	To      string
+}
+
+// QueryPlan, план запроса с фактическим временем и буферами
+type QueryPlan struct {
+	Name string
+	SQL  string
+	Plan string
+}
+
+// Explain, выполняет запрос приложения под EXPLAIN (ANALYZE, BUFFERS) на живой базе и отдает его план, запрос действительно выполняется,
+// поэтому идет в транзакции, которая всегда откатывается, блокировки выборки перевода держатся только на время плана
+func (r *PostgresRepo) Explain(ctx context.Context, name string, p ExplainParams) (QueryPlan, error) {
+	if p.To == "" {
+		p.To = p.Address
+	}
+	var (
+		q    string
+		args sqlArgs
+	)
+	switch name {
+	case ExplainBalance:
+		q, args = balanceQuery, sqlArgs{p.Address}
+	case ExplainListing:
+		var err error
+		q, args, err = listTransactionsQuery(ListOptions{Visibility: TxVisibility{All: true}, Address: p.Address, Desc: true})
+		if err != nil {
+			return QueryPlan{}, err
+		}
+	case ExplainTransfer:
+		q, args = lockWalletsQuery, sqlArgs{p.Address, p.To}
+	default:
+		return QueryPlan{}, ErrUnknownQuery
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `EXPLAIN (ANALYZE, BUFFERS) `+q, args...)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return QueryPlan{}, err
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return QueryPlan{}, err
+	}
+	return QueryPlan{Name: name, SQL: normalizeSpace(q), Plan: strings.Join(lines, "\n")}, nil
+}
+
+// normalizeSpace, текст запроса одной строкой
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
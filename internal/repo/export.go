@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// exportColumns, колонки выгрузки транзакций, время в utc, сумма в валюте с двумя знаками, как в ответах api
+const exportColumns = `t.id, to_char(t.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"') AS created_at,
+	t.from_address, t.to_address, (t.amount_cents / 100.0)::numeric(20, 2) AS amount, t.type,
+	COALESCE(t.initiated_by, '') AS initiated_by, COALESCE(t.channel, '') AS channel, COALESCE(t.group_id::text, '') AS group_id`
+
+// ExportTransactions, пишет в w csv с заголовком всех транзакций под фильтрами и видимостью o по возрастанию id, страница и сортировка o не учитываются,
+// строки идут из базы через COPY TO STDOUT прямо в w и в памяти не собираются, отдает число строк, оборванная выгрузка закрывает соединение, а не возвращает его в пул,
+// внутри ReadConsistent выгрузка читает снимок согласованного чтения
+func (r *PostgresRepo) ExportTransactions(ctx context.Context, o ListOptions, w io.Writer) (int64, error) {
+	var args sqlArgs
+	where := o.where(&args)
+	var n int64
+	err := r.withPgxConn(ctx, func(pc *pgx.Conn) error {
+		cond, err := inlineArgs(pc.PgConn(), where, args)
+		if err != nil {
+			return err
+		}
+		inTx, err := importSnapshot(ctx, pc)
+		if err != nil {
+			return err
+		}
+		tag, err := pc.PgConn().CopyTo(ctx, w, `COPY (
+			SELECT `+exportColumns+`
+			FROM transactions t
+			WHERE `+cond+`
+			ORDER BY t.id
+		) TO STDOUT WITH (FORMAT csv, HEADER)`)
+		n = tag.RowsAffected()
+		if err == nil && inTx {
+			_, err = pc.Exec(ctx, `ROLLBACK`)
+		}
+		return err
+	})
+	return n, err
+}
+
+// placeholder, плейсхолдер параметра запроса
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+// inlineArgs, подставляет аргументы в условие литералами, COPY параметров не принимает, строки экранируются соединением,
+// замена идет одним проходом, так что $n внутри подставленной строки не трогается
+func inlineArgs(pc *pgconn.PgConn, cond string, args sqlArgs) (string, error) {
+	var firstErr error
+	out := placeholder.ReplaceAllStringFunc(cond, func(p string) string {
+		i, _ := strconv.Atoi(p[1:])
+		if i < 1 || i > len(args) {
+			firstErr = fmt.Errorf("export: no argument for %s", p)
+			return p
+		}
+		switch v := args[i-1].(type) {
+		case string:
+			s, err := pc.EscapeString(v)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			return "'" + s + "'"
+		case time.Time:
+			return "'" + v.UTC().Format(time.RFC3339Nano) + "'::timestamptz"
+		case int64:
+			return strconv.FormatInt(v, 10)
+		case int:
+			return strconv.Itoa(v)
+		default:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("export: unsupported argument %T", v)
+			}
+			return p
+		}
+	})
+	return out, firstErr
+}
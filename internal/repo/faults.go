@@ -0,0 +1,46 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// точки внедрения сбоев в перевод, после списания с отправителя, перед зачислением получателю, перед коммитом
+const (
+	FaultAfterDebit   = "after_debit"
+	FaultBeforeCredit = "before_credit"
+	FaultBeforeCommit = "before_commit"
+)
+
+// FaultPoints, все точки внедрения сбоев
+var FaultPoints = []string{FaultAfterDebit, FaultBeforeCredit, FaultBeforeCommit}
+
+// ErrInjectedFault, искусственный сбой, перевод должен откатиться целиком
+var ErrInjectedFault = errors.New("injected fault")
+
+// ErrFaultsNotBuilt, сбои запрошены, но бинарник собран без тега faultinject
+var ErrFaultsNotBuilt = errors.New("fault injection is not built in, rebuild with -tags faultinject")
+
+// FaultConfig, режим проверки целостности, Rate, вероятность сбоя в каждой точке от 0 до 1, ноль выключает, Points, в каких точках, пустой во всех, Seed, зерно генератора для воспроизводимости, ноль берет случайное
+type FaultConfig struct {
+	Rate   float64
+	Points []string
+	Seed   int64
+}
+
+// validate, проверяет вероятность и имена точек
+func (c FaultConfig) validate() error {
+	if c.Rate < 0 || c.Rate > 1 {
+		return fmt.Errorf("fault rate must be between 0 and 1, got %v", c.Rate)
+	}
+	for _, p := range c.Points {
+		known := false
+		for _, k := range FaultPoints {
+			known = known || p == k
+		}
+		if !known {
+			return fmt.Errorf("unknown fault point %q", p)
+		}
+	}
+	return nil
+}
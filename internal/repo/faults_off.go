@@ -0,0 +1,26 @@
+//go:build !faultinject
+
+package repo
+
+// FaultsBuilt, собран ли бинарник со слоем внедрения сбоев
+const FaultsBuilt = false
+
+// EnableFaults, без тега faultinject сбои не внедряются, ненулевая вероятность дает ErrFaultsNotBuilt, чтобы режим проверки не включился молча вхолостую
+func EnableFaults(c FaultConfig) error {
+	if c.Rate == 0 {
+		return nil
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return ErrFaultsNotBuilt
+}
+
+// DisableFaults, ничего не делает без тега faultinject
+func DisableFaults() {}
+
+// FaultHits, без тега faultinject сбоев не бывает
+func FaultHits() map[string]int64 { return nil }
+
+// injectFault, заглушка, компилятор ее встраивает и в обычной сборке проверка ничего не стоит
+func injectFault(string) error { return nil }
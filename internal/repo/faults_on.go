@@ -0,0 +1,95 @@
+//go:build faultinject
+
+package repo
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// FaultsBuilt, собран ли бинарник со слоем внедрения сбоев
+const FaultsBuilt = true
+
+// faultInjector, включенный режим сбоев, генератор общий для всех переводов, поэтому под мьютексом
+type faultInjector struct {
+	mu     sync.Mutex
+	rnd    *rand.Rand
+	rate   float64
+	points map[string]bool
+	hits   map[string]int64
+}
+
+var (
+	faultsMu sync.RWMutex
+	faults   *faultInjector
+)
+
+// EnableFaults, включает внедрение сбоев для всех переводов процесса, нулевая вероятность выключает
+func EnableFaults(c FaultConfig) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.Rate == 0 {
+		DisableFaults()
+		return nil
+	}
+	seed := c.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	points := c.Points
+	if len(points) == 0 {
+		points = FaultPoints
+	}
+	f := &faultInjector{rnd: rand.New(rand.NewSource(seed)), rate: c.Rate, points: map[string]bool{}, hits: map[string]int64{}}
+	for _, p := range points {
+		f.points[p] = true
+	}
+
+	faultsMu.Lock()
+	faults = f
+	faultsMu.Unlock()
+	return nil
+}
+
+// DisableFaults, выключает внедрение сбоев
+func DisableFaults() {
+	faultsMu.Lock()
+	faults = nil
+	faultsMu.Unlock()
+}
+
+// FaultHits, сколько сбоев внедрено в каждой точке с последнего EnableFaults
+func FaultHits() map[string]int64 {
+	faultsMu.RLock()
+	f := faults
+	faultsMu.RUnlock()
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make(map[string]int64, len(f.hits))
+	for k, v := range f.hits {
+		out[k] = v
+	}
+	return out
+}
+
+// injectFault, с заданной вероятностью возвращает ErrInjectedFault в точке point
+func injectFault(point string) error {
+	faultsMu.RLock()
+	f := faults
+	faultsMu.RUnlock()
+	if f == nil || !f.points[point] {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.rnd.Float64() >= f.rate {
+		return nil
+	}
+	f.hits[point]++
+	return ErrInjectedFault
+}
@@ -0,0 +1,148 @@
+//go:build faultinject
+
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestInjectFault, сбой срабатывает только в выбранных точках, с вероятностью 1 всегда, после выключения никогда
+func TestInjectFault(t *testing.T) {
+	defer DisableFaults()
+
+	if err := EnableFaults(FaultConfig{Rate: 1, Points: []string{"nowhere"}}); err == nil {
+		t.Fatalf("want error for unknown point")
+	}
+	if err := EnableFaults(FaultConfig{Rate: 1.5}); err == nil {
+		t.Fatalf("want error for rate > 1")
+	}
+
+	if err := EnableFaults(FaultConfig{Rate: 1, Points: []string{FaultBeforeCommit}, Seed: 1}); err != nil {
+		t.Fatalf("enable: %v", err)
+	}
+	if err := injectFault(FaultBeforeCommit); !errors.Is(err, ErrInjectedFault) {
+		t.Fatalf("before_commit: want injected fault, got %v", err)
+	}
+	if err := injectFault(FaultAfterDebit); err != nil {
+		t.Fatalf("after_debit is not selected, got %v", err)
+	}
+	if hits := FaultHits(); hits[FaultBeforeCommit] != 1 || hits[FaultAfterDebit] != 0 {
+		t.Fatalf("unexpected hits %v", hits)
+	}
+
+	DisableFaults()
+	if err := injectFault(FaultBeforeCommit); err != nil {
+		t.Fatalf("disabled: got %v", err)
+	}
+}
+
+// TestTransfer_AtomicUnderFaults, гоняет конкурентные переводы по кругу кошельков со сбоями в каждой точке и проверяет инварианты, ни один перевод не применяется частично, балансы сходятся с журналом, сумма не меняется
+func TestTransfer_AtomicUnderFaults(t *testing.T) {
+	db := testfixtures.Open(t)
+	defer DisableFaults()
+
+	const (
+		wallets   = 5
+		initial   = 10000
+		transfers = 200
+	)
+	r := NewPostgres(db)
+	ctx := context.Background()
+
+	for _, point := range FaultPoints {
+		t.Run(point, func(t *testing.T) {
+			addrs := make([]string, wallets)
+			for i := range addrs {
+				b := make([]byte, 32)
+				_, _ = rand.Read(b)
+				addrs[i] = hex.EncodeToString(b)
+				if _, err := db.Exec(`INSERT INTO wallets(address, balance_cents) VALUES ($1,$2)`, addrs[i], initial); err != nil {
+					t.Fatalf("insert wallet: %v", err)
+				}
+				if _, err := db.Exec(`INSERT INTO supply_adjustments(delta_cents, reason, address) VALUES ($1,'test',$2)`, initial, addrs[i]); err != nil {
+					t.Fatalf("insert supply: %v", err)
+				}
+			}
+			defer func() {
+				for _, a := range addrs {
+					_, _ = db.Exec(`DELETE FROM transactions WHERE from_address=$1 OR to_address=$1`, a)
+					_, _ = db.Exec(`DELETE FROM wallets WHERE address=$1`, a)
+					_, _ = db.Exec(`DELETE FROM supply_adjustments WHERE address=$1`, a)
+				}
+			}()
+
+			if err := EnableFaults(FaultConfig{Rate: 0.5, Points: []string{point}, Seed: 42}); err != nil {
+				t.Fatalf("enable: %v", err)
+			}
+
+			var (
+				wg sync.WaitGroup
+				mu sync.Mutex
+				ok int
+			)
+			for i := 0; i < transfers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					from, to := addrs[i%wallets], addrs[(i+1)%wallets]
+					err := r.Transfer(ctx, from, to, int64(1+i%7))
+					switch {
+					case err == nil:
+						mu.Lock()
+						ok++
+						mu.Unlock()
+					case errors.Is(err, ErrInjectedFault), errors.Is(err, ErrInsufficientFunds):
+					default:
+						t.Errorf("transfer %d: unexpected error %v", i, err)
+					}
+				}(i)
+			}
+			wg.Wait()
+			DisableFaults()
+
+			if ok == 0 || ok == transfers {
+				t.Fatalf("want a mix of committed and failed transfers, got %d of %d committed", ok, transfers)
+			}
+
+			var journaled int
+			var total int64
+			for _, a := range addrs {
+				var in, out int64
+				var n int
+				if err := db.QueryRow(`
+					SELECT COALESCE(SUM(amount_cents) FILTER (WHERE to_address = $1), 0),
+					       COALESCE(SUM(amount_cents) FILTER (WHERE from_address = $1), 0),
+					       COUNT(*) FILTER (WHERE from_address = $1)
+					FROM transactions WHERE from_address = $1 OR to_address = $1
+				`, a).Scan(&in, &out, &n); err != nil {
+					t.Fatalf("journal: %v", err)
+				}
+				bal, err := r.GetBalance(ctx, a)
+				if err != nil {
+					t.Fatalf("balance: %v", err)
+				}
+				if bal != initial+in-out {
+					t.Fatalf("wallet %s: balance %d, journal says %d", a, bal, initial+in-out)
+				}
+				journaled += n
+				total += bal
+			}
+			if journaled != ok {
+				t.Fatalf("journal has %d transfers, %d committed", journaled, ok)
+			}
+			if total != wallets*initial {
+				t.Fatalf("sum of balances %d, want %d", total, wallets*initial)
+			}
+			if c, err := r.CheckMoneySupply(ctx); err != nil || !c.OK {
+				t.Fatalf("money supply: %+v, err=%v", c, err)
+			}
+		})
+	}
+}
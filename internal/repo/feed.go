@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// transactionsChannel, канал уведомлений о новых транзакциях, см. миграцию 0027
+const transactionsChannel = "transactions"
+
+// ListenTransactions, слушает уведомления о новых транзакциях на отдельном соединении и передает их id в fn в порядке коммитов, возвращается при отмене контекста или обрыве соединения, соединение в пул не возвращается
+func (r *PostgresRepo) ListenTransactions(ctx context.Context, fn func(id int64)) error {
+	return r.listen(ctx, []string{transactionsChannel}, nil, func(_, payload string) {
+		if id, err := strconv.ParseInt(payload, 10, 64); err == nil {
+			fn(id)
+		}
+	})
+}
+
+// listen, подписывается на каналы на отдельном соединении, listening, если не nil, вызывается, когда подписка уже действует, до первого уведомления,
+// возвращается при отмене контекста или обрыве соединения, соединение в пул не возвращается
+func (r *PostgresRepo) listen(ctx context.Context, channels []string, listening func(), fn func(channel, payload string)) error {
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var listenErr error
+	_ = conn.Raw(func(dc any) error {
+		pc := dc.(*stdlib.Conn).Conn()
+		for _, ch := range channels {
+			if _, listenErr = pc.Exec(ctx, "LISTEN "+ch); listenErr != nil {
+				return driver.ErrBadConn
+			}
+		}
+		if listening != nil {
+			listening()
+		}
+		for {
+			n, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				listenErr = err
+				// соединение с подпиской или прерванным чтением в пул не годится
+				return driver.ErrBadConn
+			}
+			fn(n.Channel, n.Payload)
+		}
+	})
+	if errors.Is(listenErr, context.Canceled) && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return listenErr
+}
+
+// TransactionsAfter, транзакции с id больше afterID по возрастанию id, не больше limit, для догона ленты после переподключения
+func (r *PostgresRepo) TransactionsAfter(ctx context.Context, afterID int64, limit int) ([]Transaction, error) {
+	return r.queryTransactions(ctx, `
+		SELECT `+txColumns+`
+		FROM transactions t
+		WHERE t.id > $1
+		ORDER BY t.id
+		LIMIT $2
+	`, afterID, limit)
+}
+
+// TransactionsByIDs, транзакции по списку id по возрастанию id, отсутствующие пропускаются
+func (r *PostgresRepo) TransactionsByIDs(ctx context.Context, ids []int64) ([]Transaction, error) {
+	return r.queryTransactions(ctx, `
+		SELECT `+txColumns+`
+		FROM transactions t
+		WHERE t.id = ANY($1)
+		ORDER BY t.id
+	`, ids)
+}
+
+// queryTransactions, выполняет запрос со столбцами txColumns и читает транзакции
+func (r *PostgresRepo) queryTransactions(ctx context.Context, query string, args ...any) ([]Transaction, error) {
+	rows, err := r.reader(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,335 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gotechtask/internal/money"
+)
+
+// AuditWalletHot, действие журнала аудита, кошелек помечен горячим или пометка снята
+const AuditWalletHot = "wallet.hot"
+
+// hotPendingCents, сумма неприменных зачислений кошелька из строки wallets, входит во все чтения баланса
+const hotPendingCents = `COALESCE((SELECT SUM(h.amount_cents) FROM hot_credits h WHERE h.address = wallets.address), 0)`
+
+// transferFlags, что перевод узнает о сторонах до блокировки, denied, одна из сторон в стоп-листе,
+// fromPending, у отправителя есть неприменные зачисления, toHot, получатель горячий
+type transferFlags struct {
+	denied      bool
+	fromPending bool
+	toHot       bool
+}
+
+// transferFlagsOf, флаги перевода одним запросом внутри транзакции
+func transferFlagsOf(ctx context.Context, tx *sql.Tx, from, to string) (transferFlags, error) {
+	var f transferFlags
+	err := tx.QueryRowContext(ctx, `
+		SELECT
+			EXISTS (SELECT 1 FROM denylist WHERE address = ANY($1)),
+			EXISTS (SELECT 1 FROM hot_credits WHERE address = $2),
+			EXISTS (SELECT 1 FROM wallets WHERE address = $3 AND hot_since IS NOT NULL)
+	`, []string{from, to}, from, to).Scan(&f.denied, &f.fromPending, &f.toHot)
+	return f, err
+}
+
+// hotTransferTx, перевод на горячий кошелек, блокируется только отправитель, зачисление ставится в очередь hot_credits и применяется к балансу получателя позже пачкой,
+// так переводы разных отправителей на один кошелек не ждут друг друга, операция и событие баланса отправителя пишутся сразу
+func hotTransferTx(ctx context.Context, tx *sql.Tx, from, to string, amountCents int64, fromPending bool) error {
+	got, err := lockWallets(ctx, tx, from, from)
+	if err != nil {
+		return err
+	}
+	if len(got) != 1 {
+		return missingWallet(got, from)
+	}
+	if err := closedWallet(got); err != nil {
+		return err
+	}
+	sender := got[0]
+
+	// баланс получателя без блокировки, он нужен только для проверки предела, внешний ключ очереди держит строку до коммита,
+	// горячий кошелек не закрывается слиянием, преемник здесь только на случай снятия отметки после закрытия
+	var toBal int64
+	var successor string
+	err = tx.QueryRowContext(ctx, `SELECT balance_cents + `+hotPendingCents+`, COALESCE(successor, '') FROM wallets WHERE address = $1`, to).Scan(&toBal, &successor)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &WalletNotFoundError{Address: to}
+	}
+	if err != nil {
+		return err
+	}
+	if successor != "" {
+		return &WalletClosedError{Address: to, Successor: successor}
+	}
+
+	fromBal := sender.bal
+	if fromPending {
+		if fromBal, err = applyHotCredits(ctx, tx, from, fromBal); err != nil {
+			return err
+		}
+	}
+	if fromBal+sender.overdraft < amountCents {
+		return &InsufficientFundsError{Address: from, NeededCents: amountCents, AvailableCents: fromBal + sender.overdraft}
+	}
+	if toNew, err := money.Add(toBal, amountCents); err != nil || toNew > money.MaxCents {
+		return ErrBalanceOverflow
+	}
+	if err := debitTx(ctx, tx, sender, fromBal, amountCents); err != nil {
+		return err
+	}
+
+	var id int64
+	err = tx.QueryRowContext(ctx, `
+		WITH t AS (
+			INSERT INTO transactions(from_address, to_address, amount_cents, initiated_by, channel, type, group_id)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid)
+			RETURNING id, created_at
+		), e AS (
+			INSERT INTO balance_events(address, tx_id, delta_cents, balance_cents, created_at)
+			SELECT $1, t.id, -$3::bigint, $8::bigint, t.created_at FROM t
+		), `+traceCTE(9)+`
+		INSERT INTO hot_credits(address, tx_id, amount_cents, created_at)
+		SELECT $2, t.id, $3, t.created_at FROM t
+		RETURNING tx_id
+	`, append([]any{from, to, amountCents, ActorFromContext(ctx), ChannelFromContext(ctx), TxTypeTransfer, TransferGroupFromContext(ctx), fromBal - amountCents}, traceArgs(ctx)...)...).Scan(&id)
+	if err != nil {
+		return err
+	}
+	// зачисление в очереди уже входит в видимый баланс получателя, проводка пишется сразу, применение очереди счет не меняет
+	return postLedger(ctx, tx, id, ledgerPosting{from, -amountCents}, ledgerPosting{to, amountCents})
+}
+
+// applyHotCredits, применяет очередь зачислений кошелька к его балансу одним обновлением, строка кошелька уже заблокирована вызывающим, bal, ее баланс,
+// на каждое зачисление пишется событие баланса с нарастающим итогом, отдает новый баланс, пустая очередь ничего не меняет
+func applyHotCredits(ctx context.Context, tx *sql.Tx, address string, bal int64) (int64, error) {
+	var n int
+	var next int64
+	if err := tx.QueryRowContext(ctx, `
+		WITH d AS (
+			DELETE FROM hot_credits WHERE address = $1
+			RETURNING id, tx_id, amount_cents
+		), e AS (
+			INSERT INTO balance_events(address, tx_id, delta_cents, balance_cents, created_at)
+			SELECT $1, tx_id, amount_cents, $2::bigint + SUM(amount_cents) OVER (ORDER BY id), now() FROM d
+			RETURNING balance_cents
+		)
+		SELECT COUNT(*), COALESCE(MAX(balance_cents), $2::bigint) FROM e
+	`, address, bal).Scan(&n, &next); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return bal, nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE wallets SET balance_cents = $1, updated_at = now(), last_tx_at = now(), low_balance_since = `+lowBalanceSince+` WHERE address = $2`,
+		next, address); err != nil {
+		if isBalanceOverflow(err) {
+			return 0, ErrBalanceOverflow
+		}
+		return 0, err
+	}
+	return next, nil
+}
+
+// applyAllHotCredits, применяет очереди зачислений всех кошельков внутри транзакции, для операций, которые держат таблицу кошельков целиком
+func applyAllHotCredits(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT address, balance_cents FROM wallets
+		WHERE address IN (SELECT address FROM hot_credits)
+		ORDER BY address
+		FOR NO KEY UPDATE
+	`)
+	if err != nil {
+		return err
+	}
+	type pending struct {
+		addr string
+		bal  int64
+	}
+	var ws []pending
+	for rows.Next() {
+		var w pending
+		if err := rows.Scan(&w.addr, &w.bal); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		ws = append(ws, w)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	for _, w := range ws {
+		if _, err := applyHotCredits(ctx, tx, w.addr, w.bal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyHotCredits, применяет очереди зачислений не более чем limit кошельков, каждый в своей транзакции, отдает число кошельков с примененной очередью,
+// строка кошелька блокируется FOR NO KEY UPDATE, это не мешает новым зачислениям в очередь, а переводы с кошелька ждут применения
+func (r *PostgresRepo) ApplyHotCredits(ctx context.Context, limit int) (int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := r.DB.QueryContext(ctx, `SELECT DISTINCT address FROM hot_credits LIMIT $1`, limit)
+	if err != nil {
+		return 0, err
+	}
+	var addrs []string
+	for rows.Next() {
+		var a string
+		if err := rows.Scan(&a); err != nil {
+			_ = rows.Close()
+			return 0, err
+		}
+		addrs = append(addrs, a)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return 0, err
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	for _, addr := range addrs {
+		err := retryDeadlocks(ctx, func() error {
+			tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+			if err != nil {
+				return err
+			}
+			defer func() { _ = tx.Rollback() }()
+
+			var bal int64
+			err = tx.QueryRowContext(ctx, `SELECT balance_cents FROM wallets WHERE address = $1 FOR NO KEY UPDATE`, addr).Scan(&bal)
+			if errors.Is(err, sql.ErrNoRows) {
+				// кошелек удален, его очередь ушла вместе с ним
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := applyHotCredits(ctx, tx, addr, bal); err != nil {
+				return err
+			}
+			return tx.Commit()
+		})
+		if err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// RecipientCounts, переводы с since, всего и по получателям, у которых их не меньше min, больше всего первыми
+func (r *PostgresRepo) RecipientCounts(ctx context.Context, since time.Time, min int) (int, []WalletCount, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT to_address, COUNT(*), SUM(COUNT(*)) OVER ()::bigint
+		FROM transactions
+		WHERE type = $1 AND created_at >= $2
+		GROUP BY to_address
+		ORDER BY COUNT(*) DESC, to_address
+	`, TxTypeTransfer, since)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	var total int
+	var out []WalletCount
+	for rows.Next() {
+		var c WalletCount
+		if err := rows.Scan(&c.Address, &c.Count, &total); err != nil {
+			return 0, nil, err
+		}
+		if c.Count >= min {
+			out = append(out, c)
+		}
+	}
+	return total, out, rows.Err()
+}
+
+// SetWalletHot, помечает кошелек горячим или снимает пометку, повторная установка того же состояния ничего не меняет и в аудит не пишется,
+// после снятия уже поставленные зачисления применяются обычным порядком
+func (r *PostgresRepo) SetWalletHot(ctx context.Context, address string, hot bool, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE wallets SET hot_since = CASE WHEN $1::boolean THEN now() END
+		WHERE address = $2 AND (hot_since IS NOT NULL) <> $1
+	`, hot, address)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE address = $1)`, address).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return &WalletNotFoundError{Address: address}
+		}
+		return nil
+	}
+
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditWalletHot,
+		Actor:   actor,
+		Address: address,
+		Details: map[string]any{"hot": hot},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// HotWallet, горячий кошелек или кошелек с неприменными зачислениями, глубина его очереди, Since, нулевое если пометка уже снята
+type HotWallet struct {
+	Address       string
+	Since         time.Time
+	PendingCount  int64
+	PendingCents  int64
+	OldestPending time.Time
+}
+
+// ListHotWallets, горячие кошельки и кошельки с очередью зачислений, самые глубокие очереди первыми
+func (r *PostgresRepo) ListHotWallets(ctx context.Context) ([]HotWallet, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT w.address, w.hot_since, COUNT(h.id), COALESCE(SUM(h.amount_cents), 0), MIN(h.created_at)
+		FROM wallets w
+		LEFT JOIN hot_credits h ON h.address = w.address
+		WHERE w.hot_since IS NOT NULL OR h.id IS NOT NULL
+		GROUP BY w.address, w.hot_since
+		ORDER BY COUNT(h.id) DESC, w.address
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []HotWallet
+	for rows.Next() {
+		var w HotWallet
+		var since, oldest sql.NullTime
+		if err := rows.Scan(&w.Address, &since, &w.PendingCount, &w.PendingCents, &oldest); err != nil {
+			return nil, err
+		}
+		w.Since, w.OldestPending = since.Time, oldest.Time
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,86 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestHotWallet_QueuedCredits, зачисления на горячий кошелек идут очередью и сразу видны в балансе, перевод с него и применение очереди пишут события с нарастающим итогом
+func TestHotWallet_QueuedCredits(t *testing.T) {
+	t.Parallel()
+
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := context.Background()
+
+	a, b, h := fx.Wallet(1000), fx.Wallet(1000), fx.Wallet(0)
+
+	if err := r.SetWalletHot(ctx, h, true, "test"); err != nil {
+		t.Fatalf("set hot: %v", err)
+	}
+	if err := r.Transfer(ctx, a, h, 300); err != nil {
+		t.Fatalf("transfer a->h: %v", err)
+	}
+	if err := r.Transfer(ctx, b, h, 200); err != nil {
+		t.Fatalf("transfer b->h: %v", err)
+	}
+
+	var raw int64
+	rawBalance := func() int64 {
+		t.Helper()
+		if err := db.QueryRow(`SELECT balance_cents FROM wallets WHERE address = $1`, h).Scan(&raw); err != nil {
+			t.Fatalf("raw balance: %v", err)
+		}
+		return raw
+	}
+	if got := rawBalance(); got != 0 {
+		t.Fatalf("hot wallet row touched by credit: %d", got)
+	}
+	if bal, err := r.GetBalance(ctx, h); err != nil || bal != 500 {
+		t.Fatalf("balance with queued credits = %d, %v", bal, err)
+	}
+	hot, err := r.ListHotWallets(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var found bool
+	for _, w := range hot {
+		if w.Address == h {
+			found = w.PendingCount == 2 && w.PendingCents == 500 && !w.Since.IsZero()
+		}
+	}
+	if !found {
+		t.Fatalf("queue depth of %s not listed: %+v", h, hot)
+	}
+
+	// перевод с горячего кошелька сначала применяет его очередь
+	if err := r.Transfer(ctx, h, a, 100); err != nil {
+		t.Fatalf("transfer h->a: %v", err)
+	}
+	if got := rawBalance(); got != 400 {
+		t.Fatalf("raw balance after debit = %d, want 400", got)
+	}
+	events, err := r.BalanceEvents(ctx, h, BalanceEventQuery{})
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if len(events) != 3 || events[2].BalanceCents != 300 || events[1].BalanceCents != 500 || events[0].BalanceCents != 400 {
+		t.Fatalf("events = %+v", events)
+	}
+
+	if err := r.Transfer(ctx, a, h, 50); err != nil {
+		t.Fatalf("transfer a->h: %v", err)
+	}
+	if _, err := r.ApplyHotCredits(ctx, 0); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got := rawBalance(); got != 450 {
+		t.Fatalf("raw balance after apply = %d, want 450", got)
+	}
+	if c, err := r.CheckMoneySupply(ctx); err != nil || !c.OK {
+		t.Fatalf("supply: %+v, %v", c, err)
+	}
+}
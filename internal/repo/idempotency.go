@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ошибки ключей идемпотентности
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key reused with a different request")
+	ErrIdempotencyInProgress = errors.New("request with this idempotency key is in progress")
+)
+
+// IdempotentResponse, сохраненный ответ на запрос с ключом идемпотентности, Location, заголовок ответа, создавшего ресурс, пустой у остальных
+type IdempotentResponse struct {
+	StatusCode int
+	Body       []byte
+	Location   string
+}
+
+// BeginIdempotent, занимает ключ участника под запрос с отпечатком fingerprint, nil значит ключ свободен и запрос надо выполнить, иначе возвращается сохраненный ответ, ключи старше ttl забываются
+func (r *PostgresRepo) BeginIdempotent(ctx context.Context, actor, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, error) {
+	// истекшие ключи участника, в том числе оставшиеся занятыми после падения сервиса
+	if _, err := r.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE actor = $1 AND created_at < now() - $2 * interval '1 second'
+	`, actor, int64(ttl/time.Second)); err != nil {
+		return nil, err
+	}
+
+	res, err := r.DB.ExecContext(ctx, `
+		INSERT INTO idempotency_keys(actor, key, fingerprint) VALUES ($1, $2, $3)
+		ON CONFLICT (actor, key) DO NOTHING
+	`, actor, key, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		return nil, nil
+	}
+
+	var (
+		fp       string
+		status   sql.NullInt64
+		body     []byte
+		location sql.NullString
+	)
+	err = r.DB.QueryRowContext(ctx, `
+		SELECT fingerprint, status_code, response, location FROM idempotency_keys WHERE actor = $1 AND key = $2
+	`, actor, key).Scan(&fp, &status, &body, &location)
+	if errors.Is(err, sql.ErrNoRows) {
+		// ключ освободили между вставкой и чтением, повтор его займет
+		return nil, ErrIdempotencyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	if fp != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if !status.Valid {
+		return nil, ErrIdempotencyInProgress
+	}
+	return &IdempotentResponse{StatusCode: int(status.Int64), Body: body, Location: location.String}, nil
+}
+
+// CompleteIdempotent, сохраняет ответ на запрос с занятым ключом
+func (r *PostgresRepo) CompleteIdempotent(ctx context.Context, actor, key string, resp IdempotentResponse) error {
+	_, err := r.DB.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status_code = $3, response = $4, location = NULLIF($5, '') WHERE actor = $1 AND key = $2
+	`, actor, key, resp.StatusCode, resp.Body, resp.Location)
+	return err
+}
+
+// ReleaseIdempotent, освобождает ключ запроса без результата, например после ошибки, которую стоит повторить
+func (r *PostgresRepo) ReleaseIdempotent(ctx context.Context, actor, key string) error {
+	_, err := r.DB.ExecContext(ctx, `
+		DELETE FROM idempotency_keys WHERE actor = $1 AND key = $2 AND status_code IS NULL
+	`, actor, key)
+	return err
+}
@@ -0,0 +1,227 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestTryLock_TwoInstances, блокировку прохода держит один экземпляр, второй ее не получает, пока первый не отпустит
+func TestTryLock_TwoInstances(t *testing.T) {
+	t.Parallel()
+
+	db1, db2 := testfixtures.Open(t), testfixtures.Open(t)
+	r1, r2 := NewPostgres(db1), NewPostgres(db2)
+	ctx := context.Background()
+	name := "test-" + randomAddress()[:8]
+
+	unlock, ok, err := r1.TryLock(ctx, name)
+	if err != nil || !ok {
+		t.Fatalf("first instance: ok=%v err=%v", ok, err)
+	}
+	if _, ok, err := r2.TryLock(ctx, name); err != nil || ok {
+		t.Fatalf("second instance must not get a held lock: ok=%v err=%v", ok, err)
+	}
+	unlock()
+	unlock2, ok, err := r2.TryLock(ctx, name)
+	if err != nil || !ok {
+		t.Fatalf("second instance after release: ok=%v err=%v", ok, err)
+	}
+	unlock2()
+}
+
+// TestJobs_TwoInstances, два экземпляра разбирают очередь одновременно, каждая задача достается одному, исход попытки, чье закрепление перехватили, не записывается
+func TestJobs_TwoInstances(t *testing.T) {
+	t.Parallel()
+
+	db1, db2 := testfixtures.Open(t), testfixtures.Open(t)
+	repos := []*PostgresRepo{NewPostgres(db1), NewPostgres(db2)}
+	ctx := context.Background()
+
+	kind := "test-" + randomAddress()[:8]
+	defer db1.Exec(`DELETE FROM jobs WHERE kind = $1`, kind)
+	const n = 50
+	for i := 0; i < n; i++ {
+		if err := repos[0].EnqueueJob(ctx, kind, map[string]int{"i": i}); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		claimed = map[int64]int{}
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(r *PostgresRepo) {
+			defer wg.Done()
+			for {
+				jobs, err := r.ClaimJobs(ctx, 5)
+				if err != nil {
+					t.Errorf("claim: %v", err)
+					return
+				}
+				mine := 0
+				for _, j := range jobs {
+					if j.Kind != kind {
+						// чужая задача из других тестов возвращается в очередь как была
+						_, _ = r.DB.Exec(`UPDATE jobs SET status = 'pending', attempts = attempts - 1, locked_until = NULL WHERE id = $1`, j.ID)
+						continue
+					}
+					mine++
+					mu.Lock()
+					claimed[j.ID]++
+					mu.Unlock()
+					if err := r.CompleteJob(ctx, j.ID, j.Attempts); err != nil {
+						t.Errorf("complete %d: %v", j.ID, err)
+					}
+				}
+				if mine == 0 {
+					return
+				}
+			}
+		}(repos[i%2])
+	}
+	wg.Wait()
+
+	if len(claimed) != n {
+		t.Fatalf("want %d jobs claimed, got %d", n, len(claimed))
+	}
+	for id, c := range claimed {
+		if c != 1 {
+			t.Fatalf("job %d claimed %d times", id, c)
+		}
+	}
+
+	// закрепление истекло, задачу забрал второй экземпляр, первый завершает ее уже не своей попыткой
+	if err := repos[0].EnqueueJob(ctx, kind, nil); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	var id int64
+	if err := db1.QueryRow(`UPDATE jobs SET status = 'running', attempts = 1, locked_until = now() - interval '1 second' WHERE kind = $1 AND status = 'pending' RETURNING id`, kind).Scan(&id); err != nil {
+		t.Fatalf("expire lease: %v", err)
+	}
+	var attempts int
+	if err := db2.QueryRow(`UPDATE jobs SET attempts = attempts + 1, locked_until = now() + interval '5 minutes' WHERE id = $1 RETURNING attempts`, id).Scan(&attempts); err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if err := repos[0].StartJob(ctx, id, 1); !errors.Is(err, ErrJobLeaseLost) {
+		t.Fatalf("stale start: want ErrJobLeaseLost, got %v", err)
+	}
+	if err := repos[1].StartJob(ctx, id, attempts); err != nil {
+		t.Fatalf("current start: %v", err)
+	}
+	if err := repos[0].CompleteJob(ctx, id, 1); !errors.Is(err, ErrJobLeaseLost) {
+		t.Fatalf("stale complete: want ErrJobLeaseLost, got %v", err)
+	}
+	if err := repos[0].FailJob(ctx, id, 1, errors.New("boom"), time.Now()); !errors.Is(err, ErrJobLeaseLost) {
+		t.Fatalf("stale fail: want ErrJobLeaseLost, got %v", err)
+	}
+	if err := repos[1].CompleteJob(ctx, id, attempts); err != nil {
+		t.Fatalf("current complete: %v", err)
+	}
+}
+
+// TestListenBalances_TwoInstances, перевод на одном экземпляре после коммита приходит второму уведомлениями по адресам обеих сторон,
+// зачисление в очередь горячего кошелька тоже, а откаченное изменение не приходит
+func TestListenBalances_TwoInstances(t *testing.T) {
+	t.Parallel()
+
+	db1, db2 := testfixtures.Open(t), testfixtures.Open(t)
+	r1, r2 := NewPostgres(db1), NewPostgres(db2)
+	fx := testfixtures.New(t, db2)
+	ctx, cancel := context.WithCancel(WithCaller(context.Background(), Caller{Admin: true, Channel: ChannelCLI}))
+	defer cancel()
+
+	a, b, hot, rolled := fx.Wallet(1000), fx.Wallet(0), fx.Wallet(0), fx.Wallet(0)
+	if err := r2.SetWalletHot(ctx, hot, true, "test"); err != nil {
+		t.Fatalf("set hot: %v", err)
+	}
+
+	got := make(chan string, 64)
+	listening := make(chan struct{})
+	go r1.ListenBalances(ctx, func() { close(listening) }, func(addr string) {
+		if addr == a || addr == b || addr == hot || addr == rolled {
+			got <- addr
+		}
+	})
+	select {
+	case <-listening:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not subscribe")
+	}
+
+	tx, err := db2.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	if _, err := tx.Exec(`UPDATE wallets SET balance_cents = 5 WHERE address = $1`, rolled); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	_ = tx.Rollback()
+
+	if err := r2.Transfer(ctx, a, b, 100); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if err := r2.Transfer(ctx, a, hot, 100); err != nil {
+		t.Fatalf("hot transfer: %v", err)
+	}
+
+	want := map[string]bool{a: true, b: true, hot: true}
+	seen := map[string]bool{}
+	for len(seen) < len(want) {
+		select {
+		case addr := <-got:
+			if !want[addr] {
+				t.Fatalf("notification for %s", addr)
+			}
+			seen[addr] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("notifications: got %v, want %v", seen, want)
+		}
+	}
+}
+
+// TestListenAPIKeys_TwoInstances, отзыв ключа на одном экземпляре после коммита приходит второму уведомлением с идентификатором ключа
+func TestListenAPIKeys_TwoInstances(t *testing.T) {
+	t.Parallel()
+
+	db1, db2 := testfixtures.Open(t), testfixtures.Open(t)
+	r1, r2 := NewPostgres(db1), NewPostgres(db2)
+	fx := testfixtures.New(t, db2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	u, _ := fx.UserWithKey()
+	var id int64
+	if err := db2.QueryRow(`SELECT id FROM api_keys WHERE user_id = $1`, u.ID).Scan(&id); err != nil {
+		t.Fatalf("key id: %v", err)
+	}
+
+	got := make(chan int64, 8)
+	listening := make(chan struct{})
+	go r1.ListenAPIKeys(ctx, func() { close(listening) }, func(key int64) {
+		if key == id {
+			got <- key
+		}
+	})
+	select {
+	case <-listening:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not subscribe")
+	}
+
+	if err := r2.RevokeAPIKey(ctx, u.ID, id); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	select {
+	case <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification for revoked key")
+	}
+}
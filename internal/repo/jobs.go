@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Job, фоновая задача из таблицы jobs, вид, полезная нагрузка, номер текущей попытки и их предел
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempts    int
+	MaxAttempts int
+}
+
+// jobLease, на сколько задача закрепляется за обработчиком, после истечения ее может забрать другой экземпляр
+const jobLease = 5 * time.Minute
+
+// ErrJobLeaseLost, задачу после истечения закрепления забрал другой обработчик, исход прежнего не записывается
+var ErrJobLeaseLost = errors.New("job lease lost")
+
+// EnqueueJob, ставит задачу в очередь на немедленное выполнение
+func (r *PostgresRepo) EnqueueJob(ctx context.Context, kind string, payload any) error {
+	return enqueueJob(ctx, r.DB, kind, payload, time.Time{})
+}
+
+// enqueueJob, ставит задачу через переданное соединение или транзакцию, нулевой runAt означает сейчас
+func enqueueJob(ctx context.Context, ex execer, kind string, payload any, runAt time.Time) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var at any
+	if !runAt.IsZero() {
+		at = runAt
+	}
+	_, err = ex.ExecContext(ctx, `
+		INSERT INTO jobs(kind, payload, run_at)
+		VALUES ($1, $2::jsonb, COALESCE($3::timestamptz, now()))
+	`, kind, string(b), at)
+	return err
+}
+
+// ClaimJobs, забирает до limit готовых задач, в том числе брошенные упавшими обработчиками, skip locked позволяет нескольким экземплярам разбирать очередь без конфликтов
+func (r *PostgresRepo) ClaimJobs(ctx context.Context, limit int) ([]Job, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		UPDATE jobs
+		SET status = 'running', attempts = attempts + 1,
+		    locked_until = now() + make_interval(secs => $2), updated_at = now()
+		WHERE id IN (
+			SELECT id FROM jobs
+			WHERE (status = 'pending' AND run_at <= now())
+			   OR (status = 'running' AND locked_until < now())
+			ORDER BY run_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, kind, payload, attempts, max_attempts
+	`, limit, jobLease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Job
+	for rows.Next() {
+		var j Job
+		var payload []byte
+		if err := rows.Scan(&j.ID, &j.Kind, &payload, &j.Attempts, &j.MaxAttempts); err != nil {
+			return nil, err
+		}
+		j.Payload = payload
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// StartJob, продлевает закрепление попытки attempt перед запуском обработчика, задачи пачки ждут своей очереди
+// и без продления закрепление могло истечь раньше старта, если задачу уже забрал другой обработчик, ErrJobLeaseLost
+func (r *PostgresRepo) StartJob(ctx context.Context, id int64, attempt int) error {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE jobs SET locked_until = now() + make_interval(secs => $3), updated_at = now()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'
+	`, id, attempt, jobLease.Seconds())
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// CompleteJob, отмечает задачу выполненной, attempt, номер попытки из ClaimJobs, если задачу с тех пор забрал другой обработчик, ErrJobLeaseLost
+func (r *PostgresRepo) CompleteJob(ctx context.Context, id int64, attempt int) error {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE jobs SET status = 'done', locked_until = NULL, updated_at = now()
+		WHERE id = $1 AND attempts = $2 AND status = 'running'
+	`, id, attempt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// FailJob, записывает ошибку, при исчерпании попыток или нулевом retryAt задача помечается failed, иначе возвращается в очередь на retryAt,
+// как и CompleteJob, пишет исход только попытки attempt, иначе ErrJobLeaseLost
+func (r *PostgresRepo) FailJob(ctx context.Context, id int64, attempt int, cause error, retryAt time.Time) error {
+	var at any
+	if !retryAt.IsZero() {
+		at = retryAt
+	}
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE jobs
+		SET status = CASE WHEN $3::timestamptz IS NULL OR attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+		    run_at = COALESCE($3::timestamptz, run_at),
+		    last_error = $2, locked_until = NULL, updated_at = now()
+		WHERE id = $1 AND attempts = $4 AND status = 'running'
+	`, id, cause.Error(), at, attempt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// WalletEmail, возвращает адрес почты кошелька, пустая строка если не задан
+func (r *PostgresRepo) WalletEmail(ctx context.Context, address string) (string, error) {
+	var email sql.NullString
+	if err := r.DB.QueryRowContext(ctx, `SELECT email FROM wallets WHERE address=$1`, address).Scan(&email); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", &WalletNotFoundError{Address: address}
+		}
+		return "", err
+	}
+	return email.String, nil
+}
+
+// AuditWalletEmail, действие журнала аудита, изменение адреса почты кошелька
+const AuditWalletEmail = "wallet.email"
+
+// SetWalletEmail, задает или очищает адрес почты кошелька для квитанций
+func (r *PostgresRepo) SetWalletEmail(ctx context.Context, address, email, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := setWalletEmailTx(ctx, tx, address, email, actor); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setWalletEmailTx, почта кошелька в транзакции tx вместе с записью аудита, версия настроек кошелька растет
+func setWalletEmailTx(ctx context.Context, tx *sql.Tx, address, email, actor string) error {
+	res, err := tx.ExecContext(ctx, `
+		UPDATE wallets SET email = NULLIF($1, ''), updated_at = now(), meta_version = meta_version + 1 WHERE address = $2
+	`, email, address)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return &WalletNotFoundError{Address: address}
+	}
+	return insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditWalletEmail,
+		Actor:   actor,
+		Address: address,
+		Details: map[string]any{"email": email},
+	})
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+	"database/sql/driver"
+)
+
+// TryLock, берет сессионную рекомендательную блокировку name на отдельном соединении без ожидания, ok false, ее держит другой экземпляр,
+// имя блокировки включает арендатора соединения, так что экземпляры разных арендаторов друг другу не мешают, упавший экземпляр теряет блокировку вместе с соединением
+func (r *PostgresRepo) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := r.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	var ok bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock(hashtext(app_tenant() || '/' || $1))`, name).Scan(&ok); err != nil || !ok {
+		_ = conn.Close()
+		return nil, false, err
+	}
+	unlock := func() {
+		// контекст прохода может быть уже отменен, а соединение с блокировкой в пул возвращать нельзя
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock(hashtext(app_tenant() || '/' || $1))`, name); err != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}
+	return unlock, true, nil
+}
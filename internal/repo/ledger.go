@@ -0,0 +1,224 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LedgerMode, режим перехода на счета и проводки, off, пишется только колонка баланса, dual, каждое изменение баланса пишется еще и проводкой,
+// shadow, двойная запись и теневое чтение, чтение баланса сверяет колонку со счетом и отмечает расхождения, ответ по-прежнему из колонки
+type LedgerMode string
+
+// режимы перехода на счета
+const (
+	LedgerOff    LedgerMode = "off"
+	LedgerDual   LedgerMode = "dual"
+	LedgerShadow LedgerMode = "shadow"
+)
+
+// ParseLedgerMode, режим по имени, пустое имя выключает
+func ParseLedgerMode(s string) (LedgerMode, error) {
+	switch m := LedgerMode(s); m {
+	case "":
+		return LedgerOff, nil
+	case LedgerOff, LedgerDual, LedgerShadow:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown ledger mode %q, want off, dual or shadow", s)
+}
+
+// ledgerMode, режим процесса, общий для всех переводов, как режим сбоев
+var ledgerMode atomic.Value
+
+// SetLedgerMode, включает режим для всех переводов и чтений процесса, выключение не удаляет счета, а следующее включение не знает о пропущенных проводках,
+// поэтому после выключения счета очищают ResetLedger
+func SetLedgerMode(m LedgerMode) {
+	ledgerMode.Store(m)
+}
+
+// CurrentLedgerMode, режим процесса, по умолчанию off
+func CurrentLedgerMode() LedgerMode {
+	m, _ := ledgerMode.Load().(LedgerMode)
+	if m == "" {
+		return LedgerOff
+	}
+	return m
+}
+
+// ledgerPosting, проводка стороны операции, изменение видимого баланса кошелька
+type ledgerPosting struct {
+	address string
+	delta   int64
+}
+
+// postLedger, пишет проводки операции txID, если включена двойная запись, вызывается после изменения баланса в той же транзакции,
+// счет без строки открывается с остатком, равным видимому балансу кошелька с очередью зачислений за вычетом проводки,
+// так счет, открытый посреди работы, сразу сходится с колонкой, а одновременное открытие вторым переводом превращается в обычное зачисление на счет
+func postLedger(ctx context.Context, ex execer, txID int64, postings ...ledgerPosting) error {
+	if CurrentLedgerMode() == LedgerOff || len(postings) == 0 {
+		return nil
+	}
+	addrs := make([]string, len(postings))
+	deltas := make([]int64, len(postings))
+	for i, p := range postings {
+		addrs[i], deltas[i] = p.address, p.delta
+	}
+	_, err := ex.ExecContext(ctx, `
+		WITH p AS (
+			SELECT address, delta FROM unnest($2::text[], $3::bigint[]) AS p(address, delta)
+		), a AS (
+			INSERT INTO ledger_accounts AS la (address, opening_cents, balance_cents)
+			SELECT p.address, wallets.balance_cents + `+hotPendingCents+` - p.delta, wallets.balance_cents + `+hotPendingCents+`
+			FROM p JOIN wallets ON wallets.address = p.address
+			ON CONFLICT (address) DO UPDATE SET balance_cents = la.balance_cents + EXCLUDED.balance_cents - EXCLUDED.opening_cents, updated_at = now()
+		)
+		INSERT INTO ledger_entries(address, tx_id, delta_cents)
+		SELECT address, $1, delta FROM p
+	`, txID, addrs, deltas)
+	return err
+}
+
+// ledgerBalance, подзапрос баланса счета к строке wallets для теневого чтения, NULL, если счет не открыт
+const ledgerBalance = `(SELECT la.balance_cents FROM ledger_accounts la WHERE la.address = wallets.address)`
+
+// shadowRow, строка чтения баланса с добавленным в конец балансом счета
+type shadowRow struct {
+	row    interface{ Scan(...any) error }
+	ledger *sql.NullInt64
+}
+
+func (s shadowRow) Scan(dest ...any) error { return s.row.Scan(append(dest, s.ledger)...) }
+
+// LedgerMismatch, кошелек, у которого колонка баланса не сходится со счетом, JournalCents, остаток открытия плюс сумма проводок, его знает только полная сверка,
+// At, время теневого чтения
+type LedgerMismatch struct {
+	Address      string
+	BalanceCents int64
+	LedgerCents  int64
+	JournalCents int64
+	At           time.Time
+}
+
+// ShadowStats, итоги теневого чтения процесса с запуска, Compared, чтения с открытым счетом, Unopened, чтения кошельков без счета,
+// Recent, последние расхождения, не больше shadowRecent
+type ShadowStats struct {
+	Reads      int64
+	Compared   int64
+	Unopened   int64
+	Mismatches int64
+	Recent     []LedgerMismatch
+}
+
+// shadowRecent, сколько последних расхождений теневого чтения помнит процесс
+const shadowRecent = 50
+
+var shadow struct {
+	reads, compared, unopened, mismatches atomic.Int64
+
+	mu     sync.Mutex
+	recent []LedgerMismatch
+}
+
+// shadowCompare, сверяет прочитанный баланс со счетом одной строки, расхождение пишется в лог и в последние расхождения
+func shadowCompare(address string, balance int64, ledger sql.NullInt64) {
+	shadow.reads.Add(1)
+	if !ledger.Valid {
+		shadow.unopened.Add(1)
+		return
+	}
+	shadow.compared.Add(1)
+	if ledger.Int64 == balance {
+		return
+	}
+	shadow.mismatches.Add(1)
+	log.Printf("ledger shadow mismatch: address=%s balance=%d ledger=%d", address, balance, ledger.Int64)
+
+	shadow.mu.Lock()
+	defer shadow.mu.Unlock()
+	if len(shadow.recent) == shadowRecent {
+		shadow.recent = append(shadow.recent[:0], shadow.recent[1:]...)
+	}
+	shadow.recent = append(shadow.recent, LedgerMismatch{Address: address, BalanceCents: balance, LedgerCents: ledger.Int64, At: time.Now().UTC()})
+}
+
+// LedgerShadowStats, итоги теневого чтения процесса, последние расхождения от старых к новым
+func LedgerShadowStats() ShadowStats {
+	shadow.mu.Lock()
+	recent := append([]LedgerMismatch(nil), shadow.recent...)
+	shadow.mu.Unlock()
+	return ShadowStats{
+		Reads:      shadow.reads.Load(),
+		Compared:   shadow.compared.Load(),
+		Unopened:   shadow.unopened.Load(),
+		Mismatches: shadow.mismatches.Load(),
+		Recent:     recent,
+	}
+}
+
+// LedgerComparison, итог полной сверки колонки баланса со счетами, Mismatches не больше ledgerCompareLimit, Total, все расхождения
+type LedgerComparison struct {
+	Wallets    int64
+	Opened     int64
+	Total      int64
+	Mismatches []LedgerMismatch
+}
+
+// ledgerCompareLimit, сколько расхождений отдает полная сверка
+const ledgerCompareLimit = 100
+
+// CompareLedger, сверяет каждый кошелек со счетом в одном снимке базы, расхождение, счет не равен видимому балансу кошелька
+// или сумме остатка открытия и проводок, неоткрытые счета считаются отдельно и расхождением не считаются
+func (r *PostgresRepo) CompareLedger(ctx context.Context) (LedgerComparison, error) {
+	var c LedgerComparison
+	var list []LedgerMismatch
+	err := r.ReadConsistent(ctx, func(ctx context.Context) error {
+		db := r.reader(ctx)
+		if err := db.QueryRowContext(ctx, `
+			SELECT (SELECT COUNT(*) FROM wallets), (SELECT COUNT(*) FROM ledger_accounts)
+		`).Scan(&c.Wallets, &c.Opened); err != nil {
+			return err
+		}
+
+		rows, err := db.QueryContext(ctx, `
+			SELECT s.address, s.balance, s.ledger, s.journal FROM (
+				SELECT wallets.address, wallets.balance_cents + `+hotPendingCents+` AS balance, la.balance_cents AS ledger,
+					la.opening_cents + COALESCE((SELECT SUM(e.delta_cents) FROM ledger_entries e WHERE e.address = la.address), 0) AS journal
+				FROM wallets JOIN ledger_accounts la ON la.address = wallets.address
+			) s
+			WHERE s.balance <> s.ledger OR s.journal <> s.ledger
+			ORDER BY s.address
+		`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var m LedgerMismatch
+			if err := rows.Scan(&m.Address, &m.BalanceCents, &m.LedgerCents, &m.JournalCents); err != nil {
+				return err
+			}
+			c.Total++
+			if len(list) < ledgerCompareLimit {
+				list = append(list, m)
+			}
+		}
+		return rows.Err()
+	})
+	c.Mismatches = list
+	return c, wrapf(err, "compare ledger")
+}
+
+// ResetLedger, удаляет все счета и проводки, нужен перед повторным включением двойной записи, иначе счета не знают о пропущенных проводках,
+// с арендатором удаляются только его счета
+func (r *PostgresRepo) ResetLedger(ctx context.Context) (int64, error) {
+	res, err := r.DB.ExecContext(ctx, `DELETE FROM ledger_accounts`)
+	if err != nil {
+		return 0, wrapf(err, "reset ledger")
+	}
+	return res.RowsAffected()
+}
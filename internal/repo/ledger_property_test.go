@@ -0,0 +1,238 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	mrand "math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"gotechtask/internal/money"
+	"gotechtask/internal/testfixtures"
+)
+
+// виды операций сценария, перевод, отмена ранее прошедшего перевода обратным переводом, изменение лимита овердрафта
+const (
+	opTransfer = iota
+	opReversal
+	opOverdraft
+)
+
+// ledgerOp, операция сценария, кошельки задаются индексами, Ref у отмены, номер среди уже прошедших переводов
+type ledgerOp struct {
+	Kind     int
+	From, To int
+	Amount   int64
+	Ref      int
+}
+
+// ledgerScenario, начальные балансы кошельков и последовательность операций
+type ledgerScenario struct {
+	Initial []int64
+	Ops     []ledgerOp
+}
+
+// Generate, случайный сценарий для testing/quick, суммы иногда больше балансов, чтобы попадать в отказы
+func (ledgerScenario) Generate(rnd *mrand.Rand, size int) reflect.Value {
+	sc := ledgerScenario{Initial: make([]int64, 2+rnd.Intn(4))}
+	for i := range sc.Initial {
+		sc.Initial[i] = rnd.Int63n(5000)
+	}
+	n := len(sc.Initial)
+	for range 1 + rnd.Intn(size+1) {
+		op := ledgerOp{From: rnd.Intn(n), To: rnd.Intn(n), Amount: 1 + rnd.Int63n(3000), Ref: rnd.Intn(1 << 16)}
+		switch k := rnd.Intn(10); {
+		case k < 6:
+			op.Kind = opTransfer
+		case k < 8:
+			op.Kind = opReversal
+		default:
+			op.Kind = opOverdraft
+			op.Amount = rnd.Int63n(2000)
+		}
+		sc.Ops = append(sc.Ops, op)
+	}
+	return reflect.ValueOf(sc)
+}
+
+// testLedger, часть репозитория, которую проверяют свойства
+type testLedger interface {
+	Transfer(ctx context.Context, from, to string, amountCents int64) error
+	SetOverdraftLimit(ctx context.Context, address string, limitCents int64, actor string) error
+	GetBalance(ctx context.Context, address string) (int64, error)
+}
+
+// memLedger, эталонный журнал в памяти, те же правила, что у transferTx и SetOverdraftLimit
+type memLedger struct {
+	bal       map[string]int64
+	overdraft map[string]int64
+}
+
+func newMemLedger(addrs []string, initial []int64) *memLedger {
+	m := &memLedger{bal: map[string]int64{}, overdraft: map[string]int64{}}
+	for i, a := range addrs {
+		m.bal[a] = initial[i]
+	}
+	return m
+}
+
+func (m *memLedger) Transfer(_ context.Context, from, to string, amountCents int64) error {
+	if from == to {
+		return ErrSameAddress
+	}
+	if m.bal[from]+m.overdraft[from] < amountCents {
+		return ErrInsufficientFunds
+	}
+	if m.bal[to] > money.MaxCents-amountCents {
+		return ErrBalanceOverflow
+	}
+	m.bal[from] -= amountCents
+	m.bal[to] += amountCents
+	return nil
+}
+
+func (m *memLedger) SetOverdraftLimit(_ context.Context, address string, limitCents int64, _ string) error {
+	if m.bal[address] < -limitCents {
+		return ErrOverdraftInUse
+	}
+	m.overdraft[address] = limitCents
+	return nil
+}
+
+func (m *memLedger) GetBalance(_ context.Context, address string) (int64, error) {
+	return m.bal[address], nil
+}
+
+// runScenario, прогоняет сценарий на журнале и после каждой операции проверяет инварианты, сумма балансов не меняется, баланс не ниже минус лимита овердрафта, кошелек без овердрафта не уходит в минус
+// oracle, если задан, получает те же операции, исход каждой должен совпасть, пустая строка значит что свойства выполнены
+func runScenario(ctx context.Context, l testLedger, oracle *memLedger, addrs []string, sc ledgerScenario) string {
+	var total int64
+	for _, c := range sc.Initial {
+		total += c
+	}
+	overdraft := make(map[string]int64)
+	type done struct {
+		from, to string
+		amount   int64
+	}
+	var transfers []done
+
+	for i, op := range sc.Ops {
+		from, to := addrs[op.From], addrs[op.To]
+		var err, want error
+		switch op.Kind {
+		case opTransfer, opReversal:
+			amount := op.Amount
+			if op.Kind == opReversal {
+				if len(transfers) == 0 {
+					continue
+				}
+				d := transfers[op.Ref%len(transfers)]
+				from, to, amount = d.to, d.from, d.amount
+			}
+			err = l.Transfer(ctx, from, to, amount)
+			if oracle != nil {
+				want = oracle.Transfer(ctx, from, to, amount)
+			}
+			if err == nil {
+				transfers = append(transfers, done{from, to, amount})
+			}
+		case opOverdraft:
+			err = l.SetOverdraftLimit(ctx, from, op.Amount, "test")
+			if oracle != nil {
+				want = oracle.SetOverdraftLimit(ctx, from, op.Amount, "test")
+			}
+			if err == nil {
+				overdraft[from] = op.Amount
+			}
+		}
+		if oracle != nil && err != want {
+			return fmt.Sprintf("op %d %+v: got %v, model says %v", i, op, err, want)
+		}
+		switch err {
+		case nil, ErrSameAddress, ErrInsufficientFunds, ErrBalanceOverflow, ErrOverdraftInUse:
+		default:
+			return fmt.Sprintf("op %d %+v: unexpected error %v", i, op, err)
+		}
+
+		var sum int64
+		for _, a := range addrs {
+			b, err := l.GetBalance(ctx, a)
+			if err != nil {
+				return fmt.Sprintf("op %d: balance: %v", i, err)
+			}
+			if b < -overdraft[a] {
+				return fmt.Sprintf("op %d %+v: wallet %s balance %d below overdraft %d", i, op, a, b, overdraft[a])
+			}
+			sum += b
+		}
+		if sum != total {
+			return fmt.Sprintf("op %d %+v: sum of balances %d, want %d", i, op, sum, total)
+		}
+	}
+	return ""
+}
+
+// TestLedgerProperties_Memory, свойства переводов на эталонном журнале, заодно проверяет сам генератор сценариев
+func TestLedgerProperties_Memory(t *testing.T) {
+	ctx := context.Background()
+	prop := func(sc ledgerScenario) bool {
+		addrs := make([]string, len(sc.Initial))
+		for i := range addrs {
+			addrs[i] = fmt.Sprintf("w%d", i)
+		}
+		if msg := runScenario(ctx, newMemLedger(addrs, sc.Initial), nil, addrs, sc); msg != "" {
+			t.Log(msg)
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 500}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestLedgerProperties_Postgres, те же сценарии на postgres, исход каждой операции совпадает с эталоном, инварианты держатся после каждой операции, балансы сходятся с журналом транзакций
+func TestLedgerProperties_Postgres(t *testing.T) {
+	t.Parallel()
+
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := context.Background()
+
+	prop := func(sc ledgerScenario) bool {
+		addrs := make([]string, len(sc.Initial))
+		for i := range addrs {
+			addrs[i] = fx.Wallet(sc.Initial[i])
+		}
+
+		if msg := runScenario(ctx, r, newMemLedger(addrs, sc.Initial), addrs, sc); msg != "" {
+			t.Log(msg)
+			return false
+		}
+		for i, a := range addrs {
+			var in, out int64
+			if err := db.QueryRow(`
+				SELECT COALESCE(SUM(amount_cents) FILTER (WHERE to_address = $1), 0),
+				       COALESCE(SUM(amount_cents) FILTER (WHERE from_address = $1), 0)
+				FROM transactions WHERE from_address = $1 OR to_address = $1
+			`, a).Scan(&in, &out); err != nil {
+				t.Fatalf("journal: %v", err)
+			}
+			bal, err := r.GetBalance(ctx, a)
+			if err != nil {
+				t.Fatalf("balance: %v", err)
+			}
+			if bal != sc.Initial[i]+in-out {
+				t.Logf("wallet %s: balance %d, journal says %d", a, bal, sc.Initial[i]+in-out)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(prop, &quick.Config{MaxCount: 30}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+func TestParseLedgerMode(t *testing.T) {
+	for in, want := range map[string]LedgerMode{"": LedgerOff, "off": LedgerOff, "dual": LedgerDual, "shadow": LedgerShadow} {
+		if got, err := ParseLedgerMode(in); err != nil || got != want {
+			t.Fatalf("ParseLedgerMode(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseLedgerMode("on"); err == nil {
+		t.Fatal("want error for unknown mode")
+	}
+}
+
+// TestShadowCompare, неоткрытый счет считается отдельно, расхождение попадает в последние, старые вытесняются
+func TestShadowCompare(t *testing.T) {
+	before := LedgerShadowStats()
+	shadowCompare("w1", 100, sql.NullInt64{})
+	shadowCompare("w1", 100, sql.NullInt64{Int64: 100, Valid: true})
+	for i := 0; i < shadowRecent+1; i++ {
+		shadowCompare("w2", 100, sql.NullInt64{Int64: int64(i), Valid: true})
+	}
+	st := LedgerShadowStats()
+	if st.Reads-before.Reads != shadowRecent+3 || st.Unopened-before.Unopened != 1 || st.Compared-before.Compared != shadowRecent+2 {
+		t.Fatalf("counters: before %+v after %+v", before, st)
+	}
+	if st.Mismatches-before.Mismatches != shadowRecent+1 {
+		t.Fatalf("want %d mismatches, got %d", shadowRecent+1, st.Mismatches-before.Mismatches)
+	}
+	if len(st.Recent) != shadowRecent || st.Recent[len(st.Recent)-1].LedgerCents != shadowRecent || st.Recent[0].LedgerCents != 1 {
+		t.Fatalf("recent: len %d first %+v", len(st.Recent), st.Recent[0])
+	}
+}
+
+// TestLedgerDualWrite, счета, открытые посреди работы, сходятся с колонкой после обычного, горячего перевода, эмиссии и слияния,
+// проводки, пропущенные при выключенной записи, видны в сверке, а сброс очищает счета
+func TestLedgerDualWrite(t *testing.T) {
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := WithCaller(context.Background(), Caller{Admin: true, Channel: ChannelCLI})
+
+	a := fx.Wallet(10000)
+	b := fx.Wallet(500)
+	hot := fx.Wallet(0)
+	gone := fx.Wallet(700)
+	if err := r.SetWalletHot(ctx, hot, true, "test"); err != nil {
+		t.Fatalf("set hot: %v", err)
+	}
+
+	SetLedgerMode(LedgerShadow)
+	defer SetLedgerMode(LedgerOff)
+
+	if err := r.Transfer(ctx, a, b, 2500); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if err := r.Transfer(ctx, a, hot, 1000); err != nil {
+		t.Fatalf("hot transfer: %v", err)
+	}
+	if err := r.Transfer(ctx, hot, b, 400); err != nil {
+		t.Fatalf("transfer from hot: %v", err)
+	}
+	if _, err := r.Faucet(ctx, b, 300); err != nil {
+		t.Fatalf("faucet: %v", err)
+	}
+	if _, err := r.MergeWallet(ctx, gone, b, "test"); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	mine := func(c LedgerComparison) []LedgerMismatch {
+		var out []LedgerMismatch
+		for _, m := range c.Mismatches {
+			if m.Address == a || m.Address == b || m.Address == hot || m.Address == gone {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+	c, err := r.CompareLedger(ctx)
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	if got := mine(c); len(got) != 0 {
+		t.Fatalf("dual write mismatches: %+v", got)
+	}
+	want := map[string]int64{a: 6500, b: 4400, hot: 600, gone: 0}
+	for addr, cents := range want {
+		var ledger int64
+		if err := db.QueryRow(`SELECT balance_cents FROM ledger_accounts WHERE address=$1`, addr).Scan(&ledger); err != nil || ledger != cents {
+			t.Fatalf("ledger %s: want %d, got %d %v", addr, cents, ledger, err)
+		}
+	}
+
+	before := LedgerShadowStats()
+	if bal, err := r.GetBalance(ctx, hot); err != nil || bal != 600 {
+		t.Fatalf("get balance: %d %v", bal, err)
+	}
+	if st := LedgerShadowStats(); st.Compared != before.Compared+1 || st.Mismatches != before.Mismatches {
+		t.Fatalf("shadow read: before %+v after %+v", before, st)
+	}
+
+	// пропущенная при выключенной записи операция расходится со счетами
+	SetLedgerMode(LedgerOff)
+	if err := r.Transfer(ctx, a, b, 100); err != nil {
+		t.Fatalf("transfer off: %v", err)
+	}
+	if c, err = r.CompareLedger(ctx); err != nil || len(mine(c)) != 2 {
+		t.Fatalf("want 2 mismatches after writes off, got %+v %v", c.Mismatches, err)
+	}
+
+	if _, err := r.ResetLedger(ctx); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if c, err = r.CompareLedger(ctx); err != nil || len(mine(c)) != 0 {
+		t.Fatalf("after reset: %+v %v", c.Mismatches, err)
+	}
+}
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// AuditWalletLowBalance, действие журнала аудита, изменение порога низкого баланса
+const AuditWalletLowBalance = "wallet.low_balance"
+
+// SetLowBalanceThreshold, задает порог низкого баланса кошелька, ноль выключает, состояние сразу пересчитывается по текущему балансу,
+// уведомление при этом не ставится, его дает только перевод, опустивший баланс ниже порога, пишет запись аудита
+func (r *PostgresRepo) SetLowBalanceThreshold(ctx context.Context, address string, thresholdCents int64, actor string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := setLowBalanceTx(ctx, tx, address, thresholdCents, actor); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setLowBalanceTx, порог низкого баланса в транзакции tx вместе с записью аудита, версия настроек кошелька растет
+func setLowBalanceTx(ctx context.Context, tx *sql.Tx, address string, thresholdCents int64, actor string) error {
+	if thresholdCents < 0 {
+		return errors.New("low balance threshold must be >= 0")
+	}
+
+	var prev int64
+	err := tx.QueryRowContext(ctx, `
+		UPDATE wallets w SET low_balance_cents = NULLIF($1::bigint, 0), updated_at = now(), meta_version = w.meta_version + 1,
+			low_balance_since = CASE WHEN w.balance_cents < NULLIF($1::bigint, 0) THEN COALESCE(w.low_balance_since, now()) END
+		FROM (SELECT COALESCE(low_balance_cents, 0) AS low_balance_cents FROM wallets WHERE address = $2 FOR UPDATE) old
+		WHERE w.address = $2
+		RETURNING old.low_balance_cents
+	`, thresholdCents, address).Scan(&prev)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &WalletNotFoundError{Address: address}
+	}
+	if err != nil {
+		return err
+	}
+
+	return insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditWalletLowBalance,
+		Actor:   actor,
+		Address: address,
+		Details: map[string]any{"from_cents": prev, "to_cents": thresholdCents},
+	})
+}
@@ -0,0 +1,154 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gotechtask/internal/money"
+)
+
+// AuditWalletMerge, действие журнала аудита, слияние кошелька с преемником
+const AuditWalletMerge = "wallet.merge"
+
+// ErrMergeRefused, кошелек нельзя слить, причина в *MergeRefusedError
+var ErrMergeRefused = errors.New("wallet merge refused")
+
+// MergeRefusedError, отказ слияния по состоянию кошельков, errors.Is с ErrMergeRefused дает true
+type MergeRefusedError struct {
+	Address string
+	Reason  string
+}
+
+func (e *MergeRefusedError) Error() string { return "merge " + e.Address + " refused: " + e.Reason }
+
+func (e *MergeRefusedError) Is(target error) bool { return target == ErrMergeRefused }
+
+// причины отказа слияния
+const (
+	MergeReasonSystem   = "system wallet"
+	MergeReasonHot      = "hot wallet"
+	MergeReasonNegative = "negative balance"
+)
+
+// WalletMerge, итог слияния, From закрыт, его баланс MovedCents передан Into одной операцией вида merge
+type WalletMerge struct {
+	From       string
+	Into       string
+	MovedCents int64
+	ClosedAt   time.Time
+}
+
+// MergeWallet, сливает кошелек from в into, в одной транзакции переносит баланс from вместе с очередью зачислений операцией вида merge,
+// закрывает from с преемником into и пишет аудит, история from остается в журнале под его адресом, переводы с закрытым кошельком отклоняются с ErrWalletClosed,
+// служебные, горячие и ушедшие в минус кошельки не сливаются, слитый или закрытый into дает *WalletClosedError
+func (r *PostgresRepo) MergeWallet(ctx context.Context, from, into, actor string) (WalletMerge, error) {
+	if from == into {
+		return WalletMerge{}, ErrSameAddress
+	}
+	unlock, err := r.lockWallets(ctx, from, into)
+	if err != nil {
+		return WalletMerge{}, err
+	}
+	defer unlock()
+
+	var m WalletMerge
+	err = retryDeadlocks(ctx, func() error {
+		var err error
+		m, err = r.mergeOnce(ctx, from, into, actor)
+		return err
+	})
+	return m, wrapf(err, "merge %s->%s", from, into)
+}
+
+// mergeOnce, одна попытка слияния в отдельной транзакции
+func (r *PostgresRepo) mergeOnce(ctx context.Context, from, into, actor string) (WalletMerge, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return WalletMerge{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	a1, a2 := from, into
+	if a2 < a1 {
+		a1, a2 = a2, a1
+	}
+	got, err := lockWallets(ctx, tx, a1, a2)
+	if err != nil {
+		return WalletMerge{}, err
+	}
+	if len(got) != 2 {
+		return WalletMerge{}, missingWallet(got, from, into)
+	}
+	if err := closedWallet(got); err != nil {
+		return WalletMerge{}, err
+	}
+	src, dst := got[0], got[1]
+	if src.addr != from {
+		src, dst = dst, src
+	}
+
+	// горячий кошелек получает зачисления без блокировки строки, закрыть его под блокировкой нельзя
+	var system, hot string
+	if err := tx.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((SELECT address FROM system_wallets WHERE address = ANY($1) LIMIT 1), ''),
+			COALESCE((SELECT address FROM wallets WHERE address = ANY($1) AND hot_since IS NOT NULL LIMIT 1), '')
+	`, []string{from, into}).Scan(&system, &hot); err != nil {
+		return WalletMerge{}, err
+	}
+	switch {
+	case system != "":
+		return WalletMerge{}, &MergeRefusedError{Address: system, Reason: MergeReasonSystem}
+	case hot != "":
+		return WalletMerge{}, &MergeRefusedError{Address: hot, Reason: MergeReasonHot}
+	}
+
+	// остаток очереди зачислений кошелька, с которого сняли отметку горячего, входит в переносимый баланс
+	bal, err := applyHotCredits(ctx, tx, from, src.bal)
+	if err != nil {
+		return WalletMerge{}, err
+	}
+	if bal < 0 {
+		return WalletMerge{}, &MergeRefusedError{Address: from, Reason: MergeReasonNegative}
+	}
+
+	// закрытый кошелек пуст, обнуляется до записи операции, чтобы проводки видели оба баланса уже после слияния, порог низкого баланса на нем больше не срабатывает
+	m := WalletMerge{From: from, Into: into, MovedCents: bal}
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE wallets SET balance_cents = 0, low_balance_since = NULL, closed_at = now(), successor = $2, updated_at = now()
+		WHERE address = $1
+		RETURNING closed_at
+	`, from, into).Scan(&m.ClosedAt); err != nil {
+		return WalletMerge{}, err
+	}
+
+	if bal > 0 {
+		intoNew, err := money.Add(dst.bal, bal)
+		if err != nil || intoNew > money.MaxCents {
+			return WalletMerge{}, ErrBalanceOverflow
+		}
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE wallets SET balance_cents = $1, updated_at = now(), last_tx_at = now(), low_balance_since = `+lowBalanceSince+` WHERE address = $2`,
+			intoNew, into); err != nil {
+			if isBalanceOverflow(err) {
+				return WalletMerge{}, ErrBalanceOverflow
+			}
+			return WalletMerge{}, err
+		}
+		if err := insertTransaction(ctx, tx, TxTypeMerge, from, into, bal, 0, intoNew); err != nil {
+			return WalletMerge{}, err
+		}
+	}
+
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditWalletMerge,
+		Actor:   actor,
+		Address: from,
+		Details: map[string]any{"into": into, "moved_cents": bal},
+	}); err != nil {
+		return WalletMerge{}, err
+	}
+	return m, tx.Commit()
+}
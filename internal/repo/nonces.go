@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// ConsumeNonce, отмечает nonce ключа использованным до expiresAt, возвращает false если nonce уже встречался, заодно удаляет истекшие nonce этого ключа
+func (r *PostgresRepo) ConsumeNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) (bool, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		WITH purge AS (
+			DELETE FROM signature_nonces WHERE key_id = $1 AND expires_at < now()
+		)
+		INSERT INTO signature_nonces(key_id, nonce, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (key_id, nonce) DO NOTHING
+	`, keyID, nonce, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// AuditWalletOverdraft, действие журнала аудита, изменение лимита овердрафта
+const AuditWalletOverdraft = "wallet.overdraft"
+
+// SetOverdraftLimit, задает лимит овердрафта кошелька, новый лимит не может быть меньше уже использованного минуса, пишет запись аудита
+func (r *PostgresRepo) SetOverdraftLimit(ctx context.Context, address string, limitCents int64, actor string) error {
+	if limitCents < 0 {
+		return errors.New("overdraft limit must be >= 0")
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var prev int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE wallets w SET overdraft_limit_cents = $1, updated_at = now()
+		FROM (SELECT overdraft_limit_cents FROM wallets WHERE address = $2 FOR UPDATE) old
+		WHERE w.address = $2
+		RETURNING old.overdraft_limit_cents
+	`, limitCents, address).Scan(&prev)
+	if err != nil {
+		if isNegativeBalance(err) {
+			return ErrOverdraftInUse
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return &WalletNotFoundError{Address: address}
+		}
+		return err
+	}
+
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditWalletOverdraft,
+		Actor:   actor,
+		Address: address,
+		Details: map[string]any{"from_cents": prev, "to_cents": limitCents},
+	}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// TxPartition, помесячная партиция таблицы транзакций, имя и первый момент месяца в utc
+type TxPartition struct {
+	Name  string
+	Month time.Time
+}
+
+// partitionName, формат имени партиции из функции ensure_transactions_partition
+var partitionName = regexp.MustCompile(`^transactions_y(\d{4})m(\d{2})$`)
+
+// EnsureTransactionPartition, создает партицию месяца содержащего at если ее еще нет, возвращает имя
+func (r *PostgresRepo) EnsureTransactionPartition(ctx context.Context, at time.Time) (string, error) {
+	var name string
+	err := r.DB.QueryRowContext(ctx, `SELECT ensure_transactions_partition($1)`, at).Scan(&name)
+	return name, err
+}
+
+// ListTransactionPartitions, возвращает помесячные партиции транзакций по возрастанию месяца, default партиция не включается,
+// таблица берется по search_path, одноименные таблицы других схем не попадают
+func (r *PostgresRepo) ListTransactionPartitions(ctx context.Context) ([]TxPartition, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'transactions'::regclass
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []TxPartition
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		m := partitionName.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		out = append(out, TxPartition{Name: name, Month: time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)})
+	}
+	return out, rows.Err()
+}
+
+// ArchiveTransactionPartition, в одной транзакции отсоединяет партицию, переносит ее строки в transactions_archive и удаляет ее, возвращает число перенесенных строк
+func (r *PostgresRepo) ArchiveTransactionPartition(ctx context.Context, p TxPartition) (int64, error) {
+	// имя подставляется в sql, поэтому принимаем только имена нашего формата
+	if !partitionName.MatchString(p.Name) {
+		return 0, fmt.Errorf("unexpected partition name %q", p.Name)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE transactions DETACH PARTITION %s`, p.Name)); err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO transactions_archive(id, from_address, to_address, amount_cents, created_at, initiated_by, channel, type, group_id, tenant_id)
+		SELECT id, from_address, to_address, amount_cents, created_at, initiated_by, channel, type, group_id, tenant_id FROM %s
+		ON CONFLICT DO NOTHING
+	`, p.Name))
+	if err != nil {
+		return 0, err
+	}
+	moved, _ := res.RowsAffected()
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP TABLE %s`, p.Name)); err != nil {
+		return 0, err
+	}
+	return moved, tx.Commit()
+}
+
+// WriteTransactionPartitionCSV, потоково пишет строки партиции в csv с заголовком, суммы в центах, время в rfc3339
+func (r *PostgresRepo) WriteTransactionPartitionCSV(ctx context.Context, p TxPartition, w io.Writer) error {
+	if !partitionName.MatchString(p.Name) {
+		return fmt.Errorf("unexpected partition name %q", p.Name)
+	}
+
+	rows, err := r.DB.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, from_address, to_address, amount_cents, created_at, COALESCE(initiated_by, ''), COALESCE(channel, ''), type
+		FROM %s
+		ORDER BY id
+	`, p.Name))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"id", "from", "to", "amount_cents", "created_at", "initiated_by", "channel", "type"}); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var t Transaction
+		if err := rows.Scan(&t.ID, &t.FromAddress, &t.ToAddress, &t.AmountCents, &t.CreatedAt, &t.InitiatedBy, &t.Channel, &t.Type); err != nil {
+			return err
+		}
+		if err := cw.Write([]string{
+			strconv.FormatInt(t.ID, 10),
+			t.FromAddress,
+			t.ToAddress,
+			strconv.FormatInt(t.AmountCents, 10),
+			t.CreatedAt.UTC().Format(time.RFC3339Nano),
+			t.InitiatedBy,
+			t.Channel,
+			t.Type,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DropTransactionPartition, отсоединяет и удаляет партицию, используется после выгрузки партиции во внешнее хранилище
+func (r *PostgresRepo) DropTransactionPartition(ctx context.Context, p TxPartition) error {
+	if !partitionName.MatchString(p.Name) {
+		return fmt.Errorf("unexpected partition name %q", p.Name)
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE transactions DETACH PARTITION %s`, p.Name)); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DROP TABLE %s`, p.Name)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,151 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ошибки адресной книги
+var (
+	ErrPayeeExists   = errors.New("payee alias already exists")
+	ErrPayeeNotFound = errors.New("payee not found")
+)
+
+// AuditPayeeRestore, действие журнала аудита, администратор вернул удаленного получателя
+const AuditPayeeRestore = "payee.restore"
+
+// Payee, сохраненный получатель кошелька, псевдоним и адрес, DeletedAt, когда получатель удален, у не удаленных нулевое
+type Payee struct {
+	Alias     string
+	Address   string
+	CreatedAt time.Time
+	DeletedAt time.Time
+}
+
+// AddPayee, сохраняет получателя под псевдонимом, занятый псевдоним дает ErrPayeeExists, отсутствующий кошелек ErrWalletNotFound
+func (r *PostgresRepo) AddPayee(ctx context.Context, wallet, alias, address string) (Payee, error) {
+	p := Payee{Alias: alias, Address: address}
+	err := r.DB.QueryRowContext(ctx, `
+		INSERT INTO payees(wallet_address, alias, payee_address)
+		SELECT address, $2, $3 FROM wallets WHERE address = $1
+		RETURNING created_at
+	`, wallet, alias, address).Scan(&p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Payee{}, &WalletNotFoundError{Address: wallet}
+	}
+	if isUniqueViolation(err) {
+		return Payee{}, ErrPayeeExists
+	}
+	return p, err
+}
+
+// ListPayees, получатели кошелька по псевдониму, удаленные не входят
+func (r *PostgresRepo) ListPayees(ctx context.Context, wallet string) ([]Payee, error) {
+	return r.listPayees(ctx, `
+		SELECT alias, payee_address, created_at, deleted_at
+		FROM payees
+		WHERE wallet_address = $1 AND deleted_at IS NULL
+		ORDER BY alias
+	`, wallet)
+}
+
+// ListDeletedPayees, удаленные получатели кошелька, последние удаленные первыми, псевдоним может повторяться, если его удаляли не раз
+func (r *PostgresRepo) ListDeletedPayees(ctx context.Context, wallet string) ([]Payee, error) {
+	return r.listPayees(ctx, `
+		SELECT alias, payee_address, created_at, deleted_at
+		FROM payees
+		WHERE wallet_address = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT 500
+	`, wallet)
+}
+
+// listPayees, читает получателей запросом query
+func (r *PostgresRepo) listPayees(ctx context.Context, query string, args ...any) ([]Payee, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Payee
+	for rows.Next() {
+		var p Payee
+		var deleted sql.NullTime
+		if err := rows.Scan(&p.Alias, &p.Address, &p.CreatedAt, &deleted); err != nil {
+			return nil, err
+		}
+		p.DeletedAt = deleted.Time
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// DeletePayee, удаляет получателя из адресной книги, строка остается с отметкой удаления, псевдоним освобождается
+func (r *PostgresRepo) DeletePayee(ctx context.Context, wallet, alias string) error {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE payees SET deleted_at = now()
+		WHERE wallet_address = $1 AND alias = $2 AND deleted_at IS NULL
+	`, wallet, alias)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPayeeNotFound
+	}
+	return nil
+}
+
+// RestorePayee, возвращает последнего удаленного получателя с псевдонимом, удаленного нет, ErrPayeeNotFound,
+// псевдоним уже занят новым получателем, ErrPayeeExists
+func (r *PostgresRepo) RestorePayee(ctx context.Context, wallet, alias, actor string) (Payee, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Payee{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	p := Payee{Alias: alias}
+	err = tx.QueryRowContext(ctx, `
+		UPDATE payees SET deleted_at = NULL
+		WHERE id = (
+			SELECT id FROM payees
+			WHERE wallet_address = $1 AND alias = $2 AND deleted_at IS NOT NULL
+			ORDER BY deleted_at DESC, id DESC
+			LIMIT 1
+		)
+		RETURNING payee_address, created_at
+	`, wallet, alias).Scan(&p.Address, &p.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Payee{}, ErrPayeeNotFound
+	}
+	if isUniqueViolation(err) {
+		return Payee{}, ErrPayeeExists
+	}
+	if err != nil {
+		return Payee{}, err
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditPayeeRestore,
+		Actor:   actor,
+		Address: wallet,
+		Details: map[string]any{"alias": alias, "payee": p.Address},
+	}); err != nil {
+		return Payee{}, err
+	}
+	return p, tx.Commit()
+}
+
+// ResolvePayee, адрес получателя по псевдониму из адресной книги кошелька
+func (r *PostgresRepo) ResolvePayee(ctx context.Context, wallet, alias string) (string, error) {
+	var addr string
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT payee_address FROM payees WHERE wallet_address = $1 AND alias = $2 AND deleted_at IS NULL
+	`, wallet, alias).Scan(&addr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrPayeeNotFound
+	}
+	return addr, err
+}
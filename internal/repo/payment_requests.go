@@ -0,0 +1,205 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// состояния запроса платежа, просроченный pending отдается как expired без отдельной фоновой задачи
+const (
+	PaymentRequestPending  = "pending"
+	PaymentRequestPaid     = "paid"
+	PaymentRequestDeclined = "declined"
+	PaymentRequestExpired  = "expired"
+)
+
+// ошибки запросов платежа
+var (
+	ErrPaymentRequestNotFound = errors.New("payment request not found")
+	ErrPaymentRequestResolved = errors.New("payment request already resolved")
+	ErrPaymentRequestExpired  = errors.New("payment request expired")
+)
+
+// PaymentRequest, запрос суммы получателем Payee у плательщика Payer
+type PaymentRequest struct {
+	ID          int64
+	Payee       string
+	Payer       string
+	AmountCents int64
+	Memo        string
+	Status      string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	ResolvedAt  time.Time
+}
+
+// PaymentRequestFilter, выборка запросов кошелька, AsPayer, запросы к кошельку как к плательщику, иначе выставленные им, пустой Status без фильтра
+type PaymentRequestFilter struct {
+	AsPayer bool
+	Status  string
+	Limit   int
+}
+
+// paymentRequestStatus, текущий статус запроса, просроченный pending читается как expired
+const paymentRequestStatus = `CASE WHEN status = 'pending' AND expires_at <= now() THEN 'expired' ELSE status END`
+
+// paymentRequestColumns, колонки для scanPaymentRequest
+const paymentRequestColumns = `id, payee_address, payer_address, amount_cents, memo, ` + paymentRequestStatus + `, created_at, expires_at, resolved_at`
+
+// scanPaymentRequest, читает запрос платежа из строки
+func scanPaymentRequest(row interface{ Scan(...any) error }) (PaymentRequest, error) {
+	var p PaymentRequest
+	var resolved sql.NullTime
+	err := row.Scan(&p.ID, &p.Payee, &p.Payer, &p.AmountCents, &p.Memo, &p.Status, &p.CreatedAt, &p.ExpiresAt, &resolved)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PaymentRequest{}, ErrPaymentRequestNotFound
+	}
+	p.ResolvedAt = resolved.Time
+	return p, err
+}
+
+// CreatePaymentRequest, выставляет запрос платежа, оба кошелька должны существовать
+func (r *PostgresRepo) CreatePaymentRequest(ctx context.Context, p PaymentRequest) (PaymentRequest, error) {
+	if p.Payee == p.Payer {
+		return PaymentRequest{}, ErrSameAddress
+	}
+	created, err := scanPaymentRequest(r.DB.QueryRowContext(ctx, `
+		INSERT INTO payment_requests(payee_address, payer_address, amount_cents, memo, expires_at)
+		SELECT $1, $2, $3, $4, $5
+		WHERE (SELECT COUNT(*) FROM wallets WHERE address IN ($1, $2)) = 2
+		RETURNING `+paymentRequestColumns,
+		p.Payee, p.Payer, p.AmountCents, p.Memo, p.ExpiresAt))
+	if errors.Is(err, ErrPaymentRequestNotFound) {
+		// строка не вставлена, значит одного из кошельков нет
+		return PaymentRequest{}, ErrWalletNotFound
+	}
+	return created, err
+}
+
+// GetPaymentRequest, запрос платежа по идентификатору
+func (r *PostgresRepo) GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	return scanPaymentRequest(r.DB.QueryRowContext(ctx, `SELECT `+paymentRequestColumns+` FROM payment_requests WHERE id = $1`, id))
+}
+
+// ListPaymentRequests, запросы кошелька, новые первыми, limit по умолчанию 50, максимум 500
+func (r *PostgresRepo) ListPaymentRequests(ctx context.Context, wallet string, f PaymentRequestFilter) ([]PaymentRequest, error) {
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	if f.Limit > 500 {
+		f.Limit = 500
+	}
+	col := "payee_address"
+	if f.AsPayer {
+		col = "payer_address"
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+paymentRequestColumns+`
+		FROM payment_requests
+		WHERE `+col+` = $1 AND ($2 = '' OR `+paymentRequestStatus+` = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, wallet, f.Status, f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []PaymentRequest
+	for rows.Next() {
+		p, err := scanPaymentRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// PayPaymentRequest, оплачивает запрос, перевод и отметка об оплате в одной транзакции
+func (r *PostgresRepo) PayPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	p, err := r.GetPaymentRequest(ctx, id)
+	if err != nil {
+		return p, err
+	}
+	err = r.retryTransfer(ctx, p.Payer, p.Payee, p.AmountCents, func(ctx context.Context) error {
+		tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if err := payRequestTx(ctx, tx, id); err != nil {
+			if errors.Is(err, ErrPaymentRequestExpired) {
+				// истечение фиксируем даже без перевода
+				if cerr := tx.Commit(); cerr != nil {
+					return cerr
+				}
+			}
+			return err
+		}
+		if err := transferTx(ctx, tx, p.Payer, p.Payee, p.AmountCents); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return p, wrapf(err, "pay request %d", id)
+	}
+	return r.GetPaymentRequest(ctx, id)
+}
+
+// payRequestTx, под блокировкой проверяет что запрос ждет оплаты и отмечает его оплаченным, просроченный отмечается истекшим
+func payRequestTx(ctx context.Context, tx *sql.Tx, id int64) error {
+	var status string
+	var expires time.Time
+	err := tx.QueryRowContext(ctx, `
+		SELECT status, expires_at FROM payment_requests WHERE id = $1 FOR UPDATE
+	`, id).Scan(&status, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPaymentRequestNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != PaymentRequestPending {
+		return ErrPaymentRequestResolved
+	}
+	if !time.Now().Before(expires) {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE payment_requests SET status = 'expired', resolved_at = now() WHERE id = $1
+		`, id); err != nil {
+			return err
+		}
+		return ErrPaymentRequestExpired
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE payment_requests SET status = 'paid', resolved_at = now() WHERE id = $1
+	`, id)
+	return err
+}
+
+// DeclinePaymentRequest, плательщик отклоняет запрос, который еще ждет оплаты
+func (r *PostgresRepo) DeclinePaymentRequest(ctx context.Context, id int64) (PaymentRequest, error) {
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE payment_requests SET status = 'declined', resolved_at = now()
+		WHERE id = $1 AND status = 'pending' AND expires_at > now()
+	`, id)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	p, err := r.GetPaymentRequest(ctx, id)
+	if err != nil {
+		return p, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if p.Status == PaymentRequestExpired {
+			return p, ErrPaymentRequestExpired
+		}
+		return p, ErrPaymentRequestResolved
+	}
+	return p, nil
+}
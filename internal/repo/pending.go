@@ -0,0 +1,252 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// способы подтверждения отложенного перевода
+const (
+	PendingMethodTOTP  = "totp"
+	PendingMethodEmail = "email"
+)
+
+// состояния отложенного перевода
+const (
+	PendingStatusPending  = "pending"
+	PendingStatusExecuted = "executed"
+	PendingStatusFailed   = "failed"
+	PendingStatusExpired  = "expired"
+)
+
+// ошибки отложенных переводов и второго фактора
+var (
+	ErrPendingNotFound = errors.New("pending transfer not found")
+	ErrPendingResolved = errors.New("pending transfer already resolved")
+	ErrPendingExpired  = errors.New("pending transfer expired")
+	ErrTooManyAttempts = errors.New("too many confirmation attempts")
+	ErrTOTPNotEnrolled = errors.New("totp not enrolled")
+	// ErrTOTPEnrollToken, токен подключения из письма не совпал или истек
+	ErrTOTPEnrollToken = errors.New("totp enrollment token invalid or expired")
+	// ErrTOTPCodeUsed, код этого или более раннего интервала уже принят
+	ErrTOTPCodeUsed = errors.New("totp code already used")
+)
+
+// PendingTransfer, перевод, ожидающий подтверждения вторым фактором, нулевой ResolvedAt пока перевод не исполнен и не отклонен
+type PendingTransfer struct {
+	ID          int64
+	UserID      int64
+	From        string
+	To          string
+	AmountCents int64
+	Method      string
+	Status      string
+	Failure     string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	ResolvedAt  time.Time
+	// PaymentRequestID, запрос платежа, который оплачивается этим переводом, ноль для обычного перевода
+	PaymentRequestID int64
+}
+
+// pendingColumns, колонки для сканирования scanPending
+const pendingColumns = `id, user_id, from_address, to_address, amount_cents, method, status, failure, created_at, expires_at, resolved_at, COALESCE(payment_request_id, 0)`
+
+// scanPending, читает отложенный перевод из строки
+func scanPending(row interface{ Scan(...any) error }) (PendingTransfer, error) {
+	var p PendingTransfer
+	var resolved sql.NullTime
+	err := row.Scan(&p.ID, &p.UserID, &p.From, &p.To, &p.AmountCents, &p.Method, &p.Status, &p.Failure, &p.CreatedAt, &p.ExpiresAt, &resolved, &p.PaymentRequestID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return PendingTransfer{}, ErrPendingNotFound
+	}
+	p.ResolvedAt = resolved.Time
+	return p, err
+}
+
+// CreatePendingTransfer, сохраняет перевод до подтверждения, tokenHash задается для подтверждения ссылкой из письма
+func (r *PostgresRepo) CreatePendingTransfer(ctx context.Context, p PendingTransfer, tokenHash string) (PendingTransfer, error) {
+	return scanPending(r.DB.QueryRowContext(ctx, `
+		INSERT INTO pending_transfers(user_id, from_address, to_address, amount_cents, method, token_hash, expires_at, payment_request_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, 0))
+		RETURNING `+pendingColumns,
+		p.UserID, p.From, p.To, p.AmountCents, p.Method, tokenHash, p.ExpiresAt, p.PaymentRequestID))
+}
+
+// GetPendingTransfer, отложенный перевод по идентификатору
+func (r *PostgresRepo) GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error) {
+	return scanPending(r.DB.QueryRowContext(ctx, `SELECT `+pendingColumns+` FROM pending_transfers WHERE id = $1`, id))
+}
+
+// PendingTransferByToken, отложенный перевод по хэшу токена ссылки подтверждения
+func (r *PostgresRepo) PendingTransferByToken(ctx context.Context, tokenHash string) (PendingTransfer, error) {
+	return scanPending(r.DB.QueryRowContext(ctx, `SELECT `+pendingColumns+` FROM pending_transfers WHERE token_hash = $1`, tokenHash))
+}
+
+// RecordPendingAttempt, учитывает неверный код подтверждения, после maxAttempts перевод отклоняется
+func (r *PostgresRepo) RecordPendingAttempt(ctx context.Context, id int64, maxAttempts int) error {
+	var attempts int
+	err := r.DB.QueryRowContext(ctx, `
+		UPDATE pending_transfers SET
+			attempts = attempts + 1,
+			status = CASE WHEN attempts + 1 >= $2 THEN 'failed' ELSE status END,
+			failure = CASE WHEN attempts + 1 >= $2 THEN 'too many attempts' ELSE failure END,
+			resolved_at = CASE WHEN attempts + 1 >= $2 THEN now() ELSE resolved_at END
+		WHERE id = $1 AND status = 'pending'
+		RETURNING attempts
+	`, id, maxAttempts).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPendingResolved
+	}
+	if err != nil {
+		return err
+	}
+	if attempts >= maxAttempts {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+// ExecutePendingTransfer, исполняет подтвержденный перевод и отмечает его исполненным в одной транзакции вместе с оплачиваемым запросом платежа, просроченный отмечается истекшим, доменная ошибка перевода отклоняет его
+func (r *PostgresRepo) ExecutePendingTransfer(ctx context.Context, id int64) (PendingTransfer, error) {
+	p, err := r.GetPendingTransfer(ctx, id)
+	if err != nil {
+		return p, err
+	}
+
+	err = r.retryTransfer(ctx, p.From, p.To, p.AmountCents, func(ctx context.Context) error {
+		return r.executePendingOnce(ctx, &p)
+	})
+	switch {
+	case err == nil, errors.Is(err, ErrPendingResolved), errors.Is(err, ErrPendingExpired):
+	case errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrWalletNotFound), errors.Is(err, ErrSameAddress), errors.Is(err, ErrAddressDenied), errors.Is(err, ErrWalletClosed),
+		errors.Is(err, ErrPaymentRequestNotFound), errors.Is(err, ErrPaymentRequestResolved), errors.Is(err, ErrPaymentRequestExpired):
+		if _, ferr := r.DB.ExecContext(ctx, `
+			UPDATE pending_transfers SET status = 'failed', failure = $2, resolved_at = now()
+			WHERE id = $1 AND status = 'pending'
+		`, id, err.Error()); ferr != nil {
+			return p, ferr
+		}
+		p.Status, p.Failure = PendingStatusFailed, err.Error()
+	}
+	return p, err
+}
+
+// executePendingOnce, одна попытка исполнения под блокировкой строки отложенного перевода
+func (r *PostgresRepo) executePendingOnce(ctx context.Context, p *PendingTransfer) error {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	cur, err := scanPending(tx.QueryRowContext(ctx, `SELECT `+pendingColumns+` FROM pending_transfers WHERE id = $1 FOR UPDATE`, p.ID))
+	if err != nil {
+		return err
+	}
+	*p = cur
+	if cur.Status != PendingStatusPending {
+		return ErrPendingResolved
+	}
+	if !time.Now().Before(cur.ExpiresAt) {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE pending_transfers SET status = 'expired', resolved_at = now() WHERE id = $1
+		`, cur.ID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		p.Status = PendingStatusExpired
+		return ErrPendingExpired
+	}
+
+	if cur.PaymentRequestID != 0 {
+		if err := payRequestTx(ctx, tx, cur.PaymentRequestID); err != nil {
+			return err
+		}
+	}
+	if err := transferTx(ctx, tx, cur.From, cur.To, cur.AmountCents); err != nil {
+		return err
+	}
+	if err := tx.QueryRowContext(ctx, `
+		UPDATE pending_transfers SET status = 'executed', resolved_at = now() WHERE id = $1
+		RETURNING resolved_at
+	`, cur.ID).Scan(&p.ResolvedAt); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	p.Status = PendingStatusExecuted
+	return nil
+}
+
+// GetTOTP, секрет totp пользователя и включен ли он, пустой секрет если пользователь не начинал подключение
+func (r *PostgresRepo) GetTOTP(ctx context.Context, userID int64) (string, bool, error) {
+	var secret sql.NullString
+	var enabled bool
+	err := r.DB.QueryRowContext(ctx, `SELECT totp_secret, totp_enabled FROM users WHERE id = $1`, userID).Scan(&secret, &enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, ErrUserNotFound
+	}
+	return secret.String, enabled, err
+}
+
+// SetTOTPSecret, начинает подключение totp с новым секретом, до подтверждения кодом второй фактор выключен,
+// непустой enrollTokenHash, хэш токена из письма, без которого подключение не завершится до enrollExpiresAt, пустой подключает одним кодом
+func (r *PostgresRepo) SetTOTPSecret(ctx context.Context, userID int64, secret, enrollTokenHash string, enrollExpiresAt time.Time) error {
+	var expires any
+	if enrollTokenHash != "" {
+		expires = enrollExpiresAt
+	}
+	res, err := r.DB.ExecContext(ctx, `
+		UPDATE users SET totp_secret = $2, totp_enabled = false, totp_enroll_token_hash = NULLIF($3, ''), totp_enroll_expires_at = $4
+		WHERE id = $1
+	`, userID, secret, enrollTokenHash, expires)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// EnableTOTP, включает totp после проверки первого кода, если подключение начато с токеном из письма, enrollTokenHash должен с ним совпасть до истечения срока
+func (r *PostgresRepo) EnableTOTP(ctx context.Context, userID int64, enrollTokenHash string) error {
+	var enrolled, enabled bool
+	err := r.DB.QueryRowContext(ctx, `
+		WITH u AS (
+			UPDATE users SET totp_enabled = true, totp_enroll_token_hash = NULL, totp_enroll_expires_at = NULL
+			WHERE id = $1 AND totp_secret IS NOT NULL
+			  AND (totp_enroll_token_hash IS NULL OR totp_enroll_token_hash = $2 AND totp_enroll_expires_at > now())
+			RETURNING id
+		)
+		SELECT totp_secret IS NOT NULL, EXISTS (SELECT 1 FROM u) FROM users WHERE id = $1
+	`, userID, enrollTokenHash).Scan(&enrolled, &enabled)
+	switch {
+	case errors.Is(err, sql.ErrNoRows), err == nil && !enrolled:
+		return ErrTOTPNotEnrolled
+	case err != nil:
+		return err
+	case !enabled:
+		return ErrTOTPEnrollToken
+	}
+	return nil
+}
+
+// UseTOTPCounter, отмечает принятым код интервала counter, код того же или более раннего интервала дает ErrTOTPCodeUsed,
+// так один код не подтверждает несколько переводов в пределах допуска, из параллельных запросов с одним кодом проходит один
+func (r *PostgresRepo) UseTOTPCounter(ctx context.Context, userID, counter int64) error {
+	res, err := r.DB.ExecContext(ctx, `UPDATE users SET totp_last_counter = $2 WHERE id = $1 AND totp_last_counter < $2`, userID, counter)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTOTPCodeUsed
+	}
+	return nil
+}
@@ -4,93 +4,408 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"io"
 	"time"
 	"math/rand"
 
 	"github.com/jackc/pgx/v5/pgconn"
+	"gotechtask/internal/address"
+	"gotechtask/internal/money"
+	"gotechtask/internal/notify"
 )
 
-// Transaction, доменная модель транзакции, содержит идентификатор, адреса сторон, сумму в центах, время создания
+// Transaction, доменная модель транзакции, содержит идентификатор, адреса сторон, сумму в центах, время создания, инициатора, канал и вид операции
 type Transaction struct {
 	ID          int64
 	FromAddress string
 	ToAddress   string
 	AmountCents int64
 	CreatedAt   time.Time
+	// InitiatedBy, инициатор в формате журнала аудита, Channel, канал, пустые у переводов до появления этих полей
+	InitiatedBy string
+	Channel     string
+	// Type, вид операции, TxTypeTransfer для обычного перевода
+	Type string
+	// GroupID, общий id транзакций одной операции, пустой у одиночных
+	GroupID string
 }
 
-// доменные ошибки, кошелек не найден, недостаточно средств, одинаковые адреса
+// виды операций в таблице транзакций
+const (
+	TxTypeTransfer   = "transfer"
+	TxTypeAdjustment = "adjustment"
+	TxTypeFee        = "fee"
+	TxTypeReversal   = "reversal"
+	TxTypeExchange   = "exchange"
+	TxTypeMint       = "mint"
+	TxTypeBurn       = "burn"
+	TxTypeMerge      = "merge"
+)
+
+// ValidTxType, известен ли вид операции
+func ValidTxType(t string) bool {
+	switch t {
+	case TxTypeTransfer, TxTypeAdjustment, TxTypeFee, TxTypeReversal, TxTypeExchange, TxTypeMint, TxTypeBurn, TxTypeMerge:
+		return true
+	}
+	return false
+}
+
+// доменные ошибки, кошелек не найден, адрес кошелька занят, недостаточно средств, баланс вышел бы за предел, одинаковые адреса, адрес в стоп-листе, перевод не прошел из-за конфликтов блокировок,
+// кошелек закрыт слиянием
 var (
 	ErrWalletNotFound    = errors.New("wallet not found")
+	ErrWalletExists      = errors.New("wallet already exists")
 	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrBalanceOverflow   = errors.New("balance limit exceeded")
 	ErrSameAddress       = errors.New("from == to")
+	ErrAddressDenied     = errors.New("address denylisted")
+	ErrOverdraftInUse    = errors.New("balance below overdraft limit")
+	ErrContention        = errors.New("could not complete transfer after retries")
+	ErrWalletClosed      = errors.New("wallet closed")
 )
 
-// Repo, контракт доступа к данным, получить баланс, выполнить перевод, получить последние транзакции
+// Repo, контракт доступа к данным, объединение узких интерфейсов, каждый потребитель может зависеть только от нужной части,
+// мок для тестов без базы в repomock, после изменения интерфейса его перегенерирует go generate ./internal/repo
+//
+//go:generate go run github.com/matryer/moq@v0.5.3 -out repomock/repo.go -pkg repomock . Repo
 type Repo interface {
+	Ledger
+	TransactionReader
+	Compliance
+	Users
+	Wallets
+	PendingTransfers
+	PaymentRequests
+	StandingOrders
+	Sandbox
+	Jobs
+}
+
+// Ledger, балансы и переводы
+type Ledger interface {
 	GetBalance(ctx context.Context, address string) (int64, error)
+	GetWallet(ctx context.Context, address string) (Wallet, error)
+	GetWallets(ctx context.Context, addresses []string) ([]Wallet, error)
+	BalanceAt(ctx context.Context, address string, at time.Time) (int64, error)
 	Transfer(ctx context.Context, from, to string, amountCents int64) error
-	GetLastTransactions(ctx context.Context, n int) ([]Transaction, error) 
+	TransferBatch(ctx context.Context, items []TransferItem, mode BatchMode) ([]error, error)
+	TransferGroup(ctx context.Context, items []TransferItem) (string, error)
+	CheckMoneySupply(ctx context.Context) (SupplyCheck, error)
+	ListenBalances(ctx context.Context, listening func(), fn func(address string)) error
+	QuoteTransfer(ctx context.Context, from, to string, amountCents int64) (TransferQuote, error)
 }
 
-// GetLastTransactions, читает последние операции из таблицы транзакций, ограничивает количество, сортирует по времени по убыванию
-func (r *PostgresRepo) GetLastTransactions(ctx context.Context, n int) ([]Transaction, error) {
-	if n <= 0 {
-		n = 10
-	}
-	if n > 100 {
-		n = 100
-	}
+// TransactionReader, чтение истории переводов
+type TransactionReader interface {
+	ListTransactions(ctx context.Context, o ListOptions) ([]Transaction, error)
+	StreamTransactions(ctx context.Context, o ListOptions, fn func(Transaction) error) error
+	GetTransaction(ctx context.Context, id int64, vis TxVisibility) (Transaction, error)
+	CountTransactions(ctx context.Context, o ListOptions, max int64) (int64, bool, error)
+	GetLastTransactions(ctx context.Context, n int) ([]Transaction, error)
+	LastTransaction(ctx context.Context) (int64, time.Time, error)
+	ListCounterparties(ctx context.Context, address string, q CounterpartyQuery) ([]Counterparty, error)
+	GetWalletStats(ctx context.Context, address string) (WalletStats, error)
+	ListenTransactions(ctx context.Context, fn func(id int64)) error
+	TransactionsAfter(ctx context.Context, afterID int64, limit int) ([]Transaction, error)
+	TransactionsByIDs(ctx context.Context, ids []int64) ([]Transaction, error)
+	BalanceEvents(ctx context.Context, address string, q BalanceEventQuery) ([]BalanceEvent, error)
+	WalletActivity(ctx context.Context, address string, q ActivityQuery) ([]ActivityItem, error)
+	ExportTransactions(ctx context.Context, o ListOptions, w io.Writer) (int64, error)
+	ReadConsistent(ctx context.Context, fn func(ctx context.Context) error) error
+	TraceTransaction(ctx context.Context, id int64) (TransferTrace, error)
+}
 
-	rows, err := r.DB.QueryContext(ctx, `
-		SELECT id, from_address, to_address, amount_cents, created_at
-		FROM transactions
-		ORDER BY created_at DESC
-		LIMIT $1
-	`, n)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+// Sandbox, пополнение кошельков и сброс данных в режиме песочницы
+type Sandbox interface {
+	Faucet(ctx context.Context, address string, amountCents int64) (Transaction, error)
+	ResetSandbox(ctx context.Context) (map[string]int64, error)
+}
 
-	var out []Transaction
-	for rows.Next() {
-		var t Transaction
-		if err := rows.Scan(&t.ID, &t.FromAddress, &t.ToAddress, &t.AmountCents, &t.CreatedAt); err != nil {
-			return nil, err
-		}
-		out = append(out, t)
-	}
-	return out, rows.Err()
+// Compliance, стоп-лист, аудит, сигналы и административные настройки кошельков
+type Compliance interface {
+	AddToDenylist(ctx context.Context, address, reason, actor string) error
+	RemoveFromDenylist(ctx context.Context, address, actor string) error
+	ListDenylist(ctx context.Context) ([]DenylistEntry, error)
+	RecordAudit(ctx context.Context, e AuditEntry) error
+
+	InsertAlert(ctx context.Context, a Alert) (bool, error)
+	ListAlerts(ctx context.Context, f AlertFilter) ([]Alert, error)
+
+	SetOverdraftLimit(ctx context.Context, address string, limitCents int64, actor string) error
+	SetWalletEmail(ctx context.Context, address, email, actor string) error
+	SetLowBalanceThreshold(ctx context.Context, address string, thresholdCents int64, actor string) error
+	UpdateWalletMeta(ctx context.Context, address string, version int64, p WalletMetaPatch, actor string) (Wallet, error)
+	SetWalletHot(ctx context.Context, address string, hot bool, actor string) error
+	MergeWallet(ctx context.Context, from, into, actor string) (WalletMerge, error)
+	ListHotWallets(ctx context.Context) ([]HotWallet, error)
+	DormantWallets(ctx context.Context, q DormantQuery) ([]Wallet, error)
+	SearchWallets(ctx context.Context, q string, limit int) ([]WalletMatch, error)
+	SystemWalletAddress(ctx context.Context, role string) (string, error)
+	ListSystemWallets(ctx context.Context) ([]SystemWallet, error)
+	Mint(ctx context.Context, amountCents int64, reason string) (Transaction, error)
+	Burn(ctx context.Context, amountCents int64, reason string) (Transaction, error)
+	CreateSweep(ctx context.Context, destination string, sources []string, mode SweepMode) (Sweep, error)
+	SweepChunk(ctx context.Context, id int64, limit int) (bool, error)
+	GetSweep(ctx context.Context, id int64) (Sweep, []SweepWallet, error)
+	ResumeSweep(ctx context.Context, id int64) (Sweep, error)
+	ReconcileBalances(ctx context.Context) ([]BalanceMismatch, error)
+	CompareLedger(ctx context.Context) (LedgerComparison, error)
+	ResetLedger(ctx context.Context) (int64, error)
+	Settle(ctx context.Context, date string, loc *time.Location) (SettlementRun, error)
+	GetSettlement(ctx context.Context, date string) (SettlementRun, []SettlementLine, error)
+	Stats(ctx context.Context, since time.Time) (SystemStats, error)
+	Explain(ctx context.Context, name string, p ExplainParams) (QueryPlan, error)
+}
+
+// Users, пользователи, ключи доступа и второй фактор
+type Users interface {
+	RegisterUser(ctx context.Context, email, name, keyHash, keyPrefix string) (User, error)
+	GetUser(ctx context.Context, id int64) (User, error)
+	UserByExternalIdentity(ctx context.Context, id ExternalIdentity) (User, error)
+	LookupAPIKey(ctx context.Context, keyHash string) (APIKey, error)
+	ListenAPIKeys(ctx context.Context, listening func(), fn func(id int64)) error
+	CreateAPIKey(ctx context.Context, userID int64, name string, scopes []string, keyHash, keyPrefix string) (APIKeyInfo, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]APIKeyInfo, error)
+	RotateAPIKey(ctx context.Context, userID, keyID int64, overlap time.Duration, keyHash, keyPrefix string) (APIKeyInfo, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int64) error
+	SetAPIKeySigningSecret(ctx context.Context, userID, keyID int64, secret string) error
+	ConsumeNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) (bool, error)
+	BeginIdempotent(ctx context.Context, actor, key, fingerprint string, ttl time.Duration) (*IdempotentResponse, error)
+	CompleteIdempotent(ctx context.Context, actor, key string, resp IdempotentResponse) error
+	ReleaseIdempotent(ctx context.Context, actor, key string) error
+	GetTOTP(ctx context.Context, userID int64) (string, bool, error)
+	SetTOTPSecret(ctx context.Context, userID int64, secret, enrollTokenHash string, enrollExpiresAt time.Time) error
+	EnableTOTP(ctx context.Context, userID int64, enrollTokenHash string) error
+	UseTOTPCounter(ctx context.Context, userID, counter int64) error
+}
+
+// Wallets, владение кошельками и адресные книги
+type Wallets interface {
+	WalletOwner(ctx context.Context, address string) (int64, error)
+	CreateWallet(ctx context.Context, userID int64, address string) (Wallet, error)
+	GetOrCreateWallet(ctx context.Context, userID int64, address string) (Wallet, bool, error)
+	CreateDerivedWallet(ctx context.Context, addr, label, actor string) (Wallet, bool, error)
+	ListUserWallets(ctx context.Context, userID int64) ([]Wallet, error)
+	AddPayee(ctx context.Context, wallet, alias, address string) (Payee, error)
+	ListPayees(ctx context.Context, wallet string) ([]Payee, error)
+	DeletePayee(ctx context.Context, wallet, alias string) error
+	ListDeletedPayees(ctx context.Context, wallet string) ([]Payee, error)
+	RestorePayee(ctx context.Context, wallet, alias, actor string) (Payee, error)
+	ResolvePayee(ctx context.Context, wallet, alias string) (string, error)
+}
+
+// PendingTransfers, переводы, ждущие подтверждения вторым фактором
+type PendingTransfers interface {
+	CreatePendingTransfer(ctx context.Context, p PendingTransfer, tokenHash string) (PendingTransfer, error)
+	GetPendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+	PendingTransferByToken(ctx context.Context, tokenHash string) (PendingTransfer, error)
+	RecordPendingAttempt(ctx context.Context, id int64, maxAttempts int) error
+	ExecutePendingTransfer(ctx context.Context, id int64) (PendingTransfer, error)
+}
+
+// PaymentRequests, запросы платежа между кошельками
+type PaymentRequests interface {
+	CreatePaymentRequest(ctx context.Context, p PaymentRequest) (PaymentRequest, error)
+	GetPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	ListPaymentRequests(ctx context.Context, wallet string, f PaymentRequestFilter) ([]PaymentRequest, error)
+	PayPaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+	DeclinePaymentRequest(ctx context.Context, id int64) (PaymentRequest, error)
+}
+
+// StandingOrders, постоянные поручения, регулярные переводы по расписанию
+type StandingOrders interface {
+	CreateStandingOrder(ctx context.Context, o StandingOrder) (StandingOrder, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	ListStandingOrders(ctx context.Context, wallet string, withCancelled bool) ([]StandingOrder, error)
+	ListDeletedStandingOrders(ctx context.Context, wallet string) ([]StandingOrder, error)
+	StandingOrderRuns(ctx context.Context, id int64, limit int) ([]StandingOrderRun, error)
+	PauseStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	ResumeStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	UpdateStandingOrder(ctx context.Context, id, version int64, p StandingOrderPatch) (StandingOrder, error)
+	DeleteStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	RestoreStandingOrder(ctx context.Context, id int64, actor string) (StandingOrder, error)
+	RunDueStandingOrder(ctx context.Context, now time.Time) (bool, error)
+}
+
+// Jobs, очередь фоновых задач
+type Jobs interface {
+	EnqueueJob(ctx context.Context, kind string, payload any) error
 }
 
 // PostgresRepo, реализация репозитория поверх sql базы
-type PostgresRepo struct{ DB *sql.DB }
+type PostgresRepo struct {
+	DB *sql.DB
+	// Locks, очередь переводов по кошелькам внутри процесса перед транзакцией базы, nil выключает
+	Locks *WalletLocks
+	// ListLimit, наибольшая страница истории для любого вызова, ноль дает MaxListLimit
+	ListLimit int
+	// Addresses, вывод адресов служебных кошельков при сбросе песочницы, nil дает случайные
+	Addresses *address.Deriver
+}
 
 // NewPostgres, конструктор репозитория
 func NewPostgres(db *sql.DB) *PostgresRepo { return &PostgresRepo{DB: db} }
 
-// GetBalance, возвращает баланс кошелька в центах, маппит отсутствие строки на доменную ошибку кошелек не найден
+// GetBalance, возвращает баланс кошелька в центах вместе с неприменными зачислениями, маппит отсутствие строки на доменную ошибку кошелек не найден,
+// в теневом режиме тот же запрос читает и счет кошелька, ответ все равно из колонки
 func (r *PostgresRepo) GetBalance(ctx context.Context, address string) (int64, error) {
-	const q = `SELECT balance_cents FROM wallets WHERE address=$1`
 	var cents int64
-	if err := r.DB.QueryRowContext(ctx, q, address).Scan(&cents); err != nil {
+	var err error
+	if CurrentLedgerMode() == LedgerShadow {
+		var ledger sql.NullInt64
+		err = r.DB.QueryRowContext(ctx, `SELECT balance_cents + `+hotPendingCents+`, `+ledgerBalance+` FROM wallets WHERE address=$1`, address).Scan(&cents, &ledger)
+		if err == nil {
+			shadowCompare(address, cents, ledger)
+		}
+	} else {
+		err = r.DB.QueryRowContext(ctx, balanceQuery, address).Scan(&cents)
+	}
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, ErrWalletNotFound
+			return 0, &WalletNotFoundError{Address: address}
 		}
-		return 0, err
+		return 0, wrapf(err, "get balance %s", address)
 	}
 	return cents, nil
 }
 
+// GetWallet, кошелек с балансом, владельцем и временем создания, изменения и последнего перевода, в теневом режиме баланс сверяется со счетом
+func (r *PostgresRepo) GetWallet(ctx context.Context, address string) (Wallet, error) {
+	var w Wallet
+	var err error
+	if CurrentLedgerMode() == LedgerShadow {
+		var ledger sql.NullInt64
+		w, err = scanWallet(shadowRow{row: r.DB.QueryRowContext(ctx, `SELECT `+walletColumns+`, `+ledgerBalance+` FROM wallets WHERE address = $1`, address), ledger: &ledger})
+		if err == nil {
+			shadowCompare(address, w.BalanceCents, ledger)
+		}
+	} else {
+		w, err = scanWallet(r.DB.QueryRowContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE address = $1`, address))
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return Wallet{}, &WalletNotFoundError{Address: address}
+	}
+	return w, wrapf(err, "get wallet %s", address)
+}
+
+// GetWallets, кошельки по списку адресов одним запросом, отсутствующих в ответе нет, порядок не задан
+func (r *PostgresRepo) GetWallets(ctx context.Context, addresses []string) ([]Wallet, error) {
+	rows, err := r.DB.QueryContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE address = ANY($1)`, addresses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Wallet
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
 // isDeadlock, определяет конфликт блокировок по коду ошибки postgres, код 40P01
 func isDeadlock(err error) bool {
 	var pgerr *pgconn.PgError
 	return errors.As(err, &pgerr) && pgerr.Code == "40P01"
 }
 
-// transferOnce, выполняет один перевод в транзакции, валидирует входные данные, блокирует оба кошелька в стабильном порядке по адресу, проверяет баланс, обновляет балансы, пишет запись в журнал транзакций, коммитит
+// balanceCheckConstraint, имя ограничения баланса снизу с учетом овердрафта из миграции 0006
+const balanceCheckConstraint = "wallets_balance_within_overdraft"
+
+// isNegativeBalance, определяет выход баланса за разрешенный минус по коду ошибки postgres 23514 и имени ограничения
+func isNegativeBalance(err error) bool {
+	var pgerr *pgconn.PgError
+	return errors.As(err, &pgerr) && pgerr.Code == "23514" && pgerr.ConstraintName == balanceCheckConstraint
+}
+
+// balanceMaxConstraint, имя ограничения баланса сверху из миграции 0028
+const balanceMaxConstraint = "wallets_balance_max"
+
+// isBalanceOverflow, баланс вышел за money.MaxCents, ловит ограничение базы и выход за bigint с кодом 22003
+func isBalanceOverflow(err error) bool {
+	var pgerr *pgconn.PgError
+	if !errors.As(err, &pgerr) {
+		return false
+	}
+	return pgerr.Code == "22003" || pgerr.Code == "23514" && pgerr.ConstraintName == balanceMaxConstraint
+}
+
+// transferOnce, выполняет один перевод в отдельной транзакции и коммитит
 func (r *PostgresRepo) transferOnce(ctx context.Context, from, to string, amountCents int64) error {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := transferTx(ctx, tx, from, to, amountCents); err != nil {
+		return err
+	}
+	if err := injectFault(FaultBeforeCommit); err != nil {
+		return err
+	}
+	// фиксируем изменения
+	return tx.Commit()
+}
+
+// lockedWallet, строка кошелька, заблокированная на время перевода
+type lockedWallet struct {
+	addr      string
+	bal       int64
+	overdraft int64
+	// lowBalance, порог низкого баланса, ноль если не задан, lowAlert, баланс уже ниже порога
+	lowBalance int64
+	lowAlert   bool
+	// successor, преемник закрытого слиянием кошелька, пустой у открытого
+	successor string
+}
+
+// lockWallets, блокирует строки кошельков FOR UPDATE в порядке адресов и закрывает курсор до возврата, пока он открыт, соединение занято, и следующий запрос той же транзакции у драйвера без буферизации строк падает
+func lockWallets(ctx context.Context, tx *sql.Tx, a1, a2 string) ([]lockedWallet, error) {
+	defer waited(ctx, time.Now())
+	rows, err := tx.QueryContext(ctx, lockWalletsQuery, a1, a2)
+	if err != nil {
+		return nil, err
+	}
+
+	var got []lockedWallet
+	for rows.Next() {
+		var w lockedWallet
+		if err := rows.Scan(&w.addr, &w.bal, &w.overdraft, &w.lowBalance, &w.lowAlert, &w.successor); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		got = append(got, w)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	return got, nil
+}
+
+// lowBalanceSince, новое значение low_balance_since при записи баланса $1, момент начала сохраняется, пока баланс ниже порога, без порога NULL
+const lowBalanceSince = `CASE WHEN $1 < low_balance_cents THEN COALESCE(low_balance_since, now()) END`
+
+// transferTx, перевод внутри переданной транзакции, движение средств и квитанции крупного перевода, коммит остается вызывающему
+func transferTx(ctx context.Context, tx *sql.Tx, from, to string, amountCents int64) error {
+	if err := moveFundsTx(ctx, tx, from, to, amountCents); err != nil {
+		return err
+	}
+	return enqueueReceiptsTx(ctx, tx, from, to, amountCents)
+}
+
+// moveFundsTx, валидирует входные данные, блокирует оба кошелька в стабильном порядке по адресу, проверяет баланс, обновляет балансы, пишет запись в журнал транзакций
+func moveFundsTx(ctx context.Context, tx *sql.Tx, from, to string, amountCents int64) error {
 	if from == to {
 		return ErrSameAddress
 	}
@@ -98,11 +413,18 @@ func (r *PostgresRepo) transferOnce(ctx context.Context, from, to string, amount
 		return errors.New("amount must be > 0")
 	}
 
-	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	// проверяем стоп-лист до блокировки кошельков, запрещенный адрес не должен участвовать ни как отправитель, ни как получатель
+	f, err := transferFlagsOf(ctx, tx, from, to)
 	if err != nil {
 		return err
 	}
-	defer func() { _ = tx.Rollback() }()
+	if f.denied {
+		return ErrAddressDenied
+	}
+	// горячему получателю зачисление ставится в очередь, его строка не блокируется
+	if f.toHot {
+		return hotTransferTx(ctx, tx, from, to, amountCents, f.fromPending)
+	}
 
 	// определяем порядок блокировки строк, всегда сначала меньший адрес, затем больший, это снижает риск дедлока
 	a1, a2 := from, to
@@ -112,106 +434,172 @@ func (r *PostgresRepo) transferOnce(ctx context.Context, from, to string, amount
 		swap = true
 	}
 
-	type row struct {
-		addr string
-		bal  int64
-	}
 	// выбираем обе строки с блокировкой, порядок по адресу, тем самым соблюдаем одинаковый порядок блокировок
-	rows, err := tx.QueryContext(ctx, `
-		SELECT address, balance_cents
-		FROM wallets
-		WHERE address = $1 OR address = $2
-		ORDER BY address
-		FOR UPDATE
-	`, a1, a2)
+	got, err := lockWallets(ctx, tx, a1, a2)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	var got []row
-	for rows.Next() {
-		var rrow row
-		if err := rows.Scan(&rrow.addr, &rrow.bal); err != nil {
-			return err
-		}
-		got = append(got, rrow)
+	if len(got) != 2 {
+		return missingWallet(got, from, to)
 	}
-	if err := rows.Err(); err != nil {
+	if err := closedWallet(got); err != nil {
 		return err
 	}
-	if len(got) != 2 {
-		return ErrWalletNotFound
-	}
 
 	// раскладываем балансы по ролям с учетом возможной перестановки адресов
-	var fromBal, toBal int64
+	var fromBal, toBal, fromOverdraft int64
+	sender := got[0]
 	if !swap {
 		// ожидаем что первый это from, второй это to, балансы берем по позиции
 		fromBal = got[0].bal
 		toBal = got[1].bal
+		fromOverdraft = got[0].overdraft
 	} else {
 		// адреса поменяны местами, значит баланс отправителя во втором элементе
 		fromBal = got[1].bal
 		toBal = got[0].bal
+		fromOverdraft = got[1].overdraft
+		sender = got[1]
+	}
+	// отправитель сам горячий, его очередь зачислений применяется до проверки средств
+	if f.fromPending {
+		if fromBal, err = applyHotCredits(ctx, tx, from, fromBal); err != nil {
+			return err
+		}
 	}
 
-	// проверка достаточности средств
-	if fromBal < amountCents {
-	return ErrInsufficientFunds
+	// проверка достаточности средств с учетом разрешенного овердрафта
+	if fromBal+fromOverdraft < amountCents {
+		return &InsufficientFundsError{Address: from, NeededCents: amountCents, AvailableCents: fromBal + fromOverdraft}
+	}
+	// зачисление не должно вывести баланс получателя за предел, иначе сумма завернулась бы через int64
+	toNew, err := money.Add(toBal, amountCents)
+	if err != nil || toNew > money.MaxCents {
+		return ErrBalanceOverflow
 	}
 
-	// обновляем баланс отправителя
+	if err := debitTx(ctx, tx, sender, fromBal, amountCents); err != nil {
+		return err
+	}
+	// обновляем баланс получателя, поднявшийся до порога баланс снимает состояние низкого баланса
 	if _, err := tx.ExecContext(ctx,
-		`UPDATE wallets SET balance_cents = $1 WHERE address = $2`,
-		fromBal-amountCents, from); err != nil {
+		`UPDATE wallets SET balance_cents = $1, updated_at = now(), last_tx_at = now(), low_balance_since = `+lowBalanceSince+` WHERE address = $2`,
+		toNew, to); err != nil {
+		if isBalanceOverflow(err) {
+			return ErrBalanceOverflow
+		}
 		return err
 	}
-	// обновляем баланс получателя
+
+	// добавляем запись о переводе и изменения балансов обеих сторон
+	return insertTransaction(ctx, tx, TxTypeTransfer, from, to, amountCents, fromBal-amountCents, toNew)
+}
+
+// debitTx, списание amountCents с заблокированного отправителя с балансом fromBal, ограничение в базе страхует от ухода в минус даже при ошибке в проверке средств,
+// отказ ограничения отдается с теми же подробностями, что и проверка средств
+func debitTx(ctx context.Context, tx *sql.Tx, sender lockedWallet, fromBal, amountCents int64) error {
+	fromNew := fromBal - amountCents
 	if _, err := tx.ExecContext(ctx,
-		`UPDATE wallets SET balance_cents = $1 WHERE address = $2`,
-		toBal+amountCents, to); err != nil {
+		`UPDATE wallets SET balance_cents = $1, updated_at = now(), last_tx_at = now(), low_balance_since = `+lowBalanceSince+` WHERE address = $2`,
+		fromNew, sender.addr); err != nil {
+		if isNegativeBalance(err) {
+			return &InsufficientFundsError{Address: sender.addr, NeededCents: amountCents, AvailableCents: fromBal + sender.overdraft}
+		}
+		return err
+	}
+	// баланс впервые опустился ниже порога, уведомление ставится в той же транзакции и откатывается вместе с переводом
+	if sender.lowBalance > 0 && !sender.lowAlert && fromNew < sender.lowBalance {
+		if err := enqueueJob(ctx, tx, notify.KindLowBalance, notify.LowBalance{
+			Address: sender.addr, BalanceCents: fromNew, ThresholdCents: sender.lowBalance, At: time.Now().UTC(),
+		}, time.Time{}); err != nil {
+			return err
+		}
+	}
+	// в режиме проверки целостности здесь может случиться сбой, списание уже сделано, зачисления еще нет
+	if err := injectFault(FaultAfterDebit); err != nil {
 		return err
 	}
+	return injectFault(FaultBeforeCredit)
+}
 
-	// добавляем запись о переводе
-	if _, err := tx.ExecContext(ctx, `
-		INSERT INTO transactions(from_address, to_address, amount_cents)
-		VALUES ($1, $2, $3)
-	`, from, to, amountCents); err != nil {
+// insertTransaction, пишет строку операции и по событию изменения баланса на каждую сторону одним запросом, fromBal и toBal, балансы сторон после операции,
+// инициатор, канал и id группы берутся из контекста, внутри retryTransfer рядом пишутся сведения о выполнении, в режиме двойной записи и проводки сторон
+func insertTransaction(ctx context.Context, tx *sql.Tx, txType, from, to string, amountCents, fromBal, toBal int64) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `
+		WITH t AS (
+			INSERT INTO transactions(from_address, to_address, amount_cents, initiated_by, channel, type, group_id)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid)
+			RETURNING id, created_at
+		), `+traceCTE(10)+`, e AS (
+			INSERT INTO balance_events(address, tx_id, delta_cents, balance_cents, created_at)
+			SELECT e.address, t.id, e.delta, e.balance, t.created_at
+			FROM t, (VALUES ($1, -$3::bigint, $8::bigint), ($2, $3::bigint, $9::bigint)) AS e(address, delta, balance)
+		)
+		SELECT id FROM t
+	`, append([]any{from, to, amountCents, ActorFromContext(ctx), ChannelFromContext(ctx), txType, TransferGroupFromContext(ctx), fromBal, toBal}, traceArgs(ctx)...)...).Scan(&id)
+	if err != nil {
 		return err
 	}
+	return postLedger(ctx, tx, id, ledgerPosting{from, -amountCents}, ledgerPosting{to, amountCents})
+}
 
-	// фиксируем изменения
-	return tx.Commit()
+// Transfer, выполняет перевод, при дедлоках повторяет попытку с задержкой, останавливается при успехе или любой другой ошибке,
+// ошибка дополняется адресами перевода, доменные отказы различаются через errors.Is, подробности отказа по средствам через errors.As с *InsufficientFundsError
+func (r *PostgresRepo) Transfer(ctx context.Context, from, to string, amountCents int64) error {
+	err := r.retryTransfer(ctx, from, to, amountCents, func(ctx context.Context) error {
+		return r.transferOnce(ctx, from, to, amountCents)
+	})
+	return wrapf(err, "transfer %s->%s", from, to)
+}
+
+// retryTransfer, повторяет попытку перевода once при дедлоках с растущей задержкой, отказ по стоп-листу пишет в аудит, с Locks перевод сначала ждет полосы обоих кошельков,
+// once получает контекст со сведениями о выполнении, число попыток и ожидание блокировок пишутся вместе со строкой перевода
+func (r *PostgresRepo) retryTransfer(ctx context.Context, from, to string, amountCents int64, once func(ctx context.Context) error) error {
+	ctx, st := withTransferStats(ctx)
+	start := time.Now()
+	unlock, err := r.lockWallets(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	waited(ctx, start)
+	err = retryDeadlocks(ctx, func() error {
+		st.attempts++
+		return once(ctx)
+	})
+	unlock()
+	if errors.Is(err, ErrAddressDenied) {
+		// попытку перевода с участием запрещенного адреса фиксируем в журнале аудита отдельно от откаченной транзакции
+		r.auditDeniedTransfer(ctx, from, to, amountCents)
+	}
+	return err
 }
 
-// Transfer, выполняет перевод, при дедлоках повторяет попытку с задержкой, останавливается при успехе или любой другой ошибке
+// retryDeadlocks, повторяет once, пока она падает на дедлоке, останавливается при успехе или любой другой ошибке
+func retryDeadlocks(ctx context.Context, once func() error) error {
+	const maxAttempts = 10
 
-func (r *PostgresRepo) Transfer(ctx context.Context, from, to string, amountCents int64) error {
-    const maxAttempts = 10 
-
-    for attempt := 0; attempt < maxAttempts; attempt++ {
-        err := r.transferOnce(ctx, from, to, amountCents)
-        if err == nil {
-            return nil
-        }
-        if isDeadlock(err) {
-            // вычисляем задержку, шаг растет с номером попытки, добавляем случайный джиттер, ждем или выходим по контексту
-            backoff := time.Duration(15*(attempt+1)) * time.Millisecond
-            jitter  := time.Duration(rand.Intn(15)) * time.Millisecond 
-            sleep := backoff + jitter
-
-            select {
-            case <-time.After(sleep):
-                continue
-            case <-ctx.Done():
-                return ctx.Err()
-            }
-        }
-        // если ошибка не дедлок, возвращаем ее сразу
-        return err
-    }
-    // все попытки исчерпаны, сообщаем об ошибке
-    return errors.New("could not complete transfer after retries")
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err := once()
+		if err == nil {
+			return nil
+		}
+		if isDeadlock(err) {
+			// вычисляем задержку, шаг растет с номером попытки, добавляем случайный джиттер, ждем или выходим по контексту
+			backoff := time.Duration(15*(attempt+1)) * time.Millisecond
+			jitter := time.Duration(rand.Intn(15)) * time.Millisecond
+			sleep := backoff + jitter
+
+			select {
+			case <-time.After(sleep):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		// если ошибка не дедлок, возвращаем ее сразу
+		return err
+	}
+	// все попытки исчерпаны, сообщаем об ошибке
+	return ErrContention
 }
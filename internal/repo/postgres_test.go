@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TestIsNegativeBalance, проверяет распознавание нарушения ограничения баланса, в том числе обернутой ошибки
+func TestIsNegativeBalance(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"balance check", &pgconn.PgError{Code: "23514", ConstraintName: balanceCheckConstraint}, true},
+		{"wrapped", fmt.Errorf("update: %w", &pgconn.PgError{Code: "23514", ConstraintName: balanceCheckConstraint}), true},
+		{"other check", &pgconn.PgError{Code: "23514", ConstraintName: "transactions_amount_cents_check"}, false},
+		{"deadlock", &pgconn.PgError{Code: "40P01"}, false},
+		{"plain", fmt.Errorf("boom"), false},
+	}
+	for _, c := range cases {
+		if got := isNegativeBalance(c.err); got != c.want {
+			t.Fatalf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package repo
+
+import (
+	"context"
+
+	"gotechtask/internal/money"
+)
+
+// TransferQuote, оценка перевода без движения средств, балансы видимые, с очередью зачислений горячего кошелька,
+// ToFound, получатель найден, без него ToBalanceCents не определен, Err, доменный отказ, который перевод получил бы сейчас, nil, если пройдет
+type TransferQuote struct {
+	FromBalanceCents   int64
+	FromOverdraftCents int64
+	ToBalanceCents     int64
+	ToFound            bool
+	Err                error
+}
+
+// AvailableCents, сколько отправитель может перевести с учетом овердрафта
+func (q TransferQuote) AvailableCents() int64 {
+	return q.FromBalanceCents + q.FromOverdraftCents
+}
+
+// QuoteTransfer, проверяет перевод теми же правилами и в том же порядке, что и Transfer, стоп-лист, существование, закрытие слиянием, средства с овердрафтом и предел баланса получателя,
+// строки не блокируются и ничего не пишется, так что одновременный перевод может изменить исход, ошибка результата только при сбое чтения
+func (r *PostgresRepo) QuoteTransfer(ctx context.Context, from, to string, amountCents int64) (TransferQuote, error) {
+	var q TransferQuote
+	if from == to {
+		q.Err = ErrSameAddress
+		return q, nil
+	}
+
+	var denied bool
+	if err := r.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM denylist WHERE address = ANY($1))`, []string{from, to}).Scan(&denied); err != nil {
+		return q, wrapf(err, "quote transfer %s->%s", from, to)
+	}
+	if denied {
+		q.Err = ErrAddressDenied
+		return q, nil
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT address, balance_cents + `+hotPendingCents+`, overdraft_limit_cents, COALESCE(successor, '')
+		FROM wallets
+		WHERE address = $1 OR address = $2
+	`, from, to)
+	if err != nil {
+		return q, wrapf(err, "quote transfer %s->%s", from, to)
+	}
+	defer rows.Close()
+
+	var fromFound bool
+	var fromSuccessor, toSuccessor string
+	for rows.Next() {
+		var addr, successor string
+		var bal, overdraft int64
+		if err := rows.Scan(&addr, &bal, &overdraft, &successor); err != nil {
+			return q, wrapf(err, "quote transfer %s->%s", from, to)
+		}
+		if addr == from {
+			fromFound, fromSuccessor = true, successor
+			q.FromBalanceCents, q.FromOverdraftCents = bal, overdraft
+		} else {
+			q.ToFound, toSuccessor = true, successor
+			q.ToBalanceCents = bal
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return q, wrapf(err, "quote transfer %s->%s", from, to)
+	}
+
+	switch {
+	case !fromFound:
+		q.Err = &WalletNotFoundError{Address: from}
+	case !q.ToFound:
+		q.Err = &WalletNotFoundError{Address: to}
+	case fromSuccessor != "":
+		q.Err = &WalletClosedError{Address: from, Successor: fromSuccessor}
+	case toSuccessor != "":
+		q.Err = &WalletClosedError{Address: to, Successor: toSuccessor}
+	case q.AvailableCents() < amountCents:
+		q.Err = &InsufficientFundsError{Address: from, NeededCents: amountCents, AvailableCents: q.AvailableCents()}
+	default:
+		if toNew, err := money.Add(q.ToBalanceCents, amountCents); err != nil || toNew > money.MaxCents {
+			q.Err = ErrBalanceOverflow
+		}
+	}
+	return q, nil
+}
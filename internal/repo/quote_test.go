@@ -0,0 +1,65 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestQuoteTransfer, оценка отказывает по тем же правилам, что и перевод, учитывает овердрафт и очередь горячего кошелька и ничего не меняет
+func TestQuoteTransfer(t *testing.T) {
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := WithCaller(context.Background(), Caller{Admin: true, Channel: ChannelCLI})
+
+	a, b, hot, denied := fx.Wallet(1000), fx.Wallet(500), fx.Wallet(0), fx.Wallet(0)
+	if err := r.SetOverdraftLimit(ctx, a, 300, "test"); err != nil {
+		t.Fatalf("overdraft: %v", err)
+	}
+	if err := r.SetWalletHot(ctx, hot, true, "test"); err != nil {
+		t.Fatalf("set hot: %v", err)
+	}
+	if err := r.Transfer(ctx, b, hot, 200); err != nil {
+		t.Fatalf("hot transfer: %v", err)
+	}
+	if err := r.AddToDenylist(ctx, denied, "test", "test"); err != nil {
+		t.Fatalf("denylist: %v", err)
+	}
+
+	q, err := r.QuoteTransfer(ctx, a, hot, 1300)
+	if err != nil || q.Err != nil || q.AvailableCents() != 1300 || q.FromOverdraftCents != 300 || !q.ToFound || q.ToBalanceCents != 200 {
+		t.Fatalf("quote within overdraft: %+v %v", q, err)
+	}
+	if bal, _ := r.GetBalance(ctx, a); bal != 1000 {
+		t.Fatalf("quote moved funds: balance %d", bal)
+	}
+
+	// очередь горячего кошелька входит в доступное отправителю
+	if q, err := r.QuoteTransfer(ctx, hot, b, 200); err != nil || q.Err != nil {
+		t.Fatalf("quote from hot: %+v %v", q, err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		from, to string
+		cents    int64
+		want     error
+	}{
+		{"insufficient", a, b, 1301, ErrInsufficientFunds},
+		{"same address", a, a, 1, ErrSameAddress},
+		{"denied", a, denied, 1, ErrAddressDenied},
+		{"missing", a, randomAddress(), 1, ErrWalletNotFound},
+	} {
+		q, err := r.QuoteTransfer(ctx, tc.from, tc.to, tc.cents)
+		if err != nil || !errors.Is(q.Err, tc.want) {
+			t.Fatalf("%s: want %v, got %+v %v", tc.name, tc.want, q, err)
+		}
+		// перевод с теми же данными отказывает так же
+		if err := r.Transfer(ctx, tc.from, tc.to, tc.cents); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: transfer got %v", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// AuditWalletRebuild, действие журнала аудита, перезапись балансов пересборкой по журналу
+const AuditWalletRebuild = "wallet.rebuild"
+
+// ошибки пересборки, снимков балансов нет, пересобранный баланс ниже лимита овердрафта, балансы тогда не переписываются
+var (
+	ErrNoSnapshot       = errors.New("no balance snapshot")
+	ErrRebuildOverdraft = errors.New("rebuilt balance below overdraft limit")
+)
+
+// RebuildOptions, параметры пересборки балансов, FromSnapshot, начинать с последнего снимка балансов, иначе с нуля по эмиссиям кошельков,
+// Apply, переписать балансы в wallets, иначе результат только в теневой таблице wallets_rebuild, Addresses, только эти кошельки, пустой список значит все
+type RebuildOptions struct {
+	FromSnapshot bool
+	Apply        bool
+	Addresses    []string
+}
+
+// BalanceDiff, кошелек, у которого текущий баланс не совпал с пересобранным
+type BalanceDiff struct {
+	Address      string
+	BalanceCents int64
+	RebuiltCents int64
+}
+
+// BalanceRebuild, итог пересборки, SnapshotAt, от какого снимка считали, нулевое при пересборке с нуля,
+// UnattributedCents, эмиссия без адреса, например исходная из миграции, ее нельзя отнести к кошельку, при пересборке с нуля такие кошельки расходятся
+type BalanceRebuild struct {
+	Wallets           int
+	SnapshotAt        time.Time
+	UnattributedCents int64
+	Diffs             []BalanceDiff
+	Applied           bool
+}
+
+// rebuildQuery, пересобранные балансы кошельков, $1, момент снимка или NULL для пересборки с нуля, $2, список адресов или NULL,
+// с нуля начальный баланс это эмиссии с адресом кошелька, кроме mint и burn, они уже есть в журнале операциями, журнал берется вместе с архивом
+const rebuildQuery = `
+	WITH opening AS (
+		SELECT address, balance_cents AS cents FROM balance_snapshots WHERE as_of = $1
+		UNION ALL
+		SELECT address, delta_cents FROM supply_adjustments
+		WHERE $1::timestamptz IS NULL AND address IS NOT NULL AND split_part(reason, ':', 1) NOT IN ('mint', 'burn')
+	),
+	ledger AS (
+		SELECT from_address, to_address, amount_cents, created_at FROM transactions
+		UNION ALL
+		SELECT from_address, to_address, amount_cents, created_at FROM transactions_archive
+	),
+	moves AS (
+		SELECT to_address AS address, amount_cents AS cents FROM ledger WHERE $1::timestamptz IS NULL OR created_at > $1
+		UNION ALL
+		SELECT from_address, -amount_cents FROM ledger WHERE $1::timestamptz IS NULL OR created_at > $1
+	),
+	rebuilt AS (
+		SELECT address, SUM(cents)::bigint AS cents FROM (SELECT * FROM opening UNION ALL SELECT * FROM moves) x GROUP BY address
+	)
+	INSERT INTO wallets_rebuild(address, balance_cents, rebuilt_cents)
+	SELECT w.address, w.balance_cents + COALESCE((SELECT SUM(h.amount_cents) FROM hot_credits h WHERE h.address = w.address), 0), COALESCE(r.cents, 0)
+	FROM wallets w
+	LEFT JOIN rebuilt r ON r.address = w.address
+	WHERE $2::text[] IS NULL OR w.address = ANY($2)
+`
+
+// RebuildBalances, пересобирает балансы кошельков по журналу операций в теневую таблицу wallets_rebuild и отдает расхождения с текущими,
+// с Apply переписывает расходящиеся балансы в wallets в той же транзакции, таблица кошельков на это время закрыта для записи, переводы ждут,
+// без Apply читает согласованный срез и ничего в wallets не меняет
+func (r *PostgresRepo) RebuildBalances(ctx context.Context, o RebuildOptions) (BalanceRebuild, error) {
+	var res BalanceRebuild
+	iso := sql.LevelRepeatableRead
+	if o.Apply {
+		iso = sql.LevelReadCommitted
+	}
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: iso})
+	if err != nil {
+		return res, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if o.Apply {
+		// EXCLUSIVE пропускает чтение, но не FOR UPDATE и не запись, начатые переводы успевают закоммититься до захвата
+		if _, err := tx.ExecContext(ctx, `LOCK TABLE wallets IN EXCLUSIVE MODE`); err != nil {
+			return res, err
+		}
+		// очереди зачислений применяются до пересборки, переписанный баланс уже включает их операции
+		if err := applyAllHotCredits(ctx, tx); err != nil {
+			return res, err
+		}
+	}
+
+	var snapAt sql.NullTime
+	if o.FromSnapshot {
+		if err := tx.QueryRowContext(ctx, `SELECT MAX(as_of) FROM balance_snapshots`).Scan(&snapAt); err != nil {
+			return res, err
+		}
+		if !snapAt.Valid {
+			return res, ErrNoSnapshot
+		}
+		res.SnapshotAt = snapAt.Time
+	} else if err := tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(delta_cents), 0)::bigint FROM supply_adjustments WHERE address IS NULL`,
+	).Scan(&res.UnattributedCents); err != nil {
+		return res, err
+	}
+
+	var addrs any
+	if len(o.Addresses) > 0 {
+		addrs = o.Addresses
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM wallets_rebuild`); err != nil {
+		return res, err
+	}
+	n, err := tx.ExecContext(ctx, rebuildQuery, snapAt, addrs)
+	if err != nil {
+		return res, err
+	}
+	wallets, _ := n.RowsAffected()
+	res.Wallets = int(wallets)
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT address, balance_cents, rebuilt_cents FROM wallets_rebuild
+		WHERE balance_cents <> rebuilt_cents
+		ORDER BY address
+	`)
+	if err != nil {
+		return res, err
+	}
+	for rows.Next() {
+		var d BalanceDiff
+		if err := rows.Scan(&d.Address, &d.BalanceCents, &d.RebuiltCents); err != nil {
+			_ = rows.Close()
+			return res, err
+		}
+		res.Diffs = append(res.Diffs, d)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return res, err
+	}
+	if err := rows.Close(); err != nil {
+		return res, err
+	}
+
+	if o.Apply && len(res.Diffs) > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE wallets w SET balance_cents = s.rebuilt_cents, updated_at = now()
+			FROM wallets_rebuild s
+			WHERE s.address = w.address AND s.balance_cents <> s.rebuilt_cents
+		`); err != nil {
+			if isNegativeBalance(err) {
+				return res, ErrRebuildOverdraft
+			}
+			return res, err
+		}
+		details := map[string]any{"wallets": res.Wallets, "changed": len(res.Diffs)}
+		if snapAt.Valid {
+			details["snapshot_at"] = snapAt.Time
+		}
+		if err := insertAudit(ctx, tx, AuditEntry{Action: AuditWalletRebuild, Details: details}); err != nil {
+			return res, err
+		}
+		res.Applied = true
+	}
+	return res, tx.Commit()
+}
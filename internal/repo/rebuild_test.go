@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestRebuildBalances, испорченный баланс виден в теневой пересборке, а с Apply восстанавливается по журналу
+func TestRebuildBalances(t *testing.T) {
+	t.Parallel()
+
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := WithCaller(context.Background(), Caller{Admin: true, Channel: ChannelCLI})
+
+	a := fx.Wallet(10000)
+	b := fx.Wallet(0)
+	defer func() {
+		_, _ = db.Exec(`DELETE FROM wallets_rebuild`)
+		_, _ = db.Exec(`DELETE FROM audit_log WHERE action=$1`, AuditWalletRebuild)
+	}()
+	if err := r.Transfer(ctx, a, b, 2500); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE wallets SET balance_cents = 999 WHERE address=$1`, b); err != nil {
+		t.Fatalf("corrupt: %v", err)
+	}
+
+	opts := RebuildOptions{Addresses: []string{a, b}}
+	res, err := r.RebuildBalances(ctx, opts)
+	if err != nil {
+		t.Fatalf("shadow rebuild: %v", err)
+	}
+	if res.Wallets != 2 || res.Applied {
+		t.Fatalf("shadow: want 2 wallets not applied, got %+v", res)
+	}
+	if len(res.Diffs) != 1 || res.Diffs[0] != (BalanceDiff{Address: b, BalanceCents: 999, RebuiltCents: 2500}) {
+		t.Fatalf("shadow diffs: %+v", res.Diffs)
+	}
+	var bal int64
+	_ = db.QueryRow(`SELECT balance_cents FROM wallets WHERE address=$1`, b).Scan(&bal)
+	if bal != 999 {
+		t.Fatalf("shadow rebuild changed wallets: %d", bal)
+	}
+
+	opts.Apply = true
+	if res, err = r.RebuildBalances(ctx, opts); err != nil || !res.Applied {
+		t.Fatalf("apply rebuild: %+v %v", res, err)
+	}
+	_ = db.QueryRow(`SELECT balance_cents FROM wallets WHERE address=$1`, b).Scan(&bal)
+	if bal != 2500 {
+		t.Fatalf("want restored 2500, got %d", bal)
+	}
+	if res, err = r.RebuildBalances(ctx, RebuildOptions{Addresses: []string{a, b}}); err != nil || len(res.Diffs) != 0 {
+		t.Fatalf("after apply: %+v %v", res, err)
+	}
+}
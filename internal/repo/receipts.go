@@ -0,0 +1,31 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"gotechtask/internal/notify"
+)
+
+// receiptThreshold, с какой суммы перевод ставит квитанции, общий для всех переводов процесса, как режим проводок, ноль выключает
+var receiptThreshold atomic.Int64
+
+// SetReceiptThreshold, порог квитанций для следующих переводов процесса, меняется без перезапуска
+func SetReceiptThreshold(cents int64) {
+	receiptThreshold.Store(cents)
+}
+
+// enqueueReceiptsTx, квитанции обеим сторонам перевода от порога в транзакции перевода, откатываются вместе с ним и не теряются после коммита
+func enqueueReceiptsTx(ctx context.Context, tx *sql.Tx, from, to string, amountCents int64) error {
+	if th := receiptThreshold.Load(); th <= 0 || amountCents < th {
+		return nil
+	}
+	for _, rc := range notify.Receipts(from, to, amountCents, time.Now().UTC()) {
+		if err := enqueueJob(ctx, tx, notify.KindTransferReceipt, rc, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"gotechtask/internal/notify"
+	"gotechtask/internal/testfixtures"
+)
+
+// TestTransferReceipts, перевод от порога ставит квитанции обеим сторонам в своей транзакции, любым путем, включая пакет, отказ квитанций не оставляет
+func TestTransferReceipts(t *testing.T) {
+	db := testfixtures.Open(t)
+	fx := testfixtures.New(t, db)
+	r := NewPostgres(db)
+	ctx := WithCaller(context.Background(), Caller{Admin: true, Channel: ChannelCLI})
+
+	SetReceiptThreshold(100)
+	defer SetReceiptThreshold(0)
+
+	a, b := fx.Wallet(1000), fx.Wallet(0)
+	defer db.Exec(`DELETE FROM jobs WHERE kind = $1 AND payload->>'from' = $2`, notify.KindTransferReceipt, a)
+	receipts := func() int {
+		var n int
+		if err := db.QueryRow(`SELECT count(*) FROM jobs WHERE kind = $1 AND payload->>'from' = $2`, notify.KindTransferReceipt, a).Scan(&n); err != nil {
+			t.Fatalf("count receipts: %v", err)
+		}
+		return n
+	}
+
+	if err := r.Transfer(ctx, a, b, 99); err != nil {
+		t.Fatalf("small transfer: %v", err)
+	}
+	if n := receipts(); n != 0 {
+		t.Fatalf("below threshold: %d receipts", n)
+	}
+	if err := r.Transfer(ctx, a, b, 100); err != nil {
+		t.Fatalf("large transfer: %v", err)
+	}
+	if n := receipts(); n != 2 {
+		t.Fatalf("at threshold: want 2 receipts, got %d", n)
+	}
+	if _, err := r.TransferBatch(ctx, []TransferItem{{From: a, To: b, AmountCents: 200}}, BatchAtomic); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if n := receipts(); n != 4 {
+		t.Fatalf("batch: want 4 receipts, got %d", n)
+	}
+	if err := r.Transfer(ctx, a, b, 10000); err == nil {
+		t.Fatal("overdrawn transfer succeeded")
+	}
+	if n := receipts(); n != 4 {
+		t.Fatalf("failed transfer left receipts: %d", n)
+	}
+}
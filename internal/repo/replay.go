@@ -0,0 +1,25 @@
+package repo
+
+import "context"
+
+// AuditReplaySeed, действие журнала аудита, баланс кошелька выставлен для повтора записи переводов
+const AuditReplaySeed = "replay.seed"
+
+// SeedReplayWallet, создает кошелек повтора, если его нет, и выставляет ему баланс balanceCents эмиссией или изъятием разницы, денежная масса сходится,
+// только для стендов, на которых повторяется запись переводов
+func (r *PostgresRepo) SeedReplayWallet(ctx context.Context, address string, balanceCents int64) error {
+	if _, err := r.DB.ExecContext(ctx, `INSERT INTO wallets(address, balance_cents) VALUES ($1, 0) ON CONFLICT (address) DO NOTHING`, address); err != nil {
+		return err
+	}
+	bal, err := r.GetBalance(ctx, address)
+	if err != nil {
+		return err
+	}
+	switch delta := balanceCents - bal; {
+	case delta > 0:
+		_, err = r.supplyOp(ctx, TxTypeMint, address, delta, "replay", AuditReplaySeed)
+	case delta < 0:
+		_, err = r.supplyOp(ctx, TxTypeBurn, address, -delta, "replay", AuditReplaySeed)
+	}
+	return err
+}
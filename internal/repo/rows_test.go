@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestLockWallets_ReleasesConn, курсор FOR UPDATE закрыт до обновлений при любом режиме исполнения запросов pgx, следующий запрос той же транзакции проходит, ошибочный перевод не оставляет занятых соединений
+func TestLockWallets_ReleasesConn(t *testing.T) {
+	t.Parallel()
+
+	modes := []pgx.QueryExecMode{
+		pgx.QueryExecModeCacheStatement,
+		pgx.QueryExecModeCacheDescribe,
+		pgx.QueryExecModeDescribeExec,
+		pgx.QueryExecModeExec,
+		pgx.QueryExecModeSimpleProtocol,
+	}
+	for _, mode := range modes {
+		t.Run(mode.String(), func(t *testing.T) {
+			cfg := testfixtures.Config(t)
+			cfg.DefaultQueryExecMode = mode
+			db := stdlib.OpenDB(*cfg)
+			// закрывается после удаления кошельков теста
+			t.Cleanup(func() { _ = db.Close() })
+			fx := testfixtures.New(t, db)
+			// одно соединение, занятое курсором оно не отдало бы следующему запросу
+			db.SetMaxOpenConns(1)
+			ctx := context.Background()
+
+			a, b := fx.Wallet(500), fx.Wallet(500)
+			a1, a2 := min(a, b), max(a, b)
+
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				t.Fatalf("begin: %v", err)
+			}
+			got, err := lockWallets(ctx, tx, a1, a2)
+			if err != nil || len(got) != 2 || got[0].addr != a1 || got[1].addr != a2 {
+				t.Fatalf("lock: %+v, %v", got, err)
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE wallets SET updated_at = now() WHERE address = $1`, a1); err != nil {
+				t.Fatalf("exec after lock: %v", err)
+			}
+			if err := tx.Rollback(); err != nil {
+				t.Fatalf("rollback: %v", err)
+			}
+
+			r := NewPostgres(db)
+			if err := r.Transfer(ctx, a, b, 200); err != nil {
+				t.Fatalf("transfer: %v", err)
+			}
+			if err := r.Transfer(ctx, a, b, 10_000); !errors.Is(err, ErrInsufficientFunds) {
+				t.Fatalf("want ErrInsufficientFunds, got %v", err)
+			}
+			if err := r.Transfer(ctx, a, randomAddress(), 1); !errors.Is(err, ErrWalletNotFound) {
+				t.Fatalf("want ErrWalletNotFound, got %v", err)
+			}
+			if st := db.Stats(); st.InUse != 0 {
+				t.Fatalf("connections in use after failed transfers: %d", st.InUse)
+			}
+			for addr, want := range map[string]int64{a: 300, b: 700} {
+				if bal, err := r.GetBalance(ctx, addr); err != nil || bal != want {
+					t.Fatalf("balance %s: %d, %v, want %d", addr, bal, err, want)
+				}
+			}
+		})
+	}
+}
+
+// randomAddress, случайный адрес кошелька
+func randomAddress() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+)
+
+// действия журнала аудита песочницы, пополнение кошелька из крана и сброс данных
+const (
+	AuditSandboxFaucet = "sandbox.faucet"
+	AuditSandboxReset  = "sandbox.reset"
+)
+
+// sandboxTables, что очищает сброс песочницы, зависимые таблицы раньше тех, на кого они ссылаются, все они разделены по арендаторам,
+// общие для арендаторов пользователи, ключи доступа, стоп-лист и ключи идемпотентности остаются, как и журнал аудита
+var sandboxTables = []string{
+	"sweep_wallets", "sweeps", "standing_order_runs", "standing_orders", "settlement_lines", "settlement_runs",
+	"pending_transfers", "payment_requests", "payees", "balance_events", "balance_snapshots", "wallets_rebuild",
+	"jobs", "alerts", "transactions", "transactions_archive", "supply_adjustments",
+	"ledger_entries", "ledger_accounts", "hot_credits", "system_wallets", "wallets",
+}
+
+// Faucet, зачисляет amountCents на кошелек из ниоткуда, только для песочницы, это эмиссия mint прямо на кошелек, инвариант денежной массы сходится
+func (r *PostgresRepo) Faucet(ctx context.Context, address string, amountCents int64) (Transaction, error) {
+	t, err := r.supplyOp(ctx, TxTypeMint, address, amountCents, "sandbox faucet", AuditSandboxFaucet)
+	return t, wrapf(err, "faucet %s", address)
+}
+
+// ResetSandbox, удаляет кошельки, операции и все связанные с ними данные одной транзакцией и заново создает служебные кошельки, возвращает число удаленных строк по таблицам,
+// с арендатором удаляются только его строки
+func (r *PostgresRepo) ResetSandbox(ctx context.Context) (map[string]int64, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// переводы, начатые до сброса, успевают закоммититься, новые ждут его конца
+	if _, err := tx.ExecContext(ctx, `LOCK TABLE wallets IN EXCLUSIVE MODE`); err != nil {
+		return nil, err
+	}
+	deleted := make(map[string]int64, len(sandboxTables))
+	for _, t := range sandboxTables {
+		// TRUNCATE обошел бы политики арендаторов
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+t)
+		if err != nil {
+			return nil, err
+		}
+		deleted[t], _ = res.RowsAffected()
+	}
+
+	// с выводом адресов служебные кошельки получают прежние адреса
+	for _, sw := range SystemWalletRoles {
+		if _, err := NewSystemWallet(ctx, tx, r.Addresses, sw.Role, sw.Description); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := insertAudit(ctx, tx, AuditEntry{Action: AuditSandboxReset, Details: map[string]any{"deleted": deleted}}); err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+	"strings"
+)
+
+// совпадения поиска кошельков, по началу адреса и по псевдониму в чьей-то адресной книге
+const (
+	MatchAddress = "address"
+	MatchAlias   = "alias"
+)
+
+// MinSearchLen, короче триграммный индекс не помогает и кандидатов слишком много
+const MinSearchLen = 3
+
+// WalletMatch, найденный кошелек и чем он совпал, для псевдонима сам псевдоним и кошелек, в адресной книге которого он записан
+type WalletMatch struct {
+	Wallet
+	MatchedBy  string
+	Alias      string
+	AliasOwner string
+}
+
+// likeEscape, экранирует спецсимволы like, чтобы % и _ из запроса искались буквально
+var likeEscape = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchWallets, кандидаты по началу адреса без учета регистра и по вхождению в псевдоним, совпадения по адресу первыми, limit по умолчанию 20, максимум 100
+func (r *PostgresRepo) SearchWallets(ctx context.Context, q string, limit int) ([]WalletMatch, error) {
+	q = strings.ToLower(strings.TrimSpace(q))
+	if len(q) < MinSearchLen {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	pattern := likeEscape.Replace(q)
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+walletColumns+`, m.matched_by, m.alias, m.alias_owner
+		FROM (
+			SELECT address, 'address' AS matched_by, '' AS alias, '' AS alias_owner, 0 AS rank
+			FROM wallets WHERE address LIKE $1
+			UNION ALL
+			SELECT payee_address, 'alias', alias, wallet_address, 1
+			FROM payees WHERE lower(alias) LIKE $2 AND deleted_at IS NULL
+		) m
+		JOIN wallets USING (address)
+		ORDER BY m.rank, length(m.alias), address
+		LIMIT $3
+	`, pattern+"%", "%"+pattern+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []WalletMatch
+	for rows.Next() {
+		var m WalletMatch
+		w, err := scanWallet(scanPrefix{rows, []any{&m.MatchedBy, &m.Alias, &m.AliasOwner}})
+		if err != nil {
+			return nil, err
+		}
+		m.Wallet = w
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// scanPrefix, дает scanWallet читать начало строки, хвостовые колонки уходят в rest
+type scanPrefix struct {
+	row  interface{ Scan(...any) error }
+	rest []any
+}
+
+func (s scanPrefix) Scan(dest ...any) error {
+	return s.row.Scan(append(dest, s.rest...)...)
+}
@@ -0,0 +1,166 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ошибки расчета дня
+var (
+	ErrSettlementNotFound = errors.New("settlement not found")
+	ErrAlreadySettled     = errors.New("business date already settled")
+)
+
+// DateLayout, формат бизнес-даты
+const DateLayout = "2006-01-02"
+
+// SettlementRun, сводка расчета за бизнес-день, период [PeriodStart, PeriodEnd) это сутки даты в часовом поясе Timezone
+type SettlementRun struct {
+	BusinessDate string
+	Timezone     string
+	PeriodStart  time.Time
+	PeriodEnd    time.Time
+	Wallets      int
+	TxCount      int64
+	VolumeCents  int64
+	FeeCents     int64
+	CreatedAt    time.Time
+}
+
+// SettlementLine, итоги кошелька за день, получено, отправлено, чистая позиция, уплаченные комиссии и число операций
+type SettlementLine struct {
+	Address  string
+	InCents  int64
+	OutCents int64
+	NetCents int64
+	FeeCents int64
+	TxCount  int64
+}
+
+// DayBounds, начало и конец бизнес-дня date в часовом поясе loc, сутки с переходом на летнее время короче или длиннее 24 часов
+func DayBounds(date string, loc *time.Location) (time.Time, time.Time, error) {
+	d, err := time.ParseInLocation(DateLayout, date, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return d, d.AddDate(0, 0, 1), nil
+}
+
+// Settle, считает итоги бизнес-дня date по операциям его периода и пишет сводку и строки кошельков в одной транзакции, повторный расчет того же дня дает ErrAlreadySettled
+func (r *PostgresRepo) Settle(ctx context.Context, date string, loc *time.Location) (SettlementRun, error) {
+	start, end, err := DayBounds(date, loc)
+	if err != nil {
+		return SettlementRun{}, err
+	}
+
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return SettlementRun{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// строка дня занимается первой, одновременный второй расчет упрется в нее
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO settlement_runs(business_date, timezone, period_start, period_end, wallets, tx_count, volume_cents, fee_cents)
+		VALUES ($1, $2, $3, $4, 0, 0, 0, 0)
+		ON CONFLICT (tenant_id, business_date) DO NOTHING
+	`, date, loc.String(), start, end)
+	if err != nil {
+		return SettlementRun{}, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return SettlementRun{}, ErrAlreadySettled
+	}
+
+	// пустая сторона эмиссии и изъятия не кошелек и в строки не попадает
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO settlement_lines(business_date, address, in_cents, out_cents, net_cents, fee_cents, tx_count)
+		SELECT $1, addr, SUM(in_c), SUM(out_c), SUM(in_c) - SUM(out_c), SUM(fee_c), COUNT(*)
+		FROM (
+			SELECT to_address AS addr, amount_cents AS in_c, 0 AS out_c, 0 AS fee_c
+			FROM transactions WHERE created_at >= $2 AND created_at < $3 AND to_address <> ''
+			UNION ALL
+			SELECT from_address, 0, amount_cents, CASE WHEN type = 'fee' THEN amount_cents ELSE 0 END
+			FROM transactions WHERE created_at >= $2 AND created_at < $3 AND from_address <> ''
+		) x
+		GROUP BY addr
+	`, date, start, end); err != nil {
+		return SettlementRun{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE settlement_runs SET
+			wallets = (SELECT COUNT(*) FROM settlement_lines WHERE business_date = $1),
+			tx_count = t.n, volume_cents = t.volume, fee_cents = t.fees
+		FROM (
+			SELECT COUNT(*) AS n, COALESCE(SUM(amount_cents), 0) AS volume,
+			       COALESCE(SUM(amount_cents) FILTER (WHERE type = 'fee'), 0) AS fees
+			FROM transactions WHERE created_at >= $2 AND created_at < $3
+		) t
+		WHERE business_date = $1
+	`, date, start, end); err != nil {
+		return SettlementRun{}, err
+	}
+
+	run, err := scanSettlementRun(tx.QueryRowContext(ctx, `SELECT `+settlementRunColumns+` FROM settlement_runs WHERE business_date = $1`, date))
+	if err != nil {
+		return SettlementRun{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return SettlementRun{}, err
+	}
+	return run, nil
+}
+
+// settlementRunColumns, колонки сводки для scanSettlementRun
+const settlementRunColumns = `to_char(business_date, 'YYYY-MM-DD'), timezone, period_start, period_end, wallets, tx_count, volume_cents, fee_cents, created_at`
+
+// scanSettlementRun, читает сводку дня из строки
+func scanSettlementRun(row interface{ Scan(...any) error }) (SettlementRun, error) {
+	var s SettlementRun
+	err := row.Scan(&s.BusinessDate, &s.Timezone, &s.PeriodStart, &s.PeriodEnd, &s.Wallets, &s.TxCount, &s.VolumeCents, &s.FeeCents, &s.CreatedAt)
+	return s, err
+}
+
+// LatestSettlement, последняя рассчитанная бизнес-дата, ok false если расчетов еще не было
+func (r *PostgresRepo) LatestSettlement(ctx context.Context) (string, bool, error) {
+	var date sql.NullString
+	if err := r.DB.QueryRowContext(ctx, `SELECT to_char(MAX(business_date), 'YYYY-MM-DD') FROM settlement_runs`).Scan(&date); err != nil {
+		return "", false, err
+	}
+	return date.String, date.Valid, nil
+}
+
+// GetSettlement, сводка и строки кошельков бизнес-дня, строки по убыванию оборота
+func (r *PostgresRepo) GetSettlement(ctx context.Context, date string) (SettlementRun, []SettlementLine, error) {
+	run, err := scanSettlementRun(r.reader(ctx).QueryRowContext(ctx, `SELECT `+settlementRunColumns+` FROM settlement_runs WHERE business_date = $1`, date))
+	if errors.Is(err, sql.ErrNoRows) {
+		return SettlementRun{}, nil, ErrSettlementNotFound
+	}
+	if err != nil {
+		return SettlementRun{}, nil, err
+	}
+
+	rows, err := r.reader(ctx).QueryContext(ctx, `
+		SELECT address, in_cents, out_cents, net_cents, fee_cents, tx_count
+		FROM settlement_lines
+		WHERE business_date = $1
+		ORDER BY in_cents + out_cents DESC, address
+	`, date)
+	if err != nil {
+		return SettlementRun{}, nil, err
+	}
+	defer rows.Close()
+
+	var lines []SettlementLine
+	for rows.Next() {
+		var l SettlementLine
+		if err := rows.Scan(&l.Address, &l.InCents, &l.OutCents, &l.NetCents, &l.FeeCents, &l.TxCount); err != nil {
+			return SettlementRun{}, nil, err
+		}
+		lines = append(lines, l)
+	}
+	return run, lines, rows.Err()
+}
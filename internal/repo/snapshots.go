@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// BalanceMismatch, кошелек, баланс которого не сходится со снимком и переводами после него
+type BalanceMismatch struct {
+	Address       string
+	SnapshotAt    time.Time
+	ExpectedCents int64
+	BalanceCents  int64
+}
+
+// netSince, чистое изменение баланса кошелька по операциям в полуинтервале (since, until], пустой адрес второй стороны эмиссии ни с кем не совпадает
+const netSince = `
+	SELECT COALESCE(SUM(CASE WHEN t.to_address = $1 THEN t.amount_cents ELSE -t.amount_cents END), 0)
+	FROM transactions t
+	WHERE (t.from_address = $1 OR t.to_address = $1) AND t.created_at > $2 AND t.created_at <= $3
+`
+
+// LatestSnapshot, время последнего снимка, ok false если снимков еще нет
+func (r *PostgresRepo) LatestSnapshot(ctx context.Context) (time.Time, bool, error) {
+	var at sql.NullTime
+	if err := r.DB.QueryRowContext(ctx, `SELECT MAX(as_of) FROM balance_snapshots`).Scan(&at); err != nil {
+		return time.Time{}, false, err
+	}
+	return at.Time, at.Valid, nil
+}
+
+// SnapshotBalances, пишет балансы всех кошельков на момент asOf, текущий баланс минус операции после asOf, в одном снимке базы, поэтому снимок точен на границу суток независимо от того, когда задача запустилась, повторный вызов на тот же момент ничего не меняет
+func (r *PostgresRepo) SnapshotBalances(ctx context.Context, asOf time.Time) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO balance_snapshots(as_of, address, balance_cents)
+		SELECT $1, w.address, w.balance_cents + COALESCE(h.cents, 0) - COALESCE(d.delta, 0)
+		FROM wallets w
+		LEFT JOIN (SELECT address, SUM(amount_cents) AS cents FROM hot_credits GROUP BY address) h ON h.address = w.address
+		LEFT JOIN (
+			SELECT addr, SUM(delta) AS delta FROM (
+				SELECT to_address AS addr, amount_cents AS delta FROM transactions WHERE created_at > $1
+				UNION ALL
+				SELECT from_address, -amount_cents FROM transactions WHERE created_at > $1
+			) x GROUP BY addr
+		) d ON d.addr = w.address
+		WHERE w.created_at <= $1
+		ON CONFLICT (as_of, address) DO NOTHING
+	`, asOf)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// BalanceAt, баланс кошелька на момент at, от ближайшего более раннего снимка вперед по операциям, без снимка от текущего баланса назад, до создания кошелька ноль
+func (r *PostgresRepo) BalanceAt(ctx context.Context, address string, at time.Time) (int64, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current int64
+	var created time.Time
+	err = tx.QueryRowContext(ctx, `SELECT balance_cents + `+hotPendingCents+`, created_at FROM wallets WHERE address = $1`, address).Scan(&current, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &WalletNotFoundError{Address: address}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if at.Before(created) {
+		return 0, nil
+	}
+
+	var snapAt time.Time
+	var snap int64
+	err = tx.QueryRowContext(ctx, `
+		SELECT as_of, balance_cents FROM balance_snapshots
+		WHERE address = $1 AND as_of <= $2
+		ORDER BY as_of DESC LIMIT 1
+	`, address, at).Scan(&snapAt, &snap)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+
+	var delta int64
+	if err == nil {
+		if err := tx.QueryRowContext(ctx, netSince, address, snapAt, at).Scan(&delta); err != nil {
+			return 0, err
+		}
+		return snap + delta, nil
+	}
+	if err := tx.QueryRowContext(ctx, netSince, address, at, time.Now()).Scan(&delta); err != nil {
+		return 0, err
+	}
+	return current - delta, nil
+}
+
+// ReconcileBalances, сверяет текущие балансы с последним снимком плюс операции после него, проигрываются только операции после снимка, отдает расхождения
+func (r *PostgresRepo) ReconcileBalances(ctx context.Context) ([]BalanceMismatch, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx, `
+		WITH s AS (SELECT MAX(as_of) AS as_of FROM balance_snapshots),
+		d AS (
+			SELECT addr, SUM(delta) AS delta FROM (
+				SELECT to_address AS addr, amount_cents AS delta FROM transactions, s WHERE created_at > s.as_of
+				UNION ALL
+				SELECT from_address, -amount_cents FROM transactions, s WHERE created_at > s.as_of
+			) x GROUP BY addr
+		)
+		SELECT w.address, s.as_of, b.balance_cents + COALESCE(d.delta, 0), w.balance_cents + COALESCE(h.cents, 0)
+		FROM s
+		JOIN balance_snapshots b ON b.as_of = s.as_of
+		JOIN wallets w ON w.address = b.address
+		LEFT JOIN d ON d.addr = w.address
+		LEFT JOIN (SELECT address, SUM(amount_cents) AS cents FROM hot_credits GROUP BY address) h ON h.address = w.address
+		WHERE w.balance_cents + COALESCE(h.cents, 0) <> b.balance_cents + COALESCE(d.delta, 0)
+		ORDER BY w.address
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BalanceMismatch
+	for rows.Next() {
+		var m BalanceMismatch
+		if err := rows.Scan(&m.Address, &m.SnapshotAt, &m.ExpectedCents, &m.BalanceCents); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,494 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gotechtask/internal/schedule"
+)
+
+// состояния постоянного поручения, отмененное больше не возобновляется
+const (
+	StandingOrderActive    = "active"
+	StandingOrderPaused    = "paused"
+	StandingOrderCancelled = "cancelled"
+)
+
+// политики отказа поручения, skip, отказавший срок пропускается до следующего, retry, срок повторяется до MaxRetries раз, но не позже следующего срока
+const (
+	StandingOrderSkip  = "skip"
+	StandingOrderRetry = "retry"
+)
+
+// исходы срока поручения
+const (
+	StandingRunDone     = "done"
+	StandingRunRetrying = "retrying"
+	StandingRunFailed   = "failed"
+)
+
+// standingRetryStep, шаг паузы перед повтором отказавшего срока, пауза растет с номером попытки
+const standingRetryStep = 30 * time.Minute
+
+// standingSavepoint, точка сохранения перевода поручения, отказ откатывает перевод, но не запись исхода
+const standingSavepoint = "standing_order"
+
+// AuditStandingOrderRestore, действие журнала аудита, администратор вернул удаленное поручение
+const AuditStandingOrderRestore = "standing_order.restore"
+
+// ошибки поручений, поручения нет, переход недопустим из текущего состояния
+var (
+	ErrStandingOrderNotFound = errors.New("standing order not found")
+	ErrStandingOrderState    = errors.New("standing order state does not allow this")
+)
+
+// StandingOrder, регулярный перевод AmountCents с From на To по расписанию Schedule в время At часового пояса Timezone,
+// SlotAt, исполняемый срок, NextRunAt, когда пробовать, после отказа с политикой retry позже SlotAt, Attempt, сколько попыток срока уже отказало,
+// Version, растет при каждом изменении полей и состояния, исполнение сроков ее не меняет, DeletedAt, когда поручение удалено, удаленное не исполняется
+type StandingOrder struct {
+	ID            int64
+	From          string
+	To            string
+	AmountCents   int64
+	Schedule      string
+	At            string
+	Timezone      string
+	FailurePolicy string
+	MaxRetries    int
+	Status        string
+	SlotAt        time.Time
+	NextRunAt     time.Time
+	Attempt       int
+	LastRunAt     time.Time
+	LastError     string
+	CreatedBy     string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Version       int64
+	DeletedAt     time.Time
+}
+
+// StandingOrderPatch, изменение поручения, nil поле не меняется, расписание, время и пояс проверяются вместе после наложения на текущие
+type StandingOrderPatch struct {
+	AmountCents   *int64
+	Schedule      *string
+	At            *string
+	Timezone      *string
+	FailurePolicy *string
+	MaxRetries    *int
+}
+
+// StandingOrderRun, исход одного срока поручения, Attempts, сколько раз срок пробовали
+type StandingOrderRun struct {
+	SlotAt    time.Time
+	Status    string
+	Attempts  int
+	Error     string
+	UpdatedAt time.Time
+}
+
+// ValidFailurePolicy, политика из списка известных
+func ValidFailurePolicy(p string) bool {
+	return p == StandingOrderSkip || p == StandingOrderRetry
+}
+
+// NextStandingRun, первый срок поручения строго после after по его расписанию и часовому поясу
+func NextStandingRun(o StandingOrder, after time.Time) (time.Time, error) {
+	s, err := schedule.Parse(o.Schedule, o.At)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.Next(after, loc), nil
+}
+
+// standingOrderColumns, колонки для scanStandingOrder
+const standingOrderColumns = `id, from_address, to_address, amount_cents, schedule, run_at, timezone, failure_policy, max_retries, status,
+	slot_at, next_run_at, attempt, last_run_at, COALESCE(last_error, ''), created_by, created_at, updated_at, version, deleted_at`
+
+// scanStandingOrder, читает поручение из строки
+func scanStandingOrder(row interface{ Scan(...any) error }) (StandingOrder, error) {
+	var o StandingOrder
+	var lastRun, deleted sql.NullTime
+	err := row.Scan(&o.ID, &o.From, &o.To, &o.AmountCents, &o.Schedule, &o.At, &o.Timezone, &o.FailurePolicy, &o.MaxRetries, &o.Status,
+		&o.SlotAt, &o.NextRunAt, &o.Attempt, &lastRun, &o.LastError, &o.CreatedBy, &o.CreatedAt, &o.UpdatedAt, &o.Version, &deleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return StandingOrder{}, ErrStandingOrderNotFound
+	}
+	o.LastRunAt, o.DeletedAt = lastRun.Time, deleted.Time
+	return o, err
+}
+
+// CreateStandingOrder, записывает активное поручение, первый срок считается от текущего момента, оба кошелька должны существовать
+func (r *PostgresRepo) CreateStandingOrder(ctx context.Context, o StandingOrder) (StandingOrder, error) {
+	if o.From == o.To {
+		return StandingOrder{}, ErrSameAddress
+	}
+	slot, err := NextStandingRun(o, time.Now())
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	created, err := scanStandingOrder(r.DB.QueryRowContext(ctx, `
+		INSERT INTO standing_orders(from_address, to_address, amount_cents, schedule, run_at, timezone, failure_policy, max_retries, slot_at, next_run_at, created_by)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $9, $10
+		WHERE (SELECT COUNT(*) FROM wallets WHERE address IN ($1, $2)) = 2
+		RETURNING `+standingOrderColumns,
+		o.From, o.To, o.AmountCents, o.Schedule, o.At, o.Timezone, o.FailurePolicy, o.MaxRetries, slot, ActorFromContext(ctx)))
+	if errors.Is(err, ErrStandingOrderNotFound) {
+		// строка не вставлена, значит одного из кошельков нет
+		return StandingOrder{}, ErrWalletNotFound
+	}
+	return created, err
+}
+
+// GetStandingOrder, поручение по идентификатору, удаленное тоже, с отметкой DeletedAt
+func (r *PostgresRepo) GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	return scanStandingOrder(r.DB.QueryRowContext(ctx, `SELECT `+standingOrderColumns+` FROM standing_orders WHERE id = $1`, id))
+}
+
+// ListStandingOrders, поручения кошелька-отправителя, новые первыми, отмененные только при withCancelled, удаленные не входят
+func (r *PostgresRepo) ListStandingOrders(ctx context.Context, wallet string, withCancelled bool) ([]StandingOrder, error) {
+	return r.listStandingOrders(ctx, `
+		SELECT `+standingOrderColumns+`
+		FROM standing_orders
+		WHERE from_address = $1 AND ($2 OR status <> 'cancelled') AND deleted_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 500
+	`, wallet, withCancelled)
+}
+
+// ListDeletedStandingOrders, удаленные поручения кошелька-отправителя, последние удаленные первыми
+func (r *PostgresRepo) ListDeletedStandingOrders(ctx context.Context, wallet string) ([]StandingOrder, error) {
+	return r.listStandingOrders(ctx, `
+		SELECT `+standingOrderColumns+`
+		FROM standing_orders
+		WHERE from_address = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id DESC
+		LIMIT 500
+	`, wallet)
+}
+
+// listStandingOrders, читает поручения запросом query
+func (r *PostgresRepo) listStandingOrders(ctx context.Context, query string, args ...any) ([]StandingOrder, error) {
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []StandingOrder
+	for rows.Next() {
+		o, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// StandingOrderRuns, последние limit исходов сроков поручения, новые первыми
+func (r *PostgresRepo) StandingOrderRuns(ctx context.Context, id int64, limit int) ([]StandingOrderRun, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT slot_at, status, attempts, COALESCE(error, ''), updated_at
+		FROM standing_order_runs
+		WHERE order_id = $1
+		ORDER BY slot_at DESC
+		LIMIT $2
+	`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []StandingOrderRun
+	for rows.Next() {
+		var run StandingOrderRun
+		if err := rows.Scan(&run.SlotAt, &run.Status, &run.Attempts, &run.Error, &run.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, run)
+	}
+	return out, rows.Err()
+}
+
+// PauseStandingOrder, приостанавливает активное поручение, сроки на паузе не исполняются и потом не догоняются
+func (r *PostgresRepo) PauseStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	return r.setStandingOrderStatus(ctx, id, StandingOrderPaused, StandingOrderActive)
+}
+
+// CancelStandingOrder, отменяет активное или приостановленное поручение
+func (r *PostgresRepo) CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	return r.setStandingOrderStatus(ctx, id, StandingOrderCancelled, StandingOrderActive, StandingOrderPaused)
+}
+
+// setStandingOrderStatus, переводит поручение в status из одного из состояний from, иначе ErrStandingOrderState, удаленное дает ErrStandingOrderNotFound
+func (r *PostgresRepo) setStandingOrderStatus(ctx context.Context, id int64, status string, from ...string) (StandingOrder, error) {
+	o, err := scanStandingOrder(r.DB.QueryRowContext(ctx, `
+		UPDATE standing_orders SET status = $2, updated_at = now(), version = version + 1
+		WHERE id = $1 AND status = ANY($3::text[]) AND deleted_at IS NULL
+		RETURNING `+standingOrderColumns,
+		id, status, from))
+	if !errors.Is(err, ErrStandingOrderNotFound) {
+		return o, err
+	}
+	// строка не обновлена, поручения нет, оно удалено или в другом состоянии
+	o, err = r.GetStandingOrder(ctx, id)
+	if err != nil {
+		return o, err
+	}
+	if !o.DeletedAt.IsZero() {
+		return StandingOrder{}, ErrStandingOrderNotFound
+	}
+	return o, ErrStandingOrderState
+}
+
+// ResumeStandingOrder, возобновляет приостановленное поручение, ближайший срок считается от текущего момента, пропущенные на паузе сроки не исполняются
+func (r *PostgresRepo) ResumeStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	o, err := scanStandingOrder(tx.QueryRowContext(ctx, `SELECT `+standingOrderColumns+` FROM standing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return o, err
+	}
+	if !o.DeletedAt.IsZero() {
+		return StandingOrder{}, ErrStandingOrderNotFound
+	}
+	if o.Status != StandingOrderPaused {
+		return o, ErrStandingOrderState
+	}
+	slot, err := NextStandingRun(o, time.Now())
+	if err != nil {
+		return o, err
+	}
+	o, err = scanStandingOrder(tx.QueryRowContext(ctx, `
+		UPDATE standing_orders SET status = 'active', slot_at = $2, next_run_at = $2, attempt = 0, updated_at = now(), version = version + 1
+		WHERE id = $1
+		RETURNING `+standingOrderColumns,
+		id, slot))
+	if err != nil {
+		return o, err
+	}
+	return o, tx.Commit()
+}
+
+// DeleteStandingOrder, удаляет поручение в любом состоянии, строка и история сроков остаются, удаленное не исполняется и не видно в списках,
+// повторное удаление дает ErrStandingOrderNotFound
+func (r *PostgresRepo) DeleteStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	return scanStandingOrder(r.DB.QueryRowContext(ctx, `
+		UPDATE standing_orders SET deleted_at = now(), updated_at = now(), version = version + 1
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+standingOrderColumns,
+		id))
+}
+
+// RestoreStandingOrder, возвращает удаленное поручение в прежнем состоянии, не удаленное дает ErrStandingOrderState,
+// у активного ближайший срок считается от текущего момента, как при возобновлении, сроки за время удаления не догоняются
+func (r *PostgresRepo) RestoreStandingOrder(ctx context.Context, id int64, actor string) (StandingOrder, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	o, err := scanStandingOrder(tx.QueryRowContext(ctx, `SELECT `+standingOrderColumns+` FROM standing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return o, err
+	}
+	if o.DeletedAt.IsZero() {
+		return o, ErrStandingOrderState
+	}
+	if o.Status == StandingOrderActive {
+		slot, err := NextStandingRun(o, time.Now())
+		if err != nil {
+			return o, err
+		}
+		o.SlotAt, o.NextRunAt, o.Attempt = slot, slot, 0
+	}
+	o, err = scanStandingOrder(tx.QueryRowContext(ctx, `
+		UPDATE standing_orders SET deleted_at = NULL, slot_at = $2, next_run_at = $3, attempt = $4, updated_at = now(), version = version + 1
+		WHERE id = $1
+		RETURNING `+standingOrderColumns,
+		id, o.SlotAt, o.NextRunAt, o.Attempt))
+	if err != nil {
+		return o, err
+	}
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  AuditStandingOrderRestore,
+		Actor:   actor,
+		Address: o.From,
+		Details: map[string]any{"standing_order_id": o.ID},
+	}); err != nil {
+		return o, err
+	}
+	return o, tx.Commit()
+}
+
+// UpdateStandingOrder, меняет поля поручения, если его версия равна version, ноль пропускает проверку, другая версия дает ErrVersionMismatch вместе с текущим поручением,
+// отмененное поручение не меняется, у активного новое расписание считает ближайший срок от текущего момента и сбрасывает повторы, приостановленному срок посчитает возобновление
+func (r *PostgresRepo) UpdateStandingOrder(ctx context.Context, id, version int64, p StandingOrderPatch) (StandingOrder, error) {
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	o, err := scanStandingOrder(tx.QueryRowContext(ctx, `SELECT `+standingOrderColumns+` FROM standing_orders WHERE id = $1 FOR UPDATE`, id))
+	if err != nil {
+		return o, err
+	}
+	if !o.DeletedAt.IsZero() {
+		return StandingOrder{}, ErrStandingOrderNotFound
+	}
+	if version != 0 && o.Version != version {
+		return o, ErrVersionMismatch
+	}
+	if o.Status == StandingOrderCancelled {
+		return o, ErrStandingOrderState
+	}
+
+	next := o
+	if p.AmountCents != nil {
+		next.AmountCents = *p.AmountCents
+	}
+	if p.Schedule != nil {
+		next.Schedule = *p.Schedule
+	}
+	if p.At != nil {
+		next.At = *p.At
+	}
+	if p.Timezone != nil {
+		next.Timezone = *p.Timezone
+	}
+	if p.FailurePolicy != nil {
+		next.FailurePolicy = *p.FailurePolicy
+	}
+	if p.MaxRetries != nil {
+		next.MaxRetries = *p.MaxRetries
+	}
+	if !ValidFailurePolicy(next.FailurePolicy) {
+		return o, errors.New("invalid failure policy")
+	}
+	if next.Schedule != o.Schedule || next.At != o.At || next.Timezone != o.Timezone {
+		slot, err := NextStandingRun(next, time.Now())
+		if err != nil {
+			return o, err
+		}
+		if o.Status == StandingOrderActive {
+			next.SlotAt, next.NextRunAt, next.Attempt = slot, slot, 0
+		}
+	}
+
+	o, err = scanStandingOrder(tx.QueryRowContext(ctx, `
+		UPDATE standing_orders
+		SET amount_cents = $2, schedule = $3, run_at = $4, timezone = $5, failure_policy = $6, max_retries = $7,
+			slot_at = $8, next_run_at = $9, attempt = $10, updated_at = now(), version = version + 1
+		WHERE id = $1
+		RETURNING `+standingOrderColumns,
+		id, next.AmountCents, next.Schedule, next.At, next.Timezone, next.FailurePolicy, next.MaxRetries, next.SlotAt, next.NextRunAt, next.Attempt))
+	if err != nil {
+		return o, err
+	}
+	return o, tx.Commit()
+}
+
+// RunDueStandingOrder, исполняет одно поручение, срок которого наступил к now, ответ false, когда наступивших нет,
+// перевод идет под точкой сохранения, доменный отказ откатывает только его, исход срока и следующий срок фиксируются в той же транзакции,
+// пропущенные за время простоя сроки схлопываются в один, поручение с неразбираемым расписанием приостанавливается
+func (r *PostgresRepo) RunDueStandingOrder(ctx context.Context, now time.Time) (bool, error) {
+	var ran bool
+	var denied StandingOrder
+	err := retryDeadlocks(ctx, func() error {
+		ran, denied = false, StandingOrder{}
+		tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		// занятое другим экземпляром поручение пропускаем
+		o, err := scanStandingOrder(tx.QueryRowContext(ctx, `
+			SELECT `+standingOrderColumns+` FROM standing_orders
+			WHERE status = 'active' AND next_run_at <= $1 AND deleted_at IS NULL
+			ORDER BY next_run_at, id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		`, now))
+		if errors.Is(err, ErrStandingOrderNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ran = true
+
+		next, err := NextStandingRun(o, now)
+		if err != nil {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE standing_orders SET status = 'paused', last_error = 'invalid schedule', updated_at = now(), version = version + 1 WHERE id = $1
+			`, o.ID); err != nil {
+				return err
+			}
+			return tx.Commit()
+		}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT "+standingSavepoint); err != nil {
+			return err
+		}
+		terr := transferTx(ctx, tx, o.From, o.To, o.AmountCents)
+		if terr != nil && !isItemError(terr) {
+			return terr
+		}
+		if terr != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+standingSavepoint); err != nil {
+				return err
+			}
+			if errors.Is(terr, ErrAddressDenied) {
+				denied = o
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+standingSavepoint); err != nil {
+			return err
+		}
+
+		attempts := o.Attempt + 1
+		status, nextRun, slot, attempt, msg := StandingRunDone, next, next, 0, ""
+		if terr != nil {
+			status, msg = StandingRunFailed, terr.Error()
+			// повтор не переходит на следующий срок, иначе за один срок ушло бы два перевода подряд
+			if retryAt := now.Add(time.Duration(attempts) * standingRetryStep); o.FailurePolicy == StandingOrderRetry && attempts <= o.MaxRetries && retryAt.Before(next) {
+				status, nextRun, slot, attempt = StandingRunRetrying, retryAt, o.SlotAt, attempts
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO standing_order_runs(order_id, slot_at, status, attempts, error)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+			ON CONFLICT (order_id, slot_at) DO UPDATE
+			SET status = EXCLUDED.status, attempts = EXCLUDED.attempts, error = EXCLUDED.error, updated_at = now()
+		`, o.ID, o.SlotAt, status, attempts, msg); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE standing_orders
+			SET slot_at = $2, next_run_at = $3, attempt = $4, last_run_at = $5, last_error = NULLIF($6, ''), updated_at = now()
+			WHERE id = $1
+		`, o.ID, slot, nextRun, attempt, now, msg); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err == nil && denied.ID != 0 {
+		r.auditDeniedTransfer(ctx, denied.From, denied.To, denied.AmountCents)
+	}
+	return ran, err
+}
@@ -0,0 +1,42 @@
+package repo
+
+import (
+	"context"
+	"time"
+)
+
+// SystemStats, сводка состояния сервиса для панели администратора, счетчики операций и сигналов с момента Since
+type SystemStats struct {
+	Since         time.Time
+	Wallets       int64
+	UserWallets   int64
+	BalanceCents  int64
+	TxCount       int64
+	TxVolumeCents int64
+	Alerts        int64
+	JobsQueued    int64
+	JobsFailed    int64
+	// HotCreditsQueued, неприменные зачисления горячих кошельков
+	HotCreditsQueued int64
+}
+
+// Stats, сводка одним запросом, балансы и очередь задач на текущий момент, переводы и сигналы с since
+func (r *PostgresRepo) Stats(ctx context.Context, since time.Time) (SystemStats, error) {
+	s := SystemStats{Since: since}
+	err := r.reader(ctx).QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM wallets),
+			(SELECT COUNT(*) FROM wallets WHERE user_id IS NOT NULL),
+			(SELECT COALESCE(SUM(balance_cents), 0) FROM wallets) + (SELECT COALESCE(SUM(amount_cents), 0) FROM hot_credits),
+			t.n, t.volume,
+			(SELECT COUNT(*) FROM alerts WHERE created_at >= $1),
+			(SELECT COUNT(*) FROM jobs WHERE status IN ('pending', 'running')),
+			(SELECT COUNT(*) FROM jobs WHERE status = 'failed'),
+			(SELECT COUNT(*) FROM hot_credits)
+		FROM (
+			SELECT COUNT(*) AS n, COALESCE(SUM(amount_cents), 0) AS volume
+			FROM transactions WHERE created_at >= $1
+		) t
+	`, since).Scan(&s.Wallets, &s.UserWallets, &s.BalanceCents, &s.TxCount, &s.TxVolumeCents, &s.Alerts, &s.JobsQueued, &s.JobsFailed, &s.HotCreditsQueued)
+	return s, err
+}
@@ -0,0 +1,19 @@
+package repo
+
+import "context"
+
+// SupplyCheck, результат проверки инварианта денежной массы, сумма балансов, ожидаемая сумма по эмиссиям и корректировкам
+type SupplyCheck struct {
+	BalancesCents int64
+	ExpectedCents int64
+	OK            bool
+}
+
+// CheckMoneySupply, вызывает хранимую проверку инварианта check_money_supply
+func (r *PostgresRepo) CheckMoneySupply(ctx context.Context) (SupplyCheck, error) {
+	var c SupplyCheck
+	err := r.reader(ctx).QueryRowContext(ctx,
+		`SELECT balances_cents::bigint, expected_cents::bigint, ok FROM check_money_supply()`,
+	).Scan(&c.BalancesCents, &c.ExpectedCents, &c.OK)
+	return c, err
+}
@@ -0,0 +1,368 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SweepMode, как исполняется сбор остатков
+type SweepMode string
+
+// режимы сбора, atomic, все кошельки одной транзакцией, отказ любого откатывает сбор, chunked, частями в фоновой задаче, отказавший кошелек отмечается и сбор идет дальше
+const (
+	SweepAtomic  SweepMode = "atomic"
+	SweepChunked SweepMode = "chunked"
+)
+
+// статусы сбора
+const (
+	SweepPending = "pending"
+	SweepRunning = "running"
+	SweepDone    = "done"
+)
+
+// исходы кошелька в сборе, ждет, остаток переведен, брать нечего, перевод отклонен
+const (
+	SweepWalletPending = "pending"
+	SweepWalletSwept   = "swept"
+	SweepWalletSkipped = "skipped"
+	SweepWalletFailed  = "failed"
+)
+
+// AuditWalletSweep, действие журнала аудита, запуск сбора остатков
+const AuditWalletSweep = "wallet.sweep"
+
+// ошибки сбора, сбора нет, сбор уже завершен
+var (
+	ErrSweepNotFound = errors.New("sweep not found")
+	ErrSweepDone     = errors.New("sweep already done")
+)
+
+// Sweep, сбор остатков списка кошельков на кошелек Destination, переводы сбора связаны GroupID, счетчики по исходам кошельков
+type Sweep struct {
+	ID          int64
+	Destination string
+	Mode        SweepMode
+	Status      string
+	GroupID     string
+	CreatedBy   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	FinishedAt  time.Time
+
+	Wallets    int
+	Pending    int
+	Swept      int
+	Skipped    int
+	Failed     int
+	SweptCents int64
+}
+
+// SweepWallet, исход одного кошелька сбора, Error, текст отказа у failed
+type SweepWallet struct {
+	Address     string
+	Status      string
+	AmountCents int64
+	Error       string
+	ProcessedAt time.Time
+}
+
+// ValidSweepMode, режим из списка известных
+func ValidSweepMode(m SweepMode) bool {
+	return m == SweepAtomic || m == SweepChunked
+}
+
+// CreateSweep, записывает сбор остатков sources на destination, atomic выполняется сразу в той же транзакции, первый отказ откатывает все и возвращается как *BatchItemError с номером кошелька,
+// chunked только записывается со статусом pending, кошельки разбирает SweepChunk
+func (r *PostgresRepo) CreateSweep(ctx context.Context, destination string, sources []string, mode SweepMode) (Sweep, error) {
+	groupID := uuid.NewString()
+	var id int64
+	err := retryDeadlocks(ctx, func() error {
+		tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM wallets WHERE address = $1)`, destination).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return &WalletNotFoundError{Address: destination}
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO sweeps(destination, mode, group_id, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id
+		`, destination, string(mode), groupID, ActorFromContext(ctx)).Scan(&id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO sweep_wallets(sweep_id, position, address)
+			SELECT $1, ord - 1, addr FROM unnest($2::text[]) WITH ORDINALITY AS s(addr, ord)
+		`, id, sources); err != nil {
+			return err
+		}
+		if err := insertAudit(ctx, tx, AuditEntry{
+			Action:  AuditWalletSweep,
+			Address: destination,
+			Details: map[string]any{"sweep_id": id, "mode": mode, "wallets": len(sources)},
+		}); err != nil {
+			return err
+		}
+
+		if mode == SweepAtomic {
+			if _, err := sweepTx(ctx, tx, id, 0, false); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return Sweep{}, err
+	}
+	return r.getSweep(ctx, id)
+}
+
+// SweepChunk, разбирает до limit ждущих кошельков сбора одной транзакцией, отказ кошелька откатывается до точки сохранения и отмечается, остальные переводятся,
+// ответ true, когда ждущих кошельков не осталось, прерванный сбор продолжается следующим вызовом с места остановки
+func (r *PostgresRepo) SweepChunk(ctx context.Context, id int64, limit int) (bool, error) {
+	var done bool
+	err := retryDeadlocks(ctx, func() error {
+		tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		if done, err = sweepTx(ctx, tx, id, limit, true); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	return done, err
+}
+
+// sweepTx, переводит остатки до limit ждущих кошельков сбора, ноль без предела, строка сбора блокируется, поэтому одновременные части одного сбора идут по очереди,
+// bestEffort, отказ кошелька откатывается до точки сохранения и записывается, иначе возвращается как *BatchItemError, ответ true, когда ждущих не осталось
+func sweepTx(ctx context.Context, tx *sql.Tx, id int64, limit int, bestEffort bool) (bool, error) {
+	var dest, status, groupID string
+	err := tx.QueryRowContext(ctx, `
+		SELECT destination, status, group_id::text FROM sweeps WHERE id = $1 FOR UPDATE
+	`, id).Scan(&dest, &status, &groupID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrSweepNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+	if status == SweepDone {
+		return true, nil
+	}
+	ctx = WithTransferGroup(ctx, groupID)
+
+	type pendingWallet struct {
+		pos  int
+		addr string
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT position, address FROM sweep_wallets
+		WHERE sweep_id = $1 AND status = 'pending'
+		ORDER BY position
+		LIMIT NULLIF($2, 0)
+	`, id, limit)
+	if err != nil {
+		return false, err
+	}
+	var batch []pendingWallet
+	for rows.Next() {
+		var p pendingWallet
+		if err := rows.Scan(&p.pos, &p.addr); err != nil {
+			_ = rows.Close()
+			return false, err
+		}
+		batch = append(batch, p)
+	}
+	if err := rows.Err(); err != nil {
+		_ = rows.Close()
+		return false, err
+	}
+	if err := rows.Close(); err != nil {
+		return false, err
+	}
+
+	for _, p := range batch {
+		if bestEffort {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT "+batchSavepoint); err != nil {
+				return false, err
+			}
+		}
+		st, amount, err := sweepWalletTx(ctx, tx, p.addr, dest)
+		var failure any
+		if err != nil {
+			if !bestEffort || !isItemError(err) {
+				if isItemError(err) {
+					return false, &BatchItemError{Index: p.pos, Err: err}
+				}
+				return false, err
+			}
+			if _, rerr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+batchSavepoint); rerr != nil {
+				return false, rerr
+			}
+			st, amount, failure = SweepWalletFailed, 0, err.Error()
+		}
+		if bestEffort {
+			if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+batchSavepoint); err != nil {
+				return false, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE sweep_wallets SET status = $3, amount_cents = $4, error = $5, processed_at = now()
+			WHERE sweep_id = $1 AND position = $2
+		`, id, p.pos, st, amount, failure); err != nil {
+			return false, err
+		}
+	}
+
+	var left bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM sweep_wallets WHERE sweep_id = $1 AND status = 'pending')
+	`, id).Scan(&left); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE sweeps
+		SET status = CASE WHEN $2 THEN 'running' ELSE 'done' END,
+		    finished_at = CASE WHEN $2 THEN NULL ELSE now() END,
+		    updated_at = now()
+		WHERE id = $1
+	`, id, left); err != nil {
+		return false, err
+	}
+	return !left, nil
+}
+
+// sweepWalletTx, переводит весь положительный остаток кошелька на dest, кошелек с нулевым или отрицательным балансом пропускается, строка блокируется до чтения баланса, чтобы остаток не изменился до перевода
+func sweepWalletTx(ctx context.Context, tx *sql.Tx, addr, dest string) (string, int64, error) {
+	var bal int64
+	err := tx.QueryRowContext(ctx, `SELECT balance_cents FROM wallets WHERE address = $1 FOR UPDATE`, addr).Scan(&bal)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", 0, &WalletNotFoundError{Address: addr}
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	// остаток горячего кошелька включает его очередь зачислений
+	if bal, err = applyHotCredits(ctx, tx, addr, bal); err != nil {
+		return "", 0, err
+	}
+	if bal <= 0 {
+		return SweepWalletSkipped, 0, nil
+	}
+	if err := transferTx(ctx, tx, addr, dest, bal); err != nil {
+		return "", 0, err
+	}
+	return SweepWalletSwept, bal, nil
+}
+
+// sweepColumns, колонки сбора с итогами по кошелькам для scanSweep
+const sweepColumns = `s.id, s.destination, s.mode, s.status, s.group_id::text, s.created_by, s.created_at, s.updated_at, s.finished_at,
+	COUNT(w.position),
+	COUNT(*) FILTER (WHERE w.status = 'pending'),
+	COUNT(*) FILTER (WHERE w.status = 'swept'),
+	COUNT(*) FILTER (WHERE w.status = 'skipped'),
+	COUNT(*) FILTER (WHERE w.status = 'failed'),
+	COALESCE(SUM(w.amount_cents), 0)`
+
+// getSweep, сбор с итогами
+func (r *PostgresRepo) getSweep(ctx context.Context, id int64) (Sweep, error) {
+	var s Sweep
+	var finished sql.NullTime
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT `+sweepColumns+`
+		FROM sweeps s LEFT JOIN sweep_wallets w ON w.sweep_id = s.id
+		WHERE s.id = $1
+		GROUP BY s.id
+	`, id).Scan(&s.ID, &s.Destination, &s.Mode, &s.Status, &s.GroupID, &s.CreatedBy, &s.CreatedAt, &s.UpdatedAt, &finished,
+		&s.Wallets, &s.Pending, &s.Swept, &s.Skipped, &s.Failed, &s.SweptCents)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Sweep{}, ErrSweepNotFound
+	}
+	s.FinishedAt = finished.Time
+	return s, err
+}
+
+// GetSweep, сбор с итогами и исходами кошельков в порядке списка
+func (r *PostgresRepo) GetSweep(ctx context.Context, id int64) (Sweep, []SweepWallet, error) {
+	s, err := r.getSweep(ctx, id)
+	if err != nil {
+		return Sweep{}, nil, err
+	}
+
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT address, status, amount_cents, COALESCE(error, ''), processed_at
+		FROM sweep_wallets WHERE sweep_id = $1 ORDER BY position
+	`, id)
+	if err != nil {
+		return Sweep{}, nil, err
+	}
+	defer rows.Close()
+
+	var out []SweepWallet
+	for rows.Next() {
+		var w SweepWallet
+		var at sql.NullTime
+		if err := rows.Scan(&w.Address, &w.Status, &w.AmountCents, &w.Error, &at); err != nil {
+			return Sweep{}, nil, err
+		}
+		w.ProcessedAt = at.Time
+		out = append(out, w)
+	}
+	return s, out, rows.Err()
+}
+
+// ResumeSweep, возвращает отказавшие кошельки сбора в ожидание, чтобы следующий проход попробовал их снова, завершенный сбор без отказов дает ErrSweepDone
+func (r *PostgresRepo) ResumeSweep(ctx context.Context, id int64) (Sweep, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Sweep{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM sweeps WHERE id = $1 FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Sweep{}, ErrSweepNotFound
+	}
+	if err != nil {
+		return Sweep{}, err
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE sweep_wallets SET status = 'pending', error = NULL, processed_at = NULL
+		WHERE sweep_id = $1 AND status = 'failed'
+	`, id)
+	if err != nil {
+		return Sweep{}, err
+	}
+	reset, _ := res.RowsAffected()
+	if status == SweepDone && reset == 0 {
+		return Sweep{}, ErrSweepDone
+	}
+	if reset > 0 {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE sweeps SET status = 'running', finished_at = NULL, updated_at = now() WHERE id = $1
+		`, id); err != nil {
+			return Sweep{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return Sweep{}, err
+	}
+	return r.getSweep(ctx, id)
+}
@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// роли служебных кошельков
+const (
+	RoleTreasury = "treasury"
+	RoleFees     = "fees"
+	RoleSuspense = "suspense"
+)
+
+// SystemWalletRoles, роли, которые сид создает при запуске, и их описания
+var SystemWalletRoles = []struct{ Role, Description string }{
+	{RoleTreasury, "treasury, source of minted funds and sink of burned funds"},
+	{RoleFees, "collected transfer fees"},
+	{RoleSuspense, "funds awaiting investigation or manual adjustment"},
+}
+
+// ErrSystemWalletNotFound, кошелька с такой ролью нет, сид еще не выполнялся или роль неизвестна
+var ErrSystemWalletNotFound = errors.New("system wallet not found")
+
+// SystemWallet, служебный кошелек, роль, адрес, описание и баланс
+type SystemWallet struct {
+	Role         string
+	Address      string
+	Description  string
+	BalanceCents int64
+	CreatedAt    time.Time
+}
+
+// SystemWalletAddress, адрес служебного кошелька по роли
+func (r *PostgresRepo) SystemWalletAddress(ctx context.Context, role string) (string, error) {
+	var addr string
+	err := r.DB.QueryRowContext(ctx, `SELECT address FROM system_wallets WHERE role = $1`, role).Scan(&addr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrSystemWalletNotFound
+	}
+	return addr, err
+}
+
+// ListSystemWallets, все служебные кошельки с балансами, по роли
+func (r *PostgresRepo) ListSystemWallets(ctx context.Context) ([]SystemWallet, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT s.role, s.address, s.description, w.balance_cents + COALESCE((SELECT SUM(h.amount_cents) FROM hot_credits h WHERE h.address = w.address), 0), s.created_at
+		FROM system_wallets s
+		JOIN wallets w ON w.address = s.address
+		ORDER BY s.role
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SystemWallet
+	for rows.Next() {
+		var s SystemWallet
+		if err := rows.Scan(&s.Role, &s.Address, &s.Description, &s.BalanceCents, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
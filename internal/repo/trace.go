@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gotechtask/internal/notify"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID, кладет в контекст идентификатор запроса, он пишется в сведения о выполнении переводов этого запроса
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext, идентификатор запроса из контекста, пустой вне запроса
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// transferStats, сведения о выполнении перевода, копятся по ходу попыток и пишутся вместе со строкой перевода
+type transferStats struct {
+	attempts int
+	lockWait time.Duration
+}
+
+type transferStatsKey struct{}
+
+// withTransferStats, новые сведения о выполнении в контексте перевода
+func withTransferStats(ctx context.Context) (context.Context, *transferStats) {
+	st := &transferStats{}
+	return context.WithValue(ctx, transferStatsKey{}, st), st
+}
+
+// waited, добавляет к ожиданию блокировок время с start, вне перевода ничего не делает
+func waited(ctx context.Context, start time.Time) {
+	if st, ok := ctx.Value(transferStatsKey{}).(*transferStats); ok {
+		st.lockWait += time.Since(start)
+	}
+}
+
+// traceCTE, подзапрос записи сведений о выполнении для строки t вставки перевода, параметры с номера n в порядке traceArgs,
+// без сведений в контексте, то есть вне retryTransfer, строка не пишется
+func traceCTE(n int) string {
+	return fmt.Sprintf(`tr AS (
+			INSERT INTO transaction_traces(tx_id, request_id, attempts, lock_wait_ms, created_at)
+			SELECT t.id, $%d::text, $%d::int, $%d::bigint, t.created_at FROM t WHERE $%d::bool
+		)`, n, n+1, n+2, n+3)
+}
+
+// traceArgs, параметры traceCTE из контекста
+func traceArgs(ctx context.Context) []any {
+	st, ok := ctx.Value(transferStatsKey{}).(*transferStats)
+	if !ok {
+		return []any{"", 0, 0, false}
+	}
+	return []any{RequestIDFromContext(ctx), st.attempts, st.lockWait.Milliseconds(), true}
+}
+
+// TransferTrace, все, что известно о проведенной операции, для ответа на вопрос, что случилось с переводом
+type TransferTrace struct {
+	Transaction Transaction
+	// Recorded, сведения о выполнении записаны, их нет у операций не через перевод и у переводов до появления сведений
+	Recorded  bool
+	RequestID string
+	// Attempts, попытки транзакции с учетом повторов на дедлоках, LockWait, ожидание полос и строк кошельков за все попытки
+	Attempts int
+	LockWait time.Duration
+	// BalanceEvents, изменения балансов сторон этой операцией
+	BalanceEvents []BalanceEvent
+	// PendingCreditCents, сумма, еще ждущая в очереди зачислений горячего получателя, ноль если зачисление применено
+	PendingCreditCents int64
+	// Group, другие операции той же группы, комиссии, развороты, переводы пакета
+	Group []Transaction
+	// Receipts, задачи квитанций о переводе, сопоставлены по сторонам, сумме и времени постановки
+	Receipts []TraceJob
+	// Audit, записи аудита по адресам сторон в пределах traceWindow до и после операции
+	Audit []TraceAudit
+}
+
+// TraceJob, задача фоновой доставки, связанная с операцией
+type TraceJob struct {
+	ID        int64
+	Kind      string
+	Side      string
+	Status    string
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TraceAudit, запись журнала аудита рядом с операцией
+type TraceAudit struct {
+	ID        int64
+	Action    string
+	Actor     string
+	Address   string
+	Details   map[string]any
+	CreatedAt time.Time
+}
+
+// traceWindow, окно времени вокруг операции, в котором ищутся квитанции и записи аудита
+const traceWindow = time.Minute
+
+// TraceTransaction, собирает жизненный путь операции id из журнала, сведений о выполнении, событий балансов, очереди зачислений, задач квитанций и аудита,
+// квитанции не хранят id перевода и сопоставляются по сторонам, сумме и времени, одинаковые переводы в одну минуту делят квитанции
+func (r *PostgresRepo) TraceTransaction(ctx context.Context, id int64) (TransferTrace, error) {
+	t, err := r.GetTransaction(ctx, id, TxVisibility{All: true})
+	if err != nil {
+		return TransferTrace{}, err
+	}
+	tr := TransferTrace{Transaction: t}
+	db := r.reader(ctx)
+
+	var waitMs int64
+	err = db.QueryRowContext(ctx, `
+		SELECT request_id, attempts, lock_wait_ms FROM transaction_traces WHERE tx_id = $1
+	`, id).Scan(&tr.RequestID, &tr.Attempts, &waitMs)
+	switch {
+	case err == nil:
+		tr.Recorded = true
+		tr.LockWait = time.Duration(waitMs) * time.Millisecond
+	case !errors.Is(err, sql.ErrNoRows):
+		return TransferTrace{}, err
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, address, tx_id, delta_cents, balance_cents, created_at FROM balance_events
+		WHERE address IN ($2, $3) AND tx_id = $1
+		ORDER BY id
+	`, id, t.FromAddress, t.ToAddress)
+	if err != nil {
+		return TransferTrace{}, err
+	}
+	for rows.Next() {
+		var e BalanceEvent
+		if err := rows.Scan(&e.ID, &e.Address, &e.TxID, &e.DeltaCents, &e.BalanceCents, &e.CreatedAt); err != nil {
+			rows.Close()
+			return TransferTrace{}, err
+		}
+		tr.BalanceEvents = append(tr.BalanceEvents, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return TransferTrace{}, err
+	}
+
+	if err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount_cents), 0) FROM hot_credits WHERE address = $2 AND tx_id = $1
+	`, id, t.ToAddress).Scan(&tr.PendingCreditCents); err != nil {
+		return TransferTrace{}, err
+	}
+
+	if t.GroupID != "" {
+		rows, err := db.QueryContext(ctx, `
+			SELECT `+txColumns+` FROM transactions t WHERE t.group_id = $1 AND t.id <> $2 ORDER BY t.id
+		`, t.GroupID, id)
+		if err != nil {
+			return TransferTrace{}, err
+		}
+		for rows.Next() {
+			g, err := scanTransaction(rows)
+			if err != nil {
+				rows.Close()
+				return TransferTrace{}, err
+			}
+			tr.Group = append(tr.Group, g)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return TransferTrace{}, err
+		}
+	}
+
+	if t.Type == TxTypeTransfer {
+		rows, err := db.QueryContext(ctx, `
+			SELECT id, kind, COALESCE(payload->>'side', ''), status, attempts, last_error, created_at, updated_at FROM jobs
+			WHERE kind = $1 AND payload->>'from' = $2 AND payload->>'to' = $3 AND (payload->>'amount_cents')::bigint = $4
+				AND created_at BETWEEN $5 AND $5::timestamptz + $6 * interval '1 second'
+			ORDER BY id
+			LIMIT 10
+		`, notify.KindTransferReceipt, t.FromAddress, t.ToAddress, t.AmountCents, t.CreatedAt, traceWindow.Seconds())
+		if err != nil {
+			return TransferTrace{}, err
+		}
+		for rows.Next() {
+			var j TraceJob
+			if err := rows.Scan(&j.ID, &j.Kind, &j.Side, &j.Status, &j.Attempts, &j.LastError, &j.CreatedAt, &j.UpdatedAt); err != nil {
+				rows.Close()
+				return TransferTrace{}, err
+			}
+			tr.Receipts = append(tr.Receipts, j)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return TransferTrace{}, err
+		}
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT id, action, actor, address, details, created_at FROM audit_log
+		WHERE address IN ($1, $2) AND created_at BETWEEN $3::timestamptz - $4 * interval '1 second' AND $3::timestamptz + $4 * interval '1 second'
+		ORDER BY created_at, id
+	`, t.FromAddress, t.ToAddress, t.CreatedAt, traceWindow.Seconds())
+	if err != nil {
+		return TransferTrace{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a TraceAudit
+		var details []byte
+		if err := rows.Scan(&a.ID, &a.Action, &a.Actor, &a.Address, &details, &a.CreatedAt); err != nil {
+			return TransferTrace{}, err
+		}
+		if err := json.Unmarshal(details, &a.Details); err != nil {
+			return TransferTrace{}, err
+		}
+		tr.Audit = append(tr.Audit, a)
+	}
+	return tr, rows.Err()
+}
@@ -0,0 +1,339 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListOptions, параметры выборки транзакций, видимость, фильтры, сортировка и страница, нулевое значение дает первые 10 переводов между общими кошельками по возрастанию времени
+type ListOptions struct {
+	// Visibility, какие транзакции видит участник
+	Visibility TxVisibility
+	// Address, только переводы с участием кошелька, пустой без фильтра
+	Address string
+	// Since, Until, полуинтервал времени [Since, Until), нулевые границы не ограничивают
+	Since time.Time
+	Until time.Time
+	// InitiatedBy, Channel, только переводы этого инициатора и канала, пустые без фильтра
+	InitiatedBy string
+	Channel     string
+	// Types, только операции этих видов, пустой без фильтра
+	Types []string
+	// GroupID, только транзакции этой группы, пустой без фильтра
+	GroupID string
+
+	SortBy string
+	Desc   bool
+	// Limit, размер страницы, по умолчанию 10, максимум MaxLimit
+	Limit int
+	// MaxLimit, предел Limit, ноль дает 100, больше MaxListLimit и ListLimit репозитория не бывает, большие страницы читаются через StreamTransactions
+	MaxLimit int
+	// Offset, смещение страницы, Cursor, продолжение после последней строки предыдущей страницы из NextCursor, вместе не задаются
+	Offset int
+	Cursor string
+}
+
+// поля сортировки транзакций, id дает порядок записи в журнал
+const (
+	TxSortCreatedAt = "created_at"
+	TxSortAmount    = "amount"
+	TxSortID        = "id"
+)
+
+// txSortExpr, допустимые выражения сортировки транзакций, значение из запроса в sql не подставляется
+var txSortExpr = map[string]string{
+	TxSortCreatedAt: "t.created_at",
+	TxSortAmount:    "t.amount_cents",
+	TxSortID:        "t.id",
+}
+
+// ErrTransactionNotFound, транзакции нет или она не видна участнику
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// txColumns, колонки транзакции t для scanTransaction
+const txColumns = `t.id, t.from_address, t.to_address, t.amount_cents, t.created_at, COALESCE(t.initiated_by, ''), COALESCE(t.channel, ''), t.type, COALESCE(t.group_id::text, '')`
+
+// scanTransaction, читает транзакцию из строки
+func scanTransaction(row interface{ Scan(...any) error }) (Transaction, error) {
+	var t Transaction
+	err := row.Scan(&t.ID, &t.FromAddress, &t.ToAddress, &t.AmountCents, &t.CreatedAt, &t.InitiatedBy, &t.Channel, &t.Type, &t.GroupID)
+	return t, err
+}
+
+// MaxListLimit, наибольшая страница истории, только для администраторов
+const MaxListLimit = 100000
+
+// ErrInvalidCursor, курсор битый, от другой сортировки или передан вместе со смещением
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// normalize, подставляет умолчания и проверяет сортировку
+func (o ListOptions) normalize() (ListOptions, error) {
+	if o.SortBy == "" {
+		o.SortBy = TxSortCreatedAt
+	}
+	if _, ok := txSortExpr[o.SortBy]; !ok {
+		return o, ErrInvalidSort
+	}
+	if o.Limit <= 0 {
+		o.Limit = 10
+	}
+	if max := o.maxLimit(); o.Limit > max {
+		o.Limit = max
+	}
+	if o.Offset < 0 {
+		o.Offset = 0
+	}
+	if o.Cursor != "" && o.Offset > 0 {
+		return o, ErrInvalidCursor
+	}
+	return o, nil
+}
+
+// maxLimit, предел Limit с умолчанием
+func (o ListOptions) maxLimit() int {
+	if o.MaxLimit <= 0 {
+		return 100
+	}
+	return min(o.MaxLimit, MaxListLimit)
+}
+
+// NextCursor, курсор следующей страницы после items, пустой если страница неполная и продолжения нет
+func (o ListOptions) NextCursor(items []Transaction) string {
+	o, err := o.normalize()
+	if err != nil || len(items) < o.Limit {
+		return ""
+	}
+	return o.CursorAfter(items[len(items)-1])
+}
+
+// CursorAfter, курсор продолжения после транзакции last, для страниц, которые не собираются в срез
+func (o ListOptions) CursorAfter(last Transaction) string {
+	if o.SortBy == "" {
+		o.SortBy = TxSortCreatedAt
+	}
+	var v string
+	switch o.SortBy {
+	case TxSortCreatedAt:
+		v = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case TxSortID:
+		v = strconv.FormatInt(last.ID, 10)
+	default:
+		v = strconv.FormatInt(last.AmountCents, 10)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(o.SortBy + "|" + v + "|" + strconv.FormatInt(last.ID, 10)))
+}
+
+// decodeCursor, значение поля сортировки и id последней строки из курсора
+func (o ListOptions) decodeCursor() (any, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] != o.SortBy {
+		return nil, 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	if o.SortBy == TxSortCreatedAt {
+		ts, err := time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		return ts, id, nil
+	}
+	amount, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+	return amount, id, nil
+}
+
+// sqlArgs, накапливает аргументы запроса и выдает их плейсхолдеры
+type sqlArgs []any
+
+func (a *sqlArgs) add(v any) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// where, условия фильтров и видимости для транзакции t
+func (o ListOptions) where(args *sqlArgs) string {
+	conds := []string{txVisibleWhere(o.Visibility, args)}
+	if o.Address != "" {
+		p := args.add(o.Address)
+		conds = append(conds, "(t.from_address = "+p+" OR t.to_address = "+p+")")
+	}
+	if !o.Since.IsZero() {
+		conds = append(conds, "t.created_at >= "+args.add(o.Since))
+	}
+	if !o.Until.IsZero() {
+		conds = append(conds, "t.created_at < "+args.add(o.Until))
+	}
+	if o.InitiatedBy != "" {
+		conds = append(conds, "t.initiated_by = "+args.add(o.InitiatedBy))
+	}
+	if o.Channel != "" {
+		conds = append(conds, "t.channel = "+args.add(o.Channel))
+	}
+	if o.GroupID != "" {
+		conds = append(conds, "t.group_id = "+args.add(o.GroupID)+"::uuid")
+	}
+	if len(o.Types) > 0 {
+		conds = append(conds, "t.type = ANY(string_to_array("+args.add(strings.Join(o.Types, " "))+", ' '))")
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// txVisibleWhere, условие видимости транзакции t, аноним видит переводы между общими кошельками, пользователь переводы своих кошельков
+func txVisibleWhere(vis TxVisibility, args *sqlArgs) string {
+	if vis.All {
+		return "TRUE"
+	}
+	if vis.UserID == 0 {
+		return `NOT EXISTS (
+			SELECT 1 FROM wallets w
+			WHERE w.address IN (t.from_address, t.to_address) AND w.user_id IS NOT NULL
+		)`
+	}
+	return `EXISTS (
+			SELECT 1 FROM wallets w
+			WHERE w.address IN (t.from_address, t.to_address) AND w.user_id = ` + args.add(vis.UserID) + `
+		)`
+}
+
+// ListTransactions, страница транзакций по параметрам, при равных значениях поля сортировки порядок по id, поэтому курсор и смещение устойчивы
+func (r *PostgresRepo) ListTransactions(ctx context.Context, o ListOptions) ([]Transaction, error) {
+	var out []Transaction
+	err := r.StreamTransactions(ctx, o, func(t Transaction) error {
+		out = append(out, t)
+		return nil
+	})
+	return out, err
+}
+
+// StreamTransactions, та же страница, что у ListTransactions, но каждая строка отдается fn сразу после чтения и в памяти не копится, ошибка fn прерывает чтение
+func (r *PostgresRepo) StreamTransactions(ctx context.Context, o ListOptions, fn func(Transaction) error) error {
+	if r.ListLimit > 0 {
+		o.MaxLimit = min(o.maxLimit(), r.ListLimit)
+	}
+	q, args, err := listTransactionsQuery(o)
+	if err != nil {
+		return err
+	}
+	rows, err := r.reader(ctx).QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// listTransactionsQuery, запрос страницы истории с аргументами
+func listTransactionsQuery(o ListOptions) (string, sqlArgs, error) {
+	o, err := o.normalize()
+	if err != nil {
+		return "", nil, err
+	}
+	expr := txSortExpr[o.SortBy]
+	dir, cmp := "ASC", ">"
+	if o.Desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	var args sqlArgs
+	where := o.where(&args)
+	if o.Cursor != "" {
+		v, id, err := o.decodeCursor()
+		if err != nil {
+			return "", nil, err
+		}
+		if o.SortBy == TxSortID {
+			where += fmt.Sprintf(" AND t.id %s %s", cmp, args.add(id))
+		} else {
+			where += fmt.Sprintf(" AND (%s, t.id) %s (%s, %s)", expr, cmp, args.add(v), args.add(id))
+		}
+	}
+	order := fmt.Sprintf("%s %s, t.id %s", expr, dir, dir)
+	if o.SortBy == TxSortID {
+		order = "t.id " + dir
+	}
+
+	q := fmt.Sprintf(`
+		SELECT `+txColumns+`
+		FROM transactions t
+		WHERE %s
+		ORDER BY %s
+		LIMIT %s OFFSET %s
+	`, where, order, args.add(o.Limit), args.add(o.Offset))
+	return q, args, nil
+}
+
+// GetTransaction, транзакция по идентификатору с учетом видимости, невидимая неотличима от отсутствующей
+func (r *PostgresRepo) GetTransaction(ctx context.Context, id int64, vis TxVisibility) (Transaction, error) {
+	args := sqlArgs{id}
+	t, err := scanTransaction(r.reader(ctx).QueryRowContext(ctx, `
+		SELECT `+txColumns+`
+		FROM transactions t
+		WHERE t.id = $1 AND `+txVisibleWhere(vis, &args), args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Transaction{}, ErrTransactionNotFound
+	}
+	return t, err
+}
+
+// CountTransactions, сколько транзакций подходит под фильтры и видимость, страница и сортировка не учитываются, счет останавливается на max, capped сообщает что реальное число больше
+func (r *PostgresRepo) CountTransactions(ctx context.Context, o ListOptions, max int64) (n int64, capped bool, err error) {
+	var args sqlArgs
+	where := o.where(&args)
+	err = r.reader(ctx).QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT COUNT(*) FROM (
+			SELECT 1 FROM transactions t WHERE %s LIMIT %s
+		) s
+	`, where, args.add(max+1)), args...).Scan(&n)
+	if err != nil {
+		return 0, false, err
+	}
+	if n > max {
+		return max, true, nil
+	}
+	return n, false, nil
+}
+
+// LastTransaction, id и время последней записанной транзакции, пустой журнал дает ноль и нулевое время,
+// транзакция базы, закоммиченная позже соседней, может получить меньший id и не сдвинуть его
+func (r *PostgresRepo) LastTransaction(ctx context.Context) (int64, time.Time, error) {
+	var (
+		id int64
+		at time.Time
+	)
+	err := r.reader(ctx).QueryRowContext(ctx, `SELECT id, created_at FROM transactions ORDER BY id DESC LIMIT 1`).Scan(&id, &at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, time.Time{}, nil
+	}
+	return id, at, err
+}
+
+// GetLastTransactions, последние записанные транзакции всех кошельков, новые первыми, обертка над ListTransactions для старых вызовов,
+// порядок по id, время начала транзакций базы, закоммиченных позже, может быть раньше
+func (r *PostgresRepo) GetLastTransactions(ctx context.Context, n int) ([]Transaction, error) {
+	return r.ListTransactions(ctx, ListOptions{Visibility: TxVisibility{All: true}, SortBy: TxSortID, Desc: true, Limit: n})
+}
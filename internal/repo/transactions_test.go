@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+// TestListOptions_Cursor, курсор полной страницы разбирается обратно в значение сортировки и id, неполная страница курсора не дает
+func TestListOptions_Cursor(t *testing.T) {
+	at := time.Date(2025, 3, 1, 10, 0, 0, 123456000, time.UTC)
+	items := []Transaction{{ID: 7, AmountCents: 100, CreatedAt: at.Add(time.Second)}, {ID: 5, AmountCents: 250, CreatedAt: at}}
+
+	o := ListOptions{Limit: 2, Desc: true}
+	o.Cursor = o.NextCursor(items)
+	if o.Cursor == "" {
+		t.Fatalf("want cursor for full page")
+	}
+	o, _ = o.normalize()
+	v, id, err := o.decodeCursor()
+	if err != nil || id != 5 || !v.(time.Time).Equal(at) {
+		t.Fatalf("decode: got %v %d %v", v, id, err)
+	}
+
+	// курсор другой сортировки не принимается
+	o.SortBy = TxSortAmount
+	if _, _, err := o.decodeCursor(); err != ErrInvalidCursor {
+		t.Fatalf("want ErrInvalidCursor for other sort, got %v", err)
+	}
+
+	// курсор по id несет сам id
+	ido := ListOptions{Limit: 2, Desc: true, SortBy: TxSortID}
+	ido.Cursor = ido.NextCursor(items)
+	if _, id, err := ido.decodeCursor(); err != nil || id != 5 {
+		t.Fatalf("decode id cursor: got %d %v", id, err)
+	}
+
+	if c := (ListOptions{Limit: 3}).NextCursor(items); c != "" {
+		t.Fatalf("want no cursor for partial page, got %q", c)
+	}
+	if _, err := (ListOptions{Cursor: "x", Offset: 1}).normalize(); err != ErrInvalidCursor {
+		t.Fatalf("want ErrInvalidCursor with offset, got %v", err)
+	}
+}
+
+// TestListOptions_MaxLimit, без MaxLimit страница не больше 100, с ним не больше MaxLimit и MaxListLimit
+func TestListOptions_MaxLimit(t *testing.T) {
+	cases := []struct{ limit, max, want int }{
+		{0, 0, 10},
+		{500, 0, 100},
+		{500, 1000, 500},
+		{5000, 1000, 1000},
+		{2 * MaxListLimit, 10 * MaxListLimit, MaxListLimit},
+	}
+	for _, c := range cases {
+		o, err := ListOptions{Limit: c.limit, MaxLimit: c.max}.normalize()
+		if err != nil || o.Limit != c.want {
+			t.Fatalf("limit %d max %d: got %d %v, want %d", c.limit, c.max, o.Limit, err, c.want)
+		}
+	}
+}
+
+// TestInlineArgs, плейсхолдеры заменяются литералами по номеру, плейсхолдер без аргумента дает ошибку
+func TestInlineArgs(t *testing.T) {
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	got, err := inlineArgs(nil, "a = $1 AND b >= $2 AND c = $3", sqlArgs{int64(7), at, 5})
+	if err != nil || got != "a = 7 AND b >= '2025-03-01T10:00:00Z'::timestamptz AND c = 5" {
+		t.Fatalf("got %q, %v", got, err)
+	}
+	if _, err := inlineArgs(nil, "a = $2", sqlArgs{int64(1)}); err == nil {
+		t.Fatalf("want error for missing argument")
+	}
+}
@@ -0,0 +1,137 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gotechtask/internal/money"
+)
+
+// действия журнала аудита для эмиссии и изъятия
+const (
+	AuditTreasuryMint = "treasury.mint"
+	AuditTreasuryBurn = "treasury.burn"
+)
+
+// Mint, выпускает amountCents на кошелек казны, операция mint и запись в supply_adjustments в одной транзакции, поэтому инвариант денежной массы сходится
+func (r *PostgresRepo) Mint(ctx context.Context, amountCents int64, reason string) (Transaction, error) {
+	t, err := r.treasuryOp(ctx, TxTypeMint, amountCents, reason)
+	return t, wrapf(err, "mint %d", amountCents)
+}
+
+// Burn, изымает amountCents с кошелька казны, овердрафт казны не используется, не хватает баланса, *InsufficientFundsError
+func (r *PostgresRepo) Burn(ctx context.Context, amountCents int64, reason string) (Transaction, error) {
+	t, err := r.treasuryOp(ctx, TxTypeBurn, amountCents, reason)
+	return t, wrapf(err, "burn %d", amountCents)
+}
+
+// treasuryOp, эмиссия или изъятие на кошельке казны
+func (r *PostgresRepo) treasuryOp(ctx context.Context, txType string, amountCents int64, reason string) (Transaction, error) {
+	action := AuditTreasuryMint
+	if txType == TxTypeBurn {
+		action = AuditTreasuryBurn
+	}
+	return r.supplyOp(ctx, txType, "", amountCents, reason, action)
+}
+
+// supplyOp, меняет баланс кошелька address на сумму операции, пустой address значит казну, пишет операцию, корректировку денежной массы и аудит действием action
+func (r *PostgresRepo) supplyOp(ctx context.Context, txType, address string, amountCents int64, reason, action string) (Transaction, error) {
+	if amountCents <= 0 {
+		return Transaction{}, errors.New("amount must be > 0")
+	}
+	delta := amountCents
+	if txType == TxTypeBurn {
+		delta = -amountCents
+	}
+
+	tx, err := r.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		return Transaction{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var addr string
+	var bal int64
+	if address == "" {
+		err = tx.QueryRowContext(ctx, `
+			SELECT w.address, w.balance_cents
+			FROM system_wallets s
+			JOIN wallets w ON w.address = s.address
+			WHERE s.role = $1
+			FOR UPDATE OF w
+		`, RoleTreasury).Scan(&addr, &bal)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Transaction{}, ErrSystemWalletNotFound
+		}
+	} else {
+		err = tx.QueryRowContext(ctx, `SELECT address, balance_cents FROM wallets WHERE address = $1 FOR UPDATE`, address).Scan(&addr, &bal)
+		if errors.Is(err, sql.ErrNoRows) {
+			return Transaction{}, &WalletNotFoundError{Address: address}
+		}
+	}
+	if err != nil {
+		return Transaction{}, err
+	}
+	if bal, err = applyHotCredits(ctx, tx, addr, bal); err != nil {
+		return Transaction{}, err
+	}
+	if bal+delta < 0 {
+		return Transaction{}, &InsufficientFundsError{Address: addr, NeededCents: amountCents, AvailableCents: bal}
+	}
+	if next, err := money.Add(bal, delta); err != nil || next > money.MaxCents {
+		return Transaction{}, ErrBalanceOverflow
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE wallets SET balance_cents = $1, updated_at = now(), last_tx_at = now(), low_balance_since = `+lowBalanceSince+` WHERE address = $2`,
+		bal+delta, addr); err != nil {
+		if isBalanceOverflow(err) {
+			return Transaction{}, ErrBalanceOverflow
+		}
+		return Transaction{}, err
+	}
+
+	// вторая сторона пустая, деньги приходят извне системы или уходят из нее
+	from, to := "", addr
+	if txType == TxTypeBurn {
+		from, to = addr, ""
+	}
+	t, err := scanTransaction(tx.QueryRowContext(ctx, `
+		INSERT INTO transactions AS t (from_address, to_address, amount_cents, initiated_by, channel, type)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+txColumns,
+		from, to, amountCents, ActorFromContext(ctx), ChannelFromContext(ctx), txType))
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO balance_events(address, tx_id, delta_cents, balance_cents, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, addr, t.ID, delta, bal+delta, t.CreatedAt); err != nil {
+		return Transaction{}, err
+	}
+	if err := postLedger(ctx, tx, t.ID, ledgerPosting{addr, delta}); err != nil {
+		return Transaction{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO supply_adjustments(delta_cents, reason, address) VALUES ($1, $2, $3)`,
+		delta, txType+": "+reason, addr); err != nil {
+		return Transaction{}, err
+	}
+
+	if err := insertAudit(ctx, tx, AuditEntry{
+		Action:  action,
+		Address: addr,
+		Details: map[string]any{"amount_cents": amountCents, "reason": reason, "transaction_id": t.ID},
+	}); err != nil {
+		return Transaction{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Transaction{}, err
+	}
+	return t, nil
+}
@@ -0,0 +1,275 @@
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ошибки пользователей и ключей доступа
+var (
+	ErrUserExists     = errors.New("user already exists")
+	ErrUserNotFound   = errors.New("user not found")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// User, учетная запись пользователя
+type User struct {
+	ID        int64
+	Email     string
+	Name      string
+	Admin     bool
+	CreatedAt time.Time
+}
+
+// APIKey, ключ доступа, найденный по хэшу токена, с признаком администратора владельца, областями доступа и сроком действия (нулевой если ключ бессрочный)
+type APIKey struct {
+	ID        int64
+	UserID    int64
+	Admin     bool
+	Scopes    []string
+	ExpiresAt time.Time
+	// SigningSecret, секрет подписи переводов, пустой если подпись для ключа не включена
+	SigningSecret string
+}
+
+// Wallet, кошелек, адрес, баланс в центах, владелец (ноль если кошелек общий), время создания
+type Wallet struct {
+	Address      string
+	BalanceCents int64
+	UserID       int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	// LastTxAt, время последнего перевода с участием кошелька, нулевое если переводов не было
+	LastTxAt time.Time
+	// LowBalanceCents, порог низкого баланса, ноль если не задан, LowBalanceSince, с какого момента баланс ниже порога, нулевое если не ниже
+	LowBalanceCents int64
+	LowBalanceSince time.Time
+	// Email, почта для квитанций, пустая если не задана, MetaVersion, версия настроек кошелька, растет при изменении почты и порога
+	Email       string
+	MetaVersion int64
+	// ClosedAt, когда кошелек закрыт слиянием, Successor, кошелек, которому передан баланс, пустые у открытого
+	ClosedAt  time.Time
+	Successor string
+	// DerivedLabel, метка, из которой выведен адрес, пустая у случайного и выбранного владельцем адреса
+	DerivedLabel string
+}
+
+// walletColumns, колонки кошелька для scanWallet, баланс вместе с неприменными зачислениями горячего кошелька
+const walletColumns = `address, balance_cents + ` + hotPendingCents + `, COALESCE(user_id, 0), created_at, updated_at, last_tx_at, COALESCE(low_balance_cents, 0), low_balance_since,
+	COALESCE(email, ''), meta_version, closed_at, COALESCE(successor, ''), COALESCE(derived_label, '')`
+
+// scanWallet, читает кошелек из строки
+func scanWallet(row interface{ Scan(...any) error }) (Wallet, error) {
+	var w Wallet
+	var last, low, closed sql.NullTime
+	err := row.Scan(&w.Address, &w.BalanceCents, &w.UserID, &w.CreatedAt, &w.UpdatedAt, &last, &w.LowBalanceCents, &low, &w.Email, &w.MetaVersion, &closed, &w.Successor, &w.DerivedLabel)
+	w.LastTxAt = last.Time
+	w.LowBalanceSince = low.Time
+	w.ClosedAt = closed.Time
+	return w, err
+}
+
+// isUniqueViolation, определяет нарушение уникальности по коду ошибки postgres 23505
+func isUniqueViolation(err error) bool {
+	var pgerr *pgconn.PgError
+	return errors.As(err, &pgerr) && pgerr.Code == "23505"
+}
+
+// RegisterUser, создает пользователя и его первый ключ доступа в одной транзакции, занятая почта маппится на ErrUserExists
+func (r *PostgresRepo) RegisterUser(ctx context.Context, email, name, keyHash, keyPrefix string) (User, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u := User{Email: email, Name: name}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users(email, name) VALUES ($1, $2)
+		RETURNING id, created_at
+	`, email, name).Scan(&u.ID, &u.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return User{}, ErrUserExists
+		}
+		return User{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO api_keys(user_id, key_hash, prefix) VALUES ($1, $2, $3)
+	`, u.ID, keyHash, keyPrefix); err != nil {
+		return User{}, err
+	}
+	return u, tx.Commit()
+}
+
+// GetUser, возвращает пользователя по идентификатору
+func (r *PostgresRepo) GetUser(ctx context.Context, id int64) (User, error) {
+	u := User{ID: id}
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(email, ''), name, is_admin, created_at FROM users WHERE id = $1
+	`, id).Scan(&u.Email, &u.Name, &u.Admin, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+// ExternalIdentity, пользователь внешнего провайдера oidc
+type ExternalIdentity struct {
+	Issuer  string
+	Subject string
+	Email   string
+	Name    string
+}
+
+// UserByExternalIdentity, находит пользователя по issuer и subject, при первом входе создает нового, почта, занятая другим пользователем, дает ErrUserExists,
+// к существующей учетной записи вход не привязывается даже с почтой, подтвержденной провайдером, почта при регистрации ключом не проверяется,
+// и привязка отдала бы учетную запись, заведенную на чужую почту, вместе с ее ключами
+func (r *PostgresRepo) UserByExternalIdentity(ctx context.Context, id ExternalIdentity) (User, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var u User
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, COALESCE(email, ''), name, is_admin, created_at
+		FROM users
+		WHERE oidc_issuer = $1 AND oidc_subject = $2
+	`, id.Issuer, id.Subject).Scan(&u.ID, &u.Email, &u.Name, &u.Admin, &u.CreatedAt)
+	if err == nil {
+		return u, tx.Commit()
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return User{}, err
+	}
+
+	// параллельный первый вход того же пользователя сходится на одной записи
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users(email, name, oidc_issuer, oidc_subject) VALUES (NULLIF($1, ''), $2, $3, $4)
+		ON CONFLICT (tenant_id, oidc_issuer, oidc_subject) WHERE oidc_subject IS NOT NULL DO UPDATE SET name = users.name
+		RETURNING id, COALESCE(email, ''), name, is_admin, created_at
+	`, id.Email, id.Name, id.Issuer, id.Subject).Scan(&u.ID, &u.Email, &u.Name, &u.Admin, &u.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return User{}, ErrUserExists
+		}
+		return User{}, err
+	}
+	return u, tx.Commit()
+}
+
+// LookupAPIKey, ищет действующий ключ по хэшу токена, отозванные и истекшие после ротации не находятся, области доступа отдаются списком
+func (r *PostgresRepo) LookupAPIKey(ctx context.Context, keyHash string) (APIKey, error) {
+	var k APIKey
+	var scopes string
+	var expires sql.NullTime
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT k.id, k.user_id, u.is_admin, array_to_string(k.scopes, ' '), k.expires_at, COALESCE(k.signing_secret, '')
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > now())
+	`, keyHash).Scan(&k.ID, &k.UserID, &k.Admin, &scopes, &expires, &k.SigningSecret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+	k.Scopes = strings.Fields(scopes)
+	k.ExpiresAt = expires.Time
+	return k, err
+}
+
+// WalletOwner, возвращает владельца кошелька, ноль для общего кошелька
+func (r *PostgresRepo) WalletOwner(ctx context.Context, address string) (int64, error) {
+	var owner sql.NullInt64
+	err := r.DB.QueryRowContext(ctx, `SELECT user_id FROM wallets WHERE address = $1`, address).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, &WalletNotFoundError{Address: address}
+	}
+	return owner.Int64, err
+}
+
+// CreateWallet, создает пустой кошелек пользователя, пустой address дает случайный адрес, занятый адрес маппится на ErrWalletExists
+func (r *PostgresRepo) CreateWallet(ctx context.Context, userID int64, address string) (Wallet, error) {
+	if address == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return Wallet{}, err
+		}
+		address = hex.EncodeToString(b)
+	}
+	w, err := scanWallet(r.DB.QueryRowContext(ctx, `
+		INSERT INTO wallets(address, balance_cents, user_id) VALUES ($1, 0, $2)
+		RETURNING `+walletColumns,
+		address, userID))
+	if isUniqueViolation(err) {
+		return Wallet{}, ErrWalletExists
+	}
+	return w, err
+}
+
+// GetOrCreateWallet, кошелек пользователя по адресу, создает его, если адрес свободен, created, создан ли он этим вызовом,
+// адрес другого пользователя или общий маппится на ErrWalletExists, повтор вызова отдает тот же кошелек
+func (r *PostgresRepo) GetOrCreateWallet(ctx context.Context, userID int64, address string) (Wallet, bool, error) {
+	w, err := scanWallet(r.DB.QueryRowContext(ctx, `
+		INSERT INTO wallets(address, balance_cents, user_id) VALUES ($1, 0, $2)
+		ON CONFLICT (address) DO NOTHING
+		RETURNING `+walletColumns,
+		address, userID))
+	if err == nil {
+		return w, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Wallet{}, false, err
+	}
+	w, err = scanWallet(r.DB.QueryRowContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE address = $1`, address))
+	if errors.Is(err, sql.ErrNoRows) {
+		// кошелек удалили между вставкой и чтением, клиент повторит
+		return Wallet{}, false, ErrWalletExists
+	}
+	if err != nil {
+		return Wallet{}, false, err
+	}
+	if w.UserID != userID {
+		return Wallet{}, false, ErrWalletExists
+	}
+	return w, false, nil
+}
+
+// ListUserWallets, кошельки пользователя, старые первыми
+func (r *PostgresRepo) ListUserWallets(ctx context.Context, userID int64) ([]Wallet, error) {
+	rows, err := r.DB.QueryContext(ctx, `
+		SELECT `+walletColumns+`
+		FROM wallets
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []Wallet
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// TxVisibility, какие транзакции видит участник, все, только по кошелькам пользователя, либо только между общими кошельками
+type TxVisibility struct {
+	All    bool
+	UserID int64
+}
@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestUserByExternalIdentity, вход через провайдера не привязывается к учетной записи, заведенной ключом на ту же почту, ее ключ остается при ней,
+// повторный вход находит того же пользователя
+func TestUserByExternalIdentity(t *testing.T) {
+	db := testfixtures.Open(t)
+	r := NewPostgres(db)
+	ctx := context.Background()
+
+	email := randomAddress()[:16] + "@example.com"
+	subject := randomAddress()
+	defer db.Exec(`DELETE FROM users WHERE email = $1 OR oidc_subject = $2`, email, subject)
+
+	local, err := r.RegisterUser(ctx, email, "squatter", randomAddress(), "wk_test")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	id := ExternalIdentity{Issuer: "https://idp.example.com", Subject: subject, Email: email, Name: "owner"}
+	if _, err := r.UserByExternalIdentity(ctx, id); !errors.Is(err, ErrUserExists) {
+		t.Fatalf("taken email: want ErrUserExists, got %v", err)
+	}
+	var linked bool
+	if err := db.QueryRow(`SELECT oidc_subject IS NOT NULL FROM users WHERE id = $1`, local.ID).Scan(&linked); err != nil || linked {
+		t.Fatalf("local account linked: %v %v", linked, err)
+	}
+
+	id.Email = ""
+	first, err := r.UserByExternalIdentity(ctx, id)
+	if err != nil || first.ID == local.ID {
+		t.Fatalf("first login: %+v %v", first, err)
+	}
+	again, err := r.UserByExternalIdentity(ctx, id)
+	if err != nil || again.ID != first.ID {
+		t.Fatalf("second login: %+v %v, want id %d", again, err, first.ID)
+	}
+}
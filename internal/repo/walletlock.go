@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+)
+
+// WalletLocks, полосатые мьютексы кошельков внутри процесса, адрес попадает в одну из полос по хэшу, переводы с общей полосой идут по очереди еще до транзакции базы,
+// так горячий кошелек не порождает дедлоков и повторов в базе, имеет смысл только для одного экземпляра сервиса, блокировки других экземпляров он не видит
+type WalletLocks struct {
+	stripes []chan struct{}
+}
+
+// NewWalletLocks, n полос, больше полос, меньше ложных очередей между разными кошельками
+func NewWalletLocks(n int) *WalletLocks {
+	l := &WalletLocks{stripes: make([]chan struct{}, n)}
+	for i := range l.stripes {
+		l.stripes[i] = make(chan struct{}, 1)
+	}
+	return l
+}
+
+// Lock, занимает полосы всех адресов по возрастанию номера, поэтому два перевода не могут ждать друг друга, ожидание прерывается отменой ctx,
+// unlock освобождает занятые полосы
+func (l *WalletLocks) Lock(ctx context.Context, addrs ...string) (unlock func(), err error) {
+	idx := make([]int, 0, len(addrs))
+	for _, a := range addrs {
+		h := fnv.New32a()
+		h.Write([]byte(a))
+		idx = append(idx, int(h.Sum32()%uint32(len(l.stripes))))
+	}
+	sort.Ints(idx)
+
+	var held []int
+	unlock = func() {
+		for _, i := range held {
+			<-l.stripes[i]
+		}
+	}
+	for n, i := range idx {
+		// несколько адресов в одной полосе занимают ее один раз
+		if n > 0 && idx[n-1] == i {
+			continue
+		}
+		select {
+		case l.stripes[i] <- struct{}{}:
+			held = append(held, i)
+		case <-ctx.Done():
+			unlock()
+			return nil, ctx.Err()
+		}
+	}
+	return unlock, nil
+}
+
+// lockWallets, занимает полосы адресов, если блокировки включены, иначе ничего не делает
+func (r *PostgresRepo) lockWallets(ctx context.Context, addrs ...string) (func(), error) {
+	if r.Locks == nil {
+		return func() {}, nil
+	}
+	return r.Locks.Lock(ctx, addrs...)
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestWalletLocks, переводы с общим кошельком идут по очереди, встречные переводы не ждут друг друга вечно, ожидание прерывается отменой контекста
+func TestWalletLocks(t *testing.T) {
+	l := NewWalletLocks(64)
+	ctx := context.Background()
+	a, b, c := randomAddress(), randomAddress(), randomAddress()
+
+	var (
+		mu      sync.Mutex
+		inside  = map[string]int{}
+		maxSeen int
+		wg      sync.WaitGroup
+	)
+	pairs := [][2]string{{a, b}, {b, a}, {a, c}, {c, b}}
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(p [2]string) {
+			defer wg.Done()
+			unlock, err := l.Lock(ctx, p[0], p[1])
+			if err != nil {
+				t.Errorf("lock: %v", err)
+				return
+			}
+			mu.Lock()
+			for _, addr := range p {
+				inside[addr]++
+				maxSeen = max(maxSeen, inside[addr])
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Microsecond)
+			mu.Lock()
+			for _, addr := range p {
+				inside[addr]--
+			}
+			mu.Unlock()
+			unlock()
+		}(pairs[i%len(pairs)])
+	}
+	wg.Wait()
+	if maxSeen != 1 {
+		t.Fatalf("wallet held by %d transfers at once", maxSeen)
+	}
+
+	// один адрес дважды не ждет сам себя
+	unlock, err := l.Lock(ctx, a, a)
+	if err != nil {
+		t.Fatalf("lock same address twice: %v", err)
+	}
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(tctx, b, a); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want deadline while wallet is held, got %v", err)
+	}
+	unlock()
+	// отмененное ожидание не оставило занятых полос
+	unlock, err = l.Lock(ctx, a, b)
+	if err != nil {
+		t.Fatalf("lock after timeout: %v", err)
+	}
+	unlock()
+}
@@ -0,0 +1,50 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// WalletMetaPatch, изменение настроек кошелька, nil поле не меняется, пустая почта очищает адрес, нулевой порог выключает уведомление
+type WalletMetaPatch struct {
+	Email           *string
+	LowBalanceCents *int64
+}
+
+// UpdateWalletMeta, меняет настройки кошелька в одной транзакции, если версия настроек равна version, ноль пропускает проверку,
+// другая версия дает ErrVersionMismatch вместе с текущим кошельком, каждое поле пишет свою запись аудита, версия растет на каждое поле
+func (r *PostgresRepo) UpdateWalletMeta(ctx context.Context, address string, version int64, p WalletMetaPatch, actor string) (Wallet, error) {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return Wallet{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	w, err := scanWallet(tx.QueryRowContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE address = $1 FOR UPDATE`, address))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Wallet{}, &WalletNotFoundError{Address: address}
+	}
+	if err != nil {
+		return Wallet{}, err
+	}
+	if version != 0 && w.MetaVersion != version {
+		return w, ErrVersionMismatch
+	}
+
+	if p.Email != nil {
+		if err := setWalletEmailTx(ctx, tx, address, *p.Email, actor); err != nil {
+			return Wallet{}, err
+		}
+	}
+	if p.LowBalanceCents != nil {
+		if err := setLowBalanceTx(ctx, tx, address, *p.LowBalanceCents, actor); err != nil {
+			return Wallet{}, err
+		}
+	}
+	w, err = scanWallet(tx.QueryRowContext(ctx, `SELECT `+walletColumns+` FROM wallets WHERE address = $1`, address))
+	if err != nil {
+		return Wallet{}, err
+	}
+	return w, tx.Commit()
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// WalletStats, итоги переводов кошелька за все время, FirstTxAt и LastTxAt нулевые, если переводов не было
+type WalletStats struct {
+	Address       string
+	SentCents     int64
+	ReceivedCents int64
+	SentCount     int64
+	ReceivedCount int64
+	FirstTxAt     time.Time
+	LastTxAt      time.Time
+}
+
+// TxCount, число переводов кошелька в обе стороны
+func (s WalletStats) TxCount() int64 { return s.SentCount + s.ReceivedCount }
+
+// GetWalletStats, сколько кошелек отправил и получил, число переводов и время первого и последнего,
+// каждая сторона считается по своему индексу адреса и времени, транзакции в архиве не учитываются
+func (r *PostgresRepo) GetWalletStats(ctx context.Context, address string) (WalletStats, error) {
+	var (
+		s                   = WalletStats{Address: address}
+		sentFirst, sentLast sql.NullTime
+		recvFirst, recvLast sql.NullTime
+	)
+	err := r.reader(ctx).QueryRowContext(ctx, `
+		SELECT s.cents, s.n, s.first, s.last, i.cents, i.n, i.first, i.last
+		FROM wallets w
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(amount_cents), 0)::bigint, COUNT(*), MIN(created_at), MAX(created_at)
+			FROM transactions WHERE from_address = w.address
+		) s(cents, n, first, last)
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(amount_cents), 0)::bigint, COUNT(*), MIN(created_at), MAX(created_at)
+			FROM transactions WHERE to_address = w.address
+		) i(cents, n, first, last)
+		WHERE w.address = $1
+	`, address).Scan(&s.SentCents, &s.SentCount, &sentFirst, &sentLast, &s.ReceivedCents, &s.ReceivedCount, &recvFirst, &recvLast)
+	if errors.Is(err, sql.ErrNoRows) {
+		return WalletStats{}, &WalletNotFoundError{Address: address}
+	}
+	if err != nil {
+		return WalletStats{}, err
+	}
+
+	for _, t := range []sql.NullTime{sentFirst, recvFirst} {
+		if t.Valid && (s.FirstTxAt.IsZero() || t.Time.Before(s.FirstTxAt)) {
+			s.FirstTxAt = t.Time
+		}
+	}
+	for _, t := range []sql.NullTime{sentLast, recvLast} {
+		if t.Valid && t.Time.After(s.LastTxAt) {
+			s.LastTxAt = t.Time
+		}
+	}
+	return s, nil
+}
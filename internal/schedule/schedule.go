@@ -0,0 +1,143 @@
+// Package schedule, расписания постоянных поручений, каждый день, по дню недели или по числу месяца в заданное время, время считается в часовом поясе бизнеса
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// периодичность расписания
+const (
+	Daily   = "daily"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// LastDay, число месяца, означающее его последний день
+const LastDay = -1
+
+// ErrInvalid, расписание или время не разобраны
+var ErrInvalid = errors.New("schedule: invalid spec")
+
+// weekdays, короткие имена дней недели в записи расписания
+var weekdays = map[string]time.Weekday{
+	"mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday,
+	"fri": time.Friday, "sat": time.Saturday, "sun": time.Sunday,
+}
+
+// Schedule, разобранное расписание, Weekday у weekly, Day у monthly, LastDay для последнего дня, число больше длины месяца сдвигается на его последний день
+type Schedule struct {
+	Every   string
+	Weekday time.Weekday
+	Day     int
+	Hour    int
+	Minute  int
+}
+
+// Parse, расписание из записи вида daily, weekly:mon, monthly:1 или monthly:last и времени HH:MM, пустое время значит полночь
+func Parse(spec, at string) (Schedule, error) {
+	var s Schedule
+	every, arg, _ := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	switch every {
+	case Daily:
+		if arg != "" {
+			return s, ErrInvalid
+		}
+	case Weekly:
+		wd, ok := weekdays[arg]
+		if !ok {
+			return s, ErrInvalid
+		}
+		s.Weekday = wd
+	case Monthly:
+		if arg == "last" {
+			s.Day = LastDay
+			break
+		}
+		d, err := strconv.Atoi(arg)
+		if err != nil || d < 1 || d > 31 {
+			return s, ErrInvalid
+		}
+		s.Day = d
+	default:
+		return s, ErrInvalid
+	}
+	s.Every = every
+
+	if at != "" {
+		t, err := time.Parse("15:04", at)
+		if err != nil {
+			return s, ErrInvalid
+		}
+		s.Hour, s.Minute = t.Hour(), t.Minute()
+	}
+	return s, nil
+}
+
+// String, каноничная запись расписания для хранения
+func (s Schedule) String() string {
+	switch s.Every {
+	case Weekly:
+		for name, wd := range weekdays {
+			if wd == s.Weekday {
+				return Weekly + ":" + name
+			}
+		}
+	case Monthly:
+		if s.Day == LastDay {
+			return Monthly + ":last"
+		}
+		return Monthly + ":" + strconv.Itoa(s.Day)
+	}
+	return s.Every
+}
+
+// At, время запуска в записи HH:MM
+func (s Schedule) At() string {
+	return fmt.Sprintf("%02d:%02d", s.Hour, s.Minute)
+}
+
+// matches, подходит ли день под расписание
+func (s Schedule) matches(y int, m time.Month, d int, loc *time.Location) bool {
+	switch s.Every {
+	case Weekly:
+		return time.Date(y, m, d, 12, 0, 0, 0, loc).Weekday() == s.Weekday
+	case Monthly:
+		last := time.Date(y, m+1, 0, 12, 0, 0, 0, loc).Day()
+		if s.Day == LastDay || s.Day > last {
+			return d == last
+		}
+		return d == s.Day
+	}
+	return true
+}
+
+// Next, первый запуск строго после after, день и время считаются в loc
+func (s Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	local := after.In(loc)
+	y, m, d := local.Date()
+	// подходящий день находится не дальше чем через 31 день, запас на случай сдвигов времени
+	for i := 0; i < 64; i++ {
+		dy, dm, dd := time.Date(y, m, d+i, 12, 0, 0, 0, loc).Date()
+		if !s.matches(dy, dm, dd, loc) {
+			continue
+		}
+		if t := time.Date(dy, dm, dd, s.Hour, s.Minute, 0, 0, loc); t.After(after) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Upcoming, n ближайших запусков после after
+func (s Schedule) Upcoming(after time.Time, loc *time.Location, n int) []time.Time {
+	out := make([]time.Time, 0, n)
+	for range n {
+		after = s.Next(after, loc)
+		out = append(out, after)
+	}
+	return out
+}
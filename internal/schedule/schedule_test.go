@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// TestNext, ближайший запуск по каждому виду расписания, короткий месяц и переход на летнее время
+func TestNext(t *testing.T) {
+	msk, _ := time.LoadLocation("Europe/Moscow")
+	ny, _ := time.LoadLocation("America/New_York")
+	cases := []struct {
+		spec, at string
+		after    time.Time
+		loc      *time.Location
+		want     time.Time
+	}{
+		{"daily", "09:00", time.Date(2025, 6, 10, 8, 0, 0, 0, msk), msk, time.Date(2025, 6, 10, 9, 0, 0, 0, msk)},
+		{"daily", "09:00", time.Date(2025, 6, 10, 9, 0, 0, 0, msk), msk, time.Date(2025, 6, 11, 9, 0, 0, 0, msk)},
+		// 10 июня 2025 вторник, ближайший понедельник 16 июня
+		{"weekly:mon", "", time.Date(2025, 6, 10, 0, 0, 0, 0, msk), msk, time.Date(2025, 6, 16, 0, 0, 0, 0, msk)},
+		{"monthly:1", "10:30", time.Date(2025, 6, 10, 0, 0, 0, 0, msk), msk, time.Date(2025, 7, 1, 10, 30, 0, 0, msk)},
+		{"monthly:31", "", time.Date(2025, 2, 1, 0, 0, 0, 0, msk), msk, time.Date(2025, 2, 28, 0, 0, 0, 0, msk)},
+		{"monthly:last", "", time.Date(2024, 2, 28, 12, 0, 0, 0, msk), msk, time.Date(2024, 2, 29, 0, 0, 0, 0, msk)},
+		// граница суток считается в часовом поясе бизнеса, 23:30 utc в москве уже следующий день
+		{"daily", "01:00", time.Date(2025, 6, 10, 23, 30, 0, 0, time.UTC), msk, time.Date(2025, 6, 12, 1, 0, 0, 0, msk)},
+		// переход на летнее время в нью-йорке 9 марта 2025, 09:00 остается 09:00 местного
+		{"daily", "09:00", time.Date(2025, 3, 8, 10, 0, 0, 0, ny), ny, time.Date(2025, 3, 9, 9, 0, 0, 0, ny)},
+	}
+	for _, c := range cases {
+		s, err := Parse(c.spec, c.at)
+		if err != nil {
+			t.Fatalf("Parse(%q, %q): %v", c.spec, c.at, err)
+		}
+		if got := s.Next(c.after, c.loc); !got.Equal(c.want) {
+			t.Errorf("%s@%s after %v: got %v, want %v", c.spec, c.at, c.after, got, c.want)
+		}
+	}
+}
+
+// TestUpcoming, запуски идут подряд по расписанию
+func TestUpcoming(t *testing.T) {
+	s, _ := Parse("monthly:31", "")
+	got := s.Upcoming(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.UTC, 3)
+	want := []time.Time{
+		time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Fatalf("run %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+// TestParse, каноничная запись и отказ на неверные
+func TestParse(t *testing.T) {
+	for spec, want := range map[string]string{"Weekly:MON": "weekly:mon", "monthly:last": "monthly:last", " daily ": "daily", "monthly:5": "monthly:5"} {
+		s, err := Parse(spec, "")
+		if err != nil || s.String() != want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", spec, s.String(), err, want)
+		}
+	}
+	for _, c := range [][2]string{{"hourly", ""}, {"weekly:funday", ""}, {"monthly:0", ""}, {"monthly:32", ""}, {"daily:1", ""}, {"daily", "25:00"}, {"daily", "9am"}} {
+		if _, err := Parse(c[0], c[1]); err != ErrInvalid {
+			t.Errorf("Parse(%q, %q): want ErrInvalid, got %v", c[0], c[1], err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package selfcheck
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gotechtask/internal/db/migrations"
+	"gotechtask/internal/repo"
+)
+
+// DB, база отвечает
+func DB(db *sql.DB) Check {
+	return Check{Name: "db", Run: func(ctx context.Context) (string, error) {
+		var version string
+		if err := db.QueryRowContext(ctx, `SHOW server_version`).Scan(&version); err != nil {
+			return "", err
+		}
+		return "postgres " + version, nil
+	}}
+}
+
+// ExpectedSchema, версия схемы, под которую собран бинарник, последняя встроенная миграция
+func ExpectedSchema() (uint, error) {
+	ms, err := migrations.Up()
+	if err != nil {
+		return 0, err
+	}
+	if len(ms) == 0 {
+		return 0, errors.New("no migrations embedded")
+	}
+	return ms[len(ms)-1].Version, nil
+}
+
+// Schema, версия схемы по schema_migrations не ниже want и не грязная, более новая схема проходит,
+// при выкладке миграции применяются раньше, чем обновляются все экземпляры
+func Schema(db *sql.DB, want uint) Check {
+	return Check{Name: "schema", Run: func(ctx context.Context) (string, error) {
+		var version uint
+		var dirty bool
+		if err := db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations`).Scan(&version, &dirty); err != nil {
+			return "", fmt.Errorf("read schema_migrations: %w", err)
+		}
+		switch {
+		case dirty:
+			return "", fmt.Errorf("schema version %d is dirty, a migration failed halfway", version)
+		case version < want:
+			return "", fmt.Errorf("schema version %d, binary expects %d", version, want)
+		case version > want:
+			return fmt.Sprintf("schema version %d, newer than binary %d", version, want), nil
+		}
+		return fmt.Sprintf("schema version %d", version), nil
+	}}
+}
+
+// SystemWallets, все служебные кошельки из repo.SystemWalletRoles созданы
+func SystemWallets(db *sql.DB) Check {
+	return Check{Name: "system_wallets", Run: func(ctx context.Context) (string, error) {
+		roles := make([]string, 0, len(repo.SystemWalletRoles))
+		for _, sw := range repo.SystemWalletRoles {
+			roles = append(roles, sw.Role)
+		}
+		var missing string
+		if err := db.QueryRowContext(ctx, `
+			SELECT COALESCE(string_agg(r, ', ' ORDER BY r), '') FROM unnest($1::text[]) AS r
+			WHERE NOT EXISTS (SELECT 1 FROM system_wallets s WHERE s.role = r)
+		`, roles).Scan(&missing); err != nil {
+			return "", err
+		}
+		if missing != "" {
+			return "", fmt.Errorf("missing system wallets: %s", missing)
+		}
+		return fmt.Sprintf("%d system wallets", len(roles)), nil
+	}}
+}
+
+// Clock, часы процесса расходятся с часами базы не больше maxSkew, время базы сравнивается с серединой запроса, так задержка сети не считается расхождением
+func Clock(db *sql.DB, maxSkew time.Duration) Check {
+	return Check{Name: "clock", Run: func(ctx context.Context) (string, error) {
+		before := time.Now()
+		var dbNow time.Time
+		if err := db.QueryRowContext(ctx, `SELECT clock_timestamp()`).Scan(&dbNow); err != nil {
+			return "", err
+		}
+		after := time.Now()
+		skew := clockSkew(before, dbNow, after)
+		if skew > maxSkew || -skew > maxSkew {
+			return "", fmt.Errorf("clock skew %s against database, max %s", skew.Round(time.Millisecond), maxSkew)
+		}
+		return fmt.Sprintf("clock skew %s", skew.Round(time.Millisecond)), nil
+	}}
+}
+
+// clockSkew, насколько часы базы впереди процесса, dbNow снято где-то между before и after, поэтому смещение считается от середины запроса
+// и уменьшается на неопределенность, половину задержки, смещение в ее пределах считается нулем
+func clockSkew(before, dbNow, after time.Time) time.Duration {
+	half := after.Sub(before) / 2
+	offset := dbNow.Sub(before.Add(half))
+	switch {
+	case offset > half:
+		return offset - half
+	case offset < -half:
+		return offset + half
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+// Package selfcheck, проверки при запуске, связь с базой, версия схемы, служебные кошельки и часы, до их прохождения сервис не готов принимать запросы
+package selfcheck
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Check, одна проверка, Run отдает пояснение к успеху или ошибку
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) (string, error)
+}
+
+// Result, итог одной проверки
+type Result struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Detail   string `json:"detail,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Duration int64  `json:"duration_ms"`
+}
+
+// Report, итог всех проверок, OK только если прошли все
+type Report struct {
+	OK     bool      `json:"ok"`
+	At     time.Time `json:"at"`
+	Checks []Result  `json:"checks"`
+}
+
+// checkTimeout, сколько ждать одну проверку
+const checkTimeout = 5 * time.Second
+
+// Run, выполняет проверки по порядку, упавшая проверка не останавливает следующие, чтобы отчет показал все причины сразу
+func Run(ctx context.Context, checks []Check) Report {
+	rep := Report{OK: true, At: time.Now().UTC(), Checks: make([]Result, 0, len(checks))}
+	for _, c := range checks {
+		cctx, cancel := context.WithTimeout(ctx, checkTimeout)
+		start := time.Now()
+		detail, err := c.Run(cctx)
+		cancel()
+		res := Result{Name: c.Name, OK: err == nil, Detail: detail, Duration: time.Since(start).Milliseconds()}
+		if err != nil {
+			res.Error = err.Error()
+			rep.OK = false
+		}
+		rep.Checks = append(rep.Checks, res)
+	}
+	return rep
+}
+
+// Gate, готовность сервиса, закрыт до первого полностью успешного отчета, потом открыт до остановки
+type Gate struct {
+	ready  atomic.Bool
+	report atomic.Pointer[Report]
+	// Logf, куда писать отчеты, подменяется в тестах
+	Logf func(format string, args ...any)
+}
+
+// NewGate, закрытый шлюз с логом в стандартный log
+func NewGate() *Gate {
+	return &Gate{Logf: log.Printf}
+}
+
+// Ready, все проверки прошли
+func (g *Gate) Ready() bool { return g.ready.Load() }
+
+// Report, последний отчет, nil до первого прогона
+func (g *Gate) Report() *Report { return g.report.Load() }
+
+// Run, повторяет проверки раз в retry, пока они не пройдут все, каждый отчет пишется в лог одной строкой json, успешный открывает шлюз,
+// отдает false, если ctx отменили раньше
+func (g *Gate) Run(ctx context.Context, checks []Check, retry time.Duration) bool {
+	t := time.NewTicker(retry)
+	defer t.Stop()
+	for {
+		rep := Run(ctx, checks)
+		g.report.Store(&rep)
+		b, _ := json.Marshal(rep)
+		g.Logf("self-check %s", b)
+		if rep.OK {
+			g.ready.Store(true)
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-t.C:
+		}
+	}
+}
+
+// startingRetryAfter, подсказка повтора, пока сервис не готов
+const startingRetryAfter = 5 * time.Second
+
+// Guard, пока шлюз закрыт, отвечает 503 с подсказкой повтора на все пути, кроме open, например проверки живости и готовности
+func (g *Gate) Guard(open ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !g.Ready() && !slices.Contains(open, r.URL.Path) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(startingRetryAfter/time.Second)))
+				writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "service starting"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ServeHTTP, проверка готовности, 200 после успешного отчета, до него 503, тело, последний отчет
+func (g *Gate) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	code := http.StatusOK
+	if !g.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+	rep := g.Report()
+	if rep == nil {
+		rep = &Report{Checks: []Result{}}
+	}
+	writeJSON(w, code, rep)
+}
+
+// writeJSON, ответ json с кодом
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,121 @@
+package selfcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotechtask/internal/testfixtures"
+)
+
+// TestRun, упавшая проверка не останавливает следующие, отчет не OK
+func TestRun(t *testing.T) {
+	rep := Run(context.Background(), []Check{
+		{Name: "a", Run: func(context.Context) (string, error) { return "", errors.New("down") }},
+		{Name: "b", Run: func(context.Context) (string, error) { return "fine", nil }},
+	})
+	if rep.OK || len(rep.Checks) != 2 {
+		t.Fatalf("report: %+v", rep)
+	}
+	if rep.Checks[0].OK || rep.Checks[0].Error != "down" || !rep.Checks[1].OK || rep.Checks[1].Detail != "fine" {
+		t.Fatalf("checks: %+v", rep.Checks)
+	}
+}
+
+// TestGate, шлюз закрыт и отвечает 503, пока проверка падает, открывается после первого успешного отчета, открытые пути доступны всегда
+func TestGate(t *testing.T) {
+	var calls atomic.Int32
+	var logged []string
+	g := NewGate()
+	g.Logf = func(format string, args ...any) { logged = append(logged, format) }
+	h := g.Guard("/health", "/ready")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }))
+	get := func(h http.Handler, path string) int {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code
+	}
+
+	if code := get(h, "/api/transactions"); code != http.StatusServiceUnavailable {
+		t.Fatalf("before checks: want 503, got %d", code)
+	}
+	if code := get(h, "/health"); code != http.StatusOK {
+		t.Fatalf("health before checks: got %d", code)
+	}
+	if code := get(g, "/ready"); code != http.StatusServiceUnavailable {
+		t.Fatalf("ready before checks: want 503, got %d", code)
+	}
+
+	ok := g.Run(context.Background(), []Check{{Name: "flaky", Run: func(context.Context) (string, error) {
+		if calls.Add(1) < 3 {
+			return "", errors.New("not yet")
+		}
+		return "", nil
+	}}}, time.Millisecond)
+	if !ok || !g.Ready() || calls.Load() != 3 || len(logged) != 3 {
+		t.Fatalf("run: ok %v ready %v calls %d logged %d", ok, g.Ready(), calls.Load(), len(logged))
+	}
+	if code := get(h, "/api/transactions"); code != http.StatusOK {
+		t.Fatalf("after checks: got %d", code)
+	}
+	rr := httptest.NewRecorder()
+	g.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"ok":true`) {
+		t.Fatalf("ready: %d %s", rr.Code, rr.Body.String())
+	}
+}
+
+// TestGateCanceled, отмена до успешного отчета оставляет шлюз закрытым
+func TestGateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g := NewGate()
+	g.Logf = func(string, ...any) {}
+	if g.Run(ctx, []Check{{Name: "down", Run: func(context.Context) (string, error) { return "", errors.New("down") }}}, time.Hour) || g.Ready() {
+		t.Fatal("canceled run opened the gate")
+	}
+}
+
+// TestClockSkew, смещение от середины запроса за вычетом половины задержки, в пределах задержки ноль
+func TestClockSkew(t *testing.T) {
+	at := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		name   string
+		rtt    time.Duration
+		offset time.Duration
+		want   time.Duration
+	}{
+		{"in sync", 100 * time.Millisecond, 50 * time.Millisecond, 0},
+		{"within round trip", 100 * time.Millisecond, 90 * time.Millisecond, 0},
+		{"ahead", 100 * time.Millisecond, 3 * time.Second, 3*time.Second - 100*time.Millisecond},
+		{"behind", 100 * time.Millisecond, -3 * time.Second, -3 * time.Second},
+		{"just past round trip", 100 * time.Millisecond, 110 * time.Millisecond, 10 * time.Millisecond},
+	} {
+		if got := clockSkew(at, at.Add(tc.offset), at.Add(tc.rtt)); got != tc.want {
+			t.Fatalf("%s: skew %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestSchema, схема тестовой базы на последней миграции проходит, отставшая и грязная нет
+func TestSchema(t *testing.T) {
+	db := testfixtures.Open(t)
+	want, err := ExpectedSchema()
+	if err != nil {
+		t.Fatalf("expected schema: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := Schema(db, want).Run(ctx); err != nil {
+		t.Fatalf("current schema: %v", err)
+	}
+	if _, err := Schema(db, want+1).Run(ctx); err == nil || !strings.Contains(err.Error(), "binary expects") {
+		t.Fatalf("old schema: want error, got %v", err)
+	}
+	if detail, err := Schema(db, want-1).Run(ctx); err != nil || !strings.Contains(detail, "newer") {
+		t.Fatalf("newer schema: %q %v", detail, err)
+	}
+}
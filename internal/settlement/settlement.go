@@ -0,0 +1,95 @@
+// Package settlement, расчет итогов бизнес-дня по кошелькам, день задается датой в часовом поясе бизнеса
+package settlement
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"gotechtask/internal/leader"
+	"gotechtask/internal/repo"
+)
+
+// settle, сколько ждать после конца бизнес-дня, чтобы успели закоммититься переводы, начатые до границы
+const settle = 5 * time.Minute
+
+// maxCatchUp, сколько пропущенных дней досчитывается за один проход, например после простоя
+const maxCatchUp = 7
+
+// Store, операции над расчетами, реализуется репозиторием postgres
+type Store interface {
+	LatestSettlement(ctx context.Context) (string, bool, error)
+	Settle(ctx context.Context, date string, loc *time.Location) (repo.SettlementRun, error)
+}
+
+// Settler, периодическая задача расчета, проверяет раз в Interval, каждый закрытый день считается один раз
+type Settler struct {
+	Store Store
+	// Interval, период проверки, закрыт ли новый день
+	Interval time.Duration
+	// Location, часовой пояс бизнеса, границы дня считаются в нем
+	Location *time.Location
+	// Lock, блокировка прохода среди экземпляров сервиса, nil для одного экземпляра
+	Lock leader.Locker
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор задачи расчета
+func New(s Store, interval time.Duration, loc *time.Location) *Settler {
+	return &Settler{Store: s, Interval: interval, Location: loc, Now: time.Now}
+}
+
+// Run, проверяет сразу и затем с заданным интервалом до отмены контекста, из нескольких экземпляров проход делает один
+func (s *Settler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+
+	for {
+		if _, err := leader.Do(ctx, s.Lock, "settlement", s.RunOnce); err != nil {
+			log.Printf("settlement: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, считает последний закрытый бизнес-день и пропущенные перед ним, не больше maxCatchUp, первый запуск считает только вчерашний день
+func (s *Settler) RunOnce(ctx context.Context) error {
+	local := s.Now().Add(-settle).In(s.Location)
+	last := time.Date(local.Year(), local.Month(), local.Day()-1, 0, 0, 0, 0, s.Location)
+
+	from := last
+	latest, ok, err := s.Store.LatestSettlement(ctx)
+	if err != nil {
+		return err
+	}
+	if ok {
+		d, err := time.ParseInLocation(repo.DateLayout, latest, s.Location)
+		if err != nil {
+			return err
+		}
+		from = d.AddDate(0, 0, 1)
+		if earliest := last.AddDate(0, 0, 1-maxCatchUp); from.Before(earliest) {
+			from = earliest
+		}
+	}
+
+	for d := from; !d.After(last); d = d.AddDate(0, 0, 1) {
+		date := d.Format(repo.DateLayout)
+		run, err := s.Store.Settle(ctx, date, s.Location)
+		if errors.Is(err, repo.ErrAlreadySettled) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("settlement: %s settled, %d wallets, %d transactions", date, run.Wallets, run.TxCount)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package settlement
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, расчеты в памяти
+type fakeStore struct {
+	latest  string
+	settled []string
+}
+
+func (f *fakeStore) LatestSettlement(context.Context) (string, bool, error) {
+	return f.latest, f.latest != "", nil
+}
+
+func (f *fakeStore) Settle(_ context.Context, date string, _ *time.Location) (repo.SettlementRun, error) {
+	f.settled = append(f.settled, date)
+	f.latest = date
+	return repo.SettlementRun{BusinessDate: date}, nil
+}
+
+// TestRunOnce_BusinessTimezone, день закрывается по полуночи часового пояса бизнеса, а не utc
+func TestRunOnce_BusinessTimezone(t *testing.T) {
+	loc := time.FixedZone("UTC+3", 3*3600)
+	st := &fakeStore{}
+	s := New(st, time.Hour, loc)
+
+	// 20:00 utc 14 июня это уже 23:00 по бизнесу, 14 число еще не закрыто
+	s.Now = func() time.Time { return time.Date(2025, 6, 14, 20, 0, 0, 0, time.UTC) }
+	if err := s.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if want := []string{"2025-06-13"}; !reflect.DeepEqual(st.settled, want) {
+		t.Fatalf("want %v, got %v", want, st.settled)
+	}
+
+	// 21:10 utc это 00:10 15 июня по бизнесу, 14 число закрыто
+	s.Now = func() time.Time { return time.Date(2025, 6, 14, 21, 10, 0, 0, time.UTC) }
+	for i := 0; i < 2; i++ {
+		if err := s.RunOnce(context.Background()); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	if want := []string{"2025-06-13", "2025-06-14"}; !reflect.DeepEqual(st.settled, want) {
+		t.Fatalf("want each day settled once, got %v", st.settled)
+	}
+}
+
+// TestRunOnce_CatchUp, пропущенные дни досчитываются, но не больше maxCatchUp
+func TestRunOnce_CatchUp(t *testing.T) {
+	st := &fakeStore{latest: "2025-05-01"}
+	s := New(st, time.Hour, time.UTC)
+	s.Now = func() time.Time { return time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC) }
+
+	if err := s.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(st.settled) != maxCatchUp || st.settled[0] != "2025-06-08" || st.latest != "2025-06-14" {
+		t.Fatalf("want last %d days up to 2025-06-14, got %v", maxCatchUp, st.settled)
+	}
+}
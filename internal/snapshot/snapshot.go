@@ -0,0 +1,77 @@
+// Package snapshot, ежедневные снимки балансов кошельков на начало суток, от них считаются исторический баланс и сверка
+package snapshot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gotechtask/internal/leader"
+)
+
+// settle, сколько ждать после границы суток, чтобы успели закоммититься переводы, начатые до нее
+const settle = 5 * time.Minute
+
+// Store, операции над снимками, реализуется репозиторием postgres
+type Store interface {
+	LatestSnapshot(ctx context.Context) (time.Time, bool, error)
+	SnapshotBalances(ctx context.Context, asOf time.Time) (int64, error)
+}
+
+// Snapshotter, периодическая задача снимков, проверяет раз в Interval, снимок делается один раз за сутки
+type Snapshotter struct {
+	Store Store
+	// Interval, период проверки, нужен ли новый снимок
+	Interval time.Duration
+	// Lock, блокировка прохода среди экземпляров сервиса, nil для одного экземпляра
+	Lock leader.Locker
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор задачи снимков
+func New(s Store, interval time.Duration) *Snapshotter {
+	return &Snapshotter{Store: s, Interval: interval, Now: time.Now}
+}
+
+// Run, проверяет сразу и затем с заданным интервалом до отмены контекста, из нескольких экземпляров проход делает один
+func (s *Snapshotter) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+
+	for {
+		if _, err := leader.Do(ctx, s.Lock, "snapshot", s.RunOnce); err != nil {
+			log.Printf("snapshot: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, делает снимок на начало текущих суток utc, если его еще нет, сразу после полуночи снимок откладывается на settle
+func (s *Snapshotter) RunOnce(ctx context.Context) error {
+	now := s.Now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if now.Sub(day) < settle {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	latest, ok, err := s.Store.LatestSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	if ok && !latest.Before(day) {
+		return nil
+	}
+
+	n, err := s.Store.SnapshotBalances(ctx, day)
+	if err != nil {
+		return err
+	}
+	log.Printf("snapshot: balances as of %s, %d wallets", day.Format("2006-01-02"), n)
+	return nil
+}
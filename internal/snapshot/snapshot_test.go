@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeStore, снимки в памяти
+type fakeStore struct {
+	latest time.Time
+	taken  []time.Time
+}
+
+func (f *fakeStore) LatestSnapshot(context.Context) (time.Time, bool, error) {
+	return f.latest, !f.latest.IsZero(), nil
+}
+
+func (f *fakeStore) SnapshotBalances(_ context.Context, asOf time.Time) (int64, error) {
+	f.taken = append(f.taken, asOf)
+	f.latest = asOf
+	return 1, nil
+}
+
+// TestRunOnce_OncePerDay, снимок на начало суток делается один раз, сразу после полуночи еще за прошлые сутки
+func TestRunOnce_OncePerDay(t *testing.T) {
+	st := &fakeStore{}
+	s := New(st, time.Hour)
+	at := func(h, m int) func() time.Time {
+		return func() time.Time { return time.Date(2025, 6, 15, h, m, 0, 0, time.UTC) }
+	}
+
+	s.Now = at(0, 2)
+	if err := s.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(st.taken) != 1 || !st.taken[0].Equal(time.Date(2025, 6, 14, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("right after midnight: want snapshot for previous day, got %v", st.taken)
+	}
+
+	for _, now := range []func() time.Time{at(0, 10), at(13, 0), at(23, 59)} {
+		s.Now = now
+		if err := s.RunOnce(context.Background()); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	}
+	if len(st.taken) != 2 || !st.taken[1].Equal(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("want exactly one snapshot for the day, got %v", st.taken)
+	}
+}
@@ -0,0 +1,85 @@
+// Package soak, наблюдение за ресурсами процесса в долгих прогонах, горутины, соединения пула базы и память, поиск монотонного роста, который выдает утечку
+package soak
+
+import (
+	"database/sql"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Sample, снимок ресурсов процесса
+type Sample struct {
+	At         time.Time
+	Goroutines int
+	// OpenConns и InUse, соединения пула базы, всего открытых и занятых
+	OpenConns int
+	InUse     int
+	// HeapBytes, живая куча после сборки мусора
+	HeapBytes uint64
+}
+
+// Take, снимок после принудительной сборки мусора, чтобы куча не зависела от момента прошлой сборки, db nil не дает данных пула
+func Take(db *sql.DB) Sample {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s := Sample{At: time.Now(), Goroutines: runtime.NumGoroutine(), HeapBytes: m.HeapAlloc}
+	if db != nil {
+		st := db.Stats()
+		s.OpenConns, s.InUse = st.OpenConnections, st.InUse
+	}
+	return s
+}
+
+// String, строка для журнала теста
+func (s Sample) String() string {
+	return fmt.Sprintf("goroutines=%d conns=%d in_use=%d heap=%.1fMiB", s.Goroutines, s.OpenConns, s.InUse, float64(s.HeapBytes)/(1<<20))
+}
+
+// Thresholds, когда рост считается утечкой, Streak, сколько снимков подряд метрика должна расти, остальные поля, насколько она должна вырасти за эти снимки, чтобы шум не давал ложных срабатываний
+type Thresholds struct {
+	Streak     int
+	Goroutines int
+	Conns      int
+	HeapBytes  uint64
+}
+
+// DefaultThresholds, пороги по умолчанию
+var DefaultThresholds = Thresholds{Streak: 4, Goroutines: 20, Conns: 2, HeapBytes: 16 << 20}
+
+// Growing, последние streak значений каждое больше предыдущего, и за них метрика выросла больше чем на slack
+func Growing(values []float64, streak int, slack float64) bool {
+	if streak < 1 || len(values) < streak+1 {
+		return false
+	}
+	tail := values[len(values)-streak-1:]
+	for i := 1; i < len(tail); i++ {
+		if tail[i] <= tail[i-1] {
+			return false
+		}
+	}
+	return tail[len(tail)-1]-tail[0] > slack
+}
+
+// Leaks, метрики ряда снимков, которые растут монотонно, пустой ответ значит что утечек не видно
+func Leaks(samples []Sample, th Thresholds) []string {
+	series := func(f func(Sample) float64) []float64 {
+		out := make([]float64, len(samples))
+		for i, s := range samples {
+			out[i] = f(s)
+		}
+		return out
+	}
+	var out []string
+	check := func(name string, values []float64, slack float64) {
+		if Growing(values, th.Streak, slack) {
+			out = append(out, fmt.Sprintf("%s grows monotonically: %v", name, values[len(values)-th.Streak-1:]))
+		}
+	}
+	check("goroutines", series(func(s Sample) float64 { return float64(s.Goroutines) }), float64(th.Goroutines))
+	check("open connections", series(func(s Sample) float64 { return float64(s.OpenConns) }), float64(th.Conns))
+	check("connections in use", series(func(s Sample) float64 { return float64(s.InUse) }), float64(th.Conns))
+	check("heap", series(func(s Sample) float64 { return float64(s.HeapBytes) }), float64(th.HeapBytes))
+	return out
+}
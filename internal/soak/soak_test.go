@@ -0,0 +1,43 @@
+package soak
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGrowing, рост засчитывается только если он непрерывный на всей серии и больше запаса
+func TestGrowing(t *testing.T) {
+	cases := []struct {
+		name   string
+		values []float64
+		want   bool
+	}{
+		{"steady leak", []float64{10, 20, 30, 40, 50}, true},
+		{"leak after plateau", []float64{10, 10, 10, 20, 30, 40, 50}, true},
+		{"plateau", []float64{50, 50, 50, 50, 50}, false},
+		{"dip inside streak", []float64{10, 20, 15, 40, 50}, false},
+		{"growth within slack", []float64{10, 11, 12, 13, 14}, false},
+		{"too short", []float64{10, 40, 70}, false},
+		{"warmup then flat", []float64{10, 40, 70, 70, 70, 70}, false},
+	}
+	for _, c := range cases {
+		if got := Growing(c.values, 4, 5); got != c.want {
+			t.Errorf("%s: Growing(%v) = %v, want %v", c.name, c.values, got, c.want)
+		}
+	}
+}
+
+// TestLeaks, в ответе только растущие метрики
+func TestLeaks(t *testing.T) {
+	var samples []Sample
+	for i := range 6 {
+		samples = append(samples, Sample{Goroutines: 30 + 25*i, OpenConns: 4, HeapBytes: 32 << 20})
+	}
+	got := Leaks(samples, DefaultThresholds)
+	if len(got) != 1 || !strings.HasPrefix(got[0], "goroutines") {
+		t.Fatalf("want goroutine leak only, got %v", got)
+	}
+	if got := Leaks(samples[:2], DefaultThresholds); len(got) != 0 {
+		t.Fatalf("too few samples to judge, got %v", got)
+	}
+}
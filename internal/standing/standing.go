@@ -0,0 +1,67 @@
+// Package standing, исполнение постоянных поручений, наступившие сроки разбираются по одному, каждое поручение в своей транзакции
+package standing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// maxPerPass, сколько поручений исполняется за один проход, остальные ждут следующего, чтобы проход не затягивался после простоя
+const maxPerPass = 1000
+
+// Store, операции над поручениями, реализуется репозиторием postgres
+type Store interface {
+	RunDueStandingOrder(ctx context.Context, now time.Time) (bool, error)
+}
+
+// Runner, периодическая задача поручений, проверяет раз в Interval, несколько экземпляров не исполняют одно поручение дважды
+type Runner struct {
+	Store Store
+	// Interval, период проверки наступивших сроков
+	Interval time.Duration
+	// Now, источник времени, подменяется в тестах
+	Now func() time.Time
+}
+
+// New, конструктор задачи поручений
+func New(s Store, interval time.Duration) *Runner {
+	return &Runner{Store: s, Interval: interval, Now: time.Now}
+}
+
+// Run, проверяет сразу и затем с заданным интервалом до отмены контекста
+func (r *Runner) Run(ctx context.Context) {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+
+	for {
+		if err := r.RunOnce(ctx); err != nil {
+			log.Printf("standing orders: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// RunOnce, исполняет поручения, срок которых наступил к началу прохода, пока они есть, но не больше maxPerPass
+func (r *Runner) RunOnce(ctx context.Context) error {
+	now := r.Now()
+	n := 0
+	for ; n < maxPerPass; n++ {
+		ran, err := r.Store.RunDueStandingOrder(ctx, now)
+		if err != nil {
+			return err
+		}
+		if !ran {
+			break
+		}
+	}
+	if n > 0 {
+		log.Printf("standing orders: %d due orders processed", n)
+	}
+	return nil
+}
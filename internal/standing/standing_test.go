@@ -0,0 +1,70 @@
+package standing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeStore, наступившие сроки в памяти
+type fakeStore struct {
+	due  []time.Time
+	ran  int
+	fail error
+}
+
+func (f *fakeStore) RunDueStandingOrder(_ context.Context, now time.Time) (bool, error) {
+	if f.fail != nil {
+		return false, f.fail
+	}
+	for i, at := range f.due {
+		if !at.After(now) {
+			f.due = append(f.due[:i], f.due[i+1:]...)
+			f.ran++
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// TestRunOnce_DueOnly, проход исполняет только наступившие к его началу сроки
+func TestRunOnce_DueOnly(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	st := &fakeStore{due: []time.Time{now.Add(-time.Hour), now, now.Add(time.Minute), now.Add(-48 * time.Hour)}}
+	r := New(st, time.Minute)
+	r.Now = func() time.Time { return now }
+
+	if err := r.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if st.ran != 3 || len(st.due) != 1 {
+		t.Fatalf("want 3 ran and 1 left, got %d ran and %d left", st.ran, len(st.due))
+	}
+}
+
+// TestRunOnce_Cap, проход ограничен maxPerPass
+func TestRunOnce_Cap(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	st := &fakeStore{}
+	for range maxPerPass + 5 {
+		st.due = append(st.due, now)
+	}
+	r := New(st, time.Minute)
+	r.Now = func() time.Time { return now }
+
+	if err := r.RunOnce(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if st.ran != maxPerPass {
+		t.Fatalf("want %d ran, got %d", maxPerPass, st.ran)
+	}
+}
+
+// TestRunOnce_Error, ошибка хранилища прерывает проход
+func TestRunOnce_Error(t *testing.T) {
+	st := &fakeStore{fail: errors.New("db down")}
+	if err := New(st, time.Minute).RunOnce(context.Background()); err == nil {
+		t.Fatal("want error")
+	}
+}
@@ -0,0 +1,52 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	gcs "cloud.google.com/go/storage"
+)
+
+// GCS, хранилище поверх google cloud storage, учетные данные берутся из application default credentials
+type GCS struct {
+	client   *gcs.Client
+	bucket   string
+	prefix   string
+	partSize int64
+}
+
+// newGCS, создает клиента gcs
+func newGCS(ctx context.Context, c Config) (*GCS, error) {
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GCS{client: client, bucket: c.Bucket, prefix: c.Prefix, partSize: c.PartSize}, nil
+}
+
+// Put, пишет поток возобновляемой загрузкой, в памяти держится только текущий чанк, при ошибке загрузка отменяется
+func (s *GCS) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w := s.client.Bucket(s.bucket).Object(objectKey(s.prefix, key)).NewWriter(ctx)
+	w.ChunkSize = int(s.partSize)
+	w.ContentType = contentType
+	if _, err := io.Copy(w, r); err != nil {
+		// отмена контекста прерывает незавершенную загрузку, объект не появится
+		cancel()
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// Get, открывает объект на чтение, отсутствие ключа маппится на ErrNotFound
+func (s *GCS) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	rd, err := s.client.Bucket(s.bucket).Object(objectKey(s.prefix, key)).NewReader(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	return rd, err
+}
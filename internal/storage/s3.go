@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3, хранилище поверх s3 или совместимого сервиса
+type S3 struct {
+	client   *s3.Client
+	uploader *manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+// newS3, собирает клиента s3 из стандартной цепочки настроек aws и параметров конфигурации
+func newS3(ctx context.Context, c Config) (*S3, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if c.S3Region != "" {
+		opts = append(opts, awsconfig.WithRegion(c.S3Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if c.S3Endpoint != "" {
+			o.BaseEndpoint = aws.String(c.S3Endpoint)
+		}
+		o.UsePathStyle = c.S3PathStyle
+	})
+	uploader := manager.NewUploader(client, func(u *manager.Uploader) {
+		u.PartSize = c.PartSize
+	})
+	return &S3{client: client, uploader: uploader, bucket: c.Bucket, prefix: c.Prefix}, nil
+}
+
+// Put, грузит поток через multipart uploader, части читаются из r по мере загрузки
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, contentType string) error {
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(objectKey(s.prefix, key)),
+		Body:        r,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+// Get, открывает объект на чтение, отсутствие ключа маппится на ErrNotFound
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(objectKey(s.prefix, key)),
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		if errors.As(err, &nsk) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return out.Body, nil
+}
@@ -0,0 +1,64 @@
+// Package storage, абстракция хранилища больших файлов (архивы, выгрузки, выписки) с реализациями s3 и gcs, запись идет потоком без буферизации файла целиком
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+)
+
+// ErrNotFound, объекта с таким ключом нет
+var ErrNotFound = errors.New("object not found")
+
+// Store, хранилище объектов по ключу
+type Store interface {
+	// Put, потоково записывает содержимое r под ключом key, большие объекты грузятся частями
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Get, открывает объект на чтение, вызывающий закрывает reader
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// Config, настройки хранилища, Backend один из s3, gcs, пустой выключает хранилище
+type Config struct {
+	Backend string
+	Bucket  string
+	// Prefix, общий префикс ключей внутри бакета
+	Prefix string
+	// PartSize, размер части при многочастной загрузке в байтах
+	PartSize int64
+
+	// S3Region, S3Endpoint, S3PathStyle, параметры s3 совместимых хранилищ, учетные данные берутся из стандартной цепочки aws
+	S3Region    string
+	S3Endpoint  string
+	S3PathStyle bool
+}
+
+// DefaultPartSize, размер части по умолчанию
+const DefaultPartSize = 16 << 20
+
+// New, создает хранилище по конфигурации, для пустого Backend возвращает nil без ошибки
+func New(ctx context.Context, c Config) (Store, error) {
+	if c.PartSize <= 0 {
+		c.PartSize = DefaultPartSize
+	}
+	switch c.Backend {
+	case "":
+		return nil, nil
+	case "s3":
+		return newS3(ctx, c)
+	case "gcs":
+		return newGCS(ctx, c)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", c.Backend)
+	}
+}
+
+// objectKey, полный ключ объекта с учетом префикса
+func objectKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return path.Join(prefix, key)
+}
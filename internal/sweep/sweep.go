@@ -0,0 +1,60 @@
+// Package sweep, фоновая задача сбора остатков кошельков на один кошелек частями, каждая часть фиксируется отдельно, поэтому прерванная задача при повторе продолжает с места остановки
+package sweep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"gotechtask/internal/repo"
+)
+
+// Kind, вид фоновой задачи сбора
+const Kind = "wallet_sweep"
+
+// размер части, сколько кошельков переводится одной транзакцией, по умолчанию и наибольший
+const (
+	DefaultChunkSize = 100
+	MaxChunkSize     = 1000
+)
+
+// Payload, полезная нагрузка задачи, номер сбора и размер части
+type Payload struct {
+	SweepID   int64 `json:"sweep_id"`
+	ChunkSize int   `json:"chunk_size,omitempty"`
+}
+
+// Store, операции сбора, реализуется репозиторием postgres
+type Store interface {
+	SweepChunk(ctx context.Context, id int64, limit int) (bool, error)
+}
+
+// Handler, обработчик задачи сбора, разбирает части, пока ждущие кошельки не кончатся, истекший срок задачи возвращается ошибкой, и очередь повторит задачу позже,
+// уже зафиксированные части при этом не повторяются, пропавший сбор задачу завершает
+func Handler(s Store) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var p Payload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			return err
+		}
+		size := p.ChunkSize
+		if size <= 0 || size > MaxChunkSize {
+			size = DefaultChunkSize
+		}
+		for {
+			done, err := s.SweepChunk(ctx, p.SweepID, size)
+			if errors.Is(err, repo.ErrSweepNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+	}
+}
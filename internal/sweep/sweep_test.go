@@ -0,0 +1,58 @@
+package sweep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gotechtask/internal/repo"
+)
+
+// fakeStore, сбор в памяти, left ждущих кошельков, fail, ошибка после failAfter частей
+type fakeStore struct {
+	left      int
+	chunks    []int
+	fail      error
+	failAfter int
+}
+
+func (f *fakeStore) SweepChunk(_ context.Context, _ int64, limit int) (bool, error) {
+	if f.fail != nil && len(f.chunks) == f.failAfter {
+		return false, f.fail
+	}
+	f.chunks = append(f.chunks, limit)
+	f.left -= min(limit, f.left)
+	return f.left == 0, nil
+}
+
+// TestHandler_Resumes, задача разбирает части до конца, после сбоя повтор продолжает с оставшихся кошельков
+func TestHandler_Resumes(t *testing.T) {
+	st := &fakeStore{left: 250, fail: errors.New("connection reset"), failAfter: 1}
+	h := Handler(st)
+	payload, _ := json.Marshal(Payload{SweepID: 7})
+
+	if err := h(context.Background(), payload); err == nil {
+		t.Fatal("want error from failed chunk")
+	}
+	if st.left != 150 {
+		t.Fatalf("first chunk must stay committed, left %d", st.left)
+	}
+
+	st.fail = nil
+	if err := h(context.Background(), payload); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if st.left != 0 || len(st.chunks) != 3 || st.chunks[0] != DefaultChunkSize {
+		t.Fatalf("unexpected chunks %v, left %d", st.chunks, st.left)
+	}
+}
+
+// TestHandler_MissingSweep, пропавший сбор завершает задачу без повторов
+func TestHandler_MissingSweep(t *testing.T) {
+	st := &fakeStore{left: 10, fail: repo.ErrSweepNotFound}
+	payload, _ := json.Marshal(Payload{SweepID: 1, ChunkSize: 5})
+	if err := Handler(st)(context.Background(), payload); err != nil {
+		t.Fatalf("want nil, got %v", err)
+	}
+}
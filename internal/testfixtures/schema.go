@@ -0,0 +1,124 @@
+package testfixtures
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"gotechtask/internal/db/migrations"
+)
+
+// SharedEnv, переменная окружения, непустое значение возвращает тесты в общую схему базы, например чтобы смотреть их данные из psql,
+// параллельно такие тесты не запускаются
+const SharedEnv = "TEST_SHARED_SCHEMA"
+
+// testSchema, схема одного теста, создается один раз на тест
+type testSchema struct {
+	once sync.Once
+	name string
+	err  error
+}
+
+// schemas, схемы тестов, по ним повторный Open находит схему теста
+var (
+	schemasMu sync.Mutex
+	schemas   = map[testing.TB]*testSchema{}
+)
+
+// Schema, имя схемы теста с примененными миграциями, создается при первом вызове в тесте и удаляется со всеми данными после него
+func Schema(t testing.TB) string {
+	t.Helper()
+	schemasMu.Lock()
+	s, ok := schemas[t]
+	if !ok {
+		s = &testSchema{}
+		schemas[t] = s
+	}
+	schemasMu.Unlock()
+
+	s.once.Do(func() {
+		s.name, s.err = createSchema(t)
+	})
+	if s.err != nil {
+		t.Fatalf("test schema: %v", s.err)
+	}
+	return s.name
+}
+
+// isolated, у теста своя схема
+func isolated(t testing.TB) bool {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	s, ok := schemas[t]
+	return ok && s.name != ""
+}
+
+// createSchema, создает схему со случайным именем, применяет в ней миграции и записывает версию в schema_migrations, как migrate,
+// удаление схемы регистрируется в t.Cleanup раньше пулов теста, поэтому выполняется после их закрытия
+func createSchema(t testing.TB) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	name := "test_" + hex.EncodeToString(b)
+
+	ctx := context.Background()
+	cfg, err := pgx.ParseConfig(DSN())
+	if err != nil {
+		return "", err
+	}
+	cfg.RuntimeParams["search_path"] = name + ", public"
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA "+name); err != nil {
+		return "", err
+	}
+	t.Cleanup(func() {
+		schemasMu.Lock()
+		delete(schemas, t)
+		schemasMu.Unlock()
+		conn, err := pgx.ConnectConfig(context.Background(), cfg)
+		if err != nil {
+			return
+		}
+		defer conn.Close(context.Background())
+		_, _ = conn.Exec(context.Background(), "DROP SCHEMA "+name+" CASCADE")
+	})
+	if _, err := conn.Exec(ctx, "COMMENT ON SCHEMA "+name+" IS "+quote(t.Name())); err != nil {
+		return "", err
+	}
+
+	ms, err := migrations.Up()
+	if err != nil {
+		return "", err
+	}
+	for _, m := range ms {
+		if _, err := conn.Exec(ctx, m.SQL); err != nil {
+			return "", fmt.Errorf("%s: %w", m.Name, err)
+		}
+	}
+	if len(ms) > 0 {
+		if _, err := conn.Exec(ctx, `
+			CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);
+			INSERT INTO schema_migrations VALUES (`+fmt.Sprint(ms[len(ms)-1].Version)+`, false)
+		`); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// quote, строковый литерал sql
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,26 @@
+package testfixtures
+
+import (
+	"testing"
+)
+
+// TestAddress, адреса теста повторяются от запуска к запуску, не повторяются внутри теста и между тестами
+func TestAddress(t *testing.T) {
+	a, b := New(t, nil), New(t, nil)
+	first := a.Address()
+	if len(first) != 64 {
+		t.Fatalf("address length %d, want 64", len(first))
+	}
+	if second := a.Address(); second == first {
+		t.Fatalf("same address twice in one test")
+	}
+	if again := b.Address(); again != first {
+		t.Fatalf("address is not deterministic: %s != %s", again, first)
+	}
+
+	var other string
+	t.Run("sub", func(t *testing.T) { other = New(t, nil).Address() })
+	if other == first {
+		t.Fatalf("subtest got the parent test address")
+	}
+}
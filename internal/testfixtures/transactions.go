@@ -0,0 +1,62 @@
+package testfixtures
+
+import (
+	"context"
+	"time"
+)
+
+// TransactionBuilder, построитель перевода между кошельками
+type TransactionBuilder struct {
+	f      *Fixtures
+	from   string
+	to     string
+	cents  int64
+	txType string
+	at     time.Time
+}
+
+// NewTransaction, построитель перевода cents с from на to, тип transfer, время текущее
+func (f *Fixtures) NewTransaction(from, to string, cents int64) *TransactionBuilder {
+	return &TransactionBuilder{f: f, from: from, to: to, cents: cents, txType: "transfer"}
+}
+
+// Type, тип операции
+func (b *TransactionBuilder) Type(txType string) *TransactionBuilder { b.txType = txType; return b }
+
+// At, время операции, для проверок истории и выборок по периоду
+func (b *TransactionBuilder) At(at time.Time) *TransactionBuilder { b.at = at; return b }
+
+// Create, пишет перевод как репозиторий, строку операции, балансы сторон и события баланса одним запросом, отдает id,
+// проверки достаточности средств и блокировки не делаются, сумма эмиссии не меняется
+func (b *TransactionBuilder) Create() int64 {
+	f := b.f
+	f.t.Helper()
+	var at any
+	if !b.at.IsZero() {
+		at = b.at
+	}
+	var id int64
+	err := f.db.QueryRowContext(context.Background(), `
+		WITH d AS (
+			UPDATE wallets SET balance_cents = balance_cents - $3, updated_at = now() WHERE address = $1
+			RETURNING balance_cents
+		), c AS (
+			UPDATE wallets SET balance_cents = balance_cents + $3, updated_at = now() WHERE address = $2
+			RETURNING balance_cents
+		), t AS (
+			INSERT INTO transactions(from_address, to_address, amount_cents, type, created_at)
+			VALUES ($1, $2, $3, $4, COALESCE($5::timestamptz, now()))
+			RETURNING id, created_at
+		), e AS (
+			INSERT INTO balance_events(address, tx_id, delta_cents, balance_cents, created_at)
+			SELECT e.address, t.id, e.delta, e.balance, t.created_at
+			FROM t, (VALUES ($1, -$3::bigint, (SELECT balance_cents FROM d)), ($2, $3::bigint, (SELECT balance_cents FROM c))) AS e(address, delta, balance)
+		)
+		SELECT id FROM t
+	`, b.from, b.to, b.cents, b.txType, at).Scan(&id)
+	if err != nil {
+		f.t.Fatalf("insert transaction: %v", err)
+	}
+	f.cleanup([]string{`DELETE FROM balance_events WHERE tx_id=$1`, `DELETE FROM transactions WHERE id=$1`}, id)
+	return id
+}
@@ -0,0 +1,88 @@
+package testfixtures
+
+import (
+	"context"
+
+	"gotechtask/internal/auth"
+)
+
+// userTeardown, удаление пользователя, ключи удаляются каскадом, кошельки пользователя удаляются раньше, их очистка зарегистрирована позже
+var userTeardown = []string{`DELETE FROM users WHERE id=$1`}
+
+// User, созданный пользователь
+type User struct {
+	ID    int64
+	Email string
+}
+
+// UserBuilder, построитель пользователя
+type UserBuilder struct {
+	f     *Fixtures
+	email string
+	name  string
+	admin bool
+}
+
+// NewUser, построитель пользователя с почтой теста
+func (f *Fixtures) NewUser() *UserBuilder {
+	return &UserBuilder{f: f, email: f.next("user")[:16] + "@fixtures.test", name: "test"}
+}
+
+// Email, своя почта вместо почты теста
+func (b *UserBuilder) Email(email string) *UserBuilder { b.email = email; return b }
+
+// Name, имя пользователя
+func (b *UserBuilder) Name(name string) *UserBuilder { b.name = name; return b }
+
+// Admin, пользователь с административным доступом, его ключу область admin:* задается через Scopes
+func (b *UserBuilder) Admin() *UserBuilder { b.admin = true; return b }
+
+// Create, создает пользователя, остатки прошлого запуска с той же почтой удаляются
+func (b *UserBuilder) Create() User {
+	f := b.f
+	f.t.Helper()
+	f.reset([]string{`DELETE FROM users WHERE email=$1`}, b.email)
+	u := User{Email: b.email}
+	if err := f.db.QueryRowContext(context.Background(), `
+		INSERT INTO users(email, name, is_admin) VALUES ($1, $2, $3) RETURNING id
+	`, b.email, b.name, b.admin).Scan(&u.ID); err != nil {
+		f.t.Fatalf("insert user: %v", err)
+	}
+	f.cleanup(userTeardown, u.ID)
+	return u
+}
+
+// APIKeyBuilder, построитель ключа доступа
+type APIKeyBuilder struct {
+	f      *Fixtures
+	userID int64
+	scopes []string
+}
+
+// NewAPIKey, построитель ключа пользователя с областями по умолчанию
+func (f *Fixtures) NewAPIKey(userID int64) *APIKeyBuilder {
+	return &APIKeyBuilder{f: f, userID: userID, scopes: auth.DefaultScopes}
+}
+
+// Scopes, области ключа вместо областей по умолчанию
+func (b *APIKeyBuilder) Scopes(scopes ...string) *APIKeyBuilder { b.scopes = scopes; return b }
+
+// Create, создает ключ и отдает токен для заголовка Authorization, токен детерминирован по имени теста
+func (b *APIKeyBuilder) Create() string {
+	f := b.f
+	f.t.Helper()
+	token := "wk_" + f.next("key")
+	hash := auth.HashToken(token)
+	f.reset([]string{`DELETE FROM api_keys WHERE key_hash=$1`}, hash)
+	f.cleanup([]string{`DELETE FROM api_keys WHERE key_hash=$1`}, hash)
+	f.exec("insert api key", `INSERT INTO api_keys(user_id, key_hash, prefix, scopes) VALUES ($1, $2, $3, $4)`,
+		b.userID, hash, token[:len("wk_")+8], b.scopes)
+	return token
+}
+
+// UserWithKey, пользователь с ключом по умолчанию, сокращение для регистрации в тестах ручек
+func (f *Fixtures) UserWithKey() (User, string) {
+	f.t.Helper()
+	u := f.NewUser().Create()
+	return u, f.NewAPIKey(u.ID).Create()
+}
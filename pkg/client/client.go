@@ -0,0 +1,185 @@
+// Package client, go клиент http api сервиса кошельков, типизированные методы, повторы с ключами идемпотентности, ошибки сервера приводятся к тем же sentinel ошибкам, что в internal/repo
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gotechtask/internal/auth"
+)
+
+// значения клиента по умолчанию
+const (
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultBackoff    = 200 * time.Millisecond
+	maxBackoff        = 10 * time.Second
+)
+
+// Client, клиент api, поля можно менять после New до первого запроса
+type Client struct {
+	// BaseURL, адрес сервиса без завершающего слэша, например http://wallet:8080
+	BaseURL string
+	// HTTP, транспорт запросов
+	HTTP *http.Client
+	// APIKey, ключ доступа для Authorization: Bearer, AdminToken, токен администратора, задается что-то одно, без обоих запросы идут анонимно
+	APIKey     string
+	AdminToken string
+	// SigningSecret, секрет подписи ключа, если задан, запросы переводов подписываются
+	SigningSecret string
+	// MaxRetries, сколько раз повторять запрос, который сервер просит повторить или который не дошел, Backoff, первая пауза, если сервер не прислал Retry-After
+	MaxRetries int
+	Backoff    time.Duration
+}
+
+// New, конструктор клиента с ключом доступа, пустой ключ дает анонимный клиент
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTP:       &http.Client{Timeout: defaultTimeout},
+		APIKey:     apiKey,
+		MaxRetries: defaultMaxRetries,
+		Backoff:    defaultBackoff,
+	}
+}
+
+// call, один вызов api
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	in     any
+	out    any
+	// idempotencyKey, ключ запроса, который меняет состояние, с ним запрос можно безопасно повторить
+	idempotencyKey string
+	// signed, подписывать тело секретом ключа
+	signed bool
+}
+
+// retryable, можно ли повторить запрос, чтение всегда, изменение только с ключом идемпотентности
+func (c call) retryable() bool {
+	return c.method == http.MethodGet || c.method == http.MethodHead || c.idempotencyKey != ""
+}
+
+// do, выполняет вызов, повторяет сетевые ошибки и ответы с Retry-After или 503 не больше MaxRetries раз, ошибочный ответ возвращается как *Error
+func (c *Client) do(ctx context.Context, cl call) (http.Header, error) {
+	var body []byte
+	if cl.in != nil {
+		b, err := json.Marshal(cl.in)
+		if err != nil {
+			return nil, err
+		}
+		body = b
+	}
+
+	for attempt := 0; ; attempt++ {
+		h, err := c.send(ctx, cl, body)
+		if err == nil {
+			return h, nil
+		}
+		if attempt >= c.MaxRetries || !cl.retryable() || ctx.Err() != nil {
+			return h, err
+		}
+		wait := c.backoff(attempt)
+		if e, ok := err.(*Error); ok {
+			if !e.Temporary() {
+				return h, err
+			}
+			if e.RetryAfter > 0 {
+				wait = e.RetryAfter
+			}
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return h, err
+		case <-t.C:
+		}
+	}
+}
+
+// send, одна попытка вызова, подпись считается заново на каждую попытку, nonce одноразовый
+func (c *Client) send(ctx context.Context, cl call, body []byte) (http.Header, error) {
+	u := c.BaseURL + cl.path
+	if len(cl.query) > 0 {
+		u += "?" + cl.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, cl.method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case c.AdminToken != "":
+		req.Header.Set("X-Admin-Token", c.AdminToken)
+	case c.APIKey != "":
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	if cl.idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", cl.idempotencyKey)
+	}
+	if cl.signed && c.SigningSecret != "" {
+		ts := time.Now().Unix()
+		nonce := randomToken()
+		req.Header.Set(auth.HeaderSignatureTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(auth.HeaderSignatureNonce, nonce)
+		req.Header.Set(auth.HeaderSignature, auth.Sign(c.SigningSecret, ts, nonce, body))
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return resp.Header, newError(resp)
+	}
+	if cl.out == nil || cl.method == http.MethodHead {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(cl.out); err != nil {
+		return resp.Header, err
+	}
+	return resp.Header, nil
+}
+
+// backoff, пауза перед повтором, удваивается с каждой попыткой до maxBackoff
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.Backoff
+	if d <= 0 {
+		d = defaultBackoff
+	}
+	for i := 0; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
+
+// NewIdempotencyKey, случайный ключ идемпотентности, нужен, если один и тот же перевод повторяется вызывающим после перезапуска
+func NewIdempotencyKey() string {
+	return randomToken()
+}
+
+// randomToken, 32 случайных hex символа, годится и как nonce подписи
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gotechtask/internal/auth"
+)
+
+// TestSend_RetriesWithSameKey, ответ с Retry-After повторяется с тем же ключом идемпотентности и новой подписью
+func TestSend_RetriesWithSameKey(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		keys   []string
+		nonces []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		nonces = append(nonces, r.Header.Get(auth.HeaderSignatureNonce))
+		n := len(keys)
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer wk_test" {
+			t.Errorf("missing api key")
+		}
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"transfer contention, retry later","retry_after_ms":5}`))
+			return
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "wk_test")
+	c.SigningSecret = "wks_secret"
+	res, err := c.Send(context.Background(), SendRequest{From: "a", To: "b", AmountCents: 129})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if !res.Replayed || res.Pending {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("want two attempts with the same key, got %q", keys)
+	}
+	if nonces[0] == "" || nonces[0] == nonces[1] {
+		t.Fatalf("want a fresh signature nonce per attempt, got %q", nonces)
+	}
+}
+
+// TestErrors_MapToRepoSentinels, тексты ошибок сервера приводятся к sentinel ошибкам, неповторяемые ошибки не повторяются
+func TestErrors_MapToRepoSentinels(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/api/send":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"insufficient funds"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"wallet not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "")
+	_, err := c.Send(context.Background(), SendRequest{From: "a", To: "b", AmountCents: 1})
+	if !errors.Is(err, ErrInsufficientFunds) || calls != 1 {
+		t.Fatalf("send: want ErrInsufficientFunds after one call, got %v after %d", err, calls)
+	}
+	var e *Error
+	if !errors.As(err, &e) || e.StatusCode != http.StatusConflict {
+		t.Fatalf("want *Error with 409, got %#v", err)
+	}
+
+	if _, err := c.Balance(context.Background(), "x"); !errors.Is(err, ErrWalletNotFound) {
+		t.Fatalf("balance: want ErrWalletNotFound, got %v", err)
+	}
+	if ok, err := c.WalletExists(context.Background(), "x"); ok || err != nil {
+		t.Fatalf("exists: want false without error, got %v %v", ok, err)
+	}
+}
+
+// TestRetry_GivesUp, повторы ограничены MaxRetries, отмена контекста прерывает ожидание
+func TestRetry_GivesUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":"server busy","retry_after_ms":1}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "")
+	c.MaxRetries = 2
+	if _, err := c.Transactions(context.Background(), TransactionQuery{}); err == nil || calls != 3 {
+		t.Fatalf("want error after 3 calls, got %v after %d", err, calls)
+	}
+
+	busy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer busy.Close()
+	c = New(busy.URL, "")
+	c.Backoff = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Balance(ctx, "x"); err == nil || time.Since(start) > time.Second {
+		t.Fatalf("want prompt error on cancelled context, got %v after %v", err, time.Since(start))
+	}
+}
+
+// TestParseCents, суммы сервера в центы
+func TestParseCents(t *testing.T) {
+	for in, want := range map[string]int64{"0.00": 0, "12.34": 1234, "-5.25": -525, "7": 700, "0.5": 50} {
+		got, err := parseCents(in)
+		if err != nil || got != want {
+			t.Errorf("parseCents(%q) = %d, %v, want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"1.234", "abc", "1.2x"} {
+		if _, err := parseCents(in); err == nil {
+			t.Errorf("parseCents(%q): want error", in)
+		}
+	}
+}
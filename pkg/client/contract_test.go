@@ -0,0 +1,182 @@
+package client_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"gotechtask/internal/api"
+	"gotechtask/internal/repo"
+	"gotechtask/internal/testfixtures"
+	"gotechtask/pkg/client"
+)
+
+// контрактные тесты, настоящий сервер поверх тестовой базы и клиент против него, ломаются при расхождении форм запросов и ответов
+
+// contractAdminToken, токен администратора тестового сервера
+const contractAdminToken = "contract-admin-token"
+
+// harness, тестовый сервер, база и построители данных
+type harness struct {
+	db  *sql.DB
+	fx  *testfixtures.Fixtures
+	srv *httptest.Server
+}
+
+// newHarness, открывает базу как тесты api и поднимает сервер с настоящим роутером
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	db := testfixtures.Open(t)
+
+	r := chi.NewRouter()
+	a := &api.API{Repo: repo.NewPostgres(db), AdminToken: contractAdminToken, Keys: api.NewKeyCache(time.Minute)}
+	a.Routes(r)
+	h := &harness{db: db, fx: testfixtures.New(t, db), srv: httptest.NewServer(r)}
+	t.Cleanup(h.srv.Close)
+	return h
+}
+
+// TestContract_Balances, баланс, пакет балансов и проверка существования
+func TestContract_Balances(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness(t)
+	a := h.fx.Wallet(1234)
+	missing := hex.EncodeToString(make([]byte, 32))
+	c := client.New(h.srv.URL, "")
+	ctx := context.Background()
+
+	bal, err := c.Balance(ctx, a)
+	if err != nil {
+		t.Fatalf("balance: %v", err)
+	}
+	if bal.Address != a || bal.BalanceCents != 1234 || bal.CreatedAt.IsZero() || bal.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected balance %+v", bal)
+	}
+	if _, err := c.Balance(ctx, missing); !errors.Is(err, client.ErrWalletNotFound) {
+		t.Fatalf("missing balance: want ErrWalletNotFound, got %v", err)
+	}
+
+	items, err := c.Balances(ctx, []string{a, missing})
+	if err != nil {
+		t.Fatalf("balances: %v", err)
+	}
+	if len(items) != 2 || items[0].BalanceCents != 1234 || items[0].Err != nil || !errors.Is(items[1].Err, client.ErrWalletNotFound) {
+		t.Fatalf("unexpected bulk balances %+v", items)
+	}
+
+	for addr, want := range map[string]bool{a: true, missing: false} {
+		ok, err := c.WalletExists(ctx, addr)
+		if err != nil || ok != want {
+			t.Fatalf("exists(%s): want %v, got %v %v", addr[:8], want, ok, err)
+		}
+	}
+}
+
+// TestContract_Send, суммы в центах доходят до сервера без потерь, ошибки приводятся к sentinel, повтор с ключом не переводит дважды
+func TestContract_Send(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness(t)
+	a := h.fx.Wallet(1000)
+	b := h.fx.Wallet(0)
+	c := client.New(h.srv.URL, "")
+	ctx := context.Background()
+
+	var sent int64
+	for _, cents := range []int64{29, 57, 113, 1} {
+		if _, err := c.Send(ctx, client.SendRequest{From: a, To: b, AmountCents: cents}); err != nil {
+			t.Fatalf("send %d: %v", cents, err)
+		}
+		sent += cents
+	}
+	if bal, err := c.Balance(ctx, b); err != nil || bal.BalanceCents != sent {
+		t.Fatalf("want %d received, got %+v %v", sent, bal, err)
+	}
+
+	key := client.NewIdempotencyKey()
+	defer h.db.Exec(`DELETE FROM idempotency_keys WHERE key = $1`, key)
+	req := client.SendRequest{From: a, To: b, AmountCents: 100, IdempotencyKey: key}
+	first, err := c.Send(ctx, req)
+	if err != nil || first.Replayed {
+		t.Fatalf("first send: %+v %v", first, err)
+	}
+	again, err := c.Send(ctx, req)
+	if err != nil || !again.Replayed {
+		t.Fatalf("repeated send: want replayed, got %+v %v", again, err)
+	}
+	if bal, _ := c.Balance(ctx, b); bal.BalanceCents != sent+100 {
+		t.Fatalf("repeated send moved money twice, balance %d", bal.BalanceCents)
+	}
+	req.AmountCents = 200
+	if _, err := c.Send(ctx, req); !errors.Is(err, client.ErrIdempotencyKeyReused) {
+		t.Fatalf("reused key: want ErrIdempotencyKeyReused, got %v", err)
+	}
+
+	cases := []struct {
+		req  client.SendRequest
+		want error
+	}{
+		{client.SendRequest{From: b, To: a, AmountCents: 1 << 40}, client.ErrInsufficientFunds},
+		{client.SendRequest{From: a, To: a, AmountCents: 1}, client.ErrSameAddress},
+		{client.SendRequest{From: a, To: hex.EncodeToString(make([]byte, 32)), AmountCents: 1}, client.ErrWalletNotFound},
+	}
+	for _, tc := range cases {
+		if _, err := c.Send(ctx, tc.req); !errors.Is(err, tc.want) {
+			t.Fatalf("send %+v: want %v, got %v", tc.req, tc.want, err)
+		}
+	}
+}
+
+// TestContract_Transactions, поля ленты, фильтр по кошельку, страницы курсором и одна транзакция по id
+func TestContract_Transactions(t *testing.T) {
+	t.Parallel()
+
+	h := newHarness(t)
+	a := h.fx.Wallet(1000)
+	b := h.fx.Wallet(0)
+	c := client.New(h.srv.URL, "")
+	c.AdminToken = contractAdminToken
+	ctx := context.Background()
+
+	for _, cents := range []int64{150, 250} {
+		if _, err := c.Send(ctx, client.SendRequest{From: a, To: b, AmountCents: cents}); err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	page, err := c.Transactions(ctx, client.TransactionQuery{Address: b, Count: 1})
+	if err != nil {
+		t.Fatalf("transactions: %v", err)
+	}
+	if len(page.Items) != 1 || page.NextCursor == "" {
+		t.Fatalf("want one item and a cursor, got %+v", page)
+	}
+	latest := page.Items[0]
+	if latest.From != a || latest.To != b || latest.AmountCents != 250 || latest.Type != "transfer" ||
+		latest.Channel != "http" || latest.InitiatedBy != "admin" || latest.CreatedAt.IsZero() {
+		t.Fatalf("unexpected transaction %+v", latest)
+	}
+
+	next, err := c.Transactions(ctx, client.TransactionQuery{Address: b, Count: 1, Cursor: page.NextCursor})
+	if err != nil || len(next.Items) != 1 || next.Items[0].AmountCents != 150 {
+		t.Fatalf("next page: %+v %v", next, err)
+	}
+
+	got, err := c.Transaction(ctx, latest.ID)
+	if err != nil || got != latest {
+		t.Fatalf("transaction by id: want %+v, got %+v %v", latest, got, err)
+	}
+	if _, err := c.Transaction(ctx, -1); !errors.Is(err, client.ErrTransactionNotFound) {
+		t.Fatalf("missing transaction: want ErrTransactionNotFound, got %v", err)
+	}
+	if _, err := c.Transactions(ctx, client.TransactionQuery{Cursor: "garbage"}); !errors.Is(err, client.ErrInvalidCursor) {
+		t.Fatalf("bad cursor: want ErrInvalidCursor, got %v", err)
+	}
+}
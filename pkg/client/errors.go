@@ -0,0 +1,111 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gotechtask/internal/repo"
+)
+
+// ошибки сервера, те же значения, что в internal/repo, сравниваются через errors.Is
+var (
+	ErrWalletNotFound         = repo.ErrWalletNotFound
+	ErrInsufficientFunds      = repo.ErrInsufficientFunds
+	ErrBalanceOverflow        = repo.ErrBalanceOverflow
+	ErrSameAddress            = repo.ErrSameAddress
+	ErrAddressDenied          = repo.ErrAddressDenied
+	ErrContention             = repo.ErrContention
+	ErrWalletClosed           = repo.ErrWalletClosed
+	ErrPayeeNotFound          = repo.ErrPayeeNotFound
+	ErrTransactionNotFound    = repo.ErrTransactionNotFound
+	ErrInvalidCursor          = repo.ErrInvalidCursor
+	ErrInvalidSort            = repo.ErrInvalidSort
+	ErrPaymentRequestNotFound = repo.ErrPaymentRequestNotFound
+	ErrPaymentRequestResolved = repo.ErrPaymentRequestResolved
+	ErrPaymentRequestExpired  = repo.ErrPaymentRequestExpired
+	ErrIdempotencyKeyReused   = repo.ErrIdempotencyKeyReused
+)
+
+// ошибки доступа, у них нет пары в репозитории
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+// sentinels, текст ошибки сервера в sentinel ошибку, тексты из writeTransferError и соседних функций api
+var sentinels = map[string]error{
+	"wallet not found":                 ErrWalletNotFound,
+	"insufficient funds":               ErrInsufficientFunds,
+	"balance limit exceeded":           ErrBalanceOverflow,
+	"from must differ from to":         ErrSameAddress,
+	"address denylisted":               ErrAddressDenied,
+	"transfer contention, retry later": ErrContention,
+	"wallet closed":                    ErrWalletClosed,
+	"payee not found":                  ErrPayeeNotFound,
+	"transaction not found":            ErrTransactionNotFound,
+	"invalid cursor":                   ErrInvalidCursor,
+	"invalid sort":                     ErrInvalidSort,
+	"payment request not found":        ErrPaymentRequestNotFound,
+	"payment request already resolved": ErrPaymentRequestResolved,
+	"payment request expired":          ErrPaymentRequestExpired,
+	"unauthorized":                     ErrUnauthorized,
+	"forbidden":                        ErrForbidden,
+
+	"idempotency key reused with a different request": ErrIdempotencyKeyReused,
+}
+
+// Error, ошибочный ответ сервера, Unwrap дает sentinel ошибку, если текст сервера известен
+type Error struct {
+	StatusCode int
+	Message    string
+	// RetryAfter, подсказка сервера, через сколько повторить, ноль если ее нет
+	RetryAfter time.Duration
+
+	err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("wallet api: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Temporary, сервер просит повторить запрос позже
+func (e *Error) Temporary() bool {
+	return e.RetryAfter > 0 || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// newError, разбирает ошибочный ответ, тело {"error": "...", "retry_after_ms": n}, подсказка из тела точнее заголовка Retry-After
+func newError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Error        string `json:"error"`
+		RetryAfterMS int64  `json:"retry_after_ms"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if json.Unmarshal(raw, &body) == nil && body.Error != "" {
+		e.Message = body.Error
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	switch {
+	case body.RetryAfterMS > 0:
+		e.RetryAfter = time.Duration(body.RetryAfterMS) * time.Millisecond
+	case resp.Header.Get("Retry-After") != "":
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		}
+	}
+	e.err = sentinels[e.Message]
+	if e.err == nil && resp.StatusCode == http.StatusUnauthorized {
+		e.err = ErrUnauthorized
+	}
+	return e
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transaction, операция ленты, для эмиссии и изъятия одна из сторон пустая
+type Transaction struct {
+	ID          int64
+	From        string
+	To          string
+	AmountCents int64
+	CreatedAt   time.Time
+	InitiatedBy string
+	Channel     string
+	Type        string
+}
+
+// TransactionQuery, фильтры списка, нулевые поля не ограничивают, Date, бизнес-день YYYY-MM-DD вместо Since и Until, Count по умолчанию 10, максимум 100, Cursor, NextCursor предыдущей страницы
+type TransactionQuery struct {
+	Address     string
+	Types       []string
+	InitiatedBy string
+	Channel     string
+	Since       time.Time
+	Until       time.Time
+	Date        string
+	Sort        string
+	Asc         bool
+	Count       int
+	Cursor      string
+}
+
+// TransactionPage, страница списка, NextCursor пустой на последней странице
+type TransactionPage struct {
+	Items      []Transaction
+	NextCursor string
+}
+
+// txDTO, операция в ответе сервера
+type txDTO struct {
+	ID          int64  `json:"id"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"`
+	CreatedAt   string `json:"created_at"`
+	InitiatedBy string `json:"initiated_by"`
+	Channel     string `json:"channel"`
+	Type        string `json:"type"`
+}
+
+// Transactions, страница видимых участнику транзакций
+func (c *Client) Transactions(ctx context.Context, q TransactionQuery) (TransactionPage, error) {
+	var out []txDTO
+	h, err := c.do(ctx, call{method: http.MethodGet, path: "/api/transactions", query: q.values(), out: &out})
+	if err != nil {
+		return TransactionPage{}, err
+	}
+	page := TransactionPage{Items: make([]Transaction, 0, len(out)), NextCursor: h.Get("X-Next-Cursor")}
+	for _, dto := range out {
+		t, err := dto.transaction()
+		if err != nil {
+			return TransactionPage{}, err
+		}
+		page.Items = append(page.Items, t)
+	}
+	return page, nil
+}
+
+// Transaction, одна транзакция по id, недоступная участнику дает ErrTransactionNotFound
+func (c *Client) Transaction(ctx context.Context, id int64) (Transaction, error) {
+	var dto txDTO
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: "/api/transactions/" + strconv.FormatInt(id, 10), out: &dto}); err != nil {
+		return Transaction{}, err
+	}
+	return dto.transaction()
+}
+
+// values, параметры запроса списка
+func (q TransactionQuery) values() url.Values {
+	v := url.Values{}
+	set := func(k, s string) {
+		if s != "" {
+			v.Set(k, s)
+		}
+	}
+	set("address", q.Address)
+	set("type", strings.Join(q.Types, ","))
+	set("initiated_by", q.InitiatedBy)
+	set("channel", q.Channel)
+	set("date", q.Date)
+	set("sort", q.Sort)
+	set("cursor", q.Cursor)
+	if !q.Since.IsZero() {
+		v.Set("from", q.Since.UTC().Format(time.RFC3339))
+	}
+	if !q.Until.IsZero() {
+		v.Set("to", q.Until.UTC().Format(time.RFC3339))
+	}
+	if q.Asc {
+		v.Set("order", "asc")
+	}
+	if q.Count > 0 {
+		v.Set("count", strconv.Itoa(q.Count))
+	}
+	return v
+}
+
+// transaction, маппинг ответа сервера
+func (d txDTO) transaction() (Transaction, error) {
+	cents, err := parseCents(d.Amount)
+	if err != nil {
+		return Transaction{}, err
+	}
+	return Transaction{
+		ID:          d.ID,
+		From:        d.From,
+		To:          d.To,
+		AmountCents: cents,
+		CreatedAt:   parseTime(d.CreatedAt),
+		InitiatedBy: d.InitiatedBy,
+		Channel:     d.Channel,
+		Type:        d.Type,
+	}, nil
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// SendRequest, перевод, получатель задается адресом To или псевдонимом ToAlias из адресной книги отправителя, IdempotencyKey, ключ перевода, пустой генерируется на вызов
+type SendRequest struct {
+	From           string
+	To             string
+	ToAlias        string
+	AmountCents    int64
+	IdempotencyKey string
+}
+
+// SendResult, итог перевода, Pending и PendingID, если крупный перевод ждет подтверждения вторым фактором, Replayed, ответ сохранен сервером при первой попытке с тем же ключом
+type SendResult struct {
+	Pending   bool
+	PendingID int64
+	Replayed  bool
+}
+
+// Send, выполняет перевод, повторы безопасны, сервер исполняет перевод с одним ключом идемпотентности один раз
+func (c *Client) Send(ctx context.Context, req SendRequest) (SendResult, error) {
+	key := req.IdempotencyKey
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+	in := struct {
+		From    string  `json:"from"`
+		To      string  `json:"to,omitempty"`
+		ToAlias string  `json:"to_alias,omitempty"`
+		Amount  float64 `json:"amount"`
+	}{req.From, req.To, req.ToAlias, float64(req.AmountCents) / 100}
+	var out struct {
+		Status    string `json:"status"`
+		PendingID int64  `json:"pending_id"`
+	}
+	h, err := c.do(ctx, call{method: http.MethodPost, path: "/api/send", in: in, out: &out, idempotencyKey: key, signed: true})
+	if err != nil {
+		return SendResult{}, err
+	}
+	return SendResult{
+		Pending:   out.PendingID != 0,
+		PendingID: out.PendingID,
+		Replayed:  h.Get("Idempotent-Replayed") == "true",
+	}, nil
+}
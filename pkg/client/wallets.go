@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Balance, баланс кошелька в центах и отметки активности, LastTxAt нулевой, если переводов не было
+type Balance struct {
+	Address      string
+	BalanceCents int64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	LastTxAt     time.Time
+}
+
+// BalanceItem, баланс одного адреса пакетного запроса, Err ErrWalletNotFound или ErrForbidden для ненайденного или чужого личного кошелька
+type BalanceItem struct {
+	Address      string
+	BalanceCents int64
+	UpdatedAt    time.Time
+	LastTxAt     time.Time
+	Err          error
+}
+
+// balanceDTO, ответ сервера на баланс, суммы строкой, время в rfc3339
+type balanceDTO struct {
+	Address   string `json:"address"`
+	Balance   string `json:"balance"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	LastTxAt  string `json:"last_tx_at"`
+	At        string `json:"at"`
+	Error     string `json:"error"`
+}
+
+// Balance, текущий баланс кошелька
+func (c *Client) Balance(ctx context.Context, address string) (Balance, error) {
+	var dto balanceDTO
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: walletPath(address, "balance"), out: &dto}); err != nil {
+		return Balance{}, err
+	}
+	cents, err := parseCents(dto.Balance)
+	if err != nil {
+		return Balance{}, err
+	}
+	return Balance{
+		Address:      dto.Address,
+		BalanceCents: cents,
+		CreatedAt:    parseTime(dto.CreatedAt),
+		UpdatedAt:    parseTime(dto.UpdatedAt),
+		LastTxAt:     parseTime(dto.LastTxAt),
+	}, nil
+}
+
+// BalanceAt, баланс кошелька на прошлый момент at
+func (c *Client) BalanceAt(ctx context.Context, address string, at time.Time) (int64, error) {
+	var dto balanceDTO
+	q := url.Values{"at": {at.UTC().Format(time.RFC3339)}}
+	if _, err := c.do(ctx, call{method: http.MethodGet, path: walletPath(address, "balance"), query: q, out: &dto}); err != nil {
+		return 0, err
+	}
+	return parseCents(dto.Balance)
+}
+
+// Balances, балансы до 500 кошельков одним запросом, ответ в порядке запроса без повторов адресов
+func (c *Client) Balances(ctx context.Context, addresses []string) ([]BalanceItem, error) {
+	var resp struct {
+		Balances []balanceDTO `json:"balances"`
+	}
+	in := map[string][]string{"addresses": addresses}
+	if _, err := c.do(ctx, call{method: http.MethodPost, path: "/api/balances", in: in, out: &resp}); err != nil {
+		return nil, err
+	}
+	out := make([]BalanceItem, 0, len(resp.Balances))
+	for _, dto := range resp.Balances {
+		item := BalanceItem{Address: dto.Address}
+		if dto.Error != "" {
+			if item.Err = sentinels[dto.Error]; item.Err == nil {
+				item.Err = errors.New(dto.Error)
+			}
+			out = append(out, item)
+			continue
+		}
+		cents, err := parseCents(dto.Balance)
+		if err != nil {
+			return nil, err
+		}
+		item.BalanceCents = cents
+		item.UpdatedAt = parseTime(dto.UpdatedAt)
+		item.LastTxAt = parseTime(dto.LastTxAt)
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// WalletExists, есть ли кошелек, баланс при этом не раскрывается
+func (c *Client) WalletExists(ctx context.Context, address string) (bool, error) {
+	_, err := c.do(ctx, call{method: http.MethodHead, path: walletPath(address, "exists")})
+	if errors.Is(err, ErrWalletNotFound) {
+		return false, nil
+	}
+	var e *Error
+	if errors.As(err, &e) && e.StatusCode == http.StatusNotFound {
+		// у HEAD нет тела, текст ошибки не приходит
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// walletPath, путь ручки кошелька
+func walletPath(address, suffix string) string {
+	return "/api/wallet/" + url.PathEscape(address) + "/" + suffix
+}
+
+// parseCents, сумма строкой вида "-12.34" в центы
+func parseCents(s string) (int64, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 2 {
+		return 0, errors.New("client: invalid amount " + s)
+	}
+	for len(frac) < 2 {
+		frac += "0"
+	}
+	var cents int64
+	for _, ch := range whole + frac {
+		if ch < '0' || ch > '9' {
+			return 0, errors.New("client: invalid amount " + s)
+		}
+		cents = cents*10 + int64(ch-'0')
+	}
+	if neg {
+		cents = -cents
+	}
+	return cents, nil
+}
+
+// parseTime, время в rfc3339, пустое или битое дает нулевое
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}